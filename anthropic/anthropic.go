@@ -6,9 +6,11 @@ package anthropic
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	anthropicSDK "github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
@@ -28,8 +30,9 @@ type messageState struct {
 	depth    int
 	config   llm.LanguageModelConfig
 	tools    []llm.Tool
-	resolver func(name string, argsGetter llm.ToolArgumentGetter, context *llm.Context) (string, error)
+	resolver func(ctx context.Context, name string, argsGetter llm.ToolArgumentGetter, llmContext *llm.Context) (string, error)
 	context  *llm.Context
+	stop     []string
 }
 
 type Anthropic struct {
@@ -40,10 +43,15 @@ type Anthropic struct {
 }
 
 func New(llmService llm.ServiceConfig, httpClient *http.Client) *Anthropic {
-	client := anthropicSDK.NewClient(
+	options := []option.RequestOption{
 		option.WithAPIKey(llmService.APIKey),
 		option.WithHTTPClient(httpClient),
-	)
+	}
+	// Regional endpoints replace the default one
+	if llmService.APIURL != "" {
+		options = append(options, option.WithBaseURL(llmService.APIURL))
+	}
+	client := anthropicSDK.NewClient(options...)
 
 	return &Anthropic{
 		client:           client,
@@ -85,6 +93,9 @@ func conversationToMessages(posts []llm.Post) (string, []anthropicSDK.MessagePar
 	for _, post := range posts {
 		switch post.Role {
 		case llm.PostRoleSystem:
+			if systemMessage != "" {
+				systemMessage += "\n\n"
+			}
 			systemMessage += post.Message
 			continue
 		case llm.PostRoleBot:
@@ -192,7 +203,11 @@ func (a *Anthropic) streamChatWithTools(state messageState) {
 		System: []anthropicSDK.TextBlockParam{{
 			Text: state.system,
 		}},
-		Tools: convertTools(state.tools),
+		Tools:         convertTools(state.tools),
+		StopSequences: state.stop,
+	}
+	if state.config.Temperature != nil {
+		params.Temperature = anthropicSDK.Float(*state.config.Temperature)
 	}
 	stream := a.client.Messages.NewStreaming(context.Background(), params)
 
@@ -232,11 +247,18 @@ func (a *Anthropic) streamChatWithTools(state messageState) {
 	pendingToolCalls := make([]llm.ToolCall, 0, len(message.Content))
 	for _, block := range message.Content {
 		if block.Type == "tool_use" {
+			providerRequest, _ := json.Marshal(map[string]any{
+				"type":  block.Type,
+				"id":    block.ID,
+				"name":  block.Name,
+				"input": block.Input,
+			})
 			pendingToolCalls = append(pendingToolCalls, llm.ToolCall{
-				ID:          block.ID,
-				Name:        block.Name,
-				Description: "",
-				Arguments:   block.Input,
+				ID:              block.ID,
+				Name:            block.Name,
+				Description:     "",
+				Arguments:       block.Input,
+				ProviderRequest: providerRequest,
 			})
 		}
 	}
@@ -270,6 +292,7 @@ func (a *Anthropic) ChatCompletion(request llm.CompletionRequest, opts ...llm.La
 		depth:    0,
 		config:   cfg,
 		context:  request.Context,
+		stop:     nativeStopSequences(request.StopSequences),
 	}
 
 	if request.Context.Tools != nil {
@@ -282,7 +305,24 @@ func (a *Anthropic) ChatCompletion(request llm.CompletionRequest, opts ...llm.La
 		a.streamChatWithTools(initialState)
 	}()
 
-	return &llm.TextStreamResult{Stream: eventStream}, nil
+	result := &llm.TextStreamResult{Stream: eventStream}
+	if len(initialState.stop) < len(request.StopSequences) {
+		return result.StopAt(request.StopSequences), nil
+	}
+
+	return result, nil
+}
+
+// nativeStopSequences returns the stop sequences the API accepts. Anthropic rejects
+// whitespace only stop sequences so those are applied to the stream instead.
+func nativeStopSequences(stopSequences []string) []string {
+	var result []string
+	for _, stop := range stopSequences {
+		if strings.TrimSpace(stop) != "" {
+			result = append(result, stop)
+		}
+	}
+	return result
 }
 
 func (a *Anthropic) ChatCompletionNoStream(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
@@ -317,5 +357,8 @@ func (a *Anthropic) InputTokenLimit() int {
 	if a.inputTokenLimit > 0 {
 		return a.inputTokenLimit
 	}
+	if limit := llm.LookupCapabilities(llm.ServiceTypeAnthropic, a.defaultModel).MaxContextTokens; limit > 0 {
+		return limit
+	}
 	return 100000
 }
@@ -7,21 +7,31 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/glossary"
 	"github.com/mattermost/mattermost-plugin-ai/i18n"
 	"github.com/mattermost/mattermost-plugin-ai/indexer"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/llmcontext"
+	"github.com/mattermost/mattermost-plugin-ai/mcp"
 	"github.com/mattermost/mattermost-plugin-ai/meetings"
+	"github.com/mattermost/mattermost-plugin-ai/memory"
 	"github.com/mattermost/mattermost-plugin-ai/metrics"
+	"github.com/mattermost/mattermost-plugin-ai/migration"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/provenance"
+	"github.com/mattermost/mattermost-plugin-ai/schedules"
 	"github.com/mattermost/mattermost-plugin-ai/search"
+	"github.com/mattermost/mattermost-plugin-ai/snippets"
 	"github.com/mattermost/mattermost-plugin-ai/streaming"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
@@ -36,6 +46,8 @@ const (
 
 type Config interface {
 	GetDefaultBotName() string
+	EmbeddingSearchConfig() embeddings.EmbeddingSearchConfig
+	GetEnableUserMemory() bool
 }
 
 // API represents the HTTP API functionality for the plugin
@@ -45,6 +57,16 @@ type API struct {
 	meetingsService      *meetings.Service
 	indexerService       *indexer.Indexer
 	searchService        *search.Search
+	glossaryStore        *glossary.Store
+	snippetStore         *snippets.Store
+	importer             *migration.Importer
+	jobQueue             *jobs.Queue
+	provenanceStore      *provenance.Store
+	memoryStore          *memory.Store
+	scheduleStore        *schedules.Store
+	mcpClientManager     *mcp.ClientManager
+	mcpAuditStore        *mcp.AuditStore
+	mcpSecretStore       *mcp.SecretStore
 	pluginAPI            *pluginapi.Client
 	metricsService       metrics.Metrics
 	metricsHandler       http.Handler
@@ -64,6 +86,16 @@ func New(
 	meetingsService *meetings.Service,
 	indexerService *indexer.Indexer,
 	searchService *search.Search,
+	glossaryStore *glossary.Store,
+	snippetStore *snippets.Store,
+	importer *migration.Importer,
+	jobQueue *jobs.Queue,
+	provenanceStore *provenance.Store,
+	memoryStore *memory.Store,
+	scheduleStore *schedules.Store,
+	mcpClientManager *mcp.ClientManager,
+	mcpAuditStore *mcp.AuditStore,
+	mcpSecretStore *mcp.SecretStore,
 	pluginAPI *pluginapi.Client,
 	metricsService metrics.Metrics,
 	llmContextBuilder *llmcontext.Builder,
@@ -80,6 +112,16 @@ func New(
 		meetingsService:      meetingsService,
 		indexerService:       indexerService,
 		searchService:        searchService,
+		glossaryStore:        glossaryStore,
+		snippetStore:         snippetStore,
+		importer:             importer,
+		jobQueue:             jobQueue,
+		provenanceStore:      provenanceStore,
+		memoryStore:          memoryStore,
+		scheduleStore:        scheduleStore,
+		mcpClientManager:     mcpClientManager,
+		mcpAuditStore:        mcpAuditStore,
+		mcpSecretStore:       mcpSecretStore,
 		pluginAPI:            pluginAPI,
 		metricsService:       metricsService,
 		metricsHandler:       metrics.NewMetricsHandler(metricsService),
@@ -102,11 +144,31 @@ func (a *API) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Reques
 	interPluginRoute := router.Group("/inter-plugin/v1")
 	interPluginRoute.Use(a.interPluginAuthorizationRequired)
 	interPluginRoute.POST("/simple_completion", a.handleInterPluginSimpleCompletion)
+	interPluginRoute.GET("/provenance", a.handleGetProvenance)
 
 	router.Use(a.MattermostAuthorizationRequired)
 
 	router.GET("/ai_threads", a.handleGetAIThreads)
 	router.GET("/ai_bots", a.handleGetAIBots)
+	router.GET("/summary_templates", a.handleGetSummaryTemplates)
+	router.GET("/personas", a.handleGetPersonas)
+	router.GET("/memories", a.handleGetMemories)
+	router.DELETE("/memories", a.handleDeleteAllMemories)
+	router.DELETE("/memories/:memoryid", a.handleDeleteMemory)
+	router.GET("/schedules", a.handleGetSchedules)
+	router.POST("/schedules", a.handleCreateSchedule)
+	router.PUT("/schedules/:scheduleid", a.handleUpdateSchedule)
+	router.DELETE("/schedules/:scheduleid", a.handleDeleteSchedule)
+	router.POST("/snippets/dialog", a.handleSnippetDialog)
+	router.GET("/mcp/prompts", a.handleGetMCPPrompts)
+	router.POST("/mcp/prompts/dialog", a.handleMCPPromptDialog)
+
+	teamRouter := router.Group("/team/:teamid")
+	teamRouter.Use(a.teamAuthorizationRequired)
+	teamRouter.GET("/snippets", a.handleGetSnippets)
+	teamRouter.POST("/snippets", a.handleCreateSnippet)
+	teamRouter.PUT("/snippets/:snippetid", a.handleUpdateSnippet)
+	teamRouter.DELETE("/snippets/:snippetid", a.handleDeleteSnippet)
 
 	botRequiredRouter := router.Group("")
 	botRequiredRouter.Use(a.aiBotRequired)
@@ -116,21 +178,81 @@ func (a *API) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Reques
 	postRouter.POST("/react", a.handleReact)
 	postRouter.POST("/analyze", a.handleThreadAnalysis)
 	postRouter.POST("/transcribe/file/:fileid", a.handleTranscribeFile)
+	postRouter.POST("/transcribe/files", a.handleTranscribeFiles)
+	postRouter.GET("/transcribe/file/:fileid/estimate", a.handleTranscribeFileEstimate)
 	postRouter.POST("/summarize_transcription", a.handleSummarizeTranscription)
 	postRouter.POST("/stop", a.handleStop)
+	postRouter.GET("/stream", a.handleGetStreamState)
 	postRouter.POST("/regenerate", a.handleRegenerate)
+	postRouter.POST("/resummarize", a.handleResummarize)
+	postRouter.POST("/refine", a.handleRefineSummary)
 	postRouter.POST("/tool_call", a.handleToolCall)
+	postRouter.POST("/tool_call/:toolcallid/explain", a.handleExplainToolCall)
 	postRouter.POST("/postback_summary", a.handlePostbackSummary)
+	postRouter.POST("/handoff", a.handleHandoff)
+	postRouter.POST("/ask", a.handleAskAboutPost)
+	postRouter.POST("/fork", a.handleFork)
+	postRouter.POST("/resend", a.handleResend)
+	postRouter.POST("/rewrite", a.handleRewritePost)
+	postRouter.GET("/export", a.handleExportConversation)
+	postRouter.GET("/usage", a.handleGetThreadUsage)
+	postRouter.GET("/persona", a.handleGetThreadPersona)
+	postRouter.PUT("/persona", a.handleSetThreadPersona)
+	postRouter.GET("/bot", a.handleGetThreadBot)
+	postRouter.PUT("/bot", a.handleSetThreadBot)
+	postRouter.GET("/pinned_context", a.handleGetPinnedContext)
+	postRouter.POST("/pin_context", a.handlePinContextPost)
+	postRouter.DELETE("/pin_context", a.handleUnpinContextPost)
+	postRouter.POST("/action_items/:itemid/done", a.handleSetActionItemDone)
+	postRouter.POST("/action_items/:itemid/send", a.handleSendActionItem)
+	postRouter.POST("/action_items/playbook_run", a.handleCreatePlaybookRun)
+	postRouter.GET("/transcript", a.handleGetTranscript)
+	postRouter.PUT("/transcript", a.handleEditTranscript)
+	postRouter.GET("/transcript/export", a.handleExportTranscript)
+
+	toolApprovalRouter := router.Group("/tool_approval/:postid")
+	toolApprovalRouter.Use(a.toolApprovalPostRequired)
+	toolApprovalRouter.GET("", a.handleGetToolApproval)
+	toolApprovalRouter.POST("", a.handleToolApproval)
 
 	channelRouter := botRequiredRouter.Group("/channel/:channelid")
 	channelRouter.Use(a.channelAuthorizationRequired)
 	channelRouter.POST("/interval", a.handleInterval)
+	channelRouter.POST("/interval/estimate", a.handleIntervalEstimate)
+	channelRouter.POST("/ask", a.handlePrivateAsk)
+	channelRouter.POST("/compose", a.handleComposeSuggestions)
+	channelRouter.POST("/rewrite", a.handleRewriteDraft)
 
 	adminRouter := router.Group("/admin")
 	adminRouter.Use(a.mattermostAdminAuthorizationRequired)
 	adminRouter.POST("/reindex", a.handleReindexPosts)
+	adminRouter.GET("/reindex/estimate", a.handleReindexEstimate)
 	adminRouter.GET("/reindex/status", a.handleGetJobStatus)
+	adminRouter.GET("/reindex/model", a.handleGetIndexedModel)
 	adminRouter.POST("/reindex/cancel", a.handleCancelJob)
+	adminRouter.POST("/reindex/resume", a.handleResumeReindex)
+	adminRouter.POST("/ffmpeg/diagnostics", a.handleFFmpegDiagnostics)
+	adminRouter.GET("/glossary", a.handleGetGlossary)
+	adminRouter.POST("/glossary", a.handleCreateGlossaryTerm)
+	adminRouter.PUT("/glossary/:termid", a.handleUpdateGlossaryTerm)
+	adminRouter.DELETE("/glossary/:termid", a.handleDeleteGlossaryTerm)
+	adminRouter.POST("/import", a.handleImport)
+	adminRouter.GET("/config/export", a.handleExportConfig)
+	adminRouter.POST("/config/import", a.handleImportConfig)
+	adminRouter.POST("/playground/run", a.handlePlaygroundRun)
+	adminRouter.POST("/playground/publish", a.handlePlaygroundPublish)
+	adminRouter.GET("/provenance", a.handleGetProvenance)
+	adminRouter.GET("/jobs", a.handleGetBackgroundJobs)
+	adminRouter.POST("/jobs/:jobid/cancel", a.handleCancelBackgroundJob)
+	adminRouter.GET("/thread_categories", a.handleGetThreadCategoryUsage)
+	adminRouter.GET("/mcp/status", a.handleGetMCPServerHealth)
+	adminRouter.POST("/mcp/servers/:serverid/test", a.handleTestMCPServer)
+	adminRouter.GET("/mcp/tool_calls", a.handleGetMCPToolCalls)
+	adminRouter.GET("/mcp/tool_calls/export", a.handleExportMCPToolCalls)
+	adminRouter.GET("/mcp/secrets", a.handleListMCPSecrets)
+	adminRouter.PUT("/mcp/secrets/:name", a.handleSetMCPSecret)
+	adminRouter.DELETE("/mcp/secrets/:name", a.handleDeleteMCPSecret)
+	adminRouter.POST("/mcp/secrets/rotate", a.handleRotateMCPSecretsKey)
 
 	searchRouter := botRequiredRouter.Group("/search")
 	// Only returns search results
@@ -211,7 +333,13 @@ func (a *API) enforceEmptyBody(c *gin.Context) error {
 func (a *API) handleGetAIThreads(c *gin.Context) {
 	userID := c.GetHeader("Mattermost-User-Id")
 
-	threads, err := a.conversationsService.GetAIThreads(userID)
+	category := c.Query("category")
+	if category != "" && !slices.Contains(conversations.ThreadCategories, category) {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("unknown thread category: %s", category))
+		return
+	}
+
+	threads, err := a.conversationsService.GetAIThreads(userID, category)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get posts for bot DM: %w", err))
 		return
@@ -230,11 +358,13 @@ type AIBotInfo struct {
 	ChannelIDs         []string               `json:"channelIDs"`
 	UserAccessLevel    llm.UserAccessLevel    `json:"userAccessLevel"`
 	UserIDs            []string               `json:"userIDs"`
+	HandoffEnabled     bool                   `json:"handoffEnabled"`
 }
 
 type AIBotsResponse struct {
 	Bots          []AIBotInfo `json:"bots"`
 	SearchEnabled bool        `json:"searchEnabled"`
+	MemoryEnabled bool        `json:"memoryEnabled"`
 }
 
 // getAIBotsForUser returns all AI bots available to a user
@@ -270,6 +400,7 @@ func (a *API) getAIBotsForUser(userID string) ([]AIBotInfo, error) {
 			ChannelIDs:         bot.GetConfig().ChannelIDs,
 			UserAccessLevel:    bot.GetConfig().UserAccessLevel,
 			UserIDs:            bot.GetConfig().UserIDs,
+			HandoffEnabled:     bot.GetConfig().Handoff.IsActive(),
 		})
 		if bot.GetMMBot().Username == defaultBotName {
 			bots[0], bots[i] = bots[i], bots[0]
@@ -279,6 +410,11 @@ func (a *API) getAIBotsForUser(userID string) ([]AIBotInfo, error) {
 	return bots, nil
 }
 
+// handleGetSummaryTemplates lists the templates meeting summaries can be requested in.
+func (a *API) handleGetSummaryTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, a.meetingsService.SummaryTemplates())
+}
+
 func (a *API) handleGetAIBots(c *gin.Context) {
 	userID := c.GetHeader("Mattermost-User-Id")
 	bots, err := a.getAIBotsForUser(userID)
@@ -293,5 +429,6 @@ func (a *API) handleGetAIBots(c *gin.Context) {
 	c.JSON(http.StatusOK, AIBotsResponse{
 		Bots:          bots,
 		SearchEnabled: searchEnabled,
+		MemoryEnabled: a.memoryStore != nil && a.config.GetEnableUserMemory(),
 	})
 }
@@ -4,15 +4,31 @@
 package api
 
 import (
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"errors"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/ffmpeg"
+	"github.com/mattermost/mattermost-plugin-ai/search"
 	"github.com/mattermost/mattermost/server/public/model"
 )
 
+// indexedModelResponse tells whether the search index was built with the configured embedding model
+type indexedModelResponse struct {
+	Indexed    *embeddings.EmbeddingModel `json:"indexed"` // Nil when it isn't known
+	Configured embeddings.EmbeddingModel  `json:"configured"`
+
+	// Stale is set when the embeddings of the index can't be compared with the ones of the
+	// configured model, until the index is rebuilt
+	Stale bool `json:"stale"`
+}
+
 // handleReindexPosts starts a background job to reindex all posts
 func (a *API) handleReindexPosts(c *gin.Context) {
 	if err := a.enforceEmptyBody(c); err != nil {
@@ -24,6 +40,12 @@ func (a *API) handleReindexPosts(c *gin.Context) {
 		return
 	}
 
+	// The search creates embeddings with the model it was set up with, until the plugin restarts
+	if search.EmbeddingModel(a.config.EmbeddingSearchConfig()) != a.indexerService.EmbeddingModel() {
+		c.AbortWithError(http.StatusPreconditionFailed, fmt.Errorf("restart the plugin to apply the embedding settings before reindexing"))
+		return
+	}
+
 	jobStatus, err := a.indexerService.StartReindexJob()
 	if err != nil {
 		switch err.Error() {
@@ -39,6 +61,61 @@ func (a *API) handleReindexPosts(c *gin.Context) {
 	c.JSON(http.StatusOK, jobStatus)
 }
 
+// handleResumeReindex resumes a failed or canceled reindex job where it stopped
+func (a *API) handleResumeReindex(c *gin.Context) {
+	if err := a.enforceEmptyBody(c); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if a.indexerService == nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("search functionality is not configured"))
+		return
+	}
+
+	if search.EmbeddingModel(a.config.EmbeddingSearchConfig()) != a.indexerService.EmbeddingModel() {
+		c.AbortWithError(http.StatusPreconditionFailed, fmt.Errorf("restart the plugin to apply the embedding settings before reindexing"))
+		return
+	}
+
+	jobStatus, err := a.indexerService.ResumeReindexJob()
+	if err != nil {
+		switch err.Error() {
+		case "job already running":
+			c.JSON(http.StatusConflict, jobStatus)
+			return
+		case "nothing to resume":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "not_resumable",
+			})
+			return
+		case "embedding model changed":
+			c.AbortWithError(http.StatusPreconditionFailed, fmt.Errorf("the index was built with another embedding model, reindex all posts instead"))
+			return
+		default:
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, jobStatus)
+}
+
+// handleReindexEstimate estimates the cost of reindexing all posts
+func (a *API) handleReindexEstimate(c *gin.Context) {
+	if a.indexerService == nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("search functionality is not configured"))
+		return
+	}
+
+	estimate, err := a.indexerService.EstimateReindex(search.EmbeddingModel(a.config.EmbeddingSearchConfig()).Model)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, estimate)
+}
+
 // handleGetJobStatus gets the status of the reindex job
 func (a *API) handleGetJobStatus(c *gin.Context) {
 	if a.indexerService == nil {
@@ -63,6 +140,27 @@ func (a *API) handleGetJobStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, jobStatus)
 }
 
+// handleGetIndexedModel tells whether the index has to be rebuilt for the configured embedding model
+func (a *API) handleGetIndexedModel(c *gin.Context) {
+	if a.indexerService == nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("search functionality is not configured"))
+		return
+	}
+
+	indexedModel, err := a.indexerService.IndexedModel()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	configuredModel := search.EmbeddingModel(a.config.EmbeddingSearchConfig())
+	c.JSON(http.StatusOK, indexedModelResponse{
+		Indexed:    indexedModel,
+		Configured: configuredModel,
+		Stale:      indexedModel != nil && *indexedModel != configuredModel,
+	})
+}
+
 // handleCancelJob cancels a running reindex job
 func (a *API) handleCancelJob(c *gin.Context) {
 	if err := a.enforceEmptyBody(c); err != nil {
@@ -107,3 +205,43 @@ func (a *API) mattermostAdminAuthorizationRequired(c *gin.Context) {
 		return
 	}
 }
+
+// handleFFmpegDiagnostics checks the ffmpeg binary used to convert recordings. The body optionally
+// has ffmpeg settings to check before they are saved, the saved settings are checked without it.
+func (a *API) handleFFmpegDiagnostics(c *gin.Context) {
+	var ffmpegConfig ffmpeg.Config
+	if err := c.ShouldBindJSON(&ffmpegConfig); errors.Is(err, io.EOF) {
+		c.JSON(http.StatusOK, a.meetingsService.FFmpegDiagnostics())
+		return
+	} else if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ffmpegConfig.Diagnose())
+}
+
+// defaultCategoryUsageDays is the period thread category usage is counted over when none is given
+const defaultCategoryUsageDays = 30
+
+// handleGetThreadCategoryUsage counts the AI threads started by category, over the last days given, 30 by default.
+func (a *API) handleGetThreadCategoryUsage(c *gin.Context) {
+	days := defaultCategoryUsageDays
+	if daysParam := c.Query("days"); daysParam != "" {
+		var err error
+		days, err = strconv.Atoi(daysParam)
+		if err != nil || days <= 0 {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid number of days: %s", daysParam))
+			return
+		}
+	}
+
+	since := time.Now().AddDate(0, 0, -days).UnixMilli()
+	usage, err := a.conversationsService.GetCategoryUsage(since)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
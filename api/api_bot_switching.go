@@ -0,0 +1,68 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+func (a *API) handleGetThreadBot(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	botUsername, err := a.conversationsService.ThreadBot(userID, post, channel)
+	switch {
+	case errors.Is(err, conversations.ErrBotSwitchNotDM):
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	case err != nil:
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get thread bot: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"botUsername": botUsername})
+}
+
+func (a *API) handleSetThreadBot(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	var data struct {
+		BotUsername string `json:"botUsername"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	err := a.conversationsService.SetThreadBot(userID, post, channel, data.BotUsername)
+	switch {
+	case errors.Is(err, conversations.ErrBotSwitchNotDM), errors.Is(err, conversations.ErrUnknownBot):
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	case errors.Is(err, bots.ErrUsageRestriction):
+		c.AbortWithError(http.StatusForbidden, err)
+		return
+	case err != nil:
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	botUsername, err := a.conversationsService.ThreadBot(userID, post, channel)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get thread bot: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"botUsername": botUsername})
+}
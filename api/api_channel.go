@@ -6,7 +6,9 @@ package api
 import (
 	stdcontext "context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 
 	"errors"
 
@@ -14,12 +16,19 @@ import (
 	"github.com/gin-gonic/gin/render"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/channels"
+	"github.com/mattermost/mattermost-plugin-ai/compose"
+	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost-plugin-ai/prompts"
 	"github.com/mattermost/mattermost-plugin-ai/streaming"
 	"github.com/mattermost/mattermost/server/public/model"
 )
 
+// composeContextPosts is the number of recent posts of the channel suggestions for a new post fit
+const composeContextPosts = 10
+
 const (
 	TitleThreadSummary     = "Thread Summary"
 	TitleSummarizeUnreads  = "Summarize Unreads"
@@ -51,82 +60,121 @@ func (a *API) channelAuthorizationRequired(c *gin.Context) {
 	}
 }
 
-func (a *API) handleInterval(c *gin.Context) {
-	userID := c.GetHeader("Mattermost-User-Id")
-	channel := c.MustGet(ContextChannelKey).(*model.Channel)
-	bot := c.MustGet(ContextBotKey).(*bots.Bot)
-
-	// Check license
-	if !a.licenseChecker.IsBasicsLicensed() {
-		c.AbortWithError(http.StatusForbidden, errors.New("feature not licensed"))
-		return
-	}
+// intervalRequest is a parsed and validated request to summarize or analyze a period of a channel.
+type intervalRequest struct {
+	user         *model.User
+	timeRange    channels.TimeRange
+	promptPreset string
+	promptTitle  string
+}
 
+// parseIntervalRequest reads the interval request body, resolving named ranges and the preset prompt.
+// The request is aborted if it isn't valid.
+func (a *API) parseIntervalRequest(c *gin.Context, userID string, channel *model.Channel) (*intervalRequest, bool) {
 	// Parse request data
 	data := struct {
 		StartTime    int64  `json:"start_time"`
 		EndTime      int64  `json:"end_time"` // 0 means "until present"
+		Range        string `json:"range"`    // Named range used instead of start_time and end_time
+		PostID       string `json:"post_id"`  // Required for the since_post range
 		PresetPrompt string `json:"preset_prompt"`
 		Prompt       string `json:"prompt"`
 	}{}
 	err := json.NewDecoder(c.Request.Body).Decode(&data)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
-		return
+		return nil, false
 	}
 	defer c.Request.Body.Close()
 
-	// Validate time range
-	if data.EndTime != 0 && data.StartTime >= data.EndTime {
-		c.AbortWithError(http.StatusBadRequest, errors.New("start_time must be before end_time"))
-		return
-	}
-
-	// Cap the date range at 14 days
-	maxDuration := int64(14 * 24 * 60 * 60) // 14 days in seconds
-	if data.EndTime != 0 && (data.EndTime-data.StartTime) > maxDuration {
-		c.AbortWithError(http.StatusBadRequest, errors.New("date range cannot exceed 14 days"))
-		return
-	}
-
 	// Get user
 	user, err := a.pluginAPI.User.Get(userID)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
-		return
+		return nil, false
 	}
 
-	// Build LLM context
-	context := a.contextBuilder.BuildLLMContextUserRequest(
-		bot,
-		user,
-		channel,
-		a.contextBuilder.WithLLMContextDefaultTools(bot, mmapi.IsDMWith(bot.GetMMBot().UserId, channel)),
-	)
+	now := time.Now()
+	timeRange := channels.TimeRange{Start: data.StartTime, End: data.EndTime}
+	if data.Range != "" {
+		if data.StartTime != 0 || data.EndTime != 0 {
+			c.AbortWithError(http.StatusBadRequest, errors.New("range can't be combined with start_time or end_time"))
+			return nil, false
+		}
+
+		inputs, inputsErr := a.intervalRangeInputs(userID, user, channel, data.Range, data.PostID, now)
+		if inputsErr != nil {
+			c.AbortWithError(http.StatusBadRequest, inputsErr)
+			return nil, false
+		}
+
+		timeRange, err = channels.ResolveNamedRange(data.Range, inputs)
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return nil, false
+		}
+	}
+
+	// Validate time range
+	if err = timeRange.Validate(now); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	request := &intervalRequest{
+		user:      user,
+		timeRange: timeRange,
+	}
 
 	// Map preset prompt to prompt type and title
-	promptPreset := ""
-	promptTitle := ""
 	switch data.PresetPrompt {
 	case "summarize_unreads":
-		promptPreset = prompts.PromptSummarizeChannelSinceSystem
-		promptTitle = TitleSummarizeUnreads
+		request.promptPreset = prompts.PromptSummarizeChannelSinceSystem
+		request.promptTitle = TitleSummarizeUnreads
 	case "summarize_range":
-		promptPreset = prompts.PromptSummarizeChannelRangeSystem
-		promptTitle = TitleSummarizeChannel
+		request.promptPreset = prompts.PromptSummarizeChannelRangeSystem
+		request.promptTitle = TitleSummarizeChannel
 	case "action_items":
-		promptPreset = prompts.PromptFindActionItemsSystem
-		promptTitle = TitleFindActionItems
+		request.promptPreset = prompts.PromptFindActionItemsSystem
+		request.promptTitle = TitleFindActionItems
 	case "open_questions":
-		promptPreset = prompts.PromptFindOpenQuestionsSystem
-		promptTitle = TitleFindOpenQuestions
+		request.promptPreset = prompts.PromptFindOpenQuestionsSystem
+		request.promptTitle = TitleFindOpenQuestions
 	default:
 		c.AbortWithError(http.StatusBadRequest, errors.New("invalid preset prompt"))
+		return nil, false
+	}
+
+	return request, true
+}
+
+func (a *API) handleInterval(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	// Check license
+	if !a.licenseChecker.IsBasicsLicensed() {
+		c.AbortWithError(http.StatusForbidden, errors.New("feature not licensed"))
 		return
 	}
 
+	request, ok := a.parseIntervalRequest(c, userID, channel)
+	if !ok {
+		return
+	}
+	user := request.user
+
+	// Build LLM context
+	context := a.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		user,
+		channel,
+		a.contextBuilder.WithLLMContextDefaultTools(bot, mmapi.IsDMWith(bot.GetMMBot().UserId, channel)),
+	)
+
 	// Call channels interval processing
-	resultStream, err := channels.New(bot.LLM(), a.prompts, a.mmClient).Interval(context, channel.Id, data.StartTime, data.EndTime, promptPreset)
+	resultStream, err := channels.New(bot.LLM(), a.prompts, a.mmClient).Interval(context, channel.Id, request.timeRange.Start, request.timeRange.End, request.promptPreset)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -143,7 +191,7 @@ func (a *API) handleInterval(c *gin.Context) {
 	}
 
 	// Save title asynchronously
-	a.conversationsService.SaveTitleAsync(post.Id, promptTitle)
+	a.conversationsService.SaveTitleAsync(post.Id, request.promptTitle)
 
 	// Return result
 	result := map[string]string{
@@ -153,3 +201,168 @@ func (a *API) handleInterval(c *gin.Context) {
 
 	c.Render(http.StatusOK, render.JSON{Data: result})
 }
+
+// handleIntervalEstimate returns the expected token usage and cost of an interval request without running it.
+func (a *API) handleIntervalEstimate(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	if !a.licenseChecker.IsBasicsLicensed() {
+		c.AbortWithError(http.StatusForbidden, errors.New("feature not licensed"))
+		return
+	}
+
+	request, ok := a.parseIntervalRequest(c, userID, channel)
+	if !ok {
+		return
+	}
+
+	context := a.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		request.user,
+		channel,
+		a.contextBuilder.WithLLMContextDefaultTools(bot, mmapi.IsDMWith(bot.GetMMBot().UserId, channel)),
+	)
+
+	inputTokens, err := channels.New(bot.LLM(), a.prompts, a.mmClient).EstimateInterval(context, channel.Id, request.timeRange.Start, request.timeRange.End, request.promptPreset)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to estimate interval: %w", err))
+		return
+	}
+
+	price, priceKnown := bot.GetConfig().Service.Price()
+	estimate := llm.NewCostEstimate(price, priceKnown, inputTokens, llm.EstimatedSummaryOutputTokens)
+
+	c.Render(http.StatusOK, render.JSON{Data: estimate})
+}
+
+// intervalRangeInputs gathers the data needed to resolve the given named range for the user.
+func (a *API) intervalRangeInputs(userID string, user *model.User, channel *model.Channel, rangeName string, postID string, now time.Time) (channels.RangeInputs, error) {
+	inputs := channels.RangeInputs{
+		Now:      now,
+		Location: time.UTC,
+	}
+
+	if loc, err := time.LoadLocation(user.GetPreferredTimezone()); err == nil && loc != nil {
+		inputs.Location = loc
+	}
+
+	switch rangeName {
+	case channels.RangeSinceLastVisit:
+		member, err := a.pluginAPI.Channel.GetMember(channel.Id, userID)
+		if err != nil {
+			return inputs, fmt.Errorf("unable to get channel membership: %w", err)
+		}
+		inputs.LastViewedAt = member.LastViewedAt
+	case channels.RangeSincePost:
+		if postID == "" {
+			return inputs, errors.New("post_id is required for the since_post range")
+		}
+		post, err := a.pluginAPI.Post.GetPost(postID)
+		if err != nil {
+			return inputs, fmt.Errorf("unable to get post: %w", err)
+		}
+		if post.ChannelId != channel.Id {
+			return inputs, errors.New("post is not in the channel")
+		}
+		inputs.SincePostCreateAt = post.CreateAt
+	}
+
+	return inputs, nil
+}
+
+// handlePrivateAsk answers a question in an ephemeral post only the user sees.
+func (a *API) handlePrivateAsk(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	var data struct {
+		Question string `json:"question" binding:"required"`
+		RootID   string `json:"root_id"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := a.pluginAPI.User.Get(userID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
+		return
+	}
+
+	answerPost, err := a.conversationsService.PrivateAsk(bot, user, channel, data.RootID, data.Question)
+	switch {
+	case errors.Is(err, conversations.ErrInvalidQuestion):
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	case err != nil:
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to answer private question: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{
+		"postid":    answerPost.Id,
+		"channelid": answerPost.ChannelId,
+	})
+}
+
+// handleComposeSuggestions suggests continuations of the draft of a post in the channel, or in the thread
+// of root_id when it is a reply.
+func (a *API) handleComposeSuggestions(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	var data struct {
+		Draft  string `json:"draft" binding:"required"`
+		RootID string `json:"root_id"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := a.pluginAPI.User.Get(userID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
+		return
+	}
+
+	var threadData *mmapi.ThreadData
+	if data.RootID != "" {
+		threadData, err = mmapi.GetThreadData(a.mmClient, data.RootID)
+		if err == nil && (len(threadData.Posts) == 0 || threadData.Posts[0].ChannelId != channel.Id) {
+			c.AbortWithError(http.StatusBadRequest, errors.New("the thread isn't in the channel"))
+			return
+		}
+	} else {
+		var posts *model.PostList
+		posts, err = a.pluginAPI.Post.GetPostsForChannel(channel.Id, 0, composeContextPosts)
+		if err == nil {
+			threadData, err = mmapi.GetMetadataForPosts(a.mmClient, posts)
+		}
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get conversation: %w", err))
+		return
+	}
+
+	context := a.contextBuilder.BuildLLMContextUserRequest(bot, user, channel)
+	suggestions, err := compose.New(bot.LLM(), a.prompts).Suggest(data.Draft, format.ThreadData(threadData), context)
+	switch {
+	case errors.Is(err, compose.ErrInvalidDraft):
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	case errors.Is(err, compose.ErrTimeout):
+		c.AbortWithError(http.StatusGatewayTimeout, err)
+		return
+	case err != nil:
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string][]string{"suggestions": suggestions})
+}
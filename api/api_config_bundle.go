@@ -0,0 +1,108 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/configbundle"
+)
+
+// maxConfigBundleSize limits the size of imported configuration bundles
+const maxConfigBundleSize = 10 * 1024 * 1024
+
+// ConfigImportResult describes an import of a configuration bundle.
+type ConfigImportResult struct {
+	DryRun  bool     `json:"dryRun"`
+	Changed []string `json:"changed"`
+
+	// Unresolved are the secret references without an environment variable or current value, the import fails with any
+	Unresolved []string `json:"unresolved"`
+}
+
+// handleExportConfig downloads the configuration as a YAML bundle with references in place of its secrets.
+func (a *API) handleExportConfig(c *gin.Context) {
+	data, err := configbundle.Export(config.SavedConfig(&a.pluginAPI.Configuration), time.Now())
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="mattermost-ai-config.yaml"`)
+	c.Data(http.StatusOK, "application/yaml", data)
+}
+
+// handleImportConfig replaces the configuration with a bundle exported by handleExportConfig.
+// Secret references are resolved from environment variables or the current configuration.
+// With dry_run=true it only reports which settings would change.
+func (a *API) handleImportConfig(c *gin.Context) {
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxConfigBundleSize))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	bundle, err := configbundle.Parse(data)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	current := config.SavedConfig(&a.pluginAPI.Configuration)
+	imported, unresolved, err := configbundle.Resolve(bundle, current, os.LookupEnv)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if err := checkConfig(imported); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	result := ConfigImportResult{
+		DryRun:     c.Query("dry_run") == "true",
+		Changed:    configbundle.Changes(current, imported),
+		Unresolved: unresolved,
+	}
+	if result.DryRun {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	if len(unresolved) > 0 {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("%w: set the environment variables %v", configbundle.ErrUnresolvedSecrets, unresolved))
+		return
+	}
+
+	err = config.UpdateSavedConfig(&a.pluginAPI.Configuration, func(cfg map[string]any) error {
+		clear(cfg)
+		maps.Copy(cfg, imported)
+		return nil
+	})
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// checkConfig checks the imported settings have the types the plugin expects.
+func checkConfig(cfg map[string]any) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("%w: %w", configbundle.ErrInvalidBundle, err)
+	}
+	if err := json.Unmarshal(data, &config.Config{}); err != nil {
+		return fmt.Errorf("%w: %w", configbundle.ErrInvalidBundle, err)
+	}
+	return nil
+}
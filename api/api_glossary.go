@@ -0,0 +1,79 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/glossary"
+)
+
+func (a *API) handleGetGlossary(c *gin.Context) {
+	terms, err := a.glossaryStore.List()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, terms)
+}
+
+func (a *API) handleCreateGlossaryTerm(c *gin.Context) {
+	var term glossary.Term
+	if err := c.ShouldBindJSON(&term); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	term.ID = ""
+
+	created, err := a.glossaryStore.Create(term)
+	if err != nil {
+		a.abortWithGlossaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (a *API) handleUpdateGlossaryTerm(c *gin.Context) {
+	var term glossary.Term
+	if err := c.ShouldBindJSON(&term); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	term.ID = c.Param("termid")
+
+	updated, err := a.glossaryStore.Update(term)
+	if err != nil {
+		a.abortWithGlossaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (a *API) handleDeleteGlossaryTerm(c *gin.Context) {
+	if err := a.glossaryStore.Delete(c.Param("termid")); err != nil {
+		a.abortWithGlossaryError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (a *API) abortWithGlossaryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, glossary.ErrTermNotFound):
+		c.AbortWithError(http.StatusNotFound, err)
+	case errors.Is(err, glossary.ErrDuplicateTerm):
+		c.AbortWithError(http.StatusConflict, err)
+	case errors.Is(err, glossary.ErrInvalidTerm):
+		c.AbortWithError(http.StatusBadRequest, err)
+	default:
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("glossary update failed: %w", err))
+	}
+}
@@ -59,8 +59,8 @@ func (a *API) handleInterPluginSimpleCompletion(c *gin.Context) {
 		a.contextBuilder.WithLLMContextParameters(req.Parameters),
 	)
 
-	// Add tools if not disabled
-	if !bot.GetConfig().DisableTools {
+	// Add tools if enabled and supported
+	if bot.GetConfig().Capabilities().Tools {
 		context.Tools = a.contextBuilder.GetToolsStoreForUser(bot, true, userID)
 	}
 
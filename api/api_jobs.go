@@ -0,0 +1,51 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
+)
+
+// maxListedJobs limits the background jobs listed at once
+const maxListedJobs = 100
+
+// handleGetBackgroundJobs lists the latest jobs of the queue, optionally only those in the state given.
+func (a *API) handleGetBackgroundJobs(c *gin.Context) {
+	state := c.Query("state")
+	switch state {
+	case "", jobs.StatePending, jobs.StateRunning, jobs.StateSucceeded, jobs.StateFailed, jobs.StateCancelled:
+	default:
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid job state %q", state))
+		return
+	}
+
+	list, err := a.jobQueue.List(state, maxListedJobs)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (a *API) handleCancelBackgroundJob(c *gin.Context) {
+	if err := a.jobQueue.Cancel(c.Param("jobid")); err != nil {
+		switch {
+		case errors.Is(err, jobs.ErrJobNotFound):
+			c.AbortWithError(http.StatusNotFound, err)
+		case errors.Is(err, jobs.ErrJobFinished):
+			c.AbortWithError(http.StatusConflict, err)
+		default:
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to cancel job: %w", err))
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,311 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/mcp"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// mcpPromptDialogState identifies the prompt a dialog fills in, and the direct message with the bot it
+// is posted in, which isn't the current channel when the dialog is opened from the RHS.
+type mcpPromptDialogState struct {
+	ServerID  string `json:"server_id"`
+	Name      string `json:"name"`
+	ChannelID string `json:"channel_id"`
+}
+
+func (a *API) handleGetMCPPrompts(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	prompts, err := a.mcpClientManager.GetPromptsForUser(userID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get MCP prompts: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, prompts)
+}
+
+// handleMCPPromptDialog receives the interactive dialog filling in the arguments of an MCP prompt.
+// The prompt the server returns is posted by the user in their direct message with the bot, which answers it.
+func (a *API) handleMCPPromptDialog(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	var request model.SubmitDialogRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if request.Cancelled {
+		c.Status(http.StatusOK)
+		return
+	}
+	if request.UserId != userID {
+		c.AbortWithError(http.StatusForbidden, errors.New("dialog was submitted by another user"))
+		return
+	}
+
+	var state mcpPromptDialogState
+	if err := json.Unmarshal([]byte(request.State), &state); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid dialog state: %w", err))
+		return
+	}
+	channelID := state.ChannelID
+	if channelID == "" {
+		channelID = request.ChannelId
+	}
+
+	channel, err := a.pluginAPI.Channel.Get(channelID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get channel: %w", err))
+		return
+	}
+	if a.bots.GetBotForDMChannel(channel) == nil || !a.pluginAPI.User.HasPermissionToChannel(userID, channel.Id, model.PermissionCreatePost) {
+		c.AbortWithError(http.StatusForbidden, errors.New("MCP prompts can only be used in a direct message with a bot"))
+		return
+	}
+
+	prompt, err := a.mcpClientManager.GetPromptForUser(userID, state.ServerID, state.Name)
+	if err != nil {
+		a.abortWithMCPPromptError(c, err)
+		return
+	}
+
+	arguments := make(map[string]string, len(request.Submission))
+	for name, value := range request.Submission {
+		if text, ok := value.(string); ok {
+			arguments[name] = text
+		}
+	}
+	if missing := prompt.MissingArguments(arguments); len(missing) > 0 {
+		response := model.SubmitDialogResponse{Errors: map[string]string{}}
+		for _, name := range missing {
+			response.Errors[name] = "A value is required."
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	message, err := a.mcpClientManager.RenderPromptForUser(userID, state.ServerID, state.Name, arguments)
+	if err != nil {
+		a.abortWithMCPPromptError(c, err)
+		return
+	}
+
+	post := &model.Post{
+		UserId:    userID,
+		ChannelId: channel.Id,
+		Message:   message,
+	}
+	post.AddProp(conversations.ActivateAIProp, true)
+	if err := a.pluginAPI.Post.CreatePost(post); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to post MCP prompt: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SubmitDialogResponse{})
+}
+
+func (a *API) abortWithMCPPromptError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, mcp.ErrPromptNotFound):
+		c.AbortWithError(http.StatusNotFound, err)
+	case errors.Is(err, mcp.ErrMissingPromptArguments):
+		c.AbortWithError(http.StatusBadRequest, err)
+	default:
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("MCP prompt failed: %w", err))
+	}
+}
+
+func (a *API) handleGetMCPServerHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, a.mcpClientManager.ServerHealth())
+}
+
+// handleTestMCPServer connects to an MCP server as the admin. The settings in the body are tested
+// when given, so servers can be tried before their configuration is saved.
+func (a *API) handleTestMCPServer(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	var serverConfig *mcp.ServerConfig
+	var body mcp.ServerConfig
+	if err := c.ShouldBindJSON(&body); err == nil {
+		serverConfig = &body
+	} else if !errors.Is(err, io.EOF) {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	health, err := a.mcpClientManager.TestServer(userID, c.Param("serverid"), serverConfig)
+	if err != nil {
+		if errors.Is(err, mcp.ErrServerNotFound) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, health)
+}
+
+func (a *API) handleListMCPSecrets(c *gin.Context) {
+	secrets, err := a.mcpSecretStore.List()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, secrets)
+}
+
+// handleSetMCPSecret stores the value of a secret MCP server configs reference by name. The servers
+// are connected to again so they use the new value.
+func (a *API) handleSetMCPSecret(c *gin.Context) {
+	var data struct {
+		Value string `json:"value" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := a.mcpSecretStore.Set(c.Param("name"), data.Value); err != nil {
+		if errors.Is(err, mcp.ErrInvalidSecretName) {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	a.mcpClientManager.Reconnect()
+
+	c.Status(http.StatusOK)
+}
+
+func (a *API) handleDeleteMCPSecret(c *gin.Context) {
+	if err := a.mcpSecretStore.Delete(c.Param("name")); err != nil {
+		if errors.Is(err, mcp.ErrSecretNotFound) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	a.mcpClientManager.Reconnect()
+
+	c.Status(http.StatusOK)
+}
+
+// handleRotateMCPSecretsKey encrypts the MCP secrets with the active key of the keyring.
+func (a *API) handleRotateMCPSecretsKey(c *gin.Context) {
+	if err := a.enforceEmptyBody(c); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := a.mcpSecretStore.RotateKey(); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// defaultMCPToolCallsWindow is the period listed when no start is given, and maxListedMCPToolCalls the
+// calls listed at once when no limit is given. Exports are limited to mcp.MaxToolCallsListed.
+const (
+	defaultMCPToolCallsWindow = 7 * 24 * time.Hour
+	maxListedMCPToolCalls     = 200
+)
+
+// handleGetMCPToolCalls lists the audit records of the MCP tool calls made between since and until, in
+// milliseconds, most recent first. They can be filtered by user, bot, server, tool and status.
+func (a *API) handleGetMCPToolCalls(c *gin.Context) {
+	filter, err := mcpToolCallFilter(c, maxListedMCPToolCalls)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	calls, err := a.mcpAuditStore.ListToolCalls(filter)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, calls)
+}
+
+// handleExportMCPToolCalls downloads the audit records matching the same filters as a CSV file.
+func (a *API) handleExportMCPToolCalls(c *gin.Context) {
+	filter, err := mcpToolCallFilter(c, mcp.MaxToolCallsListed)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	calls, err := a.mcpAuditStore.ListToolCalls(filter)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	data, err := mcp.ToolCallsCSV(calls)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to export MCP tool calls: %w", err))
+		return
+	}
+
+	filename := fmt.Sprintf("mcp-tool-calls-%s.csv", time.UnixMilli(filter.Until).UTC().Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+func mcpToolCallFilter(c *gin.Context, defaultLimit int) (mcp.ToolCallFilter, error) {
+	now := model.GetMillis()
+	since, err := millisQuery(c, "since", now-defaultMCPToolCallsWindow.Milliseconds())
+	if err != nil {
+		return mcp.ToolCallFilter{}, err
+	}
+	until, err := millisQuery(c, "until", now)
+	if err != nil {
+		return mcp.ToolCallFilter{}, err
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", mcp.ToolCallStatusSuccess, mcp.ToolCallStatusError:
+	default:
+		return mcp.ToolCallFilter{}, fmt.Errorf("invalid tool call status %q", status)
+	}
+
+	limit := defaultLimit
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			return mcp.ToolCallFilter{}, fmt.Errorf("invalid limit %q", value)
+		}
+	}
+
+	return mcp.ToolCallFilter{
+		UserID:   c.Query("user_id"),
+		BotID:    c.Query("bot_id"),
+		ServerID: c.Query("server_id"),
+		ToolName: c.Query("tool"),
+		Status:   status,
+		Since:    since,
+		Until:    until,
+		Limit:    limit,
+	}, nil
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/memory"
+)
+
+// handleGetMemories lists what the bots remember about the user. Users can see and delete their memories
+// even once user memory is disabled.
+func (a *API) handleGetMemories(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	memories, err := a.memoryStore.List(userID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, memories)
+}
+
+func (a *API) handleDeleteMemory(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	err := a.memoryStore.Delete(userID, c.Param("memoryid"))
+	if errors.Is(err, memory.ErrMemoryNotFound) {
+		c.AbortWithError(http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (a *API) handleDeleteAllMemories(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	if err := a.memoryStore.DeleteAll(userID); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/migration"
+)
+
+// handleImport migrates bots and conversation metadata from an export of another AI integration.
+// With dry_run=true it only reports what would be imported.
+func (a *API) handleImport(c *gin.Context) {
+	export, err := migration.ParseExport(c.Request.Body)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := a.importer.Import(export, c.Query("dry_run") == "true")
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
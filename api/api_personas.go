@@ -0,0 +1,57 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// handleGetPersonas lists the personas users can pick for their conversations.
+func (a *API) handleGetPersonas(c *gin.Context) {
+	c.JSON(http.StatusOK, a.conversationsService.Personas())
+}
+
+func (a *API) handleGetThreadPersona(c *gin.Context) {
+	post := c.MustGet(ContextPostKey).(*model.Post)
+
+	personaID, err := a.conversationsService.ThreadPersona(post)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get thread persona: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"personaID": personaID})
+}
+
+func (a *API) handleSetThreadPersona(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	var data struct {
+		PersonaID string `json:"personaID"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	err := a.conversationsService.SetThreadPersona(userID, post, channel, data.PersonaID)
+	switch {
+	case errors.Is(err, conversations.ErrPersonaNotDM), errors.Is(err, conversations.ErrUnknownPersona):
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	case err != nil:
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"personaID": data.PersonaID})
+}
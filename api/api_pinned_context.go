@@ -0,0 +1,68 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+func (a *API) handleGetPinnedContext(c *gin.Context) {
+	post := c.MustGet(ContextPostKey).(*model.Post)
+
+	postIDs, err := a.conversationsService.PinnedContextPosts(post)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get pinned posts: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string][]string{"postIds": postIDs})
+}
+
+func (a *API) handlePinContextPost(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	err := a.conversationsService.PinContextPost(userID, post, channel)
+	switch {
+	case errors.Is(err, conversations.ErrNotAIThread), errors.Is(err, conversations.ErrTooManyPinnedPosts):
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	case errors.Is(err, conversations.ErrNotThreadRequester):
+		c.AbortWithError(http.StatusForbidden, err)
+		return
+	case err != nil:
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to pin post: %w", err))
+		return
+	}
+
+	a.handleGetPinnedContext(c)
+}
+
+func (a *API) handleUnpinContextPost(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	err := a.conversationsService.UnpinContextPost(userID, post, channel)
+	switch {
+	case errors.Is(err, conversations.ErrNotAIThread):
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	case errors.Is(err, conversations.ErrNotThreadRequester):
+		c.AbortWithError(http.StatusForbidden, err)
+		return
+	case err != nil:
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to unpin post: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// maxPlaygroundInputs limits the sample inputs of a playground run, each costs two completions
+const maxPlaygroundInputs = 5
+
+// PlaygroundRunRequest runs sample inputs with a draft of a bot's custom instructions.
+type PlaygroundRunRequest struct {
+	BotName            string   `json:"botName" binding:"required"`
+	CustomInstructions string   `json:"customInstructions"`
+	Inputs             []string `json:"inputs" binding:"required"`
+}
+
+// PlaygroundResponse is the response of the bot to a sample input, or the error that prevented it.
+type PlaygroundResponse struct {
+	Response string `json:"response"`
+	Error    string `json:"error,omitempty"`
+}
+
+// PlaygroundResult compares the responses to a sample input with the draft and the published instructions.
+type PlaygroundResult struct {
+	Input     string             `json:"input"`
+	Draft     PlaygroundResponse `json:"draft"`
+	Published PlaygroundResponse `json:"published"`
+}
+
+// PlaygroundPublishRequest publishes a draft of a bot's custom instructions. When PublishedInstructions is
+// set, publishing fails if the instructions were changed since, such as by another admin.
+type PlaygroundPublishRequest struct {
+	BotName               string  `json:"botName" binding:"required"`
+	CustomInstructions    string  `json:"customInstructions"`
+	PublishedInstructions *string `json:"publishedInstructions"`
+}
+
+// handlePlaygroundRun answers sample inputs as a new direct message conversation with the bot, once with
+// the draft instructions and once with the published ones, so admins can compare them side by side.
+// Tools are left out so the runs don't act on behalf of the admin.
+func (a *API) handlePlaygroundRun(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	var data PlaygroundRunRequest
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if len(data.Inputs) == 0 || len(data.Inputs) > maxPlaygroundInputs {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("between 1 and %d inputs are required", maxPlaygroundInputs))
+		return
+	}
+
+	bot := a.bots.GetBotByUsername(data.BotName)
+	if bot == nil {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf("bot %q not found", data.BotName))
+		return
+	}
+
+	user, err := a.pluginAPI.User.Get(userID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
+		return
+	}
+
+	results := make([]PlaygroundResult, len(data.Inputs))
+	var wg sync.WaitGroup
+	for i, input := range data.Inputs {
+		results[i].Input = input
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			results[i].Draft = a.playgroundResponse(bot, user, data.CustomInstructions, input)
+		}()
+		go func() {
+			defer wg.Done()
+			results[i].Published = a.playgroundResponse(bot, user, bot.GetConfig().CustomInstructions, input)
+		}()
+	}
+	wg.Wait()
+
+	c.JSON(http.StatusOK, results)
+}
+
+func (a *API) playgroundResponse(bot *bots.Bot, user *model.User, customInstructions string, input string) PlaygroundResponse {
+	llmContext := a.contextBuilder.BuildLLMContextUserRequest(bot, user, nil)
+	llmContext.CustomInstructions = customInstructions
+
+	systemPrompt, err := a.prompts.Format(prompts.PromptDirectMessageQuestionSystem, llmContext)
+	if err != nil {
+		return PlaygroundResponse{Error: fmt.Sprintf("unable to format prompt: %v", err)}
+	}
+
+	response, err := bot.LLM().ChatCompletionNoStream(llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: input},
+		},
+		Context: llmContext,
+	})
+	if err != nil {
+		return PlaygroundResponse{Error: err.Error()}
+	}
+	return PlaygroundResponse{Response: response}
+}
+
+var (
+	errPlaygroundBotNotFound         = errors.New("bot not found")
+	errPlaygroundInstructionsChanged = errors.New("the published instructions were changed since the draft was started")
+)
+
+// handlePlaygroundPublish saves a draft of a bot's custom instructions to the configuration, which takes
+// effect right away.
+func (a *API) handlePlaygroundPublish(c *gin.Context) {
+	var data PlaygroundPublishRequest
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	err := config.UpdateSavedConfig(&a.pluginAPI.Configuration, func(cfg map[string]any) error {
+		botConfig := findBotConfig(cfg, data.BotName)
+		if botConfig == nil {
+			return fmt.Errorf("%w: %q", errPlaygroundBotNotFound, data.BotName)
+		}
+
+		published, _ := botConfig["customInstructions"].(string)
+		if data.PublishedInstructions != nil && *data.PublishedInstructions != published {
+			return errPlaygroundInstructionsChanged
+		}
+
+		botConfig["customInstructions"] = strings.TrimSpace(data.CustomInstructions)
+		return nil
+	})
+	switch {
+	case errors.Is(err, errPlaygroundBotNotFound):
+		c.AbortWithError(http.StatusNotFound, err)
+		return
+	case errors.Is(err, errPlaygroundInstructionsChanged):
+		c.AbortWithError(http.StatusConflict, err)
+		return
+	case err != nil:
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// findBotConfig returns the settings of the bot in the configuration as saved in the plugin settings.
+func findBotConfig(cfg map[string]any, botName string) map[string]any {
+	botConfigs, _ := cfg["bots"].([]any)
+	for _, botConfig := range botConfigs {
+		if botMap, ok := botConfig.(map[string]any); ok && botMap["name"] == botName {
+			return botMap
+		}
+	}
+	return nil
+}
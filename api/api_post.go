@@ -6,7 +6,10 @@ package api
 import (
 	stdcontext "context"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"errors"
 
@@ -16,11 +19,14 @@ import (
 	"github.com/mattermost/mattermost-plugin-ai/conversations"
 	"github.com/mattermost/mattermost-plugin-ai/i18n"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/meetings"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost-plugin-ai/react"
 	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
 	"github.com/mattermost/mattermost-plugin-ai/threads"
 	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
 )
 
 func (a *API) postAuthorizationRequired(c *gin.Context) {
@@ -180,18 +186,60 @@ func (a *API) handleThreadAnalysis(c *gin.Context) {
 }
 
 func (a *API) handleTranscribeFile(c *gin.Context) {
+	if err := a.enforceEmptyBody(c); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	a.transcribeFiles(c, []string{c.Param("fileid")})
+}
+
+// handleTranscribeFiles summarizes a meeting recorded in several files attached to the post as one meeting.
+func (a *API) handleTranscribeFiles(c *gin.Context) {
+	var data struct {
+		FileIDs []string `json:"file_ids" binding:"required"`
+	}
+	if bindErr := c.ShouldBindJSON(&data); bindErr != nil {
+		c.AbortWithError(http.StatusBadRequest, bindErr)
+		return
+	}
+
+	a.transcribeFiles(c, data.FileIDs)
+}
+
+func (a *API) transcribeFiles(c *gin.Context, fileIDs []string) {
 	userID := c.GetHeader("Mattermost-User-Id")
 	post := c.MustGet(ContextPostKey).(*model.Post)
 	channel := c.MustGet(ContextChannelKey).(*model.Channel)
-	fileID := c.Param("fileid")
 	bot := c.MustGet(ContextBotKey).(*bots.Bot)
 
-	if err := a.enforceEmptyBody(c); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+	// Optional ISO-639-1 code of the language spoken, detected when not given
+	language := c.Query("language")
+	translate := c.Query("translate") == "true"
+
+	// ID of the summary template, the standard summary when not given
+	format := c.Query("format")
+
+	result, err := a.meetingsService.HandleTranscribeFile(userID, bot, post, channel, fileIDs, language, translate, format)
+	if err != nil {
+		if errors.Is(err, meetings.ErrNoRecordings) || errors.Is(err, meetings.ErrUnknownSummaryFormat) {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
-	result, err := a.meetingsService.HandleTranscribeFile(userID, bot, post, channel, fileID)
+	c.Render(http.StatusOK, render.JSON{Data: result})
+}
+
+func (a *API) handleTranscribeFileEstimate(c *gin.Context) {
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	fileID := c.Param("fileid")
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	result, err := a.meetingsService.EstimateTranscribeFile(bot, post, channel, fileID)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -211,10 +259,22 @@ func (a *API) handleSummarizeTranscription(c *gin.Context) {
 		return
 	}
 
-	result, err := a.meetingsService.HandleSummarizeTranscription(userID, bot, post, channel)
+	// Translates the transcript into the user's locale before summarizing it
+	translate := c.Query("translate") == "true"
+
+	// ID of the summary template, the standard summary when not given
+	format := c.Query("format")
+
+	timeRange, err := parseTranscriptRange(c.Query("start"), c.Query("end"))
 	if err != nil {
-		if err.Error() == "not a calls or zoom bot post" {
-			c.AbortWithError(http.StatusBadRequest, errors.New("not a calls or zoom bot post"))
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := a.meetingsService.HandleSummarizeTranscription(userID, bot, post, channel, translate, format, timeRange)
+	if err != nil {
+		if errors.Is(err, meetings.ErrNoTranscript) || errors.Is(err, subtitles.ErrUnknownFormat) || errors.Is(err, meetings.ErrUnknownSummaryFormat) {
+			c.AbortWithError(http.StatusBadRequest, err)
 			return
 		}
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to summarize transcription: %w", err))
@@ -224,6 +284,36 @@ func (a *API) handleSummarizeTranscription(c *gin.Context) {
 	c.Render(http.StatusOK, render.JSON{Data: result})
 }
 
+// parseTranscriptRange parses the start and end offsets of the part of a transcript to summarize, in seconds.
+// Negative offsets count back from the end of the transcript, so start=-1200 is the last 20 minutes.
+func parseTranscriptRange(start, end string) (subtitles.TimeRange, error) {
+	startOffset, err := parseTranscriptOffset(start)
+	if err != nil {
+		return subtitles.TimeRange{}, err
+	}
+	endOffset, err := parseTranscriptOffset(end)
+	if err != nil {
+		return subtitles.TimeRange{}, err
+	}
+
+	// Offsets counted from different ends can only be compared once the length of the transcript is known
+	if endOffset != 0 && (startOffset < 0) == (endOffset < 0) && endOffset <= startOffset {
+		return subtitles.TimeRange{}, errors.New("the end of the transcript range must be after its start")
+	}
+	return subtitles.TimeRange{Start: startOffset, End: endOffset}, nil
+}
+
+func parseTranscriptOffset(offset string) (time.Duration, error) {
+	if offset == "" {
+		return 0, nil
+	}
+	seconds, err := strconv.Atoi(offset)
+	if err != nil {
+		return 0, fmt.Errorf("invalid transcript offset %q", offset)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
 func (a *API) handleStop(c *gin.Context) {
 	userID := c.GetHeader("Mattermost-User-Id")
 	post := c.MustGet(ContextPostKey).(*model.Post)
@@ -248,17 +338,47 @@ func (a *API) handleStop(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// StreamStateResponse is the progress of a post, for clients resuming a stream after missing its events.
+type StreamStateResponse struct {
+	Generating bool `json:"generating"`
+	streaming.StreamState
+}
+
+// handleGetStreamState returns the message of the post as streamed so far, or as saved once the stream has ended.
+func (a *API) handleGetStreamState(c *gin.Context) {
+	post := c.MustGet(ContextPostKey).(*model.Post)
+
+	if state, ok := a.streamingService.GetStreamState(post.Id); ok {
+		c.JSON(http.StatusOK, StreamStateResponse{Generating: true, StreamState: state})
+		return
+	}
+
+	c.JSON(http.StatusOK, StreamStateResponse{StreamState: streaming.StreamState{Message: post.Message}})
+}
+
 func (a *API) handleRegenerate(c *gin.Context) {
 	userID := c.GetHeader("Mattermost-User-Id")
 	post := c.MustGet(ContextPostKey).(*model.Post)
 	channel := c.MustGet(ContextChannelKey).(*model.Channel)
 
-	if err := a.enforceEmptyBody(c); err != nil {
+	// Optional instruction to regenerate the response with, such as "shorter" or "as bullet points"
+	var data struct {
+		Instruction string `json:"instruction"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil && !errors.Is(err, io.EOF) {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	err := a.conversationsService.HandleRegenerate(userID, post, channel)
+	err := a.conversationsService.HandleRegenerate(userID, post, channel, data.Instruction)
+	if errors.Is(err, conversations.ErrInvalidRegenerationInstruction) {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if errors.Is(err, meetings.ErrTranscriptNotFound) {
+		c.AbortWithError(http.StatusNotFound, err)
+		return
+	}
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to regenerate post: %w", err))
 		return
@@ -267,6 +387,61 @@ func (a *API) handleRegenerate(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// handleResummarize summarizes the transcript of a meeting summary again with the bot of the request.
+func (a *API) handleResummarize(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	if err := a.enforceEmptyBody(c); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := a.meetingsService.HandleResummarize(userID, bot, post)
+	if err != nil {
+		switch {
+		case errors.Is(err, meetings.ErrNotMeetingSummary), errors.Is(err, meetings.ErrNoTranscript):
+			c.AbortWithError(http.StatusBadRequest, err)
+		case errors.Is(err, bots.ErrUsageRestriction), errors.Is(err, meetings.ErrResummarizeNotRequester):
+			c.AbortWithError(http.StatusForbidden, err)
+		default:
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to summarize again: %w", err))
+		}
+		return
+	}
+
+	c.Render(http.StatusOK, render.JSON{Data: result})
+}
+
+func (a *API) handleRefineSummary(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	var data struct {
+		Refinement string `json:"refinement" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := a.conversationsService.HandleRefineSummary(userID, post, channel, data.Refinement); err != nil {
+		switch {
+		case errors.Is(err, conversations.ErrUnknownRefinement), errors.Is(err, conversations.ErrNotSummaryPost):
+			c.AbortWithError(http.StatusBadRequest, err)
+		case errors.Is(err, conversations.ErrRefineNotRequester):
+			c.AbortWithError(http.StatusForbidden, err)
+		default:
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to refine summary: %w", err))
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
 func (a *API) handleToolCall(c *gin.Context) {
 	userID := c.GetHeader("Mattermost-User-Id")
 	post := c.MustGet(ContextPostKey).(*model.Post)
@@ -277,47 +452,319 @@ func (a *API) handleToolCall(c *gin.Context) {
 		return
 	}
 
-	// Only the original requester can approve/reject tool calls
-	if post.GetProp(streaming.LLMRequesterUserID) != userID {
-		c.AbortWithError(http.StatusForbidden, errors.New("only the original requester can approve/reject tool calls"))
+	var data struct {
+		AcceptedToolIDs []string `json:"accepted_tool_ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	// Only the original requester or the delegated approvers can approve/reject tool calls
+	err := a.conversationsService.HandleToolCall(c.Request.Context(), userID, post, channel, data.AcceptedToolIDs)
+	if err != nil {
+		a.abortWithToolCallError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (a *API) abortWithToolCallError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, conversations.ErrMissingToolCalls), errors.Is(err, conversations.ErrInvalidToolCalls):
+		c.AbortWithError(http.StatusBadRequest, err)
+	case errors.Is(err, conversations.ErrNotToolCallApprover):
+		c.AbortWithError(http.StatusForbidden, err)
+	default:
+		c.AbortWithError(http.StatusInternalServerError, err)
+	}
+}
+
+// toolApprovalPostRequired loads the post of a delegated tool approval. Approvers may not be
+// members of the channel the tools were called in, so access is checked against the tool calls.
+func (a *API) toolApprovalPostRequired(c *gin.Context) {
+	if !a.licenseChecker.IsBasicsLicensed() {
+		c.AbortWithError(http.StatusForbidden, errors.New("feature not licensed"))
+		return
+	}
+
+	post, err := a.pluginAPI.Post.GetPost(c.Param("postid"))
+	if errors.Is(err, pluginapi.ErrNotFound) {
+		c.AbortWithError(http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Set(ContextPostKey, post)
+
+	channel, err := a.pluginAPI.Channel.Get(post.ChannelId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Set(ContextChannelKey, channel)
+}
+
+func (a *API) handleGetToolApproval(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+
+	request, err := a.conversationsService.GetToolApprovalRequest(userID, post)
+	if err != nil {
+		a.abortWithToolCallError(c, err)
 		return
 	}
 
+	c.Render(http.StatusOK, render.JSON{Data: request})
+}
+
+func (a *API) handleToolApproval(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
 	var data struct {
 		AcceptedToolIDs []string `json:"accepted_tool_ids" binding:"required"`
 	}
-
 	if err := c.ShouldBindJSON(&data); err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	err := a.conversationsService.HandleToolCall(userID, post, channel, data.AcceptedToolIDs)
+	if err := a.conversationsService.HandleToolCall(c.Request.Context(), userID, post, channel, data.AcceptedToolIDs); err != nil {
+		a.abortWithToolCallError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (a *API) handleExplainToolCall(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	toolCallID := c.Param("toolcallid")
+
+	if err := a.enforceEmptyBody(c); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if !a.licenseChecker.IsBasicsLicensed() {
+		c.AbortWithError(http.StatusForbidden, errors.New("feature not licensed"))
+		return
+	}
+
+	explanation, err := a.conversationsService.ExplainToolCall(userID, post, channel, toolCallID)
 	if err != nil {
-		if err.Error() == "post missing pending tool calls" || err.Error() == "post pending tool calls not valid JSON" {
-			c.AbortWithError(http.StatusBadRequest, err)
+		if errors.Is(err, conversations.ErrToolCallNotFound) {
+			c.AbortWithError(http.StatusNotFound, err)
 		} else {
 			c.AbortWithError(http.StatusInternalServerError, err)
 		}
 		return
 	}
 
+	c.Render(http.StatusOK, render.JSON{Data: explanation})
+}
+
+func (a *API) handleHandoff(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	if err := a.enforceEmptyBody(c); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	handoffPost, err := a.conversationsService.HandoffConversation(userID, post, channel)
+	if err != nil {
+		switch {
+		case errors.Is(err, conversations.ErrHandoffNotDM), errors.Is(err, conversations.ErrHandoffNotEnabled):
+			c.AbortWithError(http.StatusBadRequest, err)
+		default:
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to hand off conversation: %w", err))
+		}
+		return
+	}
+
+	c.Render(http.StatusOK, render.JSON{Data: map[string]string{
+		"postid":    handoffPost.Id,
+		"channelid": handoffPost.ChannelId,
+	}})
+}
+
+func (a *API) handleFork(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	if err := a.enforceEmptyBody(c); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	forkRoot, err := a.conversationsService.ForkConversation(userID, post, channel)
+	if err != nil {
+		if errors.Is(err, conversations.ErrForkNotDM) {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to fork conversation: %w", err))
+		return
+	}
+
+	c.Render(http.StatusOK, render.JSON{Data: map[string]string{
+		"postid":    forkRoot.Id,
+		"channelid": forkRoot.ChannelId,
+	}})
+}
+
+// handleResend answers an edited message again, deleting the later responses and posts of its author in the thread.
+func (a *API) handleResend(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	if err := a.enforceEmptyBody(c); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	err := a.conversationsService.ResendEditedPost(userID, post, channel)
+	switch {
+	case errors.Is(err, conversations.ErrResendNotOwnPost):
+		c.AbortWithError(http.StatusForbidden, err)
+		return
+	case errors.Is(err, conversations.ErrResendNotToBot), errors.Is(err, conversations.ErrResendNotEdited):
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	case err != nil:
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to resend post: %w", err))
+		return
+	}
+
 	c.Status(http.StatusOK)
 }
 
-func (a *API) handlePostbackSummary(c *gin.Context) {
+func (a *API) handleSetActionItemDone(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	itemID := c.Param("itemid")
+
+	var data struct {
+		Done bool `json:"done"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if post.GetProp(streaming.LLMRequesterUserID) != userID {
+		c.AbortWithError(http.StatusForbidden, errors.New("only the requester can update action items"))
+		return
+	}
+
+	if err := a.meetingsService.SetActionItemDone(post, itemID, data.Done); err != nil {
+		if errors.Is(err, meetings.ErrActionItemNotFound) {
+			c.AbortWithError(http.StatusNotFound, err)
+		} else {
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to update action item: %w", err))
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (a *API) handleSendActionItem(c *gin.Context) {
 	userID := c.GetHeader("Mattermost-User-Id")
 	post := c.MustGet(ContextPostKey).(*model.Post)
+	itemID := c.Param("itemid")
 
 	if err := a.enforceEmptyBody(c); err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	result, err := a.meetingsService.HandlePostbackSummary(userID, post)
+	if post.GetProp(streaming.LLMRequesterUserID) != userID {
+		c.AbortWithError(http.StatusForbidden, errors.New("only the requester can send action items"))
+		return
+	}
+
+	if err := a.meetingsService.SendActionItem(post, itemID, userID); err != nil {
+		switch {
+		case errors.Is(err, meetings.ErrActionItemNotFound):
+			c.AbortWithError(http.StatusNotFound, err)
+		case errors.Is(err, meetings.ErrActionItemNoOwner):
+			c.AbortWithError(http.StatusBadRequest, err)
+		default:
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to send action item: %w", err))
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// handleCreatePlaybookRun creates a Playbooks run with the action items of a checklist post.
+func (a *API) handleCreatePlaybookRun(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+
+	var data struct {
+		PlaybookID string `json:"playbook_id"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if post.GetProp(streaming.LLMRequesterUserID) != userID {
+		c.AbortWithError(http.StatusForbidden, errors.New("only the requester can create a playbook run from action items"))
+		return
+	}
+
+	run, err := a.meetingsService.CreatePlaybookRun(post, userID, data.PlaybookID)
+	if err != nil {
+		switch {
+		case errors.Is(err, meetings.ErrActionItemNotFound), errors.Is(err, meetings.ErrPlaybooksUnavailable), errors.Is(err, meetings.ErrNotMeetingSummary):
+			c.AbortWithError(http.StatusBadRequest, err)
+		case errors.Is(err, meetings.ErrPlaybookRunExists):
+			c.AbortWithError(http.StatusConflict, err)
+		default:
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to create playbook run: %w", err))
+		}
+		return
+	}
+
+	c.Render(http.StatusOK, render.JSON{Data: run})
+}
+
+func (a *API) handlePostbackSummary(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+
+	// The body is optional, without it the summary is posted back to the original call thread
+	var data struct {
+		ChannelIDs []string `json:"channel_ids"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil && !errors.Is(err, io.EOF) {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := a.meetingsService.HandlePostbackSummary(userID, post, data.ChannelIDs)
 	if err != nil {
 		if err.Error() == "post missing reference to transcription post ID" {
 			c.AbortWithError(http.StatusBadRequest, err)
+		} else if errors.Is(err, meetings.ErrPostbackNotPermitted) {
+			c.AbortWithError(http.StatusForbidden, err)
 		} else {
 			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to post back summary: %w", err))
 		}
@@ -327,6 +774,108 @@ func (a *API) handlePostbackSummary(c *gin.Context) {
 	c.Render(http.StatusOK, render.JSON{Data: result})
 }
 
+func (a *API) handleGetTranscript(c *gin.Context) {
+	post := c.MustGet(ContextPostKey).(*model.Post)
+
+	result, err := a.meetingsService.GetTranscript(post)
+	if err != nil {
+		if errors.Is(err, meetings.ErrNoTranscript) || errors.Is(err, subtitles.ErrUnknownFormat) {
+			c.AbortWithError(http.StatusBadRequest, err)
+		} else {
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get transcript: %w", err))
+		}
+		return
+	}
+
+	c.Render(http.StatusOK, render.JSON{Data: result})
+}
+
+// handleEditTranscript stores a corrected transcript for a meeting summary. With regenerate=true the
+// summary is then regenerated from the corrected transcript.
+func (a *API) handleEditTranscript(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	var data struct {
+		Segments []subtitles.Segment `json:"segments" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := a.meetingsService.EditTranscript(userID, post, data.Segments)
+	if err != nil {
+		switch {
+		case errors.Is(err, meetings.ErrInvalidTranscript), errors.Is(err, meetings.ErrNotMeetingSummary), errors.Is(err, meetings.ErrNoTranscript):
+			c.AbortWithError(http.StatusBadRequest, err)
+		case errors.Is(err, meetings.ErrTranscriptEditNotRequester):
+			c.AbortWithError(http.StatusForbidden, err)
+		default:
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to edit transcript: %w", err))
+		}
+		return
+	}
+
+	if c.Query("regenerate") == "true" {
+		if err := a.conversationsService.HandleRegenerate(userID, post, channel, ""); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to regenerate summary: %w", err))
+			return
+		}
+	}
+
+	c.Render(http.StatusOK, render.JSON{Data: result})
+}
+
+func (a *API) handleExportTranscript(c *gin.Context) {
+	post := c.MustGet(ContextPostKey).(*model.Post)
+
+	format := c.DefaultQuery("format", meetings.TranscriptFormatVTT)
+	export, err := a.meetingsService.ExportTranscript(post, format)
+	if err != nil {
+		if errors.Is(err, meetings.ErrNoTranscript) || errors.Is(err, meetings.ErrUnknownTranscriptFormat) || errors.Is(err, subtitles.ErrUnknownFormat) {
+			c.AbortWithError(http.StatusBadRequest, err)
+		} else {
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to export transcript: %w", err))
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename))
+	c.Data(http.StatusOK, export.ContentType+"; charset=utf-8", []byte(export.Content))
+}
+
+func (a *API) handleExportConversation(c *gin.Context) {
+	post := c.MustGet(ContextPostKey).(*model.Post)
+
+	format := c.DefaultQuery("format", conversations.ExportFormatMarkdown)
+	export, err := a.conversationsService.ExportConversation(post, format)
+	if err != nil {
+		if errors.Is(err, conversations.ErrUnknownExportFormat) {
+			c.AbortWithError(http.StatusBadRequest, err)
+		} else {
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to export conversation: %w", err))
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename))
+	c.Data(http.StatusOK, export.ContentType+"; charset=utf-8", []byte(export.Content))
+}
+
+func (a *API) handleGetThreadUsage(c *gin.Context) {
+	post := c.MustGet(ContextPostKey).(*model.Post)
+
+	usage, err := a.conversationsService.ThreadUsage(post)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get thread usage: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
 // makeAnalysisPost creates a post for thread analysis results
 func (a *API) makeAnalysisPost(locale string, postIDToAnalyze string, analysisType string, siteURL string) *model.Post {
 	post := &model.Post{
@@ -341,3 +890,40 @@ func (a *API) makeAnalysisPost(locale string, postIDToAnalyze string, analysisTy
 func (a *API) analysisPostMessage(locale string, postIDToAnalyze string, analysisType string, siteURL string) string {
 	return i18n.FormatAnalysisPostMessage(a.i18nBundle, locale, postIDToAnalyze, analysisType, siteURL)
 }
+
+// handleAskAboutPost answers a question of the user about the post, in their direct message with the bot.
+func (a *API) handleAskAboutPost(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	var data struct {
+		Question string `json:"question" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := a.pluginAPI.User.Get(userID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
+		return
+	}
+
+	answerPost, err := a.conversationsService.AskAboutPost(bot, user, post, channel, data.Question)
+	switch {
+	case errors.Is(err, conversations.ErrInvalidQuestion):
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	case err != nil:
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to answer question about post: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{
+		"postid":    answerPost.Id,
+		"channelid": answerPost.ChannelId,
+	})
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/provenance"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// defaultProvenanceWindow is the period listed when no start is given
+const defaultProvenanceWindow = 24 * time.Hour
+
+// handleGetProvenance lists the AI generated posts created between since and until, in milliseconds, with
+// their provenance. It is paged with the cursor returned with each page. Compliance scanners use it through
+// the admin API, export plugins through the inter-plugin API.
+func (a *API) handleGetProvenance(c *gin.Context) {
+	now := model.GetMillis()
+	since, err := millisQuery(c, "since", now-defaultProvenanceWindow.Milliseconds())
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	until, err := millisQuery(c, "until", now)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	limit := provenance.MaxPageSize
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid limit %q", value))
+			return
+		}
+	}
+
+	page, err := a.provenanceStore.List(since, until, c.Query("cursor"), limit)
+	if errors.Is(err, provenance.ErrInvalidCursor) {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func millisQuery(c *gin.Context, name string, defaultValue int64) (int64, error) {
+	value := c.Query(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || millis < 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected milliseconds since the epoch", name, value)
+	}
+	return millis, nil
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/compose"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+type rewriteRequest struct {
+	Action   string `json:"action" binding:"required"`
+	Tone     string `json:"tone"`
+	Language string `json:"language"`
+}
+
+// handleRewriteDraft rewrites the draft of a post in the channel.
+func (a *API) handleRewriteDraft(c *gin.Context) {
+	var data struct {
+		rewriteRequest
+		Message string `json:"message" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	a.rewrite(c, data.Message, data.rewriteRequest)
+}
+
+// handleRewritePost rewrites the message of an existing post, without changing the post.
+func (a *API) handleRewritePost(c *gin.Context) {
+	post := c.MustGet(ContextPostKey).(*model.Post)
+
+	var data rewriteRequest
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	a.rewrite(c, post.Message, data)
+}
+
+func (a *API) rewrite(c *gin.Context, message string, request rewriteRequest) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	user, err := a.pluginAPI.User.Get(userID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
+		return
+	}
+
+	context := a.contextBuilder.BuildLLMContextUserRequest(bot, user, channel)
+	rewritten, err := compose.New(bot.LLM(), a.prompts).Rewrite(message, compose.RewriteOptions{
+		Action:   request.Action,
+		Tone:     request.Tone,
+		Language: request.Language,
+	}, context)
+	switch {
+	case errors.Is(err, compose.ErrInvalidRewrite):
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	case err != nil:
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"message": rewritten})
+}
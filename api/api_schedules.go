@@ -0,0 +1,137 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/schedules"
+)
+
+var errUnknownScheduleBot = errors.New("unknown bot")
+
+// scheduleRequest is a schedule as created or updated by the user. Schedules are enabled unless told
+// otherwise, and run in the user's time zone unless another one is given.
+type scheduleRequest struct {
+	BotUsername string `json:"botUsername"`
+	Name        string `json:"name"`
+	Prompt      string `json:"prompt"`
+	Cadence     string `json:"cadence"`
+	TimeZone    string `json:"timeZone"`
+	Enabled     *bool  `json:"enabled"`
+}
+
+func (a *API) handleGetSchedules(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	userSchedules, err := a.scheduleStore.List(userID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, userSchedules)
+}
+
+func (a *API) handleCreateSchedule(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	schedule, ok := a.bindSchedule(c, userID)
+	if !ok {
+		return
+	}
+
+	created, err := a.scheduleStore.Create(schedule)
+	if err != nil {
+		a.abortWithScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (a *API) handleUpdateSchedule(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	schedule, ok := a.bindSchedule(c, userID)
+	if !ok {
+		return
+	}
+	schedule.ID = c.Param("scheduleid")
+
+	updated, err := a.scheduleStore.Update(schedule)
+	if err != nil {
+		a.abortWithScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (a *API) handleDeleteSchedule(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	if err := a.scheduleStore.Delete(userID, c.Param("scheduleid")); err != nil {
+		a.abortWithScheduleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// bindSchedule reads the schedule of the request for the user, checking they can use its bot.
+func (a *API) bindSchedule(c *gin.Context, userID string) (schedules.Schedule, bool) {
+	var request scheduleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return schedules.Schedule{}, false
+	}
+
+	bot := a.bots.GetBotByUsername(request.BotUsername)
+	if bot == nil {
+		a.abortWithScheduleError(c, fmt.Errorf("%w: %q", errUnknownScheduleBot, request.BotUsername))
+		return schedules.Schedule{}, false
+	}
+	if err := a.bots.CheckUsageRestrictionsForUser(bot, userID); err != nil {
+		a.abortWithScheduleError(c, err)
+		return schedules.Schedule{}, false
+	}
+
+	timeZone := request.TimeZone
+	if timeZone == "" {
+		user, err := a.pluginAPI.User.Get(userID)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
+			return schedules.Schedule{}, false
+		}
+		timeZone = user.GetPreferredTimezone()
+	}
+
+	return schedules.Schedule{
+		UserID:      userID,
+		BotUsername: request.BotUsername,
+		Name:        request.Name,
+		Prompt:      request.Prompt,
+		Cadence:     request.Cadence,
+		TimeZone:    timeZone,
+		Enabled:     request.Enabled == nil || *request.Enabled,
+	}, true
+}
+
+func (a *API) abortWithScheduleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, schedules.ErrScheduleNotFound):
+		c.AbortWithError(http.StatusNotFound, err)
+	case errors.Is(err, schedules.ErrInvalidSchedule), errors.Is(err, schedules.ErrTooManySchedules), errors.Is(err, errUnknownScheduleBot):
+		c.AbortWithError(http.StatusBadRequest, err)
+	case errors.Is(err, bots.ErrUsageRestriction):
+		c.AbortWithError(http.StatusForbidden, err)
+	default:
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("schedule update failed: %w", err))
+	}
+}
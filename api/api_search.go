@@ -10,6 +10,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/search"
 )
 
 // SearchRequest represents a search query request from the API
@@ -18,6 +20,8 @@ type SearchRequest struct {
 	TeamID     string `json:"teamId"`
 	ChannelID  string `json:"channelId"`
 	MaxResults int    `json:"maxResults"`
+	Corpus     string `json:"corpus"` // "posts" or "meetings" to search only one corpus
+	search.Filters
 }
 
 func (a *API) handleRunSearch(c *gin.Context) {
@@ -40,7 +44,17 @@ func (a *API) handleRunSearch(c *gin.Context) {
 		return
 	}
 
-	result, err := a.searchService.RunSearch(c.Request.Context(), userID, bot, req.Query, req.TeamID, req.ChannelID, req.MaxResults)
+	if !embeddings.IsValidCorpus(req.Corpus) {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("unknown search corpus: %s", req.Corpus))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid search filters: %w", err))
+		return
+	}
+
+	result, err := a.searchService.RunSearch(c.Request.Context(), userID, bot, req.Query, req.TeamID, req.ChannelID, req.Corpus, req.MaxResults, req.Filters)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -64,7 +78,17 @@ func (a *API) handleSearchQuery(c *gin.Context) {
 		return
 	}
 
-	response, err := a.searchService.SearchQuery(c.Request.Context(), userID, bot, req.Query, req.TeamID, req.ChannelID, req.MaxResults)
+	if !embeddings.IsValidCorpus(req.Corpus) {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("unknown search corpus: %s", req.Corpus))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid search filters: %w", err))
+		return
+	}
+
+	response, err := a.searchService.SearchQuery(c.Request.Context(), userID, bot, req.Query, req.TeamID, req.ChannelID, req.Corpus, req.MaxResults, req.Filters)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
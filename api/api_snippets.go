@@ -0,0 +1,243 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/snippets"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const ContextTeamIDKey = "teamid"
+
+// teamAuthorizationRequired only lets members of the team through.
+func (a *API) teamAuthorizationRequired(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	teamID := c.Param("teamid")
+
+	if !a.pluginAPI.User.HasPermissionToTeam(userID, teamID, model.PermissionViewTeam) {
+		c.AbortWithError(http.StatusForbidden, errors.New("user doesn't have permission to access team"))
+		return
+	}
+	c.Set(ContextTeamIDKey, teamID)
+}
+
+func (a *API) handleGetSnippets(c *gin.Context) {
+	teamSnippets, err := a.snippetStore.List(c.GetString(ContextTeamIDKey), c.GetHeader("Mattermost-User-Id"))
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, teamSnippets)
+}
+
+func (a *API) handleCreateSnippet(c *gin.Context) {
+	var snippet snippets.Snippet
+	if err := c.ShouldBindJSON(&snippet); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	snippet.ID = ""
+	snippet.TeamID = c.GetString(ContextTeamIDKey)
+	snippet.CreatorID = c.GetHeader("Mattermost-User-Id")
+	if snippet.Scope == snippets.ScopeOrganization && !a.pluginAPI.User.HasPermissionTo(snippet.CreatorID, model.PermissionManageSystem) {
+		a.abortWithSnippetError(c, errSnippetNotPublishable)
+		return
+	}
+
+	created, err := a.snippetStore.Create(snippet)
+	if err != nil {
+		a.abortWithSnippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (a *API) handleUpdateSnippet(c *gin.Context) {
+	var snippet snippets.Snippet
+	if err := c.ShouldBindJSON(&snippet); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	existing, err := a.getEditableSnippet(c)
+	if err != nil {
+		a.abortWithSnippetError(c, err)
+		return
+	}
+	snippet.ID = existing.ID
+	snippet.TeamID = existing.TeamID
+	snippet.Scope = existing.Scope
+	snippet.CreatorID = existing.CreatorID
+
+	updated, err := a.snippetStore.Update(snippet)
+	if err != nil {
+		a.abortWithSnippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (a *API) handleDeleteSnippet(c *gin.Context) {
+	existing, err := a.getEditableSnippet(c)
+	if err != nil {
+		a.abortWithSnippetError(c, err)
+		return
+	}
+
+	if err := a.snippetStore.Delete(existing.ID); err != nil {
+		a.abortWithSnippetError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+var (
+	errSnippetNotEditable    = errors.New("only the creator of a snippet or an admin can change it")
+	errSnippetNotPublishable = errors.New("only system admins can publish snippets to the organization")
+)
+
+// canUseSnippet checks the user can see the snippet: organization snippets are available to everyone,
+// team snippets to the members of the team, and personal snippets to their creator.
+func (a *API) canUseSnippet(userID string, snippet *snippets.Snippet) bool {
+	switch snippet.Scope {
+	case snippets.ScopeOrganization:
+		return true
+	case snippets.ScopePersonal:
+		return snippet.CreatorID == userID
+	default:
+		return a.pluginAPI.User.HasPermissionToTeam(userID, snippet.TeamID, model.PermissionViewTeam)
+	}
+}
+
+// getEditableSnippet returns the snippet of the URL if the user can change it: system admins change
+// organization snippets, creators their own snippets and team admins the snippets shared with their team.
+func (a *API) getEditableSnippet(c *gin.Context) (*snippets.Snippet, error) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	teamID := c.GetString(ContextTeamIDKey)
+
+	snippet, err := a.snippetStore.Get(c.Param("snippetid"))
+	if err != nil {
+		return nil, err
+	}
+	if snippet.Scope != snippets.ScopeOrganization && snippet.TeamID != teamID {
+		return nil, snippets.ErrSnippetNotFound
+	}
+	if !a.canUseSnippet(userID, snippet) {
+		return nil, snippets.ErrSnippetNotFound
+	}
+
+	var editable bool
+	switch snippet.Scope {
+	case snippets.ScopeOrganization:
+		editable = a.pluginAPI.User.HasPermissionTo(userID, model.PermissionManageSystem)
+	case snippets.ScopePersonal:
+		editable = snippet.CreatorID == userID
+	default:
+		editable = snippet.CreatorID == userID || a.pluginAPI.User.HasPermissionToTeam(userID, teamID, model.PermissionManageTeam)
+	}
+	if !editable {
+		return nil, errSnippetNotEditable
+	}
+
+	return snippet, nil
+}
+
+func (a *API) abortWithSnippetError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, snippets.ErrSnippetNotFound):
+		c.AbortWithError(http.StatusNotFound, err)
+	case errors.Is(err, snippets.ErrDuplicateSnippet):
+		c.AbortWithError(http.StatusConflict, err)
+	case errors.Is(err, snippets.ErrInvalidSnippet):
+		c.AbortWithError(http.StatusBadRequest, err)
+	case errors.Is(err, errSnippetNotEditable), errors.Is(err, errSnippetNotPublishable):
+		c.AbortWithError(http.StatusForbidden, err)
+	default:
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("snippet update failed: %w", err))
+	}
+}
+
+// handleSnippetDialog receives the interactive dialog filling in the variables of a snippet.
+// The filled in prompt is posted by the user in their direct message with the bot, which answers it.
+func (a *API) handleSnippetDialog(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	var request model.SubmitDialogRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if request.Cancelled {
+		c.Status(http.StatusOK)
+		return
+	}
+	if request.UserId != userID {
+		c.AbortWithError(http.StatusForbidden, errors.New("dialog was submitted by another user"))
+		return
+	}
+
+	snippet, err := a.snippetStore.Get(request.State)
+	if err != nil {
+		a.abortWithSnippetError(c, err)
+		return
+	}
+	if !a.canUseSnippet(userID, snippet) {
+		c.AbortWithError(http.StatusForbidden, errors.New("user doesn't have access to the snippet"))
+		return
+	}
+
+	channel, err := a.pluginAPI.Channel.Get(request.ChannelId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get channel: %w", err))
+		return
+	}
+	if a.bots.GetBotForDMChannel(channel) == nil || !a.pluginAPI.User.HasPermissionToChannel(userID, channel.Id, model.PermissionCreatePost) {
+		c.AbortWithError(http.StatusForbidden, errors.New("snippets can only be used in a direct message with a bot"))
+		return
+	}
+
+	values := make(map[string]string, len(request.Submission))
+	for variable, value := range request.Submission {
+		if text, ok := value.(string); ok {
+			values[variable] = text
+		}
+	}
+	if missing := snippet.Missing(values); len(missing) > 0 {
+		response := model.SubmitDialogResponse{Errors: map[string]string{}}
+		for _, variable := range missing {
+			response.Errors[variable] = "A value is required."
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	message, err := snippet.Render(values)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	post := &model.Post{
+		UserId:    userID,
+		ChannelId: channel.Id,
+		Message:   message,
+	}
+	post.AddProp(conversations.ActivateAIProp, true)
+	if err := a.pluginAPI.Post.CreatePost(post); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to post snippet: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SubmitDialogResponse{})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/metrics"
@@ -37,6 +38,14 @@ func (tc *testConfigImpl) GetDefaultBotName() string {
 	return "ai"
 }
 
+func (tc *testConfigImpl) EmbeddingSearchConfig() embeddings.EmbeddingSearchConfig {
+	return embeddings.EmbeddingSearchConfig{}
+}
+
+func (tc *testConfigImpl) GetEnableUserMemory() bool {
+	return false
+}
+
 func (e *TestEnvironment) Cleanup(t *testing.T) {
 	if e.mockAPI != nil {
 		e.mockAPI.AssertExpectations(t)
@@ -78,7 +87,7 @@ func SetupTestEnvironment(t *testing.T) *TestEnvironment {
 	// Create minimal conversations service for testing
 	conversationsService := &conversations.Conversations{}
 
-	api := New(testBots, conversationsService, nil, nil, nil, client, noopMetrics, nil, &testConfigImpl{}, nil, nil, nil, nil, nil)
+	api := New(testBots, conversationsService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, client, noopMetrics, nil, &testConfigImpl{}, nil, nil, nil, nil, nil)
 
 	return &TestEnvironment{
 		api:     api,
@@ -100,6 +109,9 @@ func TestPostRouter(t *testing.T) {
 		"summarize_transcription": "/post/postid/summarize_transcription",
 		"stop":                    "/post/postid/stop",
 		"regenerate":              "/post/postid/regenerate",
+		"resummarize":             "/post/postid/resummarize",
+		"explain_tool_call":       "/post/postid/tool_call/toolcallid/explain",
+		"postback_summary":        "/post/postid/postback_summary",
 	} {
 		for name, test := range map[string]struct {
 			request        *http.Request
@@ -276,10 +288,9 @@ func TestEmptyBodyCheckerInApi(t *testing.T) {
 		"react":                   "/post/postid/react?botUsername=thebot",
 		"transcribe file":         "/post/postid/transcribe/file/fileid?botUsername=thebot",
 		"summarize transcription": "/post/postid/summarize_transcription?botUsername=thebot",
-		"regen":                   "/post/postid/regenerate",
-		"postback summary":        "/post/postid/postback_summary",
 		"reindex":                 "/admin/reindex",
 		"cancel":                  "/admin/reindex/cancel",
+		"resume":                  "/admin/reindex/resume",
 	} {
 		t.Run(urlName, func(t *testing.T) {
 			e := SetupTestEnvironment(t)
@@ -303,6 +314,36 @@ func TestEmptyBodyCheckerInApi(t *testing.T) {
 	}
 }
 
+// TestOptionalBodyInApi tests the API endpoints taking an optional JSON body reject bodies that aren't JSON
+func TestOptionalBodyInApi(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+
+	for urlName, url := range map[string]string{
+		"regen":            "/post/postid/regenerate",
+		"postback summary": "/post/postid/postback_summary",
+	} {
+		t.Run(urlName, func(t *testing.T) {
+			e := SetupTestEnvironment(t)
+			defer e.Cleanup(t)
+
+			e.mockAPI.On("LogError", mock.Anything)
+			e.mockAPI.On("GetPost", mock.Anything).Return(&model.Post{}, nil).Maybe()
+			e.mockAPI.On("GetChannel", mock.Anything).Return(&model.Channel{}, nil).Maybe()
+			e.mockAPI.On("HasPermissionToChannel", mock.Anything, mock.Anything, model.PermissionReadChannel).Return(true).Maybe()
+
+			e.bots.SetBotsForTesting([]*bots.Bot{bots.NewBot(llm.BotConfig{Name: "thebot"}, nil)})
+
+			request := httptest.NewRequest(http.MethodPost, url, strings.NewReader("not json"))
+			request.Header.Add("Mattermost-User-ID", "userid")
+			recorder := httptest.NewRecorder()
+			e.api.ServeHTTP(&plugin.Context{}, recorder, request)
+			resp := recorder.Result()
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
 func TestChannelRouter(t *testing.T) {
 	// This just makes gin not output a whole bunch of debug stuff.
 	// maybe pipe this to the test log?
@@ -310,7 +351,8 @@ func TestChannelRouter(t *testing.T) {
 	gin.DefaultWriter = io.Discard
 
 	for urlName, url := range map[string]string{
-		"summarize since": "/channel/channelid/interval",
+		"summarize since":   "/channel/channelid/interval",
+		"interval estimate": "/channel/channelid/interval/estimate",
 	} {
 		for name, test := range map[string]struct {
 			request        *http.Request
@@ -368,3 +410,26 @@ func TestChannelRouter(t *testing.T) {
 		}
 	}
 }
+
+func TestToolApprovalPostRequired(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+
+	e := SetupTestEnvironment(t)
+	defer e.Cleanup(t)
+	e.api.licenseChecker = enterprise.NewLicenseChecker(e.api.pluginAPI)
+
+	e.mockAPI.On("GetConfig").Return(&model.Config{ServiceSettings: model.ServiceSettings{
+		EnableDeveloper: model.NewPointer(true),
+		EnableTesting:   model.NewPointer(true),
+	}})
+	e.mockAPI.On("GetLicense").Return(nil)
+	e.mockAPI.On("GetPost", "postid").Return(nil, model.NewAppError("GetPost", "app.post.get.app_error", nil, "", http.StatusNotFound))
+	e.mockAPI.On("LogError", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+
+	request := httptest.NewRequest(http.MethodGet, "/tool_approval/postid", nil)
+	request.Header.Add("Mattermost-User-ID", "userid")
+	recorder := httptest.NewRecorder()
+	e.api.ServeHTTP(&plugin.Context{}, recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Result().StatusCode)
+}
@@ -100,7 +100,8 @@ func (s *Provider) ChatCompletionNoStream(request llm.CompletionRequest, opts ..
 	if err != nil {
 		return "", err
 	}
-	return response.Message, nil
+	// ASage does not support stop sequences, so they are applied to the response.
+	return llm.TrimAtStopSequence(response.Message, request.StopSequences), nil
 }
 
 // TODO: Implement actual token counting. For now just estimated based off OpenAI estimations
@@ -0,0 +1,283 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package azurespeech transcribes recordings with the Azure AI Speech fast transcription API.
+package azurespeech
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+)
+
+const (
+	apiVersion = "2024-11-15"
+
+	// DefaultLocale is used when no locale is configured.
+	DefaultLocale = "en-US"
+
+	// DefaultMaxSpeakers is the maximum number of speakers told apart in conversation transcription mode.
+	DefaultMaxSpeakers = 10
+
+	// DefaultPricePerMinute is the list price of Azure speech to text in US dollars.
+	DefaultPricePerMinute = 1.0 / 60
+)
+
+// Config configures Azure Speech as the transcription backend.
+type Config struct {
+	Enabled bool   `json:"enabled"`
+	Region  string `json:"region"`
+	APIKey  string `json:"apiKey"`
+
+	// Endpoint overrides the regional endpoint, for example for sovereign clouds or private endpoints.
+	Endpoint string `json:"endpoint"`
+	Locale   string `json:"locale"`
+
+	// ConversationTranscription attributes the transcript to the individual speakers.
+	ConversationTranscription bool `json:"conversationTranscription"`
+	MaxSpeakers               int  `json:"maxSpeakers"`
+
+	// PricePerMinute in US dollars used for cost estimates, overriding the list price.
+	PricePerMinute float64 `json:"pricePerMinute"`
+}
+
+// IsValid reports whether the config has enough information to reach the service.
+func (c Config) IsValid() bool {
+	return c.APIKey != "" && (c.Region != "" || c.Endpoint != "")
+}
+
+// TranscriptionPrice returns the price per minute of audio transcribed.
+func (c Config) TranscriptionPrice() float64 {
+	if c.PricePerMinute > 0 {
+		return c.PricePerMinute
+	}
+	return DefaultPricePerMinute
+}
+
+type Transcriber struct {
+	config     Config
+	httpClient *http.Client
+	vocabulary []string
+}
+
+func New(config Config, httpClient *http.Client) *Transcriber {
+	return &Transcriber{
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+type transcriptionDefinition struct {
+	Locales     []string     `json:"locales"`
+	Diarization *diarization `json:"diarization,omitempty"`
+	PhraseList  *phraseList  `json:"phraseList,omitempty"`
+}
+
+type phraseList struct {
+	Phrases []string `json:"phrases"`
+}
+
+type diarization struct {
+	Enabled     bool `json:"enabled"`
+	MaxSpeakers int  `json:"maxSpeakers"`
+}
+
+type transcriptionResponse struct {
+	Phrases []phrase `json:"phrases"`
+}
+
+type phrase struct {
+	Locale               string `json:"locale"`
+	Speaker              int    `json:"speaker"`
+	OffsetMilliseconds   int64  `json:"offsetMilliseconds"`
+	DurationMilliseconds int64  `json:"durationMilliseconds"`
+	Text                 string `json:"text"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (t *Transcriber) endpoint() string {
+	base := strings.TrimSuffix(t.config.Endpoint, "/")
+	if base == "" {
+		base = fmt.Sprintf("https://%s.api.cognitive.microsoft.com", t.config.Region)
+	}
+	return base + "/speechtotext/transcriptions:transcribe?api-version=" + apiVersion
+}
+
+// languageLocales maps languages to the locale Azure Speech recognizes them in by default.
+var languageLocales = map[string]string{
+	"de": "de-DE",
+	"en": "en-US",
+	"es": "es-ES",
+	"fr": "fr-FR",
+	"it": "it-IT",
+	"ja": "ja-JP",
+	"ko": "ko-KR",
+	"nl": "nl-NL",
+	"pl": "pl-PL",
+	"pt": "pt-BR",
+	"ru": "ru-RU",
+	"sv": "sv-SE",
+	"zh": "zh-CN",
+}
+
+// locale returns the locale to transcribe the language in, falling back to the configured locale.
+// Languages can be given as ISO-639-1 codes such as "de" or as locales such as "de-CH".
+func (t *Transcriber) locale(language string) string {
+	if strings.Contains(language, "-") {
+		return language
+	}
+	if locale, ok := languageLocales[strings.ToLower(language)]; ok {
+		return locale
+	}
+	if t.config.Locale != "" {
+		return t.config.Locale
+	}
+	return DefaultLocale
+}
+
+func (t *Transcriber) definition(language string) transcriptionDefinition {
+	locale := t.locale(language)
+
+	definition := transcriptionDefinition{
+		Locales: []string{locale},
+	}
+	if t.config.ConversationTranscription {
+		maxSpeakers := t.config.MaxSpeakers
+		if maxSpeakers <= 0 {
+			maxSpeakers = DefaultMaxSpeakers
+		}
+		definition.Diarization = &diarization{
+			Enabled:     true,
+			MaxSpeakers: maxSpeakers,
+		}
+	}
+
+	if len(t.vocabulary) > 0 {
+		definition.PhraseList = &phraseList{
+			Phrases: t.vocabulary,
+		}
+	}
+
+	return definition
+}
+
+// SetVocabulary sets the terms likely to be said, such as product names and acronyms, which are
+// recognized in preference to similar sounding words.
+func (t *Transcriber) SetVocabulary(terms []string) {
+	t.vocabulary = terms
+}
+
+// Transcribe sends the audio to Azure Speech and returns the recognized phrases as subtitles.
+// The language selects the locale to recognize, the configured locale is used when it is empty.
+func (t *Transcriber) Transcribe(file io.Reader, language string) (*subtitles.Subtitles, error) {
+	if !t.config.IsValid() {
+		return nil, errors.New("azure speech is missing a key or region")
+	}
+
+	definition, err := json.Marshal(t.definition(language))
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal transcription definition: %w", err)
+	}
+
+	// The audio is streamed into the request body rather than buffered in memory
+	bodyReader, bodyWriter := io.Pipe()
+	form := multipart.NewWriter(bodyWriter)
+	go func() {
+		if err := form.WriteField("definition", string(definition)); err != nil {
+			bodyWriter.CloseWithError(err)
+			return
+		}
+		part, err := form.CreateFormFile("audio", "input.mp3")
+		if err != nil {
+			bodyWriter.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(part, file); err != nil {
+			bodyWriter.CloseWithError(err)
+			return
+		}
+		bodyWriter.CloseWithError(form.Close())
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint(), bodyReader)
+	if err != nil {
+		bodyReader.Close()
+		return nil, fmt.Errorf("unable to create transcription request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Ocp-Apim-Subscription-Key", t.config.APIKey)
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create azure speech transcription: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		if decodeErr := json.NewDecoder(resp.Body).Decode(&errResp); decodeErr == nil && errResp.Error.Message != "" {
+			return nil, fmt.Errorf("azure speech transcription failed with status %d: %s", resp.StatusCode, errResp.Error.Message)
+		}
+		return nil, fmt.Errorf("azure speech transcription failed with status %d", resp.StatusCode)
+	}
+
+	var result transcriptionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("unable to parse azure speech transcription: %w", err)
+	}
+
+	transcript := subtitles.NewSubtitlesFromSegments(phrasesToSegments(result.Phrases, t.config.ConversationTranscription))
+	transcript.SetLanguage(phrasesLocale(result.Phrases))
+	return transcript, nil
+}
+
+// phrasesLocale returns the locale most of the phrases were recognized in.
+func phrasesLocale(phrases []phrase) string {
+	counts := map[string]int{}
+	locale := ""
+	for _, p := range phrases {
+		if p.Locale == "" {
+			continue
+		}
+		counts[p.Locale]++
+		if counts[p.Locale] > counts[locale] {
+			locale = p.Locale
+		}
+	}
+	return locale
+}
+
+// phrasesToSegments converts recognized phrases to transcript segments, naming speakers when they were told apart.
+func phrasesToSegments(phrases []phrase, withSpeakers bool) []subtitles.Segment {
+	segments := make([]subtitles.Segment, 0, len(phrases))
+	for _, p := range phrases {
+		text := strings.TrimSpace(p.Text)
+		if text == "" {
+			continue
+		}
+
+		segment := subtitles.Segment{
+			StartMS: p.OffsetMilliseconds,
+			EndMS:   p.OffsetMilliseconds + p.DurationMilliseconds,
+			Text:    text,
+		}
+		if withSpeakers && p.Speaker > 0 {
+			segment.Speaker = fmt.Sprintf("Speaker %d", p.Speaker)
+		}
+		segments = append(segments, segment)
+	}
+
+	return segments
+}
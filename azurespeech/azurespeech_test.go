@@ -0,0 +1,125 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package azurespeech
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscribe(t *testing.T) {
+	tests := []struct {
+		name                      string
+		conversationTranscription bool
+		language                  string
+		vocabulary                []string
+		expectedDefinition        string
+		expected                  []subtitles.Segment
+	}{
+		{
+			name:               "plain transcription",
+			expectedDefinition: `{"locales":["de-DE"]}`,
+			expected: []subtitles.Segment{
+				{StartMS: 40, EndMS: 2000, Text: "Hello everyone."},
+				{StartMS: 2500, EndMS: 3500, Text: "Hi."},
+			},
+		},
+		{
+			name:                      "conversation transcription",
+			conversationTranscription: true,
+			expectedDefinition:        `{"locales":["de-DE"],"diarization":{"enabled":true,"maxSpeakers":10}}`,
+			expected: []subtitles.Segment{
+				{StartMS: 40, EndMS: 2000, Speaker: "Speaker 1", Text: "Hello everyone."},
+				{StartMS: 2500, EndMS: 3500, Speaker: "Speaker 2", Text: "Hi."},
+			},
+		},
+		{
+			name:               "requested language",
+			language:           "fr",
+			expectedDefinition: `{"locales":["fr-FR"]}`,
+			expected: []subtitles.Segment{
+				{StartMS: 40, EndMS: 2000, Text: "Hello everyone."},
+				{StartMS: 2500, EndMS: 3500, Text: "Hi."},
+			},
+		},
+		{
+			name:               "vocabulary",
+			vocabulary:         []string{"Mattermost", "MMCTL"},
+			expectedDefinition: `{"locales":["de-DE"],"phraseList":{"phrases":["Mattermost","MMCTL"]}}`,
+			expected: []subtitles.Segment{
+				{StartMS: 40, EndMS: 2000, Text: "Hello everyone."},
+				{StartMS: 2500, EndMS: 3500, Text: "Hi."},
+			},
+		},
+		{
+			name:               "requested locale",
+			language:           "de-CH",
+			expectedDefinition: `{"locales":["de-CH"]}`,
+			expected: []subtitles.Segment{
+				{StartMS: 40, EndMS: 2000, Text: "Hello everyone."},
+				{StartMS: 2500, EndMS: 3500, Text: "Hi."},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/speechtotext/transcriptions:transcribe", r.URL.Path)
+				assert.Equal(t, apiVersion, r.URL.Query().Get("api-version"))
+				assert.Equal(t, "thekey", r.Header.Get("Ocp-Apim-Subscription-Key"))
+
+				assert.Equal(t, tc.expectedDefinition, r.FormValue("definition"))
+				audio, _, err := r.FormFile("audio")
+				if assert.NoError(t, err) {
+					data, _ := io.ReadAll(audio)
+					assert.Equal(t, "audio data", string(data))
+				}
+
+				_ = json.NewEncoder(w).Encode(map[string]any{
+					"phrases": []map[string]any{
+						{"speaker": 1, "locale": "de-DE", "offsetMilliseconds": 40, "durationMilliseconds": 1960, "text": "Hello everyone."},
+						{"speaker": 2, "offsetMilliseconds": 2000, "durationMilliseconds": 200, "text": " "},
+						{"speaker": 2, "locale": "de-DE", "offsetMilliseconds": 2500, "durationMilliseconds": 1000, "text": "Hi."},
+					},
+				})
+			}))
+			defer server.Close()
+
+			transcriber := New(Config{
+				APIKey:                    "thekey",
+				Endpoint:                  server.URL + "/",
+				Locale:                    "de-DE",
+				ConversationTranscription: tc.conversationTranscription,
+			}, server.Client())
+			transcriber.SetVocabulary(tc.vocabulary)
+
+			result, err := transcriber.Transcribe(strings.NewReader("audio data"), tc.language)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result.Segments())
+			assert.Equal(t, "de-DE", result.Language())
+		})
+	}
+}
+
+func TestTranscribeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"code":"InvalidKey","message":"The key is invalid"}}`))
+	}))
+	defer server.Close()
+
+	transcriber := New(Config{APIKey: "wrong", Endpoint: server.URL}, server.Client())
+	_, err := transcriber.Transcribe(strings.NewReader("audio data"), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "The key is invalid")
+}
@@ -32,3 +32,8 @@ func (b *Bot) GetMMBot() *model.Bot {
 func (b *Bot) LLM() llm.LanguageModel {
 	return b.llm
 }
+
+// SetLLMForTesting sets the language model of the bot directly for testing purposes only
+func (b *Bot) SetLLMForTesting(languageModel llm.LanguageModel) {
+	b.llm = languageModel
+}
@@ -11,12 +11,15 @@ import (
 
 	"github.com/mattermost/mattermost-plugin-ai/anthropic"
 	"github.com/mattermost/mattermost-plugin-ai/asage"
+	"github.com/mattermost/mattermost-plugin-ai/azurespeech"
 	"github.com/mattermost/mattermost-plugin-ai/config"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost-plugin-ai/openai"
+	"github.com/mattermost/mattermost-plugin-ai/regions"
 	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost-plugin-ai/vllm"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
 	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
@@ -26,11 +29,27 @@ type Config interface {
 	GetDefaultBotName() string
 	EnableLLMLogging() bool
 	GetTranscriptGenerator() string
+	GetAzureSpeechConfig() azurespeech.Config
+	GetTranscriptionLanguage(channelID string) string
+	GetTranscriptionVocabulary(channelID, teamID string) []string
 }
 
 // Transcriber interface defines the contract for transcription services
 type Transcriber interface {
-	Transcribe(file io.Reader) (*subtitles.Subtitles, error)
+	// Transcribe transcribes the audio in the language given as an ISO-639-1 code such as "en",
+	// or detects the language when it is empty. The detected language is set on the result.
+	Transcribe(file io.Reader, language string) (*subtitles.Subtitles, error)
+}
+
+// VocabularyTranscriber is implemented by transcription services that can be told the terms likely to be said,
+// such as product names and acronyms, so they are spelled the way the organization does.
+type VocabularyTranscriber interface {
+	SetVocabulary(terms []string)
+}
+
+// EnglishTranscriber is implemented by transcription services that can transcribe audio in any language into English.
+type EnglishTranscriber interface {
+	TranscribeToEnglish(file io.Reader) (*subtitles.Subtitles, error)
 }
 
 type MMBots struct {
@@ -39,19 +58,47 @@ type MMBots struct {
 	licenseChecker         *enterprise.LicenseChecker
 	config                 Config
 	llmUpstreamHTTPClient  *http.Client
+	middlewares            *llm.MiddlewareRegistry
 
 	botsLock sync.RWMutex
 	bots     []*Bot
 }
 
 func New(mutexPluginAPI cluster.MutexPluginAPI, pluginAPI *pluginapi.Client, licenseChecker *enterprise.LicenseChecker, config Config, llmUpstreamHTTPClient *http.Client) *MMBots {
-	return &MMBots{
+	b := &MMBots{
 		ensureBotsClusterMutex: mutexPluginAPI,
 		pluginAPI:              pluginAPI,
 		licenseChecker:         licenseChecker,
 		config:                 config,
 		llmUpstreamHTTPClient:  llmUpstreamHTTPClient,
+		middlewares:            llm.NewMiddlewareRegistry(),
 	}
+
+	// Capability checks
+	b.middlewares.Register("capabilities", llm.MiddlewarePriorityCapabilities, func(botConfig llm.BotConfig) llm.Middleware {
+		return llm.CapabilitiesMiddleware(botConfig.Service.Type, botConfig.Service.DefaultModel)
+	})
+
+	// Tool call previews for approval
+	b.middlewares.Register("tool_preview", llm.MiddlewarePriorityToolPreview, func(_ llm.BotConfig) llm.Middleware {
+		return llm.ToolPreviewMiddleware()
+	})
+
+	// Logging
+	b.middlewares.Register("logging", llm.MiddlewarePriorityLogging, func(_ llm.BotConfig) llm.Middleware {
+		if !b.config.EnableLLMLogging() {
+			return nil
+		}
+		return llm.LoggingMiddleware(b.pluginAPI.Log)
+	})
+
+	return b
+}
+
+// Middlewares returns the registry used to wrap every bot's language model.
+// Changes take effect the next time the bots cache is updated.
+func (b *MMBots) Middlewares() *llm.MiddlewareRegistry {
+	return b.middlewares
 }
 
 func (b *MMBots) EnsureBots(cfgBots []llm.BotConfig) error {
@@ -75,6 +122,14 @@ func (b *MMBots) EnsureBots(cfgBots []llm.BotConfig) error {
 
 	aiBotConfigsByUsername := make(map[string]llm.BotConfig)
 	for _, bot := range cfgBots {
+		if err := bot.Service.ValidateModelAliases(); err != nil {
+			b.pluginAPI.Log.Error("Configured bot has invalid model aliases", "bot_name", bot.Name, "error", err)
+			continue
+		}
+		if err := bot.Service.ValidateRegions(); err != nil {
+			b.pluginAPI.Log.Error("Configured bot has invalid regions", "bot_name", bot.Name, "error", err)
+			continue
+		}
 		if !bot.IsValid() {
 			b.pluginAPI.Log.Error("Configured bot is not valid", "bot_name", bot.Name, "bot_display_name", bot.DisplayName)
 			continue
@@ -159,41 +214,58 @@ func (b *MMBots) UpdateBotsCache(cfgBots []llm.BotConfig) error {
 	}
 
 	for _, bot := range b.bots {
-		bot.llm = b.getLLM(bot.cfg.Service)
+		bot.llm = b.getLLM(bot.cfg)
 	}
 
 	return nil
 }
 
-func (b *MMBots) getLLM(serviceConfig llm.ServiceConfig) llm.LanguageModel {
-	// Create the correct model
+func (b *MMBots) getLLM(botConfig llm.BotConfig) llm.LanguageModel {
 	var result llm.LanguageModel
+	services := botConfig.Service.RegionServices()
+	if len(services) == 1 {
+		result = b.newLanguageModel(services[0].Service)
+	} else {
+		// Each region is reached like a service of its own and the router picks between them
+		routed := make([]regions.Region, 0, len(services))
+		for _, service := range services {
+			routed = append(routed, regions.Region{
+				Name:  service.Name,
+				Model: b.newLanguageModel(service.Service),
+			})
+		}
+		result = regions.New(botConfig.Service.RegionRouting, routed)
+	}
+
+	return b.middlewares.Build(botConfig)(result)
+}
+
+// newLanguageModel creates the model of the service, or of one of its regions.
+func (b *MMBots) newLanguageModel(serviceConfig llm.ServiceConfig) llm.LanguageModel {
 	switch serviceConfig.Type {
 	case llm.ServiceTypeOpenAI:
-		result = openai.New(config.OpenAIConfigFromServiceConfig(serviceConfig), b.llmUpstreamHTTPClient)
+		return openai.New(config.OpenAIConfigFromServiceConfig(serviceConfig), b.llmUpstreamHTTPClient)
 	case llm.ServiceTypeOpenAICompatible:
-		result = openai.NewCompatible(config.OpenAIConfigFromServiceConfig(serviceConfig), b.llmUpstreamHTTPClient)
+		return openai.NewCompatible(config.OpenAIConfigFromServiceConfig(serviceConfig), b.llmUpstreamHTTPClient)
 	case llm.ServiceTypeAzure:
-		result = openai.NewAzure(config.OpenAIConfigFromServiceConfig(serviceConfig), b.llmUpstreamHTTPClient)
+		return openai.NewAzure(config.OpenAIConfigFromServiceConfig(serviceConfig), b.llmUpstreamHTTPClient)
+	case llm.ServiceTypeVLLM:
+		return vllm.New(config.VLLMConfigFromServiceConfig(serviceConfig), b.llmUpstreamHTTPClient)
 	case llm.ServiceTypeAnthropic:
-		result = anthropic.New(serviceConfig, b.llmUpstreamHTTPClient)
+		return anthropic.New(serviceConfig, b.llmUpstreamHTTPClient)
 	case llm.ServiceTypeASage:
-		result = asage.New(serviceConfig, b.llmUpstreamHTTPClient)
-	}
-
-	// Truncation Support
-	result = llm.NewLLMTruncationWrapper(result)
-
-	// Logging
-	if b.config.EnableLLMLogging() {
-		result = llm.NewLanguageModelLogWrapper(b.pluginAPI.Log, result)
+		return asage.New(serviceConfig, b.llmUpstreamHTTPClient)
 	}
-
-	return result
+	return nil
 }
 
 // TODO: This really doesn't belong here. Figure out where to put this.
 func (b *MMBots) GetTranscribe() Transcriber {
+	// Azure Speech replaces the transcript generator bot when enabled
+	if azureSpeechConfig := b.config.GetAzureSpeechConfig(); azureSpeechConfig.Enabled {
+		return azurespeech.New(azureSpeechConfig, b.llmUpstreamHTTPClient)
+	}
+
 	// Get the configured transcript generator bot
 	bot := b.getTrasncriberBot()
 	if bot == nil {
@@ -201,7 +273,7 @@ func (b *MMBots) GetTranscribe() Transcriber {
 		return nil
 	}
 
-	service := bot.GetConfig().Service
+	service := bot.GetConfig().Service.PrimaryService()
 	switch service.Type {
 	case llm.ServiceTypeOpenAI:
 		return openai.New(config.OpenAIConfigFromServiceConfig(service), b.llmUpstreamHTTPClient)
@@ -217,6 +289,28 @@ func (b *MMBots) GetTranscribe() Transcriber {
 	}
 }
 
+// TranscriptionPrice returns the price per minute of audio transcribed by the configured transcription backend.
+func (b *MMBots) TranscriptionPrice() float64 {
+	if azureSpeechConfig := b.config.GetAzureSpeechConfig(); azureSpeechConfig.Enabled {
+		return azureSpeechConfig.TranscriptionPrice()
+	}
+	if bot := b.getTrasncriberBot(); bot != nil {
+		return bot.GetConfig().Service.TranscriptionPrice()
+	}
+	return llm.DefaultTranscriptionPricePerMinute
+}
+
+// TranscriptionLanguage returns the configured language of recordings transcribed in the channel,
+// empty when the language should be detected.
+func (b *MMBots) TranscriptionLanguage(channelID string) string {
+	return b.config.GetTranscriptionLanguage(channelID)
+}
+
+// TranscriptionVocabulary returns the terms recordings transcribed in the channel of the team are likely to contain.
+func (b *MMBots) TranscriptionVocabulary(channelID, teamID string) []string {
+	return b.config.GetTranscriptionVocabulary(channelID, teamID)
+}
+
 func (b *MMBots) getTrasncriberBot() *Bot {
 	b.botsLock.RLock()
 	defer b.botsLock.RUnlock()
@@ -307,18 +401,19 @@ func (b *MMBots) IsAnyBot(userID string) bool {
 	return false
 }
 
-// GetBotMentioned returns the bot mentioned in the text, if any.
-func (b *MMBots) GetBotMentioned(text string) *Bot {
+// GetBotsMentioned returns the bots mentioned in the text, so several bots can be asked the same question.
+func (b *MMBots) GetBotsMentioned(text string) []*Bot {
 	b.botsLock.RLock()
 	defer b.botsLock.RUnlock()
 
+	var mentioned []*Bot
 	for _, bot := range b.bots {
 		if userIsMentionedMarkdown(text, bot.mmBot.Username) {
-			return bot
+			mentioned = append(mentioned, bot)
 		}
 	}
 
-	return nil
+	return mentioned
 }
 
 // GetAllBots returns all bots
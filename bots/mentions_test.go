@@ -6,6 +6,8 @@ package bots
 import (
 	"testing"
 
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/stretchr/testify/require"
 )
 
@@ -78,3 +80,24 @@ func TestUserIsMentionedMarkdown(t *testing.T) {
 		})
 	}
 }
+
+func TestGetBotsMentioned(t *testing.T) {
+	mmBots := &MMBots{}
+	mmBots.SetBotsForTesting([]*Bot{
+		NewBot(llm.BotConfig{Name: "coding"}, &model.Bot{UserId: "codingid", Username: "coding"}),
+		NewBot(llm.BotConfig{Name: "legal"}, &model.Bot{UserId: "legalid", Username: "legal"}),
+		NewBot(llm.BotConfig{Name: "hr"}, &model.Bot{UserId: "hrid", Username: "hr"}),
+	})
+
+	usernames := func(bots []*Bot) []string {
+		var names []string
+		for _, bot := range bots {
+			names = append(names, bot.GetMMBot().Username)
+		}
+		return names
+	}
+
+	require.Equal(t, []string{"coding", "legal"}, usernames(mmBots.GetBotsMentioned("@legal and @coding, can we ship this?")))
+	require.Equal(t, []string{"hr"}, usernames(mmBots.GetBotsMentioned("Hello @hr")))
+	require.Empty(t, mmBots.GetBotsMentioned("`@legal` is not a mention"))
+}
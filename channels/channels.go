@@ -5,6 +5,7 @@ package channels
 
 import (
 	"slices"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-ai/format"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
@@ -38,6 +39,47 @@ func (c *Channels) Interval(
 	endTime int64,
 	promptName string,
 ) (*llm.TextStreamResult, error) {
+	completionRequest, err := c.intervalRequest(context, channelID, startTime, endTime, promptName)
+	if err != nil {
+		return nil, err
+	}
+
+	resultStream, err := c.llm.ChatCompletion(completionRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return resultStream, nil
+}
+
+// EstimateInterval returns the number of input tokens Interval would send to the model.
+func (c *Channels) EstimateInterval(
+	context *llm.Context,
+	channelID string,
+	startTime int64,
+	endTime int64,
+	promptName string,
+) (int, error) {
+	completionRequest, err := c.intervalRequest(context, channelID, startTime, endTime, promptName)
+	if err != nil {
+		return 0, err
+	}
+
+	tokens := 0
+	for _, post := range completionRequest.Posts {
+		tokens += c.llm.CountTokens(post.Message)
+	}
+
+	return tokens, nil
+}
+
+func (c *Channels) intervalRequest(
+	context *llm.Context,
+	channelID string,
+	startTime int64,
+	endTime int64,
+	promptName string,
+) (llm.CompletionRequest, error) {
 	var posts *model.PostList
 	var err error
 	if endTime == 0 {
@@ -46,12 +88,12 @@ func (c *Channels) Interval(
 		posts, err = c.getPostsByChannelBetween(channelID, startTime, endTime)
 	}
 	if err != nil {
-		return nil, err
+		return llm.CompletionRequest{}, err
 	}
 
 	threadData, err := mmapi.GetMetadataForPosts(c.client, posts)
 	if err != nil {
-		return nil, err
+		return llm.CompletionRequest{}, err
 	}
 
 	// Remove deleted posts
@@ -62,19 +104,20 @@ func (c *Channels) Interval(
 	formattedThread := format.ThreadData(threadData)
 
 	context.Parameters = map[string]any{
-		"Thread": formattedThread,
+		"Thread":    formattedThread,
+		"RangeSize": TimeRange{Start: startTime, End: endTime}.Size(time.Now()),
 	}
 	systemPrompt, err := c.prompts.Format(promptName, context)
 	if err != nil {
-		return nil, err
+		return llm.CompletionRequest{}, err
 	}
 
 	userPrompt, err := c.prompts.Format(prompts.PromptThreadUser, context)
 	if err != nil {
-		return nil, err
+		return llm.CompletionRequest{}, err
 	}
 
-	completionRequest := llm.CompletionRequest{
+	return llm.CompletionRequest{
 		Posts: []llm.Post{
 			{
 				Role:    llm.PostRoleSystem,
@@ -86,14 +129,7 @@ func (c *Channels) Interval(
 			},
 		},
 		Context: context,
-	}
-
-	resultStream, err := c.llm.ChatCompletion(completionRequest)
-	if err != nil {
-		return nil, err
-	}
-
-	return resultStream, nil
+	}, nil
 }
 
 const (
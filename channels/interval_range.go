@@ -0,0 +1,121 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package channels
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Named ranges accepted by the interval endpoint in place of explicit timestamps.
+const (
+	RangeSinceLastVisit = "since_last_visit"
+	RangeToday          = "today"
+	RangeThisWeek       = "this_week"
+	RangeSincePost      = "since_post"
+)
+
+// MaxIntervalDuration is the longest period of a channel that can be summarized at once.
+const MaxIntervalDuration = 14 * 24 * time.Hour
+
+// Range sizes used to tune the summarization prompts.
+const (
+	RangeSizeShort = "short"
+	RangeSizeDay   = "day"
+	RangeSizeLong  = "long"
+)
+
+// TimeRange is a period of a channel in milliseconds since the epoch.
+// An End of 0 means until the present.
+type TimeRange struct {
+	Start int64
+	End   int64
+}
+
+// RangeInputs holds what is needed to resolve named ranges.
+type RangeInputs struct {
+	Now               time.Time
+	Location          *time.Location
+	LastViewedAt      int64
+	SincePostCreateAt int64
+}
+
+// ResolveNamedRange converts a named range into a TimeRange.
+func ResolveNamedRange(name string, inputs RangeInputs) (TimeRange, error) {
+	now := inputs.Now
+	if inputs.Location != nil {
+		now = now.In(inputs.Location)
+	}
+
+	switch name {
+	case RangeSinceLastVisit:
+		if inputs.LastViewedAt == 0 {
+			return TimeRange{}, errors.New("channel has never been viewed")
+		}
+		return TimeRange{Start: inputs.LastViewedAt}, nil
+	case RangeToday:
+		return TimeRange{Start: startOfDay(now).UnixMilli()}, nil
+	case RangeThisWeek:
+		// Weeks start on Monday
+		daysSinceMonday := (int(now.Weekday()) + 6) % 7
+		return TimeRange{Start: startOfDay(now).AddDate(0, 0, -daysSinceMonday).UnixMilli()}, nil
+	case RangeSincePost:
+		if inputs.SincePostCreateAt == 0 {
+			return TimeRange{}, errors.New("post_id is required for the since_post range")
+		}
+		return TimeRange{Start: inputs.SincePostCreateAt}, nil
+	}
+
+	return TimeRange{}, fmt.Errorf("invalid range %q", name)
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// Validate checks the range starts in the past and is ordered.
+// Ranges with an explicit end can't be longer than MaxIntervalDuration.
+func (r TimeRange) Validate(now time.Time) error {
+	if r.Start < 0 || r.End < 0 {
+		return errors.New("start_time and end_time can't be negative")
+	}
+
+	if r.Start > now.UnixMilli() {
+		return errors.New("start_time must be in the past")
+	}
+
+	if r.End != 0 && r.Start >= r.End {
+		return errors.New("start_time must be before end_time")
+	}
+
+	if r.End != 0 && r.Duration(now) > MaxIntervalDuration {
+		return fmt.Errorf("date range cannot exceed %d days", int(MaxIntervalDuration.Hours()/24))
+	}
+
+	return nil
+}
+
+// Duration returns the length of the range, using now for open ended ranges.
+func (r TimeRange) Duration(now time.Time) time.Duration {
+	end := r.End
+	if end == 0 {
+		end = now.UnixMilli()
+	}
+	return time.Duration(end-r.Start) * time.Millisecond
+}
+
+// Size classifies the range so prompts can adjust the level of detail.
+func (r TimeRange) Size(now time.Time) string {
+	duration := r.Duration(now)
+	switch {
+	case duration <= 4*time.Hour:
+		return RangeSizeShort
+	case duration <= 36*time.Hour:
+		return RangeSizeDay
+	default:
+		return RangeSizeLong
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package channels
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveNamedRange(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// Thursday 2025-01-16 15:30 in New York
+	now := time.Date(2025, time.January, 16, 20, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		rangeName string
+		inputs    RangeInputs
+		want      TimeRange
+		wantErr   bool
+	}{
+		{
+			name:      "today uses the user's timezone",
+			rangeName: RangeToday,
+			inputs:    RangeInputs{Now: now, Location: newYork},
+			want:      TimeRange{Start: time.Date(2025, time.January, 16, 0, 0, 0, 0, newYork).UnixMilli()},
+		},
+		{
+			name:      "this week starts on monday",
+			rangeName: RangeThisWeek,
+			inputs:    RangeInputs{Now: now, Location: newYork},
+			want:      TimeRange{Start: time.Date(2025, time.January, 13, 0, 0, 0, 0, newYork).UnixMilli()},
+		},
+		{
+			name:      "this week on a sunday",
+			rangeName: RangeThisWeek,
+			inputs:    RangeInputs{Now: time.Date(2025, time.January, 19, 12, 0, 0, 0, time.UTC)},
+			want:      TimeRange{Start: time.Date(2025, time.January, 13, 0, 0, 0, 0, time.UTC).UnixMilli()},
+		},
+		{
+			name:      "since last visit",
+			rangeName: RangeSinceLastVisit,
+			inputs:    RangeInputs{Now: now, LastViewedAt: 1000},
+			want:      TimeRange{Start: 1000},
+		},
+		{
+			name:      "since last visit without a visit",
+			rangeName: RangeSinceLastVisit,
+			inputs:    RangeInputs{Now: now},
+			wantErr:   true,
+		},
+		{
+			name:      "since post",
+			rangeName: RangeSincePost,
+			inputs:    RangeInputs{Now: now, SincePostCreateAt: 2000},
+			want:      TimeRange{Start: 2000},
+		},
+		{
+			name:      "since post without a post",
+			rangeName: RangeSincePost,
+			inputs:    RangeInputs{Now: now},
+			wantErr:   true,
+		},
+		{
+			name:      "unknown range",
+			rangeName: "yesterday",
+			inputs:    RangeInputs{Now: now},
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ResolveNamedRange(tc.rangeName, tc.inputs)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestTimeRangeValidate(t *testing.T) {
+	now := time.Date(2025, time.January, 16, 12, 0, 0, 0, time.UTC)
+	hourAgo := now.Add(-time.Hour).UnixMilli()
+
+	tests := []struct {
+		name      string
+		timeRange TimeRange
+		wantErr   bool
+		wantSize  string
+	}{
+		{
+			name:      "open ended range",
+			timeRange: TimeRange{Start: hourAgo},
+			wantSize:  RangeSizeShort,
+		},
+		{
+			name:      "explicit day range",
+			timeRange: TimeRange{Start: now.Add(-30 * time.Hour).UnixMilli(), End: hourAgo},
+			wantSize:  RangeSizeDay,
+		},
+		{
+			name:      "open ended ranges aren't capped",
+			timeRange: TimeRange{Start: now.Add(-30 * 24 * time.Hour).UnixMilli()},
+			wantSize:  RangeSizeLong,
+		},
+		{
+			name:      "explicit range over the maximum",
+			timeRange: TimeRange{Start: now.Add(-15 * 24 * time.Hour).UnixMilli(), End: hourAgo},
+			wantErr:   true,
+		},
+		{
+			name:      "start after end",
+			timeRange: TimeRange{Start: hourAgo, End: hourAgo - 1},
+			wantErr:   true,
+		},
+		{
+			name:      "start in the future",
+			timeRange: TimeRange{Start: now.Add(time.Hour).UnixMilli()},
+			wantErr:   true,
+		},
+		{
+			name:      "negative start",
+			timeRange: TimeRange{Start: -1},
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.timeRange.Validate(now)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantSize, tc.timeRange.Size(now))
+		})
+	}
+}
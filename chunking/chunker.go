@@ -22,12 +22,28 @@ type Chunk struct {
 	ChunkInfo
 }
 
+// Chunking strategies
+const (
+	StrategySentences  = "sentences"
+	StrategyParagraphs = "paragraphs"
+	StrategyFixed      = "fixed"
+
+	// StrategyThreads indexes the posts of a thread together, as one document with a post per
+	// paragraph. It is split between posts, so consecutive posts of a thread share a chunk.
+	StrategyThreads = "threads"
+)
+
 // Options defines options for chunking documents
 type Options struct {
 	ChunkSize        int     `json:"chunkSize"`        // Maximum size of each chunk in characters
 	ChunkOverlap     int     `json:"chunkOverlap"`     // Number of characters to overlap between chunks
 	MinChunkSize     float64 `json:"minChunkSize"`     // Minimum chunk size as a fraction of max size (0.0-1.0)
-	ChunkingStrategy string  `json:"chunkingStrategy"` // Strategy: sentences, paragraphs, or fixed
+	ChunkingStrategy string  `json:"chunkingStrategy"` // Strategy: sentences, paragraphs, fixed or threads
+}
+
+// GroupsThreads returns true when the posts of a thread are indexed together
+func (o Options) GroupsThreads() bool {
+	return o.ChunkingStrategy == StrategyThreads
 }
 
 // DefaultOptions returns the default chunking options
@@ -36,7 +52,7 @@ func DefaultOptions() Options {
 		ChunkSize:        1000,
 		ChunkOverlap:     200,
 		MinChunkSize:     0.75,
-		ChunkingStrategy: "sentences",
+		ChunkingStrategy: StrategySentences,
 	}
 }
 
@@ -71,15 +87,16 @@ func ChunkText(content string, opts Options) []Chunk {
 	var err error
 
 	switch opts.ChunkingStrategy {
-	case "paragraphs":
-		// For paragraphs, use RecursiveCharacter with "\n\n" as first separator
+	case StrategyParagraphs, StrategyThreads:
+		// For paragraphs, use RecursiveCharacter with "\n\n" as first separator. Threads have a post
+		// per paragraph, so they are split between posts first.
 		splitter := textsplitter.NewRecursiveCharacter(
 			textsplitter.WithChunkSize(opts.ChunkSize),
 			textsplitter.WithChunkOverlap(opts.ChunkOverlap),
 			textsplitter.WithSeparators([]string{"\n\n", "\n", " ", ""}),
 		)
 		textChunks, err = splitter.SplitText(content)
-	case "fixed":
+	case StrategyFixed:
 		// For fixed chunks, use RecursiveCharacter with just space and empty string as separators
 		splitter := textsplitter.NewRecursiveCharacter(
 			textsplitter.WithChunkSize(opts.ChunkSize),
@@ -73,6 +73,23 @@ func TestChunkText(t *testing.T) {
 		}
 	})
 
+	t.Run("threads strategy", func(t *testing.T) {
+		content := "Is the release ready?\n\nAlmost, one test fails.\n\nWhich one?\n\nThe upgrade test, fixing it now."
+		opts := Options{
+			ChunkSize:        50,
+			ChunkingStrategy: StrategyThreads,
+		}
+
+		chunks := ChunkText(content, opts)
+		require.Len(t, chunks, 2)
+
+		// Consecutive posts are kept together, and never split while they fit
+		assert.Equal(t, "Is the release ready?\n\nAlmost, one test fails.", chunks[0].Content)
+		assert.Equal(t, "Which one?\n\nThe upgrade test, fixing it now.", chunks[1].Content)
+		assert.True(t, opts.GroupsThreads())
+		assert.False(t, DefaultOptions().GroupsThreads())
+	})
+
 	t.Run("fixed strategy", func(t *testing.T) {
 		content := "This is a long text that should be split into fixed-size chunks without regard to sentence boundaries."
 		opts := Options{
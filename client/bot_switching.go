@@ -0,0 +1,29 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// GetThreadBot returns the username of the bot answering the direct message conversation the post
+// belongs to.
+func (c *Client) GetThreadBot(ctx context.Context, postID string) (string, error) {
+	var response struct {
+		BotUsername string `json:"botUsername"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/post/"+postID+"/bot", nil, nil, &response); err != nil {
+		return "", err
+	}
+	return response.BotUsername, nil
+}
+
+// SetThreadBot switches the direct message conversation the post belongs to to the bot, whose model
+// answers the following requests with the conversation so far. An empty username goes back to the bot
+// the conversation is with.
+func (c *Client) SetThreadBot(ctx context.Context, postID, botUsername string) error {
+	request := map[string]string{"botUsername": botUsername}
+	return c.do(ctx, http.MethodPut, "/post/"+postID+"/bot", nil, request, nil)
+}
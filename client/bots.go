@@ -0,0 +1,37 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Bot is an AI bot the user can use.
+type Bot struct {
+	ID             string `json:"id"`
+	DisplayName    string `json:"displayName"`
+	Username       string `json:"username"`
+	LastIconUpdate int64  `json:"lastIconUpdate"`
+
+	// DMChannelID is the direct message channel between the user and the bot
+	DMChannelID    string `json:"dmChannelID"`
+	HandoffEnabled bool   `json:"handoffEnabled"`
+}
+
+// BotsResponse lists the bots available to the user, the default bot first.
+type BotsResponse struct {
+	Bots          []Bot `json:"bots"`
+	SearchEnabled bool  `json:"searchEnabled"`
+	MemoryEnabled bool  `json:"memoryEnabled"`
+}
+
+// GetBots returns the bots the user can use.
+func (c *Client) GetBots(ctx context.Context) (*BotsResponse, error) {
+	var response BotsResponse
+	if err := c.do(ctx, http.MethodGet, "/ai_bots", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
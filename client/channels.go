@@ -0,0 +1,42 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// PrivateAskResponse is the ephemeral post the answer to a private question is written to. It is only
+// shown to the user while they are connected, and isn't saved.
+type PrivateAskResponse struct {
+	PostID    string `json:"postid"`
+	ChannelID string `json:"channelid"`
+}
+
+// PrivateAsk asks the bot, the default bot when botUsername is empty, a question in the channel. The
+// question and the answer are only shown to the user, in an ephemeral post. When rootID is set the question
+// is about that thread of the channel.
+func (c *Client) PrivateAsk(ctx context.Context, channelID, rootID, question, botUsername string) (*PrivateAskResponse, error) {
+	request := map[string]string{"question": question, "root_id": rootID}
+	var response PrivateAskResponse
+	if err := c.do(ctx, http.MethodPost, "/channel/"+channelID+"/ask", botQuery(botUsername), request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ComposeSuggestions returns short continuations of the draft of a post in the channel, suggested by the
+// bot, the default bot when botUsername is empty. When rootID is set the draft is a reply in that thread.
+// Suggestions that take too long to generate fail with a 504 *APIError.
+func (c *Client) ComposeSuggestions(ctx context.Context, channelID, rootID, draft, botUsername string) ([]string, error) {
+	request := map[string]string{"draft": draft, "root_id": rootID}
+	var response struct {
+		Suggestions []string `json:"suggestions"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/channel/"+channelID+"/compose", botQuery(botUsername), request, &response); err != nil {
+		return nil, err
+	}
+	return response.Suggestions, nil
+}
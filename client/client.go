@@ -0,0 +1,163 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package client provides a typed client for the REST API of the Mattermost AI plugin, for bots and
+// integrations calling the Mattermost server, and for other plugins calling through the inter-plugin API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// PluginID is the ID the AI plugin is installed with
+	PluginID = "mattermost-ai"
+
+	// maxErrorBodyLength limits how much of an error response is kept in an APIError
+	maxErrorBodyLength = 1024
+)
+
+// ErrNotInterPlugin is returned when calling an inter-plugin only API from a client created with New.
+var ErrNotInterPlugin = errors.New("only available to plugins")
+
+// APIError is returned when the plugin answers a request with an error status.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// PluginAPI is the part of the plugin API used to call the AI plugin from another plugin.
+type PluginAPI interface {
+	PluginHTTP(*http.Request) *http.Response
+}
+
+// Client calls the REST API of the AI plugin.
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	authorize   func(r *http.Request)
+	interPlugin bool
+
+	// userID is the user of a client created with NewFromPlugin
+	userID string
+}
+
+// Option configures a client created with New.
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with the HTTP client, to set timeouts or proxies.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// New creates a client calling the plugin on the Mattermost server at siteURL, authenticated with a
+// personal access token or a bot token. Requests are made as the user or bot owning the token.
+func New(siteURL, token string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(siteURL, "/") + "/plugins/" + PluginID,
+		httpClient: http.DefaultClient,
+		authorize: func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+token)
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewFromPlugin creates a client calling the plugin from another plugin through the inter-plugin API.
+// Requests are made as the user with userID, which the calling plugin is responsible for checking.
+func NewFromPlugin(api PluginAPI, userID string) *Client {
+	return &Client{
+		baseURL:    "/" + PluginID,
+		httpClient: &http.Client{Transport: &pluginAPIRoundTripper{api: api}},
+		authorize: func(r *http.Request) {
+			r.Header.Set("Mattermost-User-Id", userID)
+		},
+		interPlugin: true,
+		userID:      userID,
+	}
+}
+
+type pluginAPIRoundTripper struct {
+	api PluginAPI
+}
+
+func (p *pluginAPIRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp := p.api.PluginHTTP(req)
+	if resp == nil {
+		return nil, errors.New("failed to make inter-plugin request")
+	}
+	return resp, nil
+}
+
+// do sends a request with the body encoded as JSON, when not nil, and decodes the response into result,
+// when not nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	requestURL := c.baseURL + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// botQuery selects the bot handling a request, the default bot when botUsername is empty.
+func botQuery(botUsername string) url.Values {
+	if botUsername == "" {
+		return nil
+	}
+	return url.Values{"botUsername": {botUsername}}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRequests(t *testing.T) {
+	var gotPath, gotQuery, gotAuthorization string
+	var gotBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotQuery = r.URL.RawQuery
+		gotAuthorization = r.Header.Get("Authorization")
+		gotBody = nil
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+
+		switch r.URL.Path {
+		case "/plugins/mattermost-ai/ai_bots":
+			_, _ = w.Write([]byte(`{"bots":[{"id":"bot","username":"ai","dmChannelID":"dm"}],"searchEnabled":true}`))
+		case "/plugins/mattermost-ai/post/post/analyze":
+			_, _ = w.Write([]byte(`{"postid":"analysis","channelid":"dm"}`))
+		case "/plugins/mattermost-ai/search":
+			_, _ = w.Write([]byte(`{"answer":"yes","results":[{"postId":"found","score":0.5}]}`))
+		case "/plugins/mattermost-ai/admin/provenance":
+			_, _ = w.Write([]byte(`{"schemaVersion":1,"records":[{"postId":"generated"}],"nextCursor":"next"}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := New(server.URL+"/", "token")
+
+	bots, err := c.GetBots(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", gotAuthorization)
+	assert.True(t, bots.SearchEnabled)
+	require.Len(t, bots.Bots, 1)
+	assert.Equal(t, "dm", bots.Bots[0].DMChannelID)
+
+	analysis, err := c.AnalyzeThread(ctx, "post", AnalysisActionItems, "ai")
+	require.NoError(t, err)
+	assert.Equal(t, "botUsername=ai", gotQuery)
+	assert.Equal(t, map[string]any{"analysis_type": "action_items"}, gotBody)
+	assert.Equal(t, AnalysisResponse{PostID: "analysis", ChannelID: "dm"}, *analysis)
+
+	search, err := c.Search(ctx, SearchRequest{Query: "question"}, "")
+	require.NoError(t, err)
+	assert.Empty(t, gotQuery)
+	assert.Equal(t, "question", gotBody["query"])
+	assert.Equal(t, "yes", search.Answer)
+	require.Len(t, search.Results, 1)
+	assert.Equal(t, "found", search.Results[0].PostID)
+
+	page, err := c.GetProvenance(ctx, ProvenanceQuery{Since: 10, Cursor: "cursor"})
+	require.NoError(t, err)
+	assert.Equal(t, "cursor=cursor&since=10", gotQuery)
+	assert.Equal(t, "next", page.NextCursor)
+	require.Len(t, page.Records, 1)
+
+	err = c.StopGenerating(ctx, "missing")
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "not found", apiErr.Message)
+	assert.Equal(t, "/plugins/mattermost-ai/post/missing/stop", gotPath)
+
+	_, err = c.SimpleCompletion(ctx, SimpleCompletionRequest{UserPrompt: "hello"})
+	assert.ErrorIs(t, err, ErrNotInterPlugin)
+}
+
+// pluginAPI serves the inter-plugin requests with a handler, as the server does with the AI plugin.
+type pluginAPI struct {
+	handler http.HandlerFunc
+}
+
+func (p *pluginAPI) PluginHTTP(r *http.Request) *http.Response {
+	recorder := httptest.NewRecorder()
+	p.handler(recorder, r)
+	return recorder.Result()
+}
+
+func TestNewFromPlugin(t *testing.T) {
+	var gotPath, gotUserID string
+	var gotRequest SimpleCompletionRequest
+	c := NewFromPlugin(&pluginAPI{handler: func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotUserID = r.Header.Get("Mattermost-User-Id")
+		switch r.URL.Path {
+		case "/mattermost-ai/inter-plugin/v1/simple_completion":
+			_ = json.NewDecoder(r.Body).Decode(&gotRequest)
+			_, _ = w.Write([]byte(`{"response":"hi"}`))
+		case "/mattermost-ai/inter-plugin/v1/provenance":
+			_, _ = w.Write([]byte(`{"schemaVersion":1,"records":[]}`))
+		}
+	}}, "user")
+
+	response, err := c.SimpleCompletion(context.Background(), SimpleCompletionRequest{UserPrompt: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "hi", response)
+	assert.Equal(t, "user", gotUserID)
+	assert.Equal(t, "user", gotRequest.RequesterUserID)
+
+	_, err = c.GetProvenance(context.Background(), ProvenanceQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, "/mattermost-ai/inter-plugin/v1/provenance", gotPath)
+}
+
+func TestWaitForResponse(t *testing.T) {
+	states := []StreamState{
+		{Generating: true, Message: "", Seq: 0},
+		{Generating: true, Message: "Hel", Seq: 1},
+		{Generating: true, Message: "Hel", Seq: 1},
+		{Generating: false, Message: "Hello"},
+	}
+	var polls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/plugins/mattermost-ai/post/post/stream", r.URL.Path)
+		state := states[min(int(polls.Add(1))-1, len(states)-1)]
+		_ = json.NewEncoder(w).Encode(state)
+	}))
+	defer server.Close()
+
+	var updates []string
+	message, err := New(server.URL, "token").WaitForResponse(context.Background(), "post", func(message string) {
+		updates = append(updates, message)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", message)
+	assert.Equal(t, []string{"", "Hel", "Hello"}, updates)
+	assert.EqualValues(t, len(states), polls.Load())
+}
+
+func TestWaitForResponseCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"generating":true,"message":"still going","seq":1}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := New(server.URL, "token").WaitForResponse(ctx, "post", func(string) {
+		cancel()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
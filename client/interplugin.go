@@ -0,0 +1,110 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// SimpleCompletionRequest is a completion of a system and user prompt, which can use the templates of
+// the plugin's prompts.
+type SimpleCompletionRequest struct {
+	SystemPrompt string `json:"systemPrompt"`
+	UserPrompt   string `json:"userPrompt"`
+
+	// BotUsername is the bot completing the prompts, the default bot when empty
+	BotUsername string `json:"botUsername,omitempty"`
+
+	// RequesterUserID is the user the completion is made for, the user of the client when empty
+	RequesterUserID string         `json:"requesterUserID"`
+	Parameters      map[string]any `json:"parameters,omitempty"`
+}
+
+// ProvenanceQuery selects the AI generated posts created between Since and Until, in milliseconds.
+// The plugin lists the last 24 hours when they are zero.
+type ProvenanceQuery struct {
+	Since int64
+	Until int64
+
+	// Cursor continues a listing after the page it was returned with
+	Cursor string
+	Limit  int
+}
+
+// ProvenanceRecord is the provenance of a post generated by an agent.
+type ProvenanceRecord struct {
+	PostID             string `json:"postId"`
+	ChannelID          string `json:"channelId"`
+	TeamID             string `json:"teamId"`
+	RootID             string `json:"rootId"`
+	PostType           string `json:"postType"`
+	BotUserID          string `json:"botUserId"`
+	RequesterUserID    string `json:"requesterUserId"`
+	RespondingToPostID string `json:"respondingToPostId"`
+	Model              string `json:"model"`
+	BaseModel          string `json:"baseModel"`
+	Experiment         string `json:"experiment"`
+	ExperimentVariant  string `json:"experimentVariant"`
+	MessageSHA256      string `json:"messageSha256"`
+	CreateAt           int64  `json:"createAt"`
+	UpdateAt           int64  `json:"updateAt"`
+	EditAt             int64  `json:"editAt"`
+	DeleteAt           int64  `json:"deleteAt"`
+}
+
+// ProvenancePage is a batch of records in the order posts were created. NextCursor is empty on the last page.
+type ProvenancePage struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	Records       []ProvenanceRecord `json:"records"`
+	NextCursor    string             `json:"nextCursor,omitempty"`
+}
+
+// SimpleCompletion completes the prompts with a bot. It is only available to plugins.
+func (c *Client) SimpleCompletion(ctx context.Context, request SimpleCompletionRequest) (string, error) {
+	if !c.interPlugin {
+		return "", ErrNotInterPlugin
+	}
+	if request.RequesterUserID == "" {
+		request.RequesterUserID = c.userID
+	}
+
+	var response struct {
+		Response string `json:"response"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/inter-plugin/v1/simple_completion", nil, request, &response); err != nil {
+		return "", err
+	}
+	return response.Response, nil
+}
+
+// GetProvenance lists the provenance of AI generated posts. Clients created with New need a system admin token.
+func (c *Client) GetProvenance(ctx context.Context, query ProvenanceQuery) (*ProvenancePage, error) {
+	values := url.Values{}
+	if query.Since > 0 {
+		values.Set("since", strconv.FormatInt(query.Since, 10))
+	}
+	if query.Until > 0 {
+		values.Set("until", strconv.FormatInt(query.Until, 10))
+	}
+	if query.Cursor != "" {
+		values.Set("cursor", query.Cursor)
+	}
+	if query.Limit > 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	path := "/admin/provenance"
+	if c.interPlugin {
+		path = "/inter-plugin/v1/provenance"
+	}
+
+	var page ProvenancePage
+	if err := c.do(ctx, http.MethodGet, path, values, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Memory is a fact the bots remember about the user across conversations.
+type Memory struct {
+	ID       string `json:"id"`
+	UserID   string `json:"userId"`
+	Content  string `json:"content"`
+	CreateAt int64  `json:"createAt"`
+}
+
+// GetMemories returns what the bots remember about the user, in the order it was remembered.
+func (c *Client) GetMemories(ctx context.Context) ([]Memory, error) {
+	var memories []Memory
+	if err := c.do(ctx, http.MethodGet, "/memories", nil, nil, &memories); err != nil {
+		return nil, err
+	}
+	return memories, nil
+}
+
+// DeleteMemory makes the bots forget a memory of the user.
+func (c *Client) DeleteMemory(ctx context.Context, memoryID string) error {
+	return c.do(ctx, http.MethodDelete, "/memories/"+memoryID, nil, nil, nil)
+}
+
+// DeleteAllMemories makes the bots forget everything they remember about the user.
+func (c *Client) DeleteAllMemories(ctx context.Context) error {
+	return c.do(ctx, http.MethodDelete, "/memories", nil, nil, nil)
+}
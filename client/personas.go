@@ -0,0 +1,43 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Persona is a way for bots to behave, defined by admins, that users pick for a conversation.
+type Persona struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// GetPersonas returns the personas the user can pick.
+func (c *Client) GetPersonas(ctx context.Context) ([]Persona, error) {
+	var personas []Persona
+	if err := c.do(ctx, http.MethodGet, "/personas", nil, nil, &personas); err != nil {
+		return nil, err
+	}
+	return personas, nil
+}
+
+// GetThreadPersona returns the ID of the persona of the conversation the post belongs to, empty
+// when it has none.
+func (c *Client) GetThreadPersona(ctx context.Context, postID string) (string, error) {
+	var response struct {
+		PersonaID string `json:"personaID"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/post/"+postID+"/persona", nil, nil, &response); err != nil {
+		return "", err
+	}
+	return response.PersonaID, nil
+}
+
+// SetThreadPersona switches the direct message conversation the post belongs to to the persona,
+// back to the bot's default behavior when empty.
+func (c *Client) SetThreadPersona(ctx context.Context, postID, personaID string) error {
+	request := map[string]string{"personaID": personaID}
+	return c.do(ctx, http.MethodPut, "/post/"+postID+"/persona", nil, request, nil)
+}
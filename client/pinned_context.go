@@ -0,0 +1,32 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// GetPinnedContext returns the IDs of the posts pinned in the thread the post belongs to, in the order
+// they were pinned.
+func (c *Client) GetPinnedContext(ctx context.Context, postID string) ([]string, error) {
+	var response struct {
+		PostIDs []string `json:"postIds"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/post/"+postID+"/pinned_context", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.PostIDs, nil
+}
+
+// PinContextPost pins the post in its conversation with a bot, so it is always given to the bot even
+// when the conversation is too long for the model.
+func (c *Client) PinContextPost(ctx context.Context, postID string) error {
+	return c.do(ctx, http.MethodPost, "/post/"+postID+"/pin_context", nil, nil, nil)
+}
+
+// UnpinContextPost unpins the post in its conversation.
+func (c *Client) UnpinContextPost(ctx context.Context, postID string) error {
+	return c.do(ctx, http.MethodDelete, "/post/"+postID+"/pin_context", nil, nil, nil)
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// AnalysisType is a kind of analysis of a thread.
+type AnalysisType string
+
+const (
+	AnalysisSummarizeThread AnalysisType = "summarize_thread"
+	AnalysisActionItems     AnalysisType = "action_items"
+	AnalysisOpenQuestions   AnalysisType = "open_questions"
+)
+
+// StreamPollInterval is how often WaitForResponse checks on a response being generated
+const StreamPollInterval = 500 * time.Millisecond
+
+// AnalysisResponse is the post the analysis is streamed to, in the direct message channel with the bot.
+type AnalysisResponse struct {
+	PostID    string `json:"postid"`
+	ChannelID string `json:"channelid"`
+}
+
+// StreamState is the message of a post generated by a bot, as streamed so far while Generating.
+// Seq increases each time the message changes.
+type StreamState struct {
+	Generating bool   `json:"generating"`
+	Message    string `json:"message"`
+	Seq        int64  `json:"seq"`
+}
+
+// AnalyzeThread asks the bot, the default bot when botUsername is empty, to analyze the thread of the post.
+// The analysis is streamed to a new post, which WaitForResponse follows.
+func (c *Client) AnalyzeThread(ctx context.Context, postID string, analysisType AnalysisType, botUsername string) (*AnalysisResponse, error) {
+	request := map[string]string{"analysis_type": string(analysisType)}
+	var response AnalysisResponse
+	if err := c.do(ctx, http.MethodPost, "/post/"+postID+"/analyze", botQuery(botUsername), request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AskAboutPost asks the bot, the default bot when botUsername is empty, a question about the post with its
+// thread as context. The answer is streamed to a new post in the direct message with the bot, which
+// WaitForResponse follows.
+func (c *Client) AskAboutPost(ctx context.Context, postID, question, botUsername string) (*AnalysisResponse, error) {
+	request := map[string]string{"question": question}
+	var response AnalysisResponse
+	if err := c.do(ctx, http.MethodPost, "/post/"+postID+"/ask", botQuery(botUsername), request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetStreamState returns the message of a post generated by a bot.
+func (c *Client) GetStreamState(ctx context.Context, postID string) (*StreamState, error) {
+	var state StreamState
+	if err := c.do(ctx, http.MethodGet, "/post/"+postID+"/stream", nil, nil, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// StopGenerating stops the generation of a post.
+func (c *Client) StopGenerating(ctx context.Context, postID string) error {
+	return c.do(ctx, http.MethodPost, "/post/"+postID+"/stop", nil, nil, nil)
+}
+
+// Regenerate generates a post of a bot again.
+func (c *Client) Regenerate(ctx context.Context, postID string) error {
+	return c.do(ctx, http.MethodPost, "/post/"+postID+"/regenerate", nil, nil, nil)
+}
+
+// RegenerateWithInstruction generates a post of a bot again, changed as the instruction asks, for example
+// "shorter" or "more formal".
+func (c *Client) RegenerateWithInstruction(ctx context.Context, postID, instruction string) error {
+	request := map[string]string{"instruction": instruction}
+	return c.do(ctx, http.MethodPost, "/post/"+postID+"/regenerate", nil, request, nil)
+}
+
+// ResendEditedPost answers a message again after it was edited by its author. The responses of bots and the
+// posts of the author that came after it in the thread are deleted, and the response is streamed to a new post.
+func (c *Client) ResendEditedPost(ctx context.Context, postID string) error {
+	return c.do(ctx, http.MethodPost, "/post/"+postID+"/resend", nil, nil, nil)
+}
+
+// ForkResponse is the root of the thread a conversation was forked to, in the direct message channel with the bot.
+type ForkResponse struct {
+	PostID    string `json:"postid"`
+	ChannelID string `json:"channelid"`
+}
+
+// ForkConversation copies the conversation with a bot, up to and including the post, to a new thread the
+// conversation can go on in without changing the original.
+func (c *Client) ForkConversation(ctx context.Context, postID string) (*ForkResponse, error) {
+	var response ForkResponse
+	if err := c.do(ctx, http.MethodPost, "/post/"+postID+"/fork", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// WaitForResponse follows a post while it is generated and returns its final message. onUpdate, when not nil,
+// is called with the message each time it changes, to show the response as it is streamed.
+func (c *Client) WaitForResponse(ctx context.Context, postID string, onUpdate func(message string)) (string, error) {
+	ticker := time.NewTicker(StreamPollInterval)
+	defer ticker.Stop()
+
+	lastSeq := int64(-1)
+	lastMessage := ""
+	for {
+		state, err := c.GetStreamState(ctx, postID)
+		if err != nil {
+			return "", err
+		}
+		if onUpdate != nil && (state.Seq != lastSeq || state.Message != lastMessage) {
+			onUpdate(state.Message)
+		}
+		if !state.Generating {
+			return state.Message, nil
+		}
+		lastSeq = state.Seq
+		lastMessage = state.Message
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Actions that rewrite a message
+const (
+	RewriteGrammar   = "grammar"
+	RewriteTone      = "tone"
+	RewriteShorten   = "shorten"
+	RewriteTranslate = "translate"
+)
+
+// RewriteOptions is how a message is rewritten. Tone is one of formal, friendly, confident or empathetic,
+// for the tone action. Language is the language of the translate action, the user's language when empty.
+type RewriteOptions struct {
+	Action   string `json:"action"`
+	Tone     string `json:"tone,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+type rewriteResponse struct {
+	Message string `json:"message"`
+}
+
+// RewriteDraft returns the draft of a post in the channel rewritten by the bot, the default bot when
+// botUsername is empty. Nothing is posted.
+func (c *Client) RewriteDraft(ctx context.Context, channelID, message string, options RewriteOptions, botUsername string) (string, error) {
+	request := struct {
+		RewriteOptions
+		Message string `json:"message"`
+	}{RewriteOptions: options, Message: message}
+	var response rewriteResponse
+	if err := c.do(ctx, http.MethodPost, "/channel/"+channelID+"/rewrite", botQuery(botUsername), request, &response); err != nil {
+		return "", err
+	}
+	return response.Message, nil
+}
+
+// RewritePost returns the message of the post rewritten by the bot, the default bot when botUsername is
+// empty. The post isn't changed.
+func (c *Client) RewritePost(ctx context.Context, postID string, options RewriteOptions, botUsername string) (string, error) {
+	var response rewriteResponse
+	if err := c.do(ctx, http.MethodPost, "/post/"+postID+"/rewrite", botQuery(botUsername), options, &response); err != nil {
+		return "", err
+	}
+	return response.Message, nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Schedule is a prompt run on a recurring cadence, answered by a bot in a direct message with the user.
+type Schedule struct {
+	ID          string `json:"id"`
+	UserID      string `json:"userId"`
+	BotUsername string `json:"botUsername"`
+	Name        string `json:"name"`
+	Prompt      string `json:"prompt"`
+	Cadence     string `json:"cadence"`
+	TimeZone    string `json:"timeZone"`
+	Enabled     bool   `json:"enabled"`
+	CreateAt    int64  `json:"createAt"`
+	UpdateAt    int64  `json:"updateAt"`
+	NextRunAt   int64  `json:"nextRunAt"`
+	LastRunAt   int64  `json:"lastRunAt"`
+	LastError   string `json:"lastError"`
+}
+
+// ScheduleRequest creates or updates a schedule. Cadence is a cron expression such as "0 9 * * 1" for
+// every Monday at 9:00, in the time zone given or the user's own when empty.
+type ScheduleRequest struct {
+	BotUsername string `json:"botUsername"`
+	Name        string `json:"name,omitempty"`
+	Prompt      string `json:"prompt"`
+	Cadence     string `json:"cadence"`
+	TimeZone    string `json:"timeZone,omitempty"`
+	Enabled     *bool  `json:"enabled,omitempty"`
+}
+
+// GetSchedules returns the scheduled prompts of the user, in the order they were created.
+func (c *Client) GetSchedules(ctx context.Context) ([]Schedule, error) {
+	var schedules []Schedule
+	if err := c.do(ctx, http.MethodGet, "/schedules", nil, nil, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// CreateSchedule schedules a prompt for the user, enabled unless the request says otherwise.
+func (c *Client) CreateSchedule(ctx context.Context, request ScheduleRequest) (*Schedule, error) {
+	var schedule Schedule
+	if err := c.do(ctx, http.MethodPost, "/schedules", nil, request, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// UpdateSchedule replaces a scheduled prompt of the user.
+func (c *Client) UpdateSchedule(ctx context.Context, scheduleID string, request ScheduleRequest) (*Schedule, error) {
+	var schedule Schedule
+	if err := c.do(ctx, http.MethodPut, "/schedules/"+scheduleID, nil, request, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// DeleteSchedule deletes a scheduled prompt of the user.
+func (c *Client) DeleteSchedule(ctx context.Context, scheduleID string) error {
+	return c.do(ctx, http.MethodDelete, "/schedules/"+scheduleID, nil, nil, nil)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// SearchRequest is a search of the posts and meetings the user can read.
+type SearchRequest struct {
+	Query      string `json:"query"`
+	TeamID     string `json:"teamId"`
+	ChannelID  string `json:"channelId"`
+	MaxResults int    `json:"maxResults"`
+
+	// Corpus is "posts" or "meetings" to search only one of them, both are searched when empty
+	Corpus string `json:"corpus"`
+
+	// Filters narrow the search down to the posts in any of the channels, by any of the users, and
+	// created between the timestamps in milliseconds. Unset filters match everything.
+	ChannelIDs    []string `json:"channelIds,omitempty"`
+	FromUserIDs   []string `json:"fromUserIds,omitempty"`
+	CreatedAfter  int64    `json:"createdAfter,omitempty"`
+	CreatedBefore int64    `json:"createdBefore,omitempty"`
+}
+
+// SearchResult is a post or meeting matching a search.
+type SearchResult struct {
+	PostID      string  `json:"postId"`
+	ChannelID   string  `json:"channelId"`
+	ChannelName string  `json:"channelName"`
+	UserID      string  `json:"userId"`
+	Username    string  `json:"username"`
+	Content     string  `json:"content"`
+	Score       float32 `json:"score"`
+
+	// Set for meeting summaries and transcripts
+	SourceType   string   `json:"sourceType,omitempty"`
+	MeetingDate  string   `json:"meetingDate,omitempty"`
+	Participants []string `json:"participants,omitempty"`
+}
+
+// SearchResponse is the answer of the bot to a search, with the results it is based on.
+type SearchResponse struct {
+	Answer  string         `json:"answer"`
+	Results []SearchResult `json:"results"`
+}
+
+// RunSearchResponse is the post asking the query in the direct message channel with the bot, which the bot replies to.
+type RunSearchResponse struct {
+	PostID    string `json:"PostID"`
+	ChannelID string `json:"ChannelID"`
+}
+
+// Search answers the query with the bot, the default bot when botUsername is empty.
+func (c *Client) Search(ctx context.Context, request SearchRequest, botUsername string) (*SearchResponse, error) {
+	var response SearchResponse
+	if err := c.do(ctx, http.MethodPost, "/search", botQuery(botUsername), request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RunSearch asks the bot, the default bot when botUsername is empty, to answer the query in a direct message.
+func (c *Client) RunSearch(ctx context.Context, request SearchRequest, botUsername string) (*RunSearchResponse, error) {
+	var response RunSearchResponse
+	if err := c.do(ctx, http.MethodPost, "/search/run", botQuery(botUsername), request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// CostEstimate is a number of tokens and their estimated cost in US dollars. The cost is only meaningful
+// when the price is known.
+type CostEstimate struct {
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+	PriceKnown   bool    `json:"price_known"`
+}
+
+// ModelUsage is the tokens a bot used with a model in a thread.
+type ModelUsage struct {
+	BotUsername string `json:"bot_username"`
+	Model       string `json:"model"`
+	Responses   int    `json:"responses"`
+	CostEstimate
+}
+
+// ThreadUsage is the tokens used to answer in a thread, by bot and model, with the estimated total cost.
+type ThreadUsage struct {
+	ThreadID string       `json:"thread_id"`
+	Models   []ModelUsage `json:"models"`
+	Total    CostEstimate `json:"total"`
+}
+
+// GetThreadUsage returns the tokens used to answer in the thread the post belongs to, and their cost
+// estimated with the prices currently configured.
+func (c *Client) GetThreadUsage(ctx context.Context, postID string) (*ThreadUsage, error) {
+	var usage ThreadUsage
+	if err := c.do(ctx, http.MethodGet, "/post/"+postID+"/usage", nil, nil, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package cohere provides an embedding provider backed by the embed API of Cohere.
+package cohere
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	DefaultAPIURL         = "https://api.cohere.com"
+	DefaultEmbeddingModel = "embed-english-v3.0"
+
+	// maxBatchSize is the largest number of texts Cohere embeds in one request
+	maxBatchSize = 96
+)
+
+// Input types tell Cohere whether the text is stored or searched for, which it embeds differently
+const (
+	inputTypeDocument = "search_document"
+	inputTypeQuery    = "search_query"
+)
+
+type Config struct {
+	APIKey         string `json:"apiKey"`
+	APIURL         string `json:"apiURL"`
+	EmbeddingModel string `json:"embeddingModel"`
+	Dimensions     int    `json:"dimensions"`
+}
+
+// Embeddings creates embeddings with Cohere
+type Embeddings struct {
+	config     Config
+	httpClient *http.Client
+}
+
+type embedRequest struct {
+	Model          string   `json:"model"`
+	Texts          []string `json:"texts"`
+	InputType      string   `json:"input_type"`
+	EmbeddingTypes []string `json:"embedding_types"`
+}
+
+type embedResponse struct {
+	Embeddings struct {
+		Float [][]float32 `json:"float"`
+	} `json:"embeddings"`
+}
+
+// NewEmbeddings creates a Cohere embedding provider, using the default model when none is configured.
+func NewEmbeddings(config Config, httpClient *http.Client) (*Embeddings, error) {
+	if config.APIKey == "" {
+		return nil, errors.New("cohere API key is required")
+	}
+	if config.APIURL == "" {
+		config.APIURL = DefaultAPIURL
+	}
+	if config.EmbeddingModel == "" {
+		config.EmbeddingModel = DefaultEmbeddingModel
+	}
+	config.APIURL = strings.TrimSuffix(config.APIURL, "/")
+
+	return &Embeddings{
+		config:     config,
+		httpClient: httpClient,
+	}, nil
+}
+
+// CreateEmbedding embeds a search query
+func (e *Embeddings) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.embed(ctx, []string{text}, inputTypeQuery)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// BatchCreateEmbeddings embeds documents to store, in as many requests as Cohere needs
+func (e *Embeddings) BatchCreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxBatchSize {
+		batch, err := e.embed(ctx, texts[start:min(start+maxBatchSize, len(texts))], inputTypeDocument)
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
+func (e *Embeddings) Dimensions() int {
+	return e.config.Dimensions
+}
+
+func (e *Embeddings) embed(ctx context.Context, texts []string, inputType string) ([][]float32, error) {
+	body, err := json.Marshal(embedRequest{
+		Model:          e.config.EmbeddingModel,
+		Texts:          texts,
+		InputType:      inputType,
+		EmbeddingTypes: []string{"float"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cohere request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.APIURL+"/v2/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.config.APIKey)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach cohere: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Errors are reported as {"message": "..."}
+		var errorResponse struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(data, &errorResponse) != nil || errorResponse.Message == "" {
+			errorResponse.Message = strings.TrimSpace(string(data))
+		}
+		return nil, fmt.Errorf("cohere returned status %d: %s", resp.StatusCode, errorResponse.Message)
+	}
+
+	var response embedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("unable to decode cohere response: %w", err)
+	}
+	if len(response.Embeddings.Float) != len(texts) {
+		return nil, fmt.Errorf("cohere returned %d embeddings for %d texts", len(response.Embeddings.Float), len(texts))
+	}
+	return response.Embeddings.Float, nil
+}
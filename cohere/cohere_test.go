@@ -0,0 +1,90 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCohere embeds each text as its length, and records the requests it receives
+type fakeCohere struct {
+	requests []embedRequest
+}
+
+func (f *fakeCohere) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v2/embed" || r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"invalid api token"}`))
+		return
+	}
+
+	var request embedRequest
+	_ = json.NewDecoder(r.Body).Decode(&request)
+	f.requests = append(f.requests, request)
+
+	var response embedResponse
+	for _, text := range request.Texts {
+		response.Embeddings.Float = append(response.Embeddings.Float, []float32{float32(len(text))})
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func TestEmbeddings(t *testing.T) {
+	fake := &fakeCohere{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	provider, err := NewEmbeddings(Config{APIKey: "secret", APIURL: server.URL + "/", Dimensions: 1024}, server.Client())
+	require.NoError(t, err)
+	assert.Equal(t, 1024, provider.Dimensions())
+
+	t.Run("embeds queries as queries", func(t *testing.T) {
+		embedding, err := provider.CreateEmbedding(context.Background(), "query")
+		require.NoError(t, err)
+		assert.Equal(t, []float32{5}, embedding)
+		assert.Equal(t, embedRequest{
+			Model:          DefaultEmbeddingModel,
+			Texts:          []string{"query"},
+			InputType:      inputTypeQuery,
+			EmbeddingTypes: []string{"float"},
+		}, fake.requests[0])
+	})
+
+	t.Run("embeds documents in batches", func(t *testing.T) {
+		fake.requests = nil
+		texts := make([]string, maxBatchSize+1)
+		for i := range texts {
+			texts[i] = "doc"
+		}
+		texts[maxBatchSize] = "last"
+
+		embeddings, err := provider.BatchCreateEmbeddings(context.Background(), texts)
+		require.NoError(t, err)
+		require.Len(t, embeddings, maxBatchSize+1)
+		assert.Equal(t, []float32{4}, embeddings[maxBatchSize])
+
+		require.Len(t, fake.requests, 2)
+		assert.Len(t, fake.requests[0].Texts, maxBatchSize)
+		assert.Equal(t, inputTypeDocument, fake.requests[1].InputType)
+	})
+
+	t.Run("reports errors of cohere", func(t *testing.T) {
+		unauthorized, err := NewEmbeddings(Config{APIKey: "wrong", APIURL: server.URL}, server.Client())
+		require.NoError(t, err)
+		_, err = unauthorized.CreateEmbedding(context.Background(), "query")
+		assert.EqualError(t, err, "cohere returned status 401: invalid api token")
+	})
+
+	t.Run("requires an API key", func(t *testing.T) {
+		_, err := NewEmbeddings(Config{}, server.Client())
+		assert.Error(t, err)
+	})
+}
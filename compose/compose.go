@@ -0,0 +1,123 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+)
+
+const (
+	// SuggestionTimeout is the latency budget of suggestions, after which they aren't useful while typing
+	SuggestionTimeout = 2 * time.Second
+
+	// MaxSuggestions is the number of continuations suggested for a draft
+	MaxSuggestions = 3
+
+	// MaxDraftLength limits the drafts suggestions are made for
+	MaxDraftLength = 2000
+
+	maxSuggestionTokens = 60
+	maxSuggestionLength = 200
+)
+
+// listMarker matches the numbering or bullet models sometimes put before each suggestion
+var listMarker = regexp.MustCompile(`^(\d+[.)]|[-*•])\s+`)
+
+var (
+	// ErrInvalidDraft is returned when asking for suggestions without a draft, or with one that is too long.
+	ErrInvalidDraft = errors.New("invalid draft")
+
+	// ErrTimeout is returned when the suggestions took longer than SuggestionTimeout.
+	ErrTimeout = errors.New("suggestions took too long")
+)
+
+// Compose suggests continuations of the message a user is writing
+type Compose struct {
+	llm     llm.LanguageModel
+	prompts *llm.Prompts
+}
+
+// New creates a new Compose
+func New(
+	llm llm.LanguageModel,
+	prompts *llm.Prompts,
+) *Compose {
+	return &Compose{
+		llm:     llm,
+		prompts: prompts,
+	}
+}
+
+// Suggest returns up to MaxSuggestions short continuations of the draft that fit the conversation. The
+// small model is used and nothing is streamed, so suggestions are quick enough to show while typing.
+func (c *Compose) Suggest(draft, conversation string, context *llm.Context) ([]string, error) {
+	if strings.TrimSpace(draft) == "" || utf8.RuneCountInString(draft) > MaxDraftLength {
+		return nil, ErrInvalidDraft
+	}
+
+	context.Parameters = map[string]any{
+		"Thread":         conversation,
+		"MaxSuggestions": MaxSuggestions,
+	}
+	prompt, err := c.prompts.Format(prompts.PromptComposeSuggestionsSystem, context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format prompt: %w", err)
+	}
+
+	completionRequest := llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: prompt},
+			{Role: llm.PostRoleUser, Message: draft},
+		},
+		Context: context,
+	}
+
+	type completion struct {
+		text string
+		err  error
+	}
+	done := make(chan completion, 1)
+	go func() {
+		text, completionErr := c.llm.ChatCompletionNoStream(completionRequest, llm.WithMaxGeneratedTokens(maxSuggestionTokens), llm.WithFeature(llm.FeatureCompose))
+		done <- completion{text: text, err: completionErr}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err != nil {
+			return nil, fmt.Errorf("failed to get suggestions: %w", result.err)
+		}
+		return ParseSuggestions(result.text), nil
+	case <-time.After(SuggestionTimeout):
+		return nil, ErrTimeout
+	}
+}
+
+// ParseSuggestions reads the suggestions of a model, one per line. Numbering, quotes and duplicates are
+// removed, and suggestions that are too long are left out.
+func ParseSuggestions(text string) []string {
+	suggestions := []string{}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		suggestion := listMarker.ReplaceAllString(strings.TrimSpace(line), "")
+		suggestion = strings.TrimSpace(strings.Trim(suggestion, "\"“”"))
+		if suggestion == "" || seen[suggestion] || utf8.RuneCountInString(suggestion) > maxSuggestionLength {
+			continue
+		}
+		seen[suggestion] = true
+		suggestions = append(suggestions, suggestion)
+		if len(suggestions) == MaxSuggestions {
+			break
+		}
+	}
+	return suggestions
+}
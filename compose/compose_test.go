@@ -0,0 +1,55 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSuggestions(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{
+			name:     "one per line",
+			text:     "the meeting tomorrow?\nthe release notes?",
+			expected: []string{"the meeting tomorrow?", "the release notes?"},
+		},
+		{
+			name:     "numbered and quoted",
+			text:     "1. \"at 3pm\"\n2) at noon\n- after lunch",
+			expected: []string{"at 3pm", "at noon", "after lunch"},
+		},
+		{
+			name:     "starting with a number",
+			text:     "3pm works for me",
+			expected: []string{"3pm works for me"},
+		},
+		{
+			name:     "empty lines and duplicates",
+			text:     "\nsounds good\n\nsounds good\n",
+			expected: []string{"sounds good"},
+		},
+		{
+			name:     "at most MaxSuggestions",
+			text:     "a\nb\nc\nd",
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "nothing suggested",
+			text:     "",
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ParseSuggestions(tc.text))
+		})
+	}
+}
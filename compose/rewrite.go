@@ -0,0 +1,123 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+)
+
+// Actions that rewrite a message
+const (
+	RewriteGrammar   = "grammar"
+	RewriteTone      = "tone"
+	RewriteShorten   = "shorten"
+	RewriteTranslate = "translate"
+)
+
+// Tones a message can be rewritten in
+const (
+	ToneFormal     = "formal"
+	ToneFriendly   = "friendly"
+	ToneConfident  = "confident"
+	ToneEmpathetic = "empathetic"
+)
+
+const (
+	// MaxRewriteLength limits the messages that can be rewritten
+	MaxRewriteLength = 4000
+
+	maxLanguageLength = 50
+)
+
+// ErrInvalidRewrite is returned when rewriting without a message or with one that is too long, with an
+// unknown action or tone, or with a language that is too long.
+var ErrInvalidRewrite = errors.New("invalid rewrite")
+
+// rewritePrompts are the prompts of the rewrite actions
+var rewritePrompts = map[string]string{
+	RewriteGrammar:   prompts.PromptRewriteGrammarSystem,
+	RewriteTone:      prompts.PromptRewriteToneSystem,
+	RewriteShorten:   prompts.PromptRewriteShortenSystem,
+	RewriteTranslate: prompts.PromptRewriteTranslateSystem,
+}
+
+// RewriteOptions is how a message is rewritten.
+type RewriteOptions struct {
+	Action string
+
+	// Tone is the tone of the RewriteTone action
+	Tone string
+
+	// Language is the language of the RewriteTranslate action, the requesting user's locale when empty
+	Language string
+}
+
+// rewritePrompt returns the prompt of the rewrite and its parameters.
+func rewritePrompt(options RewriteOptions, userLocale string) (string, map[string]any, error) {
+	promptName, ok := rewritePrompts[options.Action]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: unknown action %q", ErrInvalidRewrite, options.Action)
+	}
+
+	switch options.Action {
+	case RewriteTone:
+		switch options.Tone {
+		case ToneFormal, ToneFriendly, ToneConfident, ToneEmpathetic:
+			return promptName, map[string]any{"Tone": options.Tone}, nil
+		}
+		return "", nil, fmt.Errorf("%w: unknown tone %q", ErrInvalidRewrite, options.Tone)
+	case RewriteTranslate:
+		language := strings.TrimSpace(options.Language)
+		if language == "" {
+			language = fmt.Sprintf("the language of the locale '%s'", userLocale)
+		} else if utf8.RuneCountInString(language) > maxLanguageLength {
+			return "", nil, fmt.Errorf("%w: the language is longer than %d characters", ErrInvalidRewrite, maxLanguageLength)
+		}
+		return promptName, map[string]any{"Language": language}, nil
+	}
+	return promptName, map[string]any{}, nil
+}
+
+// Rewrite returns the message rewritten as the options ask, without posting it.
+func (c *Compose) Rewrite(message string, options RewriteOptions, context *llm.Context) (string, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", fmt.Errorf("%w: the message is empty", ErrInvalidRewrite)
+	}
+	if utf8.RuneCountInString(message) > MaxRewriteLength {
+		return "", fmt.Errorf("%w: the message is longer than %d characters", ErrInvalidRewrite, MaxRewriteLength)
+	}
+
+	userLocale := ""
+	if context.RequestingUser != nil {
+		userLocale = context.RequestingUser.Locale
+	}
+	promptName, parameters, err := rewritePrompt(options, userLocale)
+	if err != nil {
+		return "", err
+	}
+
+	context.Parameters = parameters
+	prompt, err := c.prompts.Format(promptName, context)
+	if err != nil {
+		return "", fmt.Errorf("failed to format prompt: %w", err)
+	}
+
+	rewritten, err := c.llm.ChatCompletionNoStream(llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: prompt},
+			{Role: llm.PostRoleUser, Message: message},
+		},
+		Context: context,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrite message: %w", err)
+	}
+	return strings.TrimSpace(rewritten), nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package compose
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewritePrompt(t *testing.T) {
+	tests := []struct {
+		name               string
+		options            RewriteOptions
+		expectedPrompt     string
+		expectedParameters map[string]any
+		expectedError      bool
+	}{
+		{
+			name:               "grammar",
+			options:            RewriteOptions{Action: RewriteGrammar},
+			expectedPrompt:     prompts.PromptRewriteGrammarSystem,
+			expectedParameters: map[string]any{},
+		},
+		{
+			name:               "tone",
+			options:            RewriteOptions{Action: RewriteTone, Tone: ToneFormal},
+			expectedPrompt:     prompts.PromptRewriteToneSystem,
+			expectedParameters: map[string]any{"Tone": "formal"},
+		},
+		{
+			name:          "unknown tone",
+			options:       RewriteOptions{Action: RewriteTone, Tone: "sarcastic"},
+			expectedError: true,
+		},
+		{
+			name:               "translate",
+			options:            RewriteOptions{Action: RewriteTranslate, Language: " French "},
+			expectedPrompt:     prompts.PromptRewriteTranslateSystem,
+			expectedParameters: map[string]any{"Language": "French"},
+		},
+		{
+			name:               "translate to the user's language",
+			options:            RewriteOptions{Action: RewriteTranslate},
+			expectedPrompt:     prompts.PromptRewriteTranslateSystem,
+			expectedParameters: map[string]any{"Language": "the language of the locale 'de'"},
+		},
+		{
+			name:          "language too long",
+			options:       RewriteOptions{Action: RewriteTranslate, Language: strings.Repeat("a", maxLanguageLength+1)},
+			expectedError: true,
+		},
+		{
+			name:          "unknown action",
+			options:       RewriteOptions{Action: "rhyme"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			promptName, parameters, err := rewritePrompt(tc.options, "de")
+			if tc.expectedError {
+				require.ErrorIs(t, err, ErrInvalidRewrite)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedPrompt, promptName)
+			assert.Equal(t, tc.expectedParameters, parameters)
+		})
+	}
+}
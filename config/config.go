@@ -6,24 +6,93 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/mattermost/mattermost-plugin-ai/azurespeech"
 	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/ffmpeg"
+	"github.com/mattermost/mattermost-plugin-ai/linking"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mcp"
 	"github.com/mattermost/mattermost-plugin-ai/openai"
+	"github.com/mattermost/mattermost-plugin-ai/vllm"
 )
 
 type Config struct {
-	Services                 []llm.ServiceConfig              `json:"services"`
-	Bots                     []llm.BotConfig                  `json:"bots"`
-	DefaultBotName           string                           `json:"defaultBotName"`
-	TranscriptGenerator      string                           `json:"transcriptBackend"`
-	EnableLLMTrace           bool                             `json:"enableLLMTrace"`
-	AllowedUpstreamHostnames string                           `json:"allowedUpstreamHostnames"`
-	EmbeddingSearchConfig    embeddings.EmbeddingSearchConfig `json:"embeddingSearchConfig"`
-	MCP                      mcp.Config                       `json:"mcp"`
+	Services                      []llm.ServiceConfig              `json:"services"`
+	Bots                          []llm.BotConfig                  `json:"bots"`
+	DefaultBotName                string                           `json:"defaultBotName"`
+	TranscriptGenerator           string                           `json:"transcriptBackend"`
+	AzureSpeech                   azurespeech.Config               `json:"azureSpeech"`
+	TranscriptionLanguage         string                           `json:"transcriptionLanguage"`
+	ChannelTranscriptionLanguages []ChannelTranscriptionLanguage   `json:"channelTranscriptionLanguages"`
+	TranscriptionVocabularies     []TranscriptionVocabulary        `json:"transcriptionVocabularies"`
+	FFmpeg                        ffmpeg.Config                    `json:"ffmpeg"`
+	EntityLinking                 linking.Config                   `json:"entityLinking"`
+	EnableLLMTrace                bool                             `json:"enableLLMTrace"`
+	EnableUserMemory              bool                             `json:"enableUserMemory"`
+	EnableFollowUpSuggestions     bool                             `json:"enableFollowUpSuggestions"`
+	AllowedUpstreamHostnames      string                           `json:"allowedUpstreamHostnames"`
+	EmbeddingSearchConfig         embeddings.EmbeddingSearchConfig `json:"embeddingSearchConfig"`
+	MCP                           mcp.Config                       `json:"mcp"`
+	ToolApprovals                 []llm.ToolApprovalPolicy         `json:"toolApprovals"`
+	SummaryTemplates              []SummaryTemplate                `json:"summaryTemplates"`
+	Personas                      []Persona                        `json:"personas"`
+	AutoSummarizeCalls            AutoSummarizeCalls               `json:"autoSummarizeCalls"`
+	ThreadTagging                 ThreadTagging                    `json:"threadTagging"`
+	StreamingKeepAliveSeconds     int                              `json:"streamingKeepAliveSeconds"`
+	OrphanedFileRetentionHours    int                              `json:"orphanedFileRetentionHours"`
+}
+
+// ChannelTranscriptionLanguage sets the language of recordings transcribed in the listed channels.
+type ChannelTranscriptionLanguage struct {
+	Language   string   `json:"language"`
+	ChannelIDs []string `json:"channelIDs"`
+}
+
+// TranscriptionVocabulary lists product names, acronyms and other terms of the organization so recordings of
+// the listed channels and teams are transcribed with the right spelling. Without channels and teams it applies
+// to every recording.
+type TranscriptionVocabulary struct {
+	Terms      []string `json:"terms"`
+	ChannelIDs []string `json:"channelIDs"`
+	TeamIDs    []string `json:"teamIDs"`
+}
+
+// AutoSummarizeCalls lists the channels where call recordings are transcribed and summarized as soon
+// as the Calls bot posts them, by the bot named or the default bot when empty.
+type AutoSummarizeCalls struct {
+	BotName    string   `json:"botName"`
+	ChannelIDs []string `json:"channelIDs"`
+}
+
+// ThreadTagging classifies new AI threads into categories. Model is the model of the bot's service
+// used to classify, a cheaper one than the bot's default model, which is used when empty.
+type ThreadTagging struct {
+	Enabled bool   `json:"enabled"`
+	Model   string `json:"model"`
+}
+
+// SummaryTemplate is a meeting summary template defined by admins, requesters can pick it
+// alongside the built-in templates.
+type SummaryTemplate struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Instructions string `json:"instructions"`
+}
+
+// Persona is a way for bots to behave defined by admins, users pick it when starting a conversation
+// or switch to it within a thread. Temperature is the model's default when nil, and AllowedTools
+// lists the only tools the bot can call, all of its tools when empty.
+type Persona struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	SystemPrompt string   `json:"systemPrompt"`
+	Temperature  *float64 `json:"temperature"`
+	AllowedTools []string `json:"allowedTools"`
 }
 
 func (c *Config) Clone() *Config {
@@ -52,10 +121,111 @@ func (c *Container) GetEnableLLMTrace() bool {
 	return c.cfg.Load().EnableLLMTrace
 }
 
+// GetEnableUserMemory returns true if bots remember facts about users across conversations.
+func (c *Container) GetEnableUserMemory() bool {
+	return c.cfg.Load().EnableUserMemory
+}
+
+// GetEnableFollowUpSuggestions returns true if follow-up questions are suggested after bot responses.
+func (c *Container) GetEnableFollowUpSuggestions() bool {
+	return c.cfg.Load().EnableFollowUpSuggestions
+}
+
 func (c *Container) GetTranscriptGenerator() string {
 	return c.cfg.Load().TranscriptGenerator
 }
 
+func (c *Container) GetAzureSpeechConfig() azurespeech.Config {
+	return c.cfg.Load().AzureSpeech
+}
+
+// GetTranscriptionLanguage returns the language of recordings transcribed in the channel,
+// empty when the language should be detected.
+func (c *Container) GetTranscriptionLanguage(channelID string) string {
+	cfg := c.cfg.Load()
+	for _, channelLanguage := range cfg.ChannelTranscriptionLanguages {
+		if slices.Contains(channelLanguage.ChannelIDs, channelID) {
+			return channelLanguage.Language
+		}
+	}
+	return cfg.TranscriptionLanguage
+}
+
+// GetTranscriptionVocabulary returns the terms of the vocabularies of the channel, of its team and of every
+// recording, in that order since the transcription backends may only use the first terms.
+func (c *Container) GetTranscriptionVocabulary(channelID, teamID string) []string {
+	vocabularies := c.cfg.Load().TranscriptionVocabularies
+	applies := []func(TranscriptionVocabulary) bool{
+		func(v TranscriptionVocabulary) bool { return slices.Contains(v.ChannelIDs, channelID) },
+		func(v TranscriptionVocabulary) bool { return teamID != "" && slices.Contains(v.TeamIDs, teamID) },
+		func(v TranscriptionVocabulary) bool { return len(v.ChannelIDs) == 0 && len(v.TeamIDs) == 0 },
+	}
+
+	terms := []string{}
+	for _, vocabularyApplies := range applies {
+		for _, vocabulary := range vocabularies {
+			if !vocabularyApplies(vocabulary) {
+				continue
+			}
+			for _, term := range vocabulary.Terms {
+				term = strings.TrimSpace(term)
+				if term != "" && !slices.Contains(terms, term) {
+					terms = append(terms, term)
+				}
+			}
+		}
+	}
+	return terms
+}
+
+func (c *Container) GetFFmpegConfig() ffmpeg.Config {
+	return c.cfg.Load().FFmpeg
+}
+
+func (c *Container) GetSummaryTemplates() []SummaryTemplate {
+	return c.cfg.Load().SummaryTemplates
+}
+
+func (c *Container) GetPersonas() []Persona {
+	return c.cfg.Load().Personas
+}
+
+// GetAutoSummarizeBotName returns the name of the bot summarizing call recordings of the channel
+// automatically, empty when the channel hasn't opted in.
+func (c *Container) GetAutoSummarizeBotName(channelID string) string {
+	cfg := c.cfg.Load()
+	if !slices.Contains(cfg.AutoSummarizeCalls.ChannelIDs, channelID) {
+		return ""
+	}
+	if cfg.AutoSummarizeCalls.BotName != "" {
+		return cfg.AutoSummarizeCalls.BotName
+	}
+	return cfg.DefaultBotName
+}
+
+func (c *Container) GetThreadTagging() ThreadTagging {
+	return c.cfg.Load().ThreadTagging
+}
+
+// GetStreamingKeepAliveInterval returns how long streamed responses can be idle before a heartbeat
+// is sent, zero for the default interval.
+func (c *Container) GetStreamingKeepAliveInterval() time.Duration {
+	return time.Duration(c.cfg.Load().StreamingKeepAliveSeconds) * time.Second
+}
+
+// GetOrphanedFileRetention returns how long uploaded transcripts that were never attached to a post are
+// kept before being deleted, a day unless configured.
+func (c *Container) GetOrphanedFileRetention() time.Duration {
+	if hours := c.cfg.Load().OrphanedFileRetentionHours; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+func (c *Container) GetEntityLinkingConfig() linking.Config {
+	return c.cfg.Load().EntityLinking
+}
+
 func (c *Container) GetBots() []llm.BotConfig {
 	return c.cfg.Load().Bots
 }
@@ -72,6 +242,10 @@ func (c *Container) MCP() mcp.Config {
 	return c.cfg.Load().MCP
 }
 
+func (c *Container) ToolApprovals() []llm.ToolApprovalPolicy {
+	return c.cfg.Load().ToolApprovals
+}
+
 func (c *Container) RegisterUpdateListener(listener UpdateListener) {
 	c.listeners = append(c.listeners, listener)
 }
@@ -131,3 +305,11 @@ func OpenAIConfigFromServiceConfig(serviceConfig llm.ServiceConfig) openai.Confi
 		SendUserID:       serviceConfig.SendUserID,
 	}
 }
+
+func VLLMConfigFromServiceConfig(serviceConfig llm.ServiceConfig) vllm.Config {
+	return vllm.Config{
+		OpenAI:              OpenAIConfigFromServiceConfig(serviceConfig),
+		ReplicaURLs:         serviceConfig.ReplicaAPIURLs(),
+		HealthCheckInterval: time.Duration(serviceConfig.HealthCheckIntervalSeconds) * time.Second,
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+// PluginSettingsKey is the key of the plugin settings holding the configuration
+const PluginSettingsKey = "config"
+
+// SavedConfig returns the configuration as saved in the plugin settings, including settings
+// this version of the plugin doesn't know about.
+func SavedConfig(configuration *pluginapi.ConfigurationService) map[string]any {
+	cfg, _ := configuration.GetPluginConfig()[PluginSettingsKey].(map[string]any)
+	if cfg == nil {
+		cfg = map[string]any{}
+	}
+	return cfg
+}
+
+// UpdateSavedConfig changes the configuration as saved in the plugin settings and saves it. The rest of
+// the plugin settings are kept as they are. Nothing is saved when change returns an error, which is
+// returned as it is.
+func UpdateSavedConfig(configuration *pluginapi.ConfigurationService, change func(cfg map[string]any) error) error {
+	pluginConfig := configuration.GetPluginConfig()
+	if pluginConfig == nil {
+		pluginConfig = map[string]any{}
+	}
+	cfg, _ := pluginConfig[PluginSettingsKey].(map[string]any)
+	if cfg == nil {
+		cfg = map[string]any{}
+	}
+
+	if err := change(cfg); err != nil {
+		return err
+	}
+
+	pluginConfig[PluginSettingsKey] = cfg
+	if err := configuration.SavePluginConfig(pluginConfig); err != nil {
+		return fmt.Errorf("unable to save plugin configuration: %w", err)
+	}
+	return nil
+}
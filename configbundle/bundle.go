@@ -0,0 +1,145 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package configbundle exports the plugin configuration as a YAML bundle and imports bundles exported
+// on another server, so a configuration can be promoted from staging to production. Secrets aren't
+// exported, they are replaced with references resolved on the server the bundle is imported into.
+package configbundle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the version of the bundle format
+const Version = 1
+
+var (
+	// ErrInvalidBundle is returned when importing something that isn't a bundle of a supported version.
+	ErrInvalidBundle = errors.New("invalid configuration bundle")
+
+	// ErrUnresolvedSecrets is returned when importing a bundle referencing secrets the server doesn't have.
+	// They have to be set as environment variables of the server first.
+	ErrUnresolvedSecrets = errors.New("unresolved secret references")
+)
+
+// Bundle is the exported configuration.
+type Bundle struct {
+	Version    int            `yaml:"version"`
+	ExportedAt string         `yaml:"exportedAt"`
+	Config     map[string]any `yaml:"config"`
+}
+
+// Export returns the bundle of the configuration as YAML, with its secrets replaced by references.
+func Export(cfg map[string]any, now time.Time) ([]byte, error) {
+	exported, err := normalize(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := Bundle{
+		Version:    Version,
+		ExportedAt: now.UTC().Format(time.RFC3339),
+		Config:     walkSecrets(exported, nil, func(name string, _ string) string { return reference(name) }).(map[string]any),
+	}
+
+	data, err := yaml.Marshal(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal configuration bundle: %w", err)
+	}
+	return data, nil
+}
+
+// Parse reads a bundle exported by Export.
+func Parse(data []byte) (*Bundle, error) {
+	var bundle Bundle
+	if err := yaml.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+	if bundle.Version != Version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidBundle, bundle.Version)
+	}
+	if bundle.Config == nil {
+		return nil, fmt.Errorf("%w: no configuration", ErrInvalidBundle)
+	}
+
+	cfg, err := normalize(bundle.Config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+	bundle.Config = cfg
+	return &bundle, nil
+}
+
+// Resolve returns the configuration of the bundle with its secret references replaced by the value of the
+// environment variable they name or, when the variable isn't set, by the secret at the same place in the
+// current configuration. That way a bundle can be imported back into the server it was exported from.
+// The names of the references that couldn't be resolved are returned in alphabetical order.
+func Resolve(bundle *Bundle, current map[string]any, lookupEnv func(string) (string, bool)) (map[string]any, []string, error) {
+	currentSecrets := map[string]string{}
+	if current != nil {
+		normalized, err := normalize(current)
+		if err != nil {
+			return nil, nil, err
+		}
+		walkSecrets(normalized, nil, func(name string, secret string) string {
+			currentSecrets[name] = secret
+			return secret
+		})
+	}
+
+	unresolved := []string{}
+	resolved := resolveReferences(bundle.Config, func(name string) string {
+		if value, ok := lookupEnv(name); ok {
+			return value
+		}
+		if value, ok := currentSecrets[name]; ok {
+			return value
+		}
+		unresolved = append(unresolved, name)
+		return ""
+	}).(map[string]any)
+
+	sort.Strings(unresolved)
+	return resolved, unresolved, nil
+}
+
+// Changes returns the top level settings that differ between the configurations, in alphabetical order.
+func Changes(current, imported map[string]any) []string {
+	keys := map[string]bool{}
+	for key := range current {
+		keys[key] = true
+	}
+	for key := range imported {
+		keys[key] = true
+	}
+
+	changes := []string{}
+	for key := range keys {
+		if !reflect.DeepEqual(current[key], imported[key]) {
+			changes = append(changes, key)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// normalize converts the configuration to the types JSON decodes into, so configurations read from
+// the plugin settings and from YAML can be compared and saved alike.
+func normalize(cfg map[string]any) (map[string]any, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read configuration: %w", err)
+	}
+	normalized := map[string]any{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, fmt.Errorf("unable to read configuration: %w", err)
+	}
+	return normalized, nil
+}
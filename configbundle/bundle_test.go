@@ -0,0 +1,153 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package configbundle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() map[string]any {
+	return map[string]any{
+		"defaultBotName": "copilot",
+		"bots": []any{
+			map[string]any{
+				"name":               "copilot",
+				"customInstructions": "Be brief.",
+				"service": map[string]any{
+					"type":       "openai",
+					"apiKey":     "sk-secret",
+					"tokenLimit": 128000,
+				},
+			},
+			map[string]any{
+				"name":    "local",
+				"service": map[string]any{"type": "openaicompatible", "apiKey": ""},
+			},
+		},
+		"mcp": map[string]any{
+			"enabled": true,
+			"servers": map[string]any{
+				"github": map[string]any{
+					"baseURL": "https://mcp.example.com",
+					"headers": map[string]any{"Authorization": "Bearer abc"},
+				},
+			},
+		},
+	}
+}
+
+func noEnv(string) (string, bool) {
+	return "", false
+}
+
+func TestExportReplacesSecrets(t *testing.T) {
+	data, err := Export(testConfig(), time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	exported := string(data)
+	assert.NotContains(t, exported, "sk-secret")
+	assert.NotContains(t, exported, "Bearer abc")
+	assert.Contains(t, exported, "${env:MM_AI_BOTS_COPILOT_SERVICE_APIKEY}")
+	assert.Contains(t, exported, "${env:MM_AI_MCP_SERVERS_GITHUB_HEADERS_AUTHORIZATION}")
+
+	bundle, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-05-01T12:00:00Z", bundle.ExportedAt)
+	bots := bundle.Config["bots"].([]any)
+	assert.Equal(t, "", bots[1].(map[string]any)["service"].(map[string]any)["apiKey"], "empty secrets aren't referenced")
+	assert.Equal(t, float64(128000), bots[0].(map[string]any)["service"].(map[string]any)["tokenLimit"])
+}
+
+func TestExportReplacesStdioServerEnv(t *testing.T) {
+	config := map[string]any{
+		"mcp": map[string]any{
+			"servers": map[string]any{
+				"files": map[string]any{
+					"transport": "stdio",
+					"command":   "mcp-files",
+					"env":       map[string]any{"FILES_TOKEN": "abc123"},
+				},
+			},
+		},
+	}
+
+	data, err := Export(config, time.Now())
+	require.NoError(t, err)
+
+	exported := string(data)
+	assert.NotContains(t, exported, "abc123")
+	assert.Contains(t, exported, "${env:MM_AI_MCP_SERVERS_FILES_ENV_FILES_TOKEN}")
+	assert.Contains(t, exported, "mcp-files")
+}
+
+func TestResolve(t *testing.T) {
+	data, err := Export(testConfig(), time.Now())
+	require.NoError(t, err)
+	bundle, err := Parse(data)
+	require.NoError(t, err)
+
+	t.Run("from the current configuration", func(t *testing.T) {
+		resolved, unresolved, err := Resolve(bundle, testConfig(), noEnv)
+		require.NoError(t, err)
+		assert.Empty(t, unresolved)
+
+		current, err := normalize(testConfig())
+		require.NoError(t, err)
+		assert.Equal(t, current, resolved)
+		assert.Empty(t, Changes(current, resolved))
+	})
+
+	t.Run("environment variables take precedence", func(t *testing.T) {
+		env := map[string]string{
+			"MM_AI_BOTS_COPILOT_SERVICE_APIKEY":              "sk-production",
+			"MM_AI_MCP_SERVERS_GITHUB_HEADERS_AUTHORIZATION": "Bearer production",
+		}
+		resolved, unresolved, err := Resolve(bundle, testConfig(), func(name string) (string, bool) {
+			value, ok := env[name]
+			return value, ok
+		})
+		require.NoError(t, err)
+		assert.Empty(t, unresolved)
+
+		service := resolved["bots"].([]any)[0].(map[string]any)["service"].(map[string]any)
+		assert.Equal(t, "sk-production", service["apiKey"])
+
+		current, err := normalize(testConfig())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bots", "mcp"}, Changes(current, resolved))
+	})
+
+	t.Run("unresolved references", func(t *testing.T) {
+		_, unresolved, err := Resolve(bundle, nil, noEnv)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"MM_AI_BOTS_COPILOT_SERVICE_APIKEY", "MM_AI_MCP_SERVERS_GITHUB_HEADERS_AUTHORIZATION"}, unresolved)
+	})
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "not yaml", data: "{"},
+		{name: "unsupported version", data: "version: 2\nconfig:\n  defaultBotName: ai\n"},
+		{name: "no configuration", data: "version: 1\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.data))
+			assert.ErrorIs(t, err, ErrInvalidBundle)
+		})
+	}
+}
+
+func TestSecretName(t *testing.T) {
+	assert.Equal(t, "MM_AI_BOTS_MY_BOT_SERVICE_APIKEY", secretName([]string{"bots", "my-bot", "service", "apiKey"}))
+	assert.Equal(t, "MM_AI_EMBEDDINGSEARCHCONFIG_EMBEDDINGPROVIDER_PARAMETERS_APIKEY", secretName([]string{"embeddingSearchConfig", "embeddingProvider", "parameters", "apiKey"}))
+}
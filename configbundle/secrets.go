@@ -0,0 +1,122 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package configbundle
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// secretNamePrefix starts the names of the environment variables secrets are referenced by
+const secretNamePrefix = "MM_AI"
+
+// secretKeys are the settings holding secrets, compared ignoring case
+var secretKeys = map[string]bool{
+	"apikey":       true,
+	"apisecret":    true,
+	"clientsecret": true,
+	"password":     true,
+	"secret":       true,
+	"token":        true,
+	"accesstoken":  true,
+}
+
+// secretMapKeys hold maps all values of which are treated as secrets, such as the HTTP headers sent to MCP
+// servers and the environment of the MCP servers the plugin launches
+var secretMapKeys = map[string]bool{
+	"headers": true,
+	"env":     true,
+}
+
+// referencePattern matches secret references, such as ${env:MM_AI_BOTS_COPILOT_SERVICE_APIKEY}
+var referencePattern = regexp.MustCompile(`^\$\{env:([A-Z0-9_]+)\}$`)
+
+// nonNameCharacters are replaced when turning a setting path into an environment variable name
+var nonNameCharacters = regexp.MustCompile(`[^A-Z0-9]+`)
+
+func reference(name string) string {
+	return fmt.Sprintf("${env:%s}", name)
+}
+
+// secretName names the secret at the path, such as MM_AI_BOTS_COPILOT_SERVICE_APIKEY for the API key
+// of the service of the bot named copilot.
+func secretName(path []string) string {
+	parts := []string{secretNamePrefix}
+	for _, part := range path {
+		part = strings.Trim(nonNameCharacters.ReplaceAllString(strings.ToUpper(part), "_"), "_")
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+// walkSecrets returns a copy of the value with each non-empty secret replaced by what replace returns for it.
+func walkSecrets(value any, path []string, replace func(name string, secret string) string) any {
+	return walk(value, path, false, replace)
+}
+
+func walk(value any, path []string, isSecret bool, replace func(name string, secret string) string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		walked := make(map[string]any, len(v))
+		for key, child := range v {
+			childPath := append(append([]string{}, path...), key)
+			childIsSecret := isSecret || secretKeys[strings.ToLower(key)] || secretMapKeys[strings.ToLower(key)]
+			walked[key] = walk(child, childPath, childIsSecret, replace)
+		}
+		return walked
+	case []any:
+		walked := make([]any, len(v))
+		for index, child := range v {
+			walked[index] = walk(child, append(append([]string{}, path...), elementName(child, index)), isSecret, replace)
+		}
+		return walked
+	case string:
+		if isSecret && v != "" && !referencePattern.MatchString(v) {
+			return replace(secretName(path), v)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// elementName identifies an element of a list by its name when it has one, by its position otherwise,
+// so references don't change when the elements of a list are reordered.
+func elementName(element any, index int) string {
+	if object, ok := element.(map[string]any); ok {
+		if name, ok := object["name"].(string); ok && name != "" {
+			return name
+		}
+	}
+	return strconv.Itoa(index)
+}
+
+// resolveReferences returns a copy of the value with each secret reference replaced by what resolve returns for it.
+func resolveReferences(value any, resolve func(name string) string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		resolved := make(map[string]any, len(v))
+		for key, child := range v {
+			resolved[key] = resolveReferences(child, resolve)
+		}
+		return resolved
+	case []any:
+		resolved := make([]any, len(v))
+		for index, child := range v {
+			resolved[index] = resolveReferences(child, resolve)
+		}
+		return resolved
+	case string:
+		if match := referencePattern.FindStringSubmatch(v); match != nil {
+			return resolve(match[1])
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// AskedAboutPostProp is set on the first response of a conversation about a post to the post asked about
+	AskedAboutPostProp = "asked_about_post"
+
+	// AskedQuestionProp is set on the first response of a conversation about a post to the question asked
+	AskedQuestionProp = "asked_question"
+
+	maxQuestionLength = 4000
+)
+
+var (
+	// ErrInvalidQuestion is returned when asking about a post without a question, or with one that is too long.
+	ErrInvalidQuestion = errors.New("invalid question")
+
+	// errNoAccessToAskedPost is returned when the user can no longer read the post a conversation is about.
+	errNoAccessToAskedPost = errors.New("user no longer has access to the post asked about")
+)
+
+// AskAboutPost starts a conversation in the direct message of the user with the bot, answering a question
+// about the post with its thread as context. Follow-up questions in the conversation keep that context.
+func (c *Conversations) AskAboutPost(bot *bots.Bot, user *model.User, post *model.Post, channel *model.Channel, question string) (*model.Post, error) {
+	question, err := validateQuestion(question)
+	if err != nil {
+		return nil, err
+	}
+
+	llmContext := c.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		user,
+		channel,
+		c.contextBuilder.WithLLMContextDefaultTools(bot, mmapi.IsDMWith(bot.GetMMBot().UserId, channel)),
+	)
+	stream, err := c.askAboutPost(bot, post.Id, question, llmContext)
+	if err != nil {
+		return nil, err
+	}
+
+	responsePost := &model.Post{
+		Message: c.askAboutPostMessage(user.Locale, post.Id, question),
+	}
+	responsePost.AddProp(AskedAboutPostProp, post.Id)
+	responsePost.AddProp(AskedQuestionProp, question)
+	if err := c.streamingService.StreamToNewDM(context.Background(), bot.GetMMBot().UserId, stream, user.Id, responsePost, post.Id); err != nil {
+		return nil, fmt.Errorf("unable to stream answer: %w", err)
+	}
+
+	go func() {
+		request := "Write a short title for the following question about a message. Include only the title and nothing else, no quotations. Question:\n" + question
+		if err := c.GenerateTitle(bot, request, responsePost.Id, llmContext); err != nil {
+			c.pluginAPI.Log.Error("Failed to generate title", "error", err.Error())
+		}
+	}()
+
+	return responsePost, nil
+}
+
+// validateQuestion returns the question without surrounding spaces, or ErrInvalidQuestion if it is empty
+// or too long.
+func validateQuestion(question string) (string, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return "", fmt.Errorf("%w: the question is empty", ErrInvalidQuestion)
+	}
+	if utf8.RuneCountInString(question) > maxQuestionLength {
+		return "", fmt.Errorf("%w: the question is longer than %d characters", ErrInvalidQuestion, maxQuestionLength)
+	}
+	return question, nil
+}
+
+// askAboutPost answers the question about the post.
+func (c *Conversations) askAboutPost(bot *bots.Bot, postID, question string, llmContext *llm.Context) (*llm.TextStreamResult, error) {
+	systemPrompt, err := c.askAboutPostSystemPrompt(bot, postID, llmContext)
+	if err != nil {
+		return nil, err
+	}
+
+	return bot.LLM().ChatCompletion(llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: question},
+		},
+		Context: llmContext,
+	})
+}
+
+// askAboutPostSystemPrompt gives the post asked about and its thread to the bot, if the requesting user can
+// still read them with the bot.
+func (c *Conversations) askAboutPostSystemPrompt(bot *bots.Bot, postID string, llmContext *llm.Context) (string, error) {
+	post, err := c.pluginAPI.Post.GetPost(postID)
+	if err != nil {
+		return "", fmt.Errorf("unable to get post asked about: %w", err)
+	}
+	postChannel, err := c.pluginAPI.Channel.Get(post.ChannelId)
+	if err != nil {
+		return "", fmt.Errorf("unable to get channel of post asked about: %w", err)
+	}
+	if !c.pluginAPI.User.HasPermissionToChannel(llmContext.RequestingUser.Id, postChannel.Id, model.PermissionReadChannel) ||
+		c.bots.CheckUsageRestrictions(llmContext.RequestingUser.Id, bot, postChannel) != nil {
+		return "", errNoAccessToAskedPost
+	}
+
+	thread, err := mmapi.GetThreadData(c.mmClient, threadRootID(post))
+	if err != nil {
+		return "", fmt.Errorf("unable to get thread of post asked about: %w", err)
+	}
+	author := post.UserId
+	if user, ok := thread.UsersByID[post.UserId]; ok {
+		author = user.Username
+	}
+
+	llmContext.Parameters = map[string]any{
+		"Message": fmt.Sprintf("%s: %s", author, format.PostBody(post)),
+		"Thread":  format.ThreadData(thread),
+	}
+	systemPrompt, err := c.prompts.Format(prompts.PromptAskAboutPostSystem, llmContext)
+	if err != nil {
+		return "", fmt.Errorf("failed to format prompt: %w", err)
+	}
+	return systemPrompt, nil
+}
+
+// askAboutPostMessage links the post asked about and quotes the question above the answer.
+func (c *Conversations) askAboutPostMessage(locale, postID, question string) string {
+	T := i18n.LocalizerFunc(c.i18n, locale)
+	siteURL := *c.pluginAPI.Configuration.GetConfig().ServiceSettings.SiteURL
+	return T("copilot.ask_about_post", "You asked about this message: %s/_redirect/pl/%s\n", siteURL, postID) +
+		quoteMarkdown(question) + "\n\n"
+}
+
+// quoteMarkdown formats the text as a markdown block quote.
+func quoteMarkdown(text string) string {
+	return "> " + strings.ReplaceAll(text, "\n", "\n> ")
+}
+
+// askAboutPostConversationToLLMPosts converts a conversation about a post to LLM posts, giving the post and
+// its thread to the bot again. The user is told when they can no longer read the post.
+func (c *Conversations) askAboutPostConversationToLLMPosts(bot *bots.Bot, conversation *mmapi.ThreadData, postID string, llmContext *llm.Context) ([]llm.Post, error) {
+	systemPrompt, err := c.askAboutPostSystemPrompt(bot, postID, llmContext)
+	if errors.Is(err, errNoAccessToAskedPost) {
+		T := i18n.LocalizerFunc(c.i18n, llmContext.RequestingUser.Locale)
+		responsePost := &model.Post{
+			ChannelId: llmContext.Channel.Id,
+			RootId:    conversation.Posts[0].Id,
+			Message:   T("copilot.no_longer_access_error", "Sorry, you no longer have access to the original thread."),
+		}
+		if createErr := c.BotCreateNonResponsePost(bot.GetMMBot().UserId, llmContext.RequestingUser.Id, responsePost); createErr != nil {
+			return nil, createErr
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	question, _ := conversation.Posts[0].GetProp(AskedQuestionProp).(string)
+	posts := []llm.Post{
+		{Role: llm.PostRoleSystem, Message: systemPrompt},
+		{Role: llm.PostRoleUser, Message: question},
+	}
+	return append(posts, c.ThreadToLLMPosts(bot, conversation.Posts)...), nil
+}
+
+// regenerateAskAboutPost answers the question of a conversation about a post again.
+func (c *Conversations) regenerateAskAboutPost(bot *bots.Bot, user *model.User, channel *model.Channel, post *model.Post, askedAboutPostID string, opts ...llm.ContextOption) (*llm.TextStreamResult, error) {
+	question, _ := post.GetProp(AskedQuestionProp).(string)
+	post.Message = c.askAboutPostMessage(user.Locale, askedAboutPostID, question)
+
+	llmContext := c.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		user,
+		channel,
+		append([]llm.ContextOption{c.contextBuilder.WithLLMContextDefaultTools(bot, mmapi.IsDMWith(bot.GetMMBot().UserId, channel))}, opts...)...,
+	)
+	return c.askAboutPost(bot, askedAboutPostID, question, llmContext)
+}
+
+// isAskAboutPostConversation returns the post a conversation is about, if the bot started it to answer a
+// question about a post.
+func isAskAboutPostConversation(bot *bots.Bot, rootPost *model.Post) (string, bool) {
+	postID, ok := rootPost.GetProp(AskedAboutPostProp).(string)
+	if !ok || postID == "" || rootPost.UserId != bot.GetMMBot().UserId {
+		return "", false
+	}
+	return postID, true
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteMarkdown(t *testing.T) {
+	assert.Equal(t, "> what does this mean?", quoteMarkdown("what does this mean?"))
+	assert.Equal(t, "> first line\n> second line", quoteMarkdown("first line\nsecond line"))
+}
+
+func TestIsAskAboutPostConversation(t *testing.T) {
+	bot := bots.NewBot(llm.BotConfig{Name: "ai"}, &model.Bot{UserId: "botid", Username: "ai"})
+
+	tests := []struct {
+		name           string
+		rootPost       *model.Post
+		expectedPostID string
+		expectedOK     bool
+	}{
+		{
+			name:           "started by the bot about a post",
+			rootPost:       &model.Post{UserId: "botid", Props: model.StringInterface{AskedAboutPostProp: "postid"}},
+			expectedPostID: "postid",
+			expectedOK:     true,
+		},
+		{
+			name:     "regular conversation",
+			rootPost: &model.Post{UserId: "botid"},
+		},
+		{
+			name:     "prop set by someone else",
+			rootPost: &model.Post{UserId: "userid", Props: model.StringInterface{AskedAboutPostProp: "postid"}},
+		},
+		{
+			name:     "empty post id",
+			rootPost: &model.Post{UserId: "botid", Props: model.StringInterface{AskedAboutPostProp: ""}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			postID, ok := isAskAboutPostConversation(bot, test.rootPost)
+			assert.Equal(t, test.expectedOK, ok)
+			assert.Equal(t, test.expectedPostID, postID)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/chunking"
+	"github.com/mattermost/mattermost-plugin-ai/docextract"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// IngestedFilesProp lists the files attached to the request whose text was given to the bot, on its response
+	IngestedFilesProp = "ingested_files"
+
+	// AttachmentTokenRatio is the share of the model's input limit the text of the files attached to a
+	// message can take. Files are read in order, and a file that doesn't fit is cut.
+	AttachmentTokenRatio = 0.3
+
+	// attachmentChunkTokens is the size of the parts files are cut on
+	attachmentChunkTokens = 1000
+)
+
+// IngestedFile is a file attached to a request whose text was given to the bot.
+type IngestedFile struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	// Truncated is set when only the beginning of the file was given to the bot
+	Truncated bool `json:"truncated"`
+}
+
+// fitChunks returns the leading chunks that fit in the token budget, the tokens they take, and whether
+// some chunks were left out.
+func fitChunks(chunks []string, budget int, countTokens func(string) int) ([]string, int, bool) {
+	used := 0
+	for i, chunk := range chunks {
+		tokens := countTokens(chunk)
+		if used+tokens > budget {
+			return chunks[:i], used, true
+		}
+		used += tokens
+	}
+	return chunks, used, false
+}
+
+// formatAttachment formats the text of a file for the bot, noting when only its beginning is given.
+func formatAttachment(name string, chunks []string, truncated bool) string {
+	content := fmt.Sprintf("File Name: %s\nContent: %s", name, strings.Join(chunks, "\n"))
+	if truncated {
+		content += "\n... (content truncated, only the beginning of the file fits)"
+	}
+	return content
+}
+
+// withAttachments adds the text of the attached files to a message.
+func withAttachments(message string, attachments []string) string {
+	if len(attachments) == 0 {
+		return message
+	}
+	return message + "\nAttached File Contents:\n" + strings.Join(attachments, "\n\n")
+}
+
+func (c *Conversations) postFileInfos(post *model.Post) []*model.FileInfo {
+	fileInfos := make([]*model.FileInfo, 0, len(post.FileIds))
+	for _, fileID := range post.FileIds {
+		fileInfo, err := c.pluginAPI.File.GetInfo(fileID)
+		if err != nil {
+			c.pluginAPI.Log.Error("Error getting file info", "error", err)
+			continue
+		}
+		fileInfos = append(fileInfos, fileInfo)
+	}
+	return fileInfos
+}
+
+// fileText returns the text of a file, as extracted by the server when it extracts the content of
+// files, and by the plugin otherwise. Files whose text can't be extracted have none.
+func (c *Conversations) fileText(fileInfo *model.FileInfo, maxFileSize int64) (string, bool, error) {
+	if content := strings.TrimSpace(fileInfo.Content); content != "" {
+		return content, false, nil
+	}
+	if !docextract.Supported(fileInfo.Name, fileInfo.MimeType) {
+		return "", false, nil
+	}
+
+	file, err := c.pluginAPI.File.Get(fileInfo.Id)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get file: %w", err)
+	}
+	return docextract.Extract(file, fileInfo.Name, fileInfo.MimeType, maxFileSize)
+}
+
+// attachmentContents extracts the text of the attached files, cut on parts to fit the share of the
+// bot's input limit attachments can take, and returns it with the files it comes from.
+func (c *Conversations) attachmentContents(bot *bots.Bot, fileInfos []*model.FileInfo) ([]string, []IngestedFile) {
+	maxFileSize := defaultMaxFileSize
+	if bot.GetConfig().MaxFileSize > 0 {
+		maxFileSize = bot.GetConfig().MaxFileSize
+	}
+	budget := int(float64(bot.LLM().InputTokenLimit()) * AttachmentTokenRatio)
+
+	var contents []string
+	var ingested []IngestedFile
+	for _, fileInfo := range fileInfos {
+		text, cut, err := c.fileText(fileInfo, maxFileSize)
+		if err != nil {
+			c.pluginAPI.Log.Warn("Unable to read attached file", "file_id", fileInfo.Id, "error", err)
+			continue
+		}
+		if text == "" {
+			continue
+		}
+
+		// Tokens are roughly four characters
+		chunks := chunking.SplitPlaintextOnSentences(text, attachmentChunkTokens*4)
+		included, used, truncated := fitChunks(chunks, budget, bot.LLM().CountTokens)
+		if len(included) == 0 {
+			c.pluginAPI.Log.Debug("No room left for attached file", "file_id", fileInfo.Id)
+			continue
+		}
+		budget -= used
+
+		truncated = truncated || cut
+		contents = append(contents, formatAttachment(fileInfo.Name, included, truncated))
+		ingested = append(ingested, IngestedFile{
+			ID:        fileInfo.Id,
+			Name:      fileInfo.Name,
+			Truncated: truncated,
+		})
+	}
+	return contents, ingested
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFitChunks(t *testing.T) {
+	countTokens := func(text string) int {
+		return len(text)
+	}
+
+	tests := []struct {
+		name              string
+		chunks            []string
+		budget            int
+		expectedChunks    []string
+		expectedUsed      int
+		expectedTruncated bool
+	}{
+		{
+			name:           "everything fits",
+			chunks:         []string{"abc", "de"},
+			budget:         5,
+			expectedChunks: []string{"abc", "de"},
+			expectedUsed:   5,
+		},
+		{
+			name:              "leading chunks that fit",
+			chunks:            []string{"abc", "de", "f"},
+			budget:            4,
+			expectedChunks:    []string{"abc"},
+			expectedUsed:      3,
+			expectedTruncated: true,
+		},
+		{
+			name:              "no room left",
+			chunks:            []string{"abc"},
+			budget:            2,
+			expectedChunks:    []string{},
+			expectedTruncated: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			chunks, used, truncated := fitChunks(tc.chunks, tc.budget, countTokens)
+			assert.Equal(t, tc.expectedChunks, chunks)
+			assert.Equal(t, tc.expectedUsed, used)
+			assert.Equal(t, tc.expectedTruncated, truncated)
+		})
+	}
+}
+
+func TestWithAttachments(t *testing.T) {
+	assert.Equal(t, "Summarize this", withAttachments("Summarize this", nil))
+	assert.Equal(t,
+		"Summarize this\nAttached File Contents:\nFile Name: a.txt\nContent: one\ntwo\n\nFile Name: b.pdf\nContent: three\n... (content truncated, only the beginning of the file fits)",
+		withAttachments("Summarize this", []string{
+			formatAttachment("a.txt", []string{"one", "two"}, false),
+			formatAttachment("b.pdf", []string{"three"}, true),
+		}),
+	)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+var (
+	// ErrUnknownBot is returned when switching a thread to a bot that doesn't exist.
+	ErrUnknownBot = errors.New("unknown bot")
+
+	// ErrBotSwitchNotDM is returned when switching the bot of a thread that isn't a DM with a bot.
+	ErrBotSwitchNotDM = errors.New("only direct message conversations with a bot can switch bots")
+)
+
+// servingBot returns the bot answering a conversation held with the conversation bot: the active bot of
+// the thread when it still exists and the user can use it, the conversation bot otherwise.
+func servingBot(conversationBot *bots.Bot, activeBotID string, getBot func(botID string) *bots.Bot, canUse func(bot *bots.Bot) bool) *bots.Bot {
+	if activeBotID == "" || activeBotID == conversationBot.GetMMBot().UserId {
+		return conversationBot
+	}
+	activeBot := getBot(activeBotID)
+	if activeBot == nil || !canUse(activeBot) {
+		return conversationBot
+	}
+	return activeBot
+}
+
+// threadServingBot returns the bot whose model answers the thread for the user. Responses are still
+// posted by the conversation bot, only the model serving them changes.
+func (c *Conversations) threadServingBot(conversationBot *bots.Bot, threadID, userID string) (*bots.Bot, error) {
+	activeBotID, err := c.GetActiveBot(threadID)
+	if err != nil {
+		return nil, err
+	}
+	return servingBot(conversationBot, activeBotID, c.bots.GetBotByID, func(bot *bots.Bot) bool {
+		return c.bots.CheckUsageRestrictionsForUser(bot, userID) == nil
+	}), nil
+}
+
+// ThreadBot returns the username of the bot answering the DM conversation the post belongs to.
+func (c *Conversations) ThreadBot(userID string, post *model.Post, channel *model.Channel) (string, error) {
+	conversationBot := c.bots.GetBotForDMChannel(channel)
+	if conversationBot == nil || !mmapi.IsDMWith(userID, channel) {
+		return "", ErrBotSwitchNotDM
+	}
+	bot, err := c.threadServingBot(conversationBot, threadRootID(post), userID)
+	if err != nil {
+		return "", err
+	}
+	return bot.GetMMBot().Username, nil
+}
+
+// SetThreadBot switches the DM conversation the post belongs to to the bot, so the following responses
+// are written by its model with the conversation so far. Switching to the conversation bot, or to no bot,
+// goes back to its own model.
+func (c *Conversations) SetThreadBot(userID string, post *model.Post, channel *model.Channel, botUsername string) error {
+	conversationBot := c.bots.GetBotForDMChannel(channel)
+	if conversationBot == nil || !mmapi.IsDMWith(userID, channel) {
+		return ErrBotSwitchNotDM
+	}
+
+	activeBotID := ""
+	if botUsername != "" {
+		bot := c.bots.GetBotByUsername(botUsername)
+		if bot == nil {
+			return fmt.Errorf("%w: %q", ErrUnknownBot, botUsername)
+		}
+		if err := c.bots.CheckUsageRestrictionsForUser(bot, userID); err != nil {
+			return err
+		}
+		if bot.GetMMBot().UserId != conversationBot.GetMMBot().UserId {
+			activeBotID = bot.GetMMBot().UserId
+		}
+	}
+
+	if err := c.SaveActiveBot(threadRootID(post), activeBotID); err != nil {
+		return fmt.Errorf("failed to save thread bot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServingBot(t *testing.T) {
+	small := bots.NewBot(llm.BotConfig{Name: "small"}, &model.Bot{UserId: "smallid", Username: "small"})
+	large := bots.NewBot(llm.BotConfig{Name: "large"}, &model.Bot{UserId: "largeid", Username: "large"})
+	getBot := func(botID string) *bots.Bot {
+		for _, bot := range []*bots.Bot{small, large} {
+			if bot.GetMMBot().UserId == botID {
+				return bot
+			}
+		}
+		return nil
+	}
+	canUseAll := func(*bots.Bot) bool { return true }
+
+	tests := []struct {
+		name        string
+		activeBotID string
+		canUse      func(*bots.Bot) bool
+		expected    *bots.Bot
+	}{
+		{name: "not switched", activeBotID: "", canUse: canUseAll, expected: small},
+		{name: "switched", activeBotID: "largeid", canUse: canUseAll, expected: large},
+		{name: "switched back", activeBotID: "smallid", canUse: canUseAll, expected: small},
+		{name: "active bot removed", activeBotID: "removedid", canUse: canUseAll, expected: small},
+		{name: "active bot restricted", activeBotID: "largeid", canUse: func(*bots.Bot) bool { return false }, expected: small},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Same(t, tc.expected, servingBot(small, tc.activeBotID, getBot, tc.canUse))
+		})
+	}
+}
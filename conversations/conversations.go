@@ -7,10 +7,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"strings"
 
 	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
 	"github.com/mattermost/mattermost-plugin-ai/format"
 	"github.com/mattermost/mattermost-plugin-ai/i18n"
@@ -23,6 +23,7 @@ import (
 	"github.com/mattermost/mattermost-plugin-ai/threads"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
 )
 
 const ThreadIDProp = "referenced_thread"
@@ -32,6 +33,7 @@ const AnalysisTypeProp = "prompt_type"
 type AIThread struct {
 	ID        string `json:"id"`
 	Title     string `json:"title"`
+	Category  string `json:"category"`
 	ChannelID string `json:"channel_id"`
 	BotID     string `json:"bot_id"`
 	UpdatedAt int64  `json:"updated_at"`
@@ -41,6 +43,7 @@ type Conversations struct {
 	prompts          *llm.Prompts
 	mmClient         mmapi.Client
 	pluginAPI        *pluginapi.Client
+	mutexAPI         cluster.MutexPluginAPI
 	streamingService streaming.Service
 	contextBuilder   *llmcontext.Builder
 	bots             *bots.MMBots
@@ -48,18 +51,31 @@ type Conversations struct {
 	licenseChecker   *enterprise.LicenseChecker
 	i18n             *i18n.Bundle
 	meetingsService  MeetingsService
+	config           Config
+	samplingWaiters  samplingWaiters
+}
+
+// Config is the configuration the conversations service needs
+type Config interface {
+	ToolApprovalConfig
+	GetThreadTagging() config.ThreadTagging
+	GetPersonas() []config.Persona
+	GetEnableFollowUpSuggestions() bool
 }
 
 // MeetingsService defines the interface for meetings functionality needed by conversations
 type MeetingsService interface {
-	GetCaptionsFileIDFromProps(post *model.Post) (fileID string, err error)
-	SummarizeTranscription(bot *bots.Bot, transcription *subtitles.Subtitles, context *llm.Context) (*llm.TextStreamResult, error)
+	TranscriptFileIDs(post *model.Post) (transcriptFileID string, recordingFileID string, err error)
+	SummarizeTranscription(bot *bots.Bot, transcription *subtitles.Subtitles, context *llm.Context, format string, recordingFileID string) (*llm.TextStreamResult, error)
+	TranscriptQuestionPrompt(bot *bots.Bot, threadPosts []*model.Post, question string, context *llm.Context) (string, error)
+	TranscriptForLLM(post *model.Post) (string, error)
 }
 
 func New(
 	prompts *llm.Prompts,
 	mmClient mmapi.Client,
 	pluginAPI *pluginapi.Client,
+	mutexAPI cluster.MutexPluginAPI,
 	streamingService streaming.Service,
 	contextBuilder *llmcontext.Builder,
 	botsService *bots.MMBots,
@@ -67,11 +83,13 @@ func New(
 	licenseChecker *enterprise.LicenseChecker,
 	i18nBundle *i18n.Bundle,
 	meetingsService MeetingsService,
+	cfg Config,
 ) *Conversations {
 	return &Conversations{
 		prompts:          prompts,
 		mmClient:         mmClient,
 		pluginAPI:        pluginAPI,
+		mutexAPI:         mutexAPI,
 		streamingService: streamingService,
 		contextBuilder:   contextBuilder,
 		bots:             botsService,
@@ -79,6 +97,7 @@ func New(
 		licenseChecker:   licenseChecker,
 		i18n:             i18nBundle,
 		meetingsService:  meetingsService,
+		config:           cfg,
 	}
 }
 
@@ -89,6 +108,17 @@ func (c *Conversations) SetMeetingsService(meetingsService MeetingsService) {
 
 // ProcessUserRequestWithContext is an internal helper that uses an existing context to process a message
 func (c *Conversations) ProcessUserRequestWithContext(bot *bots.Bot, postingUser *model.User, channel *model.Channel, post *model.Post, context *llm.Context) (*llm.TextStreamResult, error) {
+	personaOpts, err := c.usePersona(threadRootID(post), context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread persona: %w", err)
+	}
+
+	// Conversations escalated to another bot keep their history and are answered by its model
+	servingBot, err := c.threadServingBot(bot, threadRootID(post), postingUser.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread bot: %w", err)
+	}
+
 	var posts []llm.Post
 	if post.RootId == "" {
 		// A new conversation
@@ -110,33 +140,55 @@ func (c *Conversations) ProcessUserRequestWithContext(bot *bots.Bot, postingUser
 		}
 		previousConversation.CutoffBeforePostID(post.Id)
 
-		var err error
-		posts, err = c.existingConversationToLLMPosts(bot, previousConversation, context)
+		// Follow-up questions in meeting summary threads are answered from the transcript
+		transcriptPrompt, err := c.meetingsService.TranscriptQuestionPrompt(bot, previousConversation.Posts, post.Message, context)
 		if err != nil {
-			return nil, fmt.Errorf("failed to convert existing conversation to LLM posts: %w", err)
+			return nil, fmt.Errorf("failed to get transcript for follow-up question: %w", err)
+		}
+
+		if transcriptPrompt != "" {
+			posts = append([]llm.Post{{
+				Role:    llm.PostRoleSystem,
+				Message: transcriptPrompt,
+			}}, c.ThreadToLLMPosts(bot, previousConversation.Posts)...)
+		} else {
+			posts, err = c.existingConversationToLLMPosts(bot, previousConversation, context)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert existing conversation to LLM posts: %w", err)
+			}
 		}
 	}
 
+	// Documents attached to the request are read along with it
+	attachments, ingested := c.attachmentContents(bot, c.postFileInfos(post))
 	posts = append(posts, llm.Post{
 		Role:    llm.PostRoleUser,
-		Message: post.Message,
+		Message: withAttachments(post.Message, attachments),
 	})
 
 	completionRequest := llm.CompletionRequest{
 		Posts:   posts,
 		Context: context,
 	}
-	result, err := bot.LLM().ChatCompletion(completionRequest)
+	result, err := servingBot.LLM().ChatCompletion(completionRequest, personaOpts...)
 	if err != nil {
 		return nil, err
 	}
+	if len(ingested) > 0 {
+		result = result.WithProps(map[string]any{IngestedFilesProp: ingested})
+	}
 
 	go func() {
 		request := "Write a short title for the following request. Include only the title and nothing else, no quotations. Request:\n" + post.Message
 		if err := c.GenerateTitle(bot, request, post.Id, context); err != nil {
 			c.pluginAPI.Log.Error("Failed to generate title", "error", err.Error())
+		}
+		if post.RootId != "" {
 			return
 		}
+		if err := c.ClassifyThread(bot, post.Message, post.Id, context); err != nil {
+			c.pluginAPI.Log.Error("Failed to classify thread", "error", err.Error())
+		}
 	}()
 
 	return result, nil
@@ -159,14 +211,19 @@ func (c *Conversations) GenerateTitle(bot *bots.Bot, request string, postID stri
 	titleRequest := llm.CompletionRequest{
 		Posts:   []llm.Post{{Role: llm.PostRoleUser, Message: request}},
 		Context: context,
+		// Titles are a single line, anything after it is the model explaining itself
+		StopSequences: []string{"\n"},
 	}
 
-	conversationTitle, err := bot.LLM().ChatCompletionNoStream(titleRequest, llm.WithMaxGeneratedTokens(25))
+	conversationTitle, err := bot.LLM().ChatCompletionNoStream(titleRequest, llm.WithMaxGeneratedTokens(25), llm.WithFeature(llm.FeatureTitles))
 	if err != nil {
 		return fmt.Errorf("failed to get title: %w", err)
 	}
 
 	conversationTitle = strings.Trim(conversationTitle, "\n \"'")
+	if conversationTitle == "" {
+		return errors.New("generated title is empty")
+	}
 
 	if err := c.SaveTitle(postID, conversationTitle); err != nil {
 		return fmt.Errorf("failed to save title: %w", err)
@@ -216,6 +273,11 @@ func (c *Conversations) existingConversationToLLMPosts(bot *bots.Bot, conversati
 		return posts, nil
 	}
 
+	// Questions about a post keep the post and its thread as context
+	if askedAboutPostID, ok := isAskAboutPostConversation(bot, conversation.Posts[0]); ok {
+		return c.askAboutPostConversationToLLMPosts(bot, conversation, askedAboutPostID, context)
+	}
+
 	// Plain DM conversation
 	prompt, err := c.prompts.Format(prompts.PromptDirectMessageQuestionSystem, context)
 	if err != nil {
@@ -232,8 +294,8 @@ func (c *Conversations) existingConversationToLLMPosts(bot *bots.Bot, conversati
 	return posts, nil
 }
 
-// GetAIThreads gets AI conversation threads for a user
-func (c *Conversations) GetAIThreads(userID string) ([]AIThread, error) {
+// GetAIThreads gets AI conversation threads for a user, only those of the category when one is given
+func (c *Conversations) GetAIThreads(userID string, category string) ([]AIThread, error) {
 	allBots := c.bots.GetAllBots()
 
 	dmChannelIDs := []string{}
@@ -258,7 +320,7 @@ func (c *Conversations) GetAIThreads(userID string) ([]AIThread, error) {
 		dmChannelIDs = append(dmChannelIDs, botDMChannel.Id)
 	}
 
-	return c.getAIThreads(dmChannelIDs)
+	return c.getAIThreads(dmChannelIDs, category)
 }
 
 const defaultMaxFileSize = int64(1024 * 1024 * 5) // 5MB
@@ -280,49 +342,13 @@ func isImageMimeType(mimeType string) bool {
 
 func (c *Conversations) PostToAIPost(bot *bots.Bot, post *model.Post) llm.Post {
 	var filesForUpstream []llm.File
-	message := format.PostBody(post)
-	var extractedFileContents []string
-
-	maxFileSize := defaultMaxFileSize
-	if bot.GetConfig().MaxFileSize > 0 {
-		maxFileSize = bot.GetConfig().MaxFileSize
-	}
+	fileInfos := c.postFileInfos(post)
+	attachments, _ := c.attachmentContents(bot, fileInfos)
+	message := withAttachments(format.PostBody(post), attachments)
 
-	for _, fileID := range post.FileIds {
-		fileInfo, err := c.pluginAPI.File.GetInfo(fileID)
-		if err != nil {
-			c.pluginAPI.Log.Error("Error getting file info", "error", err)
-			continue
-		}
-
-		// Check for files that have been interpreted already by the server or are text files.
-		content := ""
-		if trimmedContent := strings.TrimSpace(fileInfo.Content); trimmedContent != "" {
-			content = trimmedContent
-		} else if strings.HasPrefix(fileInfo.MimeType, "text/") {
-			file, err := c.pluginAPI.File.Get(fileID)
-			if err != nil {
-				c.pluginAPI.Log.Error("Error getting file", "error", err)
-				continue
-			}
-			contentBytes, err := io.ReadAll(io.LimitReader(file, maxFileSize))
-			if err != nil {
-				c.pluginAPI.Log.Error("Error reading file content", "error", err)
-				continue
-			}
-			content = string(contentBytes)
-			if int64(len(contentBytes)) == maxFileSize {
-				content += "\n... (content truncated due to size limit)"
-			}
-		}
-
-		if content != "" {
-			fileContent := fmt.Sprintf("File Name: %s\nContent: %s", fileInfo.Name, content)
-			extractedFileContents = append(extractedFileContents, fileContent)
-		}
-
-		if bot.GetConfig().EnableVision && isImageMimeType(fileInfo.MimeType) {
-			file, err := c.pluginAPI.File.Get(fileID)
+	for _, fileInfo := range fileInfos {
+		if bot.GetConfig().Capabilities().Vision && isImageMimeType(fileInfo.MimeType) {
+			file, err := c.pluginAPI.File.Get(fileInfo.Id)
 			if err != nil {
 				c.pluginAPI.Log.Error("Error getting file", "error", err)
 				continue
@@ -335,14 +361,15 @@ func (c *Conversations) PostToAIPost(bot *bots.Bot, post *model.Post) llm.Post {
 		}
 	}
 
-	// Add structured file contents to the message
-	if len(extractedFileContents) > 0 {
-		message += "\nAttached File Contents:\n" + strings.Join(extractedFileContents, "\n\n")
-	}
-
 	role := llm.PostRoleUser
 	if c.bots.IsAnyBot(post.UserId) {
 		role = llm.PostRoleBot
+		// Other bots of a multi-bot thread are named so the bot doesn't take their answers for its own
+		if post.UserId != bot.GetMMBot().UserId {
+			if otherBot := c.bots.GetBotByID(post.UserId); otherBot != nil {
+				message = otherBotMessage(otherBot.GetMMBot().Username, message)
+			}
+		}
 	}
 
 	// Check for tools
@@ -366,11 +393,19 @@ func (c *Conversations) PostToAIPost(bot *bots.Bot, post *model.Post) llm.Post {
 	}
 }
 
+// otherBotMessage labels the message of another bot of the thread with its username.
+func otherBotMessage(username, message string) string {
+	return fmt.Sprintf("[@%s, another assistant in this conversation]: %s", username, message)
+}
+
 func (c *Conversations) ThreadToLLMPosts(bot *bots.Bot, posts []*model.Post) []llm.Post {
 	result := make([]llm.Post, 0, len(posts))
+	pinned := c.pinnedContextPostIDs(posts)
 
 	for _, post := range posts {
-		result = append(result, c.PostToAIPost(bot, post))
+		aiPost := c.PostToAIPost(bot, post)
+		aiPost.Pinned = pinned[post.Id]
+		result = append(result, aiPost)
 	}
 
 	return result
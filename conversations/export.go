@@ -0,0 +1,237 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	ExportRoleUser = "user"
+	ExportRoleBot  = "bot"
+)
+
+// Conversation export formats
+const (
+	ExportFormatMarkdown = "md"
+	ExportFormatJSON     = "json"
+)
+
+// ErrUnknownExportFormat is returned when exporting a conversation in a format that doesn't exist.
+var ErrUnknownExportFormat = errors.New("unknown export format")
+
+// ExportedConversation is an AI conversation as exported for archiving and sharing outside Mattermost.
+type ExportedConversation struct {
+	RootPostID string            `json:"root_post_id"`
+	ChannelID  string            `json:"channel_id"`
+	Title      string            `json:"title"`
+	ExportedAt int64             `json:"exported_at"`
+	Messages   []ExportedMessage `json:"messages"`
+}
+
+// ExportedMessage is a post of an exported conversation.
+type ExportedMessage struct {
+	PostID    string             `json:"post_id"`
+	UserID    string             `json:"user_id"`
+	Username  string             `json:"username"`
+	Role      string             `json:"role"`
+	Message   string             `json:"message"`
+	CreateAt  int64              `json:"create_at"`
+	ToolCalls []ExportedToolCall `json:"tool_calls,omitempty"`
+}
+
+// ConversationExport is an exported conversation formatted as a file.
+type ConversationExport struct {
+	Filename    string
+	ContentType string
+	Content     string
+}
+
+// ExportedToolCall is a tool call made by the bot in a post, with its result.
+type ExportedToolCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Status    string          `json:"status"`
+	Result    string          `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+var toolCallStatusNames = map[llm.ToolCallStatus]string{
+	llm.ToolCallStatusPending:  "pending",
+	llm.ToolCallStatusAccepted: "accepted",
+	llm.ToolCallStatusRejected: "rejected",
+	llm.ToolCallStatusError:    "error",
+	llm.ToolCallStatusSuccess:  "success",
+	llm.ToolCallStatusTimedOut: "timed_out",
+}
+
+// exportPost converts a post of a conversation for export, tool calls that can't be read are left out.
+func exportPost(post *model.Post, username string, isBot bool) ExportedMessage {
+	message := ExportedMessage{
+		PostID:   post.Id,
+		UserID:   post.UserId,
+		Username: username,
+		Role:     ExportRoleUser,
+		Message:  format.PostBody(post),
+		CreateAt: post.CreateAt,
+	}
+	if isBot {
+		message.Role = ExportRoleBot
+	}
+
+	toolsJSON, ok := post.GetProp(streaming.ToolCallProp).(string)
+	if !ok {
+		return message
+	}
+	var tools []llm.ToolCall
+	if err := json.Unmarshal([]byte(toolsJSON), &tools); err != nil {
+		return message
+	}
+	for _, tool := range tools {
+		message.ToolCalls = append(message.ToolCalls, ExportedToolCall{
+			Name:      tool.Name,
+			Arguments: tool.Arguments,
+			Status:    toolCallStatusNames[tool.Status],
+			Result:    tool.Result,
+			Error:     tool.Error,
+		})
+	}
+	return message
+}
+
+// ExportConversation exports the thread the post belongs to, with the tool calls made by the bots and
+// their results, in one of the export formats. System messages aren't part of the conversation and are
+// left out.
+func (c *Conversations) ExportConversation(post *model.Post, format string) (*ConversationExport, error) {
+	if format != ExportFormatMarkdown && format != ExportFormatJSON {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExportFormat, format)
+	}
+
+	conversation, err := c.exportedConversation(post)
+	if err != nil {
+		return nil, err
+	}
+	return formatConversationExport(conversation, format)
+}
+
+func (c *Conversations) exportedConversation(post *model.Post) (*ExportedConversation, error) {
+	rootID := threadRootID(post)
+	threadData, err := mmapi.GetThreadData(c.mmClient, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get conversation: %w", err)
+	}
+
+	meta, err := c.getPostMeta(rootID)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation := &ExportedConversation{
+		RootPostID: rootID,
+		ChannelID:  post.ChannelId,
+		Title:      meta.Title,
+		ExportedAt: model.GetMillis(),
+		Messages:   []ExportedMessage{},
+	}
+	for _, threadPost := range threadData.Posts {
+		if threadPost.IsSystemMessage() {
+			continue
+		}
+		username := ""
+		if user, ok := threadData.UsersByID[threadPost.UserId]; ok {
+			username = user.Username
+		}
+		conversation.Messages = append(conversation.Messages, exportPost(threadPost, username, c.bots.IsAnyBot(threadPost.UserId)))
+	}
+
+	return conversation, nil
+}
+
+// formatConversationExport formats the conversation in one of the export formats.
+func formatConversationExport(conversation *ExportedConversation, format string) (*ConversationExport, error) {
+	export := &ConversationExport{
+		Filename: "conversation-" + conversation.RootPostID + "." + format,
+	}
+	switch format {
+	case ExportFormatMarkdown:
+		export.ContentType = "text/markdown"
+		export.Content = ExportMarkdown(conversation)
+	case ExportFormatJSON:
+		content, err := json.MarshalIndent(conversation, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal conversation: %w", err)
+		}
+		export.ContentType = "application/json"
+		export.Content = string(content)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExportFormat, format)
+	}
+	return export, nil
+}
+
+// ExportMarkdown formats an exported conversation as a Markdown document, with times in UTC.
+func ExportMarkdown(conversation *ExportedConversation) string {
+	var result strings.Builder
+
+	title := conversation.Title
+	if title == "" {
+		title = "AI Conversation"
+	}
+	fmt.Fprintf(&result, "# %s\n\n", title)
+	fmt.Fprintf(&result, "_Exported %s_\n", formatExportTime(conversation.ExportedAt))
+
+	for _, message := range conversation.Messages {
+		fmt.Fprintf(&result, "\n## @%s (%s)\n\n", message.Username, formatExportTime(message.CreateAt))
+		if message.Message != "" {
+			result.WriteString(message.Message)
+			result.WriteString("\n")
+		}
+
+		for _, tool := range message.ToolCalls {
+			fmt.Fprintf(&result, "\n**Tool call: %s** (%s)\n", tool.Name, tool.Status)
+			if len(tool.Arguments) > 0 {
+				fence := codeFence(string(tool.Arguments))
+				fmt.Fprintf(&result, "\nArguments:\n\n%sjson\n%s\n%s\n", fence, tool.Arguments, fence)
+			}
+			if tool.Result != "" {
+				fence := codeFence(tool.Result)
+				fmt.Fprintf(&result, "\nResult:\n\n%s\n%s\n%s\n", fence, tool.Result, fence)
+			}
+			if tool.Error != "" {
+				fmt.Fprintf(&result, "\nError: %s\n", tool.Error)
+			}
+		}
+	}
+
+	return result.String()
+}
+
+func formatExportTime(millis int64) string {
+	return time.UnixMilli(millis).UTC().Format("2006-01-02 15:04 MST")
+}
+
+// codeFence returns a fence for a code block longer than any run of backticks in the content, so the
+// content can't close the block.
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
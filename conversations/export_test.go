@@ -0,0 +1,82 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportMarkdown(t *testing.T) {
+	conversation := &ExportedConversation{
+		RootPostID: "root",
+		Title:      "Release checklist",
+		ExportedAt: 1700000000000,
+		Messages: []ExportedMessage{
+			{Username: "alice", Role: ExportRoleUser, Message: "What's left before the release?", CreateAt: 1699999940000},
+			{
+				Username: "ai",
+				Role:     ExportRoleBot,
+				Message:  "Two issues are still open.",
+				CreateAt: 1699999960000,
+				ToolCalls: []ExportedToolCall{
+					{
+						Name:      "SearchIssues",
+						Arguments: json.RawMessage(`{"state":"open"}`),
+						Status:    "success",
+						Result:    "Use ```make dist``` to build",
+					},
+					{Name: "CreateIssue", Status: "error", Error: "not allowed"},
+				},
+			},
+		},
+	}
+
+	expected := "# Release checklist\n\n" +
+		"_Exported 2023-11-14 22:13 UTC_\n" +
+		"\n## @alice (2023-11-14 22:12 UTC)\n\n" +
+		"What's left before the release?\n" +
+		"\n## @ai (2023-11-14 22:12 UTC)\n\n" +
+		"Two issues are still open.\n" +
+		"\n**Tool call: SearchIssues** (success)\n" +
+		"\nArguments:\n\n```json\n{\"state\":\"open\"}\n```\n" +
+		"\nResult:\n\n````\nUse ```make dist``` to build\n````\n" +
+		"\n**Tool call: CreateIssue** (error)\n" +
+		"\nError: not allowed\n"
+	assert.Equal(t, expected, ExportMarkdown(conversation))
+
+	assert.Contains(t, ExportMarkdown(&ExportedConversation{}), "# AI Conversation\n")
+}
+
+func TestFormatConversationExport(t *testing.T) {
+	conversation := &ExportedConversation{RootPostID: "root", Messages: []ExportedMessage{}}
+
+	tests := []struct {
+		name          string
+		format        string
+		filename      string
+		contentType   string
+		expectedError error
+	}{
+		{name: "markdown", format: ExportFormatMarkdown, filename: "conversation-root.md", contentType: "text/markdown"},
+		{name: "json", format: ExportFormatJSON, filename: "conversation-root.json", contentType: "application/json"},
+		{name: "unknown", format: "pdf", expectedError: ErrUnknownExportFormat},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			export, err := formatConversationExport(conversation, tc.format)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.filename, export.Filename)
+			assert.Equal(t, tc.contentType, export.ContentType)
+			assert.NotEmpty(t, export.Content)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/compose"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// FollowUpSuggestionsProp is set on responses to the follow-up questions suggested to the requester
+const FollowUpSuggestionsProp = "follow_up_suggestions"
+
+// maxFollowUpTokens bounds the tokens generated for the follow-up questions of a response
+const maxFollowUpTokens = 100
+
+// SuggestFollowUps suggests follow-up questions the requester can ask next after a bot answered them,
+// when enabled. The questions are generated in the background with the small model and set on the
+// response, summaries and other analyses don't get any.
+func (c *Conversations) SuggestFollowUps(post *model.Post) {
+	if !c.config.GetEnableFollowUpSuggestions() {
+		return
+	}
+	bot := c.bots.GetBotByID(post.UserId)
+	if bot == nil {
+		return
+	}
+	respondingToID, _ := post.GetProp(streaming.RespondingToProp).(string)
+	if respondingToID == "" || post.GetProp(ThreadIDProp) != nil || IsSummaryPost(post) {
+		return
+	}
+
+	go func() {
+		if err := c.suggestFollowUps(bot, post.Id, respondingToID); err != nil {
+			c.pluginAPI.Log.Warn("Failed to suggest follow-up questions", "post_id", post.Id, "error", err.Error())
+		}
+	}()
+}
+
+func (c *Conversations) suggestFollowUps(bot *bots.Bot, postID, respondingToID string) error {
+	question, err := c.pluginAPI.Post.GetPost(respondingToID)
+	if err != nil {
+		return fmt.Errorf("unable to get question: %w", err)
+	}
+	// The response is read again, it is updated with the suggestions and may have changed since it was streamed
+	response, err := c.pluginAPI.Post.GetPost(postID)
+	if err != nil {
+		return fmt.Errorf("unable to get response: %w", err)
+	}
+	requesterID, _ := response.GetProp(streaming.LLMRequesterUserID).(string)
+	requester, err := c.pluginAPI.User.Get(requesterID)
+	if err != nil {
+		return fmt.Errorf("unable to get requester: %w", err)
+	}
+	channel, err := c.pluginAPI.Channel.Get(response.ChannelId)
+	if err != nil {
+		return fmt.Errorf("unable to get channel: %w", err)
+	}
+
+	llmContext := c.contextBuilder.BuildLLMContextUserRequest(bot, requester, channel)
+	llmContext.Parameters = map[string]any{
+		"Question":       question.Message,
+		"Answer":         response.Message,
+		"MaxSuggestions": compose.MaxSuggestions,
+	}
+	systemPrompt, err := c.prompts.Format(prompts.PromptFollowUpSuggestionsSystem, llmContext)
+	if err != nil {
+		return fmt.Errorf("failed to format prompt: %w", err)
+	}
+	userPrompt, err := c.prompts.Format(prompts.PromptFollowUpSuggestionsUser, llmContext)
+	if err != nil {
+		return fmt.Errorf("failed to format prompt: %w", err)
+	}
+
+	text, err := bot.LLM().ChatCompletionNoStream(llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: userPrompt},
+		},
+		Context: llmContext,
+	}, llm.WithMaxGeneratedTokens(maxFollowUpTokens), llm.WithFeature(llm.FeatureFollowUps))
+	if err != nil {
+		return fmt.Errorf("failed to generate follow-up questions: %w", err)
+	}
+
+	suggestions := compose.ParseSuggestions(text)
+	if len(suggestions) == 0 {
+		return nil
+	}
+	response.AddProp(FollowUpSuggestionsProp, suggestions)
+	if err := c.pluginAPI.Post.UpdatePost(response); err != nil {
+		return fmt.Errorf("unable to save follow-up questions: %w", err)
+	}
+	return nil
+}
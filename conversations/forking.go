@@ -0,0 +1,104 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// ForkedFromProp is set on the posts of a forked conversation to the post they were copied from
+const ForkedFromProp = "forked_from"
+
+// ErrForkNotDM is returned when forking a conversation that isn't a DM with a bot.
+var ErrForkNotDM = errors.New("only direct message conversations with a bot can be forked")
+
+// forkPosts returns the posts of the conversation up to and including the post it is forked from.
+// System messages aren't part of the conversation and are left out.
+func forkPosts(posts []*model.Post, postID string) []*model.Post {
+	var forked []*model.Post
+	for _, post := range posts {
+		if !post.IsSystemMessage() {
+			forked = append(forked, post)
+		}
+		if post.Id == postID {
+			return forked
+		}
+	}
+	return nil
+}
+
+// ForkConversation copies the DM conversation with a bot the post belongs to, up to and including the
+// post, to a new thread in the same DM. The original conversation is left as it is, and the new thread
+// keeps its title and persona, and records the post it was forked from. Returns the root of the new thread.
+func (c *Conversations) ForkConversation(userID string, post *model.Post, channel *model.Channel) (*model.Post, error) {
+	bot := c.bots.GetBotForDMChannel(channel)
+	if bot == nil || !mmapi.IsDMWith(userID, channel) {
+		return nil, ErrForkNotDM
+	}
+	botID := bot.GetMMBot().UserId
+
+	rootID := threadRootID(post)
+	threadData, err := mmapi.GetThreadData(c.mmClient, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get conversation: %w", err)
+	}
+	posts := forkPosts(threadData.Posts, post.Id)
+	if len(posts) == 0 {
+		return nil, errors.New("no posts to fork")
+	}
+
+	var forkRoot *model.Post
+	lastUserPostID := ""
+	for _, original := range posts {
+		forked := &model.Post{
+			ChannelId: channel.Id,
+			Message:   original.Message,
+		}
+		if forkRoot != nil {
+			forked.RootId = forkRoot.Id
+		}
+
+		if len(original.FileIds) > 0 {
+			fileIDs, copyErr := c.pluginAPI.File.CopyInfos(original.FileIds, original.UserId)
+			if copyErr != nil {
+				return nil, fmt.Errorf("unable to copy attached files: %w", copyErr)
+			}
+			forked.FileIds = fileIDs
+		}
+
+		if original.UserId == botID {
+			streaming.ModifyPostForBot(botID, userID, forked, lastUserPostID)
+		} else {
+			// Copied posts are not new requests to the bot
+			forked.UserId = original.UserId
+			forked.AddProp(FromPluginProp, "true")
+		}
+		forked.AddProp(ForkedFromProp, original.Id)
+
+		if err := c.pluginAPI.Post.CreatePost(forked); err != nil {
+			return nil, fmt.Errorf("unable to copy post: %w", err)
+		}
+		if original.UserId != botID {
+			lastUserPostID = forked.Id
+		}
+		if forkRoot == nil {
+			forkRoot = forked
+		}
+	}
+
+	meta, err := c.getPostMeta(rootID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.SaveFork(forkRoot.Id, post.Id, meta.Title, meta.Persona); err != nil {
+		return nil, err
+	}
+
+	return forkRoot, nil
+}
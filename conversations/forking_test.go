@@ -0,0 +1,43 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForkPosts(t *testing.T) {
+	posts := []*model.Post{
+		{Id: "root"},
+		{Id: "response"},
+		{Id: "joined", Type: model.PostTypeJoinChannel},
+		{Id: "question"},
+		{Id: "answer"},
+	}
+
+	tests := []struct {
+		name     string
+		postID   string
+		expected []string
+	}{
+		{name: "from the root", postID: "root", expected: []string{"root"}},
+		{name: "from a reply", postID: "question", expected: []string{"root", "response", "question"}},
+		{name: "from the last post", postID: "answer", expected: []string{"root", "response", "question", "answer"}},
+		{name: "from a system message", postID: "joined", expected: []string{"root", "response"}},
+		{name: "from a post not in the thread", postID: "other", expected: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var ids []string
+			for _, post := range forkPosts(posts, tc.postID) {
+				ids = append(ids, post.Id)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
@@ -77,9 +77,15 @@ func (c *Conversations) handleMessages(post *model.Post) error {
 		return fmt.Errorf("not responding to other bots: %w", ErrNoResponse)
 	}
 
-	// Check we are mentioned like @ai
-	if bot := c.bots.GetBotMentioned(post.Message); bot != nil {
-		return c.handleMentions(bot, post, postingUser, channel)
+	// Check we are mentioned like @ai, every bot mentioned answers in the thread
+	if mentioned := c.bots.GetBotsMentioned(post.Message); len(mentioned) > 0 {
+		var errs []error
+		for _, bot := range mentioned {
+			if err := c.handleMentions(bot, post, postingUser, channel); err != nil {
+				errs = append(errs, fmt.Errorf("bot %s: %w", bot.GetMMBot().Username, err))
+			}
+		}
+		return errors.Join(errs...)
 	}
 
 	// Check if this is post in the DM channel with any bot
@@ -121,6 +127,17 @@ func (c *Conversations) handleDMs(bot *bots.Bot, channel *model.Channel, posting
 		return err
 	}
 
+	if post.RootId == "" {
+		if err := c.savePickedPersona(post); err != nil {
+			return fmt.Errorf("unable to save conversation persona: %w", err)
+		}
+	}
+
+	// Pasted documents and logs can exceed what the model accepts in one request
+	if isLongContent(bot.LLM(), post.Message) {
+		return c.handleLongContentDM(bot, channel, postingUser, post)
+	}
+
 	stream, err := c.ProcessUserRequest(bot, postingUser, channel, post)
 	if err != nil {
 		return fmt.Errorf("unable to process bot mention: %w", err)
@@ -0,0 +1,217 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// HandoffPostType is the type of the conversations handed off to the handoff channel. The webapp has no
+	// component for it, so they are shown like other posts.
+	HandoffPostType = "custom_llm_handoff"
+
+	// HandoffUserIDProp is set on handoff posts to the user who asked for a person
+	HandoffUserIDProp = "handoff_user_id"
+
+	// maxHandoffLinks limits the links listed in a handoff
+	maxHandoffLinks = 10
+)
+
+var (
+	// ErrHandoffNotDM is returned when handing off a conversation that isn't a DM with a bot.
+	ErrHandoffNotDM = errors.New("only direct message conversations with a bot can be handed off")
+
+	// ErrHandoffNotEnabled is returned when the bot has no handoff channel configured.
+	ErrHandoffNotEnabled = errors.New("handoff is not enabled for this bot")
+)
+
+var linkRegex = regexp.MustCompile(`https?://[^\s<>()\[\]"'` + "`" + `]+`)
+
+type handoffResponse struct {
+	Summary             string   `json:"summary"`
+	UnresolvedQuestions []string `json:"unresolved_questions"`
+}
+
+// HandoffConversation packages the DM conversation the post belongs to with a summary, the links shared
+// and the questions left open, and posts it to the bot's handoff channel mentioning the people on call.
+func (c *Conversations) HandoffConversation(userID string, post *model.Post, channel *model.Channel) (*model.Post, error) {
+	bot := c.bots.GetBotForDMChannel(channel)
+	if bot == nil || !mmapi.IsDMWith(userID, channel) {
+		return nil, ErrHandoffNotDM
+	}
+	handoffConfig := bot.GetConfig().Handoff
+	if !handoffConfig.IsActive() {
+		return nil, ErrHandoffNotEnabled
+	}
+
+	user, err := c.pluginAPI.User.Get(userID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get user: %w", err)
+	}
+
+	rootID := post.Id
+	if post.RootId != "" {
+		rootID = post.RootId
+	}
+	threadData, err := mmapi.GetThreadData(c.mmClient, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get conversation: %w", err)
+	}
+
+	llmContext := c.contextBuilder.BuildLLMContextUserRequest(bot, user, channel)
+	llmContext.Parameters = map[string]any{"Thread": format.ThreadData(threadData)}
+	systemPrompt, err := c.prompts.Format(prompts.PromptHandoffSystem, llmContext)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get handoff prompt: %w", err)
+	}
+	userPrompt, err := c.prompts.Format(prompts.PromptThreadUser, llmContext)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get handoff user prompt: %w", err)
+	}
+
+	result, err := bot.LLM().ChatCompletionNoStream(llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: userPrompt},
+		},
+		Context: llmContext,
+	}, llm.WithJSONOutput(&handoffResponse{}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to summarize conversation for handoff: %w", err)
+	}
+	response, err := parseHandoff(result)
+	if err != nil {
+		return nil, err
+	}
+
+	onCallUsers := []*model.User{}
+	if len(handoffConfig.OnCallUserIDs) > 0 {
+		onCallUsers, err = c.pluginAPI.User.ListByUserIDs(handoffConfig.OnCallUserIDs)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get on-call users: %w", err)
+		}
+	}
+
+	// The handoff channel is shared, so it uses the server's language rather than the user's
+	T := i18n.LocalizerFunc(c.i18n, *c.pluginAPI.Configuration.GetConfig().LocalizationSettings.DefaultServerLocale)
+	handoffPost := &model.Post{
+		UserId:    bot.GetMMBot().UserId,
+		ChannelId: handoffConfig.ChannelID,
+		Message:   formatHandoff(T, user, bot.GetMMBot().DisplayName, onCallUsers, response, extractLinks(threadData.Posts)),
+		Type:      HandoffPostType,
+	}
+	handoffPost.AddProp(HandoffUserIDProp, user.Id)
+	streaming.MarkAIGenerated(bot.GetMMBot().UserId, handoffPost)
+	if err := c.pluginAPI.Post.CreatePost(handoffPost); err != nil {
+		return nil, fmt.Errorf("unable to post handoff: %w", err)
+	}
+
+	// Let the user know in the conversation that people are on it
+	handoffChannel, err := c.pluginAPI.Channel.Get(handoffConfig.ChannelID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get handoff channel: %w", err)
+	}
+	userT := i18n.LocalizerFunc(c.i18n, user.Locale)
+	confirmationPost := &model.Post{
+		ChannelId: channel.Id,
+		RootId:    rootID,
+		Message:   userT("copilot.handoff_confirmation", "I've handed this conversation over to the team in ~%s. Someone will follow up with you.", handoffChannel.Name),
+	}
+	if err := c.BotCreateNonResponsePost(bot.GetMMBot().UserId, user.Id, confirmationPost); err != nil {
+		return nil, fmt.Errorf("unable to confirm handoff: %w", err)
+	}
+
+	return handoffPost, nil
+}
+
+// parseHandoff parses the model's handoff response.
+func parseHandoff(result string) (*handoffResponse, error) {
+	var response handoffResponse
+	if err := llm.UnmarshalModelJSON(result, &response); err != nil {
+		return nil, fmt.Errorf("unable to parse handoff: %w", err)
+	}
+	if strings.TrimSpace(response.Summary) == "" {
+		return nil, errors.New("handoff summary is empty")
+	}
+
+	return &response, nil
+}
+
+// extractLinks returns the distinct links shared in the posts in the order they first appear.
+func extractLinks(posts []*model.Post) []string {
+	var links []string
+	seen := map[string]bool{}
+	for _, post := range posts {
+		for _, link := range linkRegex.FindAllString(format.PostBody(post), -1) {
+			link = strings.TrimRight(link, ".,;:!?")
+			if seen[link] {
+				continue
+			}
+			seen[link] = true
+			links = append(links, link)
+			if len(links) == maxHandoffLinks {
+				return links
+			}
+		}
+	}
+	return links
+}
+
+// formatHandoff formats the handoff post for the support channel.
+func formatHandoff(T i18n.TranslationFunc, user *model.User, botName string, onCallUsers []*model.User, response *handoffResponse, links []string) string {
+	var message strings.Builder
+
+	mentions := make([]string, 0, len(onCallUsers))
+	for _, onCallUser := range onCallUsers {
+		mentions = append(mentions, "@"+onCallUser.Username)
+	}
+	if len(mentions) > 0 {
+		message.WriteString(strings.Join(mentions, " "))
+		message.WriteString(" ")
+	}
+	message.WriteString(T("copilot.handoff_intro", "@%s asked to talk to a person about their conversation with %s.", user.Username, botName))
+
+	message.WriteString("\n\n#### ")
+	message.WriteString(T("copilot.handoff_summary", "Summary"))
+	message.WriteString("\n")
+	message.WriteString(strings.TrimSpace(response.Summary))
+
+	if len(links) > 0 {
+		message.WriteString("\n\n#### ")
+		message.WriteString(T("copilot.handoff_links", "Key links"))
+		for _, link := range links {
+			message.WriteString("\n- ")
+			message.WriteString(link)
+		}
+	}
+
+	var questions []string
+	for _, question := range response.UnresolvedQuestions {
+		if question = strings.TrimSpace(question); question != "" {
+			questions = append(questions, question)
+		}
+	}
+	if len(questions) > 0 {
+		message.WriteString("\n\n#### ")
+		message.WriteString(T("copilot.handoff_unresolved_questions", "Unresolved questions"))
+		for _, question := range questions {
+			message.WriteString("\n- ")
+			message.WriteString(question)
+		}
+	}
+
+	return message.String()
+}
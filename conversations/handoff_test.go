@@ -0,0 +1,97 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHandoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  string
+		want    *handoffResponse
+		wantErr bool
+	}{
+		{
+			name:   "valid handoff",
+			result: `{"summary": "Can't log in.", "unresolved_questions": ["Why is the account locked?"]}`,
+			want:   &handoffResponse{Summary: "Can't log in.", UnresolvedQuestions: []string{"Why is the account locked?"}},
+		},
+		{
+			name:   "markdown code block",
+			result: "```json\n{\"summary\": \"Can't log in.\", \"unresolved_questions\": []}\n```",
+			want:   &handoffResponse{Summary: "Can't log in.", UnresolvedQuestions: []string{}},
+		},
+		{
+			name:    "empty summary",
+			result:  `{"summary": " ", "unresolved_questions": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "not json",
+			result:  "The user can't log in.",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseHandoff(tc.result)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestExtractLinks(t *testing.T) {
+	posts := []*model.Post{
+		{Message: "See https://docs.example.com/setup. It explains the install."},
+		{Message: "Also (https://status.example.com) and https://docs.example.com/setup again"},
+		{Message: "No links here"},
+		{Message: "Logs at <http://logs.example.com/run?id=4>!"},
+	}
+
+	assert.Equal(t, []string{
+		"https://docs.example.com/setup",
+		"https://status.example.com",
+		"http://logs.example.com/run?id=4",
+	}, extractLinks(posts))
+}
+
+func TestFormatHandoff(t *testing.T) {
+	T := func(_ string, defaultMessage string, params ...any) string {
+		return fmt.Sprintf(defaultMessage, params...)
+	}
+	user := &model.User{Username: "alice"}
+	onCall := []*model.User{{Username: "bob"}, {Username: "carol"}}
+
+	t.Run("full handoff", func(t *testing.T) {
+		message := formatHandoff(T, user, "Copilot", onCall, &handoffResponse{
+			Summary:             "Alice can't log in.",
+			UnresolvedQuestions: []string{"Why is the account locked?", " "},
+		}, []string{"https://docs.example.com"})
+
+		assert.Equal(t, "@bob @carol @alice asked to talk to a person about their conversation with Copilot.\n\n"+
+			"#### Summary\nAlice can't log in.\n\n"+
+			"#### Key links\n- https://docs.example.com\n\n"+
+			"#### Unresolved questions\n- Why is the account locked?", message)
+	})
+
+	t.Run("without links, questions or on-call users", func(t *testing.T) {
+		message := formatHandoff(T, user, "Copilot", nil, &handoffResponse{Summary: "Alice can't log in."}, nil)
+
+		assert.Equal(t, "@alice asked to talk to a person about their conversation with Copilot.\n\n"+
+			"#### Summary\nAlice can't log in.", message)
+	})
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/chunking"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// LongContentProgressProp tracks the parts of a long message read so far, as a map with done and total counts
+	LongContentProgressProp = "long_content_progress"
+
+	// LongContentTokenRatio is the share of the model's input limit above which a message is read in parts.
+	// The rest of the limit is left for the system prompt, the conversation and the response.
+	LongContentTokenRatio = 0.5
+)
+
+// isLongContent returns true if the message is too long to be answered in a single request.
+func isLongContent(languageModel llm.LanguageModel, message string) bool {
+	limit := int(float64(languageModel.InputTokenLimit()) * LongContentTokenRatio)
+	return languageModel.CountTokens(message) > limit
+}
+
+// longContentParts splits a long message into parts that each fit in a single request.
+func longContentParts(languageModel llm.LanguageModel, message string) []string {
+	partTokens := int(float64(languageModel.InputTokenLimit())*LongContentTokenRatio) - llm.FunctionsTokenBudget
+	if partTokens < llm.MinTokens {
+		partTokens = llm.MinTokens
+	}
+
+	// Tokens are roughly four characters
+	return chunking.SplitPlaintextOnSentences(message, partTokens*4)
+}
+
+// condenseLongContent takes notes of each part of a long message and returns a copy of the post with
+// the message replaced by the notes, so it can be answered without exceeding the model's context.
+// progress is called after each part is read.
+func (c *Conversations) condenseLongContent(languageModel llm.LanguageModel, post *model.Post, llmContext *llm.Context, progress func(done, total int)) (*model.Post, error) {
+	parts := longContentParts(languageModel, post.Message)
+	progress(0, len(parts))
+
+	// A separate context so the parameters don't leak into the response. Notes are only taken,
+	// so the tools are left out and can't be called for a part of the message.
+	partContext := *llmContext
+	partContext.Tools = nil
+	notes := make([]string, 0, len(parts))
+	for i, part := range parts {
+		partContext.Parameters = map[string]any{
+			"Part":  fmt.Sprintf("%d", i+1),
+			"Parts": fmt.Sprintf("%d", len(parts)),
+		}
+		systemPrompt, err := c.prompts.Format(prompts.PromptLongContentChunkSystem, &partContext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format long content prompt: %w", err)
+		}
+
+		partNotes, err := languageModel.ChatCompletionNoStream(llm.CompletionRequest{
+			Posts: []llm.Post{
+				{Role: llm.PostRoleSystem, Message: systemPrompt},
+				{Role: llm.PostRoleUser, Message: part},
+			},
+			Context: &partContext,
+		}, llm.WithFeature(llm.FeatureChunkSummaries))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read part %d of %d: %w", i+1, len(parts), err)
+		}
+		notes = append(notes, fmt.Sprintf("Part %d:\n%s", i+1, strings.TrimSpace(partNotes)))
+		progress(i+1, len(parts))
+	}
+
+	partContext.Parameters = map[string]any{
+		"Parts": fmt.Sprintf("%d", len(parts)),
+		"Notes": strings.Join(notes, "\n\n"),
+	}
+	message, err := c.prompts.Format(prompts.PromptLongContentUser, &partContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format long content notes: %w", err)
+	}
+
+	condensed := post.Clone()
+	condensed.Message = message
+	return condensed, nil
+}
+
+// handleLongContentDM answers a DM too long for a single request by reading it in parts first,
+// showing the progress on the response post instead of failing on the provider's context limit.
+func (c *Conversations) handleLongContentDM(bot *bots.Bot, channel *model.Channel, postingUser *model.User, post *model.Post) error {
+	T := i18n.LocalizerFunc(c.i18n, postingUser.Locale)
+
+	responseRootID := post.Id
+	if post.RootId != "" {
+		responseRootID = post.RootId
+	}
+	responsePost := &model.Post{
+		ChannelId: channel.Id,
+		RootId:    responseRootID,
+		Message:   T("copilot.long_content_start", "Your message is too long to read at once, so I'm reading it in parts first."),
+	}
+	streaming.ModifyPostForBot(bot.GetMMBot().UserId, postingUser.Id, responsePost, post.Id)
+	if err := c.mmClient.CreatePost(responsePost); err != nil {
+		return fmt.Errorf("unable to create response post: %w", err)
+	}
+
+	ctx, err := c.streamingService.GetStreamingContext(context.Background(), responsePost.Id)
+	if err != nil {
+		return fmt.Errorf("unable to get post streaming context: %w", err)
+	}
+
+	go func() (reterr error) {
+		defer c.streamingService.FinishStreaming(responsePost.Id)
+
+		// Update to an error if we return one.
+		defer func() {
+			if reterr != nil {
+				responsePost.Message = T("copilot.long_content_error", "Sorry! Something went wrong while reading your message. Check the server logs for details.")
+				responsePost.DelProp(LongContentProgressProp)
+				if err := c.pluginAPI.Post.UpdatePost(responsePost); err != nil {
+					c.pluginAPI.Log.Error("Failed to update post in error handling handleLongContentDM", "error", err)
+				}
+				c.pluginAPI.Log.Error("Error reading long message", "error", reterr)
+			}
+		}()
+
+		llmContext := c.contextBuilder.BuildLLMContextUserRequest(
+			bot,
+			postingUser,
+			channel,
+			c.contextBuilder.WithLLMContextDefaultTools(bot, mmapi.IsDMWith(bot.GetMMBot().UserId, channel)),
+		)
+
+		condensed, err := c.condenseLongContent(bot.LLM(), post, llmContext, func(done, total int) {
+			c.updateLongContentProgress(responsePost, T, done, total)
+		})
+		if err != nil {
+			return err
+		}
+
+		stream, err := c.ProcessUserRequestWithContext(bot, postingUser, channel, condensed, llmContext)
+		if err != nil {
+			return fmt.Errorf("unable to process long message: %w", err)
+		}
+
+		responsePost.Message = ""
+		responsePost.DelProp(LongContentProgressProp)
+		c.streamingService.StreamToPost(ctx, stream, responsePost, postingUser.Locale)
+
+		return nil
+	}() //nolint:errcheck
+
+	return nil
+}
+
+// updateLongContentProgress shows how many parts of a long message have been read on the post.
+// Failures are logged since the response can still be given.
+func (c *Conversations) updateLongContentProgress(post *model.Post, T i18n.TranslationFunc, done, total int) {
+	post.Message = T("copilot.long_content_progress", "Your message is too long to read at once, so I'm reading it in parts first: %d of %d done.", done, total)
+	post.AddProp(LongContentProgressProp, map[string]any{
+		"done":  done,
+		"total": total,
+	})
+	if err := c.pluginAPI.Post.UpdatePost(post); err != nil {
+		c.pluginAPI.Log.Warn("Failed to update long message progress", "error", err)
+	}
+}
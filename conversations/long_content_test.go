@@ -0,0 +1,93 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// longMessage returns a message of sentences about three times as long as the parts of a model
+// with an input limit of 1000 tokens.
+func longMessage() string {
+	sentences := make([]string, 0, 80)
+	for i := range 80 {
+		sentences = append(sentences, fmt.Sprintf("Sentence number %02d of the long message.", i))
+	}
+	return strings.Join(sentences, " ")
+}
+
+func TestLongContentParts(t *testing.T) {
+	languageModel := mocks.NewMockLanguageModel(t)
+	languageModel.EXPECT().InputTokenLimit().Return(1000)
+
+	message := longMessage()
+	parts := longContentParts(languageModel, message)
+
+	// Half of the limit without the functions budget, at four characters a token
+	partSize := (500 - llm.FunctionsTokenBudget) * 4
+	require.Len(t, parts, 3)
+	for _, part := range parts {
+		assert.LessOrEqual(t, len(part), partSize)
+		assert.True(t, strings.HasSuffix(part, "."), "parts end on a sentence")
+	}
+	assert.Equal(t, message, strings.Join(parts, " "), "no text is lost between the parts")
+}
+
+func TestIsLongContent(t *testing.T) {
+	languageModel := mocks.NewMockLanguageModel(t)
+	languageModel.EXPECT().InputTokenLimit().Return(1000)
+	languageModel.EXPECT().CountTokens("short").Return(10)
+	languageModel.EXPECT().CountTokens("long").Return(501)
+
+	assert.False(t, isLongContent(languageModel, "short"))
+	assert.True(t, isLongContent(languageModel, "long"))
+}
+
+func TestCondenseLongContent(t *testing.T) {
+	llmPrompts, err := llm.NewPrompts(prompts.PromptsFolder)
+	require.NoError(t, err)
+	c := &Conversations{prompts: llmPrompts}
+
+	languageModel := mocks.NewMockLanguageModel(t)
+	languageModel.EXPECT().InputTokenLimit().Return(1000)
+	partNumber := regexp.MustCompile(`part (\d+) of 3`)
+	languageModel.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything).RunAndReturn(func(request llm.CompletionRequest, _ ...llm.LanguageModelOption) (string, error) {
+		assert.Nil(t, request.Context.Tools, "tools can't be called while taking notes")
+		require.Len(t, request.Posts, 2)
+		match := partNumber.FindStringSubmatch(request.Posts[0].Message)
+		require.NotNil(t, match)
+		return fmt.Sprintf("  notes of part %s  ", match[1]), nil
+	}).Times(3)
+
+	llmContext := llm.NewContext()
+	llmContext.Tools = llm.NewNoTools()
+	llmContext.Parameters = map[string]any{"Original": "value"}
+
+	var progress [][2]int
+	post := &model.Post{Id: "postid", Message: longMessage()}
+	condensed, err := c.condenseLongContent(languageModel, post, llmContext, func(done, total int) {
+		progress = append(progress, [2]int{done, total})
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, [][2]int{{0, 3}, {1, 3}, {2, 3}, {3, 3}}, progress)
+	assert.Contains(t, condensed.Message, "read in 3 parts")
+	assert.Contains(t, condensed.Message, "Part 1:\nnotes of part 1\n\nPart 2:\nnotes of part 2\n\nPart 3:\nnotes of part 3")
+	assert.Equal(t, "postid", condensed.Id)
+	assert.Equal(t, longMessage(), post.Message, "the post itself isn't changed")
+
+	assert.NotNil(t, llmContext.Tools, "the tools are kept for the response")
+	assert.Equal(t, map[string]any{"Original": "value"}, llmContext.Parameters)
+}
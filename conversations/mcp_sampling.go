@@ -0,0 +1,253 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mcp"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// MCPSamplingServerProp marks the posts asking to approve a sampling request with the MCP server making it
+	MCPSamplingServerProp = "mcp_sampling_server"
+
+	// MCPSamplingToolName is the name of the tool call standing for a sampling request, so approval
+	// policies can cover sampling
+	MCPSamplingToolName = "mcp_sampling"
+
+	// SamplingDecisionEvent is the cluster event telling the other servers of the cluster that a sampling
+	// request was decided, with the ID of the approval post as data
+	SamplingDecisionEvent = "mcp_sampling_decision"
+
+	// samplingCheckInterval is how often the approval post is checked for a decision whose notification
+	// was missed, such as when the server deciding it couldn't publish it to the cluster
+	samplingCheckInterval = 30 * time.Second
+)
+
+// errSamplingDecided is returned when expiring a sampling request that was decided meanwhile
+var errSamplingDecided = errors.New("sampling request already decided")
+
+// samplingWaiters are the sampling requests waiting for a decision on this server, notified when one is
+// made by their approval post ID
+type samplingWaiters struct {
+	mu      sync.Mutex
+	waiters map[string]chan struct{}
+}
+
+// wait returns the channel notified when the sampling request of the post is decided, and the function
+// to call once done waiting.
+func (w *samplingWaiters) wait(postID string) (<-chan struct{}, func()) {
+	decided := make(chan struct{}, 1)
+
+	w.mu.Lock()
+	if w.waiters == nil {
+		w.waiters = map[string]chan struct{}{}
+	}
+	w.waiters[postID] = decided
+	w.mu.Unlock()
+
+	return decided, func() {
+		w.mu.Lock()
+		delete(w.waiters, postID)
+		w.mu.Unlock()
+	}
+}
+
+// notify tells the request of the post waiting on this server, if any, that it was decided.
+func (w *samplingWaiters) notify(postID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	select {
+	case w.waiters[postID] <- struct{}{}:
+	default:
+	}
+}
+
+// NotifySamplingDecision tells the sampling request of the post waiting on this server, if any, that it
+// was decided on another server of the cluster.
+func (c *Conversations) NotifySamplingDecision(postID string) {
+	c.samplingWaiters.notify(postID)
+}
+
+// publishSamplingDecision tells the sampling request of the post that it was decided, wherever in the
+// cluster it waits. Requests whose notification is lost find out when they next check the post.
+func (c *Conversations) publishSamplingDecision(postID string) {
+	c.samplingWaiters.notify(postID)
+	if err := c.pluginAPI.Cluster.PublishPluginEvent(model.PluginClusterEvent{
+		Id:   SamplingDecisionEvent,
+		Data: []byte(postID),
+	}, model.PluginClusterEventSendOptions{}); err != nil {
+		c.pluginAPI.Log.Warn("Failed to publish sampling decision", "post_id", postID, "error", err.Error())
+	}
+}
+
+// Sample asks the user to approve the sampling request of an MCP server, the same way as tool calls, and
+// has the bot that called the server's tool answer it. The completion is counted in the usage of the
+// approval post, and recorded on it as the result of the call.
+func (c *Conversations) Sample(ctx context.Context, request mcp.SamplingRequest) (mcp.SamplingResult, error) {
+	bot := c.bots.GetBotByID(request.BotID)
+	if bot == nil {
+		return mcp.SamplingResult{}, fmt.Errorf("unable to get bot")
+	}
+	user, err := c.pluginAPI.User.Get(request.UserID)
+	if err != nil {
+		return mcp.SamplingResult{}, fmt.Errorf("unable to get user: %w", err)
+	}
+	channel, err := c.pluginAPI.Channel.Get(request.ChannelID)
+	if err != nil {
+		return mcp.SamplingResult{}, fmt.Errorf("unable to get channel: %w", err)
+	}
+
+	arguments, err := json.Marshal(request)
+	if err != nil {
+		return mcp.SamplingResult{}, fmt.Errorf("failed to marshal sampling request: %w", err)
+	}
+	toolCalls := []llm.ToolCall{{
+		ID:          model.NewId(),
+		Name:        MCPSamplingToolName,
+		Description: fmt.Sprintf("The MCP server %s asks the bot for a completion while running a tool", request.ServerID),
+		Arguments:   arguments,
+		Status:      llm.ToolCallStatusPending,
+	}}
+
+	T := i18n.LocalizerFunc(c.i18n, user.Locale)
+	post := &model.Post{
+		ChannelId: channel.Id,
+		Message:   T("copilot.mcp_sampling_request", "The `%s` MCP server is asking me to generate a response while running a tool.", request.ServerID),
+	}
+	c.AssignToolCallApprovers(post, toolCalls)
+	toolCallsJSON, err := json.Marshal(toolCalls)
+	if err != nil {
+		return mcp.SamplingResult{}, fmt.Errorf("failed to marshal tool calls: %w", err)
+	}
+	post.AddProp(streaming.ToolCallProp, string(toolCallsJSON))
+	post.AddProp(MCPSamplingServerProp, request.ServerID)
+	if err := c.BotCreateNonResponsePost(bot.GetMMBot().UserId, user.Id, post); err != nil {
+		return mcp.SamplingResult{}, fmt.Errorf("failed to post sampling request: %w", err)
+	}
+	c.HandleToolCallsPosted(post, toolCalls)
+
+	post, toolCall, err := c.waitForSamplingDecision(ctx, post.Id)
+	if err != nil {
+		return mcp.SamplingResult{}, err
+	}
+	if toolCall.Status != llm.ToolCallStatusAccepted {
+		return mcp.SamplingResult{}, mcp.ErrSamplingRejected
+	}
+
+	text, modelName, err := c.sample(bot, user, channel, post, request)
+	if err != nil {
+		c.pluginAPI.Log.Warn("MCP sampling request failed", "post_id", post.Id, "error", err.Error())
+		toolCall.Result = "Sampling failed"
+		toolCall.Error = toolCallFailedMessage
+		toolCall.Status = llm.ToolCallStatusError
+	} else {
+		toolCall.Result = text
+		toolCall.Status = llm.ToolCallStatusSuccess
+	}
+	if updateErr := c.updateToolCalls(post, []llm.ToolCall{toolCall}); updateErr != nil {
+		c.pluginAPI.Log.Error("Failed to update sampling request post", "post_id", post.Id, "error", updateErr.Error())
+	}
+	if err != nil {
+		return mcp.SamplingResult{}, err
+	}
+
+	return mcp.SamplingResult{Text: text, Model: modelName}, nil
+}
+
+// waitForSamplingDecision waits for the sampling request of the post to be approved or rejected, checking
+// the post when notified of the decision. A request still pending when the server stops waiting is rejected.
+func (c *Conversations) waitForSamplingDecision(ctx context.Context, postID string) (*model.Post, llm.ToolCall, error) {
+	decided, stopWaiting := c.samplingWaiters.wait(postID)
+	defer stopWaiting()
+	ticker := time.NewTicker(samplingCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		// The decision may have been made before waiting for it
+		post, err := c.pluginAPI.Post.GetPost(postID)
+		if err != nil {
+			return nil, llm.ToolCall{}, fmt.Errorf("failed to get sampling request post: %w", err)
+		}
+		toolCalls, err := postToolCalls(post)
+		if err != nil {
+			return nil, llm.ToolCall{}, err
+		}
+		if len(toolCalls) != 1 {
+			return nil, llm.ToolCall{}, errors.New("sampling request post has an unexpected number of tool calls")
+		}
+		if toolCalls[0].Status != llm.ToolCallStatusPending {
+			return post, toolCalls[0], nil
+		}
+
+		select {
+		case <-ctx.Done():
+			_, _, expireErr := c.changeToolCalls(postID, func(toolCalls []llm.ToolCall) error {
+				if len(toolCalls) != 1 || toolCalls[0].Status != llm.ToolCallStatusPending {
+					return errSamplingDecided
+				}
+				toolCalls[0].Result = "Sampling request expired"
+				toolCalls[0].Status = llm.ToolCallStatusRejected
+				return nil
+			})
+			if expireErr != nil && !errors.Is(expireErr, errSamplingDecided) {
+				c.pluginAPI.Log.Error("Failed to expire sampling request", "post_id", postID, "error", expireErr.Error())
+			}
+			return nil, llm.ToolCall{}, ctx.Err()
+		case <-decided:
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample has the bot complete the conversation of the sampling request, without tools, and counts the
+// tokens used against the approval post.
+func (c *Conversations) sample(bot *bots.Bot, user *model.User, channel *model.Channel, post *model.Post, request mcp.SamplingRequest) (string, string, error) {
+	posts := []llm.Post{}
+	if request.SystemPrompt != "" {
+		posts = append(posts, llm.Post{Role: llm.PostRoleSystem, Message: request.SystemPrompt})
+	}
+	for _, message := range request.Messages {
+		role := llm.PostRoleUser
+		if message.Role == "assistant" {
+			role = llm.PostRoleBot
+		}
+		posts = append(posts, llm.Post{Role: role, Message: message.Text})
+	}
+
+	var opts []llm.LanguageModelOption
+	if request.MaxTokens > 0 {
+		opts = append(opts, llm.WithMaxGeneratedTokens(request.MaxTokens))
+	}
+	result, err := bot.LLM().ChatCompletion(llm.CompletionRequest{
+		Posts:   posts,
+		Context: c.contextBuilder.BuildLLMContextUserRequest(bot, user, channel),
+	}, opts...)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get sampling completion: %w", err)
+	}
+
+	text, err := result.ReadAll()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read sampling completion: %w", err)
+	}
+
+	modelName := bot.GetConfig().Service.DefaultModel
+	if result.Usage != nil {
+		c.RecordUsage(post, result.Usage)
+		modelName = result.Usage.Model
+	}
+	return text, modelName, nil
+}
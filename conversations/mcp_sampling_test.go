@@ -0,0 +1,28 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSamplingWaiters(t *testing.T) {
+	var waiters samplingWaiters
+
+	decided, stopWaiting := waiters.wait("post1")
+	waiters.notify("post2")
+	assert.Empty(t, decided, "only the request of the post is notified")
+
+	// Notifications don't block when the request isn't waiting on the channel yet
+	waiters.notify("post1")
+	waiters.notify("post1")
+	assert.Len(t, decided, 1)
+	<-decided
+
+	stopWaiting()
+	waiters.notify("post1")
+	assert.Empty(t, decided)
+}
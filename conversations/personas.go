@@ -0,0 +1,156 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// PersonaProp is set by the webapp on the first post of a conversation to the persona the user picked
+const PersonaProp = "ai_persona"
+
+var (
+	// ErrUnknownPersona is returned when switching a thread to a persona that doesn't exist.
+	ErrUnknownPersona = errors.New("unknown persona")
+
+	// ErrPersonaNotDM is returned when switching the persona of a thread that isn't a DM with a bot.
+	ErrPersonaNotDM = errors.New("only direct message conversations with a bot have a persona")
+)
+
+// Persona is a persona users can pick, without its instructions.
+type Persona struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// validPersonas returns the personas defined by admins, without the ones missing an ID or a system prompt.
+// Personas without a name are named by their ID, and empty tool names are ignored.
+func validPersonas(personas []config.Persona) []config.Persona {
+	valid := make([]config.Persona, 0, len(personas))
+	for _, persona := range personas {
+		persona.ID = strings.TrimSpace(persona.ID)
+		if persona.ID == "" || strings.TrimSpace(persona.SystemPrompt) == "" {
+			continue
+		}
+		if slices.ContainsFunc(valid, func(p config.Persona) bool { return p.ID == persona.ID }) {
+			continue
+		}
+		persona.Name = strings.TrimSpace(persona.Name)
+		if persona.Name == "" {
+			persona.Name = persona.ID
+		}
+		persona.AllowedTools = slices.DeleteFunc(slices.Clone(persona.AllowedTools), func(name string) bool {
+			return strings.TrimSpace(name) == ""
+		})
+		valid = append(valid, persona)
+	}
+	return valid
+}
+
+func findPersona(personas []config.Persona, id string) (config.Persona, bool) {
+	for _, persona := range personas {
+		if persona.ID == id {
+			return persona, true
+		}
+	}
+	return config.Persona{}, false
+}
+
+// applyPersona gives the persona's instructions to the bot and restricts its tools to the ones the persona
+// allows. It returns the options the persona's requests are made with.
+func applyPersona(persona config.Persona, llmContext *llm.Context) []llm.LanguageModelOption {
+	llmContext.PersonaInstructions = persona.SystemPrompt
+	if len(persona.AllowedTools) > 0 && llmContext.Tools != nil {
+		llmContext.Tools.RestrictTo(persona.AllowedTools)
+	}
+
+	if persona.Temperature == nil {
+		return nil
+	}
+	return []llm.LanguageModelOption{llm.WithTemperature(*persona.Temperature)}
+}
+
+// Personas returns the personas users can pick.
+func (c *Conversations) Personas() []Persona {
+	valid := validPersonas(c.config.GetPersonas())
+	personas := make([]Persona, 0, len(valid))
+	for _, persona := range valid {
+		personas = append(personas, Persona{ID: persona.ID, Name: persona.Name})
+	}
+	return personas
+}
+
+// savePickedPersona saves the persona picked when starting a conversation for its thread, personas that
+// don't exist are ignored.
+func (c *Conversations) savePickedPersona(post *model.Post) error {
+	personaID, _ := post.GetProp(PersonaProp).(string)
+	if personaID == "" {
+		return nil
+	}
+	if _, ok := findPersona(validPersonas(c.config.GetPersonas()), personaID); !ok {
+		return nil
+	}
+	return c.SavePersona(post.Id, personaID)
+}
+
+// usePersona applies the persona of the thread to the context and returns the options its requests are
+// made with. Threads whose persona was removed since it was picked get the bot's default behavior.
+func (c *Conversations) usePersona(threadID string, llmContext *llm.Context) ([]llm.LanguageModelOption, error) {
+	personaID, err := c.GetPersona(threadID)
+	if err != nil {
+		return nil, err
+	}
+	if personaID == "" {
+		return nil, nil
+	}
+	persona, ok := findPersona(validPersonas(c.config.GetPersonas()), personaID)
+	if !ok {
+		return nil, nil
+	}
+	return applyPersona(persona, llmContext), nil
+}
+
+// ThreadPersona returns the ID of the persona of the thread the post belongs to, empty when it has none.
+func (c *Conversations) ThreadPersona(post *model.Post) (string, error) {
+	personaID, err := c.GetPersona(threadRootID(post))
+	if err != nil {
+		return "", err
+	}
+	if _, ok := findPersona(validPersonas(c.config.GetPersonas()), personaID); !ok {
+		return "", nil
+	}
+	return personaID, nil
+}
+
+// SetThreadPersona switches the DM conversation the post belongs to to the persona, back to the bot's
+// default behavior when empty. The following responses are written in that persona.
+func (c *Conversations) SetThreadPersona(userID string, post *model.Post, channel *model.Channel, personaID string) error {
+	if c.bots.GetBotForDMChannel(channel) == nil || !mmapi.IsDMWith(userID, channel) {
+		return ErrPersonaNotDM
+	}
+	if personaID != "" {
+		if _, ok := findPersona(validPersonas(c.config.GetPersonas()), personaID); !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownPersona, personaID)
+		}
+	}
+	if err := c.SavePersona(threadRootID(post), personaID); err != nil {
+		return fmt.Errorf("failed to save thread persona: %w", err)
+	}
+	return nil
+}
+
+func threadRootID(post *model.Post) string {
+	if post.RootId != "" {
+		return post.RootId
+	}
+	return post.Id
+}
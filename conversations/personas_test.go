@@ -0,0 +1,112 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidPersonas(t *testing.T) {
+	tests := []struct {
+		name     string
+		personas []config.Persona
+		expected []config.Persona
+	}{
+		{
+			name:     "none",
+			expected: []config.Persona{},
+		},
+		{
+			name: "named by their ID when unnamed",
+			personas: []config.Persona{
+				{ID: " reviewer ", SystemPrompt: "Review code critically."},
+				{ID: "coach", Name: " Coach ", SystemPrompt: "Encourage the user."},
+			},
+			expected: []config.Persona{
+				{ID: "reviewer", Name: "reviewer", SystemPrompt: "Review code critically."},
+				{ID: "coach", Name: "Coach", SystemPrompt: "Encourage the user."},
+			},
+		},
+		{
+			name: "without ID or system prompt",
+			personas: []config.Persona{
+				{Name: "No ID", SystemPrompt: "Answer."},
+				{ID: "empty", Name: "Empty", SystemPrompt: " \n"},
+			},
+			expected: []config.Persona{},
+		},
+		{
+			name: "empty tool names ignored",
+			personas: []config.Persona{
+				{ID: "researcher", SystemPrompt: "Cite your sources.", AllowedTools: []string{"SearchServer", " ", ""}},
+			},
+			expected: []config.Persona{
+				{ID: "researcher", Name: "researcher", SystemPrompt: "Cite your sources.", AllowedTools: []string{"SearchServer"}},
+			},
+		},
+		{
+			name: "first of duplicated IDs",
+			personas: []config.Persona{
+				{ID: "coach", Name: "Coach", SystemPrompt: "Encourage the user."},
+				{ID: "coach", Name: "Other coach", SystemPrompt: "Challenge the user."},
+			},
+			expected: []config.Persona{
+				{ID: "coach", Name: "Coach", SystemPrompt: "Encourage the user."},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, validPersonas(tc.personas))
+		})
+	}
+}
+
+func TestApplyPersona(t *testing.T) {
+	newContext := func() *llm.Context {
+		tools := llm.NewNoTools()
+		tools.AddTools([]llm.Tool{{Name: "SearchServer"}, {Name: "LookupMattermostUser"}, {Name: "GetJiraIssue"}})
+		return &llm.Context{Tools: tools}
+	}
+	toolNames := func(context *llm.Context) []string {
+		names := []string{}
+		for _, tool := range context.Tools.GetTools() {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+
+	t.Run("all tools without temperature", func(t *testing.T) {
+		context := newContext()
+		opts := applyPersona(config.Persona{ID: "coach", SystemPrompt: "Encourage the user."}, context)
+
+		assert.Empty(t, opts)
+		assert.Equal(t, "Encourage the user.", context.PersonaInstructions)
+		assert.Len(t, context.Tools.GetTools(), 3)
+	})
+
+	t.Run("allowed tools and temperature", func(t *testing.T) {
+		context := newContext()
+		temperature := 0.2
+		opts := applyPersona(config.Persona{
+			ID:           "researcher",
+			SystemPrompt: "Cite your sources.",
+			Temperature:  &temperature,
+			AllowedTools: []string{"SearchServer", "UnknownTool"},
+		}, context)
+
+		assert.Equal(t, []string{"SearchServer"}, toolNames(context))
+		require.Len(t, opts, 1)
+		cfg := llm.LanguageModelConfig{}
+		opts[0](&cfg)
+		require.NotNil(t, cfg.Temperature)
+		assert.Equal(t, 0.2, *cfg.Temperature)
+	})
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// MaxPinnedContextPosts limits the posts pinned in a thread, so they leave room for the rest of the conversation
+const MaxPinnedContextPosts = 10
+
+var (
+	// ErrNotAIThread is returned when pinning a post of a thread no bot takes part in.
+	ErrNotAIThread = errors.New("only posts of conversations with a bot can be pinned")
+
+	// ErrNotThreadRequester is returned when pinning a post of a thread in which the bot answered someone else.
+	ErrNotThreadRequester = errors.New("only the user the bot answered in the thread can pin its posts")
+
+	// ErrTooManyPinnedPosts is returned when pinning more than MaxPinnedContextPosts posts in a thread.
+	ErrTooManyPinnedPosts = fmt.Errorf("a thread can have at most %d pinned posts", MaxPinnedContextPosts)
+)
+
+// PinnedContextPosts returns the IDs of the posts pinned in the thread the post belongs to, in the order
+// they were pinned.
+func (c *Conversations) PinnedContextPosts(post *model.Post) ([]string, error) {
+	postIDs := []string{}
+	if err := c.db.DoQuery(&postIDs, c.db.Builder().
+		Select("PostID").
+		From("LLM_PinnedContext").
+		Where(sq.Eq{"RootPostID": threadRootID(post)}).
+		OrderBy("CreateAt", "PostID"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to get pinned context posts: %w", err)
+	}
+	return postIDs, nil
+}
+
+// PinContextPost pins the post in its thread, so it is always given to the bot even when the
+// conversation is truncated to fit the token limit.
+func (c *Conversations) PinContextPost(userID string, post *model.Post, channel *model.Channel) error {
+	if err := c.checkThreadRequester(userID, post, channel); err != nil {
+		return err
+	}
+
+	pinned, err := c.PinnedContextPosts(post)
+	if err != nil {
+		return err
+	}
+	for _, postID := range pinned {
+		if postID == post.Id {
+			return nil
+		}
+	}
+	if len(pinned) >= MaxPinnedContextPosts {
+		return ErrTooManyPinnedPosts
+	}
+
+	if _, err := c.db.ExecBuilder(c.db.Builder().Insert("LLM_PinnedContext").
+		Columns("RootPostID", "PostID", "UserID", "CreateAt").
+		Values(threadRootID(post), post.Id, userID, model.GetMillis()).
+		Suffix("ON CONFLICT (RootPostID, PostID) DO NOTHING")); err != nil {
+		return fmt.Errorf("failed to pin context post: %w", err)
+	}
+	return nil
+}
+
+// UnpinContextPost unpins the post in its thread, it is truncated like the rest of the conversation again.
+func (c *Conversations) UnpinContextPost(userID string, post *model.Post, channel *model.Channel) error {
+	if err := c.checkThreadRequester(userID, post, channel); err != nil {
+		return err
+	}
+
+	if _, err := c.db.ExecBuilder(c.db.Builder().Delete("LLM_PinnedContext").
+		Where(sq.Eq{"RootPostID": threadRootID(post), "PostID": post.Id})); err != nil {
+		return fmt.Errorf("failed to unpin context post: %w", err)
+	}
+	return nil
+}
+
+// checkThreadRequester returns ErrNotAIThread unless the post belongs to a direct message conversation
+// with a bot or to a thread a bot answered in, and ErrNotThreadRequester unless the bot answered the
+// user in it. Only the user and the bot are members of a direct message conversation.
+func (c *Conversations) checkThreadRequester(userID string, post *model.Post, channel *model.Channel) error {
+	if c.bots.GetBotForDMChannel(channel) != nil {
+		return nil
+	}
+
+	thread, err := c.mmClient.GetPostThread(threadRootID(post))
+	if err != nil {
+		return fmt.Errorf("unable to get thread: %w", err)
+	}
+	isAIThread := false
+	for _, threadPost := range thread.Posts {
+		if !c.bots.IsAnyBot(threadPost.UserId) {
+			continue
+		}
+		isAIThread = true
+		if threadPost.GetProp(streaming.LLMRequesterUserID) == userID {
+			return nil
+		}
+	}
+	if !isAIThread {
+		return ErrNotAIThread
+	}
+	return ErrNotThreadRequester
+}
+
+// pinnedContextPostIDs returns the posts pinned in the thread of the posts, empty when they can't be read
+// so the conversation is still answered.
+func (c *Conversations) pinnedContextPostIDs(posts []*model.Post) map[string]bool {
+	pinned := map[string]bool{}
+	if len(posts) == 0 {
+		return pinned
+	}
+	postIDs, err := c.PinnedContextPosts(posts[0])
+	if err != nil {
+		c.pluginAPI.Log.Warn("Failed to get pinned context posts, truncating them with the conversation", "error", err.Error())
+		return pinned
+	}
+	for _, postID := range postIDs {
+		pinned[postID] = true
+	}
+	return pinned
+}
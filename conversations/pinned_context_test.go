@@ -0,0 +1,72 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	mmapimocks "github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckThreadRequester(t *testing.T) {
+	mmBots := &bots.MMBots{}
+	mmBots.SetBotsForTesting([]*bots.Bot{bots.NewBot(llm.BotConfig{Name: "ai"}, &model.Bot{UserId: "botid", Username: "ai"})})
+
+	root := &model.Post{Id: "root", UserId: "requester", ChannelId: "channelid"}
+	answer := &model.Post{Id: "answer", RootId: "root", UserId: "botid", ChannelId: "channelid"}
+	answer.AddProp(streaming.LLMRequesterUserID, "requester")
+	aiThread := model.NewPostList()
+	aiThread.AddPost(root)
+	aiThread.AddPost(answer)
+
+	humanThread := model.NewPostList()
+	humanThread.AddPost(&model.Post{Id: "other", UserId: "requester", ChannelId: "channelid"})
+
+	channel := &model.Channel{Id: "channelid", Type: model.ChannelTypeOpen}
+	dm := &model.Channel{Id: "dmid", Type: model.ChannelTypeDirect, Name: model.GetDMNameFromIds("requester", "botid")}
+
+	tests := []struct {
+		name    string
+		userID  string
+		post    *model.Post
+		channel *model.Channel
+		thread  *model.PostList
+		wantErr error
+	}{
+		{name: "requester of the thread", userID: "requester", post: root, channel: channel, thread: aiThread},
+		{name: "someone else in the thread", userID: "other", post: answer, channel: channel, thread: aiThread, wantErr: ErrNotThreadRequester},
+		{name: "thread without a bot", userID: "requester", post: humanThread.Posts["other"], channel: channel, thread: humanThread, wantErr: ErrNotAIThread},
+		{name: "direct message with a bot", userID: "requester", post: &model.Post{Id: "dmpost", ChannelId: "dmid"}, channel: dm},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mmClient := mmapimocks.NewMockClient(t)
+			if tc.thread != nil {
+				mmClient.EXPECT().GetPostThread(threadRootID(tc.post)).Return(tc.thread, nil)
+			}
+			c := &Conversations{mmClient: mmClient, bots: mmBots}
+
+			err := c.checkThreadRequester(tc.userID, tc.post, tc.channel)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("unpinning checks the requester too", func(t *testing.T) {
+		mmClient := mmapimocks.NewMockClient(t)
+		mmClient.EXPECT().GetPostThread("root").Return(aiThread, nil)
+		c := &Conversations{mmClient: mmClient, bots: mmBots}
+
+		assert.ErrorIs(t, c.UnpinContextPost("other", root, channel), ErrNotThreadRequester)
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// PrivateAskProp is set on the ephemeral answers to questions asked privately
+const PrivateAskProp = "private_ask"
+
+// PrivateAsk answers a question in an ephemeral post only the user sees, so quick lookups in a channel don't
+// clutter it. When rootID is set the question is about that thread of the channel, and answered in it.
+// The answer quotes the question and is filled in once it is generated. Nothing is saved, so the question
+// can't be followed up on. Returns the ephemeral post.
+func (c *Conversations) PrivateAsk(bot *bots.Bot, user *model.User, channel *model.Channel, rootID, question string) (*model.Post, error) {
+	question, err := validateQuestion(question)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ephemeral posts can't ask for tool calls to be approved, so no tools are given
+	llmContext := c.contextBuilder.BuildLLMContextUserRequest(bot, user, channel)
+	systemPrompt, err := c.prompts.Format(prompts.PromptDirectMessageQuestionSystem, llmContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format prompt: %w", err)
+	}
+	posts := []llm.Post{{Role: llm.PostRoleSystem, Message: systemPrompt}}
+
+	if rootID != "" {
+		thread, threadErr := mmapi.GetThreadData(c.mmClient, rootID)
+		if threadErr != nil {
+			return nil, fmt.Errorf("unable to get thread: %w", threadErr)
+		}
+		if len(thread.Posts) == 0 || thread.Posts[0].ChannelId != channel.Id {
+			return nil, fmt.Errorf("%w: the thread isn't in the channel", ErrInvalidQuestion)
+		}
+		posts = append(posts, c.ThreadToLLMPosts(bot, thread.Posts)...)
+	}
+	posts = append(posts, llm.Post{Role: llm.PostRoleUser, Message: question})
+
+	stream, err := bot.LLM().ChatCompletion(llm.CompletionRequest{
+		Posts:   posts,
+		Context: llmContext,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	answer := &model.Post{
+		UserId:    bot.GetMMBot().UserId,
+		ChannelId: channel.Id,
+		RootId:    rootID,
+		Message:   quoteMarkdown(question) + "\n\n",
+	}
+	answer.AddProp(PrivateAskProp, "true")
+	c.pluginAPI.Post.SendEphemeralPost(user.Id, answer)
+
+	go func() {
+		text, readErr := stream.ReadAll()
+		if readErr != nil {
+			c.pluginAPI.Log.Error("Failed to answer private question", "error", readErr.Error())
+			T := i18n.LocalizerFunc(c.i18n, user.Locale)
+			text = T("copilot.stream_to_post_access_llm_error", "Sorry! An error occurred while accessing the LLM. See server logs for details.")
+		}
+		answer.Message += text
+		c.pluginAPI.Post.UpdateEphemeralPost(user.Id, answer)
+	}()
+
+	return answer, nil
+}
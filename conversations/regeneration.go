@@ -7,6 +7,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/mattermost/mattermost-plugin-ai/i18n"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
@@ -20,10 +22,28 @@ import (
 const (
 	ReferencedRecordingFileID  = "referenced_recording_file_id"
 	ReferencedTranscriptPostID = "referenced_transcript_post_id"
+	SummaryFormatProp          = "summary_format"
+	TranscriptRangeProp        = "transcript_range"
+	EditedTranscriptFileIDProp = "edited_transcript_file_id"
 )
 
-// HandleRegenerate handles post regeneration requests
-func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channel *model.Channel) error {
+// MaxRegenerationInstructionLength limits the instructions responses are regenerated with
+const MaxRegenerationInstructionLength = 500
+
+// ErrInvalidRegenerationInstruction is returned when regenerating with an instruction that is too long.
+var ErrInvalidRegenerationInstruction = fmt.Errorf("regeneration instructions are limited to %d characters", MaxRegenerationInstructionLength)
+
+// HandleRegenerate handles post regeneration requests. The response is steered by the instruction, such
+// as "shorter" or "more formal", when one is given.
+func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channel *model.Channel, instruction string) error {
+	instruction = strings.TrimSpace(instruction)
+	if utf8.RuneCountInString(instruction) > MaxRegenerationInstructionLength {
+		return ErrInvalidRegenerationInstruction
+	}
+	withInstruction := func(llmContext *llm.Context) {
+		llmContext.RegenerationInstruction = instruction
+	}
+
 	bot := c.bots.GetBotByID(post.UserId)
 	if bot == nil {
 		return fmt.Errorf("unable to get bot")
@@ -52,7 +72,9 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 	analysisTypeProp := post.GetProp(AnalysisTypeProp)
 	referenceRecordingFileIDProp := post.GetProp(ReferencedRecordingFileID)
 	referencedTranscriptPostProp := post.GetProp(ReferencedTranscriptPostID)
+	askedAboutPostProp, _ := post.GetProp(AskedAboutPostProp).(string)
 	post.DelProp(streaming.ToolCallProp)
+	post.DelProp(FollowUpSuggestionsProp)
 	var result *llm.TextStreamResult
 	switch {
 	case threadIDProp != nil:
@@ -75,6 +97,7 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 			user,
 			channel,
 			c.contextBuilder.WithLLMContextDefaultTools(bot, mmapi.IsDMWith(bot.GetMMBot().UserId, channel)),
+			withInstruction,
 		)
 
 		analyzer := threads.New(bot.LLM(), c.prompts, c.mmClient)
@@ -92,6 +115,13 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 			return fmt.Errorf("could not analyze thread on regen: %w", err)
 		}
 
+	case askedAboutPostProp != "":
+		var askErr error
+		result, askErr = c.regenerateAskAboutPost(bot, user, channel, post, askedAboutPostProp, withInstruction)
+		if askErr != nil {
+			return fmt.Errorf("could not answer question about post on regen: %w", askErr)
+		}
+
 	case referenceRecordingFileIDProp != nil:
 		post.Message = ""
 		referencedRecordingFileID := referenceRecordingFileIDProp.(string)
@@ -101,11 +131,16 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 			return fmt.Errorf("could not get transcription file on regen: %w", getErr)
 		}
 
-		reader, getErr := c.pluginAPI.File.Get(post.FileIds[0])
+		// The transcript attached to the post, or its corrected version when it was edited
+		transcriptFileID, linkedRecordingFileID, fileIDErr := c.meetingsService.TranscriptFileIDs(post)
+		if fileIDErr != nil {
+			return fmt.Errorf("could not get transcription file on regen: %w", fileIDErr)
+		}
+		reader, getErr := c.pluginAPI.File.Get(transcriptFileID)
 		if getErr != nil {
 			return fmt.Errorf("could not get transcription file on regen: %w", getErr)
 		}
-		transcription, parseErr := subtitles.NewSubtitlesFromVTT(reader)
+		transcription, parseErr := subtitles.NewSubtitlesFromTranscript(reader)
 		if parseErr != nil {
 			return fmt.Errorf("could not parse transcription file on regen: %w", parseErr)
 		}
@@ -124,9 +159,11 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 			user,
 			originalFileChannel,
 			c.contextBuilder.WithLLMContextDefaultTools(bot, originalFileChannel.Type == model.ChannelTypeDirect),
+			withInstruction,
 		)
+		format, _ := post.GetProp(SummaryFormatProp).(string)
 		var summaryErr error
-		result, summaryErr = c.meetingsService.SummarizeTranscription(bot, transcription, context)
+		result, summaryErr = c.meetingsService.SummarizeTranscription(bot, transcription, context, format, linkedRecordingFileID)
 		if summaryErr != nil {
 			return fmt.Errorf("could not summarize transcription on regen: %w", summaryErr)
 		}
@@ -138,28 +175,43 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 			return fmt.Errorf("could not get transcription post on regen: %w", postErr)
 		}
 
-		transcriptionFileID, fileIDErr := c.meetingsService.GetCaptionsFileIDFromProps(referencedTranscriptionPost)
+		transcriptionFileID, recordingFileID, fileIDErr := c.meetingsService.TranscriptFileIDs(referencedTranscriptionPost)
 		if fileIDErr != nil {
 			return fmt.Errorf("unable to get transcription file id: %w", fileIDErr)
 		}
+		if editedFileID, ok := post.GetProp(EditedTranscriptFileIDProp).(string); ok && editedFileID != "" {
+			transcriptionFileID = editedFileID
+		}
 		transcriptionFileReader, fileErr := c.pluginAPI.File.Get(transcriptionFileID)
 		if fileErr != nil {
 			return fmt.Errorf("unable to read calls file: %w", fileErr)
 		}
 
-		transcription, parseErr := subtitles.NewSubtitlesFromVTT(transcriptionFileReader)
+		transcription, parseErr := subtitles.NewSubtitlesFromTranscript(transcriptionFileReader)
 		if parseErr != nil {
 			return fmt.Errorf("unable to parse transcription file: %w", parseErr)
 		}
 
+		// Summaries of part of the transcript are regenerated from the same part
+		if transcriptRange, ok := post.GetProp(TranscriptRangeProp).(string); ok && transcriptRange != "" {
+			timeRange, rangeErr := subtitles.ParseTimeRange(transcriptRange)
+			if rangeErr != nil {
+				return fmt.Errorf("unable to parse transcript range: %w", rangeErr)
+			}
+			transcription = transcription.Slice(timeRange)
+		}
+
 		context := c.contextBuilder.BuildLLMContextUserRequest(
 			bot,
 			user,
 			channel,
 			c.contextBuilder.WithLLMContextDefaultTools(bot, mmapi.IsDMWith(bot.GetMMBot().UserId, channel)),
+			withInstruction,
 		)
+		// Summaries are regenerated in the format they were requested in
+		format, _ := post.GetProp(SummaryFormatProp).(string)
 		var summaryErr error
-		result, summaryErr = c.meetingsService.SummarizeTranscription(bot, transcription, context)
+		result, summaryErr = c.meetingsService.SummarizeTranscription(bot, transcription, context, format, recordingFileID)
 		if summaryErr != nil {
 			return fmt.Errorf("unable to summarize transcription: %w", summaryErr)
 		}
@@ -182,8 +234,22 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 			user,
 			channel,
 			c.contextBuilder.WithLLMContextDefaultTools(bot, mmapi.IsDMWith(bot.GetMMBot().UserId, channel)),
+			withInstruction,
 		)
 
+		if isLongContent(bot.LLM(), respondingToPost.Message) {
+			T := i18n.LocalizerFunc(c.i18n, user.Locale)
+			condensed, condenseErr := c.condenseLongContent(bot.LLM(), respondingToPost, contextWithCallback, func(done, total int) {
+				c.updateLongContentProgress(post, T, done, total)
+			})
+			if condenseErr != nil {
+				return fmt.Errorf("could not read long message on regen: %w", condenseErr)
+			}
+			respondingToPost = condensed
+			post.Message = ""
+			post.DelProp(LongContentProgressProp)
+		}
+
 		// Process the user request with the context that has the callback
 		var processErr error
 		result, processErr = c.ProcessUserRequestWithContext(bot, user, channel, respondingToPost, contextWithCallback)
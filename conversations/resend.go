@@ -0,0 +1,68 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+var (
+	// ErrResendNotOwnPost is returned when resending a post of someone else.
+	ErrResendNotOwnPost = errors.New("only the author of a message can resend it")
+
+	// ErrResendNotToBot is returned when resending a post no bot would answer.
+	ErrResendNotToBot = errors.New("only messages in a direct message with a bot, or mentioning one, can be resent")
+
+	// ErrResendNotEdited is returned when resending a post that was never edited.
+	ErrResendNotEdited = errors.New("only edited messages can be resent")
+)
+
+// postsAfter returns the posts of the thread that come after the post and were made by the user or a bot.
+// System messages aren't part of the conversation, and the posts of other users aren't the user's to
+// delete, so both are left out.
+func postsAfter(posts []*model.Post, postID, userID string, isBot func(userID string) bool) []*model.Post {
+	var after []*model.Post
+	found := false
+	for _, post := range posts {
+		if found && !post.IsSystemMessage() && (post.UserId == userID || isBot(post.UserId)) {
+			after = append(after, post)
+		}
+		if post.Id == postID {
+			found = true
+		}
+	}
+	return after
+}
+
+// ResendEditedPost answers the post again after its author edited it. The responses of bots and the posts
+// of the author that came after it in the thread are deleted, so the conversation goes on from the edited
+// post as if it had just been sent. The posts of other users are kept.
+func (c *Conversations) ResendEditedPost(userID string, post *model.Post, channel *model.Channel) error {
+	if post.UserId != userID {
+		return ErrResendNotOwnPost
+	}
+	if post.EditAt == 0 {
+		return ErrResendNotEdited
+	}
+	if len(c.bots.GetBotsMentioned(post.Message)) == 0 && c.bots.GetBotForDMChannel(channel) == nil {
+		return ErrResendNotToBot
+	}
+
+	threadData, err := mmapi.GetThreadData(c.mmClient, threadRootID(post))
+	if err != nil {
+		return fmt.Errorf("unable to get conversation: %w", err)
+	}
+	for _, later := range postsAfter(threadData.Posts, post.Id, userID, c.bots.IsAnyBot) {
+		c.streamingService.StopStreaming(later.Id)
+		if err := c.pluginAPI.Post.DeletePost(later.Id); err != nil {
+			return fmt.Errorf("unable to delete later post: %w", err)
+		}
+	}
+
+	return c.handleMessages(post)
+}
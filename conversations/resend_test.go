@@ -0,0 +1,46 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostsAfter(t *testing.T) {
+	posts := []*model.Post{
+		{Id: "root", UserId: "user"},
+		{Id: "response", UserId: "bot"},
+		{Id: "question", UserId: "user"},
+		{Id: "joined", UserId: "user", Type: model.PostTypeJoinChannel},
+		{Id: "reply", UserId: "other"},
+		{Id: "answer", UserId: "bot"},
+	}
+	isBot := func(userID string) bool {
+		return userID == "bot"
+	}
+
+	tests := []struct {
+		name     string
+		postID   string
+		expected []string
+	}{
+		{name: "from the root", postID: "root", expected: []string{"response", "question", "answer"}},
+		{name: "from a reply, keeping the replies of other users", postID: "question", expected: []string{"answer"}},
+		{name: "from the last post", postID: "answer", expected: nil},
+		{name: "from a post not in the thread", postID: "other", expected: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var ids []string
+			for _, post := range postsAfter(posts, tc.postID, "user", isBot) {
+				ids = append(ids, post.Id)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// ScheduledPromptProp is set on responses to scheduled prompts to the prompt that was run
+const ScheduledPromptProp = "scheduled_prompt"
+
+// RunScheduledPrompt starts a new conversation in the direct message of the user with the bot, answering
+// a prompt they scheduled as if they had just sent it. The bot's usage restrictions are checked on each run.
+func (c *Conversations) RunScheduledPrompt(userID, botUsername, prompt, title string) error {
+	bot := c.bots.GetBotByUsername(botUsername)
+	if bot == nil {
+		return fmt.Errorf("bot %q not found", botUsername)
+	}
+	if err := c.bots.CheckUsageRestrictionsForUser(bot, userID); err != nil {
+		return err
+	}
+
+	user, err := c.pluginAPI.User.Get(userID)
+	if err != nil {
+		return fmt.Errorf("unable to get user: %w", err)
+	}
+	if user.DeleteAt != 0 {
+		return fmt.Errorf("user is deactivated")
+	}
+	channel, err := c.mmClient.GetDirectChannel(userID, bot.GetMMBot().UserId)
+	if err != nil {
+		return fmt.Errorf("unable to get direct message channel: %w", err)
+	}
+
+	llmContext := c.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		user,
+		channel,
+		c.contextBuilder.WithLLMContextDefaultTools(bot, true),
+	)
+	systemPrompt, err := c.prompts.Format(prompts.PromptDirectMessageQuestionSystem, llmContext)
+	if err != nil {
+		return fmt.Errorf("failed to format prompt: %w", err)
+	}
+
+	stream, err := bot.LLM().ChatCompletion(llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: prompt},
+		},
+		Context: llmContext,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to run scheduled prompt: %w", err)
+	}
+
+	post := &model.Post{}
+	post.AddProp(ScheduledPromptProp, prompt)
+	post.AddProp(streaming.NoRegen, "true")
+	if err := c.streamingService.StreamToNewDM(context.Background(), bot.GetMMBot().UserId, stream, user.Id, post, ""); err != nil {
+		return fmt.Errorf("unable to stream scheduled prompt response: %w", err)
+	}
+
+	c.SaveTitleAsync(post.Id, title)
+
+	return nil
+}
@@ -27,24 +27,114 @@ func (c *Conversations) SaveTitle(threadID, title string) error {
 	return err
 }
 
+// SaveCategory saves the category a thread was classified into
+func (c *Conversations) SaveCategory(threadID, category string) error {
+	_, err := c.db.ExecBuilder(c.db.Builder().Insert("LLM_PostMeta").
+		Columns("RootPostID", "Title", "Category").
+		Values(threadID, "", category).
+		Suffix("ON CONFLICT (RootPostID) DO UPDATE SET Category = ?", category))
+	return err
+}
+
+// SavePersona saves the persona a thread is conducted in, an empty persona is the bot's default behavior
+func (c *Conversations) SavePersona(threadID, personaID string) error {
+	_, err := c.db.ExecBuilder(c.db.Builder().Insert("LLM_PostMeta").
+		Columns("RootPostID", "Title", "Persona").
+		Values(threadID, "", personaID).
+		Suffix("ON CONFLICT (RootPostID) DO UPDATE SET Persona = ?", personaID))
+	return err
+}
+
+// GetPersona returns the persona a thread is conducted in, empty when it has none
+func (c *Conversations) GetPersona(threadID string) (string, error) {
+	var personas []string
+	if err := c.db.DoQuery(&personas, c.db.Builder().
+		Select("Persona").
+		From("LLM_PostMeta").
+		Where(sq.Eq{"RootPostID": threadID}),
+	); err != nil {
+		return "", fmt.Errorf("failed to get thread persona: %w", err)
+	}
+	if len(personas) == 0 {
+		return "", nil
+	}
+	return personas[0], nil
+}
+
+// SaveActiveBot saves the bot answering a thread, an empty bot is the bot the conversation is with
+func (c *Conversations) SaveActiveBot(threadID, botID string) error {
+	_, err := c.db.ExecBuilder(c.db.Builder().Insert("LLM_PostMeta").
+		Columns("RootPostID", "Title", "ActiveBotID").
+		Values(threadID, "", botID).
+		Suffix("ON CONFLICT (RootPostID) DO UPDATE SET ActiveBotID = ?", botID))
+	return err
+}
+
+// GetActiveBot returns the ID of the bot answering a thread, empty when it wasn't switched
+func (c *Conversations) GetActiveBot(threadID string) (string, error) {
+	var botIDs []string
+	if err := c.db.DoQuery(&botIDs, c.db.Builder().
+		Select("ActiveBotID").
+		From("LLM_PostMeta").
+		Where(sq.Eq{"RootPostID": threadID}),
+	); err != nil {
+		return "", fmt.Errorf("failed to get thread active bot: %w", err)
+	}
+	if len(botIDs) == 0 {
+		return "", nil
+	}
+	return botIDs[0], nil
+}
+
+// CategoryUsage is how many AI threads of a category were started, and how many replies they got.
+type CategoryUsage struct {
+	Category string `json:"category"`
+	Threads  int    `json:"threads"`
+	Replies  int    `json:"replies"`
+}
+
+// GetCategoryUsage counts the classified AI threads started since the time given, in milliseconds, by category.
+func (c *Conversations) GetCategoryUsage(since int64) ([]CategoryUsage, error) {
+	usage := []CategoryUsage{}
+	if err := c.db.DoQuery(&usage, c.db.Builder().
+		Select(
+			"t.Category",
+			"COUNT(DISTINCT p.Id) AS Threads",
+			"COUNT(r.Id) AS Replies",
+		).
+		From("LLM_PostMeta as t").
+		Join("Posts as p ON p.Id = t.RootPostID").
+		LeftJoin("Posts as r ON r.RootId = p.Id AND r.DeleteAt = 0").
+		Where(sq.NotEq{"t.Category": ""}).
+		Where(sq.Eq{"p.DeleteAt": 0}).
+		Where(sq.GtOrEq{"p.CreateAt": since}).
+		GroupBy("t.Category").
+		OrderBy("Threads DESC"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to get thread category usage: %w", err)
+	}
+	return usage, nil
+}
+
 // This is a different AIThread struct than the one in conversations.go, used for database queries
 type aiThreadData struct {
 	ID         string
 	Message    string
 	ChannelID  string
 	Title      string
+	Category   string
 	ReplyCount int
 	UpdateAt   int64
 }
 
-func (c *Conversations) getAIThreads(dmChannelIDs []string) ([]AIThread, error) {
-	var dbPosts []aiThreadData
-	if err := c.db.DoQuery(&dbPosts, c.db.Builder().
+func (c *Conversations) getAIThreads(dmChannelIDs []string, category string) ([]AIThread, error) {
+	query := c.db.Builder().
 		Select(
 			"p.Id",
 			"p.Message",
 			"p.ChannelID",
 			"COALESCE(t.Title, '') as Title",
+			"COALESCE(t.Category, '') as Category",
 			"(SELECT COUNT(*) FROM Posts WHERE Posts.RootId = p.Id AND DeleteAt = 0) AS ReplyCount",
 			"p.UpdateAt",
 		).
@@ -55,8 +145,13 @@ func (c *Conversations) getAIThreads(dmChannelIDs []string) ([]AIThread, error)
 		LeftJoin("LLM_PostMeta as t ON t.RootPostID = p.Id").
 		OrderBy("CreateAt DESC").
 		Limit(60).
-		Offset(0),
-	); err != nil {
+		Offset(0)
+	if category != "" {
+		query = query.Where(sq.Eq{"t.Category": category})
+	}
+
+	var dbPosts []aiThreadData
+	if err := c.db.DoQuery(&dbPosts, query); err != nil {
 		return nil, fmt.Errorf("failed to get posts for bot DM: %w", err)
 	}
 
@@ -66,6 +161,7 @@ func (c *Conversations) getAIThreads(dmChannelIDs []string) ([]AIThread, error)
 		result[i] = AIThread{
 			ID:        post.ID,
 			Title:     post.Title,
+			Category:  post.Category,
 			ChannelID: post.ChannelID,
 			BotID:     "", // We don't have this info in the query
 			UpdatedAt: post.UpdateAt,
@@ -74,3 +170,36 @@ func (c *Conversations) getAIThreads(dmChannelIDs []string) ([]AIThread, error)
 
 	return result, nil
 }
+
+type postMeta struct {
+	Title   string
+	Persona string
+}
+
+// getPostMeta returns the title and persona of a thread, empty when it has none
+func (c *Conversations) getPostMeta(threadID string) (postMeta, error) {
+	var metas []postMeta
+	if err := c.db.DoQuery(&metas, c.db.Builder().
+		Select("Title", "Persona").
+		From("LLM_PostMeta").
+		Where(sq.Eq{"RootPostID": threadID}),
+	); err != nil {
+		return postMeta{}, fmt.Errorf("failed to get thread meta: %w", err)
+	}
+	if len(metas) == 0 {
+		return postMeta{}, nil
+	}
+	return metas[0], nil
+}
+
+// SaveFork saves the thread a conversation was forked to, with the post it was forked from and the title
+// and persona of the original thread
+func (c *Conversations) SaveFork(threadID, forkedFromPostID, title, personaID string) error {
+	if _, err := c.db.ExecBuilder(c.db.Builder().Insert("LLM_PostMeta").
+		Columns("RootPostID", "Title", "Persona", "ForkedFromPostID").
+		Values(threadID, title, personaID, forkedFromPostID).
+		Suffix("ON CONFLICT (RootPostID) DO UPDATE SET Title = ?, Persona = ?, ForkedFromPostID = ?", title, personaID, forkedFromPostID)); err != nil {
+		return fmt.Errorf("failed to save forked thread: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Refinements that rewrite a summary
+const (
+	RefinementShorter     = "shorter"
+	RefinementMoreDetail  = "more_detail"
+	RefinementActionItems = "action_items"
+	RefinementTranslate   = "translate"
+)
+
+const (
+	// SummaryVersionsProp keeps the earlier versions of a refined summary, the original first
+	SummaryVersionsProp = "summary_versions"
+
+	// SummaryRefinementProp is the refinement applied to the current version of a summary
+	SummaryRefinementProp = "summary_refinement"
+
+	// maxSummaryVersions bounds the versions kept in the post props, the original is always kept
+	maxSummaryVersions = 10
+
+	// refinementSourceTokenRatio is the share of the input token limit the summarized material can use
+	refinementSourceTokenRatio = 0.5
+)
+
+var (
+	// ErrNotSummaryPost is returned when refining a post that isn't a summary.
+	ErrNotSummaryPost = errors.New("post is not a summary")
+
+	// ErrUnknownRefinement is returned when refining a summary with a refinement that doesn't exist.
+	ErrUnknownRefinement = errors.New("unknown summary refinement")
+
+	// ErrRefineNotRequester is returned when someone else than the user who asked for a summary refines it.
+	ErrRefineNotRequester = errors.New("only the original requester can refine the summary")
+)
+
+// refinementReactions are the emoji that refine a summary when its requester reacts with them
+var refinementReactions = map[string]string{
+	"scissors":             RefinementShorter,
+	"mag":                  RefinementMoreDetail,
+	"clipboard":            RefinementActionItems,
+	"globe_with_meridians": RefinementTranslate,
+}
+
+// SummaryVersion is an earlier version of a refined summary.
+type SummaryVersion struct {
+	Message string `json:"message"`
+
+	// Refinement that produced the version, empty for the original
+	Refinement string `json:"refinement,omitempty"`
+}
+
+// RefinementForReaction returns the refinement requested by reacting to a summary with the emoji.
+func RefinementForReaction(emojiName string) (string, bool) {
+	refinement, ok := refinementReactions[emojiName]
+	return refinement, ok
+}
+
+func isRefinement(refinement string) bool {
+	switch refinement {
+	case RefinementShorter, RefinementMoreDetail, RefinementActionItems, RefinementTranslate:
+		return true
+	}
+	return false
+}
+
+// IsSummaryPost returns true for thread and meeting summaries.
+func IsSummaryPost(post *model.Post) bool {
+	// Excludes the posts announcing a summary is on its way
+	if post.GetProp(streaming.NoRegen) != nil {
+		return false
+	}
+	if analysisType, _ := post.GetProp(AnalysisTypeProp).(string); analysisType == "summarize_thread" {
+		return post.GetProp(ThreadIDProp) != nil
+	}
+	return post.GetProp(ReferencedRecordingFileID) != nil || post.GetProp(ReferencedTranscriptPostID) != nil
+}
+
+// summaryVersions returns the earlier versions of the summary kept in the post props.
+func summaryVersions(post *model.Post) []SummaryVersion {
+	raw := post.GetProp(SummaryVersionsProp)
+	if raw == nil {
+		return nil
+	}
+
+	// Props read back from the database are generic JSON values
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var versions []SummaryVersion
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil
+	}
+	return versions
+}
+
+// addSummaryVersion keeps the current summary as a version, dropping the oldest refinements but
+// never the original once there are too many.
+func addSummaryVersion(versions []SummaryVersion, current SummaryVersion) []SummaryVersion {
+	versions = append(versions, current)
+	if len(versions) > maxSummaryVersions {
+		versions = append(versions[:1], versions[len(versions)-maxSummaryVersions+1:]...)
+	}
+	return versions
+}
+
+// HandleRefineSummary rewrites a summary with the refinement applied, such as making it shorter or
+// translating it, keeping the current summary as a version.
+func (c *Conversations) HandleRefineSummary(userID string, post *model.Post, channel *model.Channel, refinement string) error {
+	if !isRefinement(refinement) {
+		return fmt.Errorf("%w: %q", ErrUnknownRefinement, refinement)
+	}
+	if !IsSummaryPost(post) {
+		return ErrNotSummaryPost
+	}
+
+	bot := c.bots.GetBotByID(post.UserId)
+	if bot == nil {
+		return fmt.Errorf("unable to get bot")
+	}
+
+	if post.GetProp(streaming.LLMRequesterUserID) != userID {
+		return ErrRefineNotRequester
+	}
+
+	user, err := c.pluginAPI.User.Get(userID)
+	if err != nil {
+		return fmt.Errorf("unable to get user to refine summary: %w", err)
+	}
+
+	// Thread summaries start with a line linking to the thread, which is kept as it is
+	intro := ""
+	if threadID, ok := post.GetProp(ThreadIDProp).(string); ok {
+		siteURL := c.pluginAPI.Configuration.GetConfig().ServiceSettings.SiteURL
+		if analysisIntro := i18n.FormatAnalysisPostMessage(c.i18n, user.Locale, threadID, "summarize_thread", *siteURL); strings.HasPrefix(post.Message, analysisIntro) {
+			intro = analysisIntro
+		}
+	}
+	summary := strings.TrimPrefix(post.Message, intro)
+	if strings.TrimSpace(summary) == "" {
+		return errors.New("summary is empty")
+	}
+
+	llmContext := c.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		user,
+		channel,
+	)
+	source := c.summarySource(post, bot.LLM())
+	llmContext.Parameters = map[string]any{
+		"Refinement": refinement,
+		"Locale":     user.Locale,
+		"Source":     source,
+	}
+	systemPrompt, err := c.prompts.Format(prompts.PromptSummaryRefinementSystem, llmContext)
+	if err != nil {
+		return fmt.Errorf("unable to get summary refinement prompt: %w", err)
+	}
+
+	userMessage := summary
+	if source != "" {
+		userMessage = fmt.Sprintf("Summary:\n%s\n\nMaterial:\n%s", summary, source)
+	}
+
+	result, err := bot.LLM().ChatCompletion(llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: userMessage},
+		},
+		Context: llmContext,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to refine summary: %w", err)
+	}
+
+	ctx, err := c.streamingService.GetStreamingContext(context.Background(), post.Id)
+	if err != nil {
+		return fmt.Errorf("unable to get post streaming context: %w", err)
+	}
+
+	previousRefinement, _ := post.GetProp(SummaryRefinementProp).(string)
+	post.AddProp(SummaryVersionsProp, addSummaryVersion(summaryVersions(post), SummaryVersion{
+		Message:    post.Message,
+		Refinement: previousRefinement,
+	}))
+	post.AddProp(SummaryRefinementProp, refinement)
+	post.Message = intro
+
+	go func() {
+		defer c.streamingService.FinishStreaming(post.Id)
+		c.streamingService.StreamToPost(ctx, result, post, user.Locale)
+	}()
+
+	return nil
+}
+
+// summarySource returns the thread or transcript the summary was made from when it fits in a request
+// with the summary, so refinements such as adding detail can use it. It is empty otherwise.
+func (c *Conversations) summarySource(post *model.Post, languageModel llm.LanguageModel) string {
+	source := ""
+	switch {
+	case post.GetProp(ThreadIDProp) != nil:
+		threadID, _ := post.GetProp(ThreadIDProp).(string)
+		threadData, err := mmapi.GetThreadData(c.mmClient, threadID)
+		if err != nil {
+			c.pluginAPI.Log.Debug("Unable to get thread to refine summary", "error", err)
+			return ""
+		}
+		source = format.ThreadData(threadData)
+	case post.GetProp(ReferencedTranscriptPostID) != nil:
+		transcriptPostID, _ := post.GetProp(ReferencedTranscriptPostID).(string)
+		transcriptPost, err := c.pluginAPI.Post.GetPost(transcriptPostID)
+		if err != nil {
+			c.pluginAPI.Log.Debug("Unable to get transcript post to refine summary", "error", err)
+			return ""
+		}
+		source, err = c.meetingsService.TranscriptForLLM(transcriptPost)
+		if err != nil {
+			c.pluginAPI.Log.Debug("Unable to read transcript to refine summary", "error", err)
+			return ""
+		}
+	default:
+		var err error
+		source, err = c.meetingsService.TranscriptForLLM(post)
+		if err != nil {
+			c.pluginAPI.Log.Debug("Unable to read transcript to refine summary", "error", err)
+			return ""
+		}
+	}
+
+	if languageModel.CountTokens(source) > int(float64(languageModel.InputTokenLimit())*refinementSourceTokenRatio) {
+		return ""
+	}
+	return source
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSummaryPost(t *testing.T) {
+	tests := []struct {
+		name     string
+		props    map[string]any
+		expected bool
+	}{
+		{
+			name:     "thread summary",
+			props:    map[string]any{ThreadIDProp: "thread", AnalysisTypeProp: "summarize_thread"},
+			expected: true,
+		},
+		{
+			name:     "thread action items",
+			props:    map[string]any{ThreadIDProp: "thread", AnalysisTypeProp: "action_items"},
+			expected: false,
+		},
+		{
+			name:     "call recording summary",
+			props:    map[string]any{ReferencedRecordingFileID: "recording"},
+			expected: true,
+		},
+		{
+			name:     "transcript summary",
+			props:    map[string]any{ReferencedTranscriptPostID: "transcript"},
+			expected: true,
+		},
+		{
+			name:     "summary on its way",
+			props:    map[string]any{ReferencedTranscriptPostID: "transcript", streaming.NoRegen: "true"},
+			expected: false,
+		},
+		{
+			name:     "conversation response",
+			props:    map[string]any{streaming.RespondingToProp: "post"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			post := &model.Post{}
+			for key, value := range tc.props {
+				post.AddProp(key, value)
+			}
+			assert.Equal(t, tc.expected, IsSummaryPost(post))
+		})
+	}
+}
+
+func TestRefinementForReaction(t *testing.T) {
+	refinement, ok := RefinementForReaction("scissors")
+	assert.True(t, ok)
+	assert.Equal(t, RefinementShorter, refinement)
+
+	_, ok = RefinementForReaction("+1")
+	assert.False(t, ok)
+}
+
+func TestHandleRefineSummaryNotRequester(t *testing.T) {
+	mmBots := &bots.MMBots{}
+	mmBots.SetBotsForTesting([]*bots.Bot{bots.NewBot(llm.BotConfig{Name: "ai"}, &model.Bot{UserId: "botid", Username: "ai"})})
+	c := &Conversations{bots: mmBots}
+
+	post := &model.Post{Id: "summaryid", UserId: "botid"}
+	post.AddProp(AnalysisTypeProp, "summarize_thread")
+	post.AddProp(ThreadIDProp, "threadid")
+	post.AddProp(streaming.LLMRequesterUserID, "requester")
+
+	err := c.HandleRefineSummary("other", post, &model.Channel{}, RefinementShorter)
+	assert.ErrorIs(t, err, ErrRefineNotRequester)
+}
+
+func TestSummaryVersions(t *testing.T) {
+	post := &model.Post{}
+	assert.Empty(t, summaryVersions(post))
+
+	// Props read back from the database are generic JSON values
+	post.AddProp(SummaryVersionsProp, []any{
+		map[string]any{"message": "original"},
+		map[string]any{"message": "shorter", "refinement": RefinementShorter},
+	})
+	assert.Equal(t, []SummaryVersion{
+		{Message: "original"},
+		{Message: "shorter", Refinement: RefinementShorter},
+	}, summaryVersions(post))
+}
+
+func TestAddSummaryVersion(t *testing.T) {
+	var versions []SummaryVersion
+	for i := 0; i < maxSummaryVersions+3; i++ {
+		versions = addSummaryVersion(versions, SummaryVersion{Message: fmt.Sprintf("version %d", i)})
+	}
+
+	assert.Len(t, versions, maxSummaryVersions)
+	assert.Equal(t, "version 0", versions[0].Message)
+	assert.Equal(t, "version 4", versions[1].Message)
+	assert.Equal(t, fmt.Sprintf("version %d", maxSummaryVersions+2), versions[len(versions)-1].Message)
+}
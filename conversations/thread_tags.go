@@ -0,0 +1,90 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+)
+
+// Categories AI threads are classified into
+const (
+	CategoryCoding  = "coding"
+	CategoryHR      = "hr"
+	CategorySupport = "support"
+	CategoryMeeting = "meeting"
+	CategoryOther   = "other"
+)
+
+// maxClassifiedRequestLength is how much of the request is classified, the start of a long request says enough
+const maxClassifiedRequestLength = 2000
+
+// ThreadCategories lists the categories AI threads are classified into.
+var ThreadCategories = []string{CategoryCoding, CategoryHR, CategorySupport, CategoryMeeting, CategoryOther}
+
+// ParseCategory returns the category a classification response names, other when it names none.
+func ParseCategory(response string) string {
+	category := strings.ToLower(strings.Trim(response, " \n\t\"'.`*"))
+	if slices.Contains(ThreadCategories, category) {
+		return category
+	}
+	return CategoryOther
+}
+
+// ClassifyThread tags a new thread with the category of its first request when thread tagging is enabled.
+// The request is classified with the configured model, usually cheaper than the one answering it.
+func (c *Conversations) ClassifyThread(bot *bots.Bot, request string, postID string, context *llm.Context) error {
+	tagging := c.config.GetThreadTagging()
+	if !tagging.Enabled {
+		return nil
+	}
+
+	systemPrompt, err := c.prompts.Format(prompts.PromptThreadClassificationSystem, context)
+	if err != nil {
+		return fmt.Errorf("failed to format classification prompt: %w", err)
+	}
+
+	if runes := []rune(request); len(runes) > maxClassifiedRequestLength {
+		request = string(runes[:maxClassifiedRequestLength])
+	}
+
+	opts := []llm.LanguageModelOption{llm.WithMaxGeneratedTokens(5)}
+	if tagging.Model != "" {
+		opts = append(opts, llm.WithModel(tagging.Model))
+	}
+	response, err := bot.LLM().ChatCompletionNoStream(llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: request},
+		},
+		Context:       context,
+		StopSequences: []string{"\n"},
+	}, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to classify thread: %w", err)
+	}
+
+	if err := c.SaveCategory(postID, ParseCategory(response)); err != nil {
+		return fmt.Errorf("failed to save category: %w", err)
+	}
+
+	return nil
+}
+
+// TagThreadAsync tags a thread whose category is known without classifying it, when thread tagging is enabled.
+func (c *Conversations) TagThreadAsync(threadID, category string) {
+	if !c.config.GetThreadTagging().Enabled {
+		return
+	}
+	go func() {
+		if err := c.SaveCategory(threadID, category); err != nil {
+			c.pluginAPI.Log.Error("failed to save category: " + err.Error())
+		}
+	}()
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{name: "category", response: "coding", want: CategoryCoding},
+		{name: "different case", response: "HR", want: CategoryHR},
+		{name: "surrounded by punctuation", response: " \"Support.\"\n", want: CategorySupport},
+		{name: "markdown", response: "**meeting**", want: CategoryMeeting},
+		{name: "unknown category", response: "finance", want: CategoryOther},
+		{name: "sentence", response: "The category is coding", want: CategoryOther},
+		{name: "empty", response: "", want: CategoryOther},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, ParseCategory(tc.response))
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mcp"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// ToolApprovalRequestPostType is the type of the posts asking delegated approvers to decide on tool calls
+	ToolApprovalRequestPostType = "custom_llm_tool_approval"
+
+	// ToolCallPostIDProp references the post with the tool calls from an approval request
+	ToolCallPostIDProp = "tool_call_post_id"
+
+	channelMembersPerPage = 200
+)
+
+// ErrNotToolCallApprover is returned when a user decides on tool calls they can't approve.
+var ErrNotToolCallApprover = errors.New("user can't approve or reject these tool calls")
+
+// ToolApprovalConfig provides the policies routing tool approvals to users other than the requester.
+type ToolApprovalConfig interface {
+	ToolApprovals() []llm.ToolApprovalPolicy
+}
+
+// ToolApprovalRequest is what a delegated approver needs to decide on the tool calls of a post.
+type ToolApprovalRequest struct {
+	PostID          string         `json:"post_id"`
+	ChannelID       string         `json:"channel_id"`
+	RequesterUserID string         `json:"requester_user_id"`
+	ToolCalls       []llm.ToolCall `json:"tool_calls"`
+}
+
+// AssignToolCallApprovers assigns approvers to the tool calls covered by an approval policy. It is
+// called before the tool calls are saved to the post, so the requester can't decide on them before
+// their approvers are known. Tool calls nobody can approve are rejected.
+func (c *Conversations) AssignToolCallApprovers(post *model.Post, toolCalls []llm.ToolCall) {
+	policies := c.config.ToolApprovals()
+	delegated := map[int]*llm.ToolApprovalPolicy{}
+	for i := range toolCalls {
+		if policy := llm.FindToolApprovalPolicy(policies, toolCalls[i].Name); policy != nil {
+			delegated[i] = policy
+		}
+	}
+	if len(delegated) == 0 {
+		return
+	}
+
+	// Without the channel the approvers aren't known, so the delegated calls are rejected
+	channel, err := c.pluginAPI.Channel.Get(post.ChannelId)
+	if err != nil {
+		c.pluginAPI.Log.Error("Failed to get channel for tool approvals", "error", err)
+	}
+
+	for i, policy := range delegated {
+		var approvers []string
+		if channel != nil {
+			var approversErr error
+			approvers, approversErr = c.toolCallApprovers(*policy, channel)
+			if approversErr != nil {
+				c.pluginAPI.Log.Error("Failed to get tool call approvers", "tool", toolCalls[i].Name, "error", approversErr)
+			}
+		}
+
+		// Nobody can approve the call, the requester isn't allowed to approve it either
+		if len(approvers) == 0 {
+			toolCalls[i].Result = "No approvers are available for this tool"
+			toolCalls[i].Status = llm.ToolCallStatusRejected
+			continue
+		}
+		toolCalls[i].ApproverIDs = approvers
+	}
+}
+
+// HandleToolCallsPosted asks the approvers assigned to the tool calls to decide by DM. It is called once
+// the tool calls have been added to the post.
+func (c *Conversations) HandleToolCallsPosted(post *model.Post, toolCalls []llm.ToolCall) {
+	requesterID, _ := post.GetProp(streaming.LLMRequesterUserID).(string)
+	approvals := map[string][]llm.ToolCall{}
+	var approvers []string
+	for _, toolCall := range toolCalls {
+		if toolCall.Status != llm.ToolCallStatusPending {
+			continue
+		}
+		for _, approver := range toolCall.ApproverIDs {
+			// The requester decides in the conversation itself
+			if approver == requesterID {
+				continue
+			}
+			if _, ok := approvals[approver]; !ok {
+				approvers = append(approvers, approver)
+			}
+			approvals[approver] = append(approvals[approver], toolCall)
+		}
+	}
+
+	for _, approverID := range approvers {
+		if err := c.sendToolApprovalRequest(post, requesterID, approverID, approvals[approverID]); err != nil {
+			c.pluginAPI.Log.Error("Failed to send tool approval request", "approver_id", approverID, "error", err)
+		}
+	}
+}
+
+// toolCallApprovers returns the IDs of the users who approve calls covered by the policy in the channel.
+func (c *Conversations) toolCallApprovers(policy llm.ToolApprovalPolicy, channel *model.Channel) ([]string, error) {
+	if policy.Approvers != llm.ToolApproversChannelAdmins {
+		return policy.ApproverUserIDs, nil
+	}
+
+	var admins []string
+	for page := 0; ; page++ {
+		members, err := c.pluginAPI.Channel.ListMembers(channel.Id, page, channelMembersPerPage)
+		if err != nil {
+			return policy.ApproverUserIDs, fmt.Errorf("failed to list channel members: %w", err)
+		}
+		for _, member := range members {
+			if member.SchemeAdmin {
+				admins = append(admins, member.UserId)
+			}
+		}
+		if len(members) < channelMembersPerPage {
+			break
+		}
+	}
+
+	// Direct and group messages have no admins
+	if len(admins) == 0 {
+		return policy.ApproverUserIDs, nil
+	}
+	return admins, nil
+}
+
+func (c *Conversations) sendToolApprovalRequest(post *model.Post, requesterID string, approverID string, toolCalls []llm.ToolCall) error {
+	bot := c.bots.GetBotByID(post.UserId)
+	if bot == nil {
+		return fmt.Errorf("unable to get bot")
+	}
+	approver, err := c.pluginAPI.User.Get(approverID)
+	if err != nil {
+		return fmt.Errorf("failed to get approver: %w", err)
+	}
+	requester, err := c.pluginAPI.User.Get(requesterID)
+	if err != nil {
+		return fmt.Errorf("failed to get requester: %w", err)
+	}
+
+	T := i18n.LocalizerFunc(c.i18n, approver.Locale)
+	toolNames := make([]string, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		if serverKey, toolName, ok := mcp.SplitToolName(toolCall.Name); ok {
+			toolNames = append(toolNames, T("copilot.tool_approval_request_mcp_tool", "`%s` from `%s`", toolName, serverKey))
+			continue
+		}
+		toolNames = append(toolNames, "`"+toolCall.Name+"`")
+	}
+	request := &model.Post{
+		Type:    ToolApprovalRequestPostType,
+		Message: T("copilot.tool_approval_request", "@%s asked me to use tools that need your approval: %s", requester.Username, strings.Join(toolNames, ", ")),
+	}
+	request.AddProp(ToolCallPostIDProp, post.Id)
+
+	return c.mmClient.DM(bot.GetMMBot().UserId, approverID, request)
+}
+
+// GetToolApprovalRequest returns the tool calls of the post the user is a delegated approver for.
+func (c *Conversations) GetToolApprovalRequest(userID string, post *model.Post) (*ToolApprovalRequest, error) {
+	tools, err := postToolCalls(post)
+	if err != nil {
+		return nil, err
+	}
+
+	requesterID, _ := post.GetProp(streaming.LLMRequesterUserID).(string)
+	request := &ToolApprovalRequest{
+		PostID:          post.Id,
+		ChannelID:       post.ChannelId,
+		RequesterUserID: requesterID,
+		ToolCalls:       []llm.ToolCall{},
+	}
+	for _, tool := range tools {
+		if slices.Contains(tool.ApproverIDs, userID) {
+			request.ToolCalls = append(request.ToolCalls, tool)
+		}
+	}
+	if len(request.ToolCalls) == 0 {
+		return nil, ErrNotToolCallApprover
+	}
+
+	return request, nil
+}
+
+// postToolCalls returns the tool calls stored on a post.
+func postToolCalls(post *model.Post) ([]llm.ToolCall, error) {
+	toolsJSON, ok := post.GetProp(streaming.ToolCallProp).(string)
+	if !ok {
+		return nil, ErrMissingToolCalls
+	}
+
+	var tools []llm.ToolCall
+	if err := json.Unmarshal([]byte(toolsJSON), &tools); err != nil {
+		return nil, ErrInvalidToolCalls
+	}
+	return tools, nil
+}
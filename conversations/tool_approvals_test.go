@@ -0,0 +1,213 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	mmapimocks "github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// toolApprovalConfig is a Config with only tool approval policies.
+type toolApprovalConfig struct {
+	policies []llm.ToolApprovalPolicy
+}
+
+func (c toolApprovalConfig) ToolApprovals() []llm.ToolApprovalPolicy { return c.policies }
+func (c toolApprovalConfig) GetThreadTagging() config.ThreadTagging  { return config.ThreadTagging{} }
+func (c toolApprovalConfig) GetPersonas() []config.Persona           { return nil }
+func (c toolApprovalConfig) GetEnableFollowUpSuggestions() bool      { return false }
+
+func TestAssignToolCallApprovers(t *testing.T) {
+	post := &model.Post{Id: "postid", ChannelId: "channelid"}
+	policies := []llm.ToolApprovalPolicy{
+		{Tools: []string{"deploy"}, Approvers: llm.ToolApproversUsers, ApproverUserIDs: []string{"lead"}},
+		{Tools: []string{"delete"}, Approvers: llm.ToolApproversChannelAdmins},
+	}
+
+	setup := func(t *testing.T) (*Conversations, *plugintest.API) {
+		mockAPI := &plugintest.API{}
+		t.Cleanup(func() { mockAPI.AssertExpectations(t) })
+		return &Conversations{
+			pluginAPI: pluginapi.NewClient(mockAPI, nil),
+			config:    toolApprovalConfig{policies: policies},
+		}, mockAPI
+	}
+
+	t.Run("approvers are assigned before the tool calls are saved", func(t *testing.T) {
+		c, mockAPI := setup(t)
+		mockAPI.On("GetChannel", "channelid").Return(&model.Channel{Id: "channelid", Type: model.ChannelTypeOpen}, nil)
+		mockAPI.On("GetChannelMembers", "channelid", 0, channelMembersPerPage).Return(model.ChannelMembers{
+			{ChannelId: "channelid", UserId: "admin", SchemeAdmin: true},
+			{ChannelId: "channelid", UserId: "member"},
+		}, nil)
+
+		toolCalls := []llm.ToolCall{
+			{ID: "1", Name: "deploy", Status: llm.ToolCallStatusPending},
+			{ID: "2", Name: "delete", Status: llm.ToolCallStatusPending},
+			{ID: "3", Name: "search", Status: llm.ToolCallStatusPending},
+		}
+		c.AssignToolCallApprovers(post, toolCalls)
+
+		assert.Equal(t, []string{"lead"}, toolCalls[0].ApproverIDs)
+		assert.Equal(t, []string{"admin"}, toolCalls[1].ApproverIDs)
+		assert.Empty(t, toolCalls[2].ApproverIDs, "the requester approves tools without a policy")
+		for _, toolCall := range toolCalls {
+			assert.Equal(t, llm.ToolCallStatusPending, toolCall.Status)
+		}
+	})
+
+	t.Run("tool calls nobody can approve are rejected", func(t *testing.T) {
+		c, mockAPI := setup(t)
+		mockAPI.On("GetChannel", "channelid").Return(nil, model.NewAppError("GetChannel", "not_found", nil, "", 404))
+		mockAPI.On("LogError", mock.Anything, mock.Anything, mock.Anything).Maybe()
+
+		toolCalls := []llm.ToolCall{{ID: "1", Name: "delete", Status: llm.ToolCallStatusPending}}
+		c.AssignToolCallApprovers(post, toolCalls)
+
+		assert.Equal(t, llm.ToolCallStatusRejected, toolCalls[0].Status)
+		assert.Empty(t, toolCalls[0].ApproverIDs)
+	})
+
+	t.Run("tool calls without a policy don't need the channel", func(t *testing.T) {
+		c, _ := setup(t)
+		toolCalls := []llm.ToolCall{{ID: "1", Name: "search", Status: llm.ToolCallStatusPending}}
+		c.AssignToolCallApprovers(post, toolCalls)
+		assert.Equal(t, llm.ToolCallStatusPending, toolCalls[0].Status)
+	})
+}
+
+func TestHandleToolCallsPosted(t *testing.T) {
+	mockAPI := &plugintest.API{}
+	defer mockAPI.AssertExpectations(t)
+	mmClient := mmapimocks.NewMockClient(t)
+
+	mmBots := &bots.MMBots{}
+	mmBots.SetBotsForTesting([]*bots.Bot{bots.NewBot(llm.BotConfig{Name: "ai"}, &model.Bot{UserId: "botid", Username: "ai"})})
+	c := &Conversations{
+		pluginAPI: pluginapi.NewClient(mockAPI, nil),
+		mmClient:  mmClient,
+		bots:      mmBots,
+		i18n:      i18n.Init(),
+	}
+
+	mockAPI.On("GetUser", "requester").Return(&model.User{Id: "requester", Username: "requester"}, nil)
+	mockAPI.On("GetUser", "lead").Return(&model.User{Id: "lead", Username: "lead"}, nil)
+	mmClient.EXPECT().DM("botid", "lead", mock.Anything).RunAndReturn(func(_, _ string, request *model.Post) error {
+		assert.Equal(t, ToolApprovalRequestPostType, request.Type)
+		assert.Equal(t, "postid", request.GetProp(ToolCallPostIDProp))
+		assert.Contains(t, request.Message, "`deploy`")
+		assert.NotContains(t, request.Message, "`delete`", "decided tool calls aren't asked about")
+		return nil
+	}).Once()
+
+	post := &model.Post{Id: "postid", UserId: "botid", ChannelId: "channelid"}
+	post.AddProp(streaming.LLMRequesterUserID, "requester")
+	c.HandleToolCallsPosted(post, []llm.ToolCall{
+		{ID: "1", Name: "deploy", Status: llm.ToolCallStatusPending, ApproverIDs: []string{"lead", "requester"}},
+		{ID: "2", Name: "delete", Status: llm.ToolCallStatusRejected},
+		{ID: "3", Name: "search", Status: llm.ToolCallStatusPending},
+	})
+}
+
+func TestHandleToolCallDecisions(t *testing.T) {
+	const postID = "postid"
+
+	setup := func(t *testing.T, saved []llm.ToolCall) (*Conversations, *plugintest.API, *[]llm.ToolCall) {
+		mockAPI := &plugintest.API{}
+		t.Cleanup(func() { mockAPI.AssertExpectations(t) })
+
+		mmBots := &bots.MMBots{}
+		mmBots.SetBotsForTesting([]*bots.Bot{bots.NewBot(llm.BotConfig{Name: "ai"}, &model.Bot{UserId: "botid", Username: "ai"})})
+
+		// The decisions are made holding the lock of the post
+		mockAPI.On("KVSetWithOptions", "mutex_tool_calls_"+postID, mock.Anything, mock.Anything).Return(true, nil)
+
+		savedPost := &model.Post{Id: postID, UserId: "botid", ChannelId: "channelid"}
+		savedPost.AddProp(streaming.LLMRequesterUserID, "requester")
+		savedJSON, err := json.Marshal(saved)
+		require.NoError(t, err)
+		savedPost.AddProp(streaming.ToolCallProp, string(savedJSON))
+		mockAPI.On("GetPost", postID).Return(savedPost, nil)
+
+		var updated []llm.ToolCall
+		mockAPI.On("UpdatePost", mock.Anything).Return(func(post *model.Post) (*model.Post, *model.AppError) {
+			require.NoError(t, json.Unmarshal([]byte(post.GetProp(streaming.ToolCallProp).(string)), &updated))
+			return post.Clone(), nil
+		}).Maybe()
+
+		return &Conversations{
+			pluginAPI: pluginapi.NewClient(mockAPI, nil),
+			mutexAPI:  mockAPI,
+			bots:      mmBots,
+		}, mockAPI, &updated
+	}
+
+	// stalePost is the post as the approver loaded it, before the decisions of the others were saved
+	stalePost := func(tools []llm.ToolCall) *model.Post {
+		post := &model.Post{Id: postID, UserId: "botid", ChannelId: "channelid"}
+		post.AddProp(streaming.LLMRequesterUserID, "requester")
+		toolsJSON, _ := json.Marshal(tools)
+		post.AddProp(streaming.ToolCallProp, string(toolsJSON))
+		return post
+	}
+
+	pending := []llm.ToolCall{
+		{ID: "1", Name: "deploy", Status: llm.ToolCallStatusPending, ApproverIDs: []string{"lead"}},
+		{ID: "2", Name: "delete", Status: llm.ToolCallStatusPending, ApproverIDs: []string{"admin"}},
+		{ID: "3", Name: "restart", Status: llm.ToolCallStatusPending, ApproverIDs: []string{"admin"}},
+	}
+
+	t.Run("decisions of other approvers are kept", func(t *testing.T) {
+		saved := []llm.ToolCall{
+			pending[0],
+			{ID: "2", Name: "delete", Status: llm.ToolCallStatusRejected, ApproverIDs: []string{"admin"}, DecidedBy: "admin"},
+			pending[2],
+		}
+		c, _, updated := setup(t, saved)
+
+		err := c.HandleToolCall(context.Background(), "lead", stalePost(pending), &model.Channel{Id: "channelid"}, []string{"1"})
+		require.NoError(t, err)
+
+		require.Len(t, *updated, 3)
+		assert.Equal(t, llm.ToolCallStatusAccepted, (*updated)[0].Status)
+		assert.Equal(t, "lead", (*updated)[0].DecidedBy)
+		assert.Equal(t, llm.ToolCallStatusRejected, (*updated)[1].Status, "the decision saved meanwhile isn't overwritten")
+		assert.Equal(t, llm.ToolCallStatusPending, (*updated)[2].Status)
+	})
+
+	t.Run("tool calls decided meanwhile aren't decided or run again", func(t *testing.T) {
+		saved := []llm.ToolCall{
+			{ID: "1", Name: "deploy", Status: llm.ToolCallStatusAccepted, ApproverIDs: []string{"lead", "deputy"}, DecidedBy: "deputy"},
+		}
+		c, mockAPI, _ := setup(t, saved)
+
+		err := c.HandleToolCall(context.Background(), "lead", stalePost([]llm.ToolCall{
+			{ID: "1", Name: "deploy", Status: llm.ToolCallStatusPending, ApproverIDs: []string{"lead", "deputy"}},
+		}), &model.Channel{Id: "channelid"}, []string{"1"})
+		assert.ErrorIs(t, err, ErrNotToolCallApprover)
+		mockAPI.AssertNotCalled(t, "UpdatePost", mock.Anything)
+	})
+
+	t.Run("the requester can't decide on calls assigned to approvers", func(t *testing.T) {
+		c, mockAPI, _ := setup(t, pending)
+
+		err := c.HandleToolCall(context.Background(), "requester", stalePost(pending), &model.Channel{Id: "channelid"}, []string{"1", "2", "3"})
+		assert.ErrorIs(t, err, ErrNotToolCallApprover)
+		mockAPI.AssertNotCalled(t, "UpdatePost", mock.Anything)
+	})
+}
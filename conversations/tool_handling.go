@@ -9,34 +9,86 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"strings"
 
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
 	"github.com/mattermost/mattermost-plugin-ai/streaming"
 	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
 )
 
-// HandleToolCall handles tool call approval/rejection
-func (c *Conversations) HandleToolCall(userID string, post *model.Post, channel *model.Channel, acceptedToolIDs []string) error {
+var (
+	// ErrMissingToolCalls is returned for posts without tool calls.
+	ErrMissingToolCalls = errors.New("post missing pending tool calls")
+
+	// ErrInvalidToolCalls is returned when the tool calls of a post can't be read.
+	ErrInvalidToolCalls = errors.New("post pending tool calls not valid JSON")
+)
+
+// The errors of failed tool calls can hold details of the servers running them, such as their addresses,
+// so they are logged and the tool calls kept in the post only hold these messages.
+const (
+	toolCallFailedMessage   = "The tool call failed."
+	toolCallTimedOutMessage = "The tool call timed out."
+)
+
+// HandleToolCall handles tool call approval/rejection by the requester or a delegated approver.
+// The user only decides on the tool calls they can approve, the accepted tool calls are
+// resolved once no tool call of the post is waiting on a decision. Tools still running are
+// cancelled when ctx is, or when the requester stops the post.
+func (c *Conversations) HandleToolCall(ctx context.Context, userID string, post *model.Post, channel *model.Channel, acceptedToolIDs []string) error {
 	bot := c.bots.GetBotByID(post.UserId)
 	if bot == nil {
 		return fmt.Errorf("unable to get bot")
 	}
 
-	user, err := c.pluginAPI.User.Get(userID)
+	requesterID, _ := post.GetProp(streaming.LLMRequesterUserID).(string)
+	post, tools, err := c.changeToolCalls(post.Id, func(tools []llm.ToolCall) error {
+		decided := false
+		for i := range tools {
+			if tools[i].Status != llm.ToolCallStatusPending || !tools[i].CanBeDecidedBy(userID, requesterID) {
+				continue
+			}
+			decided = true
+			tools[i].DecidedBy = userID
+			if slices.Contains(acceptedToolIDs, tools[i].ID) {
+				tools[i].Status = llm.ToolCallStatusAccepted
+			} else {
+				tools[i].Result = "Tool call rejected by user"
+				tools[i].Status = llm.ToolCallStatusRejected
+			}
+		}
+		if !decided {
+			return ErrNotToolCallApprover
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	toolsJSON := post.GetProp(streaming.ToolCallProp)
-	if toolsJSON == nil {
-		return errors.New("post missing pending tool calls")
+	// Sampling requests are answered by the MCP client waiting for the decision
+	if _, ok := post.GetProp(MCPSamplingServerProp).(string); ok {
+		c.publishSamplingDecision(post.Id)
+		return nil
 	}
 
-	var tools []llm.ToolCall
-	unmarshalErr := json.Unmarshal([]byte(toolsJSON.(string)), &tools)
-	if unmarshalErr != nil {
-		return errors.New("post pending tool calls not valid JSON")
+	// Other approvers still have to decide. Once none do, the tools are only resolved by whoever made
+	// the last decision, as the others find no tool call left to decide on.
+	if slices.ContainsFunc(tools, func(tc llm.ToolCall) bool {
+		return tc.Status == llm.ToolCallStatusPending
+	}) {
+		return nil
+	}
+
+	// Tools are resolved on behalf of the requester, whoever approved them
+	user, err := c.pluginAPI.User.Get(requesterID)
+	if err != nil {
+		return err
 	}
 
 	llmContext := c.contextBuilder.BuildLLMContextUserRequest(
@@ -45,44 +97,57 @@ func (c *Conversations) HandleToolCall(userID string, post *model.Post, channel
 		channel,
 		c.contextBuilder.WithLLMContextDefaultTools(bot, mmapi.IsDMWith(bot.GetMMBot().UserId, channel)),
 	)
+	personaOpts, err := c.usePersona(threadRootID(post), llmContext)
+	if err != nil {
+		return fmt.Errorf("failed to get thread persona: %w", err)
+	}
+
+	// The tools run in the streaming context of the post, so stopping it cancels them
+	ctx, err = c.streamingService.GetStreamingContext(ctx, post.Id)
+	if err != nil {
+		return fmt.Errorf("failed to get streaming context: %w", err)
+	}
+	defer c.streamingService.FinishStreaming(post.Id)
 
 	for i := range tools {
-		if slices.Contains(acceptedToolIDs, tools[i].ID) {
-			result, resolveErr := llmContext.Tools.ResolveTool(tools[i].Name, func(args any) error {
-				return json.Unmarshal(tools[i].Arguments, args)
-			}, llmContext)
-			if resolveErr != nil {
-				// Maybe in the future we can return this to the user and have a retry. For now just tell the LLM it failed.
-				tools[i].Result = "Tool call failed"
-				tools[i].Status = llm.ToolCallStatusError
-				continue
-			}
-			tools[i].Result = result
-			tools[i].Status = llm.ToolCallStatusSuccess
-		} else {
-			tools[i].Result = "Tool call rejected by user"
-			tools[i].Status = llm.ToolCallStatusRejected
+		if tools[i].Status != llm.ToolCallStatusAccepted {
+			continue
 		}
+		result, resolveErr := llmContext.Tools.ResolveTool(ctx, tools[i].Name, func(args any) error {
+			return json.Unmarshal(tools[i].Arguments, args)
+		}, llmContext)
+		if errors.Is(resolveErr, llm.ErrToolTimedOut) {
+			c.pluginAPI.Log.Warn("Tool call timed out", "post_id", post.Id, "tool", tools[i].Name, "error", resolveErr.Error())
+			tools[i].Result = "Tool call timed out"
+			tools[i].Error = toolCallTimedOutMessage
+			tools[i].Status = llm.ToolCallStatusTimedOut
+			continue
+		}
+		if resolveErr != nil {
+			// Maybe in the future we can return this to the user and have a retry. For now just tell the LLM it failed.
+			c.pluginAPI.Log.Warn("Tool call failed", "post_id", post.Id, "tool", tools[i].Name, "error", resolveErr.Error())
+			tools[i].Result = "Tool call failed"
+			tools[i].Error = toolCallFailedMessage
+			tools[i].Status = llm.ToolCallStatusError
+			continue
+		}
+		tools[i].Result = result
+		tools[i].Status = llm.ToolCallStatusSuccess
 	}
 
-	responseRootID := post.Id
-	if post.RootId != "" {
-		responseRootID = post.RootId
-	}
+	responseRootID := threadRootID(post)
 
 	// Update post with the tool call results
-	resolvedToolsJSON, err := json.Marshal(tools)
-	if err != nil {
-		return fmt.Errorf("failed to marshal tool call results: %w", err)
+	if err := c.updateToolCalls(post, tools); err != nil {
+		return err
 	}
-	post.AddProp(streaming.ToolCallProp, string(resolvedToolsJSON))
 
-	if updateErr := c.pluginAPI.Post.UpdatePost(post); updateErr != nil {
-		return fmt.Errorf("failed to update post with tool call results: %w", updateErr)
+	if len(llmContext.ReconnectedToolServers) > 0 {
+		c.postReconnectNotice(bot, user, channel, responseRootID, llmContext.ReconnectedToolServers)
 	}
 
-	// Only continue if at lest one tool call was successful
-	if !slices.ContainsFunc(tools, func(tc llm.ToolCall) bool {
+	// Stopped tool calls aren't answered, nor are they if none was successful
+	if ctx.Err() != nil || !slices.ContainsFunc(tools, func(tc llm.ToolCall) bool {
 		return tc.Status == llm.ToolCallStatusSuccess
 	}) {
 		return nil
@@ -104,7 +169,7 @@ func (c *Conversations) HandleToolCall(userID string, post *model.Post, channel
 		Posts:   posts,
 		Context: llmContext,
 	}
-	result, err := bot.LLM().ChatCompletion(completionRequest)
+	result, err := bot.LLM().ChatCompletion(completionRequest, personaOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to get chat completion: %w", err)
 	}
@@ -119,3 +184,150 @@ func (c *Conversations) HandleToolCall(userID string, post *model.Post, channel
 
 	return nil
 }
+
+// postReconnectNotice tells the user in the thread that tool calls were retried after reconnecting to
+// the servers providing them. Failing to post it doesn't fail the tool calls.
+func (c *Conversations) postReconnectNotice(bot *bots.Bot, user *model.User, channel *model.Channel, rootID string, serverIDs []string) {
+	T := i18n.LocalizerFunc(c.i18n, user.Locale)
+	notice := &model.Post{
+		ChannelId: channel.Id,
+		RootId:    rootID,
+		Message:   T("copilot.mcp_reconnected", "The connection to %s dropped while using tools. I reconnected and retried the tool call.", "`"+strings.Join(serverIDs, "`, `")+"`"),
+	}
+	if err := c.BotCreateNonResponsePost(bot.GetMMBot().UserId, user.Id, notice); err != nil {
+		c.pluginAPI.Log.Error("Failed to post MCP reconnect notice", "error", err.Error())
+	}
+}
+
+func (c *Conversations) updateToolCalls(post *model.Post, tools []llm.ToolCall) error {
+	toolsJSON, err := json.Marshal(tools)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tool call results: %w", err)
+	}
+	post.AddProp(streaming.ToolCallProp, string(toolsJSON))
+
+	if updateErr := c.pluginAPI.Post.UpdatePost(post); updateErr != nil {
+		return fmt.Errorf("failed to update post with tool call results: %w", updateErr)
+	}
+	return nil
+}
+
+// changeToolCalls changes the tool calls of the post and saves them. The post is read and saved again
+// holding a cluster lock, so approvers deciding at once don't overwrite each other's decisions.
+func (c *Conversations) changeToolCalls(postID string, change func(tools []llm.ToolCall) error) (*model.Post, []llm.ToolCall, error) {
+	mtx, err := cluster.NewMutex(c.mutexAPI, "tool_calls_"+postID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create tool calls mutex: %w", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	post, err := c.pluginAPI.Post.GetPost(postID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	tools, err := postToolCalls(post)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := change(tools); err != nil {
+		return nil, nil, err
+	}
+	if err := c.updateToolCalls(post, tools); err != nil {
+		return nil, nil, err
+	}
+	return post, tools, nil
+}
+
+// ErrToolCallNotFound is returned when a post has no tool call with the requested ID.
+var ErrToolCallNotFound = errors.New("tool call not found")
+
+// ToolCallExplanation describes a tool call of a post for the user reviewing it.
+type ToolCallExplanation struct {
+	ToolCall    llm.ToolCall `json:"tool_call"`
+	Explanation string       `json:"explanation"`
+}
+
+// ExplainToolCall has the bot that made a tool call explain in plain language what was executed,
+// based on the arguments, provider request and result stored on the post.
+func (c *Conversations) ExplainToolCall(userID string, post *model.Post, channel *model.Channel, toolCallID string) (*ToolCallExplanation, error) {
+	bot := c.bots.GetBotByID(post.UserId)
+	if bot == nil {
+		return nil, fmt.Errorf("unable to get bot")
+	}
+
+	user, err := c.pluginAPI.User.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tools, err := postToolCalls(post)
+	if errors.Is(err, ErrMissingToolCalls) {
+		return nil, ErrToolCallNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	toolIndex := slices.IndexFunc(tools, func(tc llm.ToolCall) bool {
+		return tc.ID == toolCallID
+	})
+	if toolIndex == -1 {
+		return nil, ErrToolCallNotFound
+	}
+	tool := tools[toolIndex]
+
+	arguments, err := json.MarshalIndent(tool.Arguments, "", "  ")
+	if err != nil {
+		arguments = tool.Arguments
+	}
+
+	llmContext := c.contextBuilder.BuildLLMContextUserRequest(bot, user, channel)
+	llmContext.Parameters = map[string]any{
+		"Name":            tool.Name,
+		"Description":     tool.Description,
+		"Status":          toolCallStatusName(tool.Status),
+		"Arguments":       string(arguments),
+		"ProviderRequest": string(tool.ProviderRequest),
+		"Result":          tool.Result,
+		"Error":           tool.Error,
+	}
+	systemPrompt, err := c.prompts.Format(prompts.PromptToolCallExplanationSystem, llmContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format tool call explanation prompt: %w", err)
+	}
+
+	explanation, err := bot.LLM().ChatCompletionNoStream(llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: systemPrompt,
+			},
+		},
+		Context: llmContext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain tool call: %w", err)
+	}
+
+	return &ToolCallExplanation{
+		ToolCall:    tool,
+		Explanation: explanation,
+	}, nil
+}
+
+func toolCallStatusName(status llm.ToolCallStatus) string {
+	switch status {
+	case llm.ToolCallStatusPending:
+		return "waiting for the user to approve or reject it"
+	case llm.ToolCallStatusAccepted:
+		return "approved and running"
+	case llm.ToolCallStatusRejected:
+		return "rejected by the user, it was not executed"
+	case llm.ToolCallStatusError:
+		return "approved but failed"
+	case llm.ToolCallStatusSuccess:
+		return "approved and completed"
+	case llm.ToolCallStatusTimedOut:
+		return "approved but timed out before completing"
+	}
+	return "unknown"
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/llmcontext"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainToolCall(t *testing.T) {
+	llmPrompts, err := llm.NewPrompts(prompts.PromptsFolder)
+	require.NoError(t, err)
+
+	setup := func(t *testing.T) (*Conversations, *mocks.MockLanguageModel, *plugintest.API) {
+		mockAPI := &plugintest.API{}
+		t.Cleanup(func() { mockAPI.AssertExpectations(t) })
+		client := pluginapi.NewClient(mockAPI, nil)
+
+		languageModel := mocks.NewMockLanguageModel(t)
+		bot := bots.NewBot(llm.BotConfig{Name: "ai", DisplayName: "AI"}, &model.Bot{UserId: "botid", Username: "ai"})
+		bot.SetLLMForTesting(languageModel)
+		mmBots := &bots.MMBots{}
+		mmBots.SetBotsForTesting([]*bots.Bot{bot})
+
+		return &Conversations{
+			prompts:        llmPrompts,
+			pluginAPI:      client,
+			contextBuilder: llmcontext.NewLLMContextBuilder(client, nil, nil, nil, nil),
+			bots:           mmBots,
+		}, languageModel, mockAPI
+	}
+
+	toolCalls, err := json.Marshal([]llm.ToolCall{
+		{
+			ID:              "call1",
+			Name:            "create_issue",
+			Description:     "Creates an issue in the tracker",
+			Arguments:       json.RawMessage(`{"title":"Login fails"}`),
+			ProviderRequest: json.RawMessage(`{"name":"create_issue"}`),
+			Result:          "Created issue PROJ-42",
+			Status:          llm.ToolCallStatusSuccess,
+		},
+	})
+	require.NoError(t, err)
+	post := &model.Post{Id: "postid", UserId: "botid", ChannelId: "dmid"}
+	post.AddProp(streaming.ToolCallProp, string(toolCalls))
+	channel := &model.Channel{Id: "dmid", Type: model.ChannelTypeDirect}
+
+	t.Run("the bot explains the tool call from what was stored on the post", func(t *testing.T) {
+		c, languageModel, mockAPI := setup(t)
+		mockAPI.On("GetUser", "userid").Return(&model.User{Id: "userid", Username: "user"}, nil)
+		mockAPI.On("GetConfig").Return(&model.Config{})
+		mockAPI.On("GetLicense").Return(nil)
+
+		languageModel.EXPECT().ChatCompletionNoStream(mock.Anything).RunAndReturn(func(request llm.CompletionRequest, _ ...llm.LanguageModelOption) (string, error) {
+			require.Len(t, request.Posts, 1)
+			assert.Equal(t, llm.PostRoleSystem, request.Posts[0].Role)
+			prompt := request.Posts[0].Message
+			assert.Contains(t, prompt, "Tool: create_issue")
+			assert.Contains(t, prompt, "Description: Creates an issue in the tracker")
+			assert.Contains(t, prompt, "\"title\": \"Login fails\"", "the arguments are indented")
+			assert.Contains(t, prompt, `{"name":"create_issue"}`)
+			assert.Contains(t, prompt, "Result:\nCreated issue PROJ-42")
+			assert.NotContains(t, prompt, "Error:")
+			assert.Equal(t, "userid", request.Context.RequestingUser.Id)
+			return "It created an issue.", nil
+		})
+
+		explanation, err := c.ExplainToolCall("userid", post, channel, "call1")
+		require.NoError(t, err)
+		assert.Equal(t, "It created an issue.", explanation.Explanation)
+		assert.Equal(t, "call1", explanation.ToolCall.ID)
+	})
+
+	t.Run("unknown tool call", func(t *testing.T) {
+		c, _, mockAPI := setup(t)
+		mockAPI.On("GetUser", "userid").Return(&model.User{Id: "userid"}, nil)
+
+		_, err := c.ExplainToolCall("userid", post, channel, "call2")
+		assert.ErrorIs(t, err, ErrToolCallNotFound)
+	})
+
+	t.Run("post without tool calls", func(t *testing.T) {
+		c, _, mockAPI := setup(t)
+		mockAPI.On("GetUser", "userid").Return(&model.User{Id: "userid"}, nil)
+
+		_, err := c.ExplainToolCall("userid", &model.Post{Id: "postid", UserId: "botid"}, channel, "call1")
+		assert.ErrorIs(t, err, ErrToolCallNotFound)
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// ModelUsage is the tokens a bot used with a model in a thread, and their estimated cost.
+type ModelUsage struct {
+	BotUsername string `json:"bot_username"`
+	Model       string `json:"model"`
+	Responses   int    `json:"responses"`
+	llm.CostEstimate
+}
+
+// ThreadUsage is the tokens used to answer in a thread, by bot and model, with the estimated total cost.
+// Costs are estimated with the prices currently configured, so they follow price changes.
+type ThreadUsage struct {
+	ThreadID string           `json:"thread_id"`
+	Models   []ModelUsage     `json:"models"`
+	Total    llm.CostEstimate `json:"total"`
+}
+
+type threadTokens struct {
+	BotUsername      string
+	Model            string
+	Responses        int
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// RecordUsage adds the tokens used by a response to the usage of its thread.
+func (c *Conversations) RecordUsage(post *model.Post, usage *llm.TokenUsage) {
+	completionTokens := usage.CompletionTokens()
+	if _, err := c.db.ExecBuilder(c.db.Builder().Insert("LLM_ThreadUsage").
+		Columns("RootPostID", "BotUsername", "Model", "Responses", "PromptTokens", "CompletionTokens", "UpdateAt").
+		Values(threadRootID(post), usage.Bot, usage.Model, 1, usage.PromptTokens, completionTokens, model.GetMillis()).
+		Suffix(`ON CONFLICT (RootPostID, BotUsername, Model) DO UPDATE SET
+			Responses = LLM_ThreadUsage.Responses + 1,
+			PromptTokens = LLM_ThreadUsage.PromptTokens + EXCLUDED.PromptTokens,
+			CompletionTokens = LLM_ThreadUsage.CompletionTokens + EXCLUDED.CompletionTokens,
+			UpdateAt = EXCLUDED.UpdateAt`)); err != nil {
+		c.pluginAPI.Log.Error("Failed to record thread usage", "post_id", post.Id, "error", err.Error())
+	}
+}
+
+// ThreadUsage returns the tokens used to answer in the thread of the post, and their estimated cost.
+func (c *Conversations) ThreadUsage(post *model.Post) (*ThreadUsage, error) {
+	threadID := threadRootID(post)
+
+	var tokens []threadTokens
+	if err := c.db.DoQuery(&tokens, c.db.Builder().
+		Select("BotUsername", "Model", "Responses", "PromptTokens", "CompletionTokens").
+		From("LLM_ThreadUsage").
+		Where(sq.Eq{"RootPostID": threadID}).
+		OrderBy("BotUsername", "Model"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to get thread usage: %w", err)
+	}
+
+	usage := threadUsage(threadID, tokens, c.modelPrice)
+	return &usage, nil
+}
+
+// modelPrice returns the price of a model of the bot's service, or its list price if the bot was removed.
+func (c *Conversations) modelPrice(botUsername, modelName string) (llm.ModelPrice, bool) {
+	if bot := c.bots.GetBotByUsername(botUsername); bot != nil {
+		return bot.GetConfig().Service.ModelPrice(modelName)
+	}
+	return llm.LookupModelPrice(modelName)
+}
+
+// threadUsage estimates the cost of the tokens used in a thread. The total price is only known if it is
+// known for every model.
+func threadUsage(threadID string, tokens []threadTokens, price func(botUsername, model string) (llm.ModelPrice, bool)) ThreadUsage {
+	usage := ThreadUsage{
+		ThreadID: threadID,
+		Models:   make([]ModelUsage, 0, len(tokens)),
+		Total:    llm.CostEstimate{PriceKnown: true},
+	}
+	for _, t := range tokens {
+		modelPrice, known := price(t.BotUsername, t.Model)
+		estimate := llm.NewCostEstimate(modelPrice, known, t.PromptTokens, t.CompletionTokens)
+		usage.Models = append(usage.Models, ModelUsage{
+			BotUsername:  t.BotUsername,
+			Model:        t.Model,
+			Responses:    t.Responses,
+			CostEstimate: estimate,
+		})
+		usage.Total = usage.Total.Add(estimate)
+	}
+	return usage
+}
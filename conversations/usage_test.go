@@ -0,0 +1,50 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThreadUsage(t *testing.T) {
+	price := func(botUsername, model string) (llm.ModelPrice, bool) {
+		if model == "gpt-4o" {
+			return llm.ModelPrice{InputPerMillion: 2, OutputPerMillion: 8}, true
+		}
+		return llm.ModelPrice{}, false
+	}
+
+	t.Run("no usage", func(t *testing.T) {
+		usage := threadUsage("threadid", nil, price)
+		assert.Equal(t, "threadid", usage.ThreadID)
+		assert.Empty(t, usage.Models)
+		assert.Equal(t, llm.CostEstimate{PriceKnown: true}, usage.Total)
+	})
+
+	t.Run("known prices", func(t *testing.T) {
+		usage := threadUsage("threadid", []threadTokens{
+			{BotUsername: "ai", Model: "gpt-4o", Responses: 2, PromptTokens: 1_000_000, CompletionTokens: 500_000},
+		}, price)
+		assert.Equal(t, []ModelUsage{{
+			BotUsername:  "ai",
+			Model:        "gpt-4o",
+			Responses:    2,
+			CostEstimate: llm.CostEstimate{InputTokens: 1_000_000, OutputTokens: 500_000, Cost: 6, PriceKnown: true},
+		}}, usage.Models)
+		assert.Equal(t, llm.CostEstimate{InputTokens: 1_000_000, OutputTokens: 500_000, Cost: 6, PriceKnown: true}, usage.Total)
+	})
+
+	t.Run("unknown price", func(t *testing.T) {
+		usage := threadUsage("threadid", []threadTokens{
+			{BotUsername: "ai", Model: "gpt-4o", Responses: 1, PromptTokens: 1_000_000},
+			{BotUsername: "local", Model: "llama3", Responses: 1, PromptTokens: 100, CompletionTokens: 10},
+		}, price)
+		assert.Len(t, usage.Models, 2)
+		assert.False(t, usage.Models[1].PriceKnown)
+		assert.Equal(t, llm.CostEstimate{InputTokens: 1_000_100, OutputTokens: 10, Cost: 2}, usage.Total)
+	})
+}
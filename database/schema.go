@@ -15,6 +15,38 @@ func SetupTables(db *sqlx.DB) error {
 		return fmt.Errorf("failed to create tables: %w", err)
 	}
 
+	if err := createGlossaryTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createSnippetsTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createJobsTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createUserMemoryTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createSchedulesTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createThreadUsageTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createPinnedContextTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createMCPToolCallsTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
 	if err := migrateOldTables(db); err != nil {
 		return fmt.Errorf("failed to migrate old tables: %w", err)
 	}
@@ -33,6 +65,230 @@ func createLLMPostMetaTable(db *sqlx.DB) error {
 		return fmt.Errorf("can't create llm postmeta table: %w", err)
 	}
 
+	// Threads are tagged with the category they were classified into
+	if _, err := db.Exec(`ALTER TABLE LLM_PostMeta ADD COLUMN IF NOT EXISTS Category TEXT NOT NULL DEFAULT '';`); err != nil {
+		return fmt.Errorf("can't add category to llm postmeta table: %w", err)
+	}
+
+	// Threads are conducted in the persona the user picked, if any
+	if _, err := db.Exec(`ALTER TABLE LLM_PostMeta ADD COLUMN IF NOT EXISTS Persona TEXT NOT NULL DEFAULT '';`); err != nil {
+		return fmt.Errorf("can't add persona to llm postmeta table: %w", err)
+	}
+
+	// Forked threads record the post of the original conversation they were forked from
+	if _, err := db.Exec(`ALTER TABLE LLM_PostMeta ADD COLUMN IF NOT EXISTS ForkedFromPostID TEXT NOT NULL DEFAULT '';`); err != nil {
+		return fmt.Errorf("can't add forked from post to llm postmeta table: %w", err)
+	}
+
+	// Threads can be switched to another bot to answer them, such as one with a stronger model
+	if _, err := db.Exec(`ALTER TABLE LLM_PostMeta ADD COLUMN IF NOT EXISTS ActiveBotID TEXT NOT NULL DEFAULT '';`); err != nil {
+		return fmt.Errorf("can't add active bot to llm postmeta table: %w", err)
+	}
+
+	return nil
+}
+
+// createGlossaryTable creates the LLM_Glossary table of organization terms
+func createGlossaryTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_Glossary (
+			ID TEXT NOT NULL PRIMARY KEY,
+			Term TEXT NOT NULL,
+			Aliases TEXT NOT NULL DEFAULT '',
+			Definition TEXT NOT NULL,
+			UpdateAt BIGINT NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm glossary table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_llm_glossary_term ON LLM_Glossary (LOWER(Term));`); err != nil {
+		return fmt.Errorf("can't create llm glossary term index: %w", err)
+	}
+
+	return nil
+}
+
+// createSnippetsTable creates the LLM_Snippets table of prompt snippets
+func createSnippetsTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_Snippets (
+			ID TEXT NOT NULL PRIMARY KEY,
+			TeamID TEXT NOT NULL,
+			Name TEXT NOT NULL,
+			Description TEXT NOT NULL DEFAULT '',
+			Template TEXT NOT NULL,
+			CreatorID TEXT NOT NULL,
+			UpdateAt BIGINT NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm snippets table: %w", err)
+	}
+
+	// Snippets are shared with their team, the whole organization, or kept by their creator
+	if _, err := db.Exec(`ALTER TABLE LLM_Snippets ADD COLUMN IF NOT EXISTS Scope TEXT NOT NULL DEFAULT 'team';`); err != nil {
+		return fmt.Errorf("can't add scope to llm snippets table: %w", err)
+	}
+
+	// Names are unique within a scope, personal snippets of different users can share one
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_llm_snippets_team_name;`); err != nil {
+		return fmt.Errorf("can't drop llm snippets team name index: %w", err)
+	}
+	if _, err := db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_llm_snippets_scope_name ON LLM_Snippets
+			(TeamID, Scope, (CASE WHEN Scope = 'personal' THEN CreatorID ELSE '' END), LOWER(Name));
+	`); err != nil {
+		return fmt.Errorf("can't create llm snippets name index: %w", err)
+	}
+
+	return nil
+}
+
+// createJobsTable creates the LLM_Jobs table of the background job queue
+func createJobsTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_Jobs (
+			ID TEXT NOT NULL PRIMARY KEY,
+			Type TEXT NOT NULL,
+			State TEXT NOT NULL,
+			UserID TEXT NOT NULL DEFAULT '',
+			PostID TEXT NOT NULL DEFAULT '',
+			Payload TEXT NOT NULL DEFAULT '',
+			Attempts INTEGER NOT NULL DEFAULT 0,
+			MaxAttempts INTEGER NOT NULL DEFAULT 1,
+			Error TEXT NOT NULL DEFAULT '',
+			CreateAt BIGINT NOT NULL,
+			UpdateAt BIGINT NOT NULL,
+			NextAttemptAt BIGINT NOT NULL DEFAULT 0
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm jobs table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_jobs_state ON LLM_Jobs (State, NextAttemptAt);`); err != nil {
+		return fmt.Errorf("can't create llm jobs state index: %w", err)
+	}
+
+	return nil
+}
+
+// createUserMemoryTable creates the LLM_UserMemory table of the facts bots remember about users
+func createUserMemoryTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_UserMemory (
+			ID TEXT NOT NULL PRIMARY KEY,
+			UserID TEXT NOT NULL,
+			Content TEXT NOT NULL,
+			CreateAt BIGINT NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm user memory table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_user_memory_user ON LLM_UserMemory (UserID, CreateAt);`); err != nil {
+		return fmt.Errorf("can't create llm user memory user index: %w", err)
+	}
+
+	return nil
+}
+
+// createSchedulesTable creates the LLM_Schedules table of the prompts users run on a recurring cadence
+func createSchedulesTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_Schedules (
+			ID TEXT NOT NULL PRIMARY KEY,
+			UserID TEXT NOT NULL,
+			BotUsername TEXT NOT NULL,
+			Name TEXT NOT NULL DEFAULT '',
+			Prompt TEXT NOT NULL,
+			Cadence TEXT NOT NULL,
+			TimeZone TEXT NOT NULL,
+			Enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			CreateAt BIGINT NOT NULL,
+			UpdateAt BIGINT NOT NULL,
+			NextRunAt BIGINT NOT NULL,
+			LastRunAt BIGINT NOT NULL DEFAULT 0,
+			LastError TEXT NOT NULL DEFAULT ''
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm schedules table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_schedules_user ON LLM_Schedules (UserID, CreateAt);`); err != nil {
+		return fmt.Errorf("can't create llm schedules user index: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_schedules_next_run ON LLM_Schedules (Enabled, NextRunAt);`); err != nil {
+		return fmt.Errorf("can't create llm schedules next run index: %w", err)
+	}
+
+	return nil
+}
+
+// createThreadUsageTable creates the LLM_ThreadUsage table of the tokens used to answer in each thread
+func createThreadUsageTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_ThreadUsage (
+			RootPostID TEXT NOT NULL,
+			BotUsername TEXT NOT NULL,
+			Model TEXT NOT NULL,
+			Responses INTEGER NOT NULL DEFAULT 0,
+			PromptTokens BIGINT NOT NULL DEFAULT 0,
+			CompletionTokens BIGINT NOT NULL DEFAULT 0,
+			UpdateAt BIGINT NOT NULL,
+			PRIMARY KEY (RootPostID, BotUsername, Model)
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm thread usage table: %w", err)
+	}
+
+	return nil
+}
+
+// createPinnedContextTable creates the LLM_PinnedContext table of the posts kept in the context of AI threads
+func createPinnedContextTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_PinnedContext (
+			RootPostID TEXT NOT NULL,
+			PostID TEXT NOT NULL,
+			UserID TEXT NOT NULL,
+			CreateAt BIGINT NOT NULL,
+			PRIMARY KEY (RootPostID, PostID)
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm pinned context table: %w", err)
+	}
+
+	return nil
+}
+
+// createMCPToolCallsTable creates the LLM_MCPToolCalls table auditing the calls of MCP tools
+func createMCPToolCallsTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_MCPToolCalls (
+			ID TEXT NOT NULL PRIMARY KEY,
+			UserID TEXT NOT NULL,
+			BotID TEXT NOT NULL DEFAULT '',
+			ServerID TEXT NOT NULL,
+			ToolName TEXT NOT NULL,
+			ArgumentsSHA256 TEXT NOT NULL DEFAULT '',
+			DurationMillis BIGINT NOT NULL DEFAULT 0,
+			Status TEXT NOT NULL,
+			Error TEXT NOT NULL DEFAULT '',
+			CreateAt BIGINT NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm mcp tool calls table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_mcp_tool_calls_create_at ON LLM_MCPToolCalls (CreateAt);`); err != nil {
+		return fmt.Errorf("can't create llm mcp tool calls create at index: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_mcp_tool_calls_user ON LLM_MCPToolCalls (UserID, CreateAt);`); err != nil {
+		return fmt.Errorf("can't create llm mcp tool calls user index: %w", err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,130 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package docextract extracts the text of documents attached to posts, so it can be given to a bot
+// along with the message. It reads plain text files, Word documents and PDFs.
+package docextract
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	mimeTypeDocx = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	mimeTypePDF  = "application/pdf"
+
+	// maxExpansion is how many times the maximum size of a document its decompressed parts can take, so
+	// documents made to decompress to far more than their size are rejected before filling the memory
+	maxExpansion = 10
+)
+
+var (
+	// ErrUnsupported is returned when extracting the text of a file whose format can't be read.
+	ErrUnsupported = errors.New("unsupported document format")
+
+	// ErrTooLarge is returned when a Word document or a PDF is larger than the maximum size. Only whole
+	// documents of these formats can be read.
+	ErrTooLarge = errors.New("document too large")
+
+	// ErrUnreadable is returned when the text of a document is encoded in a way that can't be read.
+	ErrUnreadable = errors.New("document text can't be read")
+)
+
+type format int
+
+const (
+	formatUnsupported format = iota
+	formatText
+	formatDocx
+	formatPDF
+)
+
+// textExtensions are read as plain text whatever their reported MIME type, which browsers often get wrong
+var textExtensions = []string{".txt", ".md", ".markdown", ".csv", ".tsv", ".json", ".yaml", ".yml", ".xml", ".log", ".rst", ".ini", ".toml"}
+
+func detectFormat(name, mimeType string) format {
+	mimeType = strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+	extension := strings.ToLower(filepath.Ext(name))
+	switch {
+	case mimeType == mimeTypeDocx || extension == ".docx":
+		return formatDocx
+	case mimeType == mimeTypePDF || extension == ".pdf":
+		return formatPDF
+	case strings.HasPrefix(mimeType, "text/"):
+		return formatText
+	}
+	for _, textExtension := range textExtensions {
+		if extension == textExtension {
+			return formatText
+		}
+	}
+	return formatUnsupported
+}
+
+// Supported returns true if the text of a file of the name and MIME type can be extracted.
+func Supported(name, mimeType string) bool {
+	return detectFormat(name, mimeType) != formatUnsupported
+}
+
+// Extract returns the text of a document. Plain text files longer than maxSize bytes are cut, which
+// is reported as truncated, while larger Word documents and PDFs return ErrTooLarge, as do those
+// decompressing to more than maxExpansion times maxSize.
+func Extract(r io.Reader, name, mimeType string, maxSize int64) (string, bool, error) {
+	docFormat := detectFormat(name, mimeType)
+	if docFormat == formatUnsupported {
+		return "", false, fmt.Errorf("%w: %s", ErrUnsupported, name)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read document: %w", err)
+	}
+	tooLarge := int64(len(data)) > maxSize
+
+	switch docFormat {
+	case formatText:
+		if tooLarge {
+			data = data[:maxSize]
+		}
+		return strings.ToValidUTF8(string(data), ""), tooLarge, nil
+	case formatDocx:
+		if tooLarge {
+			return "", false, ErrTooLarge
+		}
+		text, err := extractDocx(data, maxSize*maxExpansion)
+		return text, false, err
+	default:
+		if tooLarge {
+			return "", false, ErrTooLarge
+		}
+		text, err := extractPDF(data, maxSize*maxExpansion)
+		return text, false, err
+	}
+}
+
+var (
+	horizontalSpace = regexp.MustCompile(`[ \t]+`)
+	blankLines      = regexp.MustCompile(`\n{3,}`)
+)
+
+// normalizeText drops control characters and the spaces left around lines by extraction.
+func normalizeText(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || (r < ' ' && r != '\n' && r != '\t') {
+			return -1
+		}
+		return r
+	}, text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(horizontalSpace.ReplaceAllString(line, " "))
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
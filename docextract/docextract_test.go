@@ -0,0 +1,250 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package docextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupported(t *testing.T) {
+	tests := []struct {
+		name      string
+		fileName  string
+		mimeType  string
+		supported bool
+	}{
+		{name: "text", fileName: "notes.txt", mimeType: "text/plain; charset=utf-8", supported: true},
+		{name: "markdown without MIME type", fileName: "README.md", supported: true},
+		{name: "csv reported as binary", fileName: "export.CSV", mimeType: "application/octet-stream", supported: true},
+		{name: "word document", fileName: "spec.docx", mimeType: mimeTypeDocx, supported: true},
+		{name: "pdf", fileName: "report.pdf", mimeType: mimeTypePDF, supported: true},
+		{name: "image", fileName: "diagram.png", mimeType: "image/png"},
+		{name: "legacy word document", fileName: "spec.doc", mimeType: "application/msword"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.supported, Supported(tc.fileName, tc.mimeType))
+		})
+	}
+}
+
+func newDocx(t *testing.T, body string) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	part, err := archive.Create(docxBodyPath)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body + `</w:body></w:document>`))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func deflate(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writer := zlib.NewWriter(&buf)
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func newPDF(t *testing.T, content string, compress bool) []byte {
+	stream := []byte(content)
+	dict := fmt.Sprintf("<< /Length %d >>", len(stream))
+	if compress {
+		stream = deflate(t, stream)
+		dict = fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>", len(stream))
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	pdf.WriteString("3 0 obj\n<< /Type /XObject /Subtype /Image /Length 4 >>\nstream\nBT()\nendstream\nendobj\n")
+	pdf.WriteString("4 0 obj\n" + dict + "\nstream\n")
+	pdf.Write(stream)
+	pdf.WriteString("\nendstream\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+// withObjectStream adds a compressed object stream holding the objects to a PDF
+func withObjectStream(t *testing.T, pdf []byte, objects string) []byte {
+	stream := deflate(t, []byte(objects))
+	object := fmt.Sprintf("5 0 obj\n<< /Type /ObjStm /N 1 /Length %d /Filter /FlateDecode >>\nstream\n", len(stream))
+	return append(append(append(bytes.Clone(pdf), object...), stream...), "\nendstream\nendobj\n"...)
+}
+
+func TestExtract(t *testing.T) {
+	pageContent := `BT /F1 12 Tf 72 712 Td (Quarterly report) Tj 0 -14 Td [(Rev) 20 (enue grew) -250 (by 12%)] TJ T* <FEFF00E9007400E9> Tj ET`
+
+	tests := []struct {
+		name          string
+		fileName      string
+		mimeType      string
+		data          []byte
+		maxSize       int64
+		expected      string
+		truncated     bool
+		expectedError error
+	}{
+		{
+			name:     "text",
+			fileName: "notes.txt",
+			mimeType: "text/plain",
+			data:     []byte("First line\nSecond line"),
+			maxSize:  1024,
+			expected: "First line\nSecond line",
+		},
+		{
+			name:      "text cut at the maximum size",
+			fileName:  "server.log",
+			data:      []byte("0123456789"),
+			maxSize:   4,
+			expected:  "0123",
+			truncated: true,
+		},
+		{
+			name:     "word document",
+			fileName: "spec.docx",
+			mimeType: mimeTypeDocx,
+			data: newDocx(t, `<w:p><w:r><w:t>Project</w:t></w:r><w:r><w:t xml:space="preserve"> goals</w:t></w:r></w:p>`+
+				`<w:p><w:r><w:t>Ship</w:t><w:tab/><w:t>on time</w:t></w:r></w:p>`),
+			maxSize:  1 << 20,
+			expected: "Project goals\nShip on time",
+		},
+		{
+			name:          "word document too large",
+			fileName:      "spec.docx",
+			data:          newDocx(t, `<w:p><w:r><w:t>Project</w:t></w:r></w:p>`),
+			maxSize:       10,
+			expectedError: ErrTooLarge,
+		},
+		{
+			name:     "pdf",
+			fileName: "report.pdf",
+			mimeType: mimeTypePDF,
+			data:     newPDF(t, pageContent, false),
+			maxSize:  1 << 20,
+			expected: "Quarterly report\nRevenue grew by 12%\nété",
+		},
+		{
+			name:     "compressed pdf",
+			fileName: "report.pdf",
+			data:     newPDF(t, pageContent, true),
+			maxSize:  1 << 20,
+			expected: "Quarterly report\nRevenue grew by 12%\nété",
+		},
+		{
+			name:          "word document decompressing to too much",
+			fileName:      "spec.docx",
+			data:          newDocx(t, strings.Repeat(`<w:p><w:r><w:t>Project</w:t></w:r></w:p>`, 50000)),
+			maxSize:       1 << 15,
+			expectedError: ErrTooLarge,
+		},
+		{
+			name:          "pdf decompressing to too much",
+			fileName:      "report.pdf",
+			data:          newPDF(t, strings.Repeat("BT (Quarterly report) Tj ET ", 100000), true),
+			maxSize:       1 << 15,
+			expectedError: ErrTooLarge,
+		},
+		{
+			name:          "pdf with fonts in an identity encoding",
+			fileName:      "report.pdf",
+			data:          append(newPDF(t, "BT /F1 12 Tf <0012001F> Tj ET", false), "5 0 obj\n<< /Type /Font /Subtype /Type0 /Encoding /Identity-H >>\nendobj\n"...),
+			maxSize:       1 << 20,
+			expectedError: ErrUnreadable,
+		},
+		{
+			name:          "compressed pdf with fonts in an identity encoding",
+			fileName:      "report.pdf",
+			data:          withObjectStream(t, newPDF(t, "BT /F1 12 Tf <0012001F> Tj ET", true), "6 0 << /Type /Font /Subtype /Type0 /Encoding /Identity-H >>"),
+			maxSize:       1 << 20,
+			expectedError: ErrUnreadable,
+		},
+		{
+			name:     "compressed pdf with an object stream",
+			fileName: "report.pdf",
+			data:     withObjectStream(t, newPDF(t, pageContent, true), "6 0 << /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"),
+			maxSize:  1 << 20,
+			expected: "Quarterly report\nRevenue grew by 12%\nété",
+		},
+		{
+			name:     "pdf without text",
+			fileName: "scan.pdf",
+			data:     newPDF(t, "q 612 0 0 792 0 0 cm /Im1 Do Q", true),
+			maxSize:  1 << 20,
+			expected: "",
+		},
+		{
+			name:          "unsupported",
+			fileName:      "diagram.png",
+			mimeType:      "image/png",
+			data:          []byte{0x89, 'P', 'N', 'G'},
+			maxSize:       1024,
+			expectedError: ErrUnsupported,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			text, truncated, err := Extract(bytes.NewReader(tc.data), tc.fileName, tc.mimeType, tc.maxSize)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, text)
+			assert.Equal(t, tc.truncated, truncated)
+		})
+	}
+}
+
+func TestContentStreamText(t *testing.T) {
+	tests := []struct {
+		name     string
+		stream   string
+		expected string
+	}{
+		{
+			name:     "escapes and nested parentheses",
+			stream:   `BT (a \(b\) \\ \101 (c)) Tj ET`,
+			expected: "a (b) \\ A (c)",
+		},
+		{
+			name:     "next line operators",
+			stream:   `BT (one) Tj (two) ' 0 0 (three) " ET`,
+			expected: "one\ntwo\nthree",
+		},
+		{
+			name:     "kerning is not a space",
+			stream:   `BT [(W) 120 (ord) -80 (s)] TJ ET`,
+			expected: "Words",
+		},
+		{
+			name:     "hex string",
+			stream:   `BT <48 65 6C6C6F> Tj ET`,
+			expected: "Hello",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, normalizeText(contentStreamText([]byte(tc.stream))))
+		})
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	assert.Equal(t, "a b\n\nc", normalizeText("  a \t b \n\n\n\n c\x00 "))
+	assert.Equal(t, "", normalizeText(strings.Repeat("\n", 5)))
+}
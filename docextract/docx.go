@@ -0,0 +1,81 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package docextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// docxBodyPath is the part of a Word document with its body
+const docxBodyPath = "word/document.xml"
+
+// extractDocx returns the text of the paragraphs of a Word document, one per line. Bodies decompressing
+// to more than the limit return ErrTooLarge.
+func extractDocx(data []byte, limit int64) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to open word document: %w", err)
+	}
+
+	body, err := archive.Open(docxBodyPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open word document body: %w", err)
+	}
+	defer body.Close()
+
+	bodyXML, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read word document body: %w", err)
+	}
+	if int64(len(bodyXML)) > limit {
+		return "", ErrTooLarge
+	}
+
+	var text strings.Builder
+	decoder := xml.NewDecoder(bytes.NewReader(bodyXML))
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse word document body: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteString("\t")
+			case "br", "cr":
+				text.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.WriteString("\n")
+			case "tc":
+				// Cells of a table row are separated like columns
+				text.WriteString("\t")
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		}
+	}
+
+	return normalizeText(text.String()), nil
+}
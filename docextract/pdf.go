@@ -0,0 +1,361 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package docextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// kerningSpace is how far back, in thousandths of a text unit, a TJ adjustment moves the text for it to
+// be read as a space between words rather than kerning between letters
+const kerningSpace = -200
+
+// identityEncodings are the encodings of fonts drawing glyphs by their index in the font, which most CJK
+// text and many embedded fonts use. Their text can't be read without the character maps of the fonts.
+var identityEncodings = []string{"/Identity-H", "/Identity-V"}
+
+// extractPDF returns the text drawn by the content streams of a PDF. It reads uncompressed and Flate
+// compressed streams, and text in fonts with a standard encoding: documents with fonts in an identity
+// encoding return ErrUnreadable rather than the glyph indexes their strings hold. Scanned documents have
+// no text. Streams decompressing to more than the limit in total return ErrTooLarge.
+func extractPDF(data []byte, limit int64) (string, error) {
+	streams, err := pdfStreams(data, limit)
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	for _, stream := range streams {
+		if !bytes.Contains(stream, []byte("BT")) {
+			continue
+		}
+		text.WriteString(contentStreamText(stream))
+		text.WriteString("\n")
+	}
+
+	return normalizeText(text.String()), nil
+}
+
+// pdfStreams returns the decoded streams of a PDF that can hold page content, skipping images, fonts
+// and streams compressed with other filters. The fonts are looked for in the document and in the object
+// streams compressed documents keep their objects in.
+func pdfStreams(data []byte, limit int64) ([][]byte, error) {
+	if usesIdentityEncoding(data) {
+		return nil, ErrUnreadable
+	}
+
+	var streams [][]byte
+	rest := data
+	for {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			return streams, nil
+		}
+		if start >= 3 && string(rest[start-3:start]) == "end" {
+			rest = rest[start+len("stream"):]
+			continue
+		}
+
+		// The stream dictionary is between the start of the object and the stream
+		dictStart := bytes.LastIndex(rest[:start], []byte("obj"))
+		if dictStart < 0 {
+			dictStart = 0
+		}
+		dict := rest[dictStart:start]
+
+		body := rest[start+len("stream"):]
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			return streams, nil
+		}
+		rest = body[end+len("endstream"):]
+		body = bytes.TrimRight(body[:end], "\r\n")
+
+		stream, ok, err := decodeStream(dict, body, &limit)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if bytes.Contains(dict, []byte("/ObjStm")) {
+			if usesIdentityEncoding(stream) {
+				return nil, ErrUnreadable
+			}
+			continue
+		}
+		streams = append(streams, stream)
+	}
+}
+
+func usesIdentityEncoding(data []byte) bool {
+	for _, encoding := range identityEncodings {
+		if bytes.Contains(data, []byte(encoding)) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeStream returns the decoded body of a stream, or false for streams that can't be decoded or hold
+// no text. Decompressed bodies are taken out of the remaining size, and ErrTooLarge is returned once it
+// runs out.
+func decodeStream(dict, body []byte, remaining *int64) ([]byte, bool, error) {
+	for _, skipped := range []string{"/Image", "/FontFile", "/Length1", "/XRef"} {
+		if bytes.Contains(dict, []byte(skipped)) {
+			return nil, false, nil
+		}
+	}
+
+	if !bytes.Contains(dict, []byte("/Filter")) {
+		return body, true, nil
+	}
+	if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Count(dict, []byte("Decode")) > 1 {
+		return nil, false, nil
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, false, nil
+	}
+	defer reader.Close()
+	decoded, err := io.ReadAll(io.LimitReader(reader, *remaining+1))
+	if int64(len(decoded)) > *remaining {
+		return nil, false, ErrTooLarge
+	}
+	*remaining -= int64(len(decoded))
+	if err != nil && len(decoded) == 0 {
+		return nil, false, nil
+	}
+	return decoded, true, nil
+}
+
+type pdfOperand struct {
+	text     string
+	number   float64
+	isText   bool
+	isNumber bool
+	array    []pdfOperand
+}
+
+// contentStreamText returns the text shown by the text operators of a content stream.
+func contentStreamText(stream []byte) string {
+	var text strings.Builder
+	var operands []pdfOperand
+	var arrays [][]pdfOperand
+
+	push := func(operand pdfOperand) {
+		if len(arrays) > 0 {
+			arrays[len(arrays)-1] = append(arrays[len(arrays)-1], operand)
+			return
+		}
+		operands = append(operands, operand)
+	}
+	lastText := func() string {
+		if len(operands) > 0 && operands[len(operands)-1].isText {
+			return operands[len(operands)-1].text
+		}
+		return ""
+	}
+
+	for i := 0; i < len(stream); {
+		c := stream[i]
+		switch {
+		case isPDFSpace(c):
+			i++
+		case c == '%':
+			for i < len(stream) && stream[i] != '\n' && stream[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			value, next := readLiteralString(stream, i)
+			push(pdfOperand{text: decodePDFString(value), isText: true})
+			i = next
+		case c == '<' && i+1 < len(stream) && stream[i+1] == '<', c == '>' && i+1 < len(stream) && stream[i+1] == '>':
+			i += 2
+		case c == '<':
+			value, next := readHexString(stream, i)
+			push(pdfOperand{text: decodePDFString(value), isText: true})
+			i = next
+		case c == '[':
+			arrays = append(arrays, nil)
+			i++
+		case c == ']':
+			if len(arrays) > 0 {
+				array := arrays[len(arrays)-1]
+				arrays = arrays[:len(arrays)-1]
+				push(pdfOperand{array: array})
+			}
+			i++
+		case c == '/':
+			i++
+			for i < len(stream) && !isPDFSpace(stream[i]) && !isPDFDelimiter(stream[i]) {
+				i++
+			}
+			push(pdfOperand{})
+		default:
+			start := i
+			for i < len(stream) && !isPDFSpace(stream[i]) && !isPDFDelimiter(stream[i]) {
+				i++
+			}
+			if i == start {
+				i++
+				continue
+			}
+			token := string(stream[start:i])
+			if number, err := strconv.ParseFloat(token, 64); err == nil {
+				push(pdfOperand{number: number, isNumber: true})
+				continue
+			}
+
+			switch token {
+			case "Tj":
+				text.WriteString(lastText())
+			case "'", "\"":
+				text.WriteString("\n")
+				text.WriteString(lastText())
+			case "TJ":
+				if len(operands) > 0 {
+					for _, element := range operands[len(operands)-1].array {
+						if element.isText {
+							text.WriteString(element.text)
+						} else if element.isNumber && element.number < kerningSpace {
+							text.WriteString(" ")
+						}
+					}
+				}
+			case "Td", "TD":
+				if len(operands) >= 2 && operands[len(operands)-1].number != 0 {
+					text.WriteString("\n")
+				} else {
+					text.WriteString(" ")
+				}
+			case "T*", "ET":
+				text.WriteString("\n")
+			case "Tm":
+				text.WriteString(" ")
+			}
+			operands = operands[:0]
+		}
+	}
+
+	return text.String()
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// readLiteralString reads the string in parentheses starting at start, returning it unescaped and the
+// position after it.
+func readLiteralString(stream []byte, start int) ([]byte, int) {
+	var value []byte
+	depth := 0
+	for i := start + 1; i < len(stream); i++ {
+		c := stream[i]
+		switch c {
+		case '\\':
+			i++
+			if i >= len(stream) {
+				return value, i
+			}
+			switch e := stream[i]; e {
+			case 'n':
+				value = append(value, '\n')
+			case 'r':
+				value = append(value, '\r')
+			case 't':
+				value = append(value, '\t')
+			case 'b', 'f':
+			case '\r':
+				// A line continuation
+				if i+1 < len(stream) && stream[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					octal := 0
+					for n := 0; n < 3 && i < len(stream) && stream[i] >= '0' && stream[i] <= '7'; n++ {
+						octal = octal*8 + int(stream[i]-'0')
+						i++
+					}
+					i--
+					value = append(value, byte(octal))
+				} else {
+					value = append(value, e)
+				}
+			}
+		case '(':
+			depth++
+			value = append(value, c)
+		case ')':
+			if depth == 0 {
+				return value, i + 1
+			}
+			depth--
+			value = append(value, c)
+		default:
+			value = append(value, c)
+		}
+	}
+	return value, len(stream)
+}
+
+// readHexString reads the hexadecimal string starting at start, returning its bytes and the position after it.
+func readHexString(stream []byte, start int) ([]byte, int) {
+	end := bytes.IndexByte(stream[start:], '>')
+	if end < 0 {
+		return nil, len(stream)
+	}
+
+	var digits []byte
+	for _, c := range stream[start+1 : start+end] {
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	value := make([]byte, 0, len(digits)/2)
+	for i := 0; i < len(digits); i += 2 {
+		b, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return value, start + end + 1
+		}
+		value = append(value, byte(b))
+	}
+	return value, start + end + 1
+}
+
+// decodePDFString decodes the bytes of a string, in UTF-16 when they start with its byte order mark and
+// in Latin-1, which the standard encodings mostly agree with, otherwise.
+func decodePDFString(value []byte) string {
+	if len(value) >= 2 && value[0] == 0xFE && value[1] == 0xFF {
+		units := make([]uint16, 0, len(value)/2)
+		for i := 2; i+1 < len(value); i += 2 {
+			units = append(units, uint16(value[i])<<8|uint16(value[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	runes := make([]rune, len(value))
+	for i, b := range value {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
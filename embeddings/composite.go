@@ -85,6 +85,11 @@ func (c *CompositeSearch) Delete(ctx context.Context, postIDs []string) error {
 	return c.store.Delete(ctx, postIDs)
 }
 
+// DeleteRebuilt removes the documents rebuilt from the posts and their chunks
+func (c *CompositeSearch) DeleteRebuilt(ctx context.Context, postIDs []string) error {
+	return c.store.DeleteRebuilt(ctx, postIDs)
+}
+
 // Clear removes all documents and chunks
 func (c *CompositeSearch) Clear(ctx context.Context) error {
 	return c.store.Clear(ctx)
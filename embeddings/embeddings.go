@@ -6,19 +6,24 @@ package embeddings
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"slices"
 
 	"github.com/mattermost/mattermost-plugin-ai/chunking"
 )
 
 // Provider types
 const (
-	ProviderTypeOpenAI           = "openai"
-	ProviderTypeOpenAICompatible = "openai-compatible"
+	ProviderTypeOpenAI               = "openai"
+	ProviderTypeOpenAICompatible     = "openai-compatible"
+	ProviderTypeCohere               = "cohere"
+	ProviderTypeSentenceTransformers = "sentence-transformers"
 )
 
 // Vector store types
 const (
 	VectorStoreTypePGVector = "pgvector"
+	VectorStoreTypeQdrant   = "qdrant"
 )
 
 // Search types
@@ -26,6 +31,36 @@ const (
 	SearchTypeComposite = "composite"
 )
 
+// Corpora searched separately, documents are in the posts corpus unless they have a source type
+const (
+	CorpusPosts    = "posts"
+	CorpusMeetings = "meetings"
+	CorpusFiles    = "files"
+)
+
+// Source types of documents that aren't the message of a post, such as the summary of a call recording
+const (
+	SourceTypeMeetingSummary    = "meeting_summary"
+	SourceTypeMeetingTranscript = "meeting_transcript"
+	SourceTypeFile              = "file"
+)
+
+// IsValidCorpus returns true for the corpora that can be searched, an empty corpus searches all of them.
+func IsValidCorpus(corpus string) bool {
+	return corpus == "" || corpus == CorpusPosts || corpus == CorpusMeetings || corpus == CorpusFiles
+}
+
+// MeetingMetadata describes the meeting a summary or transcript document is from
+type MeetingMetadata struct {
+	Participants []string `json:"participants"` // Usernames of the call participants
+}
+
+// FileMetadata describes the file attached to a post a document is the text of
+type FileMetadata struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
 // PostDocument represents a Mattermost post with its metadata
 type PostDocument struct {
 	PostID    string // ID of the Mattermost post
@@ -35,10 +70,28 @@ type PostDocument struct {
 	UserID    string
 	Content   string
 
+	// SourceType is empty for the message of the post. Other documents, such as meeting summaries,
+	// reference the post they are about and are replaced as a whole when stored again.
+	SourceType string
+	Meeting    *MeetingMetadata // Set for meeting summaries and transcripts
+	File       *FileMetadata    // Set for the text of attached files
+
 	// Embed chunk info to track if this is a chunk
 	chunking.ChunkInfo
 }
 
+// Corpus returns the corpus the document is searched in.
+func (d PostDocument) Corpus() string {
+	switch d.SourceType {
+	case SourceTypeMeetingSummary, SourceTypeMeetingTranscript:
+		return CorpusMeetings
+	case SourceTypeFile:
+		return CorpusFiles
+	default:
+		return CorpusPosts
+	}
+}
+
 // SearchResult represents a single search result with its similarity score
 type SearchResult struct {
 	Document PostDocument
@@ -51,9 +104,29 @@ type SearchOptions struct {
 	MinScore      float32
 	TeamID        string
 	ChannelID     string
-	UserID        string // User ID for permission checks
+	ChannelIDs    []string // Only searches these channels, of which ChannelID when both are set
+	UserID        string   // User ID for permission checks
+	FromUserIDs   []string // Only searches the documents of these users
 	CreatedAfter  int64
 	CreatedBefore int64
+	Corpus        string // Only searches the corpus, all corpora when empty
+
+	ExcludedTeamIDs    []string // Leaves out the documents of these teams
+	ExcludedChannelIDs []string // Leaves out the documents of these channels
+}
+
+// Channels returns the channels the search is restricted to, or nil when it searches all the
+// channels of the user. When both ChannelID and ChannelIDs are set, only ChannelID is searched if it
+// is one of ChannelIDs, and no channel otherwise.
+func (o SearchOptions) Channels() []string {
+	switch {
+	case o.ChannelID == "":
+		return o.ChannelIDs
+	case o.ChannelIDs == nil || slices.Contains(o.ChannelIDs, o.ChannelID):
+		return []string{o.ChannelID}
+	default:
+		return []string{}
+	}
 }
 
 // EmbeddingSearch defines the high-level interface for storing and searching using embeddings
@@ -64,10 +137,15 @@ type EmbeddingSearch interface {
 	// Search performs a similarity search using the query text
 	Search(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error)
 
-	// Delete removes documents
+	// Delete removes every document of deleted posts, including those of other sources such as meetings
 	Delete(ctx context.Context, postIDs []string) error
 
-	// Clear removes all documents
+	// DeleteRebuilt removes the documents of post messages and their attached files before the posts
+	// are indexed again, documents from other sources are kept
+	DeleteRebuilt(ctx context.Context, postIDs []string) error
+
+	// Clear removes all documents of post messages and their attached files, documents from other
+	// sources are kept since they can't be rebuilt from the posts
 	Clear(ctx context.Context) error
 }
 
@@ -79,10 +157,13 @@ type VectorStore interface {
 	// Search performs a similarity search using the provided embedding
 	Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]SearchResult, error)
 
-	// Delete removes documents from the vector store
+	// Delete removes every document of the posts from the vector store
 	Delete(ctx context.Context, postIDs []string) error
 
-	// Clear removes all documents from the vector store
+	// DeleteRebuilt removes the documents of post messages and their attached files from the vector store
+	DeleteRebuilt(ctx context.Context, postIDs []string) error
+
+	// Clear removes all documents of post messages and their attached files from the vector store
 	Clear(ctx context.Context) error
 }
 
@@ -112,4 +193,17 @@ type EmbeddingSearchConfig struct {
 	Parameters        json.RawMessage  `json:"parameters"`
 	Dimensions        int              `json:"dimensions"`
 	ChunkingOptions   chunking.Options `json:"chunkingOptions"`
+	Exclusions        Exclusions       `json:"exclusions"`
+}
+
+// EmbeddingModel identifies the model embeddings are created with. Embeddings of different models
+// can't be compared, so the index is stale once the model changes until it is rebuilt.
+type EmbeddingModel struct {
+	Provider   string `json:"provider"`
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+}
+
+func (m EmbeddingModel) String() string {
+	return fmt.Sprintf("%s %s (%d dimensions)", m.Provider, m.Model, m.Dimensions)
 }
@@ -0,0 +1,30 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package embeddings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchOptionsChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     SearchOptions
+		expected []string
+	}{
+		{name: "all channels", opts: SearchOptions{}, expected: nil},
+		{name: "one channel", opts: SearchOptions{ChannelID: "channel1"}, expected: []string{"channel1"}},
+		{name: "several channels", opts: SearchOptions{ChannelIDs: []string{"channel1", "channel2"}}, expected: []string{"channel1", "channel2"}},
+		{name: "the channel among the channels", opts: SearchOptions{ChannelID: "channel2", ChannelIDs: []string{"channel1", "channel2"}}, expected: []string{"channel2"}},
+		{name: "the channel not among the channels", opts: SearchOptions{ChannelID: "channel3", ChannelIDs: []string{"channel1", "channel2"}}, expected: []string{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.opts.Channels())
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package embeddings
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// Exclusions lists the teams and channels whose posts are neither indexed nor searched
+type Exclusions struct {
+	TeamIDs    []string `json:"teamIds"`
+	ChannelIDs []string `json:"channelIds"`
+
+	// ChannelNamePatterns are glob patterns matched against the name of channels, such as hr-* or
+	// *-private. Invalid patterns match nothing.
+	ChannelNamePatterns []string `json:"channelNamePatterns"`
+}
+
+// IsEmpty returns true when nothing is excluded
+func (e Exclusions) IsEmpty() bool {
+	return len(e.TeamIDs) == 0 && len(e.ChannelIDs) == 0 && len(e.ChannelNamePatterns) == 0
+}
+
+// ExcludesTeam returns true if the whole team is excluded
+func (e Exclusions) ExcludesTeam(teamID string) bool {
+	return teamID != "" && slices.Contains(e.TeamIDs, teamID)
+}
+
+// Excludes returns true if the posts of the channel are excluded, either because of its team, its
+// ID or its name. Direct and group messages have no team.
+func (e Exclusions) Excludes(teamID, channelID, channelName string) bool {
+	if e.ExcludesTeam(teamID) || slices.Contains(e.ChannelIDs, channelID) {
+		return true
+	}
+
+	channelName = strings.ToLower(channelName)
+	for _, pattern := range e.ChannelNamePatterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if matched, err := path.Match(pattern, channelName); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package embeddings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExclusions(t *testing.T) {
+	exclusions := Exclusions{
+		TeamIDs:             []string{"team2"},
+		ChannelIDs:          []string{"channel2"},
+		ChannelNamePatterns: []string{"hr-*", " *-PRIVATE ", "[invalid", ""},
+	}
+
+	tests := []struct {
+		name        string
+		teamID      string
+		channelID   string
+		channelName string
+		excluded    bool
+	}{
+		{name: "channel of another team", teamID: "team1", channelID: "channel1", channelName: "town-square"},
+		{name: "excluded team", teamID: "team2", channelID: "channel1", channelName: "town-square", excluded: true},
+		{name: "excluded channel", teamID: "team1", channelID: "channel2", channelName: "town-square", excluded: true},
+		{name: "name with excluded prefix", teamID: "team1", channelID: "channel1", channelName: "hr-reviews", excluded: true},
+		{name: "name with excluded suffix", teamID: "team1", channelID: "channel1", channelName: "sales-private", excluded: true},
+		{name: "name containing a pattern", teamID: "team1", channelID: "channel1", channelName: "ask-hr-team"},
+		{name: "direct message", channelID: "channel1", channelName: "user1__user2"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.excluded, exclusions.Excludes(tc.teamID, tc.channelID, tc.channelName))
+		})
+	}
+
+	assert.False(t, Exclusions{}.Excludes("team1", "channel1", "town-square"))
+	assert.True(t, Exclusions{}.IsEmpty())
+	assert.False(t, exclusions.ExcludesTeam(""))
+}
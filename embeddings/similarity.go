@@ -0,0 +1,49 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// RelevanceScores returns the cosine similarity of each text to the query, embedding them in a single batch.
+func RelevanceScores(ctx context.Context, provider EmbeddingProvider, query string, texts []string) ([]float64, error) {
+	inputs := make([]string, 0, len(texts)+1)
+	inputs = append(inputs, query)
+	inputs = append(inputs, texts...)
+
+	vectors, err := provider.BatchCreateEmbeddings(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(inputs) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(inputs), len(vectors))
+	}
+
+	scores := make([]float64, 0, len(texts))
+	for _, vector := range vectors[1:] {
+		scores = append(scores, CosineSimilarity(vectors[0], vector))
+	}
+	return scores, nil
+}
+
+// CosineSimilarity returns the cosine similarity of two embeddings, zero when their dimensions differ.
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package ffmpeg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PluginPath is where the ffmpeg binary bundled with the plugin is installed
+	PluginPath = "./plugins/mattermost-ai/dist/ffmpeg"
+
+	// DefaultAudioBitrate keeps recordings small enough for transcription services while keeping speech clear
+	DefaultAudioBitrate = "64k"
+
+	// DefaultAudioCodec is accepted by all transcription backends
+	DefaultAudioCodec = CodecMP3
+)
+
+// Audio codecs recordings can be converted to for transcription
+const (
+	CodecMP3  = "mp3"
+	CodecOpus = "opus"
+	CodecFLAC = "flac"
+)
+
+// codec is how ffmpeg encodes and stores an audio codec.
+type codec struct {
+	Encoder   string
+	Format    string
+	Extension string
+}
+
+var codecs = map[string]codec{
+	CodecMP3:  {Encoder: "libmp3lame", Format: "mp3", Extension: "mp3"},
+	CodecOpus: {Encoder: "libopus", Format: "ogg", Extension: "ogg"},
+	CodecFLAC: {Encoder: "flac", Format: "flac", Extension: "flac"},
+}
+
+// ErrNotInstalled is returned when converting recordings without ffmpeg.
+var ErrNotInstalled = errors.New("ffmpeg not installed")
+
+var (
+	bitrateRegex = regexp.MustCompile(`^[1-9][0-9]*k?$`)
+	hwaccelRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+	versionRegex = regexp.MustCompile(`^ffmpeg version (\S+)`)
+)
+
+// Config customizes how recordings are converted to audio for transcription.
+type Config struct {
+	// Path to the ffmpeg binary, when empty ffmpeg is looked up in the PATH and then in the plugin
+	Path string `json:"path"`
+
+	// AudioBitrate such as "64k", defaults to DefaultAudioBitrate
+	AudioBitrate string `json:"audioBitrate"`
+
+	// SampleRate in Hz, 0 keeps the sample rate of the recording
+	SampleRate int `json:"sampleRate"`
+
+	// AudioCodec is one of the codecs above, defaults to DefaultAudioCodec
+	AudioCodec string `json:"audioCodec"`
+
+	// HWAccel is passed to ffmpeg's -hwaccel option to decode recordings with hardware acceleration, such as "cuda" or "auto"
+	HWAccel string `json:"hwaccel"`
+}
+
+// Validate checks the options are well formed, it doesn't check them against the ffmpeg binary.
+func (c Config) Validate() error {
+	if c.AudioBitrate != "" && !bitrateRegex.MatchString(c.AudioBitrate) {
+		return fmt.Errorf("invalid audio bitrate %q", c.AudioBitrate)
+	}
+	if c.SampleRate < 0 || c.SampleRate > 192000 {
+		return fmt.Errorf("invalid sample rate %d", c.SampleRate)
+	}
+	if _, ok := codecs[c.codecName()]; !ok {
+		return fmt.Errorf("unsupported audio codec %q", c.AudioCodec)
+	}
+	if c.HWAccel != "" && !hwaccelRegex.MatchString(c.HWAccel) {
+		return fmt.Errorf("invalid hardware acceleration %q", c.HWAccel)
+	}
+	return nil
+}
+
+func (c Config) codecName() string {
+	if c.AudioCodec == "" {
+		return DefaultAudioCodec
+	}
+	return c.AudioCodec
+}
+
+func (c Config) codec() codec {
+	if codec, ok := codecs[c.codecName()]; ok {
+		return codec
+	}
+	return codecs[DefaultAudioCodec]
+}
+
+// InputArgs returns the options that go before the input.
+func (c Config) InputArgs() []string {
+	if c.HWAccel == "" {
+		return nil
+	}
+	return []string{"-hwaccel", c.HWAccel}
+}
+
+// AudioArgs returns the options encoding the first audio stream of the input as mono audio.
+func (c Config) AudioArgs() []string {
+	bitrate := c.AudioBitrate
+	if bitrate == "" {
+		bitrate = DefaultAudioBitrate
+	}
+
+	args := []string{
+		"-map", "0:a:0",
+		"-ac", "1",
+		"-c:a", c.codec().Encoder,
+	}
+	// FLAC is lossless so it has no bitrate
+	if c.codecName() != CodecFLAC {
+		args = append(args, "-b:a", bitrate)
+	}
+	if c.SampleRate > 0 {
+		args = append(args, "-ar", fmt.Sprintf("%d", c.SampleRate))
+	}
+	return args
+}
+
+// BytesPerSecond estimates the size of a second of converted audio, used to keep audio files below size limits.
+func (c Config) BytesPerSecond() int {
+	// FLAC compresses speech to about 60% of the 16 bit samples
+	if c.codecName() == CodecFLAC {
+		sampleRate := c.SampleRate
+		if sampleRate == 0 {
+			sampleRate = 48000
+		}
+		return sampleRate * 2 * 6 / 10
+	}
+
+	bitrate := c.AudioBitrate
+	if bitrate == "" || !bitrateRegex.MatchString(bitrate) {
+		bitrate = DefaultAudioBitrate
+	}
+	multiplier := 1
+	if strings.HasSuffix(bitrate, "k") {
+		multiplier = 1000
+	}
+	bits, _ := strconv.Atoi(strings.TrimSuffix(bitrate, "k"))
+	return bits * multiplier / 8
+}
+
+// Format returns the container format audio is written in.
+func (c Config) Format() string {
+	return c.codec().Format
+}
+
+// Extension returns the file extension of the audio files.
+func (c Config) Extension() string {
+	return c.codec().Extension
+}
+
+// ResolvePath returns the path of the ffmpeg binary to use, empty when ffmpeg isn't installed.
+func (c Config) ResolvePath() string {
+	if c.Path != "" {
+		if _, err := exec.LookPath(c.Path); err != nil {
+			return ""
+		}
+		return c.Path
+	}
+
+	if _, err := exec.LookPath("ffmpeg"); err == nil {
+		return "ffmpeg"
+	}
+	if _, err := exec.LookPath(PluginPath); err == nil {
+		return PluginPath
+	}
+	return ""
+}
+
+// Diagnostics describes the ffmpeg binary found for a configuration and any problems with it.
+type Diagnostics struct {
+	Path    string   `json:"path"`
+	Version string   `json:"version"`
+	Encoder string   `json:"encoder"`
+	HWAccel string   `json:"hwaccel,omitempty"`
+	Errors  []string `json:"errors"`
+}
+
+// OK returns true if recordings can be converted with the configuration.
+func (d *Diagnostics) OK() bool {
+	return len(d.Errors) == 0
+}
+
+// Diagnose checks the configuration against the ffmpeg binary: that it runs,
+// has an encoder for the audio codec and supports the hardware acceleration.
+func (c Config) Diagnose() *Diagnostics {
+	diagnostics := &Diagnostics{
+		Encoder: c.codec().Encoder,
+		HWAccel: c.HWAccel,
+		Errors:  []string{},
+	}
+	if err := c.Validate(); err != nil {
+		diagnostics.Errors = append(diagnostics.Errors, err.Error())
+	}
+
+	diagnostics.Path = c.ResolvePath()
+	if diagnostics.Path == "" {
+		if c.Path != "" {
+			diagnostics.Errors = append(diagnostics.Errors, fmt.Sprintf("ffmpeg not found at %q", c.Path))
+		} else {
+			diagnostics.Errors = append(diagnostics.Errors, ErrNotInstalled.Error())
+		}
+		return diagnostics
+	}
+
+	version, err := run(diagnostics.Path, "-version")
+	if err != nil {
+		diagnostics.Errors = append(diagnostics.Errors, fmt.Sprintf("unable to run ffmpeg: %v", err))
+		return diagnostics
+	}
+	diagnostics.Version = parseVersion(version)
+
+	encoders, err := run(diagnostics.Path, "-hide_banner", "-encoders")
+	if err != nil {
+		diagnostics.Errors = append(diagnostics.Errors, fmt.Sprintf("unable to list ffmpeg encoders: %v", err))
+	} else if !slices.Contains(parseEncoders(encoders), diagnostics.Encoder) {
+		diagnostics.Errors = append(diagnostics.Errors, fmt.Sprintf("ffmpeg has no %s encoder for %s audio", diagnostics.Encoder, c.codecName()))
+	}
+
+	// auto picks whatever is available, falling back to software decoding
+	if c.HWAccel != "" && c.HWAccel != "auto" {
+		hwaccels, err := run(diagnostics.Path, "-hide_banner", "-hwaccels")
+		if err != nil {
+			diagnostics.Errors = append(diagnostics.Errors, fmt.Sprintf("unable to list ffmpeg hardware acceleration methods: %v", err))
+		} else if !slices.Contains(parseHWAccels(hwaccels), c.HWAccel) {
+			diagnostics.Errors = append(diagnostics.Errors, fmt.Sprintf("ffmpeg doesn't support %s hardware acceleration", c.HWAccel))
+		}
+	}
+
+	return diagnostics
+}
+
+func run(path string, args ...string) (string, error) {
+	cmd := exec.Command(path, args...) //nolint:gosec
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// parseVersion finds the version in the output of ffmpeg -version.
+func parseVersion(output string) string {
+	matches := versionRegex.FindStringSubmatch(strings.TrimSpace(output))
+	if matches == nil {
+		return ""
+	}
+	return matches[1]
+}
+
+// parseEncoders lists the encoder names in the output of ffmpeg -encoders,
+// where each encoder follows the legend as a line of capability flags, the name and a description.
+func parseEncoders(output string) []string {
+	var encoders []string
+	_, list, found := strings.Cut(output, "------")
+	if !found {
+		return nil
+	}
+	for _, line := range strings.Split(list, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		encoders = append(encoders, fields[1])
+	}
+	return encoders
+}
+
+// parseHWAccels lists the methods in the output of ffmpeg -hwaccels, one per line after the heading.
+func parseHWAccels(output string) []string {
+	var hwaccels []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasSuffix(line, ":") {
+			continue
+		}
+		hwaccels = append(hwaccels, line)
+	}
+	return hwaccels
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package ffmpeg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "defaults", config: Config{}},
+		{name: "all options", config: Config{AudioBitrate: "32k", SampleRate: 16000, AudioCodec: CodecOpus, HWAccel: "cuda"}},
+		{name: "bitrate in bits", config: Config{AudioBitrate: "64000"}},
+		{name: "invalid bitrate", config: Config{AudioBitrate: "64 kbps"}, wantErr: true},
+		{name: "negative sample rate", config: Config{SampleRate: -1}, wantErr: true},
+		{name: "unsupported codec", config: Config{AudioCodec: "aac"}, wantErr: true},
+		{name: "argument injection", config: Config{HWAccel: "cuda -y"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestArgs(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		config := Config{}
+		assert.Empty(t, config.InputArgs())
+		assert.Equal(t, []string{"-map", "0:a:0", "-ac", "1", "-c:a", "libmp3lame", "-b:a", "64k"}, config.AudioArgs())
+		assert.Equal(t, "mp3", config.Format())
+		assert.Equal(t, "mp3", config.Extension())
+	})
+
+	t.Run("all options", func(t *testing.T) {
+		config := Config{AudioBitrate: "32k", SampleRate: 16000, AudioCodec: CodecOpus, HWAccel: "cuda"}
+		assert.Equal(t, []string{"-hwaccel", "cuda"}, config.InputArgs())
+		assert.Equal(t, []string{"-map", "0:a:0", "-ac", "1", "-c:a", "libopus", "-b:a", "32k", "-ar", "16000"}, config.AudioArgs())
+		assert.Equal(t, "ogg", config.Format())
+		assert.Equal(t, "ogg", config.Extension())
+	})
+
+	t.Run("flac has no bitrate", func(t *testing.T) {
+		config := Config{AudioBitrate: "32k", AudioCodec: CodecFLAC}
+		assert.Equal(t, []string{"-map", "0:a:0", "-ac", "1", "-c:a", "flac"}, config.AudioArgs())
+	})
+}
+
+func TestBytesPerSecond(t *testing.T) {
+	assert.Equal(t, 8000, Config{}.BytesPerSecond())
+	assert.Equal(t, 4000, Config{AudioBitrate: "32k", AudioCodec: CodecOpus}.BytesPerSecond())
+	assert.Equal(t, 16000, Config{AudioBitrate: "128000"}.BytesPerSecond())
+	assert.Equal(t, 19200, Config{AudioCodec: CodecFLAC, SampleRate: 16000}.BytesPerSecond())
+}
+
+func TestParseVersion(t *testing.T) {
+	assert.Equal(t, "6.1.1-3ubuntu5", parseVersion("ffmpeg version 6.1.1-3ubuntu5 Copyright (c) 2000-2023 the FFmpeg developers\nbuilt with gcc 13"))
+	assert.Equal(t, "", parseVersion("not ffmpeg"))
+}
+
+func TestParseEncoders(t *testing.T) {
+	output := `Encoders:
+ V..... = Video
+ A..... = Audio
+ ------
+ V....D libx264              libx264 H.264 / AVC / MPEG-4 AVC / MPEG-4 part 10 (codec h264)
+ A....D flac                 FLAC (Free Lossless Audio Codec)
+ A....D libmp3lame           libmp3lame MP3 (MPEG audio layer 3) (codec mp3)
+`
+	assert.Equal(t, []string{"libx264", "flac", "libmp3lame"}, parseEncoders(output))
+	assert.Empty(t, parseEncoders("no list"))
+}
+
+func TestParseHWAccels(t *testing.T) {
+	output := "Hardware acceleration methods:\nvdpau\ncuda\nvaapi\n\n"
+	assert.Equal(t, []string{"vdpau", "cuda", "vaapi"}, parseHWAccels(output))
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package glossary
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+const (
+	// MiddlewarePriority places the glossary outside truncation, so the definitions count against the token limit.
+	MiddlewarePriority = 900
+
+	// maxRelevantTerms limits the definitions added to a single request
+	maxRelevantTerms = 20
+
+	maxTermLength       = 128
+	maxDefinitionLength = 2000
+)
+
+// ErrInvalidTerm is returned when saving a term without a name or definition or with fields that are too long.
+var ErrInvalidTerm = errors.New("invalid glossary term")
+
+// Term is a glossary entry: an organization's term, acronym or product name and its definition.
+type Term struct {
+	ID         string   `json:"id"`
+	Term       string   `json:"term"`
+	Aliases    []string `json:"aliases"`
+	Definition string   `json:"definition"`
+	UpdateAt   int64    `json:"updateAt"`
+}
+
+// Names returns the term followed by its aliases, for example "SRE (site reliability engineering)".
+func (t Term) Names() string {
+	if len(t.Aliases) == 0 {
+		return t.Term
+	}
+	return t.Term + " (" + strings.Join(t.Aliases, ", ") + ")"
+}
+
+// IsValid checks the term has a name and a definition within the length limits.
+func (t Term) IsValid() error {
+	if strings.TrimSpace(t.Term) == "" {
+		return fmt.Errorf("%w: term is required", ErrInvalidTerm)
+	}
+	if strings.TrimSpace(t.Definition) == "" {
+		return fmt.Errorf("%w: definition is required", ErrInvalidTerm)
+	}
+	if utf8.RuneCountInString(t.Term) > maxTermLength {
+		return fmt.Errorf("%w: term is too long", ErrInvalidTerm)
+	}
+	for _, alias := range t.Aliases {
+		if utf8.RuneCountInString(alias) > maxTermLength {
+			return fmt.Errorf("%w: alias is too long", ErrInvalidTerm)
+		}
+	}
+	if utf8.RuneCountInString(t.Definition) > maxDefinitionLength {
+		return fmt.Errorf("%w: definition is too long", ErrInvalidTerm)
+	}
+	return nil
+}
+
+// normalize trims the fields and drops empty or repeated aliases.
+func (t Term) normalize() Term {
+	t.Term = strings.TrimSpace(t.Term)
+	t.Definition = strings.TrimSpace(t.Definition)
+
+	aliases := make([]string, 0, len(t.Aliases))
+	for _, alias := range t.Aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" || strings.EqualFold(alias, t.Term) || slices.Contains(aliases, alias) {
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+	t.Aliases = aliases
+	return t
+}
+
+// appearsIn returns true if the term or one of its aliases appears in the text.
+func (t Term) appearsIn(text string) bool {
+	if containsWord(text, t.Term) {
+		return true
+	}
+	for _, alias := range t.Aliases {
+		if containsWord(text, alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// Relevant returns the terms that appear in any of the texts, up to maxRelevantTerms.
+func Relevant(terms []Term, texts []string) []Term {
+	var relevant []Term
+	for _, term := range terms {
+		for _, text := range texts {
+			if term.appearsIn(text) {
+				relevant = append(relevant, term)
+				break
+			}
+		}
+		if len(relevant) == maxRelevantTerms {
+			break
+		}
+	}
+	return relevant
+}
+
+// containsWord returns true if the word appears in the text as a whole word. Acronyms such as "ACME"
+// are matched case sensitively so they don't match common words, other words ignore case.
+func containsWord(text, word string) bool {
+	if word == "" {
+		return false
+	}
+	if !isAcronym(word) {
+		text = strings.ToLower(text)
+		word = strings.ToLower(word)
+	}
+
+	for offset := 0; offset < len(text); {
+		index := strings.Index(text[offset:], word)
+		if index < 0 {
+			return false
+		}
+		start := offset + index
+		end := start + len(word)
+
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func isAcronym(word string) bool {
+	letters := 0
+	for _, r := range word {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters > 1
+}
+
+// TermSource provides the glossary terms.
+type TermSource interface {
+	Terms() ([]Term, error)
+}
+
+// Middleware adds the definitions of the glossary terms that appear in the conversation to the system prompt,
+// formatted with the template. Requests without any of the terms are unchanged.
+func Middleware(source TermSource, prompts *llm.Prompts, templateName string, log pluginapi.LogService) llm.Middleware {
+	return llm.RequestMiddleware(func(_ llm.LanguageModel, request llm.CompletionRequest) llm.CompletionRequest {
+		terms, err := source.Terms()
+		if err != nil {
+			log.Warn("Unable to get glossary terms", "error", err)
+			return request
+		}
+		if len(terms) == 0 {
+			return request
+		}
+
+		// Only the conversation is searched, the system prompt has instructions rather than the user's words
+		texts := make([]string, 0, len(request.Posts))
+		for _, post := range request.Posts {
+			if post.Role != llm.PostRoleSystem {
+				texts = append(texts, post.Message)
+			}
+		}
+		relevant := Relevant(terms, texts)
+		if len(relevant) == 0 {
+			return request
+		}
+
+		glossaryContext := llm.NewContext()
+		glossaryContext.Parameters = map[string]any{"Terms": relevant}
+		definitions, err := prompts.Format(templateName, glossaryContext)
+		if err != nil {
+			log.Warn("Unable to format glossary", "error", err)
+			return request
+		}
+
+		request.Posts = addToSystemPrompt(request.Posts, definitions)
+		return request
+	})
+}
+
+// addToSystemPrompt appends the text to the system prompt, adding one when there is none.
+// The posts are copied so the caller's request isn't changed.
+func addToSystemPrompt(posts []llm.Post, text string) []llm.Post {
+	if len(posts) > 0 && posts[0].Role == llm.PostRoleSystem {
+		posts = slices.Clone(posts)
+		posts[0].Message = posts[0].Message + "\n\n" + text
+		return posts
+	}
+	return append([]llm.Post{{Role: llm.PostRoleSystem, Message: text}}, posts...)
+}
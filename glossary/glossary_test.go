@@ -0,0 +1,87 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package glossary
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainsWord(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		word string
+		want bool
+	}{
+		{name: "whole word", text: "The Falcon release is next week", word: "falcon", want: true},
+		{name: "part of a word", text: "Falconry is a hobby", word: "falcon", want: false},
+		{name: "punctuation around the word", text: "Is it (Falcon)?", word: "Falcon", want: true},
+		{name: "later occurrence", text: "Falconry and Falcon", word: "falcon", want: true},
+		{name: "acronym is case sensitive", text: "Where is the sre on call?", word: "SRE", want: false},
+		{name: "acronym", text: "Ask the SRE on call", word: "SRE", want: true},
+		{name: "term with symbols", text: "Written in C++ mostly", word: "C++", want: true},
+		{name: "multiple words", text: "The Data Lake House stores it", word: "data lake house", want: true},
+		{name: "accented letters are part of words", text: "Kaféine", word: "kaf", want: false},
+		{name: "empty word", text: "anything", word: "", want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, containsWord(tc.text, tc.word))
+		})
+	}
+}
+
+func TestRelevant(t *testing.T) {
+	terms := []Term{
+		{Term: "Falcon", Definition: "Our billing platform"},
+		{Term: "SRE", Aliases: []string{"site reliability"}, Definition: "Site reliability engineering team"},
+		{Term: "Nimbus", Definition: "The internal cloud"},
+	}
+
+	relevant := Relevant(terms, []string{"Who owns falcon?", "Ask site reliability"})
+	assert.Equal(t, []Term{terms[0], terms[1]}, relevant)
+
+	assert.Empty(t, Relevant(terms, []string{"Nothing relevant here"}))
+}
+
+func TestTermNormalizeAndValidate(t *testing.T) {
+	term := Term{
+		Term:       " SRE ",
+		Aliases:    []string{"site reliability", "", " site reliability ", "sre"},
+		Definition: " Site reliability engineering team ",
+	}.normalize()
+
+	assert.Equal(t, Term{
+		Term:       "SRE",
+		Aliases:    []string{"site reliability"},
+		Definition: "Site reliability engineering team",
+	}, term)
+	require.NoError(t, term.IsValid())
+	assert.Equal(t, "SRE (site reliability)", term.Names())
+
+	require.ErrorIs(t, Term{Term: "SRE"}.IsValid(), ErrInvalidTerm)
+	require.ErrorIs(t, Term{Definition: "Team"}.IsValid(), ErrInvalidTerm)
+}
+
+func TestAddToSystemPrompt(t *testing.T) {
+	posts := []llm.Post{
+		{Role: llm.PostRoleSystem, Message: "You are a bot."},
+		{Role: llm.PostRoleUser, Message: "What is Falcon?"},
+	}
+
+	added := addToSystemPrompt(posts, "Falcon: Our billing platform")
+	assert.Equal(t, "You are a bot.\n\nFalcon: Our billing platform", added[0].Message)
+	assert.Equal(t, "You are a bot.", posts[0].Message)
+
+	added = addToSystemPrompt(posts[1:], "Falcon: Our billing platform")
+	assert.Equal(t, []llm.Post{
+		{Role: llm.PostRoleSystem, Message: "Falcon: Our billing platform"},
+		{Role: llm.PostRoleUser, Message: "What is Falcon?"},
+	}, added)
+}
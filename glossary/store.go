@@ -0,0 +1,187 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package glossary
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// cacheDuration is how long terms are kept in memory. Changes made on this server are seen immediately,
+// changes made on other servers of a cluster after at most this long.
+const cacheDuration = time.Minute
+
+var (
+	// ErrTermNotFound is returned when updating or deleting a term that doesn't exist.
+	ErrTermNotFound = errors.New("glossary term not found")
+
+	// ErrDuplicateTerm is returned when a term with the same name already exists.
+	ErrDuplicateTerm = errors.New("glossary term already exists")
+)
+
+// Store keeps the glossary in the LLM_Glossary table.
+type Store struct {
+	db *mmapi.DBClient
+
+	mu       sync.Mutex
+	cached   []Term
+	cachedAt time.Time
+}
+
+// NewStore creates a glossary store.
+func NewStore(db *mmapi.DBClient) *Store {
+	return &Store{db: db}
+}
+
+type termRow struct {
+	ID         string
+	Term       string
+	Aliases    string
+	Definition string
+	UpdateAt   int64
+}
+
+// Aliases are stored one per line
+func (r termRow) toTerm() Term {
+	term := Term{
+		ID:         r.ID,
+		Term:       r.Term,
+		Aliases:    []string{},
+		Definition: r.Definition,
+		UpdateAt:   r.UpdateAt,
+	}
+	if r.Aliases != "" {
+		term.Aliases = strings.Split(r.Aliases, "\n")
+	}
+	return term
+}
+
+// List returns all terms in alphabetical order.
+func (s *Store) List() ([]Term, error) {
+	var rows []termRow
+	if err := s.db.DoQuery(&rows, s.db.Builder().
+		Select("ID", "Term", "Aliases", "Definition", "UpdateAt").
+		From("LLM_Glossary").
+		OrderBy("LOWER(Term)")); err != nil {
+		return nil, fmt.Errorf("failed to list glossary terms: %w", err)
+	}
+
+	terms := make([]Term, 0, len(rows))
+	for _, row := range rows {
+		terms = append(terms, row.toTerm())
+	}
+	return terms, nil
+}
+
+// Terms returns all terms, cached for cacheDuration since they are needed for every request.
+func (s *Store) Terms() ([]Term, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached != nil && time.Since(s.cachedAt) < cacheDuration {
+		return s.cached, nil
+	}
+
+	terms, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	s.cached = terms
+	s.cachedAt = time.Now()
+	return terms, nil
+}
+
+func (s *Store) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cached = nil
+}
+
+// Create adds a term to the glossary.
+func (s *Store) Create(term Term) (*Term, error) {
+	term = term.normalize()
+	if err := term.IsValid(); err != nil {
+		return nil, err
+	}
+	if err := s.checkDuplicate(term); err != nil {
+		return nil, err
+	}
+
+	term.ID = model.NewId()
+	term.UpdateAt = model.GetMillis()
+	if _, err := s.db.ExecBuilder(s.db.Builder().Insert("LLM_Glossary").
+		Columns("ID", "Term", "Aliases", "Definition", "UpdateAt").
+		Values(term.ID, term.Term, strings.Join(term.Aliases, "\n"), term.Definition, term.UpdateAt)); err != nil {
+		return nil, fmt.Errorf("failed to create glossary term: %w", err)
+	}
+	s.invalidate()
+
+	return &term, nil
+}
+
+// Update replaces the term with the same ID.
+func (s *Store) Update(term Term) (*Term, error) {
+	term = term.normalize()
+	if err := term.IsValid(); err != nil {
+		return nil, err
+	}
+	if err := s.checkDuplicate(term); err != nil {
+		return nil, err
+	}
+
+	term.UpdateAt = model.GetMillis()
+	result, err := s.db.ExecBuilder(s.db.Builder().Update("LLM_Glossary").
+		Set("Term", term.Term).
+		Set("Aliases", strings.Join(term.Aliases, "\n")).
+		Set("Definition", term.Definition).
+		Set("UpdateAt", term.UpdateAt).
+		Where(sq.Eq{"ID": term.ID}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update glossary term: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return nil, ErrTermNotFound
+	}
+	s.invalidate()
+
+	return &term, nil
+}
+
+// Delete removes the term with the ID.
+func (s *Store) Delete(id string) error {
+	result, err := s.db.ExecBuilder(s.db.Builder().Delete("LLM_Glossary").Where(sq.Eq{"ID": id}))
+	if err != nil {
+		return fmt.Errorf("failed to delete glossary term: %w", err)
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return ErrTermNotFound
+	}
+	s.invalidate()
+
+	return nil
+}
+
+// checkDuplicate returns ErrDuplicateTerm if another term has the same name, ignoring case.
+func (s *Store) checkDuplicate(term Term) error {
+	var ids []string
+	if err := s.db.DoQuery(&ids, s.db.Builder().
+		Select("ID").
+		From("LLM_Glossary").
+		Where("LOWER(Term) = LOWER(?)", term.Term).
+		Where(sq.NotEq{"ID": term.ID})); err != nil {
+		return fmt.Errorf("failed to check for duplicate glossary terms: %w", err)
+	}
+	if len(ids) > 0 {
+		return ErrDuplicateTerm
+	}
+	return nil
+}
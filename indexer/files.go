@@ -0,0 +1,80 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package indexer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/docextract"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// maxIndexedFileSize limits the size of the files whose text is extracted for indexing
+const maxIndexedFileSize = int64(1024 * 1024 * 10) // 10MB
+
+// FileService reads the files attached to posts
+type FileService interface {
+	GetInfo(fileID string) (*model.FileInfo, error)
+	Get(fileID string) (io.Reader, error)
+}
+
+// fileDocuments returns a document with the text of each attached file that has text, with the
+// metadata of the post the file is attached to. Files that can't be read are skipped.
+func (s *Indexer) fileDocuments(post embeddings.PostDocument, fileIDs []string) []embeddings.PostDocument {
+	if s.files == nil {
+		return nil
+	}
+
+	var docs []embeddings.PostDocument
+	for _, fileID := range fileIDs {
+		fileInfo, err := s.files.GetInfo(fileID)
+		if err != nil {
+			s.pluginAPI.LogWarn("Failed to get attached file for indexing", "file_id", fileID, "error", err)
+			continue
+		}
+
+		text, err := s.fileText(fileInfo)
+		if err != nil {
+			s.pluginAPI.LogWarn("Unable to read attached file for indexing", "file_id", fileID, "error", err)
+			continue
+		}
+		if text == "" {
+			continue
+		}
+
+		doc := post
+		doc.Content = text
+		doc.SourceType = embeddings.SourceTypeFile
+		doc.File = &embeddings.FileMetadata{
+			ID:   fileInfo.Id,
+			Name: fileInfo.Name,
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// fileText returns the text of a file, as extracted by the server when it extracts the content of
+// files, and by the plugin otherwise. Files whose text can't be extracted have none.
+func (s *Indexer) fileText(fileInfo *model.FileInfo) (string, error) {
+	if content := strings.TrimSpace(fileInfo.Content); content != "" {
+		return content, nil
+	}
+	if !docextract.Supported(fileInfo.Name, fileInfo.MimeType) {
+		return "", nil
+	}
+
+	file, err := s.files.Get(fileInfo.Id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get file: %w", err)
+	}
+	text, _, err := docextract.Extract(file, fileInfo.Name, fileInfo.MimeType, maxIndexedFileSize)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(text), nil
+}
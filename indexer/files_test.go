@@ -0,0 +1,87 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package indexer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+)
+
+// memoryFiles serves the files attached to posts from memory
+type memoryFiles struct {
+	infos    map[string]*model.FileInfo
+	contents map[string]string
+}
+
+func (f memoryFiles) GetInfo(fileID string) (*model.FileInfo, error) {
+	info, ok := f.infos[fileID]
+	if !ok {
+		return nil, fmt.Errorf("file %s not found", fileID)
+	}
+	return info, nil
+}
+
+func (f memoryFiles) Get(fileID string) (io.Reader, error) {
+	return strings.NewReader(f.contents[fileID]), nil
+}
+
+func TestPostDocuments(t *testing.T) {
+	indexer := &Indexer{files: memoryFiles{
+		infos: map[string]*model.FileInfo{
+			"notes":    {Id: "notes", Name: "notes.txt", MimeType: "text/plain"},
+			"spec":     {Id: "spec", Name: "spec.pdf", MimeType: "application/pdf", Content: "Text extracted by the server"},
+			"diagram":  {Id: "diagram", Name: "diagram.png", MimeType: "image/png"},
+			"empty":    {Id: "empty", Name: "empty.txt", MimeType: "text/plain"},
+			"untitled": {Id: "untitled", Name: "untitled", MimeType: "application/octet-stream"},
+		},
+		contents: map[string]string{
+			"notes": "  Release on Friday\n",
+		},
+	}}
+
+	post := &model.Post{
+		Id:        "post1",
+		ChannelId: "channel1",
+		UserId:    "user1",
+		CreateAt:  100,
+		Message:   "See the attached files",
+		FileIds:   model.StringArray{"notes", "spec", "diagram", "empty", "untitled"},
+	}
+	expectedFile := func(file *embeddings.FileMetadata, content string) embeddings.PostDocument {
+		return embeddings.PostDocument{
+			PostID:     "post1",
+			CreateAt:   100,
+			TeamID:     "team1",
+			ChannelID:  "channel1",
+			UserID:     "user1",
+			Content:    content,
+			SourceType: embeddings.SourceTypeFile,
+			File:       file,
+		}
+	}
+
+	t.Run("indexes the message and the text of the files", func(t *testing.T) {
+		assert.Equal(t, []embeddings.PostDocument{
+			{PostID: "post1", CreateAt: 100, TeamID: "team1", ChannelID: "channel1", UserID: "user1", Content: "See the attached files"},
+			expectedFile(&embeddings.FileMetadata{ID: "notes", Name: "notes.txt"}, "Release on Friday"),
+			expectedFile(&embeddings.FileMetadata{ID: "spec", Name: "spec.pdf"}, "Text extracted by the server"),
+		}, indexer.postDocuments(post, "team1"))
+	})
+
+	t.Run("indexes the files of posts without a message", func(t *testing.T) {
+		filesOnly := post.Clone()
+		filesOnly.Message = ""
+		filesOnly.FileIds = model.StringArray{"spec"}
+		assert.Equal(t, []embeddings.PostDocument{
+			expectedFile(&embeddings.FileMetadata{ID: "spec", Name: "spec.pdf"}, "Text extracted by the server"),
+		}, indexer.postDocuments(filesOnly, "team1"))
+	})
+}
@@ -4,13 +4,17 @@
 package indexer
 
 import (
-	"context"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost/server/public/model"
 )
@@ -20,6 +24,28 @@ type Indexer struct {
 	pluginAPI mmapi.Client
 	bots      *bots.MMBots
 	db        *sqlx.DB
+	files     FileService
+
+	// embeddingModel is the model the search creates embeddings with
+	embeddingModel embeddings.EmbeddingModel
+
+	// nodeID identifies the server the reindex jobs run on
+	nodeID string
+
+	// groupThreads indexes the posts of a thread together, see SetChunkingOptions
+	groupThreads bool
+
+	// Teams and channels whose posts aren't indexed
+	exclusions atomic.Pointer[embeddings.Exclusions]
+
+	// Posts waiting to be indexed, in the order they were first queued
+	queueMu    sync.Mutex
+	queued     map[string]queuedPost
+	queueOrder []string
+
+	wake chan struct{}
+	stop chan struct{}
+	wg   sync.WaitGroup
 }
 
 func New(
@@ -27,46 +53,59 @@ func New(
 	pluginAPI mmapi.Client,
 	bots *bots.MMBots,
 	db *sqlx.DB,
+	files FileService,
+	embeddingModel embeddings.EmbeddingModel,
 ) *Indexer {
 	return &Indexer{
-		search:    search,
-		pluginAPI: pluginAPI,
-		bots:      bots,
-		db:        db,
+		search:         search,
+		pluginAPI:      pluginAPI,
+		bots:           bots,
+		db:             db,
+		files:          files,
+		embeddingModel: embeddingModel,
+		nodeID:         nodeID(),
+		queued:         map[string]queuedPost{},
+		wake:           make(chan struct{}, 1),
 	}
 }
 
-// IndexPost indexes a post if it meets the criteria
-func (s *Indexer) IndexPost(ctx context.Context, post *model.Post, channel *model.Channel) error {
-	if !s.shouldIndexPost(post, channel) {
-		return nil
+// nodeID returns the host name of the server, which stays the same when the plugin restarts
+func nodeID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
 	}
+	return hostname
+}
 
-	if s.search == nil {
-		return nil // Search not configured
-	}
+// SetExclusions sets the teams and channels whose posts aren't indexed. Posts already indexed are
+// removed by reindexing.
+func (s *Indexer) SetExclusions(exclusions embeddings.Exclusions) {
+	s.exclusions.Store(&exclusions)
+}
+
+// isExcluded returns whether the channel is excluded from indexing
+func (s *Indexer) isExcluded(channel *model.Channel) bool {
+	exclusions := s.exclusions.Load()
+	return exclusions != nil && channel != nil && exclusions.Excludes(channel.TeamId, channel.Id, channel.Name)
+}
 
-	// Create document
+// postDocuments returns the documents of the message of a post and of the text of its attached files
+func (s *Indexer) postDocuments(post *model.Post, teamID string) []embeddings.PostDocument {
 	doc := embeddings.PostDocument{
 		PostID:    post.Id,
 		CreateAt:  post.CreateAt,
-		TeamID:    channel.TeamId,
+		TeamID:    teamID,
 		ChannelID: post.ChannelId,
 		UserID:    post.UserId,
 		Content:   post.Message,
 	}
 
-	// Store the document
-	return s.search.Store(ctx, []embeddings.PostDocument{doc})
-}
-
-// DeletePost deletes a post from the index
-func (s *Indexer) DeletePost(ctx context.Context, postID string) error {
-	if s.search == nil {
-		return nil // Search not configured
+	var docs []embeddings.PostDocument
+	if post.Message != "" {
+		docs = append(docs, doc)
 	}
-
-	return s.search.Delete(ctx, []string{postID})
+	return append(docs, s.fileDocuments(doc, post.FileIds)...)
 }
 
 // StartReindexJob starts a post reindexing job
@@ -88,19 +127,16 @@ func (s *Indexer) StartReindexJob() (JobStatus, error) {
 		return jobStatus, fmt.Errorf("job already running")
 	}
 
-	// Get an estimate of total posts for progress tracking
-	var count int64
-	dbErr := s.db.Get(&count, `SELECT COUNT(*) FROM Posts WHERE DeleteAt = 0 AND Message != '' AND Type = ''`)
-	if dbErr != nil {
-		s.pluginAPI.LogWarn("Failed to get post count for progress tracking", "error", dbErr)
-		count = 0 // Continue with zero estimate
-	}
-
 	// Create initial job status
+	count := s.countIndexedRows()
+	now := time.Now()
 	newJobStatus := JobStatus{
-		Status:    JobStatusRunning,
-		StartedAt: time.Now(),
-		TotalRows: count,
+		Status:        JobStatusRunning,
+		StartedAt:     now,
+		TotalRows:     count,
+		RemainingRows: count,
+		NodeID:        s.nodeID,
+		HeartbeatAt:   now,
 	}
 
 	// Save initial job status
@@ -108,6 +144,7 @@ func (s *Indexer) StartReindexJob() (JobStatus, error) {
 	if err != nil {
 		return JobStatus{}, fmt.Errorf("failed to save job status: %w", err)
 	}
+	s.publishJobStatus(&newJobStatus)
 
 	// Start the reindexing job in background
 	go s.runReindexJob(&newJobStatus)
@@ -115,6 +152,166 @@ func (s *Indexer) StartReindexJob() (JobStatus, error) {
 	return newJobStatus, nil
 }
 
+// ResumeReindexJob resumes a failed or canceled reindex job after the last post it indexed, keeping
+// the posts it already indexed
+func (s *Indexer) ResumeReindexJob() (JobStatus, error) {
+	if s.search == nil {
+		return JobStatus{}, fmt.Errorf("search functionality is not configured")
+	}
+
+	var jobStatus JobStatus
+	err := s.pluginAPI.KVGet(ReindexJobKey, &jobStatus)
+	if err != nil && err.Error() != "not found" {
+		return JobStatus{}, fmt.Errorf("failed to check job status: %w", err)
+	}
+	if jobStatus.Status == JobStatusRunning {
+		return jobStatus, fmt.Errorf("job already running")
+	}
+	if !jobStatus.Resumable() {
+		return jobStatus, fmt.Errorf("nothing to resume")
+	}
+
+	// The index was cleared for the model of the job, posts can't be added with another one
+	indexedModel, err := s.IndexedModel()
+	if err != nil {
+		return JobStatus{}, err
+	}
+	if indexedModel != nil && *indexedModel != s.embeddingModel {
+		return jobStatus, fmt.Errorf("embedding model changed")
+	}
+
+	jobStatus.Status = JobStatusRunning
+	jobStatus.Error = ""
+	jobStatus.ResumedAt = time.Now()
+	jobStatus.CompletedAt = time.Time{}
+	jobStatus.NodeID = s.nodeID
+	jobStatus.HeartbeatAt = jobStatus.ResumedAt
+	if count := s.countIndexedRows(); count > 0 {
+		jobStatus.TotalRows = count
+	}
+	jobStatus.updateProgress(jobStatus.ProcessedRows, 0, jobStatus.ResumedAt, jobStatus.ResumedAt)
+
+	if err := s.pluginAPI.KVSet(ReindexJobKey, jobStatus); err != nil {
+		return JobStatus{}, fmt.Errorf("failed to save job status: %w", err)
+	}
+	s.publishJobStatus(&jobStatus)
+
+	go s.runReindexJob(&jobStatus)
+
+	return jobStatus, nil
+}
+
+// countIndexedRows estimates the number of posts the reindex job reads, for progress tracking. It
+// is 0 when the posts can't be counted.
+func (s *Indexer) countIndexedRows() int64 {
+	condition := indexedPostsCondition
+	if s.groupThreads {
+		// Every root is read, along with the replies that may be indexed
+		condition = "(" + threadRootsCondition + " OR " + indexedPostsCondition + ")"
+	}
+
+	var count int64
+	if err := s.db.Get(&count, `SELECT COUNT(*) FROM Posts WHERE DeleteAt = 0 AND `+condition); err != nil {
+		s.pluginAPI.LogWarn("Failed to get post count for progress tracking", "error", err)
+		return 0
+	}
+	return count
+}
+
+// reindexEstimateSampleSize is how many of the latest posts and files the length of the others is estimated from
+const reindexEstimateSampleSize = 10000
+
+// EstimateReindex estimates the cost of embedding every post with the given embedding model. The length
+// of the posts and files is extrapolated from the latest ones rather than read from the whole tables.
+func (s *Indexer) EstimateReindex(embeddingModel string) (llm.CostEstimate, error) {
+	if s.search == nil {
+		return llm.CostEstimate{}, fmt.Errorf("search functionality is not configured")
+	}
+
+	characters, err := s.estimateCharacters("Posts", "DeleteAt, Message, Type",
+		`CASE WHEN DeleteAt = 0 AND Message != '' AND Type = '' THEN LENGTH(Message) ELSE 0 END`)
+	if err != nil {
+		return llm.CostEstimate{}, fmt.Errorf("failed to get post length: %w", err)
+	}
+
+	// Only the text the server extracted from files is known without reading them
+	fileCharacters, err := s.estimateCharacters("FileInfo", "DeleteAt, PostId, Content",
+		`CASE WHEN DeleteAt = 0 AND PostId != '' THEN COALESCE(LENGTH(Content), 0) ELSE 0 END`)
+	if err != nil {
+		return llm.CostEstimate{}, fmt.Errorf("failed to get file length: %w", err)
+	}
+	characters += fileCharacters
+
+	// Roughly four characters per token for English text
+	tokens := int(characters / 4)
+	price, priceKnown := llm.LookupModelPrice(embeddingModel)
+
+	return llm.NewCostEstimate(price, priceKnown, tokens, 0), nil
+}
+
+// estimateCharacters estimates the characters of a table to embed, the length of each row being given by
+// lengthExpression of the columns. The latest rows are measured and extrapolated to the number of rows
+// postgres estimates the table has, which is exact for tables smaller than the sample.
+func (s *Indexer) estimateCharacters(table, columns, lengthExpression string) (int64, error) {
+	var sample struct {
+		Rows       int64 `db:"rows"`
+		Characters int64 `db:"characters"`
+	}
+	query := `SELECT COUNT(*) AS rows, COALESCE(SUM(` + lengthExpression + `), 0) AS characters
+		FROM (SELECT ` + columns + ` FROM ` + table + ` ORDER BY CreateAt DESC LIMIT $1) AS sample`
+	if err := s.db.Get(&sample, query, reindexEstimateSampleSize); err != nil {
+		return 0, err
+	}
+	if sample.Rows < reindexEstimateSampleSize {
+		return sample.Characters, nil
+	}
+
+	var estimatedRows float64
+	if err := s.db.Get(&estimatedRows, `SELECT reltuples FROM pg_class WHERE oid = to_regclass($1)`, strings.ToLower(table)); err != nil {
+		return 0, fmt.Errorf("failed to get the estimated rows of %s: %w", table, err)
+	}
+	// Tables that were never analyzed have no estimate, the sample is all that is known of them
+	if estimatedRows <= float64(sample.Rows) {
+		return sample.Characters, nil
+	}
+
+	return int64(float64(sample.Characters) / float64(sample.Rows) * estimatedRows), nil
+}
+
+// EmbeddingModel returns the model the search creates embeddings with
+func (s *Indexer) EmbeddingModel() embeddings.EmbeddingModel {
+	return s.embeddingModel
+}
+
+// IndexedModel returns the embedding model the index was built with, nil when it isn't known
+func (s *Indexer) IndexedModel() (*embeddings.EmbeddingModel, error) {
+	var indexedModel *embeddings.EmbeddingModel
+	if err := s.pluginAPI.KVGet(IndexedModelKey, &indexedModel); err != nil {
+		return nil, fmt.Errorf("failed to get the embedding model of the index: %w", err)
+	}
+	return indexedModel, nil
+}
+
+// RecordIndexedModel records the model of the search as the one the index was built with when none
+// is recorded yet, such as for indexes built before the model was recorded.
+func (s *Indexer) RecordIndexedModel() error {
+	if s.search == nil {
+		return nil
+	}
+
+	indexedModel, err := s.IndexedModel()
+	if err != nil {
+		return err
+	}
+	if indexedModel != nil {
+		return nil
+	}
+	if err := s.pluginAPI.KVSet(IndexedModelKey, s.embeddingModel); err != nil {
+		return fmt.Errorf("failed to save the embedding model of the index: %w", err)
+	}
+	return nil
+}
+
 // GetJobStatus gets the status of the reindex job
 func (s *Indexer) GetJobStatus() (JobStatus, error) {
 	var jobStatus JobStatus
@@ -125,6 +322,32 @@ func (s *Indexer) GetJobStatus() (JobStatus, error) {
 	return jobStatus, nil
 }
 
+// RecoverInterruptedJob marks the reindex job as failed when it's recorded as running but its run was
+// interrupted, so it can be resumed. The run was interrupted when it was on this server, which is only
+// starting, or when it stopped saving its progress, such as when its server stopped.
+func (s *Indexer) RecoverInterruptedJob() error {
+	var jobStatus JobStatus
+	if err := s.pluginAPI.KVGet(ReindexJobKey, &jobStatus); err != nil {
+		return fmt.Errorf("failed to check job status: %w", err)
+	}
+	if jobStatus.Status != JobStatusRunning {
+		return nil
+	}
+	if jobStatus.NodeID != s.nodeID && time.Since(jobStatus.lastHeartbeat()) < jobStaleAfter {
+		return nil
+	}
+
+	jobStatus.Status = JobStatusFailed
+	jobStatus.Error = "Job was interrupted"
+	jobStatus.CompletedAt = time.Now()
+	jobStatus.RowsPerSecond = 0
+	jobStatus.ETASeconds = 0
+	s.saveJobStatus(&jobStatus)
+	s.pluginAPI.LogWarn("Reindex job was interrupted", "node_id", jobStatus.NodeID, "resumable", jobStatus.Resumable())
+
+	return nil
+}
+
 // CancelJob cancels a running reindex job
 func (s *Indexer) CancelJob() (JobStatus, error) {
 	var jobStatus JobStatus
@@ -146,6 +369,7 @@ func (s *Indexer) CancelJob() (JobStatus, error) {
 	if err != nil {
 		return JobStatus{}, fmt.Errorf("failed to save job status: %w", err)
 	}
+	s.publishJobStatus(&jobStatus)
 
 	return jobStatus, nil
 }
@@ -153,7 +377,7 @@ func (s *Indexer) CancelJob() (JobStatus, error) {
 // shouldIndexPost returns whether a post should be indexed based on consistent criteria
 func (s *Indexer) shouldIndexPost(post *model.Post, channel *model.Channel) bool {
 	// Skip posts that don't have content
-	if post.Message == "" {
+	if post.Message == "" && len(post.FileIds) == 0 {
 		return false
 	}
 
@@ -177,5 +401,10 @@ func (s *Indexer) shouldIndexPost(post *model.Post, channel *model.Channel) bool
 		return false
 	}
 
+	// Skip posts in excluded teams and channels
+	if s.isExcluded(channel) {
+		return false
+	}
+
 	return true
 }
@@ -5,7 +5,9 @@ package indexer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/mattermost/mattermost-plugin-ai/embeddings"
@@ -20,31 +22,125 @@ const (
 
 	defaultBatchSize = 100
 
+	// jobStaleAfter is how long a running job can go without a heartbeat, saved with each batch, before
+	// it's considered interrupted
+	jobStaleAfter = 10 * time.Minute
+
+	// ReindexProgressEvent is the websocket event sent to system admins as the reindex job progresses
+	ReindexProgressEvent = "reindex_progress"
+
 	// KV store keys
-	ReindexJobKey = "reindex_job_status"
+	ReindexJobKey   = "reindex_job_status"
+	IndexedModelKey = "indexed_embedding_model"
+
+	// indexedPostsCondition selects the regular posts with a message or attached files
+	indexedPostsCondition = `(Posts.Message != '' OR Posts.FileIds != '[]') AND Posts.Type = ''`
+
+	// threadRootsCondition selects the root posts threads are indexed from when they are indexed
+	// together, the roots may not be indexed themselves
+	threadRootsCondition = `Posts.RootId = ''`
 )
 
 // PostRecord represents a post record from the database
 type PostRecord struct {
 	ID       string `db:"id"`
+	RootID   string `db:"rootid"`
 	Message  string `db:"message"`
+	Type     string `db:"type"`
 	UserID   string `db:"userid"`
 	CreateAt int64  `db:"createat"`
 	TeamID   string `db:"teamid"`
+	FileIDs  string `db:"fileids"` // JSON list of the attached files
 
 	ChannelID   string `db:"channelid"`
 	ChannelName string `db:"channelname"`
 	ChannelType string `db:"channeltype"`
 }
 
+// recordPost returns the post of a record, with the fields indexing needs
+func (s *Indexer) recordPost(r PostRecord) *model.Post {
+	post := &model.Post{
+		Id:        r.ID,
+		RootId:    r.RootID,
+		ChannelId: r.ChannelID,
+		UserId:    r.UserID,
+		Message:   r.Message,
+		CreateAt:  r.CreateAt,
+		Type:      r.Type,
+		DeleteAt:  0, // We already filter deleted posts in the SQL query
+	}
+	if r.FileIDs != "" {
+		if err := json.Unmarshal([]byte(r.FileIDs), &post.FileIds); err != nil {
+			s.pluginAPI.LogWarn("Failed to read the files of post", "post_id", r.ID, "error", err)
+		}
+	}
+	return post
+}
+
+// channel returns a minimal channel object with necessary fields for filtering
+func (r PostRecord) channel() *model.Channel {
+	return &model.Channel{
+		Id:     r.ChannelID,
+		TeamId: r.TeamID,
+		Name:   r.ChannelName,
+		Type:   model.ChannelType(r.ChannelType),
+	}
+}
+
 // JobStatus represents the status of a reindex job
 type JobStatus struct {
 	Status        string    `json:"status"`
 	Error         string    `json:"error,omitempty"`
 	StartedAt     time.Time `json:"started_at"`
+	ResumedAt     time.Time `json:"resumed_at,omitempty"`
 	CompletedAt   time.Time `json:"completed_at,omitempty"`
 	ProcessedRows int64     `json:"processed_rows"`
 	TotalRows     int64     `json:"total_rows"`
+	RemainingRows int64     `json:"remaining_rows"`
+
+	// Pace of the current run, and the time left at that pace, 0 until known
+	RowsPerSecond float64 `json:"rows_per_second"`
+	ETASeconds    int64   `json:"eta_seconds"`
+
+	// Cursor of the last indexed post, a failed or canceled job resumes after it
+	LastCreateAt int64  `json:"last_create_at,omitempty"`
+	LastPostID   string `json:"last_post_id,omitempty"`
+
+	// NodeID is the server the job runs on, HeartbeatAt the last time it saved its progress
+	NodeID      string    `json:"node_id,omitempty"`
+	HeartbeatAt time.Time `json:"heartbeat_at,omitempty"`
+}
+
+// lastHeartbeat returns the last time the running job was known to be alive
+func (j JobStatus) lastHeartbeat() time.Time {
+	heartbeat := j.HeartbeatAt
+	for _, at := range []time.Time{j.StartedAt, j.ResumedAt} {
+		if at.After(heartbeat) {
+			heartbeat = at
+		}
+	}
+	return heartbeat
+}
+
+// Resumable tells whether the job stopped before indexing every post and can resume where it stopped
+func (j JobStatus) Resumable() bool {
+	return (j.Status == JobStatusFailed || j.Status == JobStatusCanceled) && j.LastPostID != ""
+}
+
+// updateProgress records the rows processed so far, and estimates the time left from the pace of
+// the current run, which processed runRows rows since runStart
+func (j *JobStatus) updateProgress(processed, runRows int64, runStart, now time.Time) {
+	j.ProcessedRows = processed
+	j.RemainingRows = max(j.TotalRows-processed, 0)
+	j.RowsPerSecond = 0
+	j.ETASeconds = 0
+
+	elapsed := now.Sub(runStart).Seconds()
+	if runRows <= 0 || elapsed <= 0 {
+		return
+	}
+	j.RowsPerSecond = float64(runRows) / elapsed
+	j.ETASeconds = int64(math.Ceil(float64(j.RemainingRows) / j.RowsPerSecond))
 }
 
 // runReindexJob runs the reindexing process
@@ -61,49 +157,59 @@ func (s *Indexer) runReindexJob(jobStatus *JobStatus) {
 
 	ctx := context.Background()
 
-	// Clear the existing index
-	if err := s.search.Clear(ctx); err != nil {
-		jobStatus.Status = JobStatusFailed
-		jobStatus.Error = fmt.Sprintf("Failed to clear search index: %s", err)
-		jobStatus.CompletedAt = time.Now()
-		s.saveJobStatus(jobStatus)
-		return
+	// Resumed jobs keep what they indexed before stopping
+	if jobStatus.LastPostID == "" {
+		// Clear the existing index
+		if err := s.search.Clear(ctx); err != nil {
+			jobStatus.Status = JobStatusFailed
+			jobStatus.Error = fmt.Sprintf("Failed to clear search index: %s", err)
+			jobStatus.CompletedAt = time.Now()
+			s.saveJobStatus(jobStatus)
+			return
+		}
+
+		// The index only has embeddings of the current model from now on
+		if err := s.pluginAPI.KVSet(IndexedModelKey, s.embeddingModel); err != nil {
+			s.pluginAPI.LogError("Failed to save the embedding model of the index", "error", err)
+		}
 	}
 
 	var posts []PostRecord
-	lastCreateAt := int64(0)
-	lastID := ""
-	processedCount := int64(0)
-	lastSavedCount := int64(0) // Track when we last saved status
+	processedCount := jobStatus.ProcessedRows
+	runStart := time.Now()
+	runStartCount := processedCount
+	lastLoggedCount := processedCount // Track when we last logged progress
 
 	for {
-		// Check if the job was canceled
-		var currentStatus JobStatus
-		if err := s.pluginAPI.KVGet(ReindexJobKey, &currentStatus); err == nil {
-			if currentStatus.Status == JobStatusCanceled {
-				s.pluginAPI.LogWarn("Reindex job was canceled")
-				return
-			}
+		if s.jobStopped(jobStatus) {
+			return
 		}
 
-		// Run a batch of indexing
+		// Run a batch of indexing. Threads indexed together are indexed from their root post.
+		condition := indexedPostsCondition
+		if s.groupThreads {
+			condition = threadRootsCondition
+		}
 		query := `SELECT
 			Posts.Id as id,
+			Posts.RootId as rootid,
 			Posts.Message as message,
+			Posts.Type as type,
 			Posts.UserId as userid,
 			Posts.ChannelId as channelid,
 			Posts.CreateAt as createat,
+			Posts.FileIds as fileids,
 			Channels.TeamId as teamid,
 			Channels.Name as channelname,
 			Channels.Type as channeltype
 		FROM Posts
 		LEFT JOIN Channels ON Posts.ChannelId = Channels.Id
-		WHERE Posts.DeleteAt = 0 AND Posts.Message != '' AND Posts.Type = ''
+		WHERE Posts.DeleteAt = 0 AND ` + condition + `
 			AND (Posts.CreateAt, Posts.Id) > ($1, $2)
 		ORDER BY Posts.CreateAt ASC, Posts.Id ASC
 		LIMIT $3`
 
-		err := s.db.Select(&posts, query, lastCreateAt, lastID, defaultBatchSize)
+		err := s.db.Select(&posts, query, jobStatus.LastCreateAt, jobStatus.LastPostID, defaultBatchSize)
 		if err != nil {
 			jobStatus.Status = JobStatusFailed
 			jobStatus.Error = fmt.Sprintf("Failed to fetch posts: %s", err)
@@ -117,38 +223,28 @@ func (s *Indexer) runReindexJob(jobStatus *JobStatus) {
 		}
 
 		// Process batch and index posts
-		docs := make([]embeddings.PostDocument, 0, len(posts))
-		for _, post := range posts {
-			modelPost := &model.Post{
-				Id:        post.ID,
-				ChannelId: post.ChannelID,
-				UserId:    post.UserID,
-				Message:   post.Message,
-				Type:      model.PostTypeDefault, // We already filter out non-default post types in the SQL query
-				DeleteAt:  0,                     // We already filter deleted posts in the SQL query
-			}
-
-			// Create a minimal channel object with necessary fields for filtering
-			channel := &model.Channel{
-				Id:     post.ChannelID,
-				TeamId: post.TeamID,
-				Name:   post.ChannelName,
-				Type:   model.ChannelType(post.ChannelType),
+		var docs []embeddings.PostDocument
+		if s.groupThreads {
+			threadDocs, replies, threadErr := s.threadBatchDocuments(posts)
+			if threadErr != nil {
+				jobStatus.Status = JobStatusFailed
+				jobStatus.Error = fmt.Sprintf("Failed to fetch threads: %s", threadErr)
+				jobStatus.CompletedAt = time.Now()
+				s.saveJobStatus(jobStatus)
+				return
 			}
+			docs = threadDocs
+			processedCount += int64(replies)
+		} else {
+			for _, post := range posts {
+				// Apply same indexing rules as indexPost
+				modelPost := s.recordPost(post)
+				if !s.shouldIndexPost(modelPost, post.channel()) {
+					continue
+				}
 
-			// Apply same indexing rules as indexPost
-			if !s.shouldIndexPost(modelPost, channel) {
-				continue
+				docs = append(docs, s.postDocuments(modelPost, post.TeamID)...)
 			}
-
-			docs = append(docs, embeddings.PostDocument{
-				PostID:    modelPost.Id,
-				CreateAt:  modelPost.CreateAt,
-				TeamID:    post.TeamID,
-				ChannelID: post.ChannelID,
-				UserID:    post.UserID,
-				Content:   post.Message,
-			})
 		}
 
 		// Store the batch
@@ -164,34 +260,81 @@ func (s *Indexer) runReindexJob(jobStatus *JobStatus) {
 
 		// Update progress
 		processedCount += int64(len(posts))
-		jobStatus.ProcessedRows = processedCount
+		jobStatus.updateProgress(processedCount, processedCount-runStartCount, runStart, time.Now())
 
 		// Update cursors for next batch
 		lastPost := posts[len(posts)-1]
-		lastCreateAt = lastPost.CreateAt
-		lastID = lastPost.ID
+		jobStatus.LastCreateAt = lastPost.CreateAt
+		jobStatus.LastPostID = lastPost.ID
 
-		// Save progress every 500 additional processed records
-		if processedCount >= lastSavedCount+500 {
-			s.saveJobStatus(jobStatus)
+		// Save progress after every batch so the job resumes from it, unless the job stopped while
+		// the batch was indexed
+		if s.jobStopped(jobStatus) {
+			return
+		}
+		jobStatus.HeartbeatAt = time.Now()
+		s.saveJobStatus(jobStatus)
+
+		// Log progress every 500 additional processed records
+		if processedCount >= lastLoggedCount+500 {
 			s.pluginAPI.LogWarn("Reindexing progress",
 				"processed", processedCount,
-				"estimated_total", jobStatus.TotalRows)
-			lastSavedCount = processedCount
+				"estimated_total", jobStatus.TotalRows,
+				"eta_seconds", jobStatus.ETASeconds)
+			lastLoggedCount = processedCount
 		}
 	}
 
 	// Completed successfully
 	jobStatus.Status = JobStatusCompleted
 	jobStatus.CompletedAt = time.Now()
+	jobStatus.RemainingRows = 0
+	jobStatus.ETASeconds = 0
 	s.saveJobStatus(jobStatus)
 
 	s.pluginAPI.LogWarn("Reindexing completed", "processed_posts", processedCount)
 }
 
-// saveJobStatus saves the job status to KV store
+// jobStopped tells whether the job was canceled, or another run took over, such as a job started
+// or resumed after canceling this one
+func (s *Indexer) jobStopped(jobStatus *JobStatus) bool {
+	var currentStatus JobStatus
+	if err := s.pluginAPI.KVGet(ReindexJobKey, &currentStatus); err != nil {
+		return false
+	}
+	if currentStatus.Status == JobStatusCanceled {
+		s.pluginAPI.LogWarn("Reindex job was canceled")
+		return true
+	}
+	if !currentStatus.StartedAt.Equal(jobStatus.StartedAt) || !currentStatus.ResumedAt.Equal(jobStatus.ResumedAt) {
+		s.pluginAPI.LogWarn("Reindex job was replaced by another run")
+		return true
+	}
+	return false
+}
+
+// saveJobStatus saves the job status to KV store, and sends it to the system admins
 func (s *Indexer) saveJobStatus(status *JobStatus) {
 	if err := s.pluginAPI.KVSet(ReindexJobKey, status); err != nil {
 		s.pluginAPI.LogError("Failed to save job status", "error", err)
 	}
+	s.publishJobStatus(status)
+}
+
+// publishJobStatus sends the job status to the system admins with a websocket event, so the system
+// console shows the progress without polling. The event has the fields of the status endpoint.
+func (s *Indexer) publishJobStatus(status *JobStatus) {
+	data, err := json.Marshal(status)
+	if err != nil {
+		s.pluginAPI.LogError("Failed to marshal job status", "error", err)
+		return
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(data, &payload); err != nil {
+		s.pluginAPI.LogError("Failed to unmarshal job status", "error", err)
+		return
+	}
+	s.pluginAPI.PublishWebSocketEvent(ReindexProgressEvent, payload, &model.WebsocketBroadcast{
+		ContainsSensitiveData: true,
+	})
 }
@@ -0,0 +1,163 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package indexer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	mmapimocks "github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+)
+
+func TestJobStatusProgress(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		total         int64
+		processed     int64
+		runRows       int64
+		elapsed       time.Duration
+		wantRemaining int64
+		wantRate      float64
+		wantETA       int64
+	}{
+		{name: "pace of the run", total: 1000, processed: 200, runRows: 200, elapsed: 10 * time.Second, wantRemaining: 800, wantRate: 20, wantETA: 40},
+		{name: "resumed run only counts its own rows", total: 1000, processed: 600, runRows: 100, elapsed: 10 * time.Second, wantRemaining: 400, wantRate: 10, wantETA: 40},
+		{name: "partial seconds are rounded up", total: 100, processed: 30, runRows: 30, elapsed: 4 * time.Second, wantRemaining: 70, wantRate: 7.5, wantETA: 10},
+		{name: "unknown until rows are processed", total: 1000, processed: 500, runRows: 0, elapsed: 10 * time.Second, wantRemaining: 500},
+		{name: "more rows than estimated", total: 100, processed: 150, runRows: 150, elapsed: 10 * time.Second, wantRemaining: 0, wantRate: 15, wantETA: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := JobStatus{TotalRows: tt.total}
+			status.updateProgress(tt.processed, tt.runRows, start, start.Add(tt.elapsed))
+
+			assert.Equal(t, tt.processed, status.ProcessedRows)
+			assert.Equal(t, tt.wantRemaining, status.RemainingRows)
+			assert.InDelta(t, tt.wantRate, status.RowsPerSecond, 0.001)
+			assert.Equal(t, tt.wantETA, status.ETASeconds)
+		})
+	}
+}
+
+func TestResumeReindexJob(t *testing.T) {
+	model := embeddings.EmbeddingModel{Provider: embeddings.ProviderTypeOpenAI, Model: "text-embedding-3-large", Dimensions: 3072}
+
+	newIndexer := func(t *testing.T, status JobStatus, indexedModel embeddings.EmbeddingModel) *Indexer {
+		client := mmapimocks.NewMockClient(t)
+		client.EXPECT().KVGet(ReindexJobKey, mock.Anything).RunAndReturn(func(_ string, value interface{}) error {
+			*value.(*JobStatus) = status
+			return nil
+		})
+		client.EXPECT().KVGet(IndexedModelKey, mock.Anything).RunAndReturn(func(_ string, value interface{}) error {
+			*value.(**embeddings.EmbeddingModel) = &indexedModel
+			return nil
+		}).Maybe()
+		return New(&recordingSearch{}, client, nil, nil, nil, model)
+	}
+
+	t.Run("running jobs aren't resumed", func(t *testing.T) {
+		indexer := newIndexer(t, JobStatus{Status: JobStatusRunning, LastPostID: "post1"}, model)
+		_, err := indexer.ResumeReindexJob()
+		assert.EqualError(t, err, "job already running")
+	})
+
+	t.Run("completed jobs and jobs that indexed nothing aren't resumed", func(t *testing.T) {
+		for _, status := range []JobStatus{
+			{Status: JobStatusCompleted, LastPostID: "post1"},
+			{Status: JobStatusFailed},
+			{},
+		} {
+			indexer := newIndexer(t, status, model)
+			_, err := indexer.ResumeReindexJob()
+			assert.EqualError(t, err, "nothing to resume")
+		}
+	})
+
+	t.Run("jobs of another embedding model aren't resumed", func(t *testing.T) {
+		otherModel := model
+		otherModel.Dimensions = 1024
+		indexer := newIndexer(t, JobStatus{Status: JobStatusCanceled, LastPostID: "post1"}, otherModel)
+		_, err := indexer.ResumeReindexJob()
+		assert.EqualError(t, err, "embedding model changed")
+	})
+}
+
+func TestRecoverInterruptedJob(t *testing.T) {
+	recoverJob := func(t *testing.T, status JobStatus) *JobStatus {
+		client := mmapimocks.NewMockClient(t)
+		client.EXPECT().KVGet(ReindexJobKey, mock.Anything).RunAndReturn(func(_ string, value interface{}) error {
+			*value.(*JobStatus) = status
+			return nil
+		})
+		var saved *JobStatus
+		client.EXPECT().KVSet(ReindexJobKey, mock.Anything).RunAndReturn(func(_ string, value interface{}) error {
+			saved = value.(*JobStatus)
+			return nil
+		}).Maybe()
+		client.EXPECT().PublishWebSocketEvent(ReindexProgressEvent, mock.Anything, mock.Anything).Maybe()
+		client.EXPECT().LogWarn(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+
+		indexer := &Indexer{pluginAPI: client, nodeID: "node1"}
+		assert.NoError(t, indexer.RecoverInterruptedJob())
+		return saved
+	}
+
+	t.Run("job of this server is interrupted", func(t *testing.T) {
+		saved := recoverJob(t, JobStatus{
+			Status:      JobStatusRunning,
+			NodeID:      "node1",
+			HeartbeatAt: time.Now(),
+			LastPostID:  "post1",
+		})
+		require.NotNil(t, saved)
+		assert.Equal(t, JobStatusFailed, saved.Status)
+		assert.Equal(t, "Job was interrupted", saved.Error)
+		assert.False(t, saved.CompletedAt.IsZero())
+		assert.True(t, saved.Resumable())
+	})
+
+	t.Run("job without a recent heartbeat is interrupted", func(t *testing.T) {
+		saved := recoverJob(t, JobStatus{
+			Status:      JobStatusRunning,
+			NodeID:      "node2",
+			StartedAt:   time.Now().Add(-time.Hour),
+			HeartbeatAt: time.Now().Add(-2 * jobStaleAfter),
+			LastPostID:  "post1",
+		})
+		require.NotNil(t, saved)
+		assert.Equal(t, JobStatusFailed, saved.Status)
+	})
+
+	t.Run("job of another server with a recent heartbeat keeps running", func(t *testing.T) {
+		saved := recoverJob(t, JobStatus{
+			Status:      JobStatusRunning,
+			NodeID:      "node2",
+			StartedAt:   time.Now().Add(-time.Hour),
+			HeartbeatAt: time.Now().Add(-time.Minute),
+		})
+		assert.Nil(t, saved)
+	})
+
+	t.Run("job resumed recently on another server keeps running", func(t *testing.T) {
+		saved := recoverJob(t, JobStatus{
+			Status:    JobStatusRunning,
+			NodeID:    "node2",
+			StartedAt: time.Now().Add(-time.Hour),
+			ResumedAt: time.Now().Add(-time.Minute),
+		})
+		assert.Nil(t, saved)
+	})
+
+	t.Run("stopped jobs are left alone", func(t *testing.T) {
+		saved := recoverJob(t, JobStatus{Status: JobStatusCanceled, NodeID: "node1", LastPostID: "post1"})
+		assert.Nil(t, saved)
+	})
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package indexer
+
+import (
+	"context"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// queueFlushInterval is how long posts wait in the queue before they are indexed
+	queueFlushInterval = 5 * time.Second
+
+	// queueBatchSize is how many posts are indexed at once. The queue is flushed early when it holds
+	// that many posts.
+	queueBatchSize = 50
+
+	// queueFlushTimeout bounds the time spent indexing a batch
+	queueFlushTimeout = 2 * time.Minute
+
+	// queueCloseTimeout bounds the time spent indexing the queued posts when the plugin stops. The
+	// changes left are saved under PendingQueueKey and indexed once the queue starts again.
+	queueCloseTimeout = 30 * time.Second
+
+	// PendingQueueKey holds the changes left in the queue when the plugin stopped
+	PendingQueueKey = "indexer_pending_queue"
+)
+
+// pendingChange is a change left in the queue when the plugin stopped. Only the IDs are kept, the
+// posts are read again when the change is queued again.
+type pendingChange struct {
+	PostID  string   `json:"post_id"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// queuedPost is a change to the index waiting to be written
+type queuedPost struct {
+	post   *model.Post // Nil when the post is removed from the index
+	teamID string
+
+	// Set when the post was deleted, so the documents of other sources such as meetings are removed too
+	deleted bool
+
+	// Set instead of the post when threads are indexed together, the thread is indexed again as a
+	// whole and the posts removed from it since are removed from the index
+	thread  *model.Channel
+	removed []string
+}
+
+// StartQueue indexes the queued posts in the background, in batches so their embeddings are created
+// together. The changes left when the plugin last stopped are queued first.
+func (s *Indexer) StartQueue() {
+	if s.search == nil {
+		return
+	}
+	s.restoreQueue()
+	s.stop = make(chan struct{})
+	s.wg.Add(1)
+	go s.queueLoop(s.stop)
+}
+
+// Close indexes the posts left in the queue, for at most queueCloseTimeout, and stops it.
+func (s *Indexer) Close() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	s.stop = nil
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(queueCloseTimeout + 5*time.Second):
+		// Indexing is cancelled at queueCloseTimeout, this only guards against stores ignoring it
+		s.pluginAPI.LogWarn("Timed out waiting for the indexing queue to stop")
+	}
+}
+
+func (s *Indexer) queueLoop(stop chan struct{}) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(queueFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			ctx, cancel := context.WithTimeout(context.Background(), queueCloseTimeout)
+			s.drainQueue(ctx)
+			cancel()
+			return
+		case <-ticker.C:
+			s.flushQueue()
+		case <-s.wake:
+			s.flushQueue()
+		}
+	}
+}
+
+// QueuePost queues a new post to be indexed with the next batch, if it should be indexed.
+func (s *Indexer) QueuePost(post *model.Post, channel *model.Channel) {
+	if s.search == nil || !s.shouldIndexPost(post, channel) {
+		return
+	}
+	if s.groupThreads {
+		s.queueThread(post, channel, "")
+		return
+	}
+	s.enqueue(post.Id, queuedPost{post: post, teamID: channel.TeamId})
+}
+
+// QueueUpdate queues an edited post to be indexed again with the next batch, or removed from the
+// index when it shouldn't be indexed anymore.
+func (s *Indexer) QueueUpdate(post *model.Post, channel *model.Channel) {
+	if s.search == nil {
+		return
+	}
+	if s.groupThreads {
+		s.queueThread(post, channel, "")
+		return
+	}
+	if !s.shouldIndexPost(post, channel) {
+		s.enqueue(post.Id, queuedPost{})
+		return
+	}
+	s.enqueue(post.Id, queuedPost{post: post, teamID: channel.TeamId})
+}
+
+// QueueDelete queues a deleted post to be removed from the index with the next batch. When threads
+// are indexed together, the rest of the thread is indexed again.
+func (s *Indexer) QueueDelete(post *model.Post) {
+	if s.search == nil {
+		return
+	}
+	if s.groupThreads && post.RootId != "" {
+		channel, err := s.pluginAPI.GetChannel(post.ChannelId)
+		if err != nil {
+			s.pluginAPI.LogError("Failed to get channel of deleted post for indexing", "error", err)
+			s.enqueue(post.Id, queuedPost{deleted: true})
+			return
+		}
+		s.queueThread(post, channel, post.Id)
+		return
+	}
+	s.enqueue(post.Id, queuedPost{deleted: true})
+}
+
+// enqueue replaces the queued change of the post, or of the thread when threads are indexed
+// together, so a post edited several times is indexed once
+func (s *Indexer) enqueue(postID string, change queuedPost) {
+	s.queueMu.Lock()
+	previous, ok := s.queued[postID]
+	if !ok {
+		s.queueOrder = append(s.queueOrder, postID)
+	}
+	change.removed = append(previous.removed, change.removed...)
+	s.queued[postID] = change
+	full := len(s.queueOrder) >= queueBatchSize
+	s.queueMu.Unlock()
+
+	if full {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// takeQueue empties the queue, returning the changes it held in the order they were queued
+func (s *Indexer) takeQueue() (map[string]queuedPost, []string) {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+	queued, order := s.queued, s.queueOrder
+	s.queued = map[string]queuedPost{}
+	s.queueOrder = nil
+	return queued, order
+}
+
+// flushQueue writes the queued changes to the index in batches. The previous documents of the posts
+// are removed before the new ones are stored, so edits don't leave chunks of the old message
+// behind. Batches that fail are logged and dropped, reindexing rebuilds them.
+func (s *Indexer) flushQueue() {
+	queued, order := s.takeQueue()
+	for start := 0; start < len(order); start += queueBatchSize {
+		queuedIDs := order[start:min(start+queueBatchSize, len(order))]
+		if err := s.indexBatch(context.Background(), queuedIDs, queued); err != nil {
+			s.pluginAPI.LogError("Failed to index queued posts", "posts", len(queuedIDs), "error", err)
+		}
+	}
+}
+
+// drainQueue writes the queued changes to the index when the queue stops, until ctx is done. The
+// changes that couldn't be written in time are saved to be queued again when the queue starts.
+func (s *Indexer) drainQueue(ctx context.Context) {
+	queued, order := s.takeQueue()
+	var pending []pendingChange
+	for start := 0; start < len(order); start += queueBatchSize {
+		queuedIDs := order[start:min(start+queueBatchSize, len(order))]
+		if ctx.Err() == nil {
+			err := s.indexBatch(ctx, queuedIDs, queued)
+			if err == nil {
+				continue
+			}
+			if ctx.Err() == nil {
+				s.pluginAPI.LogError("Failed to index queued posts", "posts", len(queuedIDs), "error", err)
+				continue
+			}
+		}
+		for _, queuedID := range queuedIDs {
+			pending = append(pending, pendingChange{PostID: queuedID, Removed: queued[queuedID].removed})
+		}
+	}
+
+	if len(pending) == 0 {
+		return
+	}
+	if err := s.pluginAPI.KVSet(PendingQueueKey, pending); err != nil {
+		s.pluginAPI.LogError("Failed to save the posts left to index", "posts", len(pending), "error", err)
+		return
+	}
+	s.pluginAPI.LogWarn("Stopped before indexing every queued post, the rest are indexed on the next start", "posts", len(pending))
+}
+
+// restoreQueue queues again the changes saved when the queue last stopped, reading the posts as they
+// are now. Posts that were deleted since are removed from the index.
+func (s *Indexer) restoreQueue() {
+	var pending []pendingChange
+	if err := s.pluginAPI.KVGet(PendingQueueKey, &pending); err != nil {
+		s.pluginAPI.LogError("Failed to get the posts left to index", "error", err)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+	if err := s.pluginAPI.KVSet(PendingQueueKey, nil); err != nil {
+		s.pluginAPI.LogError("Failed to clear the posts left to index", "error", err)
+	}
+
+	for _, change := range pending {
+		for _, removedID := range change.Removed {
+			s.enqueue(removedID, queuedPost{deleted: true})
+		}
+
+		post, err := s.pluginAPI.GetPost(change.PostID)
+		if err != nil || post.DeleteAt != 0 {
+			s.enqueue(change.PostID, queuedPost{deleted: err == nil})
+			continue
+		}
+		channel, err := s.pluginAPI.GetChannel(post.ChannelId)
+		if err != nil {
+			s.pluginAPI.LogWarn("Failed to get channel of post left to index", "post_id", post.Id, "error", err)
+			continue
+		}
+		s.QueueUpdate(post, channel)
+	}
+}
+
+func (s *Indexer) indexBatch(parent context.Context, queuedIDs []string, queued map[string]queuedPost) error {
+	ctx, cancel := context.WithTimeout(parent, queueFlushTimeout)
+	defer cancel()
+
+	// The documents rebuilt from the posts are replaced, deleted posts lose the documents of every source
+	var rebuiltIDs, deletedIDs []string
+	var docs []embeddings.PostDocument
+	for _, queuedID := range queuedIDs {
+		change := queued[queuedID]
+		switch {
+		case change.thread != nil:
+			threadDocs, threadPostIDs := s.indexedThread(queuedID, change.thread)
+			docs = append(docs, threadDocs...)
+			rebuiltIDs = append(rebuiltIDs, threadPostIDs...)
+		case change.post != nil:
+			docs = append(docs, s.postDocuments(change.post, change.teamID)...)
+			rebuiltIDs = append(rebuiltIDs, queuedID)
+		case change.deleted:
+			deletedIDs = append(deletedIDs, queuedID)
+		default:
+			rebuiltIDs = append(rebuiltIDs, queuedID)
+		}
+		deletedIDs = append(deletedIDs, change.removed...)
+	}
+
+	if len(rebuiltIDs) > 0 {
+		if err := s.search.DeleteRebuilt(ctx, rebuiltIDs); err != nil {
+			return err
+		}
+	}
+	if len(deletedIDs) > 0 {
+		if err := s.search.Delete(ctx, deletedIDs); err != nil {
+			return err
+		}
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	return s.search.Store(ctx, docs)
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package indexer
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	mmapimocks "github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+)
+
+// recordingSearch records the changes written to the index
+type recordingSearch struct {
+	mu      sync.Mutex
+	stored  [][]string
+	rebuilt [][]string
+	deleted [][]string
+}
+
+func (r *recordingSearch) Store(_ context.Context, docs []embeddings.PostDocument) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	contents := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		contents = append(contents, doc.Content)
+	}
+	r.stored = append(r.stored, contents)
+	return nil
+}
+
+func (r *recordingSearch) Search(context.Context, string, embeddings.SearchOptions) ([]embeddings.SearchResult, error) {
+	return nil, nil
+}
+
+func (r *recordingSearch) Delete(_ context.Context, postIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleted = append(r.deleted, postIDs)
+	return nil
+}
+
+func (r *recordingSearch) DeleteRebuilt(_ context.Context, postIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rebuilt = append(r.rebuilt, postIDs)
+	return nil
+}
+
+func (r *recordingSearch) Clear(context.Context) error {
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	search := &recordingSearch{}
+	client := mmapimocks.NewMockClient(t)
+	client.EXPECT().KVGet(PendingQueueKey, mock.Anything).Return(nil).Maybe()
+	indexer := New(search, client, nil, nil, nil, embeddings.EmbeddingModel{})
+
+	post := func(id, message string) *model.Post {
+		return &model.Post{Id: id, ChannelId: "channel1", UserId: "user1", Message: message}
+	}
+	channel := &model.Channel{Id: "channel1", TeamId: "team1"}
+
+	indexer.enqueue("post1", queuedPost{post: post("post1", "first"), teamID: channel.TeamId})
+	indexer.enqueue("post2", queuedPost{post: post("post2", "second"), teamID: channel.TeamId})
+	indexer.enqueue("post1", queuedPost{post: post("post1", "first, edited"), teamID: channel.TeamId})
+	indexer.QueueDelete(&model.Post{Id: "post3"})
+	indexer.QueueDelete(&model.Post{Id: "post2"})
+
+	indexer.flushQueue()
+
+	t.Run("changes are batched and the last change of a post wins", func(t *testing.T) {
+		assert.Equal(t, [][]string{{"post1"}}, search.rebuilt)
+		assert.Equal(t, [][]string{{"post2", "post3"}}, search.deleted, "deleted posts lose the documents of every source")
+		assert.Equal(t, [][]string{{"first, edited"}}, search.stored)
+	})
+
+	t.Run("flushing an empty queue writes nothing", func(t *testing.T) {
+		indexer.flushQueue()
+		assert.Len(t, search.rebuilt, 1)
+		assert.Len(t, search.deleted, 1)
+		assert.Len(t, search.stored, 1)
+	})
+
+	t.Run("queued posts are indexed when closing", func(t *testing.T) {
+		indexer.StartQueue()
+		indexer.enqueue("post4", queuedPost{post: post("post4", "fourth"), teamID: channel.TeamId})
+		indexer.Close()
+
+		assert.Equal(t, []string{"post4"}, search.rebuilt[len(search.rebuilt)-1])
+		assert.Equal(t, []string{"fourth"}, search.stored[len(search.stored)-1])
+	})
+
+	t.Run("large queues are indexed in batches", func(t *testing.T) {
+		search.deleted = nil
+		for i := 0; i < queueBatchSize+1; i++ {
+			indexer.QueueDelete(&model.Post{Id: model.NewId()})
+		}
+		indexer.flushQueue()
+		assert.Len(t, search.deleted, 2)
+		assert.Len(t, search.deleted[0], queueBatchSize)
+	})
+}
+
+func TestQueueLeftovers(t *testing.T) {
+	channel := &model.Channel{Id: "channel1", TeamId: "team1"}
+
+	t.Run("changes left when stopping are saved", func(t *testing.T) {
+		client := mmapimocks.NewMockClient(t)
+		client.EXPECT().KVSet(PendingQueueKey, []pendingChange{{PostID: "post1"}, {PostID: "post2"}}).Return(nil)
+		client.EXPECT().LogWarn(mock.Anything, mock.Anything, mock.Anything).Maybe()
+		search := &recordingSearch{}
+		indexer := New(search, client, nil, nil, nil, embeddings.EmbeddingModel{})
+
+		indexer.enqueue("post1", queuedPost{post: &model.Post{Id: "post1", Message: "first"}, teamID: channel.TeamId})
+		indexer.QueueDelete(&model.Post{Id: "post2"})
+
+		// The time to index them is up already
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		indexer.drainQueue(ctx)
+
+		assert.Empty(t, search.stored)
+		assert.Empty(t, search.rebuilt)
+		assert.Empty(t, search.deleted)
+	})
+
+	t.Run("saved changes are queued again when starting", func(t *testing.T) {
+		client := mmapimocks.NewMockClient(t)
+		client.EXPECT().KVGet(PendingQueueKey, mock.Anything).RunAndReturn(func(_ string, value interface{}) error {
+			*value.(*[]pendingChange) = []pendingChange{{PostID: "post1"}, {PostID: "post2"}}
+			return nil
+		})
+		client.EXPECT().KVSet(PendingQueueKey, nil).Return(nil)
+		client.EXPECT().GetPost("post1").Return(&model.Post{Id: "post1", ChannelId: channel.Id, Message: "first"}, nil)
+		client.EXPECT().GetPost("post2").Return(&model.Post{Id: "post2", ChannelId: channel.Id, DeleteAt: 1}, nil)
+		client.EXPECT().GetChannel(channel.Id).Return(channel, nil)
+		indexer := New(&recordingSearch{}, client, &bots.MMBots{}, nil, nil, embeddings.EmbeddingModel{})
+
+		indexer.restoreQueue()
+
+		assert.Equal(t, []string{"post1", "post2"}, indexer.queueOrder)
+		assert.NotNil(t, indexer.queued["post1"].post, "existing posts are indexed again")
+		assert.Nil(t, indexer.queued["post2"].post, "deleted posts are removed from the index")
+	})
+}
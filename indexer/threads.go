@@ -0,0 +1,178 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package indexer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattermost/mattermost-plugin-ai/chunking"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// threadPostSeparator separates the posts in the document of a thread, chunking splits on it first
+const threadPostSeparator = "\n\n"
+
+// SetChunkingOptions sets how the search chunks documents. When it groups threads, the posts of a
+// thread are indexed together and any change to a post indexes its whole thread again. It must be
+// set before the queue starts.
+func (s *Indexer) SetChunkingOptions(options chunking.Options) {
+	s.groupThreads = options.GroupsThreads()
+}
+
+// threadDocuments returns the documents of a thread when posts are indexed by thread: one document
+// with the messages of the thread in order, and a document per attached file. The document of the
+// thread is referenced by the root post, even when the root itself isn't indexed, so it is removed
+// with the root.
+func (s *Indexer) threadDocuments(rootID string, posts []*model.Post, channel *model.Channel) []embeddings.PostDocument {
+	var thread *embeddings.PostDocument
+	var messages []string
+	var files []embeddings.PostDocument
+	for _, post := range posts {
+		if !s.shouldIndexPost(post, channel) {
+			continue
+		}
+
+		doc := embeddings.PostDocument{
+			PostID:    post.Id,
+			CreateAt:  post.CreateAt,
+			TeamID:    channel.TeamId,
+			ChannelID: post.ChannelId,
+			UserID:    post.UserId,
+		}
+		if thread == nil {
+			threadDoc := doc
+			threadDoc.PostID = rootID
+			thread = &threadDoc
+		}
+		if message := strings.TrimSpace(post.Message); message != "" {
+			messages = append(messages, message)
+		}
+		files = append(files, s.fileDocuments(doc, post.FileIds)...)
+	}
+
+	if thread == nil {
+		return nil
+	}
+	var docs []embeddings.PostDocument
+	if len(messages) > 0 {
+		thread.Content = strings.Join(messages, threadPostSeparator)
+		docs = append(docs, *thread)
+	}
+	return append(docs, files...)
+}
+
+// queueThread queues the thread of a post to be indexed again with the next batch. The removed post
+// is removed from the index along with the previous documents of the thread.
+func (s *Indexer) queueThread(post *model.Post, channel *model.Channel, removedPostID string) {
+	rootID := post.RootId
+	if rootID == "" {
+		rootID = post.Id
+	}
+
+	// Deleting the root deletes the thread
+	if removedPostID == rootID {
+		s.enqueue(rootID, queuedPost{deleted: true})
+		return
+	}
+
+	change := queuedPost{thread: channel}
+	if removedPostID != "" {
+		change.removed = []string{removedPostID}
+	}
+	s.enqueue(rootID, change)
+}
+
+// indexedThread returns the documents of a thread as it is now, and the posts whose documents they
+// replace. Threads that can't be read are only removed from the index.
+func (s *Indexer) indexedThread(rootID string, channel *model.Channel) ([]embeddings.PostDocument, []string) {
+	postList, err := s.pluginAPI.GetPostThread(rootID)
+	if err != nil {
+		s.pluginAPI.LogWarn("Unable to get thread for indexing, removing it from the index", "root_id", rootID, "error", err)
+		return nil, []string{rootID}
+	}
+
+	posts := make([]*model.Post, 0, len(postList.Posts))
+	for _, post := range postList.Posts {
+		posts = append(posts, post)
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].CreateAt < posts[j].CreateAt
+	})
+
+	postIDs := []string{rootID}
+	for _, post := range posts {
+		if post.Id != rootID {
+			postIDs = append(postIDs, post.Id)
+		}
+	}
+	return s.threadDocuments(rootID, posts, channel), postIDs
+}
+
+// threadReplies returns the replies of the root posts that may be indexed, by root post
+func (s *Indexer) threadReplies(rootIDs []string) (map[string][]PostRecord, error) {
+	if len(rootIDs) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT
+			Posts.Id as id,
+			Posts.RootId as rootid,
+			Posts.Message as message,
+			Posts.Type as type,
+			Posts.UserId as userid,
+			Posts.ChannelId as channelid,
+			Posts.CreateAt as createat,
+			Posts.FileIds as fileids,
+			Channels.TeamId as teamid,
+			Channels.Name as channelname,
+			Channels.Type as channeltype
+		FROM Posts
+		LEFT JOIN Channels ON Posts.ChannelId = Channels.Id
+		WHERE Posts.DeleteAt = 0 AND `+indexedPostsCondition+`
+			AND Posts.RootId IN (?)
+		ORDER BY Posts.CreateAt ASC, Posts.Id ASC`, rootIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build replies query: %w", err)
+	}
+
+	var replies []PostRecord
+	if err := s.db.Select(&replies, s.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch replies: %w", err)
+	}
+
+	byRoot := make(map[string][]PostRecord, len(rootIDs))
+	for _, reply := range replies {
+		byRoot[reply.RootID] = append(byRoot[reply.RootID], reply)
+	}
+	return byRoot, nil
+}
+
+// threadBatchDocuments returns the documents of the threads of a batch of root posts, and the number
+// of replies they have
+func (s *Indexer) threadBatchDocuments(roots []PostRecord) ([]embeddings.PostDocument, int, error) {
+	rootIDs := make([]string, 0, len(roots))
+	for _, root := range roots {
+		rootIDs = append(rootIDs, root.ID)
+	}
+	replies, err := s.threadReplies(rootIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var docs []embeddings.PostDocument
+	replyCount := 0
+	for _, root := range roots {
+		posts := []*model.Post{s.recordPost(root)}
+		for _, reply := range replies[root.ID] {
+			posts = append(posts, s.recordPost(reply))
+		}
+		replyCount += len(replies[root.ID])
+		docs = append(docs, s.threadDocuments(root.ID, posts, root.channel())...)
+	}
+	return docs, replyCount, nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package indexer
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/chunking"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	mmapimocks "github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+)
+
+func TestThreadDocuments(t *testing.T) {
+	channel := &model.Channel{Id: "channel1", TeamId: "team1"}
+	post := func(id, message string, createAt int64) *model.Post {
+		return &model.Post{Id: id, RootId: "root", ChannelId: "channel1", UserId: "user1", CreateAt: createAt, Message: message}
+	}
+	root := post("root", "Is the release ready?", 100)
+	root.RootId = ""
+	joined := post("joined", "", 150)
+	joined.Type = model.PostTypeJoinChannel
+	joined.Message = "user1 joined the channel"
+
+	indexer := &Indexer{bots: &bots.MMBots{}, files: memoryFiles{}}
+
+	t.Run("the messages of the thread are indexed together under the root", func(t *testing.T) {
+		docs := indexer.threadDocuments("root", []*model.Post{root, joined, post("reply1", "Almost, one test fails.", 200)}, channel)
+		assert.Equal(t, []embeddings.PostDocument{
+			{PostID: "root", CreateAt: 100, TeamID: "team1", ChannelID: "channel1", UserID: "user1", Content: "Is the release ready?\n\nAlmost, one test fails."},
+		}, docs)
+	})
+
+	t.Run("threads whose root isn't indexed are still indexed under the root", func(t *testing.T) {
+		emptyRoot := root.Clone()
+		emptyRoot.Message = ""
+		docs := indexer.threadDocuments("root", []*model.Post{emptyRoot, post("reply1", "Almost, one test fails.", 200)}, channel)
+		assert.Equal(t, []embeddings.PostDocument{
+			{PostID: "root", CreateAt: 200, TeamID: "team1", ChannelID: "channel1", UserID: "user1", Content: "Almost, one test fails."},
+		}, docs)
+	})
+
+	t.Run("threads without indexed posts have no documents", func(t *testing.T) {
+		assert.Empty(t, indexer.threadDocuments("root", []*model.Post{joined}, channel))
+	})
+}
+
+func TestThreadQueue(t *testing.T) {
+	channel := &model.Channel{Id: "channel1", TeamId: "team1"}
+	root := &model.Post{Id: "root", ChannelId: "channel1", UserId: "user1", CreateAt: 100, Message: "Is the release ready?"}
+	reply := &model.Post{Id: "reply1", RootId: "root", ChannelId: "channel1", UserId: "user2", CreateAt: 200, Message: "Almost, one test fails."}
+
+	client := mmapimocks.NewMockClient(t)
+	thread := model.NewPostList()
+	thread.AddPost(reply)
+	thread.AddPost(root)
+	client.EXPECT().GetPostThread("root").Return(thread, nil)
+	client.EXPECT().GetChannel("channel1").Return(channel, nil)
+
+	search := &recordingSearch{}
+	indexer := New(search, client, &bots.MMBots{}, nil, memoryFiles{}, embeddings.EmbeddingModel{})
+	indexer.SetChunkingOptions(chunking.Options{ChunkSize: 1000, ChunkingStrategy: chunking.StrategyThreads})
+
+	t.Run("new replies index the whole thread again", func(t *testing.T) {
+		indexer.QueuePost(reply, channel)
+		indexer.flushQueue()
+
+		assert.Equal(t, [][]string{{"root", "reply1"}}, search.rebuilt)
+		assert.Empty(t, search.deleted)
+		assert.Equal(t, [][]string{{"Is the release ready?\n\nAlmost, one test fails."}}, search.stored)
+	})
+
+	t.Run("deleted replies are removed with the previous documents of the thread", func(t *testing.T) {
+		search.rebuilt, search.deleted, search.stored = nil, nil, nil
+		deleted := &model.Post{Id: "reply2", RootId: "root", ChannelId: "channel1"}
+		indexer.QueueDelete(deleted)
+		indexer.flushQueue()
+
+		assert.Equal(t, [][]string{{"root", "reply1"}}, search.rebuilt)
+		assert.Equal(t, [][]string{{"reply2"}}, search.deleted)
+		assert.Len(t, search.stored, 1)
+	})
+
+	t.Run("deleting the root removes the thread", func(t *testing.T) {
+		search.rebuilt, search.deleted, search.stored = nil, nil, nil
+		indexer.QueueDelete(root)
+		indexer.flushQueue()
+
+		assert.Empty(t, search.rebuilt)
+		assert.Equal(t, [][]string{{"root"}}, search.deleted)
+		assert.Empty(t, search.stored)
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package jobs runs long tasks, such as transcribing and summarizing recordings, from a queue kept in the
+// database so they survive restarts of the plugin. Any server of a cluster can run a job, and jobs abandoned
+// by a server that stopped are resumed by another one, or by the same one once it's back.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Job states
+const (
+	StatePending   = "pending"
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+var (
+	// ErrJobNotFound is returned when cancelling a job that doesn't exist.
+	ErrJobNotFound = errors.New("job not found")
+
+	// ErrJobFinished is returned when cancelling a job that already succeeded, failed or was cancelled.
+	ErrJobFinished = errors.New("job already finished")
+
+	// ErrCancelled is given to the Failed handler of jobs cancelled by an admin.
+	ErrCancelled = errors.New("job cancelled")
+
+	// errAbandoned is given to the Failed handler of jobs whose server stopped during their last attempt.
+	errAbandoned = errors.New("job abandoned during its last attempt")
+)
+
+// Job is a task of the queue.
+type Job struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+
+	// UserID requested the job and PostID is the post telling them about it, both are optional
+	UserID string `json:"userId"`
+	PostID string `json:"postId"`
+
+	// Payload holds the JSON encoded parameters of the job
+	Payload string `json:"-"`
+
+	Attempts      int    `json:"attempts"`
+	MaxAttempts   int    `json:"maxAttempts"`
+	Error         string `json:"error"`
+	CreateAt      int64  `json:"createAt"`
+	UpdateAt      int64  `json:"updateAt"`
+	NextAttemptAt int64  `json:"nextAttemptAt"`
+}
+
+// DecodePayload reads the parameters of the job into v.
+func (j *Job) DecodePayload(v any) error {
+	if err := json.Unmarshal([]byte(j.Payload), v); err != nil {
+		return fmt.Errorf("unable to decode payload of %s job %s: %w", j.Type, j.ID, err)
+	}
+	return nil
+}
+
+// IsFinished returns true if the job won't run again.
+func (j *Job) IsFinished() bool {
+	return j.State == StateSucceeded || j.State == StateFailed || j.State == StateCancelled
+}
+
+// Handler runs the jobs of a type.
+type Handler struct {
+	// Run does the work of the job. It can be interrupted by a restart at any point and run again from the
+	// start, so it must pick up or replace what a previous attempt left behind. The context is cancelled
+	// when the job is cancelled or the plugin stops.
+	Run func(ctx context.Context, job *Job) error
+
+	// Failed is called once the job failed its last attempt or was cancelled, to tell the requester.
+	Failed func(job *Job, err error)
+
+	// MaxAttempts is how many times a failing job is run before giving up, at least once.
+	MaxAttempts int
+}
+
+// retryDelay is how long to wait before running a job again after its attempt failed: a minute after the
+// first failure, then growing quickly so a struggling backend isn't flooded, up to an hour.
+func retryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := time.Duration(attempts*attempts) * time.Minute
+	return min(delay, time.Hour)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 0, expected: time.Minute},
+		{attempts: 1, expected: time.Minute},
+		{attempts: 2, expected: 4 * time.Minute},
+		{attempts: 5, expected: 25 * time.Minute},
+		{attempts: 8, expected: time.Hour},
+		{attempts: 100, expected: time.Hour},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, retryDelay(tc.attempts), "attempts %d", tc.attempts)
+	}
+}
+
+func TestJobDecodePayload(t *testing.T) {
+	type payload struct {
+		PostID string `json:"postId"`
+	}
+
+	job := &Job{ID: "job", Type: "test", Payload: `{"postId":"post"}`}
+	var decoded payload
+	require.NoError(t, job.DecodePayload(&decoded))
+	assert.Equal(t, "post", decoded.PostID)
+
+	job.Payload = "not json"
+	assert.Error(t, job.DecodePayload(&decoded))
+}
+
+func TestJobIsFinished(t *testing.T) {
+	for state, finished := range map[string]bool{
+		StatePending:   false,
+		StateRunning:   false,
+		StateSucceeded: true,
+		StateFailed:    true,
+		StateCancelled: true,
+	} {
+		assert.Equal(t, finished, (&Job{State: state}).IsFinished(), state)
+	}
+}
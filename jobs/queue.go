@@ -0,0 +1,486 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+const (
+	// pollInterval is how often the queue looks for jobs enqueued by other servers or due for a retry
+	pollInterval = 10 * time.Second
+
+	// heartbeatInterval is how often running jobs are marked as alive
+	heartbeatInterval = 30 * time.Second
+
+	// staleAfter is how long a running job can go without a heartbeat before it's considered abandoned
+	staleAfter = 3 * heartbeatInterval
+
+	// maxConcurrentJobs limits the jobs run at once by each server
+	maxConcurrentJobs = 2
+
+	// claimCandidates is how many pending jobs are considered at once, in case other servers claim some first
+	claimCandidates = 5
+
+	// finishedJobRetention is how long finished jobs are kept so admins can look into them
+	finishedJobRetention = 30 * 24 * time.Hour
+)
+
+var jobColumns = []string{"ID", "Type", "State", "UserID", "PostID", "Payload", "Attempts", "MaxAttempts", "Error", "CreateAt", "UpdateAt", "NextAttemptAt"}
+
+// Queue keeps jobs in the LLM_Jobs table and runs them with the handlers registered for their type.
+type Queue struct {
+	db  *mmapi.DBClient
+	log pluginapi.LogService
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	running  map[string]context.CancelFunc
+	closing  bool
+
+	wake        chan struct{}
+	stop        chan struct{}
+	wg          sync.WaitGroup
+	lastCleanup time.Time
+}
+
+// NewQueue creates a job queue. Handlers are registered before it's started.
+func NewQueue(db *mmapi.DBClient, log pluginapi.LogService) *Queue {
+	return &Queue{
+		db:       db,
+		log:      log,
+		handlers: map[string]Handler{},
+		running:  map[string]context.CancelFunc{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler of the jobs of a type. This server only runs the types it has handlers for.
+func (q *Queue) Register(jobType string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if handler.MaxAttempts < 1 {
+		handler.MaxAttempts = 1
+	}
+	q.handlers[jobType] = handler
+}
+
+// Start runs pending jobs in the background, including those interrupted by a previous stop.
+func (q *Queue) Start() {
+	q.stop = make(chan struct{})
+	q.wg.Add(1)
+	go q.loop()
+}
+
+// Close stops running jobs and waits for them to return. They are put back in the queue to be
+// resumed once the plugin starts again.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	q.closing = true
+	for _, cancel := range q.running {
+		cancel()
+	}
+	q.mu.Unlock()
+
+	if q.stop != nil {
+		close(q.stop)
+	}
+	q.wg.Wait()
+}
+
+// Enqueue adds a job of the type with the parameters in payload, which must encode as JSON.
+func (q *Queue) Enqueue(jobType, userID, postID string, payload any) (*Job, error) {
+	q.mu.Lock()
+	handler, ok := q.handlers[jobType]
+	q.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no handler for %s jobs", jobType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode payload of %s job: %w", jobType, err)
+	}
+
+	now := model.GetMillis()
+	job := &Job{
+		ID:          model.NewId(),
+		Type:        jobType,
+		State:       StatePending,
+		UserID:      userID,
+		PostID:      postID,
+		Payload:     string(data),
+		MaxAttempts: handler.MaxAttempts,
+		CreateAt:    now,
+		UpdateAt:    now,
+	}
+	if _, err := q.db.ExecBuilder(q.db.Builder().Insert("LLM_Jobs").
+		Columns(jobColumns...).
+		Values(job.ID, job.Type, job.State, job.UserID, job.PostID, job.Payload, job.Attempts, job.MaxAttempts, job.Error, job.CreateAt, job.UpdateAt, job.NextAttemptAt)); err != nil {
+		return nil, fmt.Errorf("failed to enqueue %s job: %w", jobType, err)
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Get returns the job with the ID.
+func (q *Queue) Get(jobID string) (*Job, error) {
+	var jobs []Job
+	if err := q.db.DoQuery(&jobs, q.db.Builder().
+		Select(jobColumns...).
+		From("LLM_Jobs").
+		Where(sq.Eq{"ID": jobID})); err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil, ErrJobNotFound
+	}
+	return &jobs[0], nil
+}
+
+// List returns the latest jobs first, only those in the state when it isn't empty.
+func (q *Queue) List(state string, limit uint64) ([]Job, error) {
+	query := q.db.Builder().
+		Select(jobColumns...).
+		From("LLM_Jobs").
+		OrderBy("CreateAt DESC").
+		Limit(limit)
+	if state != "" {
+		query = query.Where(sq.Eq{"State": state})
+	}
+
+	jobs := []Job{}
+	if err := q.db.DoQuery(&jobs, query); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Cancel stops a pending or running job. A job running on another server stops at its next heartbeat.
+func (q *Queue) Cancel(jobID string) error {
+	job, err := q.Get(jobID)
+	if err != nil {
+		return err
+	}
+	if job.IsFinished() {
+		return ErrJobFinished
+	}
+
+	result, err := q.db.ExecBuilder(q.db.Builder().Update("LLM_Jobs").
+		Set("State", StateCancelled).
+		Set("Error", ErrCancelled.Error()).
+		Set("UpdateAt", model.GetMillis()).
+		Where(sq.Eq{"ID": jobID, "State": []string{StatePending, StateRunning}}))
+	if err != nil {
+		return fmt.Errorf("failed to cancel job: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrJobFinished
+	}
+
+	q.mu.Lock()
+	cancel := q.running[jobID]
+	handler := q.handlers[job.Type]
+	q.mu.Unlock()
+
+	if cancel != nil {
+		// The runner tells the requester once the job returns
+		cancel()
+	} else if job.State == StatePending && handler.Failed != nil {
+		handler.Failed(job, ErrCancelled)
+	}
+	return nil
+}
+
+func (q *Queue) loop() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		q.poll()
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+func (q *Queue) poll() {
+	if err := q.resumeAbandoned(); err != nil {
+		q.log.Error("Failed to resume abandoned jobs", "error", err)
+	}
+	if time.Since(q.lastCleanup) > time.Hour {
+		if err := q.deleteFinished(); err != nil {
+			q.log.Error("Failed to delete finished jobs", "error", err)
+		}
+		q.lastCleanup = time.Now()
+	}
+
+	for q.hasCapacity() {
+		job, err := q.claim()
+		if err != nil {
+			q.log.Error("Failed to claim job", "error", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+		q.run(job)
+	}
+}
+
+func (q *Queue) hasCapacity() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return !q.closing && len(q.running) < maxConcurrentJobs
+}
+
+func (q *Queue) handlerTypes() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	types := make([]string, 0, len(q.handlers))
+	for jobType := range q.handlers {
+		types = append(types, jobType)
+	}
+	return types
+}
+
+// claim takes the oldest pending job that is due, or returns nil when there is none. The update only
+// succeeds for one server when several try to claim the same job.
+func (q *Queue) claim() (*Job, error) {
+	types := q.handlerTypes()
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	var candidates []Job
+	if err := q.db.DoQuery(&candidates, q.db.Builder().
+		Select(jobColumns...).
+		From("LLM_Jobs").
+		Where(sq.Eq{"State": StatePending, "Type": types}).
+		Where(sq.LtOrEq{"NextAttemptAt": model.GetMillis()}).
+		OrderBy("CreateAt").
+		Limit(claimCandidates)); err != nil {
+		return nil, fmt.Errorf("failed to get pending jobs: %w", err)
+	}
+
+	for _, job := range candidates {
+		now := model.GetMillis()
+		result, err := q.db.ExecBuilder(q.db.Builder().Update("LLM_Jobs").
+			Set("State", StateRunning).
+			Set("Attempts", sq.Expr("Attempts + 1")).
+			Set("UpdateAt", now).
+			Where(sq.Eq{"ID": job.ID, "State": StatePending}))
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim job: %w", err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 1 {
+			job.State = StateRunning
+			job.Attempts++
+			job.UpdateAt = now
+			return &job, nil
+		}
+	}
+	return nil, nil
+}
+
+func (q *Queue) run(job *Job) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	q.mu.Lock()
+	handler := q.handlers[job.Type]
+	q.running[job.ID] = cancel
+	q.mu.Unlock()
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		defer func() {
+			q.mu.Lock()
+			delete(q.running, job.ID)
+			q.mu.Unlock()
+			cancel()
+		}()
+
+		stopHeartbeat := q.heartbeat(ctx, job.ID, cancel)
+		q.log.Debug("Running job", "job_id", job.ID, "type", job.Type, "attempt", job.Attempts)
+		err := runHandler(ctx, handler, job)
+		stopHeartbeat()
+
+		if finishErr := q.finish(job, handler, err); finishErr != nil {
+			q.log.Error("Failed to update finished job", "job_id", job.ID, "error", finishErr)
+		}
+	}()
+}
+
+// runHandler runs the job, turning a panic into an error so one job can't take the plugin down.
+func runHandler(ctx context.Context, handler Handler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler.Run(ctx, job)
+}
+
+// heartbeat marks the job as alive until the returned function is called. It cancels the context when
+// the job is no longer running, such as when it was cancelled from another server.
+func (q *Queue) heartbeat(ctx context.Context, jobID string, cancel context.CancelFunc) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				result, err := q.db.ExecBuilder(q.db.Builder().Update("LLM_Jobs").
+					Set("UpdateAt", model.GetMillis()).
+					Where(sq.Eq{"ID": jobID, "State": StateRunning}))
+				if err != nil {
+					q.log.Warn("Failed to update job heartbeat", "job_id", jobID, "error", err)
+					continue
+				}
+				if rows, _ := result.RowsAffected(); rows == 0 {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// finish records the outcome of an attempt, scheduling another one when the job failed and has attempts left.
+func (q *Queue) finish(job *Job, handler Handler, runErr error) error {
+	q.mu.Lock()
+	closing := q.closing
+	q.mu.Unlock()
+
+	update := q.db.Builder().Update("LLM_Jobs").
+		Set("UpdateAt", model.GetMillis()).
+		Where(sq.Eq{"ID": job.ID, "State": StateRunning})
+	retry := runErr != nil && job.Attempts < job.MaxAttempts
+	switch {
+	case closing && runErr != nil:
+		// Interrupted by the plugin stopping, this attempt doesn't count
+		update = update.Set("State", StatePending).Set("Attempts", sq.Expr("Attempts - 1"))
+	case runErr == nil:
+		update = update.Set("State", StateSucceeded).Set("Error", "")
+	case retry:
+		update = update.Set("State", StatePending).
+			Set("Error", runErr.Error()).
+			Set("NextAttemptAt", model.GetMillis()+retryDelay(job.Attempts).Milliseconds())
+	default:
+		update = update.Set("State", StateFailed).Set("Error", runErr.Error())
+	}
+
+	result, err := q.db.ExecBuilder(update)
+	if err != nil {
+		return err
+	}
+
+	// No longer running: it was cancelled, or taken over by another server after missing heartbeats
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		current, getErr := q.Get(job.ID)
+		if getErr != nil {
+			return getErr
+		}
+		if current.State == StateCancelled && handler.Failed != nil {
+			handler.Failed(current, ErrCancelled)
+		}
+		return nil
+	}
+
+	switch {
+	case closing && runErr != nil:
+		q.log.Info("Job interrupted, it will be resumed", "job_id", job.ID, "type", job.Type)
+	case runErr == nil:
+		q.log.Debug("Job succeeded", "job_id", job.ID, "type", job.Type)
+	case retry:
+		q.log.Warn("Job failed, it will be retried", "job_id", job.ID, "type", job.Type, "attempt", job.Attempts, "error", runErr)
+	default:
+		q.log.Error("Job failed", "job_id", job.ID, "type", job.Type, "attempts", job.Attempts, "error", runErr)
+		if handler.Failed != nil {
+			handler.Failed(job, runErr)
+		}
+	}
+	return nil
+}
+
+// resumeAbandoned puts back in the queue the running jobs without a recent heartbeat, whose server stopped
+// without closing the queue. Those that were on their last attempt fail instead.
+func (q *Queue) resumeAbandoned() error {
+	var abandoned []Job
+	if err := q.db.DoQuery(&abandoned, q.db.Builder().
+		Select(jobColumns...).
+		From("LLM_Jobs").
+		Where(sq.Eq{"State": StateRunning}).
+		Where(sq.Lt{"UpdateAt": model.GetMillis() - staleAfter.Milliseconds()})); err != nil {
+		return fmt.Errorf("failed to get abandoned jobs: %w", err)
+	}
+
+	for _, job := range abandoned {
+		state, jobErr := StatePending, ""
+		if job.Attempts >= job.MaxAttempts {
+			state, jobErr = StateFailed, errAbandoned.Error()
+		}
+		result, err := q.db.ExecBuilder(q.db.Builder().Update("LLM_Jobs").
+			Set("State", state).
+			Set("Error", jobErr).
+			Set("UpdateAt", model.GetMillis()).
+			Where(sq.Eq{"ID": job.ID, "State": StateRunning, "UpdateAt": job.UpdateAt}))
+		if err != nil {
+			return fmt.Errorf("failed to resume abandoned job: %w", err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			continue
+		}
+
+		if state == StatePending {
+			q.log.Info("Resuming abandoned job", "job_id", job.ID, "type", job.Type)
+			continue
+		}
+		q.log.Error("Abandoned job failed", "job_id", job.ID, "type", job.Type)
+		q.mu.Lock()
+		handler, ok := q.handlers[job.Type]
+		q.mu.Unlock()
+		if ok && handler.Failed != nil {
+			job.State, job.Error = StateFailed, jobErr
+			handler.Failed(&job, errAbandoned)
+		}
+	}
+	return nil
+}
+
+func (q *Queue) deleteFinished() error {
+	if _, err := q.db.ExecBuilder(q.db.Builder().Delete("LLM_Jobs").
+		Where(sq.Eq{"State": []string{StateSucceeded, StateFailed, StateCancelled}}).
+		Where(sq.Lt{"UpdateAt": model.GetMillis() - finishedJobRetention.Milliseconds()})); err != nil {
+		return fmt.Errorf("failed to delete finished jobs: %w", err)
+	}
+	return nil
+}
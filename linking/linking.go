@@ -0,0 +1,240 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package linking
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxCandidates limits the names looked up for a single response
+const maxCandidates = 50
+
+// Config enables linking the entities mentioned in responses to Mattermost users, channels and ticket trackers.
+type Config struct {
+	Enabled        bool            `json:"enabled"`
+	TicketPatterns []TicketPattern `json:"ticketPatterns"`
+}
+
+// TicketPattern links ticket IDs matching the regular expression to the URL, where {id} is replaced by the ID.
+type TicketPattern struct {
+	Pattern string `json:"pattern"`
+	URL     string `json:"url"`
+}
+
+// Resolver looks up which names belong to existing users and channels.
+type Resolver interface {
+	// ExistingUsernames returns the usernames of active users among the names.
+	ExistingUsernames(names []string) map[string]bool
+
+	// ExistingChannelNames returns the names of public channels among the names.
+	ExistingChannelNames(names []string) map[string]bool
+}
+
+type ticketPattern struct {
+	regex *regexp.Regexp
+	url   string
+}
+
+// Linker rewrites messages so the entities they mention link to Mattermost objects.
+type Linker struct {
+	ticketPatterns []ticketPattern
+}
+
+var (
+	// Code, links and URLs are left as they are
+	protectedRegex = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`|!?\\[[^\\]\n]*\\]\\([^)\n]*\\)|<?https?://[^\\s>]+>?")
+
+	// Names of users and channels, optionally with the prefix people use for them. Usernames can have
+	// dots, dashes and underscores, channel names dashes and underscores, both end with a letter or digit.
+	nameRegex = regexp.MustCompile(`[@~#]?[a-z0-9][a-z0-9._-]*[a-z0-9]`)
+)
+
+// New creates a linker for the configuration, ticket patterns that don't compile or have no URL are skipped.
+func New(config Config) (*Linker, error) {
+	linker := &Linker{}
+	var errs []string
+	for _, pattern := range config.TicketPatterns {
+		if strings.TrimSpace(pattern.Pattern) == "" || strings.TrimSpace(pattern.URL) == "" {
+			continue
+		}
+		regex, err := regexp.Compile(pattern.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid ticket pattern %q: %v", pattern.Pattern, err))
+			continue
+		}
+		linker.ticketPatterns = append(linker.ticketPatterns, ticketPattern{regex: regex, url: strings.TrimSpace(pattern.URL)})
+	}
+	if len(errs) > 0 {
+		return linker, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return linker, nil
+}
+
+// segment is a part of a message, protected segments are never rewritten.
+type segment struct {
+	text      string
+	protected bool
+}
+
+func splitProtected(message string, regex *regexp.Regexp) []segment {
+	var segments []segment
+	last := 0
+	for _, match := range regex.FindAllStringIndex(message, -1) {
+		if match[0] == match[1] {
+			continue
+		}
+		if match[0] > last {
+			segments = append(segments, segment{text: message[last:match[0]]})
+		}
+		segments = append(segments, segment{text: message[match[0]:match[1]], protected: true})
+		last = match[1]
+	}
+	if last < len(message) {
+		segments = append(segments, segment{text: message[last:]})
+	}
+	return segments
+}
+
+// Link rewrites the message: ticket IDs become links to their tracker, usernames become @mentions
+// and channel names become channel links. Code, existing links and URLs are left as they are.
+func (l *Linker) Link(message string, resolver Resolver) string {
+	segments := splitProtected(message, protectedRegex)
+
+	// Ticket IDs first, so their links are protected from the name lookups
+	var linked []segment
+	for _, seg := range segments {
+		if seg.protected {
+			linked = append(linked, seg)
+			continue
+		}
+		linked = append(linked, l.linkTickets(seg.text)...)
+	}
+
+	// Names are looked up all at once
+	var userCandidates, channelCandidates []string
+	seen := map[string]bool{}
+	for _, seg := range linked {
+		if seg.protected {
+			continue
+		}
+		for _, match := range findNames(seg.text) {
+			name := match.name
+			if seen[match.prefix+name] || len(seen) >= maxCandidates {
+				continue
+			}
+			seen[match.prefix+name] = true
+			if match.prefix == "" && isUsernameLike(name) {
+				userCandidates = append(userCandidates, name)
+			}
+			if (match.prefix == "#" || match.prefix == "" && strings.Contains(name, "-")) && !strings.Contains(name, ".") {
+				channelCandidates = append(channelCandidates, name)
+			}
+		}
+	}
+
+	users := map[string]bool{}
+	if len(userCandidates) > 0 {
+		users = resolver.ExistingUsernames(userCandidates)
+	}
+	channels := map[string]bool{}
+	if len(channelCandidates) > 0 {
+		channels = resolver.ExistingChannelNames(channelCandidates)
+	}
+
+	var result strings.Builder
+	for _, seg := range linked {
+		if seg.protected {
+			result.WriteString(seg.text)
+			continue
+		}
+		result.WriteString(linkNames(seg.text, users, channels))
+	}
+	return result.String()
+}
+
+// linkTickets replaces ticket IDs with markdown links, returning the links as protected segments.
+func (l *Linker) linkTickets(text string) []segment {
+	segments := []segment{{text: text}}
+	for _, pattern := range l.ticketPatterns {
+		var next []segment
+		for _, seg := range segments {
+			if seg.protected {
+				next = append(next, seg)
+				continue
+			}
+			for _, part := range splitProtected(seg.text, pattern.regex) {
+				if part.protected {
+					part.text = fmt.Sprintf("[%s](%s)", part.text, strings.ReplaceAll(pattern.url, "{id}", part.text))
+				}
+				next = append(next, part)
+			}
+		}
+		segments = next
+	}
+	return segments
+}
+
+type nameMatch struct {
+	start, end int
+	prefix     string
+	name       string
+}
+
+// findNames finds the words that could be user or channel names. Words that are part of a larger word,
+// a path or an email address are skipped.
+func findNames(text string) []nameMatch {
+	var matches []nameMatch
+	for _, match := range nameRegex.FindAllStringIndex(text, -1) {
+		start, end := match[0], match[1]
+		if start > 0 && isNameByte(text[start-1]) {
+			continue
+		}
+		if end < len(text) && isNameByte(text[end]) {
+			continue
+		}
+
+		word := text[start:end]
+		prefix := ""
+		if strings.ContainsAny(word[:1], "@~#") {
+			prefix = word[:1]
+			word = word[1:]
+		}
+		matches = append(matches, nameMatch{start: start, end: end, prefix: prefix, name: word})
+	}
+	return matches
+}
+
+// isNameByte returns true for characters that continue a name, so matches next to them are part of a longer word.
+func isNameByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '_' || b == '@' || b == '/' || b >= 0x80
+}
+
+// isUsernameLike returns true for names written the way usernames are, with a dot, dash, underscore or digit,
+// so common words that happen to be usernames aren't turned into mentions.
+func isUsernameLike(name string) bool {
+	return strings.ContainsAny(name, "._-0123456789")
+}
+
+func linkNames(text string, users, channels map[string]bool) string {
+	var result strings.Builder
+	last := 0
+	for _, match := range findNames(text) {
+		var replacement string
+		switch {
+		case match.prefix == "" && users[match.name]:
+			replacement = "@" + match.name
+		case (match.prefix == "" || match.prefix == "#") && channels[match.name]:
+			replacement = "~" + match.name
+		default:
+			continue
+		}
+		result.WriteString(text[last:match.start])
+		result.WriteString(replacement)
+		last = match.end
+	}
+	result.WriteString(text[last:])
+	return result.String()
+}
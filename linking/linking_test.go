@@ -0,0 +1,150 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package linking
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testResolver struct {
+	users    []string
+	channels []string
+
+	userLookups    [][]string
+	channelLookups [][]string
+}
+
+func (r *testResolver) ExistingUsernames(names []string) map[string]bool {
+	r.userLookups = append(r.userLookups, names)
+	return existing(names, r.users)
+}
+
+func (r *testResolver) ExistingChannelNames(names []string) map[string]bool {
+	r.channelLookups = append(r.channelLookups, names)
+	return existing(names, r.channels)
+}
+
+func existing(names, known []string) map[string]bool {
+	result := map[string]bool{}
+	for _, name := range names {
+		for _, k := range known {
+			if name == k {
+				result[name] = true
+			}
+		}
+	}
+	return result
+}
+
+func TestLink(t *testing.T) {
+	linker, err := New(Config{
+		Enabled: true,
+		TicketPatterns: []TicketPattern{
+			{Pattern: `\bMM-\d+\b`, URL: "https://tracker.example.com/browse/{id}"},
+			{Pattern: `\bGH#\d+\b`, URL: ""},
+		},
+	})
+	require.NoError(t, err)
+
+	resolver := func() *testResolver {
+		return &testResolver{
+			users:    []string{"john.doe", "jane_smith", "admin2", "team"},
+			channels: []string{"town-square", "dev-ops"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "usernames",
+			message: "Ask john.doe or jane_smith.",
+			want:    "Ask @john.doe or @jane_smith.",
+		},
+		{
+			name:    "common words aren't usernames",
+			message: "The team agreed.",
+			want:    "The team agreed.",
+		},
+		{
+			name:    "unknown names",
+			message: "Ask jim.beam about follow-up items.",
+			want:    "Ask jim.beam about follow-up items.",
+		},
+		{
+			name:    "channel names",
+			message: "Discussed in town-square and #dev-ops",
+			want:    "Discussed in ~town-square and ~dev-ops",
+		},
+		{
+			name:    "existing mentions and channel links",
+			message: "@john.doe posted in ~town-square",
+			want:    "@john.doe posted in ~town-square",
+		},
+		{
+			name:    "tickets",
+			message: "Fixed by MM-1234 (see also MM-99).",
+			want:    "Fixed by [MM-1234](https://tracker.example.com/browse/MM-1234) (see also [MM-99](https://tracker.example.com/browse/MM-99)).",
+		},
+		{
+			name:    "patterns without a URL are skipped",
+			message: "Tracked in GH#12",
+			want:    "Tracked in GH#12",
+		},
+		{
+			name:    "code is left as is",
+			message: "Run `john.doe` then\n```\ntown-square MM-1\n```\nfor admin2",
+			want:    "Run `john.doe` then\n```\ntown-square MM-1\n```\nfor @admin2",
+		},
+		{
+			name:    "links and URLs are left as is",
+			message: "[john.doe](https://example.com/john.doe) and https://example.com/town-square/MM-1",
+			want:    "[john.doe](https://example.com/john.doe) and https://example.com/town-square/MM-1",
+		},
+		{
+			name:    "emails, paths and longer words are left as is",
+			message: "Mail john.doe@example.com, open /town-square or Dev-ops",
+			want:    "Mail john.doe@example.com, open /town-square or Dev-ops",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, linker.Link(tc.message, resolver()))
+		})
+	}
+}
+
+func TestLinkLooksUpNamesOnce(t *testing.T) {
+	linker, err := New(Config{Enabled: true})
+	require.NoError(t, err)
+
+	resolver := &testResolver{users: []string{"john.doe"}}
+	assert.Equal(t, "@john.doe, @john.doe and `john.doe`", linker.Link("john.doe, john.doe and `john.doe`", resolver))
+	assert.Equal(t, [][]string{{"john.doe"}}, resolver.userLookups)
+	assert.Empty(t, resolver.channelLookups)
+
+	resolver = &testResolver{}
+	assert.Equal(t, "Nothing to link here", linker.Link("Nothing to link here", resolver))
+	assert.Empty(t, resolver.userLookups)
+	assert.Empty(t, resolver.channelLookups)
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	linker, err := New(Config{
+		Enabled: true,
+		TicketPatterns: []TicketPattern{
+			{Pattern: `MM-(\d+`, URL: "https://tracker.example.com/{id}"},
+			{Pattern: `ABC-\d+`, URL: "https://tracker.example.com/{id}"},
+		},
+	})
+	require.Error(t, err)
+	require.NotNil(t, linker)
+	assert.Equal(t, "[ABC-1](https://tracker.example.com/ABC-1)", linker.Link("ABC-1", &testResolver{}))
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package linking
+
+import (
+	"sync/atomic"
+
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+// maxTeams limits the teams searched for channel names when a response isn't posted in a team
+const maxTeams = 5
+
+// Processor links the entities in completed responses, following the plugin configuration.
+type Processor struct {
+	pluginAPI *pluginapi.Client
+	linker    atomic.Pointer[Linker]
+}
+
+// NewProcessor creates a processor, it does nothing until configured.
+func NewProcessor(pluginAPI *pluginapi.Client) *Processor {
+	return &Processor{pluginAPI: pluginAPI}
+}
+
+// Configure applies the configuration, disabling linking when it isn't enabled.
+func (p *Processor) Configure(config Config) {
+	if !config.Enabled {
+		p.linker.Store(nil)
+		return
+	}
+
+	linker, err := New(config)
+	if err != nil {
+		p.pluginAPI.Log.Warn("Some entity linking ticket patterns are invalid and were skipped", "error", err)
+	}
+	p.linker.Store(linker)
+}
+
+// Process links the entities in the message of a response post. It matches streaming.MessageProcessor.
+func (p *Processor) Process(post *model.Post, message string) string {
+	linker := p.linker.Load()
+	if linker == nil {
+		return message
+	}
+
+	return linker.Link(message, &postResolver{
+		pluginAPI: p.pluginAPI,
+		post:      post,
+	})
+}
+
+// postResolver resolves names for a post: channels are looked up in the post's team,
+// or in the requester's teams for direct messages.
+type postResolver struct {
+	pluginAPI *pluginapi.Client
+	post      *model.Post
+}
+
+func (r *postResolver) ExistingUsernames(names []string) map[string]bool {
+	existing := map[string]bool{}
+	users, err := r.pluginAPI.User.ListByUsernames(names)
+	if err != nil {
+		r.pluginAPI.Log.Debug("Unable to look up usernames for entity linking", "error", err)
+		return existing
+	}
+	for _, user := range users {
+		if user.DeleteAt == 0 {
+			existing[user.Username] = true
+		}
+	}
+	return existing
+}
+
+func (r *postResolver) ExistingChannelNames(names []string) map[string]bool {
+	existing := map[string]bool{}
+	teamIDs := r.teamIDs()
+	for _, name := range names {
+		for _, teamID := range teamIDs {
+			channel, err := r.pluginAPI.Channel.GetByName(teamID, name, false)
+			if err != nil {
+				continue
+			}
+			// Private channel names aren't linked since not everyone in the conversation can see them
+			if channel.Type == model.ChannelTypeOpen {
+				existing[name] = true
+				break
+			}
+		}
+	}
+	return existing
+}
+
+func (r *postResolver) teamIDs() []string {
+	channel, err := r.pluginAPI.Channel.Get(r.post.ChannelId)
+	if err != nil {
+		r.pluginAPI.Log.Debug("Unable to get channel for entity linking", "error", err)
+		return nil
+	}
+	if channel.TeamId != "" {
+		return []string{channel.TeamId}
+	}
+
+	requesterID, _ := r.post.GetProp(streaming.LLMRequesterUserID).(string)
+	if requesterID == "" {
+		return nil
+	}
+	teams, err := r.pluginAPI.Team.List(pluginapi.FilterTeamsByUser(requesterID))
+	if err != nil {
+		r.pluginAPI.Log.Debug("Unable to get teams for entity linking", "error", err)
+		return nil
+	}
+	teamIDs := make([]string, 0, len(teams))
+	for _, team := range teams {
+		if len(teamIDs) == maxTeams {
+			break
+		}
+		teamIDs = append(teamIDs, team.Id)
+	}
+	return teamIDs
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"strings"
+)
+
+// MiddlewarePriorityCapabilities places capability checks inside the experiment,
+// so they apply to the model that is actually called.
+const MiddlewarePriorityCapabilities = 700
+
+// Capabilities describes what a model supports.
+type Capabilities struct {
+	Tools      bool
+	Vision     bool
+	JSONOutput bool
+
+	// MaxContextTokens is zero when the context size of the model is unknown.
+	MaxContextTokens int
+}
+
+// intersect returns the capabilities supported by both.
+func (c Capabilities) intersect(other Capabilities) Capabilities {
+	return Capabilities{
+		Tools:            c.Tools && other.Tools,
+		Vision:           c.Vision && other.Vision,
+		JSONOutput:       c.JSONOutput && other.JSONOutput,
+		MaxContextTokens: c.MaxContextTokens,
+	}
+}
+
+// serviceCapabilities are the features each service integration can use.
+// Models of unknown services or unlisted models are assumed to support all of them.
+var serviceCapabilities = map[string]Capabilities{
+	ServiceTypeOpenAI:           {Tools: true, Vision: true, JSONOutput: true},
+	ServiceTypeOpenAICompatible: {Tools: true, Vision: true, JSONOutput: true},
+	ServiceTypeAzure:            {Tools: true, Vision: true, JSONOutput: true},
+	ServiceTypeAnthropic:        {Tools: true, Vision: true},
+	ServiceTypeVLLM:             {Tools: true, Vision: true, JSONOutput: true},
+	ServiceTypeASage:            {},
+}
+
+// modelCapabilities are the capabilities of known models, matched by the longest prefix of the model name.
+var modelCapabilities = map[string]Capabilities{
+	"gpt-3.5-turbo":          {Tools: true, JSONOutput: true, MaxContextTokens: 16385},
+	"gpt-3.5-turbo-instruct": {MaxContextTokens: 4096},
+	"gpt-4":                  {Tools: true, MaxContextTokens: 8192},
+	"gpt-4-0125-preview":     {Tools: true, JSONOutput: true, MaxContextTokens: 128000},
+	"gpt-4-1106-preview":     {Tools: true, JSONOutput: true, MaxContextTokens: 128000},
+	"gpt-4-turbo":            {Tools: true, Vision: true, JSONOutput: true, MaxContextTokens: 128000},
+	"gpt-4o":                 {Tools: true, Vision: true, JSONOutput: true, MaxContextTokens: 128000},
+	"gpt-4.1":                {Tools: true, Vision: true, JSONOutput: true, MaxContextTokens: 1047576},
+	"o1-preview":             {MaxContextTokens: 128000},
+	"o1-mini":                {MaxContextTokens: 128000},
+	"o3-mini":                {Tools: true, JSONOutput: true, MaxContextTokens: 200000},
+	"o4-mini":                {Tools: true, Vision: true, JSONOutput: true, MaxContextTokens: 200000},
+	"claude-2":               {MaxContextTokens: 100000},
+	"claude-3":               {Tools: true, Vision: true, MaxContextTokens: 200000},
+	"claude-sonnet-4":        {Tools: true, Vision: true, MaxContextTokens: 200000},
+	"claude-opus-4":          {Tools: true, Vision: true, MaxContextTokens: 200000},
+	"deepseek-reasoner":      {MaxContextTokens: 64000},
+}
+
+// LookupCapabilities returns the capabilities of a model used through the given service type.
+func LookupCapabilities(serviceType string, model string) Capabilities {
+	result := Capabilities{Tools: true, Vision: true, JSONOutput: true}
+
+	// Fine-tuned models have the capabilities of the model they are based on
+	model = strings.TrimPrefix(strings.ToLower(model), "ft:")
+	bestPrefix := ""
+	for prefix := range modelCapabilities {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(bestPrefix) {
+			bestPrefix = prefix
+		}
+	}
+	if bestPrefix != "" {
+		result = modelCapabilities[bestPrefix]
+	}
+
+	if service, ok := serviceCapabilities[serviceType]; ok {
+		result = result.intersect(service)
+	}
+
+	return result
+}
+
+// Capabilities returns the features the bot can use, combining the capabilities
+// of its default model with the bot's configuration.
+func (c BotConfig) Capabilities() Capabilities {
+	_, baseModel := c.Service.ResolveModel(c.Service.DefaultModel)
+	result := LookupCapabilities(c.Service.Type, baseModel)
+	result.Tools = result.Tools && !c.DisableTools
+	result.Vision = result.Vision && c.EnableVision
+	if c.Service.InputTokenLimit > 0 {
+		result.MaxContextTokens = c.Service.InputTokenLimit
+	}
+	return result
+}
+
+// CapabilitiesMiddleware removes the parts of requests the called model doesn't support,
+// so features degrade instead of failing with provider errors.
+// defaultModel is used when no model is chosen by the request options.
+func CapabilitiesMiddleware(serviceType string, defaultModel string) Middleware {
+	degrade := func(request CompletionRequest, opts []LanguageModelOption) (CompletionRequest, []LanguageModelOption) {
+		cfg := LanguageModelConfig{Model: defaultModel}
+		for _, opt := range opts {
+			opt(&cfg)
+		}
+		capabilities := LookupCapabilities(serviceType, cfg.Model)
+		return degradeRequest(request, opts, cfg, capabilities)
+	}
+
+	return Interceptor{
+		ChatCompletion: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+			request, opts = degrade(request, opts)
+			return next.ChatCompletion(request, opts...)
+		},
+		ChatCompletionNoStream: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+			request, opts = degrade(request, opts)
+			return next.ChatCompletionNoStream(request, opts...)
+		},
+	}.Middleware()
+}
+
+func degradeRequest(request CompletionRequest, opts []LanguageModelOption, cfg LanguageModelConfig, capabilities Capabilities) (CompletionRequest, []LanguageModelOption) {
+	if !capabilities.Tools {
+		request = withoutTools(request)
+	}
+
+	if !capabilities.Vision {
+		var posts []Post
+		for i, post := range request.Posts {
+			if len(post.Files) == 0 {
+				continue
+			}
+			if posts == nil {
+				posts = append([]Post{}, request.Posts...)
+			}
+			posts[i].Files = nil
+		}
+		if posts != nil {
+			request.Posts = posts
+		}
+	}
+
+	if !capabilities.JSONOutput && cfg.JSONOutputFormat != nil {
+		opts = append(append([]LanguageModelOption{}, opts...), func(cfg *LanguageModelConfig) {
+			cfg.JSONOutputFormat = nil
+		})
+	}
+
+	return request, opts
+}
+
+// withoutTools returns the request with no tools available, leaving the caller's context untouched.
+func withoutTools(request CompletionRequest) CompletionRequest {
+	if request.Context == nil || request.Context.Tools == nil {
+		return request
+	}
+	context := *request.Context
+	context.Tools = nil
+	request.Context = &context
+	return request
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCapabilities(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceType string
+		model       string
+		expected    Capabilities
+	}{
+		{
+			name:        "known model",
+			serviceType: ServiceTypeOpenAI,
+			model:       "gpt-4o-mini",
+			expected:    Capabilities{Tools: true, Vision: true, JSONOutput: true, MaxContextTokens: 128000},
+		},
+		{
+			name:        "longest prefix wins",
+			serviceType: ServiceTypeOpenAI,
+			model:       "gpt-4.1-mini",
+			expected:    Capabilities{Tools: true, Vision: true, JSONOutput: true, MaxContextTokens: 1047576},
+		},
+		{
+			name:        "model without tools",
+			serviceType: ServiceTypeOpenAI,
+			model:       "o1-mini",
+			expected:    Capabilities{MaxContextTokens: 128000},
+		},
+		{
+			name:        "service limits the model",
+			serviceType: ServiceTypeAnthropic,
+			model:       "claude-3-7-sonnet-latest",
+			expected:    Capabilities{Tools: true, Vision: true, MaxContextTokens: 200000},
+		},
+		{
+			name:        "unknown model is assumed capable",
+			serviceType: ServiceTypeOpenAICompatible,
+			model:       "my-local-model",
+			expected:    Capabilities{Tools: true, Vision: true, JSONOutput: true},
+		},
+		{
+			name:        "service without features",
+			serviceType: ServiceTypeASage,
+			model:       "gpt-4o",
+			expected:    Capabilities{MaxContextTokens: 128000},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, LookupCapabilities(tc.serviceType, tc.model))
+		})
+	}
+}
+
+func TestBotConfigCapabilities(t *testing.T) {
+	bot := BotConfig{
+		Service: ServiceConfig{
+			Type:            ServiceTypeOpenAI,
+			DefaultModel:    "gpt-4o",
+			InputTokenLimit: 50000,
+		},
+		DisableTools: true,
+		EnableVision: false,
+	}
+
+	assert.Equal(t, Capabilities{JSONOutput: true, MaxContextTokens: 50000}, bot.Capabilities())
+}
+
+// recordingModel records the last request and options it received.
+type recordingModel struct {
+	stubModel
+	request CompletionRequest
+	config  LanguageModelConfig
+}
+
+func (r *recordingModel) ChatCompletionNoStream(request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	r.request = request
+	r.config = LanguageModelConfig{}
+	for _, opt := range opts {
+		opt(&r.config)
+	}
+	return "", nil
+}
+
+func TestCapabilitiesMiddleware(t *testing.T) {
+	tools := NewNoTools()
+	newRequest := func() CompletionRequest {
+		return CompletionRequest{
+			Posts: []Post{
+				{Role: PostRoleSystem, Message: "system"},
+				{Role: PostRoleUser, Message: "look", Files: []File{{MimeType: "image/png"}}},
+			},
+			Context: &Context{Tools: tools},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		defaultModel string
+		opts         []LanguageModelOption
+		expectTools  bool
+		expectFiles  bool
+		expectJSON   bool
+	}{
+		{
+			name:         "capable model is unchanged",
+			defaultModel: "gpt-4o",
+			opts:         []LanguageModelOption{WithJSONOutput(&struct{}{})},
+			expectTools:  true,
+			expectFiles:  true,
+			expectJSON:   true,
+		},
+		{
+			name:         "features removed for limited model",
+			defaultModel: "o1-mini",
+			opts:         []LanguageModelOption{WithJSONOutput(&struct{}{})},
+		},
+		{
+			name:         "model chosen by options is checked",
+			defaultModel: "gpt-4o",
+			opts:         []LanguageModelOption{WithJSONOutput(&struct{}{}), WithModel("o1-preview")},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			next := &recordingModel{}
+			model := CapabilitiesMiddleware(ServiceTypeOpenAI, tc.defaultModel)(next)
+
+			request := newRequest()
+			_, err := model.ChatCompletionNoStream(request, tc.opts...)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expectTools, next.request.Context.Tools != nil)
+			assert.Equal(t, tc.expectFiles, len(next.request.Posts[1].Files) > 0)
+			assert.Equal(t, tc.expectJSON, next.config.JSONOutputFormat != nil)
+
+			// The caller's request is not modified
+			assert.NotNil(t, request.Context.Tools)
+			assert.Len(t, request.Posts[1].Files, 1)
+		})
+	}
+}
@@ -29,11 +29,33 @@ type Post struct {
 	Message string
 	Files   []File
 	ToolUse []ToolCall
+
+	// Pinned posts are kept when the conversation is truncated to fit the token limit
+	Pinned bool
 }
 
 type CompletionRequest struct {
 	Posts   []Post
 	Context *Context
+
+	// StopSequences end the generation as soon as the model outputs one of them.
+	// The stop sequence itself is not included in the result.
+	StopSequences []string
+}
+
+// TrimAtStopSequence cuts text at the first occurrence of any of the stop sequences.
+// It is used by providers without native stop sequence support.
+func TrimAtStopSequence(text string, stopSequences []string) string {
+	end := len(text)
+	for _, stop := range stopSequences {
+		if stop == "" {
+			continue
+		}
+		if index := strings.Index(text[:end], stop); index >= 0 {
+			end = index
+		}
+	}
+	return text[:end]
 }
 
 func (b *CompletionRequest) Truncate(maxTokens int, countTokens func(string) int) bool {
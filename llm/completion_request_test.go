@@ -4,6 +4,7 @@
 package llm
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -90,3 +91,117 @@ func TestCompletionRequestTruncate(t *testing.T) {
 		assert.LessOrEqual(t, tokenCount, 20, "Truncated message should be within token limit")
 	})
 }
+
+func TestTrimAtStopSequence(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		stopSequences []string
+		want          string
+	}{
+		{name: "no stop sequences", text: "one\ntwo", want: "one\ntwo"},
+		{name: "not found", text: "one two", stopSequences: []string{"\n"}, want: "one two"},
+		{name: "cut at stop sequence", text: "one\ntwo", stopSequences: []string{"\n"}, want: "one"},
+		{name: "earliest stop sequence wins", text: "one two\nthree", stopSequences: []string{"\n", " "}, want: "one"},
+		{name: "empty stop sequence ignored", text: "one", stopSequences: []string{""}, want: "one"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, TrimAtStopSequence(tc.text, tc.stopSequences))
+		})
+	}
+}
+
+func TestTextStreamResultStopAt(t *testing.T) {
+	streamOf := func(chunks ...string) *TextStreamResult {
+		stream := make(chan TextStreamEvent)
+		go func() {
+			defer close(stream)
+			for _, chunk := range chunks {
+				stream <- TextStreamEvent{Type: EventTypeText, Value: chunk}
+			}
+			stream <- TextStreamEvent{Type: EventTypeEnd}
+		}()
+		return &TextStreamResult{Stream: stream}
+	}
+
+	tests := []struct {
+		name          string
+		chunks        []string
+		stopSequences []string
+		want          string
+	}{
+		{name: "no stop sequence in text", chunks: []string{"hello ", "world"}, stopSequences: []string{"END"}, want: "hello world"},
+		{name: "stop sequence in a chunk", chunks: []string{"hello\nworld", "more"}, stopSequences: []string{"\n"}, want: "hello"},
+		{name: "stop sequence split across chunks", chunks: []string{"hello E", "ND world"}, stopSequences: []string{"END"}, want: "hello "},
+		{name: "partial match is released", chunks: []string{"hello E", "ngland"}, stopSequences: []string{"END"}, want: "hello England"},
+		{name: "multibyte text", chunks: []string{"héllo wörld"}, stopSequences: []string{"END"}, want: "héllo wörld"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := streamOf(tc.chunks...).StopAt(tc.stopSequences).ReadAll()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, result)
+		})
+	}
+}
+
+func TestTextStreamResultAppend(t *testing.T) {
+	failed := errors.New("failed")
+	toolCalls := []ToolCall{{ID: "1"}}
+
+	tests := []struct {
+		name   string
+		text   string
+		events []TextStreamEvent
+		want   []TextStreamEvent
+	}{
+		{
+			name:   "appended before the end",
+			text:   " and more",
+			events: []TextStreamEvent{{Type: EventTypeText, Value: "text"}, {Type: EventTypeEnd}},
+			want:   []TextStreamEvent{{Type: EventTypeText, Value: "text"}, {Type: EventTypeText, Value: " and more"}, {Type: EventTypeEnd}},
+		},
+		{
+			name:   "nothing to append",
+			events: []TextStreamEvent{{Type: EventTypeText, Value: "text"}, {Type: EventTypeEnd}},
+			want:   []TextStreamEvent{{Type: EventTypeText, Value: "text"}, {Type: EventTypeEnd}},
+		},
+		{
+			name:   "failed stream",
+			text:   " and more",
+			events: []TextStreamEvent{{Type: EventTypeText, Value: "text"}, {Type: EventTypeError, Value: failed}},
+			want:   []TextStreamEvent{{Type: EventTypeText, Value: "text"}, {Type: EventTypeError, Value: failed}},
+		},
+		{
+			name:   "tool calls requested",
+			text:   " and more",
+			events: []TextStreamEvent{{Type: EventTypeToolCalls, Value: toolCalls}},
+			want:   []TextStreamEvent{{Type: EventTypeToolCalls, Value: toolCalls}},
+		},
+		{
+			name:   "closed without an end event",
+			text:   " and more",
+			events: []TextStreamEvent{{Type: EventTypeText, Value: "text"}},
+			want:   []TextStreamEvent{{Type: EventTypeText, Value: "text"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := make(chan TextStreamEvent, len(tc.events))
+			for _, event := range tc.events {
+				stream <- event
+			}
+			close(stream)
+
+			var got []TextStreamEvent
+			for event := range (&TextStreamResult{Stream: stream}).Append(tc.text).Stream {
+				got = append(got, event)
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
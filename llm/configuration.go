@@ -3,6 +3,8 @@
 
 package llm
 
+import "strings"
+
 type ServiceConfig struct {
 	Name         string `json:"name"`
 	Type         string `json:"type"`
@@ -18,6 +20,42 @@ type ServiceConfig struct {
 
 	// Otherwise known as maxTokens
 	OutputTokenLimit int `json:"outputTokenLimit"`
+
+	// Prices in US dollars used for cost estimates, overriding the built in price list.
+	// Token prices are per million tokens.
+	InputTokenPrice             float64 `json:"inputTokenPrice"`
+	OutputTokenPrice            float64 `json:"outputTokenPrice"`
+	TranscriptionPricePerMinute float64 `json:"transcriptionPricePerMinute"`
+
+	// ModelPrices are the prices of other models of the service, such as alternate or feature models
+	ModelPrices []ModelPriceConfig `json:"modelPrices"`
+
+	// ModelAliases name fine-tuned models so they can be used as the default or alternate model
+	ModelAliases []ModelAlias `json:"modelAliases"`
+
+	// ReplicaURLs are the vLLM servers requests are balanced between, in addition to the API URL
+	ReplicaURLs                []string `json:"replicaURLs"`
+	HealthCheckIntervalSeconds int      `json:"healthCheckIntervalSeconds"`
+
+	// Regions are other endpoints of the service requests are routed between with RegionRouting,
+	// failing over from one to the next
+	Regions       []ServiceRegion `json:"regions"`
+	RegionRouting string          `json:"regionRouting"`
+}
+
+// ReplicaAPIURLs returns the API URL followed by the additional replica URLs, without duplicates.
+func (c ServiceConfig) ReplicaAPIURLs() []string {
+	var urls []string
+	seen := map[string]bool{}
+	for _, url := range append([]string{c.APIURL}, c.ReplicaURLs...) {
+		url = strings.TrimSuffix(strings.TrimSpace(url), "/")
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		urls = append(urls, url)
+	}
+	return urls
 }
 
 type ChannelAccessLevel int
@@ -39,19 +77,41 @@ const (
 )
 
 type BotConfig struct {
-	ID                 string             `json:"id"`
-	Name               string             `json:"name"`
-	DisplayName        string             `json:"displayName"`
-	CustomInstructions string             `json:"customInstructions"`
-	Service            ServiceConfig      `json:"service"`
-	EnableVision       bool               `json:"enableVision"`
-	DisableTools       bool               `json:"disableTools"`
-	ChannelAccessLevel ChannelAccessLevel `json:"channelAccessLevel"`
-	ChannelIDs         []string           `json:"channelIDs"`
-	UserAccessLevel    UserAccessLevel    `json:"userAccessLevel"`
-	UserIDs            []string           `json:"userIDs"`
-	TeamIDs            []string           `json:"teamIDs"`
-	MaxFileSize        int64              `json:"maxFileSize"`
+	ID                 string              `json:"id"`
+	Name               string              `json:"name"`
+	DisplayName        string              `json:"displayName"`
+	CustomInstructions string              `json:"customInstructions"`
+	Service            ServiceConfig       `json:"service"`
+	EnableVision       bool                `json:"enableVision"`
+	DisableTools       bool                `json:"disableTools"`
+	ChannelAccessLevel ChannelAccessLevel  `json:"channelAccessLevel"`
+	ChannelIDs         []string            `json:"channelIDs"`
+	UserAccessLevel    UserAccessLevel     `json:"userAccessLevel"`
+	UserIDs            []string            `json:"userIDs"`
+	TeamIDs            []string            `json:"teamIDs"`
+	MaxFileSize        int64               `json:"maxFileSize"`
+	Experiment         ExperimentConfig    `json:"experiment"`
+	Ensemble           EnsembleConfig      `json:"ensemble"`
+	Handoff            HandoffConfig       `json:"handoff"`
+	FeatureModels      FeatureModelsConfig `json:"featureModels"`
+
+	// HistoryRelevanceFiltering drops the history least relevant to the latest question first, rather
+	// than the oldest, when a conversation doesn't fit the context window. It requires embedding search.
+	HistoryRelevanceFiltering bool `json:"historyRelevanceFiltering"`
+}
+
+// HandoffConfig lets users of a bot hand a DM conversation over to people in a support channel.
+type HandoffConfig struct {
+	Enabled   bool   `json:"enabled"`
+	ChannelID string `json:"channelID"`
+
+	// OnCallUserIDs are mentioned when a conversation is handed off.
+	OnCallUserIDs []string `json:"onCallUserIDs"`
+}
+
+// IsActive returns true if conversations can be handed off.
+func (c HandoffConfig) IsActive() bool {
+	return c.Enabled && c.ChannelID != ""
 }
 
 func (c *BotConfig) IsValid() bool {
@@ -68,12 +128,21 @@ func (c *BotConfig) IsValid() bool {
 		return false
 	}
 
+	if c.Service.ValidateModelAliases() != nil {
+		return false
+	}
+	if c.Service.ValidateRegions() != nil {
+		return false
+	}
+
 	// Service-specific validation
 	switch c.Service.Type {
 	case ServiceTypeOpenAI:
 		return c.Service.APIKey != ""
 	case ServiceTypeOpenAICompatible:
 		return c.Service.APIURL != ""
+	case ServiceTypeVLLM:
+		return len(c.Service.ReplicaAPIURLs()) > 0
 	case ServiceTypeAzure:
 		return c.Service.APIKey != "" && c.Service.APIURL != ""
 	case ServiceTypeAnthropic:
@@ -5,6 +5,7 @@ package llm
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -27,10 +28,25 @@ type Context struct {
 	// User that is making the request
 	RequestingUser *model.User
 
+	// UserMemories are the facts remembered about the requesting user in previous conversations
+	UserMemories []string
+
 	// Bot Specific
 	BotName            string
+	BotID              string
 	CustomInstructions string
 
+	// PersonaInstructions is the system prompt of the persona the conversation is conducted in
+	PersonaInstructions string
+
+	// RegenerationInstruction is how the user asked for the response being regenerated to change,
+	// such as "shorter" or "as bullet points". Empty when it isn't being regenerated with one.
+	RegenerationInstruction string
+
+	// ReconnectedToolServers are the servers that had to be connected to again while resolving tools,
+	// the calls to them being retried once reconnected
+	ReconnectedToolServers []string
+
 	Tools      *ToolStore
 	Parameters map[string]interface{}
 }
@@ -51,6 +67,14 @@ func NewContext(opts ...ContextOption) *Context {
 	return c
 }
 
+// AddReconnectedToolServer records that the connection to a tool server dropped and was made again
+// while resolving a tool, once per server.
+func (c *Context) AddReconnectedToolServer(serverID string) {
+	if !slices.Contains(c.ReconnectedToolServers, serverID) {
+		c.ReconnectedToolServers = append(c.ReconnectedToolServers, serverID)
+	}
+}
+
 func (c Context) String() string {
 	var result strings.Builder
 	result.WriteString(fmt.Sprintf("Time: %v\nServerName: %v\nCompanyName: %v", c.Time, c.ServerName, c.CompanyName))
@@ -0,0 +1,159 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// EnsembleProp is set on responses reconciled from more than one answer.
+const EnsembleProp = "llm_ensemble"
+
+// MiddlewarePriorityEnsemble places the ensemble outside the experiment,
+// so every answer and the judge pass go through the rest of the bot's chain.
+const MiddlewarePriorityEnsemble = 300
+
+// EnsembleConfig has a question answered twice and a judge pass reconcile the answers
+// and flag disagreements, for conversations where mistakes are costly.
+type EnsembleConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// SecondaryBot is the bot whose model gives the second answer.
+	// When empty the bot answers twice and the answers are checked for consistency.
+	SecondaryBot string `json:"secondaryBot"`
+
+	// ChannelIDs limits the ensemble to these channels, it applies everywhere when empty.
+	ChannelIDs []string `json:"channelIDs"`
+}
+
+// AppliesTo returns true if the ensemble should answer the request.
+func (c EnsembleConfig) AppliesTo(request CompletionRequest) bool {
+	if !c.Enabled {
+		return false
+	}
+	if len(c.ChannelIDs) == 0 {
+		return true
+	}
+	if request.Context == nil || request.Context.Channel == nil {
+		return false
+	}
+	return slices.Contains(c.ChannelIDs, request.Context.Channel.Id)
+}
+
+// EnsembleJudge creates the request that reconciles the answers to the original request.
+type EnsembleJudge func(request CompletionRequest, answers []string) (CompletionRequest, error)
+
+// NewPromptEnsembleJudge creates an EnsembleJudge that replaces the system prompt with the given template.
+// The template receives the original system prompt as Instructions and the answers as AnswerA and AnswerB.
+func NewPromptEnsembleJudge(prompts *Prompts, templateName string) EnsembleJudge {
+	return func(request CompletionRequest, answers []string) (CompletionRequest, error) {
+		if len(answers) != 2 {
+			return CompletionRequest{}, fmt.Errorf("expected 2 answers, got %d", len(answers))
+		}
+
+		instructions := ""
+		posts := make([]Post, 0, len(request.Posts))
+		for _, post := range request.Posts {
+			if post.Role == PostRoleSystem {
+				instructions += post.Message + "\n"
+				continue
+			}
+			posts = append(posts, post)
+		}
+
+		context := NewContext()
+		if request.Context != nil {
+			copied := *request.Context
+			context = &copied
+		}
+		context.Parameters = map[string]any{
+			"Instructions": instructions,
+			"AnswerA":      answers[0],
+			"AnswerB":      answers[1],
+		}
+		systemMessage, err := prompts.Format(templateName, context)
+		if err != nil {
+			return CompletionRequest{}, fmt.Errorf("failed to format ensemble judge prompt: %w", err)
+		}
+
+		request.Posts = append([]Post{{Role: PostRoleSystem, Message: systemMessage}}, posts...)
+		return request, nil
+	}
+}
+
+// EnsembleMiddleware creates a Middleware that answers requests the ensemble applies to with both
+// the wrapped model and the secondary model, then streams the judge's reconciliation of the answers.
+// When secondary returns nil the wrapped model answers twice.
+// Only streamed responses are reconciled, requests without streaming are internal ones such as titles.
+// Tools aren't offered to ensemble answers since their calls can't be reconciled.
+func EnsembleMiddleware(cfg EnsembleConfig, secondary func() LanguageModel, judge EnsembleJudge) Middleware {
+	answer := func(next LanguageModel, request CompletionRequest, opts []LanguageModelOption) ([]string, error) {
+		request = withoutTools(request)
+
+		// Options such as the model only apply to the bot's own model
+		secondModel, secondOpts := secondary(), []LanguageModelOption(nil)
+		if secondModel == nil {
+			secondModel, secondOpts = next, opts
+		}
+
+		answers := make([]string, 2)
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			answers[0], errs[0] = next.ChatCompletionNoStream(request, opts...)
+		}()
+		go func() {
+			defer wg.Done()
+			answers[1], errs[1] = secondModel.ChatCompletionNoStream(request, secondOpts...)
+		}()
+		wg.Wait()
+
+		if errs[0] != nil && errs[1] != nil {
+			return nil, fmt.Errorf("failed to get ensemble answers: %w", errors.Join(errs...))
+		}
+
+		// A single answer is used as is
+		if errs[0] != nil {
+			return answers[1:], nil
+		}
+		if errs[1] != nil {
+			return answers[:1], nil
+		}
+		return answers, nil
+	}
+
+	reconcile := func(next LanguageModel, request CompletionRequest, opts []LanguageModelOption) (*TextStreamResult, error) {
+		answers, err := answer(next, request, opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(answers) == 1 {
+			return NewStreamFromString(answers[0]), nil
+		}
+
+		judgeRequest, err := judge(withoutTools(request), answers)
+		if err != nil {
+			return nil, err
+		}
+		result, err := next.ChatCompletion(judgeRequest, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return result.WithProps(map[string]any{EnsembleProp: true}), nil
+	}
+
+	return Interceptor{
+		ChatCompletion: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+			if !cfg.AppliesTo(request) {
+				return next.ChatCompletion(request, opts...)
+			}
+			return reconcile(next, request, opts)
+		},
+	}.Middleware()
+}
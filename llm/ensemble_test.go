@@ -0,0 +1,116 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// answeringModel answers with a fixed message, or echoes the system prompt when streaming.
+type answeringModel struct {
+	stubModel
+	answer string
+	err    error
+}
+
+func (a *answeringModel) ChatCompletion(request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	return NewStreamFromString(request.Posts[0].Message), nil
+}
+
+func (a *answeringModel) ChatCompletionNoStream(request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	return a.answer, a.err
+}
+
+func TestEnsembleMiddleware(t *testing.T) {
+	prompts, err := NewPrompts(fstest.MapFS{
+		"judge.tmpl": {Data: []byte("{{.Parameters.Instructions}}A: {{.Parameters.AnswerA}} B: {{.Parameters.AnswerB}}")},
+	})
+	require.NoError(t, err)
+	judge := NewPromptEnsembleJudge(prompts, "judge")
+
+	request := CompletionRequest{
+		Posts: []Post{
+			{Role: PostRoleSystem, Message: "Be precise."},
+			{Role: PostRoleUser, Message: "What is the notice period?"},
+		},
+		Context: &Context{Channel: &model.Channel{Id: "legal"}, Tools: NewNoTools()},
+	}
+
+	tests := []struct {
+		name      string
+		cfg       EnsembleConfig
+		primary   *answeringModel
+		secondary *answeringModel
+		expected  string
+		ensemble  bool
+		wantErr   bool
+	}{
+		{
+			name:      "answers reconciled by the judge",
+			cfg:       EnsembleConfig{Enabled: true},
+			primary:   &answeringModel{answer: "30 days"},
+			secondary: &answeringModel{answer: "60 days"},
+			expected:  "Be precise.\nA: 30 days B: 60 days",
+			ensemble:  true,
+		},
+		{
+			name:     "self-consistency without a secondary model",
+			cfg:      EnsembleConfig{Enabled: true},
+			primary:  &answeringModel{answer: "30 days"},
+			expected: "Be precise.\nA: 30 days B: 30 days",
+			ensemble: true,
+		},
+		{
+			name:     "other channels are answered directly",
+			cfg:      EnsembleConfig{Enabled: true, ChannelIDs: []string{"security"}},
+			primary:  &answeringModel{answer: "30 days"},
+			expected: "Be precise.",
+		},
+		{
+			name:      "single answer used when the other fails",
+			cfg:       EnsembleConfig{Enabled: true, ChannelIDs: []string{"legal"}},
+			primary:   &answeringModel{answer: "30 days"},
+			secondary: &answeringModel{err: errors.New("unavailable")},
+			expected:  "30 days",
+		},
+		{
+			name:      "error when both fail",
+			cfg:       EnsembleConfig{Enabled: true},
+			primary:   &answeringModel{err: errors.New("unavailable")},
+			secondary: &answeringModel{err: errors.New("unavailable")},
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			secondary := func() LanguageModel {
+				if tc.secondary == nil {
+					return nil
+				}
+				return tc.secondary
+			}
+			model := EnsembleMiddleware(tc.cfg, secondary, judge)(tc.primary)
+
+			result, err := model.ChatCompletion(request)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			text, err := result.ReadAll()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, text)
+			assert.Equal(t, tc.ensemble, result.Props[EnsembleProp] == true)
+			assert.NotNil(t, request.Context.Tools)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Post props set on responses generated while an experiment is running.
+const (
+	ExperimentProp        = "llm_experiment"
+	ExperimentVariantProp = "llm_experiment_variant"
+)
+
+// Experiment variants
+const (
+	ExperimentVariantControl   = "control"
+	ExperimentVariantAlternate = "alternate"
+)
+
+// MiddlewarePriorityExperiment places the experiment between logging and truncation.
+const MiddlewarePriorityExperiment = 500
+
+// ExperimentConfig routes a percentage of a bot's requests to an alternate model
+// so model upgrades can be evaluated in production.
+type ExperimentConfig struct {
+	Enabled        bool   `json:"enabled"`
+	Name           string `json:"name"`
+	AlternateModel string `json:"alternateModel"`
+	Percentage     int    `json:"percentage"`
+}
+
+// IsActive returns true if the experiment should route requests.
+func (c ExperimentConfig) IsActive() bool {
+	return c.Enabled && c.AlternateModel != "" && c.Percentage > 0
+}
+
+// ExperimentMetrics records the outcome of experiment requests.
+type ExperimentMetrics interface {
+	ObserveExperimentLatency(experiment, variant string, elapsed float64)
+}
+
+// ExperimentMiddleware creates a Middleware that sends the configured percentage of requests
+// to the alternate model and tags the results with the variant used.
+func ExperimentMiddleware(cfg ExperimentConfig, metrics ExperimentMetrics) Middleware {
+	chooseVariant := func() string {
+		if rand.IntN(100) < cfg.Percentage {
+			return ExperimentVariantAlternate
+		}
+		return ExperimentVariantControl
+	}
+
+	variantOpts := func(variant string, opts []LanguageModelOption) []LanguageModelOption {
+		if variant != ExperimentVariantAlternate {
+			return opts
+		}
+		// Appended last so it overrides any model chosen by the caller
+		return append(append([]LanguageModelOption{}, opts...), WithModel(cfg.AlternateModel))
+	}
+
+	return Interceptor{
+		ChatCompletion: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+			variant := chooseVariant()
+			start := time.Now()
+			result, err := next.ChatCompletion(request, variantOpts(variant, opts)...)
+			if err != nil {
+				return nil, err
+			}
+
+			return result.WithProps(map[string]any{
+				ExperimentProp:        cfg.Name,
+				ExperimentVariantProp: variant,
+			}).OnEnd(func() {
+				metrics.ObserveExperimentLatency(cfg.Name, variant, time.Since(start).Seconds())
+			}), nil
+		},
+		ChatCompletionNoStream: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+			variant := chooseVariant()
+			start := time.Now()
+			result, err := next.ChatCompletionNoStream(request, variantOpts(variant, opts)...)
+			if err != nil {
+				return "", err
+			}
+			metrics.ObserveExperimentLatency(cfg.Name, variant, time.Since(start).Seconds())
+			return result, nil
+		},
+	}.Middleware()
+}
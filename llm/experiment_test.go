@@ -0,0 +1,134 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingModelRecorder streams a fixed response and records the model each request is sent to.
+type streamingModelRecorder struct {
+	modelRecorder
+}
+
+func (m *streamingModelRecorder) ChatCompletion(request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	if _, err := m.ChatCompletionNoStream(request, opts...); err != nil {
+		return nil, err
+	}
+	return NewStreamFromString("response"), nil
+}
+
+// latencies records the variants experiment latencies were observed for.
+type latencies struct {
+	variants []string
+}
+
+func (l *latencies) ObserveExperimentLatency(experiment, variant string, elapsed float64) {
+	l.variants = append(l.variants, experiment+"/"+variant)
+}
+
+func TestExperimentMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		percentage int
+		variant    string
+		model      string
+	}{
+		{name: "all requests to the alternate model", percentage: 100, variant: ExperimentVariantAlternate, model: "alternate"},
+		{name: "no requests to the alternate model", percentage: 0, variant: ExperimentVariantControl, model: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := &streamingModelRecorder{}
+			metrics := &latencies{}
+			model := ExperimentMiddleware(ExperimentConfig{
+				Enabled:        true,
+				Name:           "upgrade",
+				AlternateModel: "alternate",
+				Percentage:     tc.percentage,
+			}, metrics)(recorder)
+
+			result, err := model.ChatCompletion(CompletionRequest{})
+			require.NoError(t, err)
+			assert.Equal(t, "upgrade", result.Props[ExperimentProp])
+			assert.Equal(t, tc.variant, result.Props[ExperimentVariantProp])
+
+			assert.Empty(t, metrics.variants, "the latency isn't observed before the stream ends")
+			text, err := result.ReadAll()
+			require.NoError(t, err)
+			assert.Equal(t, "response", text)
+			assert.Equal(t, []string{"upgrade/" + tc.variant}, metrics.variants, "the latency is observed once the stream ends")
+
+			_, err = model.ChatCompletionNoStream(CompletionRequest{})
+			require.NoError(t, err)
+			assert.Equal(t, []string{tc.model, tc.model}, recorder.models)
+			assert.Len(t, metrics.variants, 2)
+		})
+	}
+
+	t.Run("the alternate model overrides the model of the caller", func(t *testing.T) {
+		recorder := &streamingModelRecorder{}
+		model := ExperimentMiddleware(ExperimentConfig{Name: "upgrade", AlternateModel: "alternate", Percentage: 100}, &latencies{})(recorder)
+
+		_, err := model.ChatCompletionNoStream(CompletionRequest{}, WithModel("chosen"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alternate"}, recorder.models)
+	})
+}
+
+func TestOnEnd(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []TextStreamEvent
+	}{
+		{
+			name:   "ended",
+			events: []TextStreamEvent{{Type: EventTypeText, Value: "a"}, {Type: EventTypeText, Value: "b"}, {Type: EventTypeEnd}},
+		},
+		{
+			name:   "failed",
+			events: []TextStreamEvent{{Type: EventTypeText, Value: "a"}, {Type: EventTypeError, Value: errors.New("failed")}},
+		},
+		{
+			name:   "tool calls requested",
+			events: []TextStreamEvent{{Type: EventTypeToolCalls, Value: []ToolCall{{ID: "1"}}}, {Type: EventTypeEnd}},
+		},
+		{
+			name:   "closed without an end event",
+			events: []TextStreamEvent{{Type: EventTypeText, Value: "a"}},
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := make(chan TextStreamEvent, len(tc.events))
+			for _, event := range tc.events {
+				stream <- event
+			}
+			close(stream)
+
+			calls := 0
+			result := (&TextStreamResult{Stream: stream, Props: map[string]any{"prop": "value"}}).OnEnd(func() {
+				calls++
+			})
+
+			var received []TextStreamEvent
+			for event := range result.Stream {
+				received = append(received, event)
+			}
+
+			assert.Equal(t, 1, calls)
+			assert.Equal(t, tc.events, received, "the events are passed on unchanged")
+			assert.Equal(t, "value", result.Props["prop"])
+		})
+	}
+}
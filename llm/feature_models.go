@@ -0,0 +1,102 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+// MiddlewarePriorityFeatureModels places feature routing inside the experiment, so experiments still
+// override the model, and outside alias resolution, so feature models can be aliases.
+const MiddlewarePriorityFeatureModels = 550
+
+// Feature is what a request is made for, used to route it to a model suited to the task.
+type Feature string
+
+const (
+	// FeatureChat answers people, it is the feature of requests that don't name one
+	FeatureChat           Feature = "chat"
+	FeatureTitles         Feature = "titles"
+	FeatureReactions      Feature = "reactions"
+	FeatureChunkSummaries Feature = "chunkSummaries"
+	FeatureCompose        Feature = "compose"
+	FeatureFollowUps      Feature = "followUps"
+)
+
+// lightweightFeatures are the features sent to the small model by default. Their output is short
+// or an intermediate step, so a cheaper model does well enough.
+var lightweightFeatures = map[Feature]bool{
+	FeatureTitles:         true,
+	FeatureReactions:      true,
+	FeatureChunkSummaries: true,
+	FeatureCompose:        true,
+	FeatureFollowUps:      true,
+}
+
+// defaultSmallModels are the small models used when none is configured, for the services that have one
+// whatever their deployment.
+var defaultSmallModels = map[string]string{
+	ServiceTypeOpenAI:    "gpt-4o-mini",
+	ServiceTypeAnthropic: "claude-3-5-haiku-latest",
+}
+
+// FeatureModelsConfig routes the requests of a bot to different models depending on their feature.
+type FeatureModelsConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// SmallModel answers the lightweight features, such as titles and reactions. When empty, a small
+	// model of the service is used if it has a well known one, the default model otherwise.
+	SmallModel string `json:"smallModel"`
+
+	// Models overrides the model of individual features, by feature name
+	Models map[string]string `json:"models"`
+}
+
+// ModelFor returns the model to use for the feature, or empty to use the default model of the service.
+func (c FeatureModelsConfig) ModelFor(serviceType string, feature Feature) string {
+	if !c.Enabled {
+		return ""
+	}
+	if model := c.Models[string(feature)]; model != "" {
+		return model
+	}
+	if !lightweightFeatures[feature] {
+		return ""
+	}
+	if c.SmallModel != "" {
+		return c.SmallModel
+	}
+	return defaultSmallModels[serviceType]
+}
+
+// WithFeature tags a request with the feature it is made for.
+func WithFeature(feature Feature) LanguageModelOption {
+	return func(cfg *LanguageModelConfig) {
+		cfg.Feature = feature
+	}
+}
+
+// FeatureModelsMiddleware creates a Middleware that sends each request to the model configured for its
+// feature. Requests for which the caller chose a model keep it.
+func FeatureModelsMiddleware(serviceType string, cfg FeatureModelsConfig) Middleware {
+	route := func(opts []LanguageModelOption) []LanguageModelOption {
+		requested := LanguageModelConfig{Feature: FeatureChat}
+		for _, opt := range opts {
+			opt(&requested)
+		}
+		if requested.Model != "" {
+			return opts
+		}
+		model := cfg.ModelFor(serviceType, requested.Feature)
+		if model == "" {
+			return opts
+		}
+		return append(append([]LanguageModelOption{}, opts...), WithModel(model))
+	}
+
+	return Interceptor{
+		ChatCompletion: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+			return next.ChatCompletion(request, route(opts)...)
+		},
+		ChatCompletionNoStream: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+			return next.ChatCompletionNoStream(request, route(opts)...)
+		},
+	}.Middleware()
+}
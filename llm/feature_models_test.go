@@ -0,0 +1,83 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureModelsMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceType string
+		cfg         FeatureModelsConfig
+		opts        []LanguageModelOption
+		expected    string
+	}{
+		{
+			name:        "disabled",
+			serviceType: ServiceTypeOpenAI,
+			cfg:         FeatureModelsConfig{SmallModel: "small"},
+			opts:        []LanguageModelOption{WithFeature(FeatureTitles)},
+		},
+		{
+			name:        "chat keeps the default model",
+			serviceType: ServiceTypeOpenAI,
+			cfg:         FeatureModelsConfig{Enabled: true, SmallModel: "small"},
+		},
+		{
+			name:        "lightweight feature uses the small model",
+			serviceType: ServiceTypeOpenAI,
+			cfg:         FeatureModelsConfig{Enabled: true, SmallModel: "small"},
+			opts:        []LanguageModelOption{WithFeature(FeatureReactions)},
+			expected:    "small",
+		},
+		{
+			name:        "default small model of the service",
+			serviceType: ServiceTypeAnthropic,
+			cfg:         FeatureModelsConfig{Enabled: true},
+			opts:        []LanguageModelOption{WithFeature(FeatureChunkSummaries)},
+			expected:    "claude-3-5-haiku-latest",
+		},
+		{
+			name:        "service without a default small model",
+			serviceType: ServiceTypeOpenAICompatible,
+			cfg:         FeatureModelsConfig{Enabled: true},
+			opts:        []LanguageModelOption{WithFeature(FeatureTitles)},
+		},
+		{
+			name:        "feature override",
+			serviceType: ServiceTypeOpenAI,
+			cfg:         FeatureModelsConfig{Enabled: true, SmallModel: "small", Models: map[string]string{"titles": "titler", "chat": "flagship"}},
+			opts:        []LanguageModelOption{WithFeature(FeatureTitles)},
+			expected:    "titler",
+		},
+		{
+			name:        "chat override",
+			serviceType: ServiceTypeOpenAI,
+			cfg:         FeatureModelsConfig{Enabled: true, Models: map[string]string{"chat": "flagship"}},
+			expected:    "flagship",
+		},
+		{
+			name:        "model chosen by the caller is kept",
+			serviceType: ServiceTypeOpenAI,
+			cfg:         FeatureModelsConfig{Enabled: true, SmallModel: "small"},
+			opts:        []LanguageModelOption{WithModel("tagger"), WithFeature(FeatureTitles)},
+			expected:    "tagger",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			next := &recordingModel{}
+			model := FeatureModelsMiddleware(tc.serviceType, tc.cfg)(next)
+
+			_, err := model.ChatCompletionNoStream(CompletionRequest{}, tc.opts...)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, next.config.Model)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+// feedbackKeyPrefix prefixes the keys remembering which users gave feedback on which responses
+const feedbackKeyPrefix = "feedback_"
+
+// feedbackRetention is how long the feedback of a user on a response is remembered
+const feedbackRetention = 90 * 24 * time.Hour
+
+// FeedbackKV remembers which users gave feedback on which responses.
+type FeedbackKV interface {
+	Set(key string, value any, options ...pluginapi.KVSetOption) (bool, error)
+}
+
+// FeedbackMetrics counts the feedback on responses.
+type FeedbackMetrics interface {
+	IncrementModelFeedback(model, baseModel string, positive bool)
+	IncrementExperimentFeedback(experiment, variant string, positive bool)
+}
+
+// RecordFeedback counts a reaction on a response as feedback for the model and the experiment variant
+// that generated it. Only the first feedback of each user on a response is counted, so reacting with
+// both thumbs or removing and adding a reaction again doesn't skew the metrics.
+func RecordFeedback(kv FeedbackKV, metrics FeedbackMetrics, post *model.Post, userID string, positive bool) error {
+	modelName, _ := post.GetProp(ModelProp).(string)
+	variant, _ := post.GetProp(ExperimentVariantProp).(string)
+	if modelName == "" && variant == "" {
+		return nil
+	}
+
+	first, err := kv.Set(feedbackKeyPrefix+post.Id+"_"+userID, positive, pluginapi.SetAtomic(nil), pluginapi.SetExpiry(feedbackRetention))
+	if err != nil {
+		return fmt.Errorf("unable to record feedback: %w", err)
+	}
+	if !first {
+		return nil
+	}
+
+	if modelName != "" {
+		baseModel, _ := post.GetProp(BaseModelProp).(string)
+		metrics.IncrementModelFeedback(modelName, baseModel, positive)
+	}
+	if variant != "" {
+		experiment, _ := post.GetProp(ExperimentProp).(string)
+		metrics.IncrementExperimentFeedback(experiment, variant, positive)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// feedbackCounts records the feedback counted per model and experiment variant.
+type feedbackCounts struct {
+	models   []string
+	variants []string
+}
+
+func (f *feedbackCounts) IncrementModelFeedback(model, baseModel string, positive bool) {
+	f.models = append(f.models, feedbackLabel(model+"/"+baseModel, positive))
+}
+
+func (f *feedbackCounts) IncrementExperimentFeedback(experiment, variant string, positive bool) {
+	f.variants = append(f.variants, feedbackLabel(experiment+"/"+variant, positive))
+}
+
+func feedbackLabel(name string, positive bool) string {
+	if positive {
+		return name + " +"
+	}
+	return name + " -"
+}
+
+func TestRecordFeedback(t *testing.T) {
+	// setup returns a KV store that, like the server, only sets keys that don't exist yet on atomic sets
+	setup := func(t *testing.T) *pluginapi.KVService {
+		mockAPI := &plugintest.API{}
+		t.Cleanup(func() { mockAPI.AssertExpectations(t) })
+		stored := map[string][]byte{}
+		mockAPI.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything).Return(func(key string, value []byte, options model.PluginKVSetOptions) (bool, *model.AppError) {
+			require.True(t, options.Atomic)
+			require.Nil(t, options.OldValue)
+			assert.Positive(t, options.ExpireInSeconds)
+			if _, ok := stored[key]; ok {
+				return false, nil
+			}
+			stored[key] = value
+			return true, nil
+		}).Maybe()
+		return &pluginapi.NewClient(mockAPI, nil).KV
+	}
+
+	response := &model.Post{Id: "postid"}
+	response.AddProp(ModelProp, "fast")
+	response.AddProp(BaseModelProp, "gpt-4o-mini")
+	response.AddProp(ExperimentProp, "upgrade")
+	response.AddProp(ExperimentVariantProp, ExperimentVariantAlternate)
+
+	t.Run("each user's feedback on a response counts once", func(t *testing.T) {
+		kv := setup(t)
+		var counts feedbackCounts
+
+		require.NoError(t, RecordFeedback(kv, &counts, response, "user1", true))
+		require.NoError(t, RecordFeedback(kv, &counts, response, "user1", true), "the reaction was removed and added again")
+		require.NoError(t, RecordFeedback(kv, &counts, response, "user1", false), "the user reacted with both thumbs")
+		require.NoError(t, RecordFeedback(kv, &counts, response, "user2", false))
+
+		assert.Equal(t, []string{"fast/gpt-4o-mini +", "fast/gpt-4o-mini -"}, counts.models)
+		assert.Equal(t, []string{"upgrade/alternate +", "upgrade/alternate -"}, counts.variants)
+	})
+
+	t.Run("feedback on the same user's other responses counts", func(t *testing.T) {
+		kv := setup(t)
+		var counts feedbackCounts
+		other := response.Clone()
+		other.Id = "otherid"
+
+		require.NoError(t, RecordFeedback(kv, &counts, response, "user1", true))
+		require.NoError(t, RecordFeedback(kv, &counts, other, "user1", true))
+
+		assert.Len(t, counts.models, 2)
+	})
+
+	t.Run("posts without a model aren't remembered", func(t *testing.T) {
+		kv := setup(t)
+		var counts feedbackCounts
+
+		require.NoError(t, RecordFeedback(kv, &counts, &model.Post{Id: "humanid"}, "user1", true))
+		assert.Empty(t, counts.models)
+		assert.Empty(t, counts.variants)
+	})
+}
@@ -33,6 +33,12 @@ type LanguageModelConfig struct {
 	MaxGeneratedTokens int
 	EnableVision       bool
 	JSONOutputFormat   any
+
+	// Temperature is the sampling temperature, the model's default when nil
+	Temperature *float64
+
+	// Feature is what the request is made for, providers ignore it
+	Feature Feature
 }
 
 type LanguageModelOption func(*LanguageModelConfig)
@@ -52,5 +58,8 @@ func WithJSONOutput(format any) LanguageModelOption {
 		cfg.JSONOutputFormat = format
 	}
 }
-
-type LanguageModelWrapper func(LanguageModel) LanguageModel
+func WithTemperature(temperature float64) LanguageModelOption {
+	return func(cfg *LanguageModelConfig) {
+		cfg.Temperature = &temperature
+	}
+}
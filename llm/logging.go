@@ -9,37 +9,11 @@ import (
 	"github.com/mattermost/mattermost/server/public/pluginapi"
 )
 
-type LanguageModelLogWrapper struct {
-	log     pluginapi.LogService
-	wrapped LanguageModel
-}
-
-func NewLanguageModelLogWrapper(log pluginapi.LogService, wrapped LanguageModel) *LanguageModelLogWrapper {
-	return &LanguageModelLogWrapper{
-		log:     log,
-		wrapped: wrapped,
-	}
-}
-
-func (w *LanguageModelLogWrapper) logInput(request CompletionRequest, opts ...LanguageModelOption) {
-	prompt := fmt.Sprintf("\n%v", request)
-	w.log.Info("LLM Call", "prompt", prompt)
-}
-
-func (w *LanguageModelLogWrapper) ChatCompletion(request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
-	w.logInput(request, opts...)
-	return w.wrapped.ChatCompletion(request, opts...)
-}
-
-func (w *LanguageModelLogWrapper) ChatCompletionNoStream(request CompletionRequest, opts ...LanguageModelOption) (string, error) {
-	w.logInput(request, opts...)
-	return w.wrapped.ChatCompletionNoStream(request, opts...)
-}
-
-func (w *LanguageModelLogWrapper) CountTokens(text string) int {
-	return w.wrapped.CountTokens(text)
-}
-
-func (w *LanguageModelLogWrapper) InputTokenLimit() int {
-	return w.wrapped.InputTokenLimit()
+// LoggingMiddleware logs every completion request before passing it on.
+func LoggingMiddleware(log pluginapi.LogService) Middleware {
+	return RequestMiddleware(func(_ LanguageModel, request CompletionRequest) CompletionRequest {
+		prompt := fmt.Sprintf("\n%v", request)
+		log.Info("LLM Call", "prompt", prompt)
+		return request
+	})
 }
@@ -0,0 +1,160 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"sort"
+	"sync"
+)
+
+// Middleware decorates a LanguageModel with additional behaviour such as logging,
+// truncation, metrics or redaction.
+type Middleware func(LanguageModel) LanguageModel
+
+// Chain composes middlewares into a single middleware.
+// The first middleware is the outermost one: it sees requests first and results last.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(model LanguageModel) LanguageModel {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			if middlewares[i] == nil {
+				continue
+			}
+			model = middlewares[i](model)
+		}
+		return model
+	}
+}
+
+// Interceptor describes a middleware by the calls it intercepts.
+// Nil functions pass the call straight through to the next model.
+type Interceptor struct {
+	ChatCompletion         func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error)
+	ChatCompletionNoStream func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (string, error)
+}
+
+// Middleware returns a Middleware that applies the interceptor.
+func (i Interceptor) Middleware() Middleware {
+	return func(next LanguageModel) LanguageModel {
+		return &interceptedModel{
+			next:        next,
+			interceptor: i,
+		}
+	}
+}
+
+// RequestMiddleware creates a Middleware that inspects or rewrites every completion request
+// before it reaches the next model.
+func RequestMiddleware(hook func(next LanguageModel, request CompletionRequest) CompletionRequest) Middleware {
+	return Interceptor{
+		ChatCompletion: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+			return next.ChatCompletion(hook(next, request), opts...)
+		},
+		ChatCompletionNoStream: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+			return next.ChatCompletionNoStream(hook(next, request), opts...)
+		},
+	}.Middleware()
+}
+
+type interceptedModel struct {
+	next        LanguageModel
+	interceptor Interceptor
+}
+
+func (m *interceptedModel) ChatCompletion(request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	if m.interceptor.ChatCompletion == nil {
+		return m.next.ChatCompletion(request, opts...)
+	}
+	return m.interceptor.ChatCompletion(m.next, request, opts...)
+}
+
+func (m *interceptedModel) ChatCompletionNoStream(request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	if m.interceptor.ChatCompletionNoStream == nil {
+		return m.next.ChatCompletionNoStream(request, opts...)
+	}
+	return m.interceptor.ChatCompletionNoStream(m.next, request, opts...)
+}
+
+func (m *interceptedModel) CountTokens(text string) int {
+	return m.next.CountTokens(text)
+}
+
+func (m *interceptedModel) InputTokenLimit() int {
+	return m.next.InputTokenLimit()
+}
+
+// Priorities of the built-in middlewares. Lower priorities wrap higher ones.
+const (
+	MiddlewarePriorityLogging    = 100
+	MiddlewarePriorityTruncation = 1000
+)
+
+// MiddlewareFactory creates the middleware to apply to a specific bot's model.
+// Returning nil skips the middleware for that bot.
+type MiddlewareFactory func(bot BotConfig) Middleware
+
+type registeredMiddleware struct {
+	name     string
+	priority int
+	factory  MiddlewareFactory
+}
+
+// MiddlewareRegistry is the registration point for subsystems that want to wrap every bot's model.
+type MiddlewareRegistry struct {
+	mu      sync.RWMutex
+	entries []registeredMiddleware
+}
+
+// NewMiddlewareRegistry creates an empty middleware registry.
+func NewMiddlewareRegistry() *MiddlewareRegistry {
+	return &MiddlewareRegistry{}
+}
+
+// Register adds a middleware factory under the given name, replacing any factory with the same name.
+// Middlewares with a lower priority are placed outside those with a higher priority.
+func (r *MiddlewareRegistry) Register(name string, priority int, factory MiddlewareFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeLocked(name)
+	r.entries = append(r.entries, registeredMiddleware{
+		name:     name,
+		priority: priority,
+		factory:  factory,
+	})
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		return r.entries[i].priority < r.entries[j].priority
+	})
+}
+
+// Unregister removes the middleware factory with the given name.
+func (r *MiddlewareRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeLocked(name)
+}
+
+func (r *MiddlewareRegistry) removeLocked(name string) {
+	for i, entry := range r.entries {
+		if entry.name == name {
+			r.entries = append(r.entries[:i], r.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Build returns the chain of all registered middlewares for the given bot.
+func (r *MiddlewareRegistry) Build(bot BotConfig) Middleware {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	middlewares := make([]Middleware, 0, len(r.entries))
+	for _, entry := range r.entries {
+		if middleware := entry.factory(bot); middleware != nil {
+			middlewares = append(middlewares, middleware)
+		}
+	}
+
+	return Chain(middlewares...)
+}
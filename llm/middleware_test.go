@@ -0,0 +1,113 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubModel struct {
+	calls []string
+}
+
+func (s *stubModel) ChatCompletion(request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	return nil, nil
+}
+
+func (s *stubModel) ChatCompletionNoStream(request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	for _, post := range request.Posts {
+		s.calls = append(s.calls, post.Message)
+	}
+	return "", nil
+}
+
+func (s *stubModel) CountTokens(text string) int {
+	return len(text)
+}
+
+func (s *stubModel) InputTokenLimit() int {
+	return 1000
+}
+
+// appendingMiddleware adds a post with the given name so the order of the chain is observable.
+func appendingMiddleware(name string) Middleware {
+	return RequestMiddleware(func(_ LanguageModel, request CompletionRequest) CompletionRequest {
+		request.Posts = append(request.Posts, Post{Role: PostRoleUser, Message: name})
+		return request
+	})
+}
+
+func TestChain(t *testing.T) {
+	tests := []struct {
+		name        string
+		middlewares []Middleware
+		expected    []string
+	}{
+		{
+			name:        "no middlewares",
+			middlewares: nil,
+			expected:    nil,
+		},
+		{
+			name:        "first middleware is outermost",
+			middlewares: []Middleware{appendingMiddleware("a"), appendingMiddleware("b"), appendingMiddleware("c")},
+			expected:    []string{"a", "b", "c"},
+		},
+		{
+			name:        "nil middlewares are skipped",
+			middlewares: []Middleware{appendingMiddleware("a"), nil, appendingMiddleware("c")},
+			expected:    []string{"a", "c"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := &stubModel{}
+			model := Chain(tc.middlewares...)(stub)
+
+			_, err := model.ChatCompletionNoStream(CompletionRequest{})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, stub.calls)
+			assert.Equal(t, 1000, model.InputTokenLimit())
+		})
+	}
+}
+
+func TestMiddlewareRegistry(t *testing.T) {
+	t.Run("orders by priority", func(t *testing.T) {
+		registry := NewMiddlewareRegistry()
+		registry.Register("inner", 10, func(_ BotConfig) Middleware { return appendingMiddleware("inner") })
+		registry.Register("outer", 1, func(_ BotConfig) Middleware { return appendingMiddleware("outer") })
+
+		stub := &stubModel{}
+		_, err := registry.Build(BotConfig{})(stub).ChatCompletionNoStream(CompletionRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"outer", "inner"}, stub.calls)
+	})
+
+	t.Run("register replaces by name and factories can skip bots", func(t *testing.T) {
+		registry := NewMiddlewareRegistry()
+		registry.Register("a", 1, func(_ BotConfig) Middleware { return appendingMiddleware("first") })
+		registry.Register("a", 1, func(_ BotConfig) Middleware { return appendingMiddleware("second") })
+		registry.Register("vision", 2, func(bot BotConfig) Middleware {
+			if !bot.EnableVision {
+				return nil
+			}
+			return appendingMiddleware("vision")
+		})
+
+		stub := &stubModel{}
+		_, err := registry.Build(BotConfig{})(stub).ChatCompletionNoStream(CompletionRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"second"}, stub.calls)
+
+		registry.Unregister("a")
+		stub = &stubModel{}
+		_, err = registry.Build(BotConfig{EnableVision: true})(stub).ChatCompletionNoStream(CompletionRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"vision"}, stub.calls)
+	})
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Post props set on responses with the model that generated them, so models can be compared.
+const (
+	ModelProp     = "llm_model"
+	BaseModelProp = "llm_base_model"
+)
+
+// MiddlewarePriorityModelAliases places alias resolution inside the experiment, so alternate models
+// can be aliases, and outside the capability checks, so they apply to the resolved model.
+const MiddlewarePriorityModelAliases = 600
+
+// ErrInvalidModelAlias is returned when the model aliases of a service aren't valid.
+var ErrInvalidModelAlias = errors.New("invalid model alias")
+
+// ModelAlias names a model of the service, usually a fine-tuned model with a long generated ID,
+// so it can be used as the default or alternate model of a bot by a short name.
+type ModelAlias struct {
+	Alias string `json:"alias"`
+
+	// Model is the ID of the model: an OpenAI fine-tune (ft:...), an Azure deployment or a LoRA adapter name
+	Model string `json:"model"`
+
+	// BaseModel is the model that was fine-tuned, for capabilities and comparisons with it
+	BaseModel string `json:"baseModel"`
+}
+
+// azureDeploymentName matches the names Azure allows for deployments
+var azureDeploymentName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// ValidateModelAliases returns an error describing the first invalid alias of the service.
+func (c ServiceConfig) ValidateModelAliases() error {
+	seen := map[string]bool{}
+	for _, alias := range c.ModelAliases {
+		if alias.Alias == "" || strings.ContainsFunc(alias.Alias, unicode.IsSpace) {
+			return fmt.Errorf("%w: %q must be non-empty and without spaces", ErrInvalidModelAlias, alias.Alias)
+		}
+		if seen[alias.Alias] {
+			return fmt.Errorf("%w: %q is used more than once", ErrInvalidModelAlias, alias.Alias)
+		}
+		seen[alias.Alias] = true
+
+		if err := ValidateModelID(c.Type, alias.Model); err != nil {
+			return fmt.Errorf("%w: %q: %w", ErrInvalidModelAlias, alias.Alias, err)
+		}
+	}
+	return nil
+}
+
+// ValidateModelID checks that a model ID has the format the service expects.
+func ValidateModelID(serviceType string, model string) error {
+	if model == "" || strings.ContainsFunc(model, unicode.IsSpace) {
+		return fmt.Errorf("model %q must be non-empty and without spaces", model)
+	}
+
+	switch serviceType {
+	case ServiceTypeOpenAI:
+		if strings.HasPrefix(model, "ft:") && fineTunedBaseModel(model) == "" {
+			return fmt.Errorf("model %q isn't a fine-tuned model ID of the form ft:base-model:organization:suffix:id", model)
+		}
+	case ServiceTypeAzure:
+		if !azureDeploymentName.MatchString(model) {
+			return fmt.Errorf("model %q isn't a valid Azure deployment name", model)
+		}
+	}
+	return nil
+}
+
+// ResolveModel returns the model ID of an alias and the model it is based on. Names that aren't
+// aliases are returned as they are, with the base model of OpenAI fine-tunes taken from their ID.
+func (c ServiceConfig) ResolveModel(name string) (model string, baseModel string) {
+	for _, alias := range c.ModelAliases {
+		if alias.Alias != name {
+			continue
+		}
+		baseModel = alias.BaseModel
+		if baseModel == "" {
+			baseModel = fineTunedBaseModel(alias.Model)
+		}
+		if baseModel == "" {
+			baseModel = alias.Model
+		}
+		return alias.Model, baseModel
+	}
+
+	if baseModel = fineTunedBaseModel(name); baseModel != "" {
+		return name, baseModel
+	}
+	return name, name
+}
+
+// fineTunedBaseModel returns the base model of an OpenAI fine-tuned model ID such as
+// ft:gpt-4o-mini-2024-07-18:org:suffix:id, or empty if the ID isn't one.
+func fineTunedBaseModel(model string) string {
+	parts := strings.Split(model, ":")
+	if len(parts) < 4 || len(parts) > 5 || parts[0] != "ft" {
+		return ""
+	}
+	if parts[1] == "" || parts[len(parts)-1] == "" {
+		return ""
+	}
+	return parts[1]
+}
+
+// ModelMetrics records the usage of each model.
+type ModelMetrics interface {
+	ObserveModelLatency(model, baseModel string, elapsed float64)
+}
+
+// ModelAliasMiddleware creates a Middleware that replaces aliases with the models they name and tags
+// results with the requested model and its base model, so fine-tunes can be compared with their base.
+func ModelAliasMiddleware(service ServiceConfig, metrics ModelMetrics) Middleware {
+	resolve := func(opts []LanguageModelOption) (string, string, []LanguageModelOption) {
+		cfg := LanguageModelConfig{Model: service.DefaultModel}
+		for _, opt := range opts {
+			opt(&cfg)
+		}
+		model, baseModel := service.ResolveModel(cfg.Model)
+		if model == cfg.Model {
+			return cfg.Model, baseModel, opts
+		}
+		return cfg.Model, baseModel, append(append([]LanguageModelOption{}, opts...), WithModel(model))
+	}
+
+	return Interceptor{
+		ChatCompletion: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+			name, baseModel, opts := resolve(opts)
+			start := time.Now()
+			result, err := next.ChatCompletion(request, opts...)
+			if err != nil {
+				return nil, err
+			}
+
+			return result.WithProps(map[string]any{
+				ModelProp:     name,
+				BaseModelProp: baseModel,
+			}).OnEnd(func() {
+				metrics.ObserveModelLatency(name, baseModel, time.Since(start).Seconds())
+			}), nil
+		},
+		ChatCompletionNoStream: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+			name, baseModel, opts := resolve(opts)
+			start := time.Now()
+			result, err := next.ChatCompletionNoStream(request, opts...)
+			if err != nil {
+				return "", err
+			}
+			metrics.ObserveModelLatency(name, baseModel, time.Since(start).Seconds())
+			return result, nil
+		},
+	}.Middleware()
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateModelAliases(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceType string
+		aliases     []ModelAlias
+		wantErr     bool
+	}{
+		{
+			name:        "no aliases",
+			serviceType: ServiceTypeOpenAI,
+		},
+		{
+			name:        "OpenAI fine-tune",
+			serviceType: ServiceTypeOpenAI,
+			aliases:     []ModelAlias{{Alias: "support", Model: "ft:gpt-4o-mini-2024-07-18:acme:support:9ABel2dg"}},
+		},
+		{
+			name:        "OpenAI fine-tune without suffix",
+			serviceType: ServiceTypeOpenAI,
+			aliases:     []ModelAlias{{Alias: "support", Model: "ft:gpt-3.5-turbo-0613:acme::7p4lURel"}},
+		},
+		{
+			name:        "malformed OpenAI fine-tune",
+			serviceType: ServiceTypeOpenAI,
+			aliases:     []ModelAlias{{Alias: "support", Model: "ft:gpt-4o-mini"}},
+			wantErr:     true,
+		},
+		{
+			name:        "Azure deployment",
+			serviceType: ServiceTypeAzure,
+			aliases:     []ModelAlias{{Alias: "support", Model: "support-gpt4o_v2"}},
+		},
+		{
+			name:        "invalid Azure deployment",
+			serviceType: ServiceTypeAzure,
+			aliases:     []ModelAlias{{Alias: "support", Model: "ft:gpt-4o:acme::id"}},
+			wantErr:     true,
+		},
+		{
+			name:        "LoRA adapter",
+			serviceType: ServiceTypeOpenAICompatible,
+			aliases:     []ModelAlias{{Alias: "support", Model: "support-lora", BaseModel: "meta-llama/Llama-3.1-8B-Instruct"}},
+		},
+		{
+			name:        "empty alias",
+			serviceType: ServiceTypeOpenAICompatible,
+			aliases:     []ModelAlias{{Alias: "", Model: "support-lora"}},
+			wantErr:     true,
+		},
+		{
+			name:        "alias with spaces",
+			serviceType: ServiceTypeOpenAICompatible,
+			aliases:     []ModelAlias{{Alias: "support bot", Model: "support-lora"}},
+			wantErr:     true,
+		},
+		{
+			name:        "missing model",
+			serviceType: ServiceTypeOpenAICompatible,
+			aliases:     []ModelAlias{{Alias: "support"}},
+			wantErr:     true,
+		},
+		{
+			name:        "duplicate alias",
+			serviceType: ServiceTypeOpenAICompatible,
+			aliases:     []ModelAlias{{Alias: "support", Model: "a"}, {Alias: "support", Model: "b"}},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ServiceConfig{Type: tc.serviceType, ModelAliases: tc.aliases}.ValidateModelAliases()
+			if tc.wantErr {
+				assert.True(t, errors.Is(err, ErrInvalidModelAlias))
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestResolveModel(t *testing.T) {
+	service := ServiceConfig{
+		ModelAliases: []ModelAlias{
+			{Alias: "support", Model: "ft:gpt-4o-mini-2024-07-18:acme:support:9ABel2dg"},
+			{Alias: "lora", Model: "support-lora", BaseModel: "llama-3.1-8b"},
+			{Alias: "unknown-base", Model: "custom-model"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		wantModel string
+		wantBase  string
+	}{
+		{name: "support", wantModel: "ft:gpt-4o-mini-2024-07-18:acme:support:9ABel2dg", wantBase: "gpt-4o-mini-2024-07-18"},
+		{name: "lora", wantModel: "support-lora", wantBase: "llama-3.1-8b"},
+		{name: "unknown-base", wantModel: "custom-model", wantBase: "custom-model"},
+		{name: "ft:gpt-4o-2024-08-06:acme::abc123", wantModel: "ft:gpt-4o-2024-08-06:acme::abc123", wantBase: "gpt-4o-2024-08-06"},
+		{name: "gpt-4o", wantModel: "gpt-4o", wantBase: "gpt-4o"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			model, baseModel := service.ResolveModel(tc.name)
+			assert.Equal(t, tc.wantModel, model)
+			assert.Equal(t, tc.wantBase, baseModel)
+		})
+	}
+}
+
+// modelRecorder records the model each request is sent to.
+type modelRecorder struct {
+	stubModel
+	models []string
+}
+
+func (m *modelRecorder) ChatCompletionNoStream(request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	var cfg LanguageModelConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	m.models = append(m.models, cfg.Model)
+	return "", nil
+}
+
+type modelLatencyRecorder struct {
+	observed [][2]string
+}
+
+func (m *modelLatencyRecorder) ObserveModelLatency(model, baseModel string, elapsed float64) {
+	m.observed = append(m.observed, [2]string{model, baseModel})
+}
+
+func TestModelAliasMiddleware(t *testing.T) {
+	service := ServiceConfig{
+		DefaultModel: "support",
+		ModelAliases: []ModelAlias{{Alias: "support", Model: "support-lora", BaseModel: "llama-3.1-8b"}},
+	}
+	recorder := &modelRecorder{}
+	metrics := &modelLatencyRecorder{}
+	model := ModelAliasMiddleware(service, metrics)(recorder)
+
+	_, err := model.ChatCompletionNoStream(CompletionRequest{})
+	require.NoError(t, err)
+	_, err = model.ChatCompletionNoStream(CompletionRequest{}, WithModel("llama-3.1-8b"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"support-lora", "llama-3.1-8b"}, recorder.models)
+	assert.Equal(t, [][2]string{{"support", "llama-3.1-8b"}, {"llama-3.1-8b", "llama-3.1-8b"}}, metrics.observed)
+}
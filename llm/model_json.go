@@ -0,0 +1,19 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// UnmarshalModelJSON parses the JSON a model responded with, removing the markdown code block some models
+// wrap it in even when asked not to.
+func UnmarshalModelJSON(result string, v any) error {
+	result = strings.TrimSpace(result)
+	result = strings.TrimPrefix(result, "```json")
+	result = strings.TrimPrefix(result, "```")
+	result = strings.TrimSuffix(result, "```")
+	return json.Unmarshal([]byte(result), v)
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalModelJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+	}{
+		{name: "plain", result: `{"name": "value"}`},
+		{name: "json code block", result: "```json\n{\"name\": \"value\"}\n```"},
+		{name: "code block", result: "  ```\n{\"name\": \"value\"}\n```  "},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var response struct {
+				Name string `json:"name"`
+			}
+			require.NoError(t, UnmarshalModelJSON(tc.result, &response))
+			assert.Equal(t, "value", response.Name)
+		})
+	}
+
+	t.Run("not json", func(t *testing.T) {
+		var response map[string]any
+		assert.Error(t, UnmarshalModelJSON("Sorry, I can't help with that.", &response))
+	})
+}
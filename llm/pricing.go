@@ -0,0 +1,150 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"math"
+	"strings"
+)
+
+// EstimatedSummaryOutputTokens is the expected length of a generated summary, used for cost estimates.
+const EstimatedSummaryOutputTokens = 1000
+
+// DefaultTranscriptionPricePerMinute is the list price of OpenAI's Whisper transcription in US dollars.
+const DefaultTranscriptionPricePerMinute = 0.006
+
+// ModelPrice is the price of a model in US dollars per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// defaultModelPrices are list prices for common models, matched by the longest prefix of the model name.
+// Admins can set prices on the service for models that aren't listed or have negotiated prices.
+var defaultModelPrices = map[string]ModelPrice{
+	"gpt-4o-mini":            {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+	"gpt-4o":                 {InputPerMillion: 2.50, OutputPerMillion: 10.00},
+	"gpt-4.1-nano":           {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+	"gpt-4.1-mini":           {InputPerMillion: 0.40, OutputPerMillion: 1.60},
+	"gpt-4.1":                {InputPerMillion: 2.00, OutputPerMillion: 8.00},
+	"o3-mini":                {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+	"o4-mini":                {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+	"claude-3-5-haiku":       {InputPerMillion: 0.80, OutputPerMillion: 4.00},
+	"claude-3-5-sonnet":      {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+	"claude-3-7-sonnet":      {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+	"claude-sonnet-4":        {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+	"claude-opus-4":          {InputPerMillion: 15.00, OutputPerMillion: 75.00},
+	"text-embedding-3-small": {InputPerMillion: 0.02},
+	"text-embedding-3-large": {InputPerMillion: 0.13},
+}
+
+// LookupModelPrice returns the list price of the model if it is known.
+func LookupModelPrice(model string) (ModelPrice, bool) {
+	model = strings.ToLower(model)
+	bestPrefix := ""
+	for prefix := range defaultModelPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(bestPrefix) {
+			bestPrefix = prefix
+		}
+	}
+	if bestPrefix == "" {
+		return ModelPrice{}, false
+	}
+	return defaultModelPrices[bestPrefix], true
+}
+
+// ModelPriceConfig is the price of a model of a service configured by the admin, in US dollars per
+// million tokens. The model is a model ID or one of the service's aliases.
+type ModelPriceConfig struct {
+	Model            string  `json:"model"`
+	InputTokenPrice  float64 `json:"inputTokenPrice"`
+	OutputTokenPrice float64 `json:"outputTokenPrice"`
+}
+
+// Price returns the price of the service's default model, preferring the prices configured on the service.
+func (c ServiceConfig) Price() (ModelPrice, bool) {
+	return c.ModelPrice(c.DefaultModel)
+}
+
+// ModelPrice returns the price of a model of the service. Prices configured for the model in the price
+// table come first, then the prices configured on the service for its default model, then the list price.
+// Models are matched by name or by the model their alias resolves to.
+func (c ServiceConfig) ModelPrice(model string) (ModelPrice, bool) {
+	if model == "" {
+		model = c.DefaultModel
+	}
+	resolved, _ := c.ResolveModel(model)
+	matches := func(name string) bool {
+		if name == "" {
+			return false
+		}
+		nameResolved, _ := c.ResolveModel(name)
+		return strings.EqualFold(name, model) || strings.EqualFold(nameResolved, resolved)
+	}
+
+	for _, configured := range c.ModelPrices {
+		if matches(configured.Model) {
+			return ModelPrice{
+				InputPerMillion:  configured.InputTokenPrice,
+				OutputPerMillion: configured.OutputTokenPrice,
+			}, true
+		}
+	}
+	if matches(c.DefaultModel) && (c.InputTokenPrice > 0 || c.OutputTokenPrice > 0) {
+		return ModelPrice{
+			InputPerMillion:  c.InputTokenPrice,
+			OutputPerMillion: c.OutputTokenPrice,
+		}, true
+	}
+	return LookupModelPrice(resolved)
+}
+
+// TranscriptionPrice returns the price per minute of audio transcribed by the service.
+func (c ServiceConfig) TranscriptionPrice() float64 {
+	if c.TranscriptionPricePerMinute > 0 {
+		return c.TranscriptionPricePerMinute
+	}
+	return DefaultTranscriptionPricePerMinute
+}
+
+// CostEstimate is the expected usage and cost of an operation.
+type CostEstimate struct {
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	AudioMinutes float64 `json:"audio_minutes,omitempty"`
+
+	// Cost is in US dollars and only meaningful when PriceKnown is true
+	Cost       float64 `json:"cost"`
+	PriceKnown bool    `json:"price_known"`
+}
+
+// NewCostEstimate estimates the cost of a completion with the given number of tokens.
+func NewCostEstimate(price ModelPrice, priceKnown bool, inputTokens int, outputTokens int) CostEstimate {
+	estimate := CostEstimate{
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+		PriceKnown:   priceKnown,
+	}
+	if priceKnown {
+		estimate.Cost = roundCost(float64(inputTokens)/1_000_000*price.InputPerMillion +
+			float64(outputTokens)/1_000_000*price.OutputPerMillion)
+	}
+	return estimate
+}
+
+// Add combines two estimates, the price is only known if it is known for both.
+func (e CostEstimate) Add(other CostEstimate) CostEstimate {
+	return CostEstimate{
+		InputTokens:  e.InputTokens + other.InputTokens,
+		OutputTokens: e.OutputTokens + other.OutputTokens,
+		AudioMinutes: e.AudioMinutes + other.AudioMinutes,
+		Cost:         roundCost(e.Cost + other.Cost),
+		PriceKnown:   e.PriceKnown && other.PriceKnown,
+	}
+}
+
+// roundCost rounds to a hundredth of a cent to avoid floating point noise.
+func roundCost(cost float64) float64 {
+	return math.Round(cost*10000) / 10000
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceConfigPrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		service   ServiceConfig
+		wantPrice ModelPrice
+		wantKnown bool
+	}{
+		{
+			name:      "listed model",
+			service:   ServiceConfig{DefaultModel: "gpt-4o"},
+			wantPrice: ModelPrice{InputPerMillion: 2.50, OutputPerMillion: 10.00},
+			wantKnown: true,
+		},
+		{
+			name:      "longest prefix wins",
+			service:   ServiceConfig{DefaultModel: "gpt-4o-mini-2024-07-18"},
+			wantPrice: ModelPrice{InputPerMillion: 0.15, OutputPerMillion: 0.60},
+			wantKnown: true,
+		},
+		{
+			name:      "model names are case insensitive",
+			service:   ServiceConfig{DefaultModel: "Claude-Sonnet-4-20250514"},
+			wantPrice: ModelPrice{InputPerMillion: 3.00, OutputPerMillion: 15.00},
+			wantKnown: true,
+		},
+		{
+			name:      "configured price overrides the list",
+			service:   ServiceConfig{DefaultModel: "gpt-4o", InputTokenPrice: 1, OutputTokenPrice: 2},
+			wantPrice: ModelPrice{InputPerMillion: 1, OutputPerMillion: 2},
+			wantKnown: true,
+		},
+		{
+			name:      "unknown model",
+			service:   ServiceConfig{DefaultModel: "llama3"},
+			wantKnown: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			price, known := tc.service.Price()
+			assert.Equal(t, tc.wantKnown, known)
+			assert.Equal(t, tc.wantPrice, price)
+		})
+	}
+}
+
+func TestServiceConfigModelPrice(t *testing.T) {
+	service := ServiceConfig{
+		DefaultModel:     "gpt-4o",
+		InputTokenPrice:  2,
+		OutputTokenPrice: 8,
+		ModelAliases:     []ModelAlias{{Alias: "support", Model: "ft:gpt-4o-mini-2024-07-18:org:support:id"}},
+		ModelPrices: []ModelPriceConfig{
+			{Model: "support", InputTokenPrice: 0.3, OutputTokenPrice: 1.2},
+			{Model: "llama3", InputTokenPrice: 0, OutputTokenPrice: 0},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		model     string
+		wantPrice ModelPrice
+		wantKnown bool
+	}{
+		{
+			name:      "default model uses the service prices",
+			model:     "gpt-4o",
+			wantPrice: ModelPrice{InputPerMillion: 2, OutputPerMillion: 8},
+			wantKnown: true,
+		},
+		{
+			name:      "empty model is the default model",
+			model:     "",
+			wantPrice: ModelPrice{InputPerMillion: 2, OutputPerMillion: 8},
+			wantKnown: true,
+		},
+		{
+			name:      "alias in the price table",
+			model:     "support",
+			wantPrice: ModelPrice{InputPerMillion: 0.3, OutputPerMillion: 1.2},
+			wantKnown: true,
+		},
+		{
+			name:      "model an alias in the price table resolves to",
+			model:     "ft:gpt-4o-mini-2024-07-18:org:support:id",
+			wantPrice: ModelPrice{InputPerMillion: 0.3, OutputPerMillion: 1.2},
+			wantKnown: true,
+		},
+		{
+			name:      "free model in the price table",
+			model:     "llama3",
+			wantKnown: true,
+		},
+		{
+			name:      "other model uses the list price",
+			model:     "gpt-4o-mini",
+			wantPrice: ModelPrice{InputPerMillion: 0.15, OutputPerMillion: 0.60},
+			wantKnown: true,
+		},
+		{
+			name:      "unknown model",
+			model:     "mistral",
+			wantKnown: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			price, known := service.ModelPrice(tc.model)
+			assert.Equal(t, tc.wantKnown, known)
+			assert.Equal(t, tc.wantPrice, price)
+		})
+	}
+}
+
+func TestCostEstimate(t *testing.T) {
+	price := ModelPrice{InputPerMillion: 3, OutputPerMillion: 15}
+
+	estimate := NewCostEstimate(price, true, 100_000, 1000)
+	assert.Equal(t, CostEstimate{InputTokens: 100_000, OutputTokens: 1000, Cost: 0.315, PriceKnown: true}, estimate)
+
+	unknown := NewCostEstimate(price, false, 1000, 1000)
+	assert.Equal(t, CostEstimate{InputTokens: 1000, OutputTokens: 1000}, unknown)
+
+	total := estimate.Add(CostEstimate{AudioMinutes: 10, Cost: 0.06, PriceKnown: true})
+	assert.Equal(t, CostEstimate{InputTokens: 100_000, OutputTokens: 1000, AudioMinutes: 10, Cost: 0.375, PriceKnown: true}, total)
+	assert.False(t, total.Add(unknown).PriceKnown)
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"fmt"
+	"slices"
+)
+
+// MiddlewarePriorityRegeneration places the regeneration instruction outside truncation, so it is kept
+// with the latest turn.
+const MiddlewarePriorityRegeneration = 800
+
+// RegenerationMiddleware creates a Middleware that steers responses regenerated with an instruction. The
+// instruction of the request's context is added after the conversation as a system message formatted with
+// the template. Only streamed completions, the responses users see, are steered, not the intermediate
+// completions used to build them.
+func RegenerationMiddleware(prompts *Prompts, templateName string) Middleware {
+	return Interceptor{
+		ChatCompletion: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+			if request.Context == nil || request.Context.RegenerationInstruction == "" {
+				return next.ChatCompletion(request, opts...)
+			}
+
+			steering, err := prompts.Format(templateName, request.Context)
+			if err != nil {
+				return nil, fmt.Errorf("failed to format regeneration instruction: %w", err)
+			}
+			request.Posts = append(slices.Clone(request.Posts), Post{Role: PostRoleSystem, Message: steering})
+			return next.ChatCompletion(request, opts...)
+		},
+	}.Middleware()
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoingModel struct {
+	stubModel
+}
+
+func (e *echoingModel) ChatCompletion(request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	messages := make([]string, 0, len(request.Posts))
+	for _, post := range request.Posts {
+		messages = append(messages, post.Message)
+	}
+	return NewStreamFromString(strings.Join(messages, "|")), nil
+}
+
+func TestRegenerationMiddleware(t *testing.T) {
+	prompts, err := NewPrompts(fstest.MapFS{
+		"regenerate.tmpl": {Data: []byte("rewrite: {{.RegenerationInstruction}}")},
+	})
+	require.NoError(t, err)
+	model := RegenerationMiddleware(prompts, "regenerate")(&echoingModel{})
+
+	posts := []Post{{Role: PostRoleUser, Message: "hi"}}
+
+	result, err := model.ChatCompletion(CompletionRequest{Posts: posts, Context: &Context{}})
+	require.NoError(t, err)
+	text, err := result.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "hi", text)
+
+	result, err = model.ChatCompletion(CompletionRequest{Posts: posts, Context: &Context{RegenerationInstruction: "shorter"}})
+	require.NoError(t, err)
+	text, err = result.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "hi|rewrite: shorter", text)
+	assert.Len(t, posts, 1)
+}
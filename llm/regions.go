@@ -0,0 +1,104 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Routings of the requests of a service between its regions.
+const (
+	// RegionRoutingPriority sends requests to the service's own endpoint, failing over to the regions in order
+	RegionRoutingPriority = "priority"
+
+	// RegionRoutingLatency sends requests to the region answering fastest, failing over to the next fastest
+	RegionRoutingLatency = "latency"
+
+	// DefaultRegionName names the service's own endpoint among its regions
+	DefaultRegionName = "default"
+)
+
+// ErrInvalidRegion is returned when the regions of a service aren't valid.
+var ErrInvalidRegion = errors.New("invalid service region")
+
+// ServiceRegion is another endpoint of the same service, such as a deployment of the same models in
+// another Azure region. Deployments with data locality requirements only list regions they may use.
+type ServiceRegion struct {
+	Name   string `json:"name"`
+	APIURL string `json:"apiURL"`
+
+	// APIKey is the key of the region, the key of the service is used when empty
+	APIKey string `json:"apiKey"`
+}
+
+// ValidateRegions returns an error describing the first invalid region of the service.
+func (c ServiceConfig) ValidateRegions() error {
+	if len(c.Regions) == 0 {
+		return nil
+	}
+
+	switch c.Type {
+	case ServiceTypeOpenAI, ServiceTypeOpenAICompatible, ServiceTypeAzure, ServiceTypeAnthropic:
+	default:
+		return fmt.Errorf("%w: %s services don't support regions", ErrInvalidRegion, c.Type)
+	}
+
+	if c.RegionRouting != "" && c.RegionRouting != RegionRoutingPriority && c.RegionRouting != RegionRoutingLatency {
+		return fmt.Errorf("%w: unknown routing %q", ErrInvalidRegion, c.RegionRouting)
+	}
+
+	seen := map[string]bool{DefaultRegionName: true}
+	for _, region := range c.Regions {
+		if strings.TrimSpace(region.Name) == "" {
+			return fmt.Errorf("%w: regions must be named", ErrInvalidRegion)
+		}
+		if seen[region.Name] {
+			return fmt.Errorf("%w: %q is used more than once", ErrInvalidRegion, region.Name)
+		}
+		seen[region.Name] = true
+
+		apiURL, err := url.Parse(strings.TrimSpace(region.APIURL))
+		if err != nil || (apiURL.Scheme != "https" && apiURL.Scheme != "http") || apiURL.Host == "" {
+			return fmt.Errorf("%w: %q needs an http or https API URL", ErrInvalidRegion, region.Name)
+		}
+	}
+	return nil
+}
+
+// RegionService is the configuration of a service reaching one of its regions.
+type RegionService struct {
+	Name    string
+	Service ServiceConfig
+}
+
+// PrimaryService returns the configuration of the service's own endpoint. OpenAI and Anthropic have a
+// single public endpoint, so an API URL left from another type of service is ignored.
+func (c ServiceConfig) PrimaryService() ServiceConfig {
+	primary := c
+	primary.Regions = nil
+	if c.Type == ServiceTypeOpenAI || c.Type == ServiceTypeAnthropic {
+		primary.APIURL = ""
+	}
+	return primary
+}
+
+// RegionServices returns the configuration of the service for each of its regions, starting with its
+// own endpoint, so each region can be reached like a service of its own.
+func (c ServiceConfig) RegionServices() []RegionService {
+	primary := c.PrimaryService()
+	services := []RegionService{{Name: DefaultRegionName, Service: primary}}
+
+	for _, region := range c.Regions {
+		regional := primary
+		regional.APIURL = strings.TrimSpace(region.APIURL)
+		if region.APIKey != "" {
+			regional.APIKey = region.APIKey
+		}
+		services = append(services, RegionService{Name: region.Name, Service: regional})
+	}
+	return services
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRegions(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceType string
+		routing     string
+		regions     []ServiceRegion
+		wantErr     bool
+	}{
+		{
+			name:        "no regions",
+			serviceType: ServiceTypeVLLM,
+		},
+		{
+			name:        "Azure regions",
+			serviceType: ServiceTypeAzure,
+			routing:     RegionRoutingLatency,
+			regions: []ServiceRegion{
+				{Name: "westeurope", APIURL: "https://acme-westeurope.openai.azure.com", APIKey: "key"},
+				{Name: "swedencentral", APIURL: "https://acme-swedencentral.openai.azure.com"},
+			},
+		},
+		{
+			name:        "unsupported service",
+			serviceType: ServiceTypeVLLM,
+			regions:     []ServiceRegion{{Name: "eu", APIURL: "http://vllm-eu:8000/v1"}},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown routing",
+			serviceType: ServiceTypeAnthropic,
+			routing:     "random",
+			regions:     []ServiceRegion{{Name: "eu", APIURL: "https://eu.example.com"}},
+			wantErr:     true,
+		},
+		{
+			name:        "unnamed region",
+			serviceType: ServiceTypeOpenAI,
+			regions:     []ServiceRegion{{APIURL: "https://eu.api.openai.com/v1"}},
+			wantErr:     true,
+		},
+		{
+			name:        "region named like the service's own endpoint",
+			serviceType: ServiceTypeOpenAI,
+			regions:     []ServiceRegion{{Name: DefaultRegionName, APIURL: "https://eu.api.openai.com/v1"}},
+			wantErr:     true,
+		},
+		{
+			name:        "duplicate region",
+			serviceType: ServiceTypeAzure,
+			regions: []ServiceRegion{
+				{Name: "eu", APIURL: "https://a.openai.azure.com"},
+				{Name: "eu", APIURL: "https://b.openai.azure.com"},
+			},
+			wantErr: true,
+		},
+		{
+			name:        "URL without scheme",
+			serviceType: ServiceTypeAzure,
+			regions:     []ServiceRegion{{Name: "eu", APIURL: "acme.openai.azure.com"}},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ServiceConfig{Type: tc.serviceType, RegionRouting: tc.routing, Regions: tc.regions}.ValidateRegions()
+			if tc.wantErr {
+				assert.True(t, errors.Is(err, ErrInvalidRegion))
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRegionServices(t *testing.T) {
+	service := ServiceConfig{
+		Type:         ServiceTypeOpenAI,
+		APIURL:       "https://left.from.another.type",
+		APIKey:       "key",
+		DefaultModel: "gpt-4o",
+		Regions: []ServiceRegion{
+			{Name: "eu", APIURL: " https://eu.api.openai.com/v1 "},
+			{Name: "us", APIURL: "https://us.example.com/v1", APIKey: "us-key"},
+		},
+	}
+
+	services := service.RegionServices()
+	assert.Len(t, services, 3)
+
+	assert.Equal(t, DefaultRegionName, services[0].Name)
+	assert.Empty(t, services[0].Service.APIURL)
+	assert.Equal(t, "key", services[0].Service.APIKey)
+
+	assert.Equal(t, "eu", services[1].Name)
+	assert.Equal(t, "https://eu.api.openai.com/v1", services[1].Service.APIURL)
+	assert.Equal(t, "key", services[1].Service.APIKey)
+	assert.Equal(t, "gpt-4o", services[1].Service.DefaultModel)
+
+	assert.Equal(t, "us", services[2].Name)
+	assert.Equal(t, "us-key", services[2].Service.APIKey)
+
+	for _, s := range services {
+		assert.Empty(t, s.Service.Regions)
+	}
+}
@@ -3,7 +3,10 @@
 
 package llm
 
-import "fmt"
+import (
+	"fmt"
+	"unicode/utf8"
+)
 
 // EventType represents the type of event in the text stream
 type EventType int
@@ -28,6 +31,141 @@ type TextStreamEvent struct {
 // TextStreamResult represents a stream of text events
 type TextStreamResult struct {
 	Stream <-chan TextStreamEvent
+
+	// Props are added to the post the result is streamed to
+	Props map[string]any
+
+	// Usage counts the tokens of the completion, nil when they aren't counted
+	Usage *TokenUsage
+}
+
+// WithProps adds props to be set on the post the result is streamed to.
+func (t *TextStreamResult) WithProps(props map[string]any) *TextStreamResult {
+	if t.Props == nil {
+		t.Props = make(map[string]any, len(props))
+	}
+	for key, value := range props {
+		t.Props[key] = value
+	}
+	return t
+}
+
+// OnEnd returns a result that calls fn once the stream finishes, either by ending, failing or requesting tool calls.
+func (t *TextStreamResult) OnEnd(fn func()) *TextStreamResult {
+	output := make(chan TextStreamEvent)
+
+	go func() {
+		defer close(output)
+		called := false
+		for event := range t.Stream {
+			if !called && event.Type != EventTypeText {
+				fn()
+				called = true
+			}
+			output <- event
+		}
+		if !called {
+			fn()
+		}
+	}()
+
+	return &TextStreamResult{
+		Stream: output,
+		Props:  t.Props,
+		Usage:  t.Usage,
+	}
+}
+
+// StopAt returns a result that ends the stream at the first stop sequence in the text.
+// It emulates stop sequences for providers that can't apply them natively. Text that could be
+// the start of a stop sequence is held back until the next chunk shows it isn't one.
+func (t *TextStreamResult) StopAt(stopSequences []string) *TextStreamResult {
+	longest := 0
+	for _, stop := range stopSequences {
+		longest = max(longest, len(stop))
+	}
+	if longest == 0 {
+		return t
+	}
+
+	output := make(chan TextStreamEvent)
+
+	go func() {
+		defer close(output)
+		pending := ""
+		stopped := false
+		for event := range t.Stream {
+			if stopped {
+				// Drain the stream so the provider isn't blocked
+				continue
+			}
+
+			if event.Type != EventTypeText {
+				if pending != "" {
+					output <- TextStreamEvent{Type: EventTypeText, Value: pending}
+					pending = ""
+				}
+				output <- event
+				continue
+			}
+
+			text, _ := event.Value.(string)
+			pending += text
+			if trimmed := TrimAtStopSequence(pending, stopSequences); len(trimmed) < len(pending) {
+				if trimmed != "" {
+					output <- TextStreamEvent{Type: EventTypeText, Value: trimmed}
+				}
+				output <- TextStreamEvent{Type: EventTypeEnd}
+				stopped = true
+				continue
+			}
+
+			// Keep back enough text to match a stop sequence split across chunks
+			flush := len(pending) - (longest - 1)
+			for flush > 0 && flush < len(pending) && !utf8.RuneStart(pending[flush]) {
+				flush--
+			}
+			if flush > 0 {
+				output <- TextStreamEvent{Type: EventTypeText, Value: pending[:flush]}
+				pending = pending[flush:]
+			}
+		}
+		if !stopped && pending != "" {
+			output <- TextStreamEvent{Type: EventTypeText, Value: pending}
+		}
+	}()
+
+	return &TextStreamResult{
+		Stream: output,
+		Props:  t.Props,
+		Usage:  t.Usage,
+	}
+}
+
+// Append returns a result that streams text after the stream ends. Nothing is added when it fails
+// or requests tool calls.
+func (t *TextStreamResult) Append(text string) *TextStreamResult {
+	if text == "" {
+		return t
+	}
+
+	output := make(chan TextStreamEvent)
+
+	go func() {
+		defer close(output)
+		for event := range t.Stream {
+			if event.Type == EventTypeEnd {
+				output <- TextStreamEvent{Type: EventTypeText, Value: text}
+			}
+			output <- event
+		}
+	}()
+
+	return &TextStreamResult{
+		Stream: output,
+		Props:  t.Props,
+		Usage:  t.Usage,
+	}
 }
 
 func NewStreamFromString(text string) *TextStreamResult {
@@ -0,0 +1,390 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// SummaryTokenBudgetRatio is the share of the history budget reserved for the summary of dropped turns.
+const SummaryTokenBudgetRatio = 0.2
+
+// DroppedHistoryHeader introduces the synthetic system message that replaces dropped turns.
+const DroppedHistoryHeader = "Summary of the earlier part of this conversation, which was removed to save space:"
+
+// RecentHistoryKept is how many of the latest history posts are kept before the others are ranked by
+// relevance, so follow-up questions that don't repeat their subject keep the turn they refer to.
+const RecentHistoryKept = 2
+
+// RelevanceScorer scores how relevant each message is to the query, higher scores being more relevant.
+// It returns one score per message.
+type RelevanceScorer func(query string, messages []string) ([]float64, error)
+
+// HistorySummarizer condenses conversation turns that were dropped to fit the token budget.
+// The model passed is the one the request will be sent to.
+type HistorySummarizer func(model LanguageModel, dropped []Post) (string, error)
+
+// TokenBudget fits a conversation within a token limit.
+// It always keeps the system prompt and the latest user turn, then the pinned posts, drops the oldest
+// turns first and, when a Summarizer is set, replaces the dropped turns with a summary. When a
+// RelevanceScorer is set, the history posts least relevant to the latest user turn are dropped first instead.
+type TokenBudget struct {
+	MaxTokens       int
+	CountTokens     func(string) int
+	Summarizer      HistorySummarizer
+	RelevanceScorer RelevanceScorer
+}
+
+// Fit trims the request to the budget and returns true if the request was modified.
+func (b TokenBudget) Fit(model LanguageModel, request *CompletionRequest) bool {
+	if b.countPosts(request.Posts) <= b.MaxTokens {
+		return false
+	}
+
+	// Everything from the latest user turn onwards is kept, this includes any tool calls made in response to it.
+	tailStart := len(request.Posts) - 1
+	for i := len(request.Posts) - 1; i >= 0; i-- {
+		if request.Posts[i].Role == PostRoleUser {
+			tailStart = i
+			break
+		}
+	}
+
+	var systemPosts, history []Post
+	for _, post := range request.Posts[:tailStart] {
+		if post.Role == PostRoleSystem {
+			systemPosts = append(systemPosts, post)
+		} else {
+			history = append(history, post)
+		}
+	}
+	tail := slices.Clone(request.Posts[tailStart:])
+
+	remaining := b.MaxTokens - b.countPosts(systemPosts) - b.countPosts(tail)
+	if remaining <= 0 {
+		request.Posts = append(systemPosts, b.trimLatestTurn(tail, remaining)...)
+		if b.countPosts(request.Posts) > b.MaxTokens {
+			// Even the system prompt doesn't fit, fall back to keeping the newest content.
+			request.Truncate(b.MaxTokens, b.CountTokens)
+		}
+		return true
+	}
+
+	summaryReserve := 0
+	if b.Summarizer != nil {
+		summaryReserve = int(float64(remaining) * SummaryTokenBudgetRatio)
+	}
+
+	historyBudget := remaining - summaryReserve
+
+	// Pinned posts are kept before the others whatever their age or relevance, the newest first
+	// when they don't all fit
+	var pinned, unpinned []Post
+	for _, post := range history {
+		if post.Pinned {
+			pinned = append(pinned, post)
+		} else {
+			unpinned = append(unpinned, post)
+		}
+	}
+	keptPinned := b.keepRecent(pinned, historyBudget)
+	for i, post := range pinned {
+		if keptPinned[i] {
+			historyBudget -= b.CountTokens(post.Message)
+		}
+	}
+
+	var keptUnpinned []bool
+	if b.RelevanceScorer != nil && len(tail) > 0 && len(unpinned) > 0 {
+		keptUnpinned = b.keepRelevant(tail[0].Message, unpinned, historyBudget)
+	}
+	if keptUnpinned == nil {
+		keptUnpinned = b.keepRecent(unpinned, historyBudget)
+	}
+
+	var keptHistory, dropped []Post
+	pinnedIndex, unpinnedIndex := 0, 0
+	for _, post := range history {
+		var kept bool
+		if post.Pinned {
+			kept = keptPinned[pinnedIndex]
+			pinnedIndex++
+		} else {
+			kept = keptUnpinned[unpinnedIndex]
+			unpinnedIndex++
+		}
+		if kept {
+			keptHistory = append(keptHistory, post)
+		} else {
+			dropped = append(dropped, post)
+		}
+	}
+
+	posts := make([]Post, 0, len(systemPosts)+len(keptHistory)+len(tail)+1)
+	posts = append(posts, systemPosts...)
+	if len(dropped) > 0 && b.Summarizer != nil {
+		if summary := b.summarize(model, dropped, summaryReserve); summary != "" {
+			posts = append(posts, Post{Role: PostRoleSystem, Message: summary})
+		}
+	}
+	posts = append(posts, keptHistory...)
+	posts = append(posts, tail...)
+	request.Posts = posts
+
+	return true
+}
+
+// keepRecent keeps the newest history posts that fit the budget.
+func (b TokenBudget) keepRecent(history []Post, budget int) []bool {
+	kept := make([]bool, len(history))
+	used := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		postTokens := b.CountTokens(history[i].Message)
+		if used+postTokens > budget {
+			break
+		}
+		used += postTokens
+		kept[i] = true
+	}
+	return kept
+}
+
+// keepRelevant keeps the latest history posts, then the posts most relevant to the query that fit the
+// budget, the newest first among equally relevant ones. It returns nil when the posts can't be scored.
+func (b TokenBudget) keepRelevant(query string, history []Post, budget int) []bool {
+	messages := make([]string, 0, len(history))
+	for _, post := range history {
+		messages = append(messages, post.Message)
+	}
+	scores, err := b.RelevanceScorer(query, messages)
+	if err != nil || len(scores) != len(history) {
+		// Falling back to recency is preferable to failing the whole request.
+		return nil
+	}
+
+	order := make([]int, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		order = append(order, i)
+	}
+	recent := min(RecentHistoryKept, len(order))
+	slices.SortStableFunc(order[recent:], func(i, j int) int {
+		switch {
+		case scores[i] > scores[j]:
+			return -1
+		case scores[i] < scores[j]:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	kept := make([]bool, len(history))
+	used := 0
+	for _, i := range order {
+		postTokens := b.CountTokens(history[i].Message)
+		if used+postTokens > budget {
+			// Smaller, less relevant posts may still fit
+			continue
+		}
+		used += postTokens
+		kept[i] = true
+	}
+	return kept
+}
+
+func (b TokenBudget) summarize(model LanguageModel, dropped []Post, maxTokens int) string {
+	summary, err := b.Summarizer(model, dropped)
+	if err != nil || strings.TrimSpace(summary) == "" {
+		// Losing the summary is preferable to failing the whole request.
+		return ""
+	}
+
+	message := DroppedHistoryHeader + "\n" + strings.TrimSpace(summary)
+	if overflow := b.CountTokens(message) - maxTokens; overflow > 0 {
+		// Tokens are roughly four characters
+		cut := len(message) - overflow*4
+		if cut <= len(DroppedHistoryHeader) {
+			return ""
+		}
+		for cut > 0 && !utf8.RuneStart(message[cut]) {
+			cut--
+		}
+		message = strings.TrimSpace(message[:cut])
+	}
+
+	return message
+}
+
+// trimLatestTurn cuts the start of the latest user turn so the tail fits when remaining is negative.
+func (b TokenBudget) trimLatestTurn(tail []Post, remaining int) []Post {
+	if len(tail) == 0 || remaining >= 0 {
+		return tail
+	}
+
+	tail[0].Message = trimTokensFromStart(tail[0].Message, -remaining)
+
+	return tail
+}
+
+// trimTokensFromStart removes roughly the given number of tokens from the start of the message.
+func trimTokensFromStart(message string, tokens int) string {
+	// Tokens are roughly four characters
+	charactersToCut := tokens * 4
+	if charactersToCut >= len(message) {
+		return ""
+	}
+	// Cut on a rune boundary so multi-byte characters aren't split
+	for charactersToCut < len(message) && !utf8.RuneStart(message[charactersToCut]) {
+		charactersToCut++
+	}
+	return strings.TrimSpace(message[charactersToCut:])
+}
+
+// NewPromptHistorySummarizer creates a HistorySummarizer that asks the model to summarize
+// the dropped turns using the given system prompt template.
+func NewPromptHistorySummarizer(prompts *Prompts, templateName string) HistorySummarizer {
+	return func(model LanguageModel, dropped []Post) (string, error) {
+		systemMessage, err := prompts.Format(templateName, NewContext())
+		if err != nil {
+			return "", fmt.Errorf("failed to format history summary prompt: %w", err)
+		}
+
+		var conversation strings.Builder
+		for _, post := range dropped {
+			switch post.Role {
+			case PostRoleUser:
+				conversation.WriteString("--- User ---\n")
+			case PostRoleBot:
+				conversation.WriteString("--- Bot ---\n")
+			default:
+				continue
+			}
+			conversation.WriteString(post.Message)
+			conversation.WriteString("\n")
+		}
+
+		// The dropped turns can be larger than the model accepts, keep the most recent part of them.
+		userMessage := conversation.String()
+		conversationBudget := model.InputTokenLimit() - FunctionsTokenBudget - model.CountTokens(systemMessage)
+		if overflow := model.CountTokens(userMessage) - conversationBudget; overflow > 0 {
+			userMessage = trimTokensFromStart(userMessage, overflow)
+		}
+
+		request := CompletionRequest{
+			Posts: []Post{
+				{Role: PostRoleSystem, Message: systemMessage},
+				{Role: PostRoleUser, Message: userMessage},
+			},
+			Context: NewContext(),
+		}
+
+		summary, err := model.ChatCompletionNoStream(request)
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize dropped history: %w", err)
+		}
+
+		return summary, nil
+	}
+}
+
+// HistorySummaryCacheSize is how many summaries of dropped history a HistorySummaryCache keeps.
+const HistorySummaryCacheSize = 256
+
+// HistorySummaryCache wraps a summarizer so the same dropped turns of a thread are only summarized once.
+// Every request in a long thread drops the same range of it, such as the tool call rounds of a response
+// or its regenerations, until the thread grows.
+type HistorySummaryCache struct {
+	summarizer HistorySummarizer
+	cache      *historySummaryCache
+}
+
+// NewHistorySummaryCache creates a cache of the summaries made by the summarizer, keeping the latest size ones.
+func NewHistorySummaryCache(summarizer HistorySummarizer, size int) *HistorySummaryCache {
+	return &HistorySummaryCache{
+		summarizer: summarizer,
+		cache:      &historySummaryCache{size: size, summaries: make(map[string]string)},
+	}
+}
+
+// Summarizer returns the cached summarizer of the bot. Its summaries are kept apart from those of other
+// bots and models, which summarize the same turns in their own way.
+func (c *HistorySummaryCache) Summarizer(bot BotConfig) HistorySummarizer {
+	scope := strings.Join([]string{bot.ID, bot.Service.Type, bot.Service.DefaultModel}, "/")
+	return func(model LanguageModel, dropped []Post) (string, error) {
+		key := droppedHistoryKey(scope, dropped)
+		if summary, ok := c.cache.get(key); ok {
+			return summary, nil
+		}
+
+		summary, err := c.summarizer(model, dropped)
+		if err != nil {
+			return "", err
+		}
+		c.cache.add(key, summary)
+		return summary, nil
+	}
+}
+
+// droppedHistoryKey identifies the dropped turns by the bot and model summarizing them and by their
+// content, which includes the start of the thread they were dropped from.
+func droppedHistoryKey(scope string, dropped []Post) string {
+	hash := sha256.New()
+	var scopeLength [8]byte
+	binary.BigEndian.PutUint64(scopeLength[:], uint64(len(scope)))
+	hash.Write(scopeLength[:])
+	hash.Write([]byte(scope))
+	for _, post := range dropped {
+		var header [9]byte
+		header[0] = byte(post.Role)
+		binary.BigEndian.PutUint64(header[1:], uint64(len(post.Message)))
+		hash.Write(header[:])
+		hash.Write([]byte(post.Message))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// historySummaryCache keeps the latest summaries, evicting the oldest once full.
+type historySummaryCache struct {
+	mu        sync.Mutex
+	size      int
+	order     []string
+	summaries map[string]string
+}
+
+func (c *historySummaryCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	summary, ok := c.summaries[key]
+	return summary, ok
+}
+
+func (c *historySummaryCache) add(key, summary string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.summaries[key]; exists {
+		c.summaries[key] = summary
+		return
+	}
+	if len(c.order) >= c.size && len(c.order) > 0 {
+		delete(c.summaries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.order = append(c.order, key)
+	c.summaries[key] = summary
+}
+
+func (b TokenBudget) countPosts(posts []Post) int {
+	total := 0
+	for _, post := range posts {
+		total += b.CountTokens(post.Message)
+	}
+	return total
+}
@@ -0,0 +1,315 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBudgetFit(t *testing.T) {
+	// Same approximation as the providers' heuristics, four characters per token
+	countTokens := func(text string) int {
+		return len(text) / 4
+	}
+
+	summarizer := func(_ LanguageModel, _ []Post) (string, error) {
+		return "summary", nil
+	}
+
+	failingSummarizer := func(_ LanguageModel, _ []Post) (string, error) {
+		return "", errors.New("failed")
+	}
+
+	// The first turn is the one relevant to the latest question
+	scorer := func(_ string, messages []string) ([]float64, error) {
+		scores := make([]float64, len(messages))
+		for i, message := range messages {
+			if strings.HasPrefix(message, "a") {
+				scores[i] = 0.9
+			} else {
+				scores[i] = 0.1
+			}
+		}
+		return scores, nil
+	}
+
+	failingScorer := func(_ string, _ []string) ([]float64, error) {
+		return nil, errors.New("failed")
+	}
+
+	// Each message is ten tokens
+	message := func(c string) string {
+		return strings.Repeat(c, 40)
+	}
+
+	conversation := []Post{
+		{Role: PostRoleSystem, Message: message("s")},
+		{Role: PostRoleUser, Message: message("a")},
+		{Role: PostRoleBot, Message: message("b")},
+		{Role: PostRoleUser, Message: message("c")},
+		{Role: PostRoleBot, Message: message("d")},
+		{Role: PostRoleUser, Message: message("l")},
+	}
+
+	tests := []struct {
+		name          string
+		maxTokens     int
+		summarizer    HistorySummarizer
+		scorer        RelevanceScorer
+		posts         []Post
+		wantTruncated bool
+		wantPosts     []Post
+	}{
+		{
+			name:          "fits within budget",
+			maxTokens:     1000,
+			posts:         conversation,
+			wantTruncated: false,
+			wantPosts:     conversation,
+		},
+		{
+			name:          "drops oldest turns without summarizer",
+			maxTokens:     40,
+			posts:         conversation,
+			wantTruncated: true,
+			wantPosts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleUser, Message: message("c")},
+				{Role: PostRoleBot, Message: message("d")},
+				{Role: PostRoleUser, Message: message("l")},
+			},
+		},
+		{
+			name:          "drops the least relevant turns first",
+			maxTokens:     50,
+			scorer:        scorer,
+			posts:         conversation,
+			wantTruncated: true,
+			wantPosts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleUser, Message: message("a")},
+				{Role: PostRoleUser, Message: message("c")},
+				{Role: PostRoleBot, Message: message("d")},
+				{Role: PostRoleUser, Message: message("l")},
+			},
+		},
+		{
+			name:          "keeps the latest turns whatever their relevance",
+			maxTokens:     40,
+			scorer:        scorer,
+			posts:         conversation,
+			wantTruncated: true,
+			wantPosts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleUser, Message: message("c")},
+				{Role: PostRoleBot, Message: message("d")},
+				{Role: PostRoleUser, Message: message("l")},
+			},
+		},
+		{
+			name:          "scorer failure drops the oldest turns",
+			maxTokens:     50,
+			scorer:        failingScorer,
+			posts:         conversation,
+			wantTruncated: true,
+			wantPosts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleBot, Message: message("b")},
+				{Role: PostRoleUser, Message: message("c")},
+				{Role: PostRoleBot, Message: message("d")},
+				{Role: PostRoleUser, Message: message("l")},
+			},
+		},
+		{
+			name:      "keeps pinned turns whatever their age",
+			maxTokens: 40,
+			posts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleUser, Message: message("a"), Pinned: true},
+				{Role: PostRoleBot, Message: message("b")},
+				{Role: PostRoleUser, Message: message("c")},
+				{Role: PostRoleBot, Message: message("d")},
+				{Role: PostRoleUser, Message: message("l")},
+			},
+			wantTruncated: true,
+			wantPosts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleUser, Message: message("a"), Pinned: true},
+				{Role: PostRoleBot, Message: message("d")},
+				{Role: PostRoleUser, Message: message("l")},
+			},
+		},
+		{
+			name:      "keeps the newest pinned turns that fit",
+			maxTokens: 30,
+			scorer:    scorer,
+			posts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleUser, Message: message("a"), Pinned: true},
+				{Role: PostRoleBot, Message: message("b"), Pinned: true},
+				{Role: PostRoleUser, Message: message("c")},
+				{Role: PostRoleUser, Message: message("l")},
+			},
+			wantTruncated: true,
+			wantPosts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleBot, Message: message("b"), Pinned: true},
+				{Role: PostRoleUser, Message: message("l")},
+			},
+		},
+		{
+			name:       "summarizes dropped turns",
+			maxTokens:  240,
+			summarizer: summarizer,
+			posts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleUser, Message: strings.Repeat("a", 400)},
+				{Role: PostRoleBot, Message: strings.Repeat("b", 400)},
+				{Role: PostRoleUser, Message: strings.Repeat("c", 400)},
+				{Role: PostRoleBot, Message: strings.Repeat("d", 400)},
+				{Role: PostRoleUser, Message: message("l")},
+			},
+			wantTruncated: true,
+			wantPosts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleSystem, Message: DroppedHistoryHeader + "\nsummary"},
+				{Role: PostRoleBot, Message: strings.Repeat("d", 400)},
+				{Role: PostRoleUser, Message: message("l")},
+			},
+		},
+		{
+			name:          "summarizer failure drops history",
+			maxTokens:     40,
+			summarizer:    failingSummarizer,
+			posts:         conversation,
+			wantTruncated: true,
+			wantPosts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleBot, Message: message("d")},
+				{Role: PostRoleUser, Message: message("l")},
+			},
+		},
+		{
+			name:      "trims the start of the latest turn when it alone is too large",
+			maxTokens: 20,
+			posts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleBot, Message: message("b")},
+				{Role: PostRoleUser, Message: message("x") + message("y")},
+			},
+			wantTruncated: true,
+			wantPosts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleUser, Message: message("y")},
+			},
+		},
+		{
+			name:      "keeps tool calls made after the latest user turn",
+			maxTokens: 30,
+			posts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleUser, Message: message("a")},
+				{Role: PostRoleUser, Message: message("l")},
+				{Role: PostRoleBot, Message: message("t"), ToolUse: []ToolCall{{ID: "tool"}}},
+			},
+			wantTruncated: true,
+			wantPosts: []Post{
+				{Role: PostRoleSystem, Message: message("s")},
+				{Role: PostRoleUser, Message: message("l")},
+				{Role: PostRoleBot, Message: message("t"), ToolUse: []ToolCall{{ID: "tool"}}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := CompletionRequest{Posts: tc.posts}
+			budget := TokenBudget{
+				MaxTokens:       tc.maxTokens,
+				CountTokens:     countTokens,
+				Summarizer:      tc.summarizer,
+				RelevanceScorer: tc.scorer,
+			}
+
+			truncated := budget.Fit(&stubModel{}, &request)
+			assert.Equal(t, tc.wantTruncated, truncated)
+			assert.Equal(t, tc.wantPosts, request.Posts)
+		})
+	}
+}
+
+func TestTrimTokensFromStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		tokens  int
+		want    string
+	}{
+		{name: "ascii", message: "abcdefgh", tokens: 1, want: "efgh"},
+		{name: "cuts on a rune boundary", message: "aééé", tokens: 1, want: "é"},
+		{name: "cuts everything", message: "abc", tokens: 1, want: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, trimTokensFromStart(tc.message, tc.tokens))
+		})
+	}
+}
+
+func TestHistorySummaryCache(t *testing.T) {
+	calls := 0
+	cache := NewHistorySummaryCache(func(_ LanguageModel, dropped []Post) (string, error) {
+		calls++
+		return "summary of " + dropped[0].Message, nil
+	}, 3)
+	bot := BotConfig{ID: "bot1", Service: ServiceConfig{Type: "openai", DefaultModel: "gpt-4o"}}
+	summarizer := cache.Summarizer(bot)
+
+	first := []Post{{Role: PostRoleUser, Message: "first"}}
+	second := []Post{{Role: PostRoleUser, Message: "second"}}
+
+	summary, err := summarizer(&stubModel{}, first)
+	assert.NoError(t, err)
+	assert.Equal(t, "summary of first", summary)
+
+	summary, err = cache.Summarizer(bot)(&stubModel{}, first)
+	assert.NoError(t, err)
+	assert.Equal(t, "summary of first", summary)
+	assert.Equal(t, 1, calls)
+
+	// Other bots and models summarize the same turns themselves
+	otherBot := bot
+	otherBot.ID = "bot2"
+	_, err = cache.Summarizer(otherBot)(&stubModel{}, first)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	otherModel := bot
+	otherModel.Service.DefaultModel = "gpt-4o-mini"
+	_, err = cache.Summarizer(otherModel)(&stubModel{}, first)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	// The oldest summary is evicted once the cache is full
+	_, err = summarizer(&stubModel{}, second)
+	assert.NoError(t, err)
+	_, err = summarizer(&stubModel{}, first)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, calls)
+
+	// Failures aren't cached
+	failing := NewHistorySummaryCache(func(_ LanguageModel, _ []Post) (string, error) {
+		calls++
+		return "", errors.New("failed")
+	}, 1).Summarizer(bot)
+	_, err = failing(&stubModel{}, first)
+	assert.Error(t, err)
+	_, err = failing(&stubModel{}, first)
+	assert.Error(t, err)
+	assert.Equal(t, 7, calls)
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import "slices"
+
+const (
+	// ToolApproversChannelAdmins routes approvals to the admins of the channel the tool was called in.
+	ToolApproversChannelAdmins = "channel_admins"
+
+	// ToolApproversUsers routes approvals to a named group of users.
+	ToolApproversUsers = "users"
+)
+
+// ToolApprovalPolicy has calls to certain tools approved by someone other than the requesting user.
+type ToolApprovalPolicy struct {
+	// Tools are the names of the tools the policy applies to.
+	Tools []string `json:"tools"`
+
+	// Approvers is either ToolApproversChannelAdmins or ToolApproversUsers.
+	Approvers string `json:"approvers"`
+
+	// ApproverUserIDs approve calls for ToolApproversUsers, and for ToolApproversChannelAdmins
+	// in channels without admins such as direct messages.
+	ApproverUserIDs []string `json:"approverUserIDs"`
+}
+
+// FindToolApprovalPolicy returns the first policy covering the tool,
+// or nil when calls to the tool are approved by the requesting user.
+func FindToolApprovalPolicy(policies []ToolApprovalPolicy, toolName string) *ToolApprovalPolicy {
+	for i := range policies {
+		if slices.Contains(policies[i].Tools, toolName) {
+			return &policies[i]
+		}
+	}
+	return nil
+}
+
+// CanBeDecidedBy reports whether the user can approve or reject the tool call.
+// Tool calls without delegated approvers are decided by the user who made the request.
+func (tc ToolCall) CanBeDecidedBy(userID string, requesterUserID string) bool {
+	if len(tc.ApproverIDs) == 0 {
+		return userID == requesterUserID
+	}
+	return slices.Contains(tc.ApproverIDs, userID)
+}
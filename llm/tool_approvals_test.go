@@ -0,0 +1,90 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindToolApprovalPolicy(t *testing.T) {
+	policies := []ToolApprovalPolicy{
+		{Tools: []string{"create_issue", "delete_issue"}, Approvers: ToolApproversUsers, ApproverUserIDs: []string{"approver"}},
+		{Tools: []string{"delete_issue", "send_email"}, Approvers: ToolApproversChannelAdmins},
+	}
+
+	tests := []struct {
+		name     string
+		toolName string
+		want     *ToolApprovalPolicy
+	}{
+		{
+			name:     "covered tool",
+			toolName: "send_email",
+			want:     &policies[1],
+		},
+		{
+			name:     "first policy wins",
+			toolName: "delete_issue",
+			want:     &policies[0],
+		},
+		{
+			name:     "tool without policy",
+			toolName: "search",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FindToolApprovalPolicy(policies, tt.toolName))
+		})
+	}
+}
+
+func TestToolCallCanBeDecidedBy(t *testing.T) {
+	tests := []struct {
+		name     string
+		toolCall ToolCall
+		userID   string
+		want     bool
+	}{
+		{
+			name:     "requester without approvers",
+			toolCall: ToolCall{},
+			userID:   "requester",
+			want:     true,
+		},
+		{
+			name:     "other user without approvers",
+			toolCall: ToolCall{},
+			userID:   "other",
+			want:     false,
+		},
+		{
+			name:     "requester with approvers",
+			toolCall: ToolCall{ApproverIDs: []string{"approver"}},
+			userID:   "requester",
+			want:     false,
+		},
+		{
+			name:     "approver",
+			toolCall: ToolCall{ApproverIDs: []string{"approver"}},
+			userID:   "approver",
+			want:     true,
+		},
+		{
+			name:     "requester who is also an approver",
+			toolCall: ToolCall{ApproverIDs: []string{"approver", "requester"}},
+			userID:   "requester",
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.toolCall.CanBeDecidedBy(tt.userID, "requester"))
+		})
+	}
+}
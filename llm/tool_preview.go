@@ -0,0 +1,252 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// MiddlewarePriorityToolPreview places the tool call previews inside the other middlewares, so the tool
+// calls of every model are previewed before they are posted.
+const MiddlewarePriorityToolPreview = 1200
+
+// maxToolArgumentPreviewLength limits the length of the value of an argument in its preview
+const maxToolArgumentPreviewLength = 500
+
+// ToolArgument is an argument of a tool call, with its value formatted for the user approving the call.
+type ToolArgument struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ToolPreviewMiddleware creates a Middleware that describes the tool calls of streamed completions before
+// they are posted for approval, with their arguments validated against the schema of the tool.
+func ToolPreviewMiddleware() Middleware {
+	return Interceptor{
+		ChatCompletion: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+			result, err := next.ChatCompletion(request, opts...)
+			if err != nil || request.Context == nil || request.Context.Tools == nil {
+				return result, err
+			}
+			return result.WithToolPreviews(request.Context.Tools), nil
+		},
+	}.Middleware()
+}
+
+// WithToolPreviews returns a result whose tool calls are previewed with the tools of the store.
+func (t *TextStreamResult) WithToolPreviews(tools *ToolStore) *TextStreamResult {
+	output := make(chan TextStreamEvent)
+
+	go func() {
+		defer close(output)
+		for event := range t.Stream {
+			if event.Type == EventTypeToolCalls {
+				if toolCalls, ok := event.Value.([]ToolCall); ok {
+					tools.PreviewToolCalls(toolCalls)
+				}
+			}
+			output <- event
+		}
+	}()
+
+	return &TextStreamResult{
+		Stream: output,
+		Props:  t.Props,
+		Usage:  t.Usage,
+	}
+}
+
+// PreviewToolCalls fills in the description of the tool calls, the summary of their arguments in the
+// order of the schema, and the ways their arguments don't match the schema.
+func (s *ToolStore) PreviewToolCalls(toolCalls []ToolCall) {
+	for i := range toolCalls {
+		tool, ok := s.tools[toolCalls[i].Name]
+		if !ok {
+			toolCalls[i].ArgumentErrors = []string{"unknown tool " + toolCalls[i].Name}
+			continue
+		}
+		if toolCalls[i].Description == "" {
+			toolCalls[i].Description = tool.Description
+		}
+
+		var args map[string]json.RawMessage
+		if err := json.Unmarshal(toolCalls[i].Arguments, &args); err != nil || args == nil {
+			toolCalls[i].ArgumentErrors = []string{"the arguments are not a JSON object"}
+			continue
+		}
+		toolCalls[i].ArgumentSummary = summarizeToolArguments(tool.Schema, args)
+		toolCalls[i].ArgumentErrors = validateToolArguments(tool.Schema, args)
+	}
+}
+
+// propertyNames returns the names of the arguments, those of the schema first in their order and then
+// the others sorted.
+func propertyNames(schema *jsonschema.Schema, args map[string]json.RawMessage) []string {
+	names := []string{}
+	if schema != nil && schema.Properties != nil {
+		for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			if _, ok := args[pair.Key]; ok {
+				names = append(names, pair.Key)
+			}
+		}
+	}
+
+	others := []string{}
+	for name := range args {
+		if !slices.Contains(names, name) {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...)
+}
+
+func summarizeToolArguments(schema *jsonschema.Schema, args map[string]json.RawMessage) []ToolArgument {
+	summary := []ToolArgument{}
+	for _, name := range propertyNames(schema, args) {
+		summary = append(summary, ToolArgument{Name: name, Value: formatToolArgument(args[name])})
+	}
+	return summary
+}
+
+// formatToolArgument returns a value as the user reads it: strings without quotes, lists of values
+// separated by commas and objects as compact JSON.
+func formatToolArgument(value json.RawMessage) string {
+	var decoded any
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return truncateToolArgument(string(value))
+	}
+
+	var formatted string
+	switch v := decoded.(type) {
+	case nil:
+		formatted = "(none)"
+	case string:
+		formatted = v
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			data, _ := json.Marshal(item)
+			items = append(items, formatToolArgument(data))
+		}
+		formatted = strings.Join(items, ", ")
+	default:
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, value); err != nil {
+			formatted = string(value)
+		} else {
+			formatted = compact.String()
+		}
+	}
+	return truncateToolArgument(formatted)
+}
+
+func truncateToolArgument(value string) string {
+	runes := []rune(value)
+	if len(runes) <= maxToolArgumentPreviewLength {
+		return value
+	}
+	return string(runes[:maxToolArgumentPreviewLength]) + "…"
+}
+
+// validateToolArguments checks the required arguments are given, and that the arguments have the type
+// and one of the values the schema allows. Arguments the schema doesn't list are reported when it
+// doesn't allow additional properties.
+func validateToolArguments(schema *jsonschema.Schema, args map[string]json.RawMessage) []string {
+	if schema == nil {
+		return nil
+	}
+
+	problems := []string{}
+	for _, name := range schema.Required {
+		if _, ok := args[name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s is required", name))
+		}
+	}
+
+	noAdditional := false
+	if schema.AdditionalProperties != nil {
+		data, _ := json.Marshal(schema.AdditionalProperties)
+		noAdditional = string(data) == "false"
+	}
+
+	for _, name := range propertyNames(schema, args) {
+		var property *jsonschema.Schema
+		if schema.Properties != nil {
+			property, _ = schema.Properties.Get(name)
+		}
+		if property == nil {
+			if noAdditional {
+				problems = append(problems, fmt.Sprintf("%s is not an argument of the tool", name))
+			}
+			continue
+		}
+		if problem := validateToolArgument(property, args[name]); problem != "" {
+			problems = append(problems, fmt.Sprintf("%s %s", name, problem))
+		}
+	}
+	return problems
+}
+
+func validateToolArgument(property *jsonschema.Schema, value json.RawMessage) string {
+	var decoded any
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return "is not valid JSON"
+	}
+
+	if property.Type != "" && !hasJSONType(decoded, property.Type) {
+		return "must be of type " + property.Type
+	}
+
+	if len(property.Enum) > 0 && !slices.ContainsFunc(property.Enum, func(allowed any) bool {
+		// Allowed values are compared as decoded from JSON, like the value
+		data, _ := json.Marshal(allowed)
+		var decodedAllowed any
+		_ = json.Unmarshal(data, &decodedAllowed)
+		return reflect.DeepEqual(decodedAllowed, decoded)
+	}) {
+		allowed := make([]string, 0, len(property.Enum))
+		for _, value := range property.Enum {
+			data, _ := json.Marshal(value)
+			allowed = append(allowed, string(data))
+		}
+		return "must be one of " + strings.Join(allowed, ", ")
+	}
+	return ""
+}
+
+func hasJSONType(value any, schemaType string) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
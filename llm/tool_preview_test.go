@@ -0,0 +1,129 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type previewTestArgs struct {
+	Repository string   `json:"repository"`
+	State      string   `json:"state" jsonschema:"enum=open,enum=closed"`
+	Labels     []string `json:"labels,omitempty"`
+	Limit      int      `json:"limit,omitempty"`
+	Filter     struct {
+		Author string `json:"author"`
+	} `json:"filter,omitempty"`
+}
+
+func TestPreviewToolCalls(t *testing.T) {
+	store := NewNoTools()
+	store.AddTools([]Tool{{
+		Name:        "search_issues",
+		Description: "Search the issues of a repository",
+		Schema:      NewJSONSchemaFromStruct(previewTestArgs{}),
+	}})
+
+	tests := []struct {
+		name            string
+		toolName        string
+		arguments       string
+		expectedSummary []ToolArgument
+		expectedErrors  []string
+	}{
+		{
+			name:      "summarizes arguments in schema order",
+			toolName:  "search_issues",
+			arguments: `{"limit": 10, "labels": ["bug", "ui"], "state": "open", "repository": "mattermost/mattermost", "filter": {"author": "bob"}}`,
+			expectedSummary: []ToolArgument{
+				{Name: "repository", Value: "mattermost/mattermost"},
+				{Name: "state", Value: "open"},
+				{Name: "labels", Value: "bug, ui"},
+				{Name: "limit", Value: "10"},
+				{Name: "filter", Value: `{"author":"bob"}`},
+			},
+			expectedErrors: []string{},
+		},
+		{
+			name:      "reports missing, mistyped and unknown arguments",
+			toolName:  "search_issues",
+			arguments: `{"state": "merged", "limit": 1.5, "extra": null}`,
+			expectedSummary: []ToolArgument{
+				{Name: "state", Value: "merged"},
+				{Name: "limit", Value: "1.5"},
+				{Name: "extra", Value: "(none)"},
+			},
+			expectedErrors: []string{
+				"repository is required",
+				`state must be one of "open", "closed"`,
+				"limit must be of type integer",
+				"extra is not an argument of the tool",
+			},
+		},
+		{
+			name:           "reports arguments that aren't an object",
+			toolName:       "search_issues",
+			arguments:      `["mattermost"]`,
+			expectedErrors: []string{"the arguments are not a JSON object"},
+		},
+		{
+			name:           "reports unknown tools",
+			toolName:       "delete_repository",
+			arguments:      `{}`,
+			expectedErrors: []string{"unknown tool delete_repository"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			toolCalls := []ToolCall{{Name: tc.toolName, Arguments: json.RawMessage(tc.arguments)}}
+			store.PreviewToolCalls(toolCalls)
+
+			assert.Equal(t, tc.expectedSummary, toolCalls[0].ArgumentSummary)
+			assert.Equal(t, tc.expectedErrors, toolCalls[0].ArgumentErrors)
+		})
+	}
+}
+
+func TestFormatToolArgumentTruncates(t *testing.T) {
+	value, _ := json.Marshal(strings.Repeat("é", maxToolArgumentPreviewLength+10))
+
+	formatted := formatToolArgument(value)
+	assert.Equal(t, strings.Repeat("é", maxToolArgumentPreviewLength)+"…", formatted)
+}
+
+func TestToolPreviewMiddleware(t *testing.T) {
+	store := NewNoTools()
+	store.AddTools([]Tool{{
+		Name:        "search_issues",
+		Description: "Search the issues of a repository",
+		Schema:      NewJSONSchemaFromStruct(previewTestArgs{}),
+	}})
+
+	stream := make(chan TextStreamEvent, 2)
+	stream <- TextStreamEvent{Type: EventTypeToolCalls, Value: []ToolCall{{
+		Name:      "search_issues",
+		Arguments: json.RawMessage(`{"repository": "mattermost/mattermost", "state": "closed"}`),
+	}}}
+	stream <- TextStreamEvent{Type: EventTypeEnd}
+	close(stream)
+
+	result := (&TextStreamResult{Stream: stream}).WithToolPreviews(store)
+
+	event := <-result.Stream
+	toolCalls := event.Value.([]ToolCall)
+	assert.Equal(t, "Search the issues of a repository", toolCalls[0].Description)
+	assert.Equal(t, []ToolArgument{
+		{Name: "repository", Value: "mattermost/mattermost"},
+		{Name: "state", Value: "closed"},
+	}, toolCalls[0].ArgumentSummary)
+	assert.Empty(t, toolCalls[0].ArgumentErrors)
+
+	event = <-result.Stream
+	assert.Equal(t, EventTypeEnd, event.Type)
+}
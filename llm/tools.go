@@ -4,9 +4,12 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/invopop/jsonschema"
 )
@@ -17,15 +20,25 @@ import (
 // It is the Resolver function that implements the actual functionality.
 //
 // The Schema field should contain a JSONSchema that defines the expected structure of the tool's arguments.
-// The Resolver function receives the conversation context and a way to access the parsed arguments,
-// and returns either a result that will be passed to the LLM or an error.
+// The Resolver function receives a context cancelled once the tool times out or the request is stopped,
+// the conversation context and a way to access the parsed arguments, and returns either a result that
+// will be passed to the LLM or an error.
 type Tool struct {
 	Name        string
 	Description string
 	Schema      *jsonschema.Schema
-	Resolver    func(context *Context, argsGetter ToolArgumentGetter) (string, error)
+	Resolver    func(ctx context.Context, llmContext *Context, argsGetter ToolArgumentGetter) (string, error)
+
+	// Timeout is how long the tool can run, DefaultToolTimeout when zero.
+	Timeout time.Duration
 }
 
+// DefaultToolTimeout is how long tools without their own timeout can run
+const DefaultToolTimeout = 5 * time.Minute
+
+// ErrToolTimedOut is returned when a tool doesn't resolve before its timeout.
+var ErrToolTimedOut = errors.New("tool call timed out")
+
 // ToolCallStatus represents the current status of a tool call
 type ToolCallStatus int
 
@@ -40,6 +53,8 @@ const (
 	ToolCallStatusError
 	// ToolCallStatusSuccess indicates the tool call was accepted and resolved successfully
 	ToolCallStatusSuccess
+	// ToolCallStatusTimedOut indicates the tool call was accepted but didn't resolve before its timeout
+	ToolCallStatusTimedOut
 )
 
 // ToolCall represents a tool call. An empty result indicates that the tool has not yet been resolved.
@@ -50,6 +65,24 @@ type ToolCall struct {
 	Arguments   json.RawMessage `json:"arguments"`
 	Result      string          `json:"result"`
 	Status      ToolCallStatus  `json:"status"`
+
+	// ProviderRequest is the tool call exactly as the provider returned it, kept to explain what was executed.
+	ProviderRequest json.RawMessage `json:"provider_request,omitempty"`
+
+	// Error is the reason a tool call failed to resolve.
+	Error string `json:"error,omitempty"`
+
+	// ApproverIDs are the users who decide on the tool call instead of the requesting user.
+	ApproverIDs []string `json:"approver_ids,omitempty"`
+
+	// DecidedBy is the user who approved or rejected the tool call.
+	DecidedBy string `json:"decided_by,omitempty"`
+
+	// ArgumentSummary are the arguments formatted for the user deciding on the tool call.
+	ArgumentSummary []ToolArgument `json:"argument_summary,omitempty"`
+
+	// ArgumentErrors are the ways the arguments don't match the schema of the tool.
+	ArgumentErrors []string `json:"argument_errors,omitempty"`
 }
 
 type ToolArgumentGetter func(args any) error
@@ -97,17 +130,39 @@ func (s *ToolStore) AddTools(tools []Tool) {
 	}
 }
 
-func (s *ToolStore) ResolveTool(name string, argsGetter ToolArgumentGetter, context *Context) (string, error) {
+// ResolveTool runs the named tool until it resolves, times out or ctx is cancelled. Tools that time out
+// return ErrToolTimedOut.
+func (s *ToolStore) ResolveTool(ctx context.Context, name string, argsGetter ToolArgumentGetter, llmContext *Context) (string, error) {
 	tool, ok := s.tools[name]
 	if !ok {
 		s.TraceUnknown(name, argsGetter)
 		return "", errors.New("unknown tool " + name)
 	}
-	results, err := tool.Resolver(context, argsGetter)
+
+	timeout := tool.Timeout
+	if timeout <= 0 {
+		timeout = DefaultToolTimeout
+	}
+	toolCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results, err := tool.Resolver(toolCtx, llmContext, argsGetter)
+	if err != nil && ctx.Err() == nil && errors.Is(toolCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%w after %s: %w", ErrToolTimedOut, timeout, err)
+	}
 	s.TraceResolved(name, argsGetter, results)
 	return results, err
 }
 
+// RestrictTo removes the tools not named.
+func (s *ToolStore) RestrictTo(names []string) {
+	for name := range s.tools {
+		if !slices.Contains(names, name) {
+			delete(s.tools, name)
+		}
+	}
+}
+
 func (s *ToolStore) GetTools() []Tool {
 	result := make([]Tool, 0, len(s.tools))
 	for _, tool := range s.tools {
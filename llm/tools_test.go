@@ -0,0 +1,57 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveTool(t *testing.T) {
+	store := NewNoTools()
+	store.AddTools([]Tool{
+		{
+			Name: "wait",
+			Resolver: func(ctx context.Context, _ *Context, _ ToolArgumentGetter) (string, error) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			},
+			Timeout: 10 * time.Millisecond,
+		},
+		{
+			Name: "echo",
+			Resolver: func(_ context.Context, _ *Context, _ ToolArgumentGetter) (string, error) {
+				return "done", nil
+			},
+		},
+	})
+	noArgs := func(any) error { return nil }
+
+	t.Run("resolves", func(t *testing.T) {
+		result, err := store.ResolveTool(context.Background(), "echo", noArgs, &Context{})
+		assert.NoError(t, err)
+		assert.Equal(t, "done", result)
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		_, err := store.ResolveTool(context.Background(), "wait", noArgs, &Context{})
+		assert.ErrorIs(t, err, ErrToolTimedOut)
+	})
+
+	t.Run("cancelled calls didn't time out", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := store.ResolveTool(ctx, "wait", noArgs, &Context{})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NotErrorIs(t, err, ErrToolTimedOut)
+	})
+
+	t.Run("unknown tool", func(t *testing.T) {
+		_, err := store.ResolveTool(context.Background(), "missing", noArgs, &Context{})
+		assert.Error(t, err)
+	})
+}
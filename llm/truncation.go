@@ -11,32 +11,19 @@ const FunctionsTokenBudget = 200
 const TokenLimitBufferSize = 0.9
 const MinTokens = 100
 
-type TruncationWrapper struct {
-	wrapped LanguageModel
-}
-
-func NewLLMTruncationWrapper(llm LanguageModel) *TruncationWrapper {
-	return &TruncationWrapper{
-		wrapped: llm,
-	}
-}
-
-func (w *TruncationWrapper) ChatCompletion(request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
-	tokenLimit := int(math.Max(math.Floor(float64(w.wrapped.InputTokenLimit()-FunctionsTokenBudget)*TokenLimitBufferSize), MinTokens))
-	request.Truncate(tokenLimit, w.wrapped.CountTokens)
-	return w.wrapped.ChatCompletion(request, opts...)
-}
-
-func (w *TruncationWrapper) ChatCompletionNoStream(request CompletionRequest, opts ...LanguageModelOption) (string, error) {
-	tokenLimit := int(math.Max(math.Floor(float64(w.wrapped.InputTokenLimit()-FunctionsTokenBudget)*TokenLimitBufferSize), MinTokens))
-	request.Truncate(tokenLimit, w.wrapped.CountTokens)
-	return w.wrapped.ChatCompletionNoStream(request, opts...)
-}
-
-func (w *TruncationWrapper) CountTokens(text string) int {
-	return w.wrapped.CountTokens(text)
-}
-
-func (w *TruncationWrapper) InputTokenLimit() int {
-	return w.wrapped.InputTokenLimit()
+// TruncationMiddleware fits requests within the input token limit of the wrapped model.
+// When summarizer is not nil the dropped history is replaced by a summary. When scorer is not nil the
+// history least relevant to the latest user turn is dropped first rather than the oldest.
+func TruncationMiddleware(summarizer HistorySummarizer, scorer RelevanceScorer) Middleware {
+	return RequestMiddleware(func(next LanguageModel, request CompletionRequest) CompletionRequest {
+		tokenLimit := int(math.Max(math.Floor(float64(next.InputTokenLimit()-FunctionsTokenBudget)*TokenLimitBufferSize), MinTokens))
+		budget := TokenBudget{
+			MaxTokens:       tokenLimit,
+			CountTokens:     next.CountTokens,
+			Summarizer:      summarizer,
+			RelevanceScorer: scorer,
+		}
+		budget.Fit(next, &request)
+		return request
+	})
 }
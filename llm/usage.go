@@ -0,0 +1,92 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"strings"
+	"sync"
+)
+
+// MiddlewarePriorityUsage places token counting inside the other middlewares, so it counts the request
+// as it is sent to the model, after truncation and with aliases resolved.
+const MiddlewarePriorityUsage = 1100
+
+// TokenUsage is the number of tokens sent to and generated by a model for a streamed completion,
+// counted with the model's tokenizer.
+type TokenUsage struct {
+	// Bot is the name of the bot whose service answered, which can differ from the bot posting the answer
+	Bot          string
+	Model        string
+	PromptTokens int
+
+	countTokens func(string) int
+
+	mu         sync.Mutex
+	completion strings.Builder
+}
+
+// CompletionTokens counts the tokens generated so far. The count is final once the stream has ended.
+func (u *TokenUsage) CompletionTokens() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.countTokens(u.completion.String())
+}
+
+func (u *TokenUsage) addCompletion(text string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.completion.WriteString(text)
+}
+
+// UsageMiddleware creates a Middleware that counts the tokens of streamed completions, so they can be
+// recorded against the post the result is streamed to. Completions that aren't streamed aren't counted.
+func UsageMiddleware(bot BotConfig) Middleware {
+	return Interceptor{
+		ChatCompletion: func(next LanguageModel, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+			cfg := LanguageModelConfig{Model: bot.Service.DefaultModel}
+			for _, opt := range opts {
+				opt(&cfg)
+			}
+
+			result, err := next.ChatCompletion(request, opts...)
+			if err != nil {
+				return nil, err
+			}
+
+			promptTokens := 0
+			for _, post := range request.Posts {
+				promptTokens += next.CountTokens(post.Message)
+			}
+			return result.WithUsage(&TokenUsage{
+				Bot:          bot.Name,
+				Model:        cfg.Model,
+				PromptTokens: promptTokens,
+				countTokens:  next.CountTokens,
+			}), nil
+		},
+	}.Middleware()
+}
+
+// WithUsage returns a result that adds the text streamed to the completion of the usage.
+func (t *TextStreamResult) WithUsage(usage *TokenUsage) *TextStreamResult {
+	output := make(chan TextStreamEvent)
+
+	go func() {
+		defer close(output)
+		for event := range t.Stream {
+			if event.Type == EventTypeText {
+				if text, ok := event.Value.(string); ok {
+					usage.addCompletion(text)
+				}
+			}
+			output <- event
+		}
+	}()
+
+	return &TextStreamResult{
+		Stream: output,
+		Props:  t.Props,
+		Usage:  usage,
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamingStubModel struct {
+	stubModel
+	response string
+}
+
+func (s *streamingStubModel) ChatCompletion(request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	return NewStreamFromString(s.response), nil
+}
+
+func TestUsageMiddleware(t *testing.T) {
+	bot := BotConfig{
+		Name: "ai",
+		Service: ServiceConfig{
+			DefaultModel: "support",
+			ModelAliases: []ModelAlias{{Alias: "support", Model: "support-lora"}},
+		},
+	}
+	model := Chain(
+		ModelAliasMiddleware(bot.Service, &modelLatencyRecorder{}),
+		UsageMiddleware(bot),
+	)(&streamingStubModel{response: "hello world"})
+
+	result, err := model.ChatCompletion(CompletionRequest{
+		Posts: []Post{
+			{Role: PostRoleSystem, Message: "be brief"},
+			{Role: PostRoleUser, Message: "hi"},
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.Usage)
+
+	text, err := result.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", text)
+
+	assert.Equal(t, "ai", result.Usage.Bot)
+	assert.Equal(t, "support-lora", result.Usage.Model)
+	assert.Equal(t, len("be brief")+len("hi"), result.Usage.PromptTokens)
+	assert.Equal(t, len("hello world"), result.Usage.CompletionTokens())
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/memory"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
 )
@@ -25,6 +26,7 @@ type MCPToolProvider interface {
 // ConfigProvider provides configuration access
 type ConfigProvider interface {
 	GetEnableLLMTrace() bool
+	GetEnableUserMemory() bool
 }
 
 // Builder builds contexts for LLM requests
@@ -33,6 +35,7 @@ type Builder struct {
 	toolProvider    ToolProvider
 	mcpToolProvider MCPToolProvider
 	configProvider  ConfigProvider
+	memoryStore     *memory.Store
 }
 
 // NewLLMContextBuilder creates a new LLM context builder
@@ -41,12 +44,14 @@ func NewLLMContextBuilder(
 	toolProvider ToolProvider,
 	mcpToolProvider MCPToolProvider,
 	configProvider ConfigProvider,
+	memoryStore *memory.Store,
 ) *Builder {
 	return &Builder{
 		pluginAPI:       pluginAPI,
 		toolProvider:    toolProvider,
 		mcpToolProvider: mcpToolProvider,
 		configProvider:  configProvider,
+		memoryStore:     memoryStore,
 	}
 }
 
@@ -55,6 +60,7 @@ func (b *Builder) BuildLLMContextUserRequest(bot *bots.Bot, requestingUser *mode
 	allOpts := []llm.ContextOption{
 		b.WithLLMContextServerInfo(),
 		b.WithLLMContextRequestingUser(requestingUser),
+		b.WithLLMContextUserMemories(),
 		b.WithLLMContextChannel(channel),
 		b.WithLLMContextBot(bot),
 	}
@@ -106,6 +112,28 @@ func (b *Builder) WithLLMContextRequestingUser(user *model.User) llm.ContextOpti
 	}
 }
 
+// userMemoryEnabled returns true if bots remember facts about users across conversations.
+func (b *Builder) userMemoryEnabled() bool {
+	return b.memoryStore != nil && b.configProvider.GetEnableUserMemory()
+}
+
+// WithLLMContextUserMemories adds the facts remembered about the requesting user. It must follow
+// WithLLMContextRequestingUser.
+func (b *Builder) WithLLMContextUserMemories() llm.ContextOption {
+	return func(c *llm.Context) {
+		if c.RequestingUser == nil || !b.userMemoryEnabled() {
+			return
+		}
+
+		memories, err := b.memoryStore.List(c.RequestingUser.Id)
+		if err != nil {
+			b.pluginAPI.Log.Error("Unable to get user memories for context", "error", err.Error(), "user_id", c.RequestingUser.Id)
+			return
+		}
+		c.UserMemories = memory.Contents(memories)
+	}
+}
+
 // GetToolsStoreForUser returns a tool store for a specific user, including MCP tools
 func (b *Builder) GetToolsStoreForUser(bot *bots.Bot, isDM bool, userID string) *llm.ToolStore {
 	// Check for nil bot, which is unexpected
@@ -120,8 +148,8 @@ func (b *Builder) GetToolsStoreForUser(bot *bots.Bot, isDM bool, userID string)
 		return llm.NewNoTools()
 	}
 
-	// Check if tools are disabled for this bot
-	if bot.GetConfig().DisableTools {
+	// Check if tools are disabled for this bot or unsupported by its model
+	if !bot.GetConfig().Capabilities().Tools {
 		return llm.NewNoTools()
 	}
 
@@ -131,6 +159,11 @@ func (b *Builder) GetToolsStoreForUser(bot *bots.Bot, isDM bool, userID string)
 	// Add built-in tools
 	store.AddTools(b.toolProvider.GetTools(isDM, bot))
 
+	// Memories are personal, so they are only managed in DMs
+	if isDM && b.userMemoryEnabled() {
+		store.AddTools(memory.Tools(b.memoryStore))
+	}
+
 	// Add MCP tools if available, enabled, and in a DM
 	if b.mcpToolProvider != nil && isDM {
 		mcpTools, err := b.mcpToolProvider.GetToolsForUser(userID)
@@ -165,6 +198,9 @@ func (b *Builder) WithLLMContextParameters(params map[string]interface{}) llm.Co
 func (b *Builder) WithLLMContextBot(bot *bots.Bot) llm.ContextOption {
 	return func(c *llm.Context) {
 		c.BotName = bot.GetConfig().DisplayName
+		if mmBot := bot.GetMMBot(); mmBot != nil {
+			c.BotID = mmBot.UserId
+		}
 		c.CustomInstructions = bot.GetConfig().CustomInstructions
 	}
 }
@@ -0,0 +1,124 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Statuses of MCP tool calls
+const (
+	ToolCallStatusSuccess = "success"
+	ToolCallStatusError   = "error"
+)
+
+// ToolCall is the audit record of a call of an MCP tool. The arguments are only kept as a hash, since they
+// can hold the content of private conversations.
+type ToolCall struct {
+	ID       string `json:"id"`
+	UserID   string `json:"user_id"`
+	BotID    string `json:"bot_id"`
+	ServerID string `json:"server_id"`
+	ToolName string `json:"tool_name"`
+
+	// ArgumentsSHA256 is the hex SHA-256 of the arguments as JSON with sorted keys, to match calls with the
+	// same arguments without storing them
+	ArgumentsSHA256 string `json:"arguments_sha256"`
+
+	DurationMillis int64  `json:"duration_ms"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+	CreateAt       int64  `json:"create_at"`
+}
+
+// ToolCallRecorder keeps the audit records of the tool calls
+type ToolCallRecorder interface {
+	RecordToolCall(call ToolCall) error
+}
+
+// newToolCall returns the audit record of a call of the tool by the user through the bot. A result flagged
+// as an error by the server is recorded as a failed call.
+func newToolCall(userID, botID, serverID, toolName string, args map[string]any, start time.Time, isError bool, err error) ToolCall {
+	call := ToolCall{
+		UserID:          userID,
+		BotID:           botID,
+		ServerID:        serverID,
+		ToolName:        toolName,
+		ArgumentsSHA256: hashArguments(args),
+		DurationMillis:  time.Since(start).Milliseconds(),
+		Status:          ToolCallStatusSuccess,
+		CreateAt:        start.UnixMilli(),
+	}
+	switch {
+	case err != nil:
+		call.Status = ToolCallStatusError
+		call.Error = err.Error()
+	case isError:
+		call.Status = ToolCallStatusError
+		call.Error = "tool returned an error"
+	}
+	return call
+}
+
+// hashArguments returns the hex SHA-256 of the arguments. Maps are marshalled with sorted keys, so the
+// hash doesn't depend on the order the model gave the arguments in.
+func hashArguments(args map[string]any) string {
+	if args == nil {
+		args = map[string]any{}
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+var toolCallCSVHeader = []string{"id", "create_at", "user_id", "bot_id", "server_id", "tool_name", "arguments_sha256", "duration_ms", "status", "error"}
+
+// csvCell escapes the values spreadsheets would evaluate as formulas, such as an error message a server
+// controls, by prefixing them with a quote
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// ToolCallsCSV returns the tool calls as CSV, with a header row, for compliance exports
+func ToolCallsCSV(calls []ToolCall) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err := writer.Write(toolCallCSVHeader); err != nil {
+		return nil, err
+	}
+	for _, call := range calls {
+		if err := writer.Write([]string{
+			csvCell(call.ID),
+			time.UnixMilli(call.CreateAt).UTC().Format(time.RFC3339Nano),
+			csvCell(call.UserID),
+			csvCell(call.BotID),
+			csvCell(call.ServerID),
+			csvCell(call.ToolName),
+			csvCell(call.ArgumentsSHA256),
+			strconv.FormatInt(call.DurationMillis, 10),
+			csvCell(call.Status),
+			csvCell(call.Error),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// MaxToolCallsListed limits the tool calls listed or exported at once
+	MaxToolCallsListed = 10000
+
+	// AuditCleanupInterval is how often the records of tool calls older than the retention are deleted
+	AuditCleanupInterval = time.Hour
+)
+
+// ToolCallFilter selects the tool calls made between Since and Until, in milliseconds. Empty fields match
+// any call.
+type ToolCallFilter struct {
+	UserID   string
+	BotID    string
+	ServerID string
+	ToolName string
+	Status   string
+	Since    int64
+	Until    int64
+	Limit    int
+}
+
+// AuditStore keeps the audit records of the MCP tool calls in the LLM_MCPToolCalls table.
+type AuditStore struct {
+	db *mmapi.DBClient
+}
+
+// NewAuditStore creates an audit store.
+func NewAuditStore(db *mmapi.DBClient) *AuditStore {
+	return &AuditStore{db: db}
+}
+
+var toolCallColumns = []string{"ID", "UserID", "BotID", "ServerID", "ToolName", "ArgumentsSHA256", "DurationMillis", "Status", "Error", "CreateAt"}
+
+// RecordToolCall adds the record of a tool call.
+func (s *AuditStore) RecordToolCall(call ToolCall) error {
+	call.ID = model.NewId()
+	if call.CreateAt == 0 {
+		call.CreateAt = model.GetMillis()
+	}
+	if _, err := s.db.ExecBuilder(s.db.Builder().Insert("LLM_MCPToolCalls").
+		Columns(toolCallColumns...).
+		Values(call.ID, call.UserID, call.BotID, call.ServerID, call.ToolName, call.ArgumentsSHA256, call.DurationMillis, call.Status, call.Error, call.CreateAt)); err != nil {
+		return fmt.Errorf("failed to record MCP tool call: %w", err)
+	}
+	return nil
+}
+
+// DeleteToolCallsBefore deletes the records of the tool calls made before the cutoff, returning how many
+// were deleted.
+func (s *AuditStore) DeleteToolCallsBefore(cutoff time.Time) (int64, error) {
+	result, err := s.db.ExecBuilder(s.db.Builder().Delete("LLM_MCPToolCalls").
+		Where(sq.Lt{"CreateAt": cutoff.UnixMilli()}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete MCP tool calls: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted MCP tool calls: %w", err)
+	}
+	return deleted, nil
+}
+
+// ListToolCalls returns the tool calls matching the filter, most recent first.
+func (s *AuditStore) ListToolCalls(filter ToolCallFilter) ([]ToolCall, error) {
+	if filter.Limit <= 0 || filter.Limit > MaxToolCallsListed {
+		filter.Limit = MaxToolCallsListed
+	}
+
+	conditions := sq.Eq{}
+	if filter.UserID != "" {
+		conditions["UserID"] = filter.UserID
+	}
+	if filter.BotID != "" {
+		conditions["BotID"] = filter.BotID
+	}
+	if filter.ServerID != "" {
+		conditions["ServerID"] = filter.ServerID
+	}
+	if filter.ToolName != "" {
+		conditions["ToolName"] = filter.ToolName
+	}
+	if filter.Status != "" {
+		conditions["Status"] = filter.Status
+	}
+
+	calls := []ToolCall{}
+	if err := s.db.DoQuery(&calls, s.db.Builder().
+		Select(toolCallColumns...).
+		From("LLM_MCPToolCalls").
+		Where(conditions).
+		Where(sq.GtOrEq{"CreateAt": filter.Since}).
+		Where(sq.Lt{"CreateAt": filter.Until}).
+		OrderBy("CreateAt DESC", "ID").
+		Limit(uint64(filter.Limit))); err != nil {
+		return nil, fmt.Errorf("failed to list MCP tool calls: %w", err)
+	}
+	return calls, nil
+}
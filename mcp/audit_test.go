@@ -0,0 +1,87 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashArguments(t *testing.T) {
+	var first, second map[string]any
+	require.NoError(t, json.Unmarshal([]byte(`{"query": "roadmap", "limit": 5}`), &first))
+	require.NoError(t, json.Unmarshal([]byte(`{"limit": 5, "query": "roadmap"}`), &second))
+
+	assert.Equal(t, hashArguments(first), hashArguments(second))
+	assert.Len(t, hashArguments(first), 64)
+	assert.NotEqual(t, hashArguments(first), hashArguments(map[string]any{"query": "roadmap"}))
+	assert.Equal(t, hashArguments(nil), hashArguments(map[string]any{}))
+}
+
+func TestNewToolCall(t *testing.T) {
+	start := time.Now().Add(-time.Second)
+
+	t.Run("successful call", func(t *testing.T) {
+		call := newToolCall("user", "bot", "server", "search", nil, start, false, nil)
+		assert.Equal(t, ToolCallStatusSuccess, call.Status)
+		assert.Empty(t, call.Error)
+		assert.Equal(t, start.UnixMilli(), call.CreateAt)
+		assert.GreaterOrEqual(t, call.DurationMillis, int64(1000))
+	})
+
+	t.Run("call that failed", func(t *testing.T) {
+		call := newToolCall("user", "bot", "server", "search", nil, start, false, errors.New("connection reset"))
+		assert.Equal(t, ToolCallStatusError, call.Status)
+		assert.Equal(t, "connection reset", call.Error)
+	})
+
+	t.Run("result flagged as an error", func(t *testing.T) {
+		call := newToolCall("user", "bot", "server", "search", nil, start, true, nil)
+		assert.Equal(t, ToolCallStatusError, call.Status)
+		assert.NotEmpty(t, call.Error)
+	})
+}
+
+func TestToolCallsCSV(t *testing.T) {
+	data, err := ToolCallsCSV([]ToolCall{{
+		ID:              "id",
+		UserID:          "user",
+		BotID:           "bot",
+		ServerID:        "server",
+		ToolName:        "search",
+		ArgumentsSHA256: "hash",
+		DurationMillis:  42,
+		Status:          ToolCallStatusError,
+		Error:           "failed, \"badly\"",
+		CreateAt:        time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).UnixMilli(),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "id,create_at,user_id,bot_id,server_id,tool_name,arguments_sha256,duration_ms,status,error\n"+
+		"id,2025-01-02T03:04:05Z,user,bot,server,search,hash,42,error,\"failed, \"\"badly\"\"\"\n", string(data))
+
+	t.Run("formulas are escaped", func(t *testing.T) {
+		data, err := ToolCallsCSV([]ToolCall{{
+			ID:       "id",
+			ServerID: "@server",
+			ToolName: "+tool",
+			Status:   ToolCallStatusError,
+			Error:    "=HYPERLINK(\"https://example.com\")",
+			CreateAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).UnixMilli(),
+		}, {
+			ID:             "id2",
+			ToolName:       "-1",
+			DurationMillis: -1,
+			CreateAt:       time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).UnixMilli(),
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, "id,create_at,user_id,bot_id,server_id,tool_name,arguments_sha256,duration_ms,status,error\n"+
+			"id,2025-01-02T03:04:05Z,,,'@server,'+tool,,0,error,\"'=HYPERLINK(\"\"https://example.com\"\")\"\n"+
+			"id2,2025-01-02T03:04:05Z,,,,'-1,,-1,,\n", string(data))
+	})
+}
@@ -4,6 +4,8 @@
 package mcp
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -12,15 +14,24 @@ import (
 	"github.com/mattermost/mattermost/server/public/pluginapi"
 )
 
+// ErrServerNotFound is returned for MCP servers that aren't configured
+var ErrServerNotFound = errors.New("MCP server not found")
+
 // ClientManager manages MCP clients for multiple users
 type ClientManager struct {
 	config        Config
 	log           pluginapi.LogService
 	clientsMu     sync.RWMutex
 	clients       map[string]*UserClient // Map of userID to UserClient
+	shared        *sharedConnections
+	stdioLimit    *stdioProcessLimit
 	cleanupTicker *time.Ticker
 	closeChan     chan struct{}
 	clientTimeout time.Duration
+	health        *healthTracker
+	auditor       ToolCallRecorder
+	sampler       Sampler
+	secrets       SecretResolver
 }
 
 // Config contains the configuration for the MCP clients
@@ -28,17 +39,48 @@ type Config struct {
 	Enabled            bool                    `json:"enabled"`
 	Servers            map[string]ServerConfig `json:"servers"`
 	IdleTimeoutMinutes int                     `json:"idleTimeoutMinutes"`
+	AuditRetentionDays int                     `json:"auditRetentionDays"`
+
+	// MaxStdioProcesses is how many processes of each stdio server that isn't shared can run for users
+	// at once
+	MaxStdioProcesses int `json:"maxStdioProcesses"`
+}
+
+// StdioProcessLimit returns how many processes of each stdio server can run for users, 20 unless configured.
+func (c Config) StdioProcessLimit() int {
+	if c.MaxStdioProcesses > 0 {
+		return c.MaxStdioProcesses
+	}
+	return 20
 }
 
-// NewClientManager creates a new MCP client manager
-func NewClientManager(config Config, log pluginapi.LogService) *ClientManager {
+// AuditRetention returns how long the records of tool calls are kept, 90 days unless configured.
+func (c Config) AuditRetention() time.Duration {
+	if c.AuditRetentionDays > 0 {
+		return time.Duration(c.AuditRetentionDays) * 24 * time.Hour
+	}
+	return 90 * 24 * time.Hour
+}
+
+// NewClientManager creates a new MCP client manager. The tool calls of the users are recorded by the auditor,
+// and the secrets server configs reference are read from secrets.
+func NewClientManager(config Config, log pluginapi.LogService, auditor ToolCallRecorder, secrets SecretResolver) *ClientManager {
 	manager := &ClientManager{
-		log: log,
+		log:     log,
+		health:  newHealthTracker(),
+		auditor: auditor,
+		secrets: secrets,
 	}
 	manager.ReInit(config)
 	return manager
 }
 
+// SetSampler sets the sampler answering the sampling requests of the servers allowed to make them.
+// It must be set before the clients of the users are created.
+func (m *ClientManager) SetSampler(sampler Sampler) {
+	m.sampler = sampler
+}
+
 // cleanupInactiveClients periodically checks for and closes inactive client connections
 func (m *ClientManager) cleanupInactiveClients() {
 	for {
@@ -70,7 +112,10 @@ func (m *ClientManager) ReInit(config Config) {
 	}
 
 	m.config = config
+	m.health.forget(config.Servers)
 	m.clients = make(map[string]*UserClient)
+	m.shared = m.newSharedConnections()
+	m.stdioLimit = newStdioProcessLimit(config.StdioProcessLimit())
 	m.clientTimeout = time.Duration(config.IdleTimeoutMinutes) * time.Minute
 	m.closeChan = make(chan struct{})
 
@@ -79,6 +124,12 @@ func (m *ClientManager) ReInit(config Config) {
 	go m.cleanupInactiveClients()
 }
 
+// Reconnect closes the connections of all users, which connect again with the current secrets the next
+// time they use the servers.
+func (m *ClientManager) Reconnect() {
+	m.ReInit(m.config)
+}
+
 // Close closes the client manager and all managed clients
 // The client manger should not be used after Close is called
 func (m *ClientManager) Close() {
@@ -101,6 +152,21 @@ func (m *ClientManager) Close() {
 
 	// Clear the clients map
 	m.clients = make(map[string]*UserClient)
+
+	for serverID, err := range m.shared.close() {
+		m.log.Error("Failed to close shared MCP client", "serverID", serverID, "error", err)
+	}
+}
+
+// newSharedConnections returns the pool of connections to the shared servers. They are made without the
+// identity of any user, and never answer sampling requests.
+func (m *ClientManager) newSharedConnections() *sharedConnections {
+	connector := &UserClient{
+		log:     m.log,
+		health:  m.health,
+		secrets: m.secrets,
+	}
+	return newSharedConnections(connector.newServerConnection)
 }
 
 // createAndStoreUserClient creates a new UserClient instance and stores it in the manager
@@ -119,9 +185,14 @@ func (m *ClientManager) createAndStoreUserClient(userID string) (*UserClient, er
 	userClient := &UserClient{
 		log:          m.log,
 		clients:      make(map[string]*ServerConnection),
-		toolDefs:     make(map[string]ToolDefinition),
 		lastActivity: time.Now(),
 		userID:       userID,
+		health:       m.health,
+		auditor:      m.auditor,
+		sampler:      m.sampler,
+		secrets:      m.secrets,
+		shared:       m.shared,
+		stdioLimit:   m.stdioLimit,
 	}
 
 	// Let user client connect to all servers
@@ -168,3 +239,103 @@ func (m *ClientManager) GetToolsForUser(userID string) ([]llm.Tool, error) {
 	// Return the user's tools
 	return userClient.GetTools(), nil
 }
+
+// GetPromptsForUser returns the prompts of the MCP servers available for a specific user
+func (m *ClientManager) GetPromptsForUser(userID string) ([]Prompt, error) {
+	if !m.config.Enabled || len(m.config.Servers) == 0 {
+		return []Prompt{}, nil
+	}
+
+	userClient, err := m.getClientForUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MCP client for user %s: %w", userID, err)
+	}
+
+	return userClient.GetPrompts(), nil
+}
+
+// GetPromptForUser returns a prompt of an MCP server available for a specific user
+func (m *ClientManager) GetPromptForUser(userID, serverID, name string) (Prompt, error) {
+	if !m.config.Enabled {
+		return Prompt{}, ErrPromptNotFound
+	}
+
+	userClient, err := m.getClientForUser(userID)
+	if err != nil {
+		return Prompt{}, fmt.Errorf("failed to get MCP client for user %s: %w", userID, err)
+	}
+
+	return userClient.GetPrompt(serverID, name)
+}
+
+// RenderPromptForUser has an MCP server fill in one of its prompts for a specific user
+func (m *ClientManager) RenderPromptForUser(userID, serverID, name string, arguments map[string]string) (string, error) {
+	if !m.config.Enabled {
+		return "", ErrPromptNotFound
+	}
+
+	userClient, err := m.getClientForUser(userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get MCP client for user %s: %w", userID, err)
+	}
+
+	return userClient.RenderPrompt(serverID, name, arguments)
+}
+
+// serverTestTimeout limits how long testing the connection to a server takes
+const serverTestTimeout = 30 * time.Second
+
+// ServerHealth returns the health of the configured MCP servers, from the last connections made to them
+func (m *ClientManager) ServerHealth() []ServerHealth {
+	activeConnections := make(map[string]int)
+	m.clientsMu.RLock()
+	for _, client := range m.clients {
+		for serverID, connection := range client.clients {
+			if !connection.shared {
+				activeConnections[serverID]++
+			}
+		}
+	}
+	m.clientsMu.RUnlock()
+	for _, serverID := range m.shared.serverIDs() {
+		activeConnections[serverID]++
+	}
+
+	return m.health.report(m.config, activeConnections)
+}
+
+// TestServer connects to an MCP server as the user and returns the health of the connection, which is then
+// closed. The configured server is tested when serverConfig is nil, and its health is updated; unsaved
+// settings are tested without being recorded.
+func (m *ClientManager) TestServer(userID, serverID string, serverConfig *ServerConfig) (ServerHealth, error) {
+	health := m.health
+	if serverConfig == nil {
+		configured, ok := m.config.Servers[serverID]
+		if !ok {
+			return ServerHealth{}, ErrServerNotFound
+		}
+		serverConfig = &configured
+	} else {
+		health = newHealthTracker()
+	}
+
+	userClient := &UserClient{
+		log:          m.log,
+		clients:      make(map[string]*ServerConnection),
+		lastActivity: time.Now(),
+		userID:       userID,
+		health:       health,
+		secrets:      m.secrets,
+	}
+	defer userClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), serverTestTimeout)
+	defer cancel()
+	if err := userClient.connectToServer(ctx, serverID, *serverConfig); err != nil {
+		m.log.Debug("MCP server connection test failed", "serverID", serverID, "error", err)
+	}
+
+	result := health.get(serverID)
+	result.Transport = serverConfig.Transport
+	return result, nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Statuses of MCP servers
+const (
+	// ServerStatusUnknown is the status of servers no one connected to since the plugin started
+	ServerStatusUnknown   = "unknown"
+	ServerStatusConnected = "connected"
+	ServerStatusError     = "error"
+	ServerStatusDisabled  = "disabled"
+)
+
+// ServerHealth is the result of the last connection to an MCP server, made for any user. The last error
+// is kept after the server connects again, to diagnose servers failing intermittently.
+type ServerHealth struct {
+	ServerID          string `json:"server_id"`
+	Transport         string `json:"transport"`
+	Status            string `json:"status"`
+	LastError         string `json:"last_error,omitempty"`
+	LastErrorAt       int64  `json:"last_error_at,omitempty"`
+	ToolCount         int    `json:"tool_count"`
+	LatencyMillis     int64  `json:"latency_ms"`
+	CheckedAt         int64  `json:"checked_at,omitempty"`
+	ActiveConnections int    `json:"active_connections"`
+}
+
+// healthTracker keeps the health of the servers connected to.
+type healthTracker struct {
+	mu      sync.Mutex
+	servers map[string]ServerHealth
+}
+
+func newHealthTracker() *healthTracker {
+	return &healthTracker{
+		servers: make(map[string]ServerHealth),
+	}
+}
+
+// record keeps the result of a connection to the server. The latency is the round trip of listing the
+// tools of the server, once connected.
+func (t *healthTracker) record(serverID string, toolCount int, latency time.Duration, err error) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	health := t.servers[serverID]
+	health.ServerID = serverID
+	health.CheckedAt = time.Now().UnixMilli()
+	if err != nil {
+		health.Status = ServerStatusError
+		health.LastError = err.Error()
+		health.LastErrorAt = health.CheckedAt
+	} else {
+		health.Status = ServerStatusConnected
+		health.ToolCount = toolCount
+		health.LatencyMillis = latency.Milliseconds()
+	}
+	t.servers[serverID] = health
+}
+
+// get returns the health of a server, unknown if it wasn't connected to.
+func (t *healthTracker) get(serverID string) ServerHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	health, ok := t.servers[serverID]
+	if !ok {
+		return ServerHealth{ServerID: serverID, Status: ServerStatusUnknown}
+	}
+	return health
+}
+
+// forget drops the health of the servers no longer configured.
+func (t *healthTracker) forget(servers map[string]ServerConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for serverID := range t.servers {
+		if _, ok := servers[serverID]; !ok {
+			delete(t.servers, serverID)
+		}
+	}
+}
+
+// report returns the health of the configured servers by ID, with the number of connections to each.
+func (t *healthTracker) report(config Config, activeConnections map[string]int) []ServerHealth {
+	report := make([]ServerHealth, 0, len(config.Servers))
+	for serverID, serverConfig := range config.Servers {
+		health := t.get(serverID)
+		if !config.Enabled {
+			health.Status = ServerStatusDisabled
+		}
+		health.Transport = serverConfig.Transport
+		health.ActiveConnections = activeConnections[serverID]
+		report = append(report, health)
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].ServerID < report[j].ServerID
+	})
+	return report
+}
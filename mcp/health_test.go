@@ -0,0 +1,68 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthTracker(t *testing.T) {
+	config := Config{
+		Enabled: true,
+		Servers: map[string]ServerConfig{
+			"github": {BaseURL: "https://github.example.com", Transport: TransportStreamableHTTP},
+			"files":  {Command: "mcp-files", Transport: TransportStdio},
+			"jira":   {BaseURL: "https://jira.example.com"},
+		},
+	}
+
+	tracker := newHealthTracker()
+	tracker.record("github", 12, 150*time.Millisecond, nil)
+	tracker.record("files", 0, 0, errors.New("executable not found"))
+	tracker.record("removed", 1, time.Millisecond, nil)
+
+	t.Run("reports the configured servers", func(t *testing.T) {
+		report := tracker.report(config, map[string]int{"github": 3})
+		require.Len(t, report, 3)
+
+		assert.Equal(t, "files", report[0].ServerID)
+		assert.Equal(t, ServerStatusError, report[0].Status)
+		assert.Equal(t, "executable not found", report[0].LastError)
+		assert.Equal(t, TransportStdio, report[0].Transport)
+
+		assert.Equal(t, "github", report[1].ServerID)
+		assert.Equal(t, ServerStatusConnected, report[1].Status)
+		assert.Equal(t, 12, report[1].ToolCount)
+		assert.Equal(t, int64(150), report[1].LatencyMillis)
+		assert.Equal(t, 3, report[1].ActiveConnections)
+
+		assert.Equal(t, "jira", report[2].ServerID)
+		assert.Equal(t, ServerStatusUnknown, report[2].Status)
+	})
+
+	t.Run("keeps the last error once connected again", func(t *testing.T) {
+		tracker.record("files", 4, 20*time.Millisecond, nil)
+		health := tracker.get("files")
+		assert.Equal(t, ServerStatusConnected, health.Status)
+		assert.Equal(t, 4, health.ToolCount)
+		assert.Equal(t, "executable not found", health.LastError)
+	})
+
+	t.Run("reports servers as disabled", func(t *testing.T) {
+		report := tracker.report(Config{Servers: config.Servers}, nil)
+		for _, health := range report {
+			assert.Equal(t, ServerStatusDisabled, health.Status)
+		}
+	})
+
+	t.Run("forgets servers no longer configured", func(t *testing.T) {
+		tracker.forget(config.Servers)
+		assert.Equal(t, ServerStatusUnknown, tracker.get("removed").Status)
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// promptTimeout limits how long a server has to fill in a prompt
+const promptTimeout = 30 * time.Second
+
+var (
+	// ErrPromptNotFound is returned for prompts none of the servers of the user provides.
+	ErrPromptNotFound = errors.New("MCP prompt not found")
+
+	// ErrMissingPromptArguments is returned when required arguments of a prompt have no value.
+	ErrMissingPromptArguments = errors.New("missing required prompt arguments")
+)
+
+// PromptArgument is an argument a prompt is filled in with
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+// Prompt is a prompt template provided by an MCP server
+type Prompt struct {
+	ServerID    string           `json:"server_id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Arguments   []PromptArgument `json:"arguments"`
+}
+
+// MissingArguments returns the required arguments of the prompt without a value.
+func (p Prompt) MissingArguments(values map[string]string) []string {
+	missing := []string{}
+	for _, argument := range p.Arguments {
+		if argument.Required && strings.TrimSpace(values[argument.Name]) == "" {
+			missing = append(missing, argument.Name)
+		}
+	}
+	return missing
+}
+
+// GetPrompts returns the prompts of the servers the user is connected to, by server and name
+func (c *UserClient) GetPrompts() []Prompt {
+	prompts := []Prompt{}
+	for serverID, serverClient := range c.clients {
+		for _, prompt := range serverClient.prompts {
+			arguments := make([]PromptArgument, 0, len(prompt.Arguments))
+			for _, argument := range prompt.Arguments {
+				arguments = append(arguments, PromptArgument{
+					Name:        argument.Name,
+					Description: argument.Description,
+					Required:    argument.Required,
+				})
+			}
+			prompts = append(prompts, Prompt{
+				ServerID:    serverID,
+				Name:        prompt.Name,
+				Description: prompt.Description,
+				Arguments:   arguments,
+			})
+		}
+	}
+
+	sort.Slice(prompts, func(i, j int) bool {
+		if prompts[i].ServerID != prompts[j].ServerID {
+			return prompts[i].ServerID < prompts[j].ServerID
+		}
+		return prompts[i].Name < prompts[j].Name
+	})
+	return prompts
+}
+
+// GetPrompt returns a prompt of a server the user is connected to
+func (c *UserClient) GetPrompt(serverID, name string) (Prompt, error) {
+	for _, prompt := range c.GetPrompts() {
+		if prompt.ServerID == serverID && prompt.Name == name {
+			return prompt, nil
+		}
+	}
+	return Prompt{}, ErrPromptNotFound
+}
+
+// RenderPrompt has the server fill in the prompt with the arguments, and returns the text of its messages
+func (c *UserClient) RenderPrompt(serverID, name string, arguments map[string]string) (string, error) {
+	prompt, err := c.GetPrompt(serverID, name)
+	if err != nil {
+		return "", err
+	}
+	if missing := prompt.MissingArguments(arguments); len(missing) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrMissingPromptArguments, strings.Join(missing, ", "))
+	}
+
+	c.lastActivity = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), promptTimeout)
+	defer cancel()
+
+	request := mcp.GetPromptRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = arguments
+	serverMCPClient, _ := c.clients[serverID].current()
+	result, err := serverMCPClient.GetPrompt(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("failed to get prompt %s from server %s: %w", name, serverID, err)
+	}
+
+	text := promptText(result.Messages)
+	if text == "" {
+		return "", fmt.Errorf("no text content found in prompt %s from server %s", name, serverID)
+	}
+	return text, nil
+}
+
+// promptText joins the text of the messages of a prompt, including the text of embedded resources.
+// Images and binary resources can't be posted as text and are left out.
+func promptText(messages []mcp.PromptMessage) string {
+	parts := []string{}
+	for _, message := range messages {
+		if textContent, ok := mcp.AsTextContent(message.Content); ok {
+			parts = append(parts, textContent.Text)
+			continue
+		}
+		if resource, ok := mcp.AsEmbeddedResource(message.Content); ok {
+			if textResource, ok := mcp.AsTextResourceContents(resource.Resource); ok {
+				parts = append(parts, textResource.Text)
+			}
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n\n"))
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromptText(t *testing.T) {
+	messages := []mcp.PromptMessage{
+		{Role: mcp.RoleUser, Content: mcp.NewTextContent("Review this code:")},
+		{Role: mcp.RoleUser, Content: mcp.NewImageContent("aGVsbG8=", "image/png")},
+		{Role: mcp.RoleUser, Content: mcp.NewEmbeddedResource(mcp.TextResourceContents{URI: "file:///main.go", Text: "package main"})},
+		{Role: mcp.RoleUser, Content: mcp.NewEmbeddedResource(mcp.BlobResourceContents{URI: "file:///logo.png", Blob: "aGVsbG8="})},
+	}
+
+	assert.Equal(t, "Review this code:\n\npackage main", promptText(messages))
+	assert.Equal(t, "", promptText(nil))
+}
+
+func TestPromptMissingArguments(t *testing.T) {
+	prompt := Prompt{Arguments: []PromptArgument{
+		{Name: "language", Required: true},
+		{Name: "code", Required: true},
+		{Name: "focus"},
+	}}
+
+	assert.Equal(t, []string{"code"}, prompt.MissingArguments(map[string]string{"language": "go", "code": "  "}))
+	assert.Empty(t, prompt.MissingArguments(map[string]string{"language": "go", "code": "x"}))
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+)
+
+const (
+	// reconnectAttempts limits how many times connecting again to a server whose connection dropped is tried
+	reconnectAttempts = 4
+
+	// reconnectDelay is the delay before the second attempt, doubled for each following one
+	reconnectDelay = 500 * time.Millisecond
+)
+
+// isConnectionError tells whether a request failed because the connection to the server dropped, rather
+// than because the server answered it with an error or it timed out.
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, errStdioServerExited)
+}
+
+// current returns the client of the connection, and the tool calls running through it.
+func (s *ServerConnection) current() (mcpClient, *activeToolCalls) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client, s.toolCalls
+}
+
+// close closes the client of the connection
+func (s *ServerConnection) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client.Close()
+}
+
+// reconnect replaces the failed client of the connection with a new one, trying again with a growing
+// delay. Calls failing at the same time only reconnect once: the client is kept when it was already replaced.
+func (s *ServerConnection) reconnect(ctx context.Context, failed mcpClient) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != failed {
+		return nil
+	}
+	if s.connect == nil {
+		return errors.New("connection can't be made again")
+	}
+
+	var err error
+	for attempt := 0; attempt < reconnectAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(reconnectDelay << (attempt - 1)):
+			}
+		}
+
+		var client mcpClient
+		var toolCalls *activeToolCalls
+		client, toolCalls, err = s.connect(ctx)
+		if err == nil {
+			_ = s.client.Close()
+			s.client = client
+			s.toolCalls = toolCalls
+			return nil
+		}
+	}
+
+	return fmt.Errorf("failed to reconnect after %d attempts: %w", reconnectAttempts, err)
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	for name, test := range map[string]struct {
+		err      error
+		expected bool
+	}{
+		"no error":          {nil, false},
+		"server error":      {errors.New("request failed: invalid params (code -32602)"), false},
+		"timeout":           {&url.Error{Op: "Post", URL: "http://server", Err: context.DeadlineExceeded}, false},
+		"connection failed": {fmt.Errorf("failed to send request: %w", &url.Error{Op: "Post", URL: "http://server", Err: errors.New("connection refused")}), true},
+		"stream dropped":    {fmt.Errorf("response stream ended before the response: %w", io.ErrUnexpectedEOF), true},
+		"server exited":     {fmt.Errorf("%w: gave up after 5 restarts", errStdioServerExited), true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, isConnectionError(test.err))
+		})
+	}
+}
+
+func TestServerConnectionReconnect(t *testing.T) {
+	failed := &closeCountingClient{}
+	replacement := &closeCountingClient{}
+	connects := 0
+	connection := &ServerConnection{
+		client:    failed,
+		toolCalls: &activeToolCalls{},
+		connect: func(context.Context) (mcpClient, *activeToolCalls, error) {
+			connects++
+			if connects == 1 {
+				return nil, nil, errors.New("unreachable")
+			}
+			return replacement, &activeToolCalls{}, nil
+		},
+	}
+
+	require.NoError(t, connection.reconnect(context.Background(), failed))
+	client, _ := connection.current()
+	assert.Same(t, replacement, client)
+	assert.Equal(t, 1, failed.closed)
+	assert.Equal(t, 2, connects)
+
+	// Calls that failed on the replaced client don't reconnect again
+	require.NoError(t, connection.reconnect(context.Background(), failed))
+	assert.Equal(t, 2, connects)
+
+	t.Run("gives up when the call times out", func(t *testing.T) {
+		connection := &ServerConnection{
+			client: failed,
+			connect: func(context.Context) (mcpClient, *activeToolCalls, error) {
+				return nil, nil, errors.New("unreachable")
+			},
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, connection.reconnect(ctx, failed), context.DeadlineExceeded)
+		client, _ := connection.current()
+		assert.Same(t, failed, client)
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// rootsListMethod is the method of the requests of servers for the roots they are scoped to
+const rootsListMethod = "roots/list"
+
+// Variables replaced in the roots of servers, scoping them to the user or to the channel of the tool call
+const (
+	RootUserIDVariable    = "{user_id}"
+	RootChannelIDVariable = "{channel_id}"
+)
+
+// expandRoots returns the roots of the templates for the user and the channel. Templates using a variable
+// without value are left out, the channel is only known while a tool runs.
+func expandRoots(templates []string, userID, channelID string) []mcp.Root {
+	roots := []mcp.Root{}
+	for _, template := range templates {
+		template = strings.TrimSpace(template)
+		if template == "" {
+			continue
+		}
+		if strings.Contains(template, RootUserIDVariable) && userID == "" {
+			continue
+		}
+		if strings.Contains(template, RootChannelIDVariable) && channelID == "" {
+			continue
+		}
+
+		uri := strings.NewReplacer(RootUserIDVariable, userID, RootChannelIDVariable, channelID).Replace(template)
+		roots = append(roots, mcp.Root{
+			URI:  uri,
+			Name: path.Base(strings.TrimRight(uri, "/")),
+		})
+	}
+	return roots
+}
+
+// rootsHandler answers the roots requests of a server with its configured roots, for the user and the
+// channel of the tool running. Shared servers are used by all users, so they only get the roots without
+// variables.
+func (c *UserClient) rootsHandler(serverConfig ServerConfig, calls *activeToolCalls) requestHandler {
+	return func(ctx context.Context, method string, _ json.RawMessage) (any, error) {
+		if method != rootsListMethod {
+			return nil, errMethodNotFound
+		}
+
+		if serverConfig.Shared {
+			return mcp.ListRootsResult{Roots: expandRoots(serverConfig.Roots, "", "")}, nil
+		}
+
+		channelID := ""
+		if call := calls.forRequest(ctx); call != nil {
+			channelID = call.channelID
+		}
+		return mcp.ListRootsResult{Roots: expandRoots(serverConfig.Roots, c.userID, channelID)}, nil
+	}
+}
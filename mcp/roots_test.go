@@ -0,0 +1,70 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandRoots(t *testing.T) {
+	templates := []string{
+		"file:///srv/shared/",
+		"file:///srv/users/{user_id}",
+		"file:///srv/channels/{channel_id}",
+		" ",
+	}
+
+	assert.Equal(t, []mcp.Root{
+		{URI: "file:///srv/shared/", Name: "shared"},
+		{URI: "file:///srv/users/userid", Name: "userid"},
+		{URI: "file:///srv/channels/channelid", Name: "channelid"},
+	}, expandRoots(templates, "userid", "channelid"))
+
+	assert.Equal(t, []mcp.Root{
+		{URI: "file:///srv/shared/", Name: "shared"},
+		{URI: "file:///srv/users/userid", Name: "userid"},
+	}, expandRoots(templates, "userid", ""))
+}
+
+func TestRootsHandler(t *testing.T) {
+	serverConfig := ServerConfig{Roots: []string{"file:///srv/shared", "file:///srv/channels/{channel_id}"}}
+	calls := &activeToolCalls{}
+	handler := (&UserClient{userID: "userid"}).rootsHandler(serverConfig, calls)
+
+	result, err := handler(context.Background(), rootsListMethod, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []mcp.Root{{URI: "file:///srv/shared", Name: "shared"}}, result.(mcp.ListRootsResult).Roots)
+
+	t.Run("scopes to the channel of the tool running", func(t *testing.T) {
+		_, end := calls.start(context.Background(), "botid", "channelid")
+		defer end()
+
+		result, err := handler(context.Background(), rootsListMethod, nil)
+		require.NoError(t, err)
+		assert.Len(t, result.(mcp.ListRootsResult).Roots, 2)
+	})
+
+	t.Run("shared servers only get roots without variables", func(t *testing.T) {
+		serverConfig.Shared = true
+		handler := (&UserClient{}).rootsHandler(serverConfig, calls)
+		_, end := calls.start(context.Background(), "botid", "channelid")
+		defer end()
+
+		result, err := handler(context.Background(), rootsListMethod, nil)
+		require.NoError(t, err)
+		assert.Len(t, result.(mcp.ListRootsResult).Roots, 1)
+	})
+
+	t.Run("dispatches by method", func(t *testing.T) {
+		dispatch := dispatchRequests(map[string]requestHandler{rootsListMethod: handler})
+		_, err := dispatch(context.Background(), samplingMethod, nil)
+		assert.ErrorIs(t, err, errMethodNotFound)
+		assert.Nil(t, dispatchRequests(nil))
+	})
+}
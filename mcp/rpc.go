@@ -0,0 +1,226 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// rpcMessage is a JSON-RPC message received from the server, a response or a request or notification
+// sent by the server.
+type rpcMessage struct {
+	ID     *int64          `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// serverRequest is a request the server sends to the client, whose ID can be a number or a string.
+type serverRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// parseServerRequest returns the request in a message, false for responses and notifications.
+func parseServerRequest(data []byte) (serverRequest, bool) {
+	var request serverRequest
+	if err := json.Unmarshal(data, &request); err != nil || request.Method == "" || len(request.ID) == 0 {
+		return serverRequest{}, false
+	}
+	return request, true
+}
+
+// toolsListChangedNotification is sent by servers when the tools they offer change
+const toolsListChangedNotification = "notifications/tools/list_changed"
+
+// parseServerNotification returns the method of the notification in a message, false for responses and
+// requests.
+func parseServerNotification(data []byte) (string, bool) {
+	var notification struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal(data, &notification); err != nil || notification.Method == "" || len(notification.ID) != 0 {
+		return "", false
+	}
+	return notification.Method, true
+}
+
+// notificationHandler handles the notifications the server sends to the client. It must not block, as
+// the messages of the server aren't read meanwhile.
+type notificationHandler func(method string)
+
+// requestHandler answers the requests the server sends to the client, returning the result of the response.
+type requestHandler func(ctx context.Context, method string, params json.RawMessage) (any, error)
+
+// serverHandlers handle the messages the server sends to the client, with the capabilities the client
+// advertises for the requests it answers.
+type serverHandlers struct {
+	capabilities mcp.ClientCapabilities
+	request      requestHandler
+	notification notificationHandler
+}
+
+// dispatchRequests answers the requests of the server with the handler of their method. It returns nil
+// without handlers, for clients answering no request.
+func dispatchRequests(handlers map[string]requestHandler) requestHandler {
+	if len(handlers) == 0 {
+		return nil
+	}
+	return func(ctx context.Context, method string, params json.RawMessage) (any, error) {
+		handler, ok := handlers[method]
+		if !ok {
+			return nil, errMethodNotFound
+		}
+		return handler(ctx, method, params)
+	}
+}
+
+// errMethodNotFound is returned by request handlers for the methods they don't answer.
+var errMethodNotFound = errors.New("method not found")
+
+// rpcError is an error returned to the server with its code.
+type rpcError struct {
+	Code    int
+	Message string
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// answerServerRequest returns the response to a request of the server. Requests are answered with an
+// error when the client has no handler, as the client then doesn't advertise any capability.
+func answerServerRequest(ctx context.Context, handler requestHandler, request serverRequest) any {
+	var result any
+	err := errMethodNotFound
+	if handler != nil {
+		result, err = handler(ctx, request.Method, request.Params)
+	}
+	if err == nil {
+		return mcp.JSONRPCResponse{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      request.ID,
+			Result:  result,
+		}
+	}
+
+	response := mcp.JSONRPCError{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      request.ID,
+	}
+	var codeErr *rpcError
+	switch {
+	case errors.As(err, &codeErr):
+		response.Error.Code = codeErr.Code
+	case errors.Is(err, errMethodNotFound):
+		response.Error.Code = mcp.METHOD_NOT_FOUND
+	default:
+		response.Error.Code = mcp.INTERNAL_ERROR
+	}
+	response.Error.Message = err.Error()
+	return response
+}
+
+// callFunc sends a request to the server and returns the result of its response.
+type callFunc func(ctx context.Context, method string, params any) (json.RawMessage, error)
+
+// responseResult returns the result of a response, or its error.
+func responseResult(message rpcMessage, id int64) (json.RawMessage, error) {
+	if message.ID == nil || *message.ID != id {
+		return nil, errors.New("response does not match the request")
+	}
+	if message.Error != nil {
+		return nil, fmt.Errorf("request failed: %s (code %d)", message.Error.Message, message.Error.Code)
+	}
+	return message.Result, nil
+}
+
+// initializeParams are the params of an initialize request, with all the required fields.
+func initializeParams(request mcp.InitializeRequest) any {
+	return struct {
+		ProtocolVersion string                 `json:"protocolVersion"`
+		ClientInfo      mcp.Implementation     `json:"clientInfo"`
+		Capabilities    mcp.ClientCapabilities `json:"capabilities"`
+	}{
+		ProtocolVersion: request.Params.ProtocolVersion,
+		ClientInfo:      request.Params.ClientInfo,
+		Capabilities:    request.Params.Capabilities,
+	}
+}
+
+// listAllTools returns the tools of a server, requesting every page of them.
+func listAllTools(ctx context.Context, call callFunc, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	var result mcp.ListToolsResult
+	for {
+		response, err := call(ctx, "tools/list", request.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		var page mcp.ListToolsResult
+		if err := json.Unmarshal(response, &page); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal tools: %w", err)
+		}
+		result.Tools = append(result.Tools, page.Tools...)
+
+		if page.NextCursor == "" {
+			return &result, nil
+		}
+		request.Params.Cursor = page.NextCursor
+	}
+}
+
+// callTool calls a tool of a server.
+func callTool(ctx context.Context, call callFunc, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	response, err := call(ctx, "tools/call", request.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := json.RawMessage(response)
+	return mcp.ParseCallToolResult(&raw)
+}
+
+// listAllPrompts returns the prompts of a server, requesting every page of them.
+func listAllPrompts(ctx context.Context, call callFunc, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	var result mcp.ListPromptsResult
+	for {
+		response, err := call(ctx, "prompts/list", request.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		var page mcp.ListPromptsResult
+		if err := json.Unmarshal(response, &page); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal prompts: %w", err)
+		}
+		result.Prompts = append(result.Prompts, page.Prompts...)
+
+		if page.NextCursor == "" {
+			return &result, nil
+		}
+		request.Params.Cursor = page.NextCursor
+	}
+}
+
+// getPrompt gets a prompt of a server, filled in with the arguments.
+func getPrompt(ctx context.Context, call callFunc, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	response, err := call(ctx, "prompts/get", request.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := json.RawMessage(response)
+	return mcp.ParseGetPromptResult(&raw)
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// samplingMethod is the method of the requests of servers for completions
+	samplingMethod = "sampling/createMessage"
+
+	// samplingRejectedCode is the error code returned to servers when the user rejects their sampling request
+	samplingRejectedCode = -1
+)
+
+// ErrSamplingRejected is returned by samplers when the user doesn't approve the sampling request.
+var ErrSamplingRejected = errors.New("sampling request rejected by the user")
+
+// SamplingMessage is a message of the conversation a server asks a completion for
+type SamplingMessage struct {
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+// SamplingRequest is a request of an MCP server for a completion, made while one of its tools runs for the
+// user. It is answered by the bot that called the tool, in the channel it was called in.
+type SamplingRequest struct {
+	ServerID     string            `json:"server_id"`
+	UserID       string            `json:"user_id"`
+	BotID        string            `json:"bot_id"`
+	ChannelID    string            `json:"channel_id"`
+	SystemPrompt string            `json:"system_prompt,omitempty"`
+	Messages     []SamplingMessage `json:"messages"`
+	MaxTokens    int               `json:"max_tokens,omitempty"`
+}
+
+// SamplingResult is the completion of a sampling request, and the model that generated it
+type SamplingResult struct {
+	Text  string
+	Model string
+}
+
+// Sampler asks the user to approve the sampling requests of MCP servers, and has the bot answer them.
+type Sampler interface {
+	Sample(ctx context.Context, request SamplingRequest) (SamplingResult, error)
+}
+
+// activeToolCall is a tool call of a server that is running, made through the bot in the channel.
+type activeToolCall struct {
+	botID     string
+	channelID string
+}
+
+// activeToolCallKey is the key of the tool call a request is made for in its context
+type activeToolCallKey struct{}
+
+// activeToolCalls are the tool calls of a server that are running. Servers only make sampling requests
+// while handling a call, which is the one the request is made for.
+type activeToolCalls struct {
+	mu    sync.Mutex
+	calls []*activeToolCall
+}
+
+// start adds a call, and returns the context to make it in, which identifies the call to the requests
+// the server sends while handling it, and the function removing it once it has ended.
+func (a *activeToolCalls) start(ctx context.Context, botID, channelID string) (context.Context, func()) {
+	call := &activeToolCall{botID: botID, channelID: channelID}
+
+	a.mu.Lock()
+	a.calls = append(a.calls, call)
+	a.mu.Unlock()
+
+	return context.WithValue(ctx, activeToolCallKey{}, call), func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		for i := range a.calls {
+			if a.calls[i] == call {
+				a.calls = append(a.calls[:i], a.calls[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// forRequest returns the running call a request of the server is made for. Requests sent in the response
+// to a call carry it in their context. Other requests, like those of stdio servers, are attributed to the
+// only call running, and to none when several are, since they can't be told apart.
+func (a *activeToolCalls) forRequest(ctx context.Context) *activeToolCall {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if call, ok := ctx.Value(activeToolCallKey{}).(*activeToolCall); ok && slices.Contains(a.calls, call) {
+		return call
+	}
+	if len(a.calls) == 1 {
+		return a.calls[0]
+	}
+	return nil
+}
+
+// samplingHandler answers the sampling requests of a server with the sampler, on behalf of the user and
+// the bot running one of the server's tools.
+func (c *UserClient) samplingHandler(serverID string, calls *activeToolCalls) requestHandler {
+	return func(ctx context.Context, method string, params json.RawMessage) (any, error) {
+		if method != samplingMethod {
+			return nil, errMethodNotFound
+		}
+
+		call := calls.forRequest(ctx)
+		if call == nil {
+			return nil, &rpcError{Code: mcp.INVALID_REQUEST, Message: "sampling is only available while a tool of the server runs"}
+		}
+
+		request, err := parseSamplingRequest(params)
+		if err != nil {
+			return nil, &rpcError{Code: mcp.INVALID_PARAMS, Message: err.Error()}
+		}
+		request.ServerID = serverID
+		request.UserID = c.userID
+		request.BotID = call.botID
+		request.ChannelID = call.channelID
+
+		result, err := c.sampler.Sample(ctx, request)
+		if errors.Is(err, ErrSamplingRejected) {
+			return nil, &rpcError{Code: samplingRejectedCode, Message: "User rejected sampling request"}
+		}
+		if err != nil {
+			c.log.Warn("MCP sampling request failed", "userID", c.userID, "serverID", serverID, "error", err)
+			return nil, err
+		}
+
+		return mcp.CreateMessageResult{
+			SamplingMessage: mcp.SamplingMessage{
+				Role:    mcp.RoleAssistant,
+				Content: mcp.NewTextContent(result.Text),
+			},
+			Model:      result.Model,
+			StopReason: "endTurn",
+		}, nil
+	}
+}
+
+// parseSamplingRequest reads the params of a sampling request. Only text messages are supported.
+func parseSamplingRequest(params json.RawMessage) (SamplingRequest, error) {
+	var createMessage mcp.CreateMessageRequest
+	if err := json.Unmarshal(params, &createMessage.Params); err != nil {
+		return SamplingRequest{}, fmt.Errorf("invalid sampling request: %w", err)
+	}
+
+	request := SamplingRequest{
+		SystemPrompt: createMessage.Params.SystemPrompt,
+		MaxTokens:    createMessage.Params.MaxTokens,
+	}
+	for _, message := range createMessage.Params.Messages {
+		content, _ := message.Content.(map[string]any)
+		text, _ := content["text"].(string)
+		if content["type"] != "text" {
+			return SamplingRequest{}, fmt.Errorf("unsupported sampling message content type %v", content["type"])
+		}
+		request.Messages = append(request.Messages, SamplingMessage{
+			Role: string(message.Role),
+			Text: text,
+		})
+	}
+	if len(request.Messages) == 0 {
+		return SamplingRequest{}, errors.New("sampling request has no messages")
+	}
+	return request, nil
+}
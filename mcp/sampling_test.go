@@ -0,0 +1,209 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSampler struct {
+	requests []SamplingRequest
+	err      error
+}
+
+func (s *testSampler) Sample(_ context.Context, request SamplingRequest) (SamplingResult, error) {
+	s.requests = append(s.requests, request)
+	if s.err != nil {
+		return SamplingResult{}, s.err
+	}
+	return SamplingResult{Text: "sampled", Model: "model"}, nil
+}
+
+const testSamplingParams = `{"systemPrompt":"Be brief","maxTokens":100,"messages":[{"role":"user","content":{"type":"text","text":"Summarize"}}]}`
+
+func TestSamplingHandler(t *testing.T) {
+	sampler := &testSampler{}
+	client := &UserClient{userID: "userid", sampler: sampler}
+	calls := &activeToolCalls{}
+	handler := client.samplingHandler("server", calls)
+
+	t.Run("rejects requests made outside of tool calls", func(t *testing.T) {
+		_, err := handler(context.Background(), "sampling/createMessage", json.RawMessage(testSamplingParams))
+		var codeErr *rpcError
+		require.ErrorAs(t, err, &codeErr)
+		assert.Equal(t, mcp.INVALID_REQUEST, codeErr.Code)
+		assert.Empty(t, sampler.requests)
+	})
+
+	t.Run("answers for the bot running the tool", func(t *testing.T) {
+		_, end := calls.start(context.Background(), "botid", "channelid")
+		defer end()
+
+		result, err := handler(context.Background(), "sampling/createMessage", json.RawMessage(testSamplingParams))
+		require.NoError(t, err)
+		assert.Equal(t, "sampled", result.(mcp.CreateMessageResult).Content.(mcp.TextContent).Text)
+
+		require.Len(t, sampler.requests, 1)
+		assert.Equal(t, SamplingRequest{
+			ServerID:     "server",
+			UserID:       "userid",
+			BotID:        "botid",
+			ChannelID:    "channelid",
+			SystemPrompt: "Be brief",
+			Messages:     []SamplingMessage{{Role: "user", Text: "Summarize"}},
+			MaxTokens:    100,
+		}, sampler.requests[0])
+	})
+
+	t.Run("answers for the tool call the request is made for", func(t *testing.T) {
+		sampler.requests = nil
+		_, endFirst := calls.start(context.Background(), "botid", "channelid")
+		defer endFirst()
+		ctx, endSecond := calls.start(context.Background(), "otherbotid", "otherchannelid")
+		defer endSecond()
+
+		_, err := handler(ctx, "sampling/createMessage", json.RawMessage(testSamplingParams))
+		require.NoError(t, err)
+		require.Len(t, sampler.requests, 1)
+		assert.Equal(t, "otherbotid", sampler.requests[0].BotID)
+		assert.Equal(t, "otherchannelid", sampler.requests[0].ChannelID)
+
+		// Requests that don't identify their call can't be attributed while several run
+		_, err = handler(context.Background(), "sampling/createMessage", json.RawMessage(testSamplingParams))
+		var codeErr *rpcError
+		require.ErrorAs(t, err, &codeErr)
+		assert.Equal(t, mcp.INVALID_REQUEST, codeErr.Code)
+		assert.Len(t, sampler.requests, 1)
+	})
+
+	t.Run("reports rejected requests", func(t *testing.T) {
+		_, end := calls.start(context.Background(), "botid", "channelid")
+		defer end()
+		sampler.err = ErrSamplingRejected
+		defer func() { sampler.err = nil }()
+
+		_, err := handler(context.Background(), "sampling/createMessage", json.RawMessage(testSamplingParams))
+		var codeErr *rpcError
+		require.ErrorAs(t, err, &codeErr)
+		assert.Equal(t, samplingRejectedCode, codeErr.Code)
+	})
+
+	t.Run("rejects messages that aren't text", func(t *testing.T) {
+		_, end := calls.start(context.Background(), "botid", "channelid")
+		defer end()
+
+		_, err := handler(context.Background(), "sampling/createMessage", json.RawMessage(`{"messages":[{"role":"user","content":{"type":"image","data":"","mimeType":"image/png"}}]}`))
+		var codeErr *rpcError
+		require.ErrorAs(t, err, &codeErr)
+		assert.Equal(t, mcp.INVALID_PARAMS, codeErr.Code)
+	})
+
+	t.Run("doesn't answer other methods", func(t *testing.T) {
+		_, err := handler(context.Background(), "roots/list", nil)
+		assert.ErrorIs(t, err, errMethodNotFound)
+	})
+}
+
+func TestAnswerServerRequest(t *testing.T) {
+	request := serverRequest{ID: json.RawMessage(`"abc"`), Method: "roots/list"}
+
+	data, err := json.Marshal(answerServerRequest(context.Background(), nil, request))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":"abc","error":{"code":-32601,"message":"method not found"}}`, string(data))
+
+	data, err = json.Marshal(answerServerRequest(context.Background(), func(context.Context, string, json.RawMessage) (any, error) {
+		return map[string]any{}, nil
+	}, request))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":"abc","result":{}}`, string(data))
+}
+
+// TestStreamableHTTPClientSampling has the server ask for a completion in the stream of a tool call, and
+// answer the call with it.
+func TestStreamableHTTPClientSampling(t *testing.T) {
+	var mu sync.Mutex
+	var sampled string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Result struct {
+				Content mcp.TextContent `json:"content"`
+			} `json:"result"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch message.Method {
+		case "initialize":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(message.ID) + `,"result":{"protocolVersion":"2025-03-26","serverInfo":{"name":"test"}}}`))
+		case "tools/call":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(`data: {"jsonrpc":"2.0","id":"sample-1","method":"sampling/createMessage","params":` + testSamplingParams + "}\n\n"))
+			w.(http.Flusher).Flush()
+			// The response waits for the client to answer the sampling request
+			for {
+				mu.Lock()
+				text := sampled
+				mu.Unlock()
+				if text != "" {
+					_, _ = w.Write([]byte(`data: {"jsonrpc":"2.0","id":` + string(message.ID) + `,"result":{"content":[{"type":"text","text":"` + text + `"}]}}` + "\n\n"))
+					return
+				}
+				select {
+				case <-r.Context().Done():
+					return
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+		case "":
+			mu.Lock()
+			sampled = message.Result.Content.Text
+			mu.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer httpServer.Close()
+
+	calls := &activeToolCalls{}
+	userClient := &UserClient{userID: "userid", sampler: &testSampler{}}
+	handler := userClient.samplingHandler("server", calls)
+
+	client := NewStreamableHTTPClient(httpServer.URL, nil)
+	client.handleRequest = handler
+	_, err := client.Initialize(context.Background(), initializeRequest(mcp.ClientCapabilities{Sampling: &struct{}{}}))
+	require.NoError(t, err)
+
+	// Another call of the server runs at the same time, in another channel
+	_, endOther := calls.start(context.Background(), "otherbotid", "otherchannelid")
+	defer endOther()
+	ctx, end := calls.start(context.Background(), "botid", "channelid")
+	defer end()
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "summarize"
+	result, err := client.CallTool(ctx, request)
+	require.NoError(t, err)
+	text, ok := mcp.AsTextContent(result.Content[0])
+	require.True(t, ok)
+	assert.Equal(t, "sampled", text.Text)
+
+	require.Len(t, userClient.sampler.(*testSampler).requests, 1)
+	assert.Equal(t, "botid", userClient.sampler.(*testSampler).requests[0].BotID)
+	assert.Equal(t, "channelid", userClient.sampler.(*testSampler).requests[0].ChannelID)
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// secretsKVKey is the key of the KV store entry holding the encrypted secrets
+const secretsKVKey = "mcp_secrets"
+
+var (
+	// ErrSecretNotFound is returned for secrets that aren't stored
+	ErrSecretNotFound = errors.New("MCP secret not found")
+
+	// ErrInvalidSecretName is returned for secret names that can't be referenced
+	ErrInvalidSecretName = errors.New("secret names can only contain letters, numbers, '-', '_' and '.'")
+
+	validSecretName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+	// secretReference matches the references to secrets in server configs, such as ${secret:github_token}
+	secretReference = regexp.MustCompile(`\$\{secret:([A-Za-z0-9_.-]{1,64})\}`)
+)
+
+// SecretKV is the part of the KV store secrets are kept in. Secrets are changed with compare and set, so
+// the servers of a cluster changing them at once don't overwrite each other.
+type SecretKV interface {
+	Get(key string, o any) error
+	SetAtomicWithRetries(key string, valueFunc func(oldValue []byte) (newValue any, err error)) error
+}
+
+// SecretResolver returns the values of the secrets server configs reference by name
+type SecretResolver interface {
+	Get(name string) (string, error)
+}
+
+// SecretInfo describes a stored secret, without its value
+type SecretInfo struct {
+	Name     string `json:"name"`
+	KeyID    string `json:"key_id"`
+	UpdateAt int64  `json:"update_at"`
+}
+
+// encryptedSecret is a secret encrypted with AES-GCM by one of the keys of the keyring, the nonce prefixing
+// the ciphertext
+type encryptedSecret struct {
+	KeyID      string `json:"key_id"`
+	Ciphertext []byte `json:"ciphertext"`
+	UpdateAt   int64  `json:"update_at"`
+}
+
+// secretsState is the KV store entry of the secrets. It only holds the IDs of the keys secrets are
+// encrypted with, the keys themselves are in the keyring.
+type secretsState struct {
+	Secrets map[string]encryptedSecret `json:"secrets"`
+}
+
+// SecretStore keeps the credentials of MCP servers encrypted in the KV store, so server configs reference
+// them by name instead of holding them in plaintext.
+type SecretStore struct {
+	mu      sync.Mutex
+	kv      SecretKV
+	keyring *SecretKeyring
+}
+
+// NewSecretStore creates a secret store encrypting the secrets with the keys of the keyring. Without a
+// keyring, secrets can be listed and deleted but not read or stored.
+func NewSecretStore(kv SecretKV, keyring *SecretKeyring) *SecretStore {
+	return &SecretStore{kv: kv, keyring: keyring}
+}
+
+func (s *SecretStore) load() (*secretsState, error) {
+	state := &secretsState{}
+	if err := s.kv.Get(secretsKVKey, state); err != nil {
+		return nil, fmt.Errorf("failed to load MCP secrets: %w", err)
+	}
+	if state.Secrets == nil {
+		state.Secrets = map[string]encryptedSecret{}
+	}
+	return state, nil
+}
+
+// update changes the secrets with compare and set, calling change again with the current secrets when
+// another server changed them in the meantime
+func (s *SecretStore) update(change func(state *secretsState) error) error {
+	err := s.kv.SetAtomicWithRetries(secretsKVKey, func(oldValue []byte) (any, error) {
+		state := &secretsState{}
+		if oldValue != nil {
+			if err := json.Unmarshal(oldValue, state); err != nil {
+				return nil, fmt.Errorf("failed to load MCP secrets: %w", err)
+			}
+		}
+		if state.Secrets == nil {
+			state.Secrets = map[string]encryptedSecret{}
+		}
+		if err := change(state); err != nil {
+			return nil, err
+		}
+		return state, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save MCP secrets: %w", err)
+	}
+	return nil
+}
+
+// List returns the stored secrets sorted by name.
+func (s *SecretStore) List() ([]SecretInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make([]SecretInfo, 0, len(state.Secrets))
+	for name, secret := range state.Secrets {
+		secrets = append(secrets, SecretInfo{Name: name, KeyID: secret.KeyID, UpdateAt: secret.UpdateAt})
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+	return secrets, nil
+}
+
+// Get returns the value of a secret.
+func (s *SecretStore) Get(name string) (string, error) {
+	if s.keyring == nil {
+		return "", ErrNoSecretsKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := state.Secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	return s.keyring.decrypt(secret)
+}
+
+// Set stores a secret encrypted with the active key of the keyring.
+func (s *SecretStore) Set(name, value string) error {
+	if !validSecretName.MatchString(name) {
+		return ErrInvalidSecretName
+	}
+	if s.keyring == nil {
+		return ErrNoSecretsKey
+	}
+
+	secret, err := s.keyring.encrypt(value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(state *secretsState) error {
+		state.Secrets[name] = secret
+		return nil
+	})
+}
+
+// Delete removes a secret.
+func (s *SecretStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(state *secretsState) error {
+		if _, ok := state.Secrets[name]; !ok {
+			return fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+		}
+		delete(state.Secrets, name)
+		return nil
+	})
+}
+
+// RotateKey encrypts the secrets encrypted with other keys with the active key of the keyring, so the
+// other keys can be removed from it. Secrets are saved once all of them are encrypted again, so they
+// stay readable if the rotation fails.
+func (s *SecretStore) RotateKey() error {
+	if s.keyring == nil {
+		return ErrNoSecretsKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(state *secretsState) error {
+		for name, secret := range state.Secrets {
+			if secret.KeyID == s.keyring.ActiveKeyID() {
+				continue
+			}
+			value, err := s.keyring.decrypt(secret)
+			if err != nil {
+				return fmt.Errorf("failed to rotate secret %s: %w", name, err)
+			}
+			rotated, err := s.keyring.encrypt(value)
+			if err != nil {
+				return fmt.Errorf("failed to rotate secret %s: %w", name, err)
+			}
+			rotated.UpdateAt = secret.UpdateAt
+			state.Secrets[name] = rotated
+		}
+		return nil
+	})
+}
+
+func (k *SecretKeyring) encrypt(value string) (encryptedSecret, error) {
+	gcm, err := newGCM(k.keys[k.activeKeyID])
+	if err != nil {
+		return encryptedSecret{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return encryptedSecret{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return encryptedSecret{
+		KeyID:      k.activeKeyID,
+		Ciphertext: gcm.Seal(nonce, nonce, []byte(value), nil),
+		UpdateAt:   model.GetMillis(),
+	}, nil
+}
+
+func (k *SecretKeyring) decrypt(secret encryptedSecret) (string, error) {
+	key, err := k.key(secret.KeyID)
+	if err != nil {
+		return "", err
+	}
+	return open(key, secret.Ciphertext)
+}
+
+// open decrypts a secret sealed with the key, the nonce prefixing the ciphertext
+func open(key, sealed []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted secret is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	value, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(value), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MCP secrets key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// expandSecrets returns the config with the references to secrets in its headers, arguments and
+// environment replaced by their values. Configs referencing secrets fail without a resolver.
+func expandSecrets(serverConfig ServerConfig, secrets SecretResolver) (ServerConfig, error) {
+	var err error
+	expand := func(value string) string {
+		return secretReference.ReplaceAllStringFunc(value, func(reference string) string {
+			if err != nil {
+				return ""
+			}
+			name := secretReference.FindStringSubmatch(reference)[1]
+			if secrets == nil {
+				err = fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+				return ""
+			}
+			var secret string
+			secret, err = secrets.Get(name)
+			return secret
+		})
+	}
+
+	expanded := serverConfig
+	if serverConfig.Headers != nil {
+		expanded.Headers = make(map[string]string, len(serverConfig.Headers))
+		for name, value := range serverConfig.Headers {
+			expanded.Headers[name] = expand(value)
+		}
+	}
+	if serverConfig.Env != nil {
+		expanded.Env = make(map[string]string, len(serverConfig.Env))
+		for name, value := range serverConfig.Env {
+			expanded.Env[name] = expand(value)
+		}
+	}
+	expanded.Args = slices.Clone(serverConfig.Args)
+	for i := range expanded.Args {
+		expanded.Args[i] = expand(expanded.Args[i])
+	}
+
+	if err != nil {
+		return ServerConfig{}, fmt.Errorf("failed to read the secrets of the server: %w", err)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// SecretsKeysEnv holds the keys MCP secrets are encrypted with, as a list of id:base64 keys separated
+	// by commas or new lines. The first key is the active one, the others only decrypt the secrets they
+	// encrypted until the key is rotated.
+	SecretsKeysEnv = "MM_AI_MCP_SECRETS_KEYS"
+
+	// SecretsKeysFileEnv is the path of a file holding the keys in the format of SecretsKeysEnv
+	SecretsKeysFileEnv = "MM_AI_MCP_SECRETS_KEYS_FILE"
+
+	// derivedKeyContext separates the key derived from the at-rest key of the server from its other uses
+	derivedKeyContext = "mattermost-ai/mcp-secrets"
+)
+
+// ErrNoSecretsKey is returned when no key to encrypt the secrets with is configured
+var ErrNoSecretsKey = errors.New("no encryption key is configured for MCP secrets")
+
+// SecretKeyring holds the keys secrets are encrypted with. The keys are kept outside of the KV store, which
+// only holds the IDs of the keys along with the encrypted secrets. The key derived from the at-rest key of
+// the server is only as safe as the server config, which is often stored in the same database.
+type SecretKeyring struct {
+	activeKeyID string
+	keys        map[string][]byte
+}
+
+// NewSecretKeyring returns the keys configured in the environment of the server, from SecretsKeysEnv or
+// the file at SecretsKeysFileEnv. Without any, a single key is derived from the at-rest encryption key of
+// the server.
+func NewSecretKeyring(atRestEncryptKey string) (*SecretKeyring, error) {
+	if keys := os.Getenv(SecretsKeysEnv); keys != "" {
+		return parseSecretKeyring(keys)
+	}
+	if path := os.Getenv(SecretsKeysFileEnv); path != "" {
+		keys, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MCP secrets keys: %w", err)
+		}
+		return parseSecretKeyring(string(keys))
+	}
+	if atRestEncryptKey == "" {
+		return nil, ErrNoSecretsKey
+	}
+	return derivedSecretKeyring(atRestEncryptKey), nil
+}
+
+// parseSecretKeyring parses a list of id:base64 keys, the first being the active one
+func parseSecretKeyring(value string) (*SecretKeyring, error) {
+	keyring := &SecretKeyring{keys: map[string][]byte{}}
+	entries := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	})
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		keyID, encoded, ok := strings.Cut(entry, ":")
+		if !ok || keyID == "" {
+			return nil, errors.New("MCP secrets keys must be written as id:base64")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MCP secrets key %s: %w", keyID, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("MCP secrets key %s must be 32 bytes long", keyID)
+		}
+		if _, exists := keyring.keys[keyID]; exists {
+			return nil, fmt.Errorf("MCP secrets key %s is listed twice", keyID)
+		}
+		keyring.keys[keyID] = key
+		if keyring.activeKeyID == "" {
+			keyring.activeKeyID = keyID
+		}
+	}
+	if keyring.activeKeyID == "" {
+		return nil, ErrNoSecretsKey
+	}
+	return keyring, nil
+}
+
+// derivedSecretKeyring derives a key from the at-rest encryption key of the server. The ID of the key
+// identifies the at-rest key, so secrets encrypted before it changed are reported as missing their key.
+func derivedSecretKeyring(atRestEncryptKey string) *SecretKeyring {
+	mac := hmac.New(sha256.New, []byte(atRestEncryptKey))
+	mac.Write([]byte(derivedKeyContext))
+	key := mac.Sum(nil)
+
+	fingerprint := sha256.Sum256(key)
+	keyID := "server-" + hex.EncodeToString(fingerprint[:4])
+	return &SecretKeyring{
+		activeKeyID: keyID,
+		keys:        map[string][]byte{keyID: key},
+	}
+}
+
+// ActiveKeyID returns the ID of the key new secrets are encrypted with.
+func (k *SecretKeyring) ActiveKeyID() string {
+	return k.activeKeyID
+}
+
+func (k *SecretKeyring) key(keyID string) ([]byte, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("encryption key %s of the secret is missing", keyID)
+	}
+	return key, nil
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryKV keeps the KV store entries as JSON, like the plugin API does
+type memoryKV map[string][]byte
+
+func (kv memoryKV) Get(key string, o any) error {
+	data, ok := kv[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(data, o)
+}
+
+func (kv memoryKV) SetAtomicWithRetries(key string, valueFunc func(oldValue []byte) (any, error)) error {
+	return kv.setAtomic(key, valueFunc, nil)
+}
+
+// setAtomic sets the entry with compare and set, calling beforeSet between reading and setting it
+func (kv memoryKV) setAtomic(key string, valueFunc func(oldValue []byte) (any, error), beforeSet func()) error {
+	for range 3 {
+		oldValue := kv[key]
+		value, err := valueFunc(bytes.Clone(oldValue))
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if beforeSet != nil {
+			beforeSet()
+			beforeSet = nil
+		}
+		if bytes.Equal(kv[key], oldValue) {
+			kv[key] = data
+			return nil
+		}
+	}
+	return errors.New("failed to set value after 3 retries")
+}
+
+// racingKV is a KV store another server changes once between the first read and set
+type racingKV struct {
+	memoryKV
+	race func()
+}
+
+func (kv *racingKV) SetAtomicWithRetries(key string, valueFunc func(oldValue []byte) (any, error)) error {
+	race := kv.race
+	kv.race = nil
+	return kv.setAtomic(key, valueFunc, race)
+}
+
+// testKeyring returns a keyring of the keys, each one made of its ID repeated, the first being the active one
+func testKeyring(t *testing.T, keyIDs ...string) *SecretKeyring {
+	t.Helper()
+	keys := ""
+	for _, keyID := range keyIDs {
+		key := bytes.Repeat([]byte(keyID), 32)[:32]
+		keys += keyID + ":" + base64.StdEncoding.EncodeToString(key) + ","
+	}
+	keyring, err := parseSecretKeyring(keys)
+	require.NoError(t, err)
+	return keyring
+}
+
+func TestSecretStore(t *testing.T) {
+	kv := memoryKV{}
+	store := NewSecretStore(kv, testKeyring(t, "first"))
+
+	require.NoError(t, store.Set("github_token", "ghp_secret"))
+	require.NoError(t, store.Set("jira", "jira-secret"))
+	assert.NotContains(t, string(kv[secretsKVKey]), "ghp_secret")
+
+	value, err := store.Get("github_token")
+	require.NoError(t, err)
+	assert.Equal(t, "ghp_secret", value)
+
+	_, err = store.Get("missing")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+	assert.ErrorIs(t, store.Set("not valid", "value"), ErrInvalidSecretName)
+
+	t.Run("rotating the key encrypts the secrets again", func(t *testing.T) {
+		store = NewSecretStore(kv, testKeyring(t, "second", "first"))
+		value, err := store.Get("jira")
+		require.NoError(t, err, "the previous key still decrypts the secrets")
+		assert.Equal(t, "jira-secret", value)
+
+		require.NoError(t, store.RotateKey())
+
+		after, err := store.List()
+		require.NoError(t, err)
+		require.Len(t, after, 2)
+		assert.Equal(t, "github_token", after[0].Name)
+		assert.Equal(t, "second", after[0].KeyID)
+		assert.Equal(t, "second", after[1].KeyID)
+
+		store = NewSecretStore(kv, testKeyring(t, "second"))
+		value, err = store.Get("jira")
+		require.NoError(t, err)
+		assert.Equal(t, "jira-secret", value)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete("jira"))
+		assert.ErrorIs(t, store.Delete("jira"), ErrSecretNotFound)
+	})
+}
+
+func TestSecretStoreKVDump(t *testing.T) {
+	kv := memoryKV{}
+	keyring := derivedSecretKeyring("at-rest-key")
+	store := NewSecretStore(kv, keyring)
+	require.NoError(t, store.Set("github_token", "ghp_secret"))
+
+	dump := memoryKV{}
+	for key, value := range kv {
+		dump[key] = bytes.Clone(value)
+	}
+
+	key := keyring.keys[keyring.ActiveKeyID()]
+	assert.NotContains(t, string(dump[secretsKVKey]), base64.StdEncoding.EncodeToString(key), "the key isn't stored")
+	var state map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(dump[secretsKVKey], &state))
+	assert.NotContains(t, state, "keys")
+
+	// Without the key, the secrets can't be decrypted from the KV store alone
+	_, err := NewSecretStore(dump, nil).Get("github_token")
+	assert.ErrorIs(t, err, ErrNoSecretsKey)
+	_, err = NewSecretStore(dump, derivedSecretKeyring("another-key")).Get("github_token")
+	assert.ErrorContains(t, err, "is missing")
+
+	var secrets secretsState
+	require.NoError(t, json.Unmarshal(dump[secretsKVKey], &secrets))
+	secret := secrets.Secrets["github_token"]
+	_, err = open(bytes.Repeat([]byte{0}, 32), secret.Ciphertext)
+	assert.Error(t, err)
+
+	value, err := NewSecretStore(dump, keyring).Get("github_token")
+	require.NoError(t, err)
+	assert.Equal(t, "ghp_secret", value)
+}
+
+func TestSecretStoreConcurrentChanges(t *testing.T) {
+	kv := memoryKV{}
+	other := NewSecretStore(kv, testKeyring(t, "key"))
+	store := NewSecretStore(&racingKV{memoryKV: kv, race: func() {
+		require.NoError(t, other.Set("jira", "jira-secret"))
+	}}, testKeyring(t, "key"))
+
+	require.NoError(t, store.Set("github_token", "ghp_secret"))
+
+	secrets, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, secrets, 2, "the secret the other server saved is kept")
+	assert.Equal(t, "github_token", secrets[0].Name)
+	assert.Equal(t, "jira", secrets[1].Name)
+}
+
+func TestParseSecretKeyring(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+
+	keyring, err := parseSecretKeyring("new:" + key + "\nold:" + key)
+	require.NoError(t, err)
+	assert.Equal(t, "new", keyring.ActiveKeyID())
+	assert.Len(t, keyring.keys, 2)
+
+	for _, keys := range []string{"", key, "short:" + base64.StdEncoding.EncodeToString([]byte("short")), "a:" + key + ",a:" + key} {
+		_, err = parseSecretKeyring(keys)
+		assert.Error(t, err, keys)
+	}
+}
+
+func TestExpandSecrets(t *testing.T) {
+	store := NewSecretStore(memoryKV{}, testKeyring(t, "key"))
+	require.NoError(t, store.Set("token", "abc"))
+
+	serverConfig := ServerConfig{
+		Headers: map[string]string{"Authorization": "Bearer ${secret:token}"},
+		Args:    []string{"--token=${secret:token}"},
+		Env:     map[string]string{"API_TOKEN": "${secret:token}"},
+	}
+
+	expanded, err := expandSecrets(serverConfig, store)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer abc", expanded.Headers["Authorization"])
+	assert.Equal(t, []string{"--token=abc"}, expanded.Args)
+	assert.Equal(t, "abc", expanded.Env["API_TOKEN"])
+	assert.Equal(t, "Bearer ${secret:token}", serverConfig.Headers["Authorization"], "the config isn't changed")
+
+	serverConfig.Headers["Authorization"] = "${secret:missing}"
+	_, err = expandSecrets(serverConfig, store)
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+
+	_, err = expandSecrets(ServerConfig{Args: []string{"${secret:token}"}}, nil)
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"sync"
+)
+
+// sharedConnections are the connections to the shared servers, made when a user first needs one and
+// used by all users until the client manager is closed.
+type sharedConnections struct {
+	mu          sync.Mutex
+	connections map[string]*ServerConnection
+	connect     func(ctx context.Context, serverID string, serverConfig ServerConfig) (*ServerConnection, error)
+}
+
+func newSharedConnections(connect func(ctx context.Context, serverID string, serverConfig ServerConfig) (*ServerConnection, error)) *sharedConnections {
+	return &sharedConnections{
+		connections: make(map[string]*ServerConnection),
+		connect:     connect,
+	}
+}
+
+// get returns the connection to the server, connecting to it if no user did yet. Failed connections
+// are tried again for the next user.
+func (s *sharedConnections) get(ctx context.Context, serverID string, serverConfig ServerConfig) (*ServerConnection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if connection, ok := s.connections[serverID]; ok {
+		return connection, nil
+	}
+
+	connection, err := s.connect(ctx, serverID, serverConfig)
+	if err != nil {
+		return nil, err
+	}
+	connection.shared = true
+	s.connections[serverID] = connection
+
+	return connection, nil
+}
+
+// serverIDs returns the servers connected to
+func (s *sharedConnections) serverIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	serverIDs := make([]string, 0, len(s.connections))
+	for serverID := range s.connections {
+		serverIDs = append(serverIDs, serverID)
+	}
+	return serverIDs
+}
+
+// close closes the connections. It returns the errors of the connections failing to close by server.
+func (s *sharedConnections) close() map[string]error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	errs := make(map[string]error)
+	for serverID, connection := range s.connections {
+		if err := connection.close(); err != nil {
+			errs[serverID] = err
+		}
+	}
+	s.connections = make(map[string]*ServerConnection)
+
+	return errs
+}
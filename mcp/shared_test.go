@@ -0,0 +1,57 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closeCountingClient is an MCP client only counting how many times it was closed
+type closeCountingClient struct {
+	mcpClient
+	closed int
+}
+
+func (c *closeCountingClient) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestSharedConnections(t *testing.T) {
+	connects := 0
+	fail := true
+	client := &closeCountingClient{}
+	shared := newSharedConnections(func(_ context.Context, serverID string, _ ServerConfig) (*ServerConnection, error) {
+		connects++
+		if fail {
+			return nil, errors.New("unreachable")
+		}
+		return &ServerConnection{client: client, serverID: serverID, tools: map[string]mcp.Tool{}}, nil
+	})
+
+	_, err := shared.get(context.Background(), "server", ServerConfig{Shared: true})
+	require.Error(t, err)
+	assert.Empty(t, shared.serverIDs())
+
+	fail = false
+	first, err := shared.get(context.Background(), "server", ServerConfig{Shared: true})
+	require.NoError(t, err)
+	second, err := shared.get(context.Background(), "server", ServerConfig{Shared: true})
+	require.NoError(t, err)
+
+	assert.Same(t, first, second)
+	assert.True(t, first.shared)
+	assert.Equal(t, 2, connects)
+	assert.Equal(t, []string{"server"}, shared.serverIDs())
+
+	assert.Empty(t, shared.close())
+	assert.Equal(t, 1, client.closed)
+	assert.Empty(t, shared.serverIDs())
+}
@@ -0,0 +1,556 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// MMUserIDEnv is set to the ID of the user in the environment of the stdio servers launched for them
+const MMUserIDEnv = "MATTERMOST_USER_ID"
+
+const (
+	// maxStdioRestarts limits how many times in a row a crashing server is restarted before giving up
+	maxStdioRestarts = 5
+
+	// stdioRestartDelay is the delay before the first restart, doubled for each following one
+	stdioRestartDelay    = time.Second
+	maxStdioRestartDelay = 30 * time.Second
+
+	// stdioStableTime is how long a server must run for its restarts to be forgotten
+	stdioStableTime = time.Minute
+
+	// stdioStopTimeout is how long a server has to exit once its input is closed before it is killed
+	stdioStopTimeout = 5 * time.Second
+
+	// stdioInitializeTimeout limits the initialization of restarted servers
+	stdioInitializeTimeout = 30 * time.Second
+
+	// stdioServerRequestTimeout limits how long answering a request of the server takes, such as waiting
+	// for the user to approve a sampling request
+	stdioServerRequestTimeout = 5 * time.Minute
+)
+
+// inheritedEnv are the variables of the plugin's environment stdio servers inherit. The rest, which can
+// hold the server's secrets, are not passed on.
+var inheritedEnv = []string{"PATH", "HOME", "TMPDIR", "LANG", "LC_ALL", "SYSTEMROOT"}
+
+var (
+	// errStdioServerExited is returned for requests the server exited without answering.
+	errStdioServerExited = errors.New("MCP server exited")
+
+	// errStdioClientClosed is returned for requests sent once the client is closed.
+	errStdioClientClosed = errors.New("MCP client closed")
+
+	// ErrStdioProcessLimit is returned when a stdio server already runs the most processes it can for users.
+	ErrStdioProcessLimit = errors.New("too many processes of the MCP server are running")
+)
+
+// StdioClient is an MCP client for a server the plugin launches, and talks to over its standard input and
+// output. The server is supervised: when it exits unexpectedly it is restarted after a growing delay and
+// initialized again, until it crashes too many times in a row.
+type StdioClient struct {
+	command string
+	args    []string
+	env     []string
+
+	log       stdioLogger
+	logFields []any
+
+	// handleRequest answers the requests the server sends
+	handleRequest requestHandler
+	// handleNotification handles the notifications the server sends
+	handleNotification notificationHandler
+
+	// onClose is called once the client is closed
+	onClose func()
+
+	requestID atomic.Int64
+
+	mu          sync.Mutex
+	process     *stdioProcess
+	initRequest *mcp.InitializeRequest
+	restarts    int
+	stopErr     error
+	closed      bool
+	// changed is closed and replaced when the process becomes ready or the client stops
+	changed chan struct{}
+}
+
+// stdioLogger is the part of the plugin's logger the output and restarts of stdio servers are logged to
+type stdioLogger interface {
+	Debug(message string, keyValuePairs ...any)
+	Warn(message string, keyValuePairs ...any)
+}
+
+// stdioProcess is a running server process.
+type stdioProcess struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	startedAt time.Time
+
+	handleRequest      requestHandler
+	handleNotification notificationHandler
+
+	writeMu sync.Mutex
+
+	pendingMu sync.Mutex
+	pending   map[int64]chan rpcMessage
+
+	// exited is closed once the process has exited
+	exited chan struct{}
+}
+
+// NewStdioClient creates a client for the server launched with the command and arguments. The server
+// gets the variables of env on top of a minimal environment. What it writes to its standard error and its
+// restarts are logged with the fields.
+func NewStdioClient(command string, args []string, env map[string]string, log stdioLogger, logFields ...any) *StdioClient {
+	processEnv := []string{}
+	for _, name := range inheritedEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			processEnv = append(processEnv, name+"="+value)
+		}
+	}
+	for name, value := range env {
+		processEnv = append(processEnv, name+"="+value)
+	}
+
+	return &StdioClient{
+		command:   command,
+		args:      args,
+		env:       processEnv,
+		log:       log,
+		logFields: logFields,
+		changed:   make(chan struct{}),
+	}
+}
+
+// Initialize launches the server and starts a session with it. The client keeps the request to initialize
+// the server again when it is restarted.
+func (c *StdioClient) Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error) {
+	if request.Params.ProtocolVersion == "" {
+		request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	}
+
+	c.mu.Lock()
+	c.initRequest = &request
+	c.mu.Unlock()
+
+	return c.start(ctx)
+}
+
+// ListTools returns the tools of the server, requesting every page of them.
+func (c *StdioClient) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	return listAllTools(ctx, c.call, request)
+}
+
+// CallTool calls a tool of the server.
+func (c *StdioClient) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return callTool(ctx, c.call, request)
+}
+
+// ListPrompts returns the prompts of the server, requesting every page of them.
+func (c *StdioClient) ListPrompts(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	return listAllPrompts(ctx, c.call, request)
+}
+
+// GetPrompt gets a prompt of the server, filled in with the arguments.
+func (c *StdioClient) GetPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return getPrompt(ctx, c.call, request)
+}
+
+// Close stops the server, killing it if it doesn't exit once its input is closed.
+func (c *StdioClient) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	process := c.process
+	c.process = nil
+	c.notifyChanged()
+	c.mu.Unlock()
+
+	if c.onClose != nil {
+		c.onClose()
+	}
+
+	if process == nil {
+		return nil
+	}
+	return process.stop()
+}
+
+// start launches the server and initializes it, making it the process requests are sent to.
+func (c *StdioClient) start(ctx context.Context) (*mcp.InitializeResult, error) {
+	c.mu.Lock()
+	request := c.initRequest
+	c.mu.Unlock()
+
+	process, err := c.launch()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := process.initialize(ctx, c.requestID.Add(1), *request)
+	if err != nil {
+		_ = process.stop()
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		_ = process.stop()
+		return nil, errStdioClientClosed
+	}
+	c.process = process
+	c.notifyChanged()
+
+	go c.supervise(process)
+
+	return result, nil
+}
+
+// launch starts the server process and the goroutines reading its output.
+func (c *StdioClient) launch() (*stdioProcess, error) {
+	cmd := exec.Command(c.command, c.args...)
+	cmd.Env = c.env
+	setProcessGroup(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start MCP server: %w", err)
+	}
+
+	process := &stdioProcess{
+		cmd:                cmd,
+		stdin:              stdin,
+		startedAt:          time.Now(),
+		handleRequest:      c.handleRequest,
+		handleNotification: c.handleNotification,
+		pending:            make(map[int64]chan rpcMessage),
+		exited:             make(chan struct{}),
+	}
+
+	var output sync.WaitGroup
+	output.Add(2)
+	go func() {
+		defer output.Done()
+		process.readResponses(stdout)
+	}()
+	go func() {
+		defer output.Done()
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			c.log.Debug("MCP server output", append([]any{"line", scanner.Text()}, c.logFields...)...)
+		}
+	}()
+	go func() {
+		// The pipes must be read to the end before waiting for the process
+		output.Wait()
+		_ = cmd.Wait()
+		close(process.exited)
+	}()
+
+	return process, nil
+}
+
+// supervise restarts the server when the process exits while it is in use.
+func (c *StdioClient) supervise(process *stdioProcess) {
+	<-process.exited
+
+	c.mu.Lock()
+	if c.closed || c.process != process {
+		c.mu.Unlock()
+		return
+	}
+	c.process = nil
+	if time.Since(process.startedAt) >= stdioStableTime {
+		c.restarts = 0
+	}
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return
+		}
+		c.restarts++
+		restarts := c.restarts
+		if restarts > maxStdioRestarts {
+			c.stopErr = fmt.Errorf("%w: gave up after %d restarts", errStdioServerExited, maxStdioRestarts)
+			c.notifyChanged()
+			c.mu.Unlock()
+			c.log.Warn("MCP server keeps exiting, not restarting it", append([]any{"restarts", maxStdioRestarts}, c.logFields...)...)
+			return
+		}
+		c.mu.Unlock()
+
+		delay := min(stdioRestartDelay<<(restarts-1), maxStdioRestartDelay)
+		c.log.Warn("MCP server exited, restarting it", append([]any{"delay", delay.String()}, c.logFields...)...)
+		time.Sleep(delay)
+
+		ctx, cancel := context.WithTimeout(context.Background(), stdioInitializeTimeout)
+		_, err := c.start(ctx)
+		cancel()
+		if err == nil || errors.Is(err, errStdioClientClosed) {
+			return
+		}
+		c.log.Warn("Failed to restart MCP server", append([]any{"error", err.Error()}, c.logFields...)...)
+	}
+}
+
+// call sends a request to the server, waiting for it to be ready if it is being restarted.
+func (c *StdioClient) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	process, err := c.readyProcess(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return process.request(ctx, c.requestID.Add(1), method, params)
+}
+
+// readyProcess returns the process requests are sent to, once there is one.
+func (c *StdioClient) readyProcess(ctx context.Context) (*stdioProcess, error) {
+	for {
+		c.mu.Lock()
+		switch {
+		case c.closed:
+			c.mu.Unlock()
+			return nil, errStdioClientClosed
+		case c.stopErr != nil:
+			c.mu.Unlock()
+			return nil, c.stopErr
+		case c.initRequest == nil:
+			c.mu.Unlock()
+			return nil, errors.New("client not initialized")
+		case c.process != nil && !c.process.hasExited():
+			process := c.process
+			c.mu.Unlock()
+			return process, nil
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// notifyChanged wakes up the requests waiting for the process. The lock must be held.
+func (c *StdioClient) notifyChanged() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// stdioProcessLimit bounds how many processes of each stdio server run at once, as one is launched for
+// each user of a server that isn't shared.
+type stdioProcessLimit struct {
+	mu      sync.Mutex
+	max     int
+	running map[string]int
+}
+
+func newStdioProcessLimit(maxProcesses int) *stdioProcessLimit {
+	return &stdioProcessLimit{
+		max:     maxProcesses,
+		running: make(map[string]int),
+	}
+}
+
+// acquire reserves a process of the server, failing when it runs the most processes already.
+func (l *stdioProcessLimit) acquire(serverID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running[serverID] >= l.max {
+		return fmt.Errorf("%w: %d processes", ErrStdioProcessLimit, l.max)
+	}
+	l.running[serverID]++
+	return nil
+}
+
+// release frees a process of the server reserved with acquire.
+func (l *stdioProcessLimit) release(serverID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running[serverID]--
+	if l.running[serverID] <= 0 {
+		delete(l.running, serverID)
+	}
+}
+
+// initialize starts a session with the process.
+func (p *stdioProcess) initialize(ctx context.Context, id int64, request mcp.InitializeRequest) (*mcp.InitializeResult, error) {
+	response, err := p.request(ctx, id, "initialize", initializeParams(request))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize MCP server: %w", err)
+	}
+
+	var result mcp.InitializeResult
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal initialize result: %w", err)
+	}
+
+	if err := p.write(mcp.JSONRPCNotification{
+		JSONRPC:      mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{Method: "notifications/initialized"},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to send initialized notification: %w", err)
+	}
+
+	return &result, nil
+}
+
+// request sends a request to the process and waits for its response.
+func (p *stdioProcess) request(ctx context.Context, id int64, method string, params any) (json.RawMessage, error) {
+	responseChan := make(chan rpcMessage, 1)
+	p.pendingMu.Lock()
+	p.pending[id] = responseChan
+	p.pendingMu.Unlock()
+	defer func() {
+		p.pendingMu.Lock()
+		delete(p.pending, id)
+		p.pendingMu.Unlock()
+	}()
+
+	if err := p.write(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      id,
+		Request: mcp.Request{Method: method},
+		Params:  params,
+	}); err != nil {
+		if p.hasExited() {
+			return nil, errStdioServerExited
+		}
+		return nil, err
+	}
+
+	select {
+	case message := <-responseChan:
+		return responseResult(message, id)
+	case <-p.exited:
+		return nil, errStdioServerExited
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// hasExited returns whether the process has exited, before it is restarted.
+func (p *stdioProcess) hasExited() bool {
+	select {
+	case <-p.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// write sends a message to the process, on a line of its own.
+func (p *stdioProcess) write(message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	if _, err := p.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write to MCP server: %w", err)
+	}
+	return nil
+}
+
+// readResponses passes the responses the process writes to the requests waiting for them, answers the
+// requests it sends and handles its notifications, until its output is closed.
+func (p *stdioProcess) readResponses(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var message rpcMessage
+			if request, ok := parseServerRequest(line); ok {
+				go p.answer(request)
+			} else if method, ok := parseServerNotification(line); ok {
+				if p.handleNotification != nil {
+					p.handleNotification(method)
+				}
+			} else if jsonErr := json.Unmarshal(line, &message); jsonErr == nil && message.ID != nil && message.Method == "" {
+				p.pendingMu.Lock()
+				responseChan, ok := p.pending[*message.ID]
+				p.pendingMu.Unlock()
+				if ok {
+					select {
+					case responseChan <- message:
+					default:
+					}
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// answer writes the response to a request of the process. Answering is given up when the process exits.
+func (p *stdioProcess) answer(request serverRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), stdioServerRequestTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-p.exited:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	_ = p.write(answerServerRequest(ctx, p.handleRequest, request))
+}
+
+// stop closes the input of the process so it exits, and kills it along with the processes it launched if
+// it doesn't in time.
+func (p *stdioProcess) stop() error {
+	_ = p.stdin.Close()
+
+	select {
+	case <-p.exited:
+		return nil
+	case <-time.After(stdioStopTimeout):
+	}
+
+	if err := killProcessGroup(p.cmd); err != nil {
+		return fmt.Errorf("failed to kill MCP server: %w", err)
+	}
+	<-p.exited
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStdioServerEnv = "MCP_TEST_STDIO_SERVER"
+
+type discardLogger struct{}
+
+func (discardLogger) Debug(string, ...any) {}
+func (discardLogger) Warn(string, ...any)  {}
+
+// TestStdioServerProcess is the MCP server the tests launch, running the test binary again.
+func TestStdioServerProcess(t *testing.T) {
+	if os.Getenv(testStdioServerEnv) != "1" {
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var request struct {
+			ID     *int64 `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Name string `json:"name"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil || request.ID == nil {
+			continue
+		}
+
+		var result any
+		switch request.Method {
+		case "initialize":
+			result = map[string]any{"protocolVersion": mcp.LATEST_PROTOCOL_VERSION, "serverInfo": map[string]string{"name": "stdio"}}
+		case "tools/list":
+			result = map[string]any{"tools": []map[string]any{{"name": "env", "inputSchema": map[string]string{"type": "object"}}}}
+		case "tools/call":
+			if request.Params.Name == "crash" {
+				os.Exit(1)
+			}
+			text := fmt.Sprintf("%s %s", os.Getenv(MMUserIDEnv), os.Getenv("MM_SQLSETTINGS_DATASOURCE"))
+			result = map[string]any{"content": []map[string]string{{"type": "text", "text": text}}}
+		}
+		response, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": *request.ID, "result": result})
+		fmt.Println(string(response))
+	}
+	os.Exit(0)
+}
+
+func callStdioTool(client *StdioClient, name string) (string, error) {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	result, err := client.CallTool(context.Background(), request)
+	if err != nil {
+		return "", err
+	}
+	text, _ := mcp.AsTextContent(result.Content[0])
+	return text.Text, nil
+}
+
+func TestStdioClient(t *testing.T) {
+	t.Setenv("MM_SQLSETTINGS_DATASOURCE", "secret")
+
+	client := NewStdioClient(os.Args[0], []string{"-test.run=TestStdioServerProcess"}, map[string]string{
+		testStdioServerEnv: "1",
+		MMUserIDEnv:        "userid",
+	}, discardLogger{})
+	defer client.Close()
+
+	result, err := client.Initialize(context.Background(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "stdio", result.ServerInfo.Name)
+
+	tools, err := client.ListTools(context.Background(), mcp.ListToolsRequest{})
+	require.NoError(t, err)
+	require.Len(t, tools.Tools, 1)
+
+	t.Run("passes only the configured environment", func(t *testing.T) {
+		text, err := callStdioTool(client, "env")
+		require.NoError(t, err)
+		assert.Equal(t, "userid ", text)
+	})
+
+	t.Run("restarts the server when it crashes", func(t *testing.T) {
+		_, err := callStdioTool(client, "crash")
+		assert.ErrorIs(t, err, errStdioServerExited)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err = client.readyProcess(ctx)
+		require.NoError(t, err)
+
+		text, err := callStdioTool(client, "env")
+		require.NoError(t, err)
+		assert.Equal(t, "userid ", text)
+	})
+
+	t.Run("fails requests once closed", func(t *testing.T) {
+		require.NoError(t, client.Close())
+		_, err := callStdioTool(client, "env")
+		assert.ErrorIs(t, err, errStdioClientClosed)
+	})
+}
+
+func TestStdioProcessLimit(t *testing.T) {
+	limit := newStdioProcessLimit(1)
+
+	require.NoError(t, limit.acquire("server"))
+	assert.ErrorIs(t, limit.acquire("server"), ErrStdioProcessLimit)
+	assert.NoError(t, limit.acquire("other"), "the limit applies to each server")
+
+	limit.release("server")
+	assert.NoError(t, limit.acquire("server"))
+}
+
+func TestConnectStdioProcessLimit(t *testing.T) {
+	client := &UserClient{userID: "userid", stdioLimit: newStdioProcessLimit(1)}
+	serverConfig := ServerConfig{
+		Transport: TransportStdio,
+		Command:   os.Args[0],
+		Args:      []string{"-test.run=TestStdioServerProcess"},
+		Env:       map[string]string{testStdioServerEnv: "1"},
+	}
+
+	first, _, err := client.connectStdio(context.Background(), "server", serverConfig, serverHandlers{})
+	require.NoError(t, err)
+
+	_, _, err = client.connectStdio(context.Background(), "server", serverConfig, serverHandlers{})
+	assert.ErrorIs(t, err, ErrStdioProcessLimit)
+
+	// Closing the client frees its process for another user
+	require.NoError(t, first.Close())
+	second, _, err := client.connectStdio(context.Background(), "server", serverConfig, serverHandlers{})
+	require.NoError(t, err)
+	require.NoError(t, second.Close())
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+//go:build windows
+
+package mcp
+
+import "os/exec"
+
+// setProcessGroup does nothing on Windows, where process groups don't separate the server's processes.
+func setProcessGroup(_ *exec.Cmd) {}
+
+// killProcessGroup kills the server. The processes it launched are left running on Windows.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,596 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// StreamableHTTPProtocolVersion is the first revision of the protocol with the streamable HTTP transport
+const StreamableHTTPProtocolVersion = "2025-03-26"
+
+const (
+	sessionIDHeader       = "Mcp-Session-Id"
+	protocolVersionHeader = "Mcp-Protocol-Version"
+	lastEventIDHeader     = "Last-Event-ID"
+
+	// maxResumeAttempts limits how many times a dropped stream is resumed before the request fails
+	maxResumeAttempts = 3
+
+	// listenRetryDelay is the delay before opening the stream of server messages again once it ends,
+	// doubled each time it can't be opened
+	listenRetryDelay    = 5 * time.Second
+	maxListenRetryDelay = 5 * time.Minute
+
+	// streamableHTTPRequestTimeout limits requests sent without a deadline of their own. The client has no
+	// timeout, since the streams of responses and of server messages stay open as long as they're needed.
+	streamableHTTPRequestTimeout = 5 * time.Minute
+
+	// streamableHTTPMessageTimeout limits the messages the server accepts without a response, such as
+	// notifications, and ending the session
+	streamableHTTPMessageTimeout = 30 * time.Second
+)
+
+var (
+	// errStreamableHTTPNotSupported is returned when the server rejects the initialization, as servers
+	// only exposing the SSE transport do.
+	errStreamableHTTPNotSupported = errors.New("server does not support the streamable HTTP transport")
+
+	// errSessionExpired is returned when the server no longer knows the session of the client.
+	errSessionExpired = errors.New("MCP session expired")
+
+	// errListenNotSupported is returned when the server doesn't offer a stream of messages outside of
+	// the responses to requests.
+	errListenNotSupported = errors.New("server does not offer a stream of messages")
+)
+
+// StreamableHTTPClient is an MCP client using the streamable HTTP transport. Every message is posted to a
+// single endpoint, which answers with either a JSON response or an SSE stream. The session the server
+// assigns is kept across requests and started again if it expires, and dropped streams are resumed from
+// the last event received. Clients handling notifications also listen to the stream the server sends
+// messages in outside of responses.
+type StreamableHTTPClient struct {
+	url        string
+	headers    map[string]string
+	httpClient *http.Client
+	// requestTimeout limits the requests sent without a deadline
+	requestTimeout time.Duration
+
+	// handleRequest answers the requests the server sends in the streams of responses
+	handleRequest requestHandler
+	// handleNotification handles the notifications the server sends
+	handleNotification notificationHandler
+	// stopListening stops listening to the server once the client is closed
+	stopListening context.CancelFunc
+
+	requestID atomic.Int64
+
+	mu              sync.RWMutex
+	sessionID       string
+	protocolVersion string
+	initRequest     *mcp.InitializeRequest
+}
+
+// NewStreamableHTTPClient creates a client for the MCP endpoint at the URL, sending the headers with
+// every request.
+func NewStreamableHTTPClient(url string, headers map[string]string) *StreamableHTTPClient {
+	return &StreamableHTTPClient{
+		url:            url,
+		headers:        headers,
+		httpClient:     &http.Client{},
+		requestTimeout: streamableHTTPRequestTimeout,
+	}
+}
+
+// withDefaultTimeout limits the context to the timeout, unless it already has a deadline.
+func withDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// sseEvent is an event of an SSE stream.
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// Initialize starts a session with the server. The client keeps the request to start a new session if
+// the server ends this one.
+func (c *StreamableHTTPClient) Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error) {
+	if request.Params.ProtocolVersion == "" {
+		request.Params.ProtocolVersion = StreamableHTTPProtocolVersion
+	}
+
+	c.mu.Lock()
+	c.initRequest = &request
+	c.mu.Unlock()
+
+	result, err := c.initialize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.handleNotification != nil && c.stopListening == nil {
+		var listenCtx context.Context
+		listenCtx, c.stopListening = context.WithCancel(context.Background())
+		go c.listen(listenCtx)
+	}
+	c.mu.Unlock()
+
+	return result, nil
+}
+
+// initialize starts a new session, dropping the current one.
+func (c *StreamableHTTPClient) initialize(ctx context.Context) (*mcp.InitializeResult, error) {
+	c.mu.Lock()
+	request := c.initRequest
+	c.sessionID = ""
+	c.protocolVersion = ""
+	c.mu.Unlock()
+
+	response, err := c.sendRequest(ctx, "initialize", initializeParams(*request))
+	if err != nil {
+		return nil, err
+	}
+
+	var result mcp.InitializeResult
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal initialize result: %w", err)
+	}
+
+	c.mu.Lock()
+	c.protocolVersion = result.ProtocolVersion
+	c.mu.Unlock()
+
+	if err := c.sendNotification(ctx, "notifications/initialized"); err != nil {
+		return nil, fmt.Errorf("failed to send initialized notification: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ListTools returns the tools of the server, requesting every page of them.
+func (c *StreamableHTTPClient) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	return listAllTools(ctx, c.call, request)
+}
+
+// CallTool calls a tool of the server.
+func (c *StreamableHTTPClient) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return callTool(ctx, c.call, request)
+}
+
+// ListPrompts returns the prompts of the server, requesting every page of them.
+func (c *StreamableHTTPClient) ListPrompts(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	return listAllPrompts(ctx, c.call, request)
+}
+
+// GetPrompt gets a prompt of the server, filled in with the arguments.
+func (c *StreamableHTTPClient) GetPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return getPrompt(ctx, c.call, request)
+}
+
+// Close ends the session, so the server can release it. Servers may not allow clients to end sessions,
+// in which case they expire on their own.
+func (c *StreamableHTTPClient) Close() error {
+	c.mu.Lock()
+	sessionID := c.sessionID
+	c.sessionID = ""
+	if c.stopListening != nil {
+		c.stopListening()
+	}
+	c.mu.Unlock()
+
+	if sessionID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), streamableHTTPMessageTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(req, sessionID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to end session: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest && resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to end session: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// call sends a request in the current session, starting a new session once if the server ended it.
+func (c *StreamableHTTPClient) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	c.mu.RLock()
+	initialized := c.initRequest != nil
+	c.mu.RUnlock()
+	if !initialized {
+		return nil, errors.New("client not initialized")
+	}
+
+	response, err := c.sendRequest(ctx, method, params)
+	if !errors.Is(err, errSessionExpired) {
+		return response, err
+	}
+
+	if _, err := c.initialize(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start a new session: %w", err)
+	}
+	return c.sendRequest(ctx, method, params)
+}
+
+// sendRequest posts a request and waits for its response, which the server sends as JSON or in an SSE
+// stream.
+func (c *StreamableHTTPClient) sendRequest(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	ctx, cancel := withDefaultTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	id := c.requestID.Add(1)
+	request := mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      id,
+		Request: mcp.Request{Method: method},
+		Params:  params,
+	}
+
+	resp, sessionID, err := c.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && sessionID != "" {
+		return nil, errSessionExpired
+	}
+	if resp.StatusCode >= http.StatusBadRequest && resp.StatusCode < http.StatusInternalServerError && method == "initialize" {
+		return nil, fmt.Errorf("%w: status %d", errStreamableHTTPNotSupported, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	if newSessionID := resp.Header.Get(sessionIDHeader); newSessionID != "" && method == "initialize" {
+		c.mu.Lock()
+		c.sessionID = newSessionID
+		c.mu.Unlock()
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		var message rpcMessage
+		if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return responseResult(message, id)
+	case "text/event-stream":
+		return c.readResponseStream(ctx, resp.Body, id)
+	default:
+		return nil, fmt.Errorf("unexpected response content type %q", mediaType)
+	}
+}
+
+// sendNotification posts a notification, which the server accepts without a response.
+func (c *StreamableHTTPClient) sendNotification(ctx context.Context, method string) error {
+	notification := mcp.JSONRPCNotification{
+		JSONRPC:      mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{Method: method},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, streamableHTTPMessageTimeout)
+	defer cancel()
+
+	resp, _, err := c.post(ctx, notification)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("notification failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// post sends a message to the endpoint in the current session, returning the session it was sent in.
+func (c *StreamableHTTPClient) post(ctx context.Context, message any) (*http.Response, string, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.mu.RLock()
+	sessionID := c.sessionID
+	c.mu.RUnlock()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	c.setHeaders(req, sessionID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to send request: %w", err)
+	}
+	return resp, sessionID, nil
+}
+
+// readResponseStream reads an SSE stream until the response to the request, answering the requests the
+// server sends before it. When the stream drops before, it is resumed from the last event the server
+// identified.
+func (c *StreamableHTTPClient) readResponseStream(ctx context.Context, body io.ReadCloser, id int64) (json.RawMessage, error) {
+	// Resumed streams can replay requests that were already answered
+	answered := map[string]bool{}
+	onRequest := func(request serverRequest) {
+		if answered[string(request.ID)] {
+			return
+		}
+		answered[string(request.ID)] = true
+		if err := c.answer(ctx, request); err != nil && ctx.Err() == nil {
+			answered[string(request.ID)] = false
+		}
+	}
+
+	lastEventID := ""
+	for attempt := 0; ; attempt++ {
+		message, eventID, err := readStreamResponse(body, id, onRequest, c.notify)
+		body.Close()
+		if eventID != "" {
+			lastEventID = eventID
+		}
+		if err == nil {
+			return responseResult(*message, id)
+		}
+		if lastEventID == "" || attempt >= maxResumeAttempts || ctx.Err() != nil {
+			return nil, fmt.Errorf("response stream ended before the response: %w", err)
+		}
+
+		body, err = c.resumeStream(ctx, lastEventID)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// answer posts the response to a request of the server, which accepts it without a response of its own.
+func (c *StreamableHTTPClient) answer(ctx context.Context, request serverRequest) error {
+	response := answerServerRequest(ctx, c.handleRequest, request)
+
+	ctx, cancel := context.WithTimeout(ctx, streamableHTTPMessageTimeout)
+	defer cancel()
+
+	resp, _, err := c.post(ctx, response)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response to server request failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// notify passes a notification of the server to the handler, if any.
+func (c *StreamableHTTPClient) notify(method string) {
+	if c.handleNotification != nil {
+		c.handleNotification(method)
+	}
+}
+
+// listen reads the stream the server sends messages in outside of responses, opening it again when it
+// ends, until the client is closed. Servers that don't offer the stream aren't asked again.
+func (c *StreamableHTTPClient) listen(ctx context.Context) {
+	delay := listenRetryDelay
+	for {
+		body, err := c.openListenStream(ctx)
+		if errors.Is(err, errListenNotSupported) {
+			return
+		}
+		if err == nil {
+			delay = listenRetryDelay
+			c.readListenStream(ctx, body)
+			body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if err != nil {
+			delay = min(delay*2, maxListenRetryDelay)
+		}
+	}
+}
+
+// openListenStream asks the server for the stream of messages sent outside of responses.
+func (c *StreamableHTTPClient) openListenStream(ctx context.Context) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.mu.RLock()
+	sessionID := c.sessionID
+	c.mu.RUnlock()
+
+	req.Header.Set("Accept", "text/event-stream")
+	c.setHeaders(req, sessionID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stream: %w", err)
+	}
+	if resp.StatusCode == http.StatusMethodNotAllowed {
+		resp.Body.Close()
+		return nil, errListenNotSupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to open stream: status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// readListenStream handles the notifications and answers the requests of the stream until it ends.
+func (c *StreamableHTTPClient) readListenStream(ctx context.Context, body io.Reader) {
+	reader := bufio.NewReader(body)
+	for {
+		event, err := readEvent(reader)
+		if event.data != "" && (event.event == "" || event.event == "message") {
+			if request, ok := parseServerRequest([]byte(event.data)); ok {
+				go func() {
+					_ = c.answer(ctx, request)
+				}()
+			} else if method, ok := parseServerNotification([]byte(event.data)); ok {
+				c.notify(method)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// resumeStream asks the server to replay the stream after the last event received.
+func (c *StreamableHTTPClient) resumeStream(ctx context.Context, lastEventID string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.mu.RLock()
+	sessionID := c.sessionID
+	c.mu.RUnlock()
+
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(lastEventIDHeader, lastEventID)
+	c.setHeaders(req, sessionID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume stream: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to resume stream: status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// setHeaders sets the session and the configured headers of a request.
+func (c *StreamableHTTPClient) setHeaders(req *http.Request, sessionID string) {
+	if sessionID != "" {
+		req.Header.Set(sessionIDHeader, sessionID)
+	}
+
+	c.mu.RLock()
+	protocolVersion := c.protocolVersion
+	c.mu.RUnlock()
+	if protocolVersion != "" {
+		req.Header.Set(protocolVersionHeader, protocolVersion)
+	}
+
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+}
+
+// readStreamResponse reads SSE events until the response to the request, passing the requests and the
+// notifications the server sends before it to onRequest and onNotification. It returns the ID of the last
+// event read, to resume the stream from if it ends first.
+func readStreamResponse(body io.Reader, id int64, onRequest func(serverRequest), onNotification func(method string)) (*rpcMessage, string, error) {
+	reader := bufio.NewReader(body)
+	lastEventID := ""
+	for {
+		event, err := readEvent(reader)
+		if event.id != "" {
+			lastEventID = event.id
+		}
+		if event.data != "" && (event.event == "" || event.event == "message") {
+			var message rpcMessage
+			if request, ok := parseServerRequest([]byte(event.data)); ok {
+				onRequest(request)
+			} else if method, ok := parseServerNotification([]byte(event.data)); ok {
+				onNotification(method)
+			} else if jsonErr := json.Unmarshal([]byte(event.data), &message); jsonErr == nil && message.ID != nil && *message.ID == id && message.Method == "" {
+				return &message, lastEventID, nil
+			}
+		}
+		if err != nil {
+			return nil, lastEventID, err
+		}
+	}
+}
+
+// readEvent reads the next event of an SSE stream. An event ended by the end of the stream is returned
+// with the error.
+func readEvent(reader *bufio.Reader) (sseEvent, error) {
+	var event sseEvent
+	var data []string
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if err == nil && len(data) == 0 && event.id == "" {
+				continue
+			}
+			event.data = strings.Join(data, "\n")
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return event, err
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.event = value
+		case "data":
+			data = append(data, value)
+		}
+
+		if err != nil {
+			event.data = strings.Join(data, "\n")
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return event, err
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer is a streamable HTTP MCP server answering with JSON, or with SSE streams dropped after the
+// first event when streaming is set.
+type testServer struct {
+	streaming bool
+
+	mu              sync.Mutex
+	sessions        int
+	validSession    string
+	endedSessions   []string
+	pendingResponse string
+	headers         []http.Header
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headers = append(s.headers, r.Header.Clone())
+
+	switch r.Method {
+	case http.MethodDelete:
+		s.endedSessions = append(s.endedSessions, r.Header.Get(sessionIDHeader))
+		return
+	case http.MethodGet:
+		if r.Header.Get(lastEventIDHeader) != "1" || s.pendingResponse == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "id: 2\nevent: message\ndata: %s\n\n", s.pendingResponse)
+		s.pendingResponse = ""
+		return
+	}
+
+	var request struct {
+		ID     *int64 `json:"id"`
+		Method string `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if request.Method == "initialize" {
+		s.sessions++
+		s.validSession = fmt.Sprintf("session-%d", s.sessions)
+		w.Header().Set(sessionIDHeader, s.validSession)
+	} else if r.Header.Get(sessionIDHeader) != s.validSession {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if request.ID == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	var result any
+	switch request.Method {
+	case "initialize":
+		result = map[string]any{"protocolVersion": StreamableHTTPProtocolVersion, "serverInfo": map[string]string{"name": "test"}}
+	case "tools/list":
+		result = map[string]any{"tools": []map[string]any{{"name": "search", "inputSchema": map[string]string{"type": "object"}}}}
+	case "tools/call":
+		result = map[string]any{"content": []map[string]string{{"type": "text", "text": "found"}}}
+	}
+	response, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": *request.ID, "result": result})
+
+	if !s.streaming || request.Method == "initialize" {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(response)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprintf(w, "id: 1\nevent: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+	s.pendingResponse = string(response)
+}
+
+func callSearch(t *testing.T, client *StreamableHTTPClient) string {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "search"
+	result, err := client.CallTool(context.Background(), request)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	text, ok := mcp.AsTextContent(result.Content[0])
+	require.True(t, ok)
+	return text.Text
+}
+
+func TestStreamableHTTPClient(t *testing.T) {
+	t.Run("keeps the session across requests", func(t *testing.T) {
+		server := &testServer{}
+		httpServer := httptest.NewServer(server)
+		defer httpServer.Close()
+
+		client := NewStreamableHTTPClient(httpServer.URL, map[string]string{MMUserIDHeader: "userid"})
+		result, err := client.Initialize(context.Background(), mcp.InitializeRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "test", result.ServerInfo.Name)
+
+		tools, err := client.ListTools(context.Background(), mcp.ListToolsRequest{})
+		require.NoError(t, err)
+		require.Len(t, tools.Tools, 1)
+		assert.Equal(t, "search", tools.Tools[0].Name)
+
+		assert.Equal(t, "found", callSearch(t, client))
+
+		require.NoError(t, client.Close())
+		assert.Equal(t, []string{"session-1"}, server.endedSessions)
+		for _, header := range server.headers {
+			assert.Equal(t, "userid", header.Get(MMUserIDHeader))
+		}
+		assert.Equal(t, StreamableHTTPProtocolVersion, server.headers[len(server.headers)-1].Get(protocolVersionHeader))
+	})
+
+	t.Run("starts a new session when the session expired", func(t *testing.T) {
+		server := &testServer{}
+		httpServer := httptest.NewServer(server)
+		defer httpServer.Close()
+
+		client := NewStreamableHTTPClient(httpServer.URL, nil)
+		_, err := client.Initialize(context.Background(), mcp.InitializeRequest{})
+		require.NoError(t, err)
+
+		server.validSession = "restarted"
+		assert.Equal(t, "found", callSearch(t, client))
+		assert.Equal(t, 2, server.sessions)
+	})
+
+	t.Run("resumes dropped streams", func(t *testing.T) {
+		server := &testServer{streaming: true}
+		httpServer := httptest.NewServer(server)
+		defer httpServer.Close()
+
+		client := NewStreamableHTTPClient(httpServer.URL, nil)
+		_, err := client.Initialize(context.Background(), mcp.InitializeRequest{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "found", callSearch(t, client))
+		assert.Empty(t, server.pendingResponse)
+	})
+
+	t.Run("reports servers without the transport", func(t *testing.T) {
+		httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}))
+		defer httpServer.Close()
+
+		client := NewStreamableHTTPClient(httpServer.URL, nil)
+		_, err := client.Initialize(context.Background(), mcp.InitializeRequest{})
+		assert.ErrorIs(t, err, errStreamableHTTPNotSupported)
+	})
+
+	t.Run("gives up on servers that don't answer", func(t *testing.T) {
+		stop := make(chan struct{})
+		httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-stop
+		}))
+		defer httpServer.Close()
+		defer close(stop)
+
+		client := NewStreamableHTTPClient(httpServer.URL, nil)
+		client.requestTimeout = 100 * time.Millisecond
+		_, err := client.Initialize(context.Background(), mcp.InitializeRequest{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"strings"
+)
+
+// ToolNameSeparator separates the server key from the name of the tool in the names of MCP tools,
+// so tools of the same name on different servers don't collide.
+const ToolNameSeparator = "__"
+
+// ToolName returns the name the LLM knows a tool of a server by. The server key is reduced to the
+// characters model providers accept in tool names.
+func ToolName(serverID, toolName string) string {
+	serverKey := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '_'
+	}, serverID)
+	return serverKey + ToolNameSeparator + toolName
+}
+
+// SplitToolName returns the server key and the name of the tool of an MCP tool name. ok is false for
+// names without a server key, such as the names of built-in tools.
+func SplitToolName(name string) (serverKey, toolName string, ok bool) {
+	serverKey, toolName, ok = strings.Cut(name, ToolNameSeparator)
+	if !ok || serverKey == "" || toolName == "" {
+		return "", name, false
+	}
+	return serverKey, toolName, true
+}
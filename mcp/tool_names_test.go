@@ -0,0 +1,24 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolName(t *testing.T) {
+	assert.Equal(t, "github__search", ToolName("github", "search"))
+	assert.Equal(t, "my_wiki-2__search", ToolName("my wiki-2", "search"))
+
+	serverKey, toolName, ok := SplitToolName(ToolName("github", "search_issues"))
+	assert.True(t, ok)
+	assert.Equal(t, "github", serverKey)
+	assert.Equal(t, "search_issues", toolName)
+
+	_, toolName, ok = SplitToolName("SearchServer")
+	assert.False(t, ok)
+	assert.Equal(t, "SearchServer", toolName)
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// toolsRefreshTimeout limits how long listing the tools of a server that changed them takes
+const toolsRefreshTimeout = 30 * time.Second
+
+// toolList returns the tools of the server
+func (s *ServerConnection) toolList() []mcp.Tool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tools := make([]mcp.Tool, 0, len(s.tools))
+	for _, tool := range s.tools {
+		tools = append(tools, tool)
+	}
+	return tools
+}
+
+// setTools replaces the tools of the server
+func (s *ServerConnection) setTools(tools []mcp.Tool) {
+	toolsByName := make(map[string]mcp.Tool, len(tools))
+	for _, tool := range tools {
+		toolsByName[tool.Name] = tool
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tools = toolsByName
+}
+
+// refreshTools lists the tools of the server again. Connections still starting are skipped, their tools
+// are listed once they are connected.
+func (s *ServerConnection) refreshTools(ctx context.Context) error {
+	client, _ := s.current()
+	if client == nil {
+		return nil
+	}
+
+	result, err := client.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to list tools: %w", err)
+	}
+	s.setTools(result.Tools)
+	return nil
+}
+
+// toolsChangedHandler refreshes the tools of the connection when the server tells they changed, so the
+// tools it adds are offered right away rather than once the user connects again.
+func (c *UserClient) toolsChangedHandler(serverClient *ServerConnection) notificationHandler {
+	return func(method string) {
+		if method != toolsListChangedNotification {
+			return
+		}
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), toolsRefreshTimeout)
+			defer cancel()
+
+			if err := serverClient.refreshTools(ctx); err != nil {
+				c.log.Warn("Failed to refresh MCP tools", "userID", c.userID, "serverID", serverClient.serverID, "error", err)
+				return
+			}
+			c.log.Debug("Refreshed MCP tools", "userID", c.userID, "serverID", serverClient.serverID)
+		}()
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listingClient is an MCP client only listing tools
+type listingClient struct {
+	mcpClient
+	tools []mcp.Tool
+}
+
+func (c *listingClient) ListTools(context.Context, mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	return &mcp.ListToolsResult{Tools: c.tools}, nil
+}
+
+func TestParseServerNotification(t *testing.T) {
+	method, ok := parseServerNotification([]byte(`{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`))
+	assert.True(t, ok)
+	assert.Equal(t, toolsListChangedNotification, method)
+
+	_, ok = parseServerNotification([]byte(`{"jsonrpc":"2.0","id":1,"method":"sampling/createMessage"}`))
+	assert.False(t, ok)
+	_, ok = parseServerNotification([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	assert.False(t, ok)
+}
+
+func TestServerConnectionRefreshTools(t *testing.T) {
+	client := &listingClient{tools: []mcp.Tool{{Name: "search"}}}
+	connection := &ServerConnection{serverID: "server", client: client}
+	connection.setTools(client.tools)
+
+	client.tools = []mcp.Tool{{Name: "search"}, {Name: "create_issue"}}
+	require.NoError(t, connection.refreshTools(context.Background()))
+	assert.ElementsMatch(t, client.tools, connection.toolList())
+
+	t.Run("skips connections still starting", func(t *testing.T) {
+		assert.NoError(t, (&ServerConnection{serverID: "server"}).refreshTools(context.Background()))
+	})
+}
+
+// TestStreamableHTTPClientListen has the server tell its tools changed in the stream it sends messages in
+// outside of responses.
+func TestStreamableHTTPClientListen(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(`data: {"jsonrpc":"2.0","method":"notifications/tools/list_changed"}` + "\n\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+
+		var message struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if message.Method == "initialize" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set(sessionIDHeader, "session")
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(message.ID) + `,"result":{"protocolVersion":"2025-03-26","serverInfo":{"name":"test"}}}`))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer httpServer.Close()
+
+	notifications := make(chan string, 1)
+	client := NewStreamableHTTPClient(httpServer.URL, nil)
+	client.handleNotification = func(method string) {
+		notifications <- method
+	}
+	_, err := client.Initialize(context.Background(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+	defer client.Close()
+
+	select {
+	case method := <-notifications:
+		assert.Equal(t, toolsListChangedNotification, method)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the notification of the server wasn't received")
+	}
+}
@@ -6,8 +6,10 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"maps"
+	"sync"
 	"time"
 
 	"github.com/invopop/jsonschema"
@@ -20,20 +22,74 @@ import (
 
 const MMUserIDHeader = "X-Mattermost-UserID"
 
+// Transports used to connect to MCP servers
+const (
+	// TransportAuto tries the streamable HTTP transport, and falls back to SSE for servers that only expose it
+	TransportAuto           = ""
+	TransportStreamableHTTP = "streamable_http"
+	TransportSSE            = "sse"
+	// TransportStdio launches the server with its command, and talks to it over its standard input and output
+	TransportStdio = "stdio"
+)
+
+// mcpClient is an MCP client, whatever transport it uses
+type mcpClient interface {
+	Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error)
+	ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error)
+	CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
+	ListPrompts(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error)
+	GetPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error)
+	Close() error
+}
+
 // ServerConnection represents the connection to a single MCP server
 type ServerConnection struct {
-	client   *client.SSEMCPClient
+	// mu guards the client and tool calls, replaced when reconnecting, and the tools, refreshed when the
+	// server tells they changed
+	mu        sync.Mutex
+	client    mcpClient
+	toolCalls *activeToolCalls
+	tools     map[string]mcp.Tool
+	// connect makes a new client for the connection, with the same settings
+	connect func(ctx context.Context) (mcpClient, *activeToolCalls, error)
+
 	serverID string
-	tools    map[string]mcp.Tool
+	prompts  []mcp.Prompt
+	// toolTimeout is how long the tools of the server can run, the default of tools when zero
+	toolTimeout time.Duration
+	// shared connections are used by all users, and closed by the client manager
+	shared bool
 }
 
 // ServerConfig contains the configuration for a single MCP server
 type ServerConfig struct {
-	BaseURL string            `json:"baseURL"`
-	Headers map[string]string `json:"headers,omitempty"`
+	BaseURL   string            `json:"baseURL"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Transport string            `json:"transport,omitempty"`
+
+	// Command, Args and Env launch the server when it uses the stdio transport
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+
+	// AllowSampling lets the server ask the bot running one of its tools for completions, which the user
+	// approves. It isn't supported with the SSE transport, nor for shared servers.
+	AllowSampling bool `json:"allowSampling,omitempty"`
+
+	// Roots are the URIs of the directories or repositories the server is scoped to, offered to it as MCP
+	// roots. They can hold the {user_id} and {channel_id} variables. It isn't supported with the SSE transport.
+	Roots []string `json:"roots,omitempty"`
+
+	// Shared servers don't need the identity of the user. A single connection to them is used for all
+	// users, and the user ID header or environment variable isn't sent.
+	Shared bool `json:"shared,omitempty"`
+
+	// ToolTimeoutSeconds is how long the tools of the server can run before they are cancelled, the
+	// default of tools when zero.
+	ToolTimeoutSeconds int `json:"toolTimeoutSeconds,omitempty"`
 }
 
-// ToolDefinition represents a tool provided by an MCP server
+// ToolDefinition represents a tool provided by an MCP server, with the name the server knows it by
 type ToolDefinition struct {
 	tool     mcp.Tool
 	serverID string
@@ -42,9 +98,14 @@ type ToolDefinition struct {
 // UserClient represents a per-user MCP client with multiple server connections
 type UserClient struct {
 	clients      map[string]*ServerConnection
-	toolDefs     map[string]ToolDefinition
 	lastActivity time.Time
 	userID       string
+	health       *healthTracker
+	auditor      ToolCallRecorder
+	sampler      Sampler
+	secrets      SecretResolver
+	shared       *sharedConnections
+	stdioLimit   *stdioProcessLimit
 	log          pluginapi.LogService
 }
 
@@ -57,7 +118,12 @@ func (c *UserClient) ConnectToAllServers(servers map[string]ServerConfig) error
 
 	// Initialize clients for each server
 	for serverID, serverConfig := range servers {
-		if serverConfig.BaseURL == "" {
+		if serverConfig.Transport == TransportStdio {
+			if serverConfig.Command == "" {
+				c.log.Warn("Skipping stdio MCP server with empty command", "serverID", serverID)
+				continue
+			}
+		} else if serverConfig.BaseURL == "" {
 			c.log.Warn("Skipping MCP server with empty BaseURL", "serverID", serverID)
 			continue
 		}
@@ -80,84 +146,252 @@ func (c *UserClient) ConnectToAllServers(servers map[string]ServerConfig) error
 	return nil
 }
 
-// connectToServer establishes a connection to a single server and registers its tools
+// connectToServer establishes a connection to a single server, or uses the shared one, and registers its tools
 func (c *UserClient) connectToServer(ctx context.Context, serverID string, serverConfig ServerConfig) error {
-	var opts []client.ClientOption
-	headers := make(map[string]string)
-	headers[MMUserIDHeader] = c.userID
-	if serverConfig.Headers != nil {
-		maps.Copy(headers, serverConfig.Headers)
+	var serverClient *ServerConnection
+	var err error
+	if serverConfig.Shared && c.shared != nil {
+		serverClient, err = c.shared.get(ctx, serverID, serverConfig)
+	} else {
+		serverClient, err = c.newServerConnection(ctx, serverID, serverConfig)
 	}
-	opts = append(opts, client.WithHeaders(serverConfig.Headers))
-
-	sseClient, err := client.NewSSEMCPClient(serverConfig.BaseURL, opts...)
 	if err != nil {
-		return fmt.Errorf("failed to create MCP client: %w", err)
+		return err
 	}
+	existingTools := c.toolDefinitions()
+	c.clients[serverID] = serverClient
 
-	// Ensure client is closed on error
-	success := false
-	defer func() {
-		if !success {
-			sseClient.Close()
+	// The tools of this server are offered under names prefixed with the server key
+	for _, tool := range serverClient.toolList() {
+		name := ToolName(serverID, tool.Name)
+
+		// Server keys differing only by characters tool names can't hold still conflict
+		if existingTool, exists := existingTools[name]; exists {
+			c.log.Warn("Tool name conflict detected",
+				"userID", c.userID,
+				"tool", name,
+				"server1", existingTool.serverID,
+				"server2", serverID)
+			// For now, one of the servers wins for conflicts
 		}
+
+		c.log.Debug("Registered MCP tool",
+			"userID", c.userID,
+			"name", name,
+			"description", tool.Description,
+			"server", serverID)
+	}
+
+	return nil
+}
+
+// newServerConnection connects to a server and lists its tools and prompts
+func (c *UserClient) newServerConnection(ctx context.Context, serverID string, serverConfig ServerConfig) (_ *ServerConnection, err error) {
+	var toolCount int
+	var latency time.Duration
+	defer func() {
+		c.health.record(serverID, toolCount, latency, err)
 	}()
 
-	if startErr := sseClient.Start(ctx); startErr != nil {
-		return fmt.Errorf("failed to start MCP client: %w", startErr)
+	serverClient := &ServerConnection{
+		serverID:    serverID,
+		tools:       make(map[string]mcp.Tool),
+		toolTimeout: time.Duration(serverConfig.ToolTimeoutSeconds) * time.Second,
+	}
+	serverClient.connect = func(ctx context.Context) (mcpClient, *activeToolCalls, error) {
+		serverMCPClient, _, toolCalls, dialErr := c.dial(ctx, serverClient, serverConfig)
+		return serverMCPClient, toolCalls, dialErr
 	}
 
-	initResult, err := sseClient.Initialize(ctx, mcp.InitializeRequest{})
+	serverMCPClient, initResult, toolCalls, err := c.dial(ctx, serverClient, serverConfig)
 	if err != nil {
-		return fmt.Errorf("failed to initialize MCP client: %w", err)
+		return nil, err
 	}
 
+	// Ensure client is closed on error
+	success := false
+	defer func() {
+		if !success {
+			serverMCPClient.Close()
+		}
+	}()
+
 	c.log.Debug("MCP client initialized successfully",
 		"userID", c.userID,
 		"serverID", serverID,
 		"serverInfo", initResult.ServerInfo)
 
-	serverClient := &ServerConnection{
-		client:   sseClient,
-		serverID: serverID,
-		tools:    make(map[string]mcp.Tool),
+	serverClient.mu.Lock()
+	serverClient.client = serverMCPClient
+	serverClient.toolCalls = toolCalls
+	serverClient.mu.Unlock()
+
+	// List available tools, timing the round trip to the server
+	listStart := time.Now()
+	result, err := serverMCPClient.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools: %w", err)
 	}
-	c.clients[serverID] = serverClient
+	latency = time.Since(listStart)
+	toolCount = len(result.Tools)
+	serverClient.setTools(result.Tools)
+
+	// Prompts are optional, the tools of servers failing to list them are still used
+	if initResult.Capabilities.Prompts != nil {
+		promptsResult, err := serverMCPClient.ListPrompts(ctx, mcp.ListPromptsRequest{})
+		if err != nil {
+			c.log.Warn("Failed to list MCP prompts", "userID", c.userID, "serverID", serverID, "error", err)
+		} else {
+			serverClient.prompts = promptsResult.Prompts
+		}
+	}
+
+	success = true
+	return serverClient, nil
+}
 
-	// List and register available tools
-	result, err := sseClient.ListTools(ctx, mcp.ListToolsRequest{})
+// dial makes a client for the connection to a server and starts a session, returning the result of the
+// initialization and the tool calls running through the client.
+func (c *UserClient) dial(ctx context.Context, serverClient *ServerConnection, serverConfig ServerConfig) (mcpClient, *mcp.InitializeResult, *activeToolCalls, error) {
+	serverID := serverClient.serverID
+
+	// Secrets are read on each connection, so changed credentials are used when reconnecting
+	serverConfig, err := expandSecrets(serverConfig, c.secrets)
 	if err != nil {
-		return fmt.Errorf("failed to list tools: %w", err)
+		return nil, nil, nil, err
 	}
 
-	// Store the tools for this server
-	for _, tool := range result.Tools {
-		serverClient.tools[tool.Name] = tool
+	headers := make(map[string]string)
+	if !serverConfig.Shared {
+		headers[MMUserIDHeader] = c.userID
+	}
+	if serverConfig.Headers != nil {
+		maps.Copy(headers, serverConfig.Headers)
+	}
 
-		// Check for tool name conflicts across servers
-		if existingTool, exists := c.toolDefs[tool.Name]; exists {
-			c.log.Warn("Tool name conflict detected",
-				"userID", c.userID,
-				"tool", tool.Name,
-				"server1", existingTool.serverID,
-				"server2", serverID)
-			// For now, last server wins for conflicts
+	// Sampling requests are answered for the tool calls of the server that are running. The calls made
+	// through a shared connection can't be told apart by user, so shared servers can't make requests.
+	toolCalls := &activeToolCalls{}
+	handlers := serverHandlers{
+		notification: c.toolsChangedHandler(serverClient),
+	}
+	requestHandlers := map[string]requestHandler{}
+	if serverConfig.AllowSampling && c.sampler != nil && !serverConfig.Shared {
+		requestHandlers[samplingMethod] = c.samplingHandler(serverID, toolCalls)
+		handlers.capabilities.Sampling = &struct{}{}
+	}
+	if len(serverConfig.Roots) > 0 {
+		requestHandlers[rootsListMethod] = c.rootsHandler(serverConfig, toolCalls)
+		handlers.capabilities.Roots = &struct {
+			ListChanged bool `json:"listChanged,omitempty"`
+		}{}
+	}
+	handlers.request = dispatchRequests(requestHandlers)
+
+	var serverMCPClient mcpClient
+	var initResult *mcp.InitializeResult
+	switch serverConfig.Transport {
+	case TransportAuto:
+		serverMCPClient, initResult, err = connectStreamableHTTP(ctx, serverConfig.BaseURL, headers, handlers)
+		if errors.Is(err, errStreamableHTTPNotSupported) {
+			c.log.Debug("MCP server does not support the streamable HTTP transport, falling back to SSE", "serverID", serverID)
+			serverMCPClient, initResult, err = connectSSE(ctx, serverConfig.BaseURL, headers, handlers)
 		}
+	case TransportStreamableHTTP:
+		serverMCPClient, initResult, err = connectStreamableHTTP(ctx, serverConfig.BaseURL, headers, handlers)
+	case TransportSSE:
+		serverMCPClient, initResult, err = connectSSE(ctx, serverConfig.BaseURL, headers, handlers)
+	case TransportStdio:
+		serverMCPClient, initResult, err = c.connectStdio(ctx, serverID, serverConfig, handlers)
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown MCP transport %q", serverConfig.Transport)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if _, ok := serverMCPClient.(*client.SSEMCPClient); ok && handlers.request != nil {
+		c.log.Warn("MCP sampling and roots are not supported with the SSE transport", "serverID", serverID)
+	}
+
+	return serverMCPClient, initResult, toolCalls, nil
+}
+
+// initializeRequest returns the request starting a session, advertising the capabilities of the client.
+func initializeRequest(capabilities mcp.ClientCapabilities) mcp.InitializeRequest {
+	request := mcp.InitializeRequest{}
+	request.Params.Capabilities = capabilities
+	return request
+}
+
+// connectStreamableHTTP connects to a server with the streamable HTTP transport and starts a session
+func connectStreamableHTTP(ctx context.Context, baseURL string, headers map[string]string, handlers serverHandlers) (mcpClient, *mcp.InitializeResult, error) {
+	httpClient := NewStreamableHTTPClient(baseURL, headers)
+	httpClient.handleRequest = handlers.request
+	httpClient.handleNotification = handlers.notification
+
+	initResult, err := httpClient.Initialize(ctx, initializeRequest(handlers.capabilities))
+	if err != nil {
+		httpClient.Close()
+		return nil, nil, fmt.Errorf("failed to initialize MCP client: %w", err)
+	}
+
+	return httpClient, initResult, nil
+}
+
+// connectSSE connects to a server with the SSE transport, which doesn't support requests of the server
+func connectSSE(ctx context.Context, baseURL string, headers map[string]string, handlers serverHandlers) (mcpClient, *mcp.InitializeResult, error) {
+	sseClient, err := client.NewSSEMCPClient(baseURL, client.WithHeaders(headers))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create MCP client: %w", err)
+	}
+	sseClient.OnNotification(func(notification mcp.JSONRPCNotification) {
+		handlers.notification(notification.Method)
+	})
+
+	if startErr := sseClient.Start(ctx); startErr != nil {
+		sseClient.Close()
+		return nil, nil, fmt.Errorf("failed to start MCP client: %w", startErr)
+	}
+
+	initResult, err := sseClient.Initialize(ctx, mcp.InitializeRequest{})
+	if err != nil {
+		sseClient.Close()
+		return nil, nil, fmt.Errorf("failed to initialize MCP client: %w", err)
+	}
+
+	return sseClient, initResult, nil
+}
+
+// connectStdio launches a server for the user and starts a session with it. The processes launched for
+// the users of a server that isn't shared count towards its limit until the client is closed.
+func (c *UserClient) connectStdio(ctx context.Context, serverID string, serverConfig ServerConfig, handlers serverHandlers) (mcpClient, *mcp.InitializeResult, error) {
+	env := make(map[string]string)
+	maps.Copy(env, serverConfig.Env)
+	if !serverConfig.Shared {
+		env[MMUserIDEnv] = c.userID
+	}
 
-		c.toolDefs[tool.Name] = ToolDefinition{
-			tool:     tool,
-			serverID: serverID,
+	stdioClient := NewStdioClient(serverConfig.Command, serverConfig.Args, env, &c.log, "userID", c.userID, "serverID", serverID)
+	if !serverConfig.Shared && c.stdioLimit != nil {
+		if err := c.stdioLimit.acquire(serverID); err != nil {
+			return nil, nil, err
 		}
+		limit := c.stdioLimit
+		stdioClient.onClose = func() {
+			limit.release(serverID)
+		}
+	}
+	stdioClient.handleRequest = handlers.request
+	stdioClient.handleNotification = handlers.notification
 
-		c.log.Debug("Registered MCP tool",
-			"userID", c.userID,
-			"name", tool.Name,
-			"description", tool.Description,
-			"server", serverID)
+	initResult, err := stdioClient.Initialize(ctx, initializeRequest(handlers.capabilities))
+	if err != nil {
+		stdioClient.Close()
+		return nil, nil, fmt.Errorf("failed to initialize MCP client: %w", err)
 	}
 
-	success = true
-	return nil
+	return stdioClient, initResult, nil
 }
 
 // Close closes all server connections for a user client
@@ -166,16 +400,18 @@ func (c *UserClient) Close() {
 		return
 	}
 
-	// Close all MCP server clients
+	// Close all MCP server clients, except the shared ones that other users keep using
 	for serverID, client := range c.clients {
-		if err := client.client.Close(); err != nil {
+		if client.shared {
+			continue
+		}
+		if err := client.close(); err != nil {
 			c.log.Error("Failed to close MCP client", "userID", c.userID, "serverID", serverID, "error", err)
 		}
 	}
 
-	// Clear clients and tool definitions
+	// Clear clients
 	c.clients = make(map[string]*ServerConnection)
-	c.toolDefs = make(map[string]ToolDefinition)
 }
 
 // ConvertPropertiesToOrderedMap converts a map of properties to an OrderedMap using JSON marshaling
@@ -189,14 +425,29 @@ func ConvertPropertiesToOrderedMap(source map[string]any) (*orderedmap.OrderedMa
 	return &target, err
 }
 
+// toolDefinitions returns the tools of the servers connected to, by the name the LLM knows them by
+func (c *UserClient) toolDefinitions() map[string]ToolDefinition {
+	toolDefs := make(map[string]ToolDefinition)
+	for serverID, serverClient := range c.clients {
+		for _, tool := range serverClient.toolList() {
+			toolDefs[ToolName(serverID, tool.Name)] = ToolDefinition{
+				tool:     tool,
+				serverID: serverID,
+			}
+		}
+	}
+	return toolDefs
+}
+
 // GetTools returns the tools available from the client
 func (c *UserClient) GetTools() []llm.Tool {
 	if len(c.clients) == 0 {
 		return nil
 	}
 
-	tools := make([]llm.Tool, 0, len(c.toolDefs))
-	for name, toolInfo := range c.toolDefs {
+	toolDefs := c.toolDefinitions()
+	tools := make([]llm.Tool, 0, len(toolDefs))
+	for name, toolInfo := range toolDefs {
 		properties, err := ConvertPropertiesToOrderedMap(toolInfo.tool.InputSchema.Properties)
 		if err != nil {
 			c.log.Error("Failed to convert tool input schema properties", "userID", c.userID, "tool", name, "error", err)
@@ -212,6 +463,7 @@ func (c *UserClient) GetTools() []llm.Tool {
 			Description: toolInfo.tool.Description,
 			Schema:      schema,
 			Resolver:    c.createToolResolver(name),
+			Timeout:     c.clients[toolInfo.serverID].toolTimeout,
 		})
 	}
 
@@ -219,8 +471,8 @@ func (c *UserClient) GetTools() []llm.Tool {
 }
 
 // createToolResolver creates a resolver function for the given tool
-func (c *UserClient) createToolResolver(toolName string) func(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
-	return func(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+func (c *UserClient) createToolResolver(toolName string) func(ctx context.Context, llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	return func(ctx context.Context, llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
 		if len(c.clients) == 0 {
 			return "", fmt.Errorf("MCP client has no active connections")
 		}
@@ -229,7 +481,7 @@ func (c *UserClient) createToolResolver(toolName string) func(llmContext *llm.Co
 		c.lastActivity = time.Now()
 
 		// Find which server has this tool
-		toolInfo, exists := c.toolDefs[toolName]
+		toolInfo, exists := c.toolDefinitions()[toolName]
 		if !exists {
 			return "", fmt.Errorf("tool %s not found", toolName)
 		}
@@ -247,13 +499,9 @@ func (c *UserClient) createToolResolver(toolName string) func(llmContext *llm.Co
 			return "", fmt.Errorf("failed to get arguments for tool %s: %w", toolName, err)
 		}
 
-		// Create the context for the tool call with timeout
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-		defer cancel()
-
 		// Call the tool
 		callRequest := mcp.CallToolRequest{}
-		callRequest.Params.Name = toolName
+		callRequest.Params.Name = toolInfo.tool.Name
 		callRequest.Params.Arguments = make(map[string]interface{})
 
 		// Parse the raw arguments into a map
@@ -263,7 +511,26 @@ func (c *UserClient) createToolResolver(toolName string) func(llmContext *llm.Co
 		}
 		callRequest.Params.Arguments = args
 
-		result, err := serverClient.client.CallTool(ctx, callRequest)
+		botID, channelID := "", ""
+		if llmContext != nil {
+			botID = llmContext.BotID
+			if llmContext.Channel != nil {
+				channelID = llmContext.Channel.Id
+			}
+		}
+		serverMCPClient, result, err := c.callTool(ctx, serverClient, callRequest, botID, channelID)
+		if isConnectionError(err) {
+			// The call is retried once on a new connection, and the user told about it
+			c.log.Warn("MCP server connection dropped, reconnecting", "userID", c.userID, "serverID", serverID, "error", err)
+			if reconnectErr := serverClient.reconnect(ctx, serverMCPClient); reconnectErr != nil {
+				c.log.Error("Failed to reconnect to MCP server", "userID", c.userID, "serverID", serverID, "error", reconnectErr)
+			} else {
+				if llmContext != nil {
+					llmContext.AddReconnectedToolServer(serverID)
+				}
+				_, result, err = c.callTool(ctx, serverClient, callRequest, botID, channelID)
+			}
+		}
 		if err != nil {
 			return "", fmt.Errorf("failed to call tool %s on server %s: %w", toolName, serverID, err)
 		}
@@ -282,3 +549,27 @@ func (c *UserClient) createToolResolver(toolName string) func(llmContext *llm.Co
 		return "", fmt.Errorf("no text content found in response from tool %s on server %s", toolName, serverID)
 	}
 }
+
+// callTool calls a tool through the current client of the connection, and records the call. The client
+// is returned to reconnect when the connection dropped.
+func (c *UserClient) callTool(ctx context.Context, serverClient *ServerConnection, callRequest mcp.CallToolRequest, botID, channelID string) (mcpClient, *mcp.CallToolResult, error) {
+	serverMCPClient, toolCalls := serverClient.current()
+
+	callCtx, endToolCall := toolCalls.start(ctx, botID, channelID)
+	start := time.Now()
+	result, err := serverMCPClient.CallTool(callCtx, callRequest)
+	endToolCall()
+	c.recordToolCall(newToolCall(c.userID, botID, serverClient.serverID, callRequest.Params.Name, callRequest.Params.Arguments, start, err == nil && result.IsError, err))
+
+	return serverMCPClient, result, err
+}
+
+// recordToolCall keeps the audit record of a tool call. Failing to record it doesn't fail the call.
+func (c *UserClient) recordToolCall(call ToolCall) {
+	if c.auditor == nil {
+		return
+	}
+	if err := c.auditor.RecordToolCall(call); err != nil {
+		c.log.Warn("Failed to record MCP tool call", "userID", c.userID, "serverID", call.ServerID, "tool", call.ToolName, "error", err)
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// ActionItemsPostType is the type of the checklist posts with the action items of a meeting
+	ActionItemsPostType = "custom_llm_action_items"
+
+	// ActionItemsProp is the post prop holding the action items of a checklist post
+	ActionItemsProp = "action_items"
+
+	// SummaryPostIDProp references the summary the action items were extracted from
+	SummaryPostIDProp = "summary_post_id"
+
+	dueDateFormat = "2006-01-02"
+)
+
+var (
+	// ErrActionItemNotFound is returned when a post doesn't have the action item.
+	ErrActionItemNotFound = errors.New("action item not found")
+
+	// ErrActionItemNoOwner is returned when sending an action item that isn't assigned to a user.
+	ErrActionItemNoOwner = errors.New("action item has no owner")
+)
+
+// ActionItem is a task from a meeting, assigned to a user when the owner is one of the participants.
+type ActionItem struct {
+	ID          string `json:"id"`
+	Task        string `json:"task"`
+	Owner       string `json:"owner"`
+	OwnerUserID string `json:"owner_user_id"`
+	DueDate     string `json:"due_date"`
+	Done        bool   `json:"done"`
+	SentAt      int64  `json:"sent_at"`
+}
+
+type actionItemsResponse struct {
+	ActionItems []struct {
+		Task    string `json:"task"`
+		Owner   string `json:"owner"`
+		DueDate string `json:"due_date"`
+	} `json:"action_items"`
+}
+
+// ExtractActionItems finds the action items in a meeting summary, assigning them to the participants.
+func (s *Service) ExtractActionItems(bot *bots.Bot, summary string, participants []*model.User, context *llm.Context) ([]ActionItem, error) {
+	var participantList strings.Builder
+	for _, participant := range participants {
+		fmt.Fprintf(&participantList, "- %s: %s\n", participant.Username, participant.GetFullName())
+	}
+	context.Parameters = map[string]any{
+		"Participants": participantList.String(),
+		"Today":        time.Now().Format(dueDateFormat),
+	}
+	systemPrompt, err := s.prompts.Format(prompts.PromptMeetingActionItemsSystem, context)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get action items prompt: %w", err)
+	}
+
+	request := llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: systemPrompt,
+			},
+			{
+				Role:    llm.PostRoleUser,
+				Message: summary,
+			},
+		},
+		Context: context,
+	}
+	result, err := bot.LLM().ChatCompletionNoStream(request, llm.WithJSONOutput(&actionItemsResponse{}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to extract action items: %w", err)
+	}
+
+	return parseActionItems(result, participants)
+}
+
+// parseActionItems converts the model response into action items. Owners are matched to the participants
+// by username or full name, due dates that aren't valid dates are dropped.
+func parseActionItems(result string, participants []*model.User) ([]ActionItem, error) {
+	var response actionItemsResponse
+	if err := llm.UnmarshalModelJSON(result, &response); err != nil {
+		return nil, fmt.Errorf("unable to parse action items: %w", err)
+	}
+
+	items := make([]ActionItem, 0, len(response.ActionItems))
+	for _, item := range response.ActionItems {
+		task := strings.TrimSpace(item.Task)
+		if task == "" {
+			continue
+		}
+
+		actionItem := ActionItem{
+			ID:    model.NewId(),
+			Task:  task,
+			Owner: strings.TrimPrefix(strings.TrimSpace(item.Owner), "@"),
+		}
+		if owner := findParticipant(participants, actionItem.Owner); owner != nil {
+			actionItem.Owner = owner.Username
+			actionItem.OwnerUserID = owner.Id
+		}
+		if dueDate, err := time.Parse(dueDateFormat, strings.TrimSpace(item.DueDate)); err == nil {
+			actionItem.DueDate = dueDate.Format(dueDateFormat)
+		}
+
+		items = append(items, actionItem)
+	}
+
+	return items, nil
+}
+
+func findParticipant(participants []*model.User, name string) *model.User {
+	if name == "" {
+		return nil
+	}
+	for _, participant := range participants {
+		if strings.EqualFold(participant.Username, name) {
+			return participant
+		}
+	}
+	for _, participant := range participants {
+		if fullName := participant.GetFullName(); fullName != "" && strings.EqualFold(fullName, name) {
+			return participant
+		}
+	}
+	return nil
+}
+
+// formatActionItems formats the checklist as markdown, for clients that can't show the interactive checklist.
+func formatActionItems(items []ActionItem) string {
+	var result strings.Builder
+	for _, item := range items {
+		check := " "
+		if item.Done {
+			check = "x"
+		}
+		fmt.Fprintf(&result, "- [%s] %s", check, item.Task)
+
+		var details []string
+		if item.OwnerUserID != "" {
+			details = append(details, "@"+item.Owner)
+		} else if item.Owner != "" {
+			details = append(details, item.Owner)
+		}
+		if item.DueDate != "" {
+			details = append(details, item.DueDate)
+		}
+		if len(details) > 0 {
+			fmt.Fprintf(&result, " (%s)", strings.Join(details, ", "))
+		}
+		result.WriteString("\n")
+	}
+	return result.String()
+}
+
+// postActionItems extracts the action items from a summary post and replies with them as a checklist.
+// Action items are optional so failures are logged rather than failing the summary.
+func (s *Service) postActionItems(bot *bots.Bot, requestingUser *model.User, channel *model.Channel, callPost *model.Post, summaryPost *model.Post) {
+	if strings.TrimSpace(summaryPost.Message) == "" {
+		return
+	}
+
+	participants, err := s.callParticipants(callPost)
+	if err != nil {
+		s.pluginAPI.Log.Warn("Unable to get call participants for action items", "error", err)
+	}
+
+	// A separate context so the parameters don't leak into other prompts
+	actionItemsContext := s.contextBuilder.BuildLLMContextUserRequest(bot, requestingUser, channel)
+	items, err := s.ExtractActionItems(bot, summaryPost.Message, participants, actionItemsContext)
+	if err != nil {
+		s.pluginAPI.Log.Warn("Unable to extract action items", "error", err)
+		return
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	T := i18n.LocalizerFunc(s.i18n, requestingUser.Locale)
+	rootID := summaryPost.RootId
+	if rootID == "" {
+		rootID = summaryPost.Id
+	}
+	post := &model.Post{
+		UserId:    bot.GetMMBot().UserId,
+		Type:      ActionItemsPostType,
+		RootId:    rootID,
+		ChannelId: summaryPost.ChannelId,
+		Message:   T("copilot.action_items", "Action items from the meeting:") + "\n" + formatActionItems(items),
+	}
+	post.AddProp(ActionItemsProp, items)
+	post.AddProp(SummaryPostIDProp, summaryPost.Id)
+	post.AddProp(streaming.LLMRequesterUserID, requestingUser.Id)
+	post.AddProp(streaming.UnsafeLinksPostProp, "true")
+	streaming.MarkAIGenerated(bot.GetMMBot().UserId, post)
+
+	if err := s.pluginAPI.Post.CreatePost(post); err != nil {
+		s.pluginAPI.Log.Warn("Unable to post action items", "error", err)
+	}
+}
+
+// postActionItemsProp returns the action items stored on a checklist post.
+func postActionItemsProp(post *model.Post) ([]ActionItem, error) {
+	if post.Type != ActionItemsPostType {
+		return nil, ErrActionItemNotFound
+	}
+
+	// Props are decoded into generic maps when the post is read back
+	itemsJSON, err := json.Marshal(post.GetProp(ActionItemsProp))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read action items: %w", err)
+	}
+	var items []ActionItem
+	if err := json.Unmarshal(itemsJSON, &items); err != nil {
+		return nil, fmt.Errorf("unable to read action items: %w", err)
+	}
+	return items, nil
+}
+
+func findActionItem(items []ActionItem, itemID string) int {
+	for i, item := range items {
+		if item.ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateActionItems saves the action items on the checklist post, keeping the markdown version in sync.
+func (s *Service) updateActionItems(post *model.Post, items []ActionItem) error {
+	intro, _, _ := strings.Cut(post.Message, "\n")
+	post.Message = intro + "\n" + formatActionItems(items)
+	post.AddProp(ActionItemsProp, items)
+	if err := s.pluginAPI.Post.UpdatePost(post); err != nil {
+		return fmt.Errorf("unable to update action items: %w", err)
+	}
+	return nil
+}
+
+// SetActionItemDone checks or unchecks an action item of a checklist post.
+func (s *Service) SetActionItemDone(post *model.Post, itemID string, done bool) error {
+	items, err := postActionItemsProp(post)
+	if err != nil {
+		return err
+	}
+	index := findActionItem(items, itemID)
+	if index < 0 {
+		return ErrActionItemNotFound
+	}
+
+	items[index].Done = done
+	return s.updateActionItems(post, items)
+}
+
+// SendActionItem sends an action item of a checklist post to its owner by DM from the bot that posted it.
+func (s *Service) SendActionItem(post *model.Post, itemID string, senderUserID string) error {
+	items, err := postActionItemsProp(post)
+	if err != nil {
+		return err
+	}
+	index := findActionItem(items, itemID)
+	if index < 0 {
+		return ErrActionItemNotFound
+	}
+	item := items[index]
+	if item.OwnerUserID == "" {
+		return ErrActionItemNoOwner
+	}
+
+	bot := s.bots.GetBotByID(post.UserId)
+	if bot == nil {
+		return fmt.Errorf("unable to get bot")
+	}
+	sender, err := s.pluginAPI.User.Get(senderUserID)
+	if err != nil {
+		return fmt.Errorf("unable to get sender: %w", err)
+	}
+	owner, err := s.pluginAPI.User.Get(item.OwnerUserID)
+	if err != nil {
+		return fmt.Errorf("unable to get action item owner: %w", err)
+	}
+
+	T := i18n.LocalizerFunc(s.i18n, owner.Locale)
+	dm := &model.Post{
+		Message: T("copilot.action_item_assigned", "@%s shared an action item from a meeting with you:", sender.Username) + "\n" + formatActionItems([]ActionItem{item}),
+	}
+	dm.AddProp(streaming.NoRegen, "true")
+	if err := s.botDMNonResponse(bot.GetMMBot().UserId, owner.Id, dm); err != nil {
+		return err
+	}
+
+	items[index].SentAt = model.GetMillis()
+	return s.updateActionItems(post, items)
+}
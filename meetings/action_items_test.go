@@ -0,0 +1,94 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseActionItems(t *testing.T) {
+	participants := []*model.User{
+		{Id: "user1", Username: "john.doe", FirstName: "John", LastName: "Doe"},
+		{Id: "user2", Username: "jane", FirstName: "Jane", LastName: "Smith"},
+	}
+
+	tests := []struct {
+		name   string
+		result string
+		want   []ActionItem
+	}{
+		{
+			name:   "owners matched by username and full name",
+			result: `{"action_items": [{"task": "Send the report", "owner": "@john.doe", "due_date": "2026-10-23"}, {"task": "Book the room", "owner": "jane smith", "due_date": ""}]}`,
+			want: []ActionItem{
+				{Task: "Send the report", Owner: "john.doe", OwnerUserID: "user1", DueDate: "2026-10-23"},
+				{Task: "Book the room", Owner: "jane", OwnerUserID: "user2"},
+			},
+		},
+		{
+			name:   "owners who aren't participants",
+			result: `{"action_items": [{"task": "Review the contract", "owner": "Legal", "due_date": "next week"}]}`,
+			want: []ActionItem{
+				{Task: "Review the contract", Owner: "Legal"},
+			},
+		},
+		{
+			name:   "empty tasks are skipped",
+			result: "```json\n{\"action_items\": [{\"task\": \" \", \"owner\": \"jane\"}, {\"task\": \"Follow up\"}]}\n```",
+			want: []ActionItem{
+				{Task: "Follow up"},
+			},
+		},
+		{
+			name:   "no action items",
+			result: `{"action_items": []}`,
+			want:   []ActionItem{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			items, err := parseActionItems(tc.result, participants)
+			require.NoError(t, err)
+			for i := range items {
+				assert.NotEmpty(t, items[i].ID)
+				items[i].ID = ""
+			}
+			assert.Equal(t, tc.want, items)
+		})
+	}
+
+	_, err := parseActionItems("not json", participants)
+	require.Error(t, err)
+}
+
+func TestFormatActionItems(t *testing.T) {
+	items := []ActionItem{
+		{Task: "Send the report", Owner: "john.doe", OwnerUserID: "user1", DueDate: "2026-10-23", Done: true},
+		{Task: "Review the contract", Owner: "Legal"},
+		{Task: "Follow up"},
+	}
+
+	assert.Equal(t, "- [x] Send the report (@john.doe, 2026-10-23)\n- [ ] Review the contract (Legal)\n- [ ] Follow up\n", formatActionItems(items))
+}
+
+func TestNewPlaybookChecklist(t *testing.T) {
+	items := []ActionItem{
+		{ID: "1", Task: "Send the report", Owner: "john.doe", OwnerUserID: "user1", DueDate: "2026-10-23"},
+		{ID: "2", Task: "Review the contract", Owner: "Legal", Done: true},
+	}
+
+	checklist := newPlaybookChecklist("Meeting action items", items)
+	assert.Equal(t, playbookChecklist{
+		Title: "Meeting action items",
+		Items: []playbookChecklistItem{
+			{Title: "Send the report", AssigneeID: "user1", DueDate: 1792713600000},
+			{Title: "Review the contract", State: "closed"},
+		},
+	}, checklist)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+)
+
+// errNoAutoSummary is returned for recording posts that aren't summarized automatically under normal conditions.
+var errNoAutoSummary = errors.New("not summarizing recording automatically")
+
+// MessageHasBeenPosted summarizes the recordings the Calls bot posts in channels that opted in,
+// as if the user who started the call had asked for it.
+func (s *Service) MessageHasBeenPosted(_ *plugin.Context, post *model.Post) {
+	if err := s.autoSummarizeRecording(post); err != nil {
+		if errors.Is(err, errNoAutoSummary) {
+			s.pluginAPI.Log.Debug(err.Error())
+		} else {
+			s.pluginAPI.Log.Error("Failed to summarize call recording automatically", "post_id", post.Id, "error", err)
+		}
+	}
+}
+
+func (s *Service) autoSummarizeRecording(post *model.Post) error {
+	if post.Type != CallsRecordingPostType {
+		return nil
+	}
+
+	botName := s.config.GetAutoSummarizeBotName(post.ChannelId)
+	if botName == "" {
+		return fmt.Errorf("channel hasn't opted in: %w", errNoAutoSummary)
+	}
+	if len(post.FileIds) == 0 {
+		return fmt.Errorf("no recording attached: %w", errNoAutoSummary)
+	}
+
+	poster, err := s.pluginAPI.User.Get(post.UserId)
+	if err != nil {
+		return fmt.Errorf("unable to get recording poster: %w", err)
+	}
+	if !poster.IsBot || poster.Username != CallsBotUsername {
+		return fmt.Errorf("recording not posted by the calls bot: %w", errNoAutoSummary)
+	}
+
+	bot := s.bots.GetBotByUsername(botName)
+	if bot == nil {
+		return fmt.Errorf("configured bot %s not found", botName)
+	}
+
+	// Recordings are posted in the thread of the call, which was started by its host
+	if post.RootId == "" {
+		return fmt.Errorf("recording not posted in a call thread: %w", errNoAutoSummary)
+	}
+	callPost, err := s.pluginAPI.Post.GetPost(post.RootId)
+	if err != nil {
+		return fmt.Errorf("unable to get call post: %w", err)
+	}
+	requesterID := callPost.UserId
+
+	channel, err := s.pluginAPI.Channel.Get(post.ChannelId)
+	if err != nil {
+		return fmt.Errorf("unable to get channel: %w", err)
+	}
+	if err := s.bots.CheckUsageRestrictions(requesterID, bot, channel); err != nil {
+		return fmt.Errorf("call host can't use %s in the channel: %w", botName, err)
+	}
+
+	if _, err := s.HandleTranscribeFile(requesterID, bot, post, channel, post.FileIds, "", false, ""); err != nil {
+		return fmt.Errorf("unable to summarize recording: %w", err)
+	}
+
+	return nil
+}
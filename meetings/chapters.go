@@ -0,0 +1,154 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/chunking"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+)
+
+// ChaptersProp is the post prop holding the chapters of the summarized recording.
+const ChaptersProp = "chapters"
+
+// MinChapteringDuration is the shortest recording that is split into chapters.
+const MinChapteringDuration = 10 * time.Minute
+
+// Chapter is a topic of a recording and when it starts.
+type Chapter struct {
+	Title   string `json:"title"`
+	StartMS int64  `json:"start_ms"`
+}
+
+type chaptersResponse struct {
+	Chapters []struct {
+		Title string `json:"title"`
+		Start string `json:"start"`
+	} `json:"chapters"`
+}
+
+// GenerateChapters detects the topic boundaries of a transcription and returns them as chapters.
+// Recordings shorter than MinChapteringDuration don't have chapters.
+func (s *Service) GenerateChapters(bot *bots.Bot, transcription *subtitles.Subtitles, context *llm.Context) ([]Chapter, error) {
+	duration := transcription.Duration()
+	if duration < MinChapteringDuration {
+		return nil, nil
+	}
+
+	systemPrompt, err := s.prompts.Format(prompts.PromptMeetingChaptersSystem, context)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get meeting chapters prompt: %w", err)
+	}
+
+	// Long transcriptions are chaptered in chunks
+	var chapters []Chapter
+	for _, chunk := range timestampedChunks(bot, transcription) {
+		request := llm.CompletionRequest{
+			Posts: []llm.Post{
+				{
+					Role:    llm.PostRoleSystem,
+					Message: systemPrompt,
+				},
+				{
+					Role:    llm.PostRoleUser,
+					Message: chunk,
+				},
+			},
+			Context: context,
+		}
+
+		result, err := bot.LLM().ChatCompletionNoStream(request, llm.WithJSONOutput(&chaptersResponse{}))
+		if err != nil {
+			return nil, fmt.Errorf("unable to get chapters: %w", err)
+		}
+
+		chunkChapters, err := parseChapters(result, duration)
+		if err != nil {
+			return nil, err
+		}
+		chapters = append(chapters, chunkChapters...)
+	}
+
+	return normalizeChapters(chapters), nil
+}
+
+// timestampedChunks splits the transcription into chunks that fit the context of the bot,
+// every chunk keeps the timestamps of its lines.
+func timestampedChunks(bot *bots.Bot, transcription *subtitles.Subtitles) []string {
+	llmFormattedTranscription := transcription.FormatForLLM()
+	tokenLimitWithMargin := int(float64(bot.LLM().InputTokenLimit())*0.75) - ContextTokenMargin
+	if tokenLimitWithMargin < 0 {
+		tokenLimitWithMargin = ContextTokenMargin / 2
+	}
+
+	if bot.LLM().CountTokens(llmFormattedTranscription) <= tokenLimitWithMargin {
+		return []string{llmFormattedTranscription}
+	}
+	return chunking.SplitPlaintextOnSentences(llmFormattedTranscription, tokenLimitWithMargin*4)
+}
+
+// parseChapters converts the model response into chapters, skipping chapters with
+// timestamps that aren't within the recording. An empty response has no chapters.
+func parseChapters(result string, duration time.Duration) ([]Chapter, error) {
+	if strings.TrimSpace(result) == "" {
+		return nil, nil
+	}
+
+	var response chaptersResponse
+	if err := llm.UnmarshalModelJSON(result, &response); err != nil {
+		return nil, fmt.Errorf("unable to parse chapters: %w", err)
+	}
+
+	chapters := make([]Chapter, 0, len(response.Chapters))
+	for _, chapter := range response.Chapters {
+		start, err := subtitles.ParseLLMTimestamp(chapter.Start)
+		if err != nil || start > duration {
+			continue
+		}
+
+		chapters = append(chapters, Chapter{
+			Title:   strings.TrimSpace(chapter.Title),
+			StartMS: start.Milliseconds(),
+		})
+	}
+
+	return chapters, nil
+}
+
+// normalizeChapters orders the chapters and drops chapters starting at the same time as another.
+// The first chapter is moved to the start of the recording, and chapters without a title are named
+// after their start.
+func normalizeChapters(chapters []Chapter) []Chapter {
+	if len(chapters) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].StartMS < chapters[j].StartMS
+	})
+
+	result := []Chapter{chapters[0]}
+	for _, chapter := range chapters[1:] {
+		if chapter.StartMS == result[len(result)-1].StartMS {
+			continue
+		}
+		result = append(result, chapter)
+	}
+	result[0].StartMS = 0
+
+	for i := range result {
+		if result[i].Title == "" {
+			result[i].Title = "Chapter at " + subtitles.FormatLLMTimestamp(time.Duration(result[i].StartMS)*time.Millisecond)
+		}
+	}
+
+	return result
+}
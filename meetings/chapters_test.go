@@ -0,0 +1,128 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChapters(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   string
+		duration time.Duration
+		want     []Chapter
+		wantErr  bool
+	}{
+		{
+			name:     "valid chapters",
+			result:   `{"chapters": [{"title": "Intro", "start": "00:00"}, {"title": "Roadmap", "start": "12:30"}]}`,
+			duration: time.Hour,
+			want: []Chapter{
+				{Title: "Intro", StartMS: 0},
+				{Title: "Roadmap", StartMS: (12*time.Minute + 30*time.Second).Milliseconds()},
+			},
+		},
+		{
+			name:     "markdown code block",
+			result:   "```json\n{\"chapters\": [{\"title\": \"Intro\", \"start\": \"00:00\"}]}\n```",
+			duration: time.Hour,
+			want:     []Chapter{{Title: "Intro", StartMS: 0}},
+		},
+		{
+			name:     "skips invalid chapters",
+			result:   `{"chapters": [{"title": "Bad", "start": "soon"}, {"title": "Late", "start": "02:00:00"}, {"title": "Kept", "start": "01:00"}]}`,
+			duration: time.Hour,
+			want:     []Chapter{{Title: "Kept", StartMS: time.Minute.Milliseconds()}},
+		},
+		{
+			name:     "keeps chapters without a title",
+			result:   `{"chapters": [{"title": " ", "start": "01:00"}]}`,
+			duration: time.Hour,
+			want:     []Chapter{{Title: "", StartMS: time.Minute.Milliseconds()}},
+		},
+		{
+			name:     "empty response",
+			result:   " ",
+			duration: time.Hour,
+			want:     nil,
+		},
+		{
+			name:     "not json",
+			result:   "Here are the chapters",
+			duration: time.Hour,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseChapters(tc.result, tc.duration)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNormalizeChapters(t *testing.T) {
+	tests := []struct {
+		name     string
+		chapters []Chapter
+		want     []Chapter
+	}{
+		{
+			name:     "no chapters",
+			chapters: nil,
+			want:     nil,
+		},
+		{
+			name: "sorts and moves the first chapter to the start",
+			chapters: []Chapter{
+				{Title: "Second", StartMS: 5000},
+				{Title: "First", StartMS: 1000},
+			},
+			want: []Chapter{
+				{Title: "First", StartMS: 0},
+				{Title: "Second", StartMS: 5000},
+			},
+		},
+		{
+			name: "names chapters without a title after their start",
+			chapters: []Chapter{
+				{Title: "", StartMS: 1000},
+				{Title: "", StartMS: (12*time.Minute + 30*time.Second).Milliseconds()},
+			},
+			want: []Chapter{
+				{Title: "Chapter at 00:00", StartMS: 0},
+				{Title: "Chapter at 12:30", StartMS: (12*time.Minute + 30*time.Second).Milliseconds()},
+			},
+		},
+		{
+			name: "drops chapters starting at the same time",
+			chapters: []Chapter{
+				{Title: "First", StartMS: 0},
+				{Title: "Second", StartMS: 5000},
+				{Title: "Duplicate", StartMS: 5000},
+			},
+			want: []Chapter{
+				{Title: "First", StartMS: 0},
+				{Title: "Second", StartMS: 5000},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, normalizeChapters(tc.chapters))
+		})
+	}
+}
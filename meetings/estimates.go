@@ -0,0 +1,117 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/ffmpeg"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// spokenTokensPerMinute approximates the transcript length of a minute of speech,
+// about 150 words at 1.3 tokens per word.
+const spokenTokensPerMinute = 200
+
+var ffmpegDurationRegex = regexp.MustCompile(`Duration: (\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+
+// EstimateTranscribeFile estimates the cost of transcribing and summarizing a recording attached to the post.
+func (s *Service) EstimateTranscribeFile(bot *bots.Bot, post *model.Post, channel *model.Channel, fileID string) (*llm.CostEstimate, error) {
+	recordingFileInfo, err := s.pluginAPI.File.GetInfo(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if recordingFileInfo.ChannelId != channel.Id || !slices.Contains(post.FileIds, fileID) {
+		return nil, errors.New("file not attached to specified post")
+	}
+
+	duration, err := s.recordingDuration(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get recording duration: %w", err)
+	}
+	minutes := duration.Minutes()
+
+	transcription := llm.CostEstimate{
+		AudioMinutes: math.Round(minutes*10) / 10,
+		Cost:         minutes * s.bots.TranscriptionPrice(),
+		PriceKnown:   true,
+	}
+
+	// Long transcripts are summarized in chunks first, see SummarizeTranscription
+	inputTokens := int(minutes * spokenTokensPerMinute)
+	outputTokens := llm.EstimatedSummaryOutputTokens
+	tokenLimitWithMargin := int(float64(bot.LLM().InputTokenLimit())*0.75) - ContextTokenMargin
+	if tokenLimitWithMargin > 0 && inputTokens > tokenLimitWithMargin {
+		chunks := int(math.Ceil(float64(inputTokens) / float64(tokenLimitWithMargin)))
+		inputTokens += chunks * llm.EstimatedSummaryOutputTokens
+		outputTokens += chunks * llm.EstimatedSummaryOutputTokens
+	}
+
+	price, priceKnown := bot.GetConfig().Service.Price()
+	estimate := transcription.Add(llm.NewCostEstimate(price, priceKnown, inputTokens, outputTokens))
+
+	return &estimate, nil
+}
+
+// recordingDuration reads the duration of a recording from its container metadata. The recording is copied
+// to a temporary file first, as the metadata of MP4 recordings can be at the end of the file, which ffmpeg
+// can't seek to in a pipe.
+func (s *Service) recordingDuration(fileID string) (time.Duration, error) {
+	ffmpegConfig := s.ffmpegConfig()
+	ffmpegPath := ffmpegConfig.ResolvePath()
+	if ffmpegPath == "" {
+		return 0, ffmpeg.ErrNotInstalled
+	}
+
+	fileReader, err := s.pluginAPI.File.Get(fileID)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read recording file: %w", err)
+	}
+
+	recordingFile, err := os.CreateTemp("", "recording-estimate")
+	if err != nil {
+		return 0, fmt.Errorf("unable to create file for recording: %w", err)
+	}
+	defer os.Remove(recordingFile.Name())
+	defer recordingFile.Close()
+
+	if _, err = io.Copy(recordingFile, fileReader); err != nil {
+		return 0, fmt.Errorf("unable to copy recording file: %w", err)
+	}
+
+	// Without an output ffmpeg only prints the input details and exits with an error, so the error is ignored
+	args := append([]string{"-hide_banner"}, ffmpegConfig.InputArgs()...)
+	cmd := exec.Command(ffmpegPath, append(args, "-i", recordingFile.Name())...) //nolint:gosec
+	output, _ := cmd.CombinedOutput()
+
+	return parseFFMPEGDuration(string(output))
+}
+
+// parseFFMPEGDuration finds the duration in ffmpeg's description of an input.
+func parseFFMPEGDuration(output string) (time.Duration, error) {
+	matches := ffmpegDurationRegex.FindStringSubmatch(output)
+	if matches == nil {
+		return 0, errors.New("duration not found in ffmpeg output")
+	}
+
+	hours, _ := strconv.Atoi(matches[1])
+	minutes, _ := strconv.Atoi(matches[2])
+	seconds, _ := strconv.ParseFloat(matches[3], 64)
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)), nil
+}
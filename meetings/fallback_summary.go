@@ -0,0 +1,164 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// FallbackSummaryProp marks summaries written from the captions and chat of a call because its
+	// recording couldn't be transcribed
+	FallbackSummaryProp = "fallback_summary"
+
+	// chatMessageDuration is how long each chat message of a call is assumed to last in the fallback transcript
+	chatMessageDuration = 5 * time.Second
+)
+
+// fallbackTranscript joins the live captions of a call and the messages posted in the call thread
+// while it was going on, to summarize a meeting whose recording couldn't be transcribed.
+// It is empty when the call has neither.
+func (s *Service) fallbackTranscript(recordingPost *model.Post) *subtitles.Subtitles {
+	var segments []subtitles.Segment
+
+	if captionsFileID, err := GetCaptionsFileIDFromProps(recordingPost); err == nil {
+		if captions, captionsErr := s.readCaptions(captionsFileID); captionsErr != nil {
+			s.pluginAPI.Log.Warn("Unable to read the captions of the call", "error", captionsErr)
+		} else {
+			segments = append(segments, captions.Segments()...)
+		}
+	}
+
+	if recordingPost.RootId != "" {
+		if chat, err := s.callChat(recordingPost); err != nil {
+			s.pluginAPI.Log.Warn("Unable to read the chat of the call", "error", err)
+		} else {
+			segments = append(segments, chat...)
+		}
+	}
+
+	slices.SortStableFunc(segments, func(a, b subtitles.Segment) int {
+		return cmp.Compare(a.StartMS, b.StartMS)
+	})
+	return subtitles.NewSubtitlesFromSegments(segments)
+}
+
+func (s *Service) readCaptions(fileID string) (*subtitles.Subtitles, error) {
+	reader, err := s.pluginAPI.File.Get(fileID)
+	if err != nil {
+		return nil, err
+	}
+	return subtitles.NewSubtitlesFromTranscript(reader)
+}
+
+// callChat returns the messages people posted in the thread of the call, timed from the start of the call.
+func (s *Service) callChat(recordingPost *model.Post) ([]subtitles.Segment, error) {
+	callPost, err := s.pluginAPI.Post.GetPost(recordingPost.RootId)
+	if err != nil {
+		return nil, err
+	}
+	thread, err := s.pluginAPI.Post.GetPostThread(recordingPost.RootId)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]*model.Post, 0, len(thread.Posts))
+	names := map[string]string{}
+	for _, post := range thread.Posts {
+		if post.Id == callPost.Id || post.Id == recordingPost.Id {
+			continue
+		}
+		posts = append(posts, post)
+		if _, ok := names[post.UserId]; ok {
+			continue
+		}
+		if user, userErr := s.pluginAPI.User.Get(post.UserId); userErr == nil && !user.IsBot {
+			names[post.UserId] = user.Username
+		} else {
+			names[post.UserId] = ""
+		}
+	}
+
+	return chatSegments(posts, callPost.CreateAt, recordingPost.CreateAt, names), nil
+}
+
+// chatSegments turns the chat messages posted during a call, from its start until the recording was
+// posted, into transcript segments attributed to their author. Messages of bots and system messages,
+// which have no name in names, are left out.
+func chatSegments(posts []*model.Post, callStart int64, callEnd int64, names map[string]string) []subtitles.Segment {
+	segments := []subtitles.Segment{}
+	for _, post := range posts {
+		name := names[post.UserId]
+		if name == "" || post.IsSystemMessage() || post.CreateAt < callStart || post.CreateAt > callEnd {
+			continue
+		}
+		message := strings.TrimSpace(post.Message)
+		if message == "" {
+			continue
+		}
+
+		start := post.CreateAt - callStart
+		segments = append(segments, subtitles.Segment{
+			StartMS: start,
+			EndMS:   start + chatMessageDuration.Milliseconds(),
+			Speaker: name + " (chat)",
+			Text:    message,
+		})
+	}
+
+	slices.SortStableFunc(segments, func(a, b subtitles.Segment) int {
+		return cmp.Compare(a.StartMS, b.StartMS)
+	})
+	return segments
+}
+
+// summarizeFallback streams a summary of the captions and chat of a call into the transcript post,
+// starting with a caveat that the recording itself couldn't be transcribed.
+func (s *Service) summarizeFallback(ctx context.Context, bot *bots.Bot, requestingUser *model.User, recordingPost *model.Post, channel *model.Channel, transcriptPost *model.Post, transcription *subtitles.Subtitles, format string) error {
+	T := i18n.LocalizerFunc(s.i18n, requestingUser.Locale)
+
+	llmContext := s.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		requestingUser,
+		channel,
+		s.contextBuilder.WithLLMContextDefaultTools(bot, channel.Type == model.ChannelTypeDirect),
+	)
+	summaryStream, err := s.SummarizeTranscription(bot, transcription, llmContext, format, "")
+	if err != nil {
+		return err
+	}
+
+	// There is no transcript to regenerate the summary from
+	transcriptPost.AddProp(FallbackSummaryProp, "true")
+	transcriptPost.AddProp(streaming.NoRegen, "true")
+	transcriptPost.Message = T("copilot.summarize_call_recording_fallback", "_The recording couldn't be transcribed, so this summary is based only on the live captions and chat messages of the call. It may miss much of what was said._") + "\n\n"
+	if err := s.pluginAPI.Post.UpdatePost(transcriptPost); err != nil {
+		return err
+	}
+
+	streamCtx, err := s.streamingService.GetStreamingContext(ctx, transcriptPost.Id)
+	if err != nil {
+		return err
+	}
+	defer s.streamingService.FinishStreaming(transcriptPost.Id)
+
+	s.streamingService.StreamToPost(streamCtx, summaryStream, transcriptPost, requestingUser.Locale)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if streamCtx.Err() == nil {
+		s.postActionItems(bot, requestingUser, channel, recordingPost, transcriptPost)
+	}
+	return nil
+}
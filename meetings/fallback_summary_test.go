@@ -0,0 +1,60 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatSegments(t *testing.T) {
+	const callStart = int64(1_700_000_000_000)
+	const callEnd = callStart + 3_600_000
+	names := map[string]string{"alice": "alice", "bob": "bob", "calls": ""}
+
+	tests := []struct {
+		name  string
+		posts []*model.Post
+		want  []subtitles.Segment
+	}{
+		{
+			name: "messages in the order they were posted",
+			posts: []*model.Post{
+				{UserId: "bob", CreateAt: callStart + 90_000, Message: "Shared the roadmap doc"},
+				{UserId: "alice", CreateAt: callStart + 30_000, Message: " Can everyone hear me? "},
+			},
+			want: []subtitles.Segment{
+				{StartMS: 30_000, EndMS: 35_000, Speaker: "alice (chat)", Text: "Can everyone hear me?"},
+				{StartMS: 90_000, EndMS: 95_000, Speaker: "bob (chat)", Text: "Shared the roadmap doc"},
+			},
+		},
+		{
+			name: "bots, system messages and empty messages left out",
+			posts: []*model.Post{
+				{UserId: "calls", CreateAt: callStart + 1_000, Message: "Recording started"},
+				{UserId: "unknown", CreateAt: callStart + 2_000, Message: "Who am I?"},
+				{UserId: "alice", CreateAt: callStart + 3_000, Message: "alice joined the channel", Type: model.PostTypeJoinChannel},
+				{UserId: "alice", CreateAt: callStart + 4_000, Message: "  "},
+			},
+			want: []subtitles.Segment{},
+		},
+		{
+			name: "messages outside the call left out",
+			posts: []*model.Post{
+				{UserId: "alice", CreateAt: callStart - 1_000, Message: "Starting in a minute"},
+				{UserId: "bob", CreateAt: callEnd + 1_000, Message: "Thanks all"},
+			},
+			want: []subtitles.Segment{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, chatSegments(tc.posts, callStart, callEnd, names))
+		})
+	}
+}
@@ -4,23 +4,155 @@
 package meetings
 
 import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/ffmpeg"
 )
 
 const (
-	ffmpegPluginPath = "./plugins/mattermost-ai/dist/ffmpeg"
+	// maxRecordingSegmentDuration is the longest part of a recording, most meetings fit in a single part.
+	// At the default bitrate 45 minutes of audio is about 22 MB, below WhisperAPILimit.
+	maxRecordingSegmentDuration = 45 * time.Minute
+
+	recordingSegmentList = "segments.csv"
 )
 
-// resolveFFMPEGPath checks for ffmpeg installation and returns the appropriate path
-func resolveFFMPEGPath() string {
-	_, standardPathErr := exec.LookPath("ffmpeg")
-	if standardPathErr != nil {
-		_, pluginPathErr := exec.LookPath(ffmpegPluginPath)
-		if pluginPathErr != nil {
-			return ""
+// recordingSegmentDuration returns how long parts of a recording can be to stay below WhisperAPILimit
+// with the audio settings, leaving a margin since the audio size is estimated.
+func recordingSegmentDuration(ffmpegConfig ffmpeg.Config) time.Duration {
+	seconds := WhisperAPILimit * 9 / 10 / ffmpegConfig.BytesPerSecond()
+	return min(time.Duration(seconds)*time.Second, maxRecordingSegmentDuration)
+}
+
+// ffmpegConfig returns the configured ffmpeg options, falling back to the defaults when they are invalid.
+func (s *Service) ffmpegConfig() ffmpeg.Config {
+	ffmpegConfig := s.config.GetFFmpegConfig()
+	if err := ffmpegConfig.Validate(); err != nil {
+		s.pluginAPI.Log.Warn("Invalid ffmpeg configuration, using the defaults", "error", err)
+		return ffmpeg.Config{Path: ffmpegConfig.Path}
+	}
+	return ffmpegConfig
+}
+
+// CheckFFmpeg logs the problems with the configured ffmpeg binary, since transcriptions fail without it.
+func (s *Service) CheckFFmpeg() {
+	diagnostics := s.FFmpegDiagnostics()
+	if !diagnostics.OK() {
+		s.pluginAPI.Log.Error("ffmpeg is not usable, transcriptions will be disabled.", "path", diagnostics.Path, "errors", strings.Join(diagnostics.Errors, "; "))
+	}
+}
+
+// FFmpegDiagnostics checks the configured ffmpeg binary and options.
+func (s *Service) FFmpegDiagnostics() *ffmpeg.Diagnostics {
+	return s.config.GetFFmpegConfig().Diagnose()
+}
+
+// recordingSegment is a part of a recording converted to audio for transcription.
+type recordingSegment struct {
+	Path  string
+	Start time.Duration
+	End   time.Duration
+}
+
+// splitRecording converts the audio of a recording to audio files of at most recordingSegmentDuration in dir.
+// converting is called with the share of the recording converted as ffmpeg reports its progress.
+// ffmpeg is killed when the context is canceled.
+func (s *Service) splitRecording(ctx context.Context, recording io.Reader, dir string, converting func(converted float64)) ([]recordingSegment, error) {
+	ffmpegConfig := s.ffmpegConfig()
+	ffmpegPath := ffmpegConfig.ResolvePath()
+	if ffmpegPath == "" {
+		return nil, ffmpeg.ErrNotInstalled
+	}
+
+	cmd := exec.CommandContext(ctx, ffmpegPath, splitRecordingArgs(ffmpegConfig, dir)...) //nolint:gosec
+	cmd.Stdin = recording
+	stderr := &ffmpegLog{}
+	cmd.Stderr = stderr
+	cmd.Stdout = &ffmpegProgressWriter{onPosition: func(position time.Duration) {
+		if duration := stderr.Duration(); duration > 0 {
+			converting(float64(position) / float64(duration))
+		}
+	}}
+
+	if err := cmd.Run(); err != nil {
+		s.pluginAPI.Log.Debug("ffmpeg stderr: " + stderr.String())
+		return nil, fmt.Errorf("unable to split recording with ffmpeg: %w", err)
+	}
+
+	segmentList, err := os.Open(filepath.Join(dir, recordingSegmentList))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open recording segment list: %w", err)
+	}
+	defer segmentList.Close()
+
+	segments, err := parseSegmentList(segmentList, dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("recording has no audio")
+	}
+
+	return segments, nil
+}
+
+// splitRecordingArgs returns the ffmpeg arguments splitting the recording read from stdin into segments in dir.
+func splitRecordingArgs(ffmpegConfig ffmpeg.Config, dir string) []string {
+	args := []string{"-hide_banner", "-nostats", "-progress", "pipe:1"}
+	args = append(args, ffmpegConfig.InputArgs()...)
+	args = append(args, "-i", "pipe:0")
+	args = append(args, ffmpegConfig.AudioArgs()...)
+	return append(args,
+		"-f", "segment",
+		"-segment_format", ffmpegConfig.Format(),
+		"-segment_time", strconv.Itoa(int(recordingSegmentDuration(ffmpegConfig).Seconds())),
+		"-segment_list", filepath.Join(dir, recordingSegmentList),
+		"-segment_list_type", "csv",
+		"-reset_timestamps", "1",
+		filepath.Join(dir, "segment_%03d."+ffmpegConfig.Extension()),
+	)
+}
+
+// parseSegmentList reads the csv segment list written by ffmpeg's segment muxer,
+// where each line has the file name and the start and end time in seconds.
+func parseSegmentList(list io.Reader, dir string) ([]recordingSegment, error) {
+	reader := csv.NewReader(list)
+	reader.FieldsPerRecord = 3
+
+	var segments []recordingSegment
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read recording segment list: %w", err)
+		}
+
+		start, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording segment start %q: %w", record[1], err)
+		}
+		end, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording segment end %q: %w", record[2], err)
 		}
-		return ffmpegPluginPath
+		segments = append(segments, recordingSegment{
+			Path:  filepath.Join(dir, filepath.Base(record[0])),
+			Start: time.Duration(start * float64(time.Second)),
+			End:   time.Duration(end * float64(time.Second)),
+		})
 	}
 
-	return "ffmpeg"
+	return segments, nil
 }
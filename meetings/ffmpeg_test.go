@@ -0,0 +1,111 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/ffmpeg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSegmentList(t *testing.T) {
+	dir := filepath.Join("tmp", "transcription")
+
+	tests := []struct {
+		name    string
+		list    string
+		want    []recordingSegment
+		wantErr bool
+	}{
+		{
+			name: "single segment",
+			list: "segment_000.mp3,0.000000,1520.640000\n",
+			want: []recordingSegment{
+				{Path: filepath.Join(dir, "segment_000.mp3"), Start: 0, End: 1520*time.Second + 640*time.Millisecond},
+			},
+		},
+		{
+			name: "multiple segments",
+			list: "segment_000.mp3,0.000000,2700.024000\nsegment_001.mp3,2700.024000,5400.000000\nsegment_002.mp3,5400.000000,5712.500000\n",
+			want: []recordingSegment{
+				{Path: filepath.Join(dir, "segment_000.mp3"), Start: 0, End: 2700*time.Second + 24*time.Millisecond},
+				{Path: filepath.Join(dir, "segment_001.mp3"), Start: 2700*time.Second + 24*time.Millisecond, End: 5400 * time.Second},
+				{Path: filepath.Join(dir, "segment_002.mp3"), Start: 5400 * time.Second, End: 5712*time.Second + 500*time.Millisecond},
+			},
+		},
+		{
+			name: "paths stay inside the directory",
+			list: "../segment_000.mp3,0.000000,10.000000\n",
+			want: []recordingSegment{
+				{Path: filepath.Join(dir, "segment_000.mp3"), Start: 0, End: 10 * time.Second},
+			},
+		},
+		{
+			name: "empty list",
+			list: "",
+		},
+		{
+			name:    "invalid start",
+			list:    "segment_000.mp3,start,10.000000\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid end",
+			list:    "segment_000.mp3,0.000000,end\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing fields",
+			list:    "segment_000.mp3\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSegmentList(strings.NewReader(tt.list), dir)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRecordingSegmentDuration(t *testing.T) {
+	assert.Equal(t, maxRecordingSegmentDuration, recordingSegmentDuration(ffmpeg.Config{}))
+	assert.Equal(t, 1406*time.Second, recordingSegmentDuration(ffmpeg.Config{AudioBitrate: "128k"}))
+	assert.Equal(t, 1171*time.Second, recordingSegmentDuration(ffmpeg.Config{AudioCodec: ffmpeg.CodecFLAC, SampleRate: 16000}))
+}
+
+func TestSplitRecordingArgs(t *testing.T) {
+	dir := filepath.Join("tmp", "transcription")
+
+	args := splitRecordingArgs(ffmpeg.Config{AudioCodec: ffmpeg.CodecOpus, HWAccel: "cuda"}, dir)
+	assert.Equal(t, []string{
+		"-hide_banner",
+		"-nostats",
+		"-progress", "pipe:1",
+		"-hwaccel", "cuda",
+		"-i", "pipe:0",
+		"-map", "0:a:0",
+		"-ac", "1",
+		"-c:a", "libopus",
+		"-b:a", "64k",
+		"-f", "segment",
+		"-segment_format", "ogg",
+		"-segment_time", "2700",
+		"-segment_list", filepath.Join(dir, recordingSegmentList),
+		"-segment_list_type", "csv",
+		"-reset_timestamps", "1",
+		filepath.Join(dir, "segment_%03d.ogg"),
+	}, args)
+}
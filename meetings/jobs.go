@@ -0,0 +1,175 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Types of the jobs summarizing meetings
+const (
+	JobTypeSummarizeRecording     = "meetings_summarize_recording"
+	JobTypeSummarizeTranscription = "meetings_summarize_transcription"
+)
+
+// Transcribing is the slow part and usually fails for good, such as on a broken recording, while
+// summaries mostly fail on transient errors of the LLM service.
+const (
+	maxRecordingSummaryAttempts     = 2
+	maxTranscriptionSummaryAttempts = 3
+)
+
+// summarizeRecordingJob transcribes and summarizes call recordings into the post of the job.
+type summarizeRecordingJob struct {
+	BotID            string   `json:"botId"`
+	RecordingPostID  string   `json:"recordingPostId"`
+	RecordingFileIDs []string `json:"recordingFileIds"`
+	ChannelID        string   `json:"channelId"`
+	Language         string   `json:"language"`
+	Translate        bool     `json:"translate"`
+	Format           string   `json:"format"`
+}
+
+// summarizeTranscriptionJob summarizes the transcript of a post in a reply to the post of the job.
+type summarizeTranscriptionJob struct {
+	BotID               string `json:"botId"`
+	TranscriptionPostID string `json:"transcriptionPostId"`
+	ChannelID           string `json:"channelId"`
+	Translate           bool   `json:"translate"`
+	Format              string `json:"format"`
+	TimeRange           string `json:"timeRange,omitempty"`
+}
+
+// RegisterJobs adds the handlers of the meeting summaries to the queue.
+func (s *Service) RegisterJobs(queue *jobs.Queue) {
+	s.jobQueue = queue
+	queue.Register(JobTypeSummarizeRecording, jobs.Handler{
+		Run:         s.runSummarizeRecordingJob,
+		Failed:      s.jobFailed,
+		MaxAttempts: maxRecordingSummaryAttempts,
+	})
+	queue.Register(JobTypeSummarizeTranscription, jobs.Handler{
+		Run:         s.runSummarizeTranscriptionJob,
+		Failed:      s.jobFailed,
+		MaxAttempts: maxTranscriptionSummaryAttempts,
+	})
+}
+
+func (s *Service) runSummarizeRecordingJob(ctx context.Context, job *jobs.Job) error {
+	var payload summarizeRecordingJob
+	if err := job.DecodePayload(&payload); err != nil {
+		return err
+	}
+
+	bot, requestingUser, channel, err := s.loadJobContext(job, payload.BotID, payload.ChannelID)
+	if err != nil {
+		return err
+	}
+	recordingPost, err := s.pluginAPI.Post.GetPost(payload.RecordingPostID)
+	if err != nil {
+		return fmt.Errorf("unable to get recording post: %w", err)
+	}
+	transcriptPost, err := s.pluginAPI.Post.GetPost(job.PostID)
+	if err != nil {
+		return fmt.Errorf("unable to get transcript post: %w", err)
+	}
+
+	return s.transcribeAndSummarizeRecording(ctx, bot, requestingUser, recordingPost, payload.RecordingFileIDs, channel, transcriptPost, payload.Language, payload.Translate, payload.Format)
+}
+
+func (s *Service) runSummarizeTranscriptionJob(ctx context.Context, job *jobs.Job) error {
+	var payload summarizeTranscriptionJob
+	if err := job.DecodePayload(&payload); err != nil {
+		return err
+	}
+
+	var timeRange subtitles.TimeRange
+	if payload.TimeRange != "" {
+		var err error
+		if timeRange, err = subtitles.ParseTimeRange(payload.TimeRange); err != nil {
+			return fmt.Errorf("unable to parse transcript range: %w", err)
+		}
+	}
+
+	bot, requestingUser, channel, err := s.loadJobContext(job, payload.BotID, payload.ChannelID)
+	if err != nil {
+		return err
+	}
+	transcriptionPost, err := s.pluginAPI.Post.GetPost(payload.TranscriptionPostID)
+	if err != nil {
+		return fmt.Errorf("unable to get transcription post: %w", err)
+	}
+	surePost, err := s.pluginAPI.Post.GetPost(job.PostID)
+	if err != nil {
+		return fmt.Errorf("unable to get summary thread post: %w", err)
+	}
+
+	return s.summarizeTranscriptionPost(ctx, bot, requestingUser, transcriptionPost, channel, surePost, payload.Translate, payload.Format, timeRange)
+}
+
+// loadJobContext returns the bot, requester and meeting channel of a job.
+func (s *Service) loadJobContext(job *jobs.Job, botID, channelID string) (*bots.Bot, *model.User, *model.Channel, error) {
+	bot := s.bots.GetBotByID(botID)
+	if bot == nil {
+		return nil, nil, nil, errors.New("the bot of the job no longer exists")
+	}
+	user, err := s.pluginAPI.User.Get(job.UserID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to get user: %w", err)
+	}
+	channel, err := s.pluginAPI.Channel.Get(channelID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to get channel: %w", err)
+	}
+	return bot, user, channel, nil
+}
+
+// jobFailed replaces the message of the post of the job, which tells the requester the summary is in progress.
+func (s *Service) jobFailed(job *jobs.Job, jobErr error) {
+	post, err := s.pluginAPI.Post.GetPost(job.PostID)
+	if err != nil {
+		s.pluginAPI.Log.Error("Unable to get the post of a failed job", "job_id", job.ID, "error", err)
+		return
+	}
+
+	locale := ""
+	if user, userErr := s.pluginAPI.User.Get(job.UserID); userErr == nil {
+		locale = user.Locale
+	}
+	T := i18n.LocalizerFunc(s.i18n, locale)
+
+	if errors.Is(jobErr, jobs.ErrCancelled) {
+		post.Message = T("copilot.summarize_job_cancelled", "The summary was cancelled by a system admin.")
+	} else {
+		post.Message = T("copilot.summarize_call_recording_processing_error", "Sorry! Something went wrong. Check the server logs for details.")
+	}
+	if err := s.pluginAPI.Post.UpdatePost(post); err != nil {
+		s.pluginAPI.Log.Error("Failed to update the post of a failed job", "job_id", job.ID, "error", err)
+	}
+}
+
+// deletePartialSummaries deletes the summaries a bot started in reply to the post in an attempt that was interrupted.
+func (s *Service) deletePartialSummaries(post *model.Post, botID string) error {
+	thread, err := s.pluginAPI.Post.GetPostThread(post.Id)
+	if err != nil {
+		return fmt.Errorf("unable to get summary thread: %w", err)
+	}
+	for _, reply := range thread.Posts {
+		if reply.Id == post.Id || reply.UserId != botID || reply.GetProp(ReferencedTranscriptPostID) == nil {
+			continue
+		}
+		if err := s.pluginAPI.Post.DeletePost(reply.Id); err != nil {
+			return fmt.Errorf("unable to delete partial summary: %w", err)
+		}
+	}
+	return nil
+}
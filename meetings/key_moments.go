@@ -0,0 +1,149 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+)
+
+// KeyMoment is a notable point of a recording, such as a decision or an important announcement.
+type KeyMoment struct {
+	Description string
+	Start       time.Duration
+}
+
+type keyMomentsResponse struct {
+	Moments []struct {
+		Description string `json:"description"`
+		Time        string `json:"time"`
+	} `json:"moments"`
+}
+
+// GenerateKeyMoments finds the key moments of a transcription.
+func (s *Service) GenerateKeyMoments(bot *bots.Bot, transcription *subtitles.Subtitles, context *llm.Context) ([]KeyMoment, error) {
+	systemPrompt, err := s.prompts.Format(prompts.PromptMeetingKeyMomentsSystem, context)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get meeting key moments prompt: %w", err)
+	}
+
+	// Long transcriptions are searched in chunks
+	duration := transcription.Duration()
+	var moments []KeyMoment
+	for _, chunk := range timestampedChunks(bot, transcription) {
+		request := llm.CompletionRequest{
+			Posts: []llm.Post{
+				{
+					Role:    llm.PostRoleSystem,
+					Message: systemPrompt,
+				},
+				{
+					Role:    llm.PostRoleUser,
+					Message: chunk,
+				},
+			},
+			Context: context,
+		}
+
+		result, err := bot.LLM().ChatCompletionNoStream(request, llm.WithJSONOutput(&keyMomentsResponse{}))
+		if err != nil {
+			return nil, fmt.Errorf("unable to get key moments: %w", err)
+		}
+
+		chunkMoments, err := parseKeyMoments(result, duration)
+		if err != nil {
+			return nil, err
+		}
+		moments = append(moments, chunkMoments...)
+	}
+
+	sort.SliceStable(moments, func(i, j int) bool {
+		return moments[i].Start < moments[j].Start
+	})
+
+	return moments, nil
+}
+
+// parseKeyMoments converts the model response into key moments, skipping moments with
+// timestamps that aren't within the recording.
+func parseKeyMoments(result string, duration time.Duration) ([]KeyMoment, error) {
+	var response keyMomentsResponse
+	if err := llm.UnmarshalModelJSON(result, &response); err != nil {
+		return nil, fmt.Errorf("unable to parse key moments: %w", err)
+	}
+
+	moments := make([]KeyMoment, 0, len(response.Moments))
+	for _, moment := range response.Moments {
+		description := strings.Join(strings.Fields(moment.Description), " ")
+		if description == "" {
+			continue
+		}
+
+		start, err := subtitles.ParseLLMTimestamp(moment.Time)
+		if err != nil || start > duration {
+			continue
+		}
+
+		moments = append(moments, KeyMoment{
+			Description: description,
+			Start:       start,
+		})
+	}
+
+	return moments, nil
+}
+
+// recordingLink returns a link to the recording file that starts playing at the offset.
+// Browsers seek media to the time of a media fragment, so no support from Calls is needed.
+func recordingLink(siteURL, fileID string, offset time.Duration) string {
+	return fmt.Sprintf("%s/api/v4/files/%s#t=%d", strings.TrimSuffix(siteURL, "/"), url.PathEscape(fileID), int(offset.Seconds()))
+}
+
+// formatKeyMoments formats the key moments as a markdown list appended to summaries, each timestamp
+// linking to the moment in the recording.
+func formatKeyMoments(moments []KeyMoment, siteURL, recordingFileID string, T i18n.TranslationFunc) string {
+	if len(moments) == 0 {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString("\n\n#### " + T("copilot.key_moments", "Key Moments") + "\n\n")
+	for _, moment := range moments {
+		fmt.Fprintf(&result, "- [%s](%s) %s\n",
+			subtitles.FormatLLMTimestamp(moment.Start),
+			recordingLink(siteURL, recordingFileID, moment.Start),
+			moment.Description,
+		)
+	}
+	return result.String()
+}
+
+// keyMoments returns the formatted key moments of the transcription. Key moments are optional
+// so failures are logged and result in no key moments.
+func (s *Service) keyMoments(bot *bots.Bot, transcription *subtitles.Subtitles, context *llm.Context, recordingFileID string, T i18n.TranslationFunc) string {
+	if recordingFileID == "" {
+		return ""
+	}
+
+	moments, err := s.GenerateKeyMoments(bot, transcription, context)
+	if err != nil {
+		s.pluginAPI.Log.Warn("Unable to find key moments", "error", err)
+		return ""
+	}
+
+	siteURL := s.pluginAPI.Configuration.GetConfig().ServiceSettings.SiteURL
+	if siteURL == nil {
+		return ""
+	}
+	return formatKeyMoments(moments, *siteURL, recordingFileID, T)
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeyMoments(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   string
+		duration time.Duration
+		want     []KeyMoment
+		wantErr  bool
+	}{
+		{
+			name:     "valid moments",
+			result:   `{"moments": [{"description": "Launch date agreed", "time": "05:10"}, {"description": "Budget approved", "time": "01:02:03"}]}`,
+			duration: 2 * time.Hour,
+			want: []KeyMoment{
+				{Description: "Launch date agreed", Start: 5*time.Minute + 10*time.Second},
+				{Description: "Budget approved", Start: time.Hour + 2*time.Minute + 3*time.Second},
+			},
+		},
+		{
+			name:     "markdown code block",
+			result:   "```json\n{\"moments\": [{\"description\": \"Launch date agreed\", \"time\": \"00:30\"}]}\n```",
+			duration: time.Hour,
+			want:     []KeyMoment{{Description: "Launch date agreed", Start: 30 * time.Second}},
+		},
+		{
+			name:     "skips invalid moments",
+			result:   `{"moments": [{"description": " ", "time": "00:10"}, {"description": "Bad", "time": "later"}, {"description": "Late", "time": "02:00:00"}, {"description": "Kept\nhere", "time": "01:00"}]}`,
+			duration: time.Hour,
+			want:     []KeyMoment{{Description: "Kept here", Start: time.Minute}},
+		},
+		{
+			name:     "not json",
+			result:   "The key moments are",
+			duration: time.Hour,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseKeyMoments(tc.result, tc.duration)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestFormatKeyMoments(t *testing.T) {
+	T := i18n.LocalizerFunc(i18n.Init(), "en")
+
+	t.Run("no moments", func(t *testing.T) {
+		assert.Empty(t, formatKeyMoments(nil, "https://chat.example.com", "file1", T))
+	})
+
+	t.Run("links to the recording", func(t *testing.T) {
+		moments := []KeyMoment{
+			{Description: "Launch date agreed", Start: 5*time.Minute + 10*time.Second},
+			{Description: "Budget approved", Start: time.Hour + 2*time.Minute + 3*time.Second},
+		}
+
+		assert.Equal(t, "\n\n#### Key Moments\n\n"+
+			"- [05:10](https://chat.example.com/api/v4/files/file1#t=310) Launch date agreed\n"+
+			"- [01:02:03](https://chat.example.com/api/v4/files/file1#t=3723) Budget approved\n",
+			formatKeyMoments(moments, "https://chat.example.com/", "file1", T))
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// SetEmbeddingSearch sets the search meeting summaries and transcripts are indexed in.
+// Meetings aren't indexed without one.
+func (s *Service) SetEmbeddingSearch(search embeddings.EmbeddingSearch) {
+	s.embeddingSearch = search
+}
+
+// indexMeeting adds the summary and transcript of a call to the meetings search corpus. The documents
+// reference the call post, so only members of its channel find them and they are removed with the post.
+// Meetings in channels excluded from search aren't indexed.
+// Indexing is best effort so failures are logged rather than failing the summary.
+func (s *Service) indexMeeting(channel *model.Channel, callPost *model.Post, transcription *subtitles.Subtitles, summary string) {
+	if s.embeddingSearch == nil {
+		return
+	}
+	if s.config.EmbeddingSearchConfig().Exclusions.Excludes(channel.TeamId, channel.Id, channel.Name) {
+		return
+	}
+
+	meeting := &embeddings.MeetingMetadata{}
+	participants, err := s.callParticipants(callPost)
+	if err != nil {
+		s.pluginAPI.Log.Warn("Unable to get call participants for meeting search", "error", err)
+	}
+	for _, participant := range participants {
+		meeting.Participants = append(meeting.Participants, participant.Username)
+	}
+
+	sources := []struct {
+		sourceType string
+		content    string
+	}{
+		{sourceType: embeddings.SourceTypeMeetingSummary, content: summary},
+		{sourceType: embeddings.SourceTypeMeetingTranscript, content: transcription.FormatForLLM()},
+	}
+	for _, source := range sources {
+		if strings.TrimSpace(source.content) == "" {
+			continue
+		}
+
+		doc := embeddings.PostDocument{
+			PostID:     callPost.Id,
+			CreateAt:   callPost.CreateAt,
+			TeamID:     channel.TeamId,
+			ChannelID:  callPost.ChannelId,
+			UserID:     callPost.UserId,
+			Content:    source.content,
+			SourceType: source.sourceType,
+			Meeting:    meeting,
+		}
+		if err := s.embeddingSearch.Store(context.Background(), []embeddings.PostDocument{doc}); err != nil {
+			s.pluginAPI.Log.Warn("Unable to index meeting for search", "error", err, "source_type", source.sourceType)
+		}
+	}
+}
@@ -7,9 +7,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
-	"os/exec"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 
@@ -28,6 +29,14 @@ const (
 	ContextTokenMargin = 1000
 	WhisperAPILimit    = 25 * 1000 * 1000 // 25 MB
 
+	// maxConcurrentTranscriptions is how many recordings of a meeting are transcribed at once
+	maxConcurrentTranscriptions = 3
+
+	// maxConcurrentChunkSummaries is how many chunks of a long transcript are summarized at once
+	maxConcurrentChunkSummaries = 4
+
+	// maxReduceLevels bounds how many times chunk summaries are summarized again to fit the context window
+	maxReduceLevels = 4
 )
 
 func GetCaptionsFileIDFromProps(post *model.Post) (fileID string, err error) {
@@ -50,70 +59,144 @@ func GetCaptionsFileIDFromProps(post *model.Post) (fileID string, err error) {
 	return captions[0].(map[string]interface{})["file_id"].(string), nil
 }
 
-// GetCaptionsFileIDFromProps is a wrapper method to make the function available via the Service
-func (s *Service) GetCaptionsFileIDFromProps(post *model.Post) (fileID string, err error) {
-	return GetCaptionsFileIDFromProps(post)
-}
+// createTranscription transcribes a recording in parts small enough for the transcription backend
+// and joins the parts into a single timeline, so long recordings are transcribed in full.
+// The language is detected by the backend when empty. When toEnglish is set and the backend supports it,
+// the recording is transcribed straight into English. The backend is told the terms of the vocabulary when it supports it.
+// The duration of the recording is returned with the transcription. Canceling the context stops the
+// conversion and the transcription before the next part.
+func (s *Service) createTranscription(ctx context.Context, recordingFileID string, language string, toEnglish bool, vocabulary []string, progress recordingProgress) (*subtitles.Subtitles, time.Duration, error) {
+	transcriber := s.bots.GetTranscribe()
+	if transcriber == nil {
+		return nil, 0, errors.New("no transcription backend configured")
+	}
+	if vocabularyTranscriber, ok := transcriber.(bots.VocabularyTranscriber); ok && len(vocabulary) > 0 {
+		vocabularyTranscriber.SetVocabulary(vocabulary)
+	}
 
-func (s *Service) createTranscription(recordingFileID string) (*subtitles.Subtitles, error) {
-	if s.ffmpegPath == "" {
-		return nil, errors.New("ffmpeg not installed")
+	fileReader, err := s.pluginAPI.File.Get(recordingFileID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to read calls file: %w", err)
 	}
 
-	recordingFileInfo, err := s.pluginAPI.File.GetInfo(recordingFileID)
+	dir, err := os.MkdirTemp("", "transcription")
 	if err != nil {
-		return nil, fmt.Errorf("unable to get calls file info: %w", err)
+		return nil, 0, fmt.Errorf("unable to create directory for recording parts: %w", err)
 	}
+	defer os.RemoveAll(dir)
 
-	fileReader, err := s.pluginAPI.File.Get(recordingFileID)
+	segments, err := s.splitRecording(ctx, fileReader, dir, progress.converting)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read calls file: %w", err)
+		return nil, 0, err
 	}
+	progress.transcribed(0, len(segments))
+
+	transcription := subtitles.NewSubtitlesFromSegments(nil)
+	for i, segment := range segments {
+		part, err := s.transcribeSegment(ctx, transcriber, segment, language, toEnglish)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to transcribe part %d of %d: %w", i+1, len(segments), err)
+		}
+		progress.transcribed(i+1, len(segments))
+
+		// Speakers are told apart within each part only, so the labels of later parts are kept distinct
+		if i > 0 {
+			names := map[string]string{}
+			for _, speaker := range part.Speakers() {
+				names[speaker] = fmt.Sprintf("%s (part %d)", speaker, i+1)
+			}
+			part.RenameSpeakers(names)
+		}
 
-	var cmd *exec.Cmd
-	if recordingFileInfo.Size > WhisperAPILimit {
-		cmd = exec.Command(s.ffmpegPath, "-i", "pipe:0", "-ac", "1", "-map", "0:a:0", "-b:a", "32k", "-ar", "16000", "-f", "mp3", "pipe:1") //nolint:gosec
-	} else {
-		cmd = exec.Command(s.ffmpegPath, "-i", "pipe:0", "-f", "mp3", "pipe:1") //nolint:gosec
+		transcription.Append(part, segment.Start)
 	}
 
-	cmd.Stdin = fileReader
+	return transcription, segments[len(segments)-1].End, nil
+}
 
-	audioReader, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("couldn't create stdout pipe: %w", err)
+// createTranscriptions transcribes the recordings of a meeting concurrently and joins them into a single
+// timeline, each recording starting where the previous one ends. The progress is reported as the recordings
+// are converted and their parts transcribed.
+func (s *Service) createTranscriptions(ctx context.Context, recordingFileIDs []string, language string, toEnglish bool, vocabulary []string, progress *transcriptionProgress) (*subtitles.Subtitles, error) {
+	transcriptions := make([]*subtitles.Subtitles, len(recordingFileIDs))
+	durations := make([]time.Duration, len(recordingFileIDs))
+	errs := make([]error, len(recordingFileIDs))
+
+	// Each transcription runs ffmpeg and uploads to the transcription backend, so only a few run at once
+	limit := make(chan struct{}, maxConcurrentTranscriptions)
+	var wg sync.WaitGroup
+	for i, recordingFileID := range recordingFileIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case limit <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-limit }()
+			transcriptions[i], durations[i], errs[i] = s.createTranscription(ctx, recordingFileID, language, toEnglish, vocabulary, progress.recording(i))
+		}()
 	}
+	wg.Wait()
+	progress.finish()
 
-	errorReader, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, fmt.Errorf("couldn't create stderr pipe: %w", err)
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("unable to transcribe recording %d of %d: %w", i+1, len(recordingFileIDs), err)
+		}
 	}
 
-	if err = cmd.Start(); err != nil {
-		return nil, fmt.Errorf("couldn't run ffmpeg: %w", err)
+	if len(transcriptions) == 1 {
+		return transcriptions[0], nil
 	}
 
-	transcriber := s.bots.GetTranscribe()
-	// Limit reader should probably error out instead of just silently failing
-	transcription, err := transcriber.Transcribe(io.LimitReader(audioReader, WhisperAPILimit))
-	if err != nil {
-		return nil, fmt.Errorf("unable to transcribe: %w", err)
+	transcription := subtitles.NewSubtitlesFromSegments(nil)
+	var offset time.Duration
+	for i, part := range transcriptions {
+		// Speakers are told apart within each recording only, so the labels of later recordings are kept distinct
+		if i > 0 {
+			names := map[string]string{}
+			for _, speaker := range part.Speakers() {
+				names[speaker] = fmt.Sprintf("%s (recording %d)", speaker, i+1)
+			}
+			part.RenameSpeakers(names)
+		}
+
+		transcription.Append(part, offset)
+		offset += max(durations[i], part.Duration())
+	}
+
+	return transcription, nil
+}
+
+func (s *Service) transcribeSegment(ctx context.Context, transcriber bots.Transcriber, segment recordingSegment, language string, toEnglish bool) (*subtitles.Subtitles, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	errout, err := io.ReadAll(errorReader)
+	file, err := os.Open(segment.Path)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read stderr from ffmpeg: %w", err)
+		return nil, fmt.Errorf("unable to open recording part: %w", err)
 	}
+	defer file.Close()
 
-	if err := cmd.Wait(); err != nil {
-		s.pluginAPI.Log.Debug("ffmpeg stderr: " + string(errout))
-		return nil, fmt.Errorf("error while waiting for ffmpeg: %w", err)
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get recording part size: %w", err)
+	}
+	if info.Size() > WhisperAPILimit {
+		return nil, fmt.Errorf("recording part of %d bytes is larger than the transcription limit", info.Size())
 	}
 
-	return transcription, nil
+	if englishTranscriber, ok := transcriber.(bots.EnglishTranscriber); ok && toEnglish {
+		return englishTranscriber.TranscribeToEnglish(file)
+	}
+	return transcriber.Transcribe(file, language)
 }
 
-func (s *Service) newCallRecordingThread(bot *bots.Bot, requestingUser *model.User, recordingPost *model.Post, channel *model.Channel, fileID string) (*model.Post, error) {
+func (s *Service) newCallRecordingThread(bot *bots.Bot, requestingUser *model.User, recordingPost *model.Post, channel *model.Channel, fileIDs []string, language string, translate bool, format string) (*model.Post, error) {
 	siteURL := s.pluginAPI.Configuration.GetConfig().ServiceSettings.SiteURL
 	T := i18n.LocalizerFunc(s.i18n, requestingUser.Locale)
 	surePost := &model.Post{
@@ -124,16 +207,16 @@ func (s *Service) newCallRecordingThread(bot *bots.Bot, requestingUser *model.Us
 		return nil, err
 	}
 
-	if err := s.summarizeCallRecording(bot, surePost.Id, requestingUser, fileID, channel); err != nil {
+	if err := s.summarizeCallRecording(bot, surePost.Id, requestingUser, recordingPost, fileIDs, channel, language, translate, format); err != nil {
 		return nil, err
 	}
 
 	return surePost, nil
 }
 
-func (s *Service) newCallTranscriptionSummaryThread(bot *bots.Bot, requestingUser *model.User, transcriptionPost *model.Post, channel *model.Channel) (*model.Post, error) {
-	if len(transcriptionPost.FileIds) != 1 {
-		return nil, errors.New("unexpected number of files in calls post")
+func (s *Service) newCallTranscriptionSummaryThread(bot *bots.Bot, requestingUser *model.User, transcriptionPost *model.Post, channel *model.Channel, translate bool, format string, timeRange subtitles.TimeRange) (*model.Post, error) {
+	if _, _, err := transcriptFileIDs(transcriptionPost); err != nil {
+		return nil, err
 	}
 
 	siteURL := s.pluginAPI.Configuration.GetConfig().ServiceSettings.SiteURL
@@ -147,179 +230,286 @@ func (s *Service) newCallTranscriptionSummaryThread(bot *bots.Bot, requestingUse
 		return nil, err
 	}
 
-	go func() (reterr error) {
-		// Update to an error if we return one.
-		defer func() {
-			if reterr != nil {
-				surePost.Message = T("copilot.summairize_subscription_error", "Sorry! Something went wrong. Check the server logs for details.")
-				if err := s.pluginAPI.Post.UpdatePost(surePost); err != nil {
-					s.pluginAPI.Log.Error("Failed to update post in error handling newCallTranscriptionSummaryThread", "error", err)
-				}
-				s.pluginAPI.Log.Error("Error in call recording post", "error", reterr)
-			}
-		}()
+	payload := summarizeTranscriptionJob{
+		BotID:               bot.GetMMBot().UserId,
+		TranscriptionPostID: transcriptionPost.Id,
+		ChannelID:           channel.Id,
+		Translate:           translate,
+		Format:              format,
+	}
+	if !timeRange.IsZero() {
+		payload.TimeRange = timeRange.String()
+	}
+	if _, err := s.jobQueue.Enqueue(JobTypeSummarizeTranscription, requestingUser.Id, surePost.Id, payload); err != nil {
+		return nil, fmt.Errorf("unable to queue transcription summary: %w", err)
+	}
 
-		transcriptionFileID, err := GetCaptionsFileIDFromProps(transcriptionPost)
-		if err != nil {
-			return fmt.Errorf("unable to get transcription file id: %w", err)
-		}
-		transcriptionFileInfo, err := s.pluginAPI.File.GetInfo(transcriptionFileID)
-		if err != nil {
-			return fmt.Errorf("unable to get transcription file info: %w", err)
-		}
-		transcriptionFilePost, err := s.pluginAPI.Post.GetPost(transcriptionFileInfo.PostId)
-		if err != nil {
-			return fmt.Errorf("unable to get transcription file post: %w", err)
-		}
-		if transcriptionFilePost.ChannelId != channel.Id {
-			return errors.New("strange configuration of calls transcription file")
-		}
-		transcriptionFileReader, err := s.pluginAPI.File.Get(transcriptionFileID)
-		if err != nil {
-			return fmt.Errorf("unable to read calls file: %w", err)
-		}
+	return surePost, nil
+}
 
-		var text *subtitles.Subtitles
-		if transcriptionFilePost.Type == "custom_zoom_chat" {
-			text, err = subtitles.NewSubtitlesFromZoomChat(transcriptionFileReader)
-			if err != nil {
-				return fmt.Errorf("unable to parse transcription file: %w", err)
-			}
-		} else {
-			text, err = subtitles.NewSubtitlesFromVTT(transcriptionFileReader)
-			if err != nil {
-				return fmt.Errorf("unable to parse transcription file: %w", err)
-			}
+// summarizeTranscriptionPost summarizes the transcript of the transcription post in a reply to surePost.
+// A reply left by an interrupted attempt is replaced.
+func (s *Service) summarizeTranscriptionPost(ctx context.Context, bot *bots.Bot, requestingUser *model.User, transcriptionPost *model.Post, channel *model.Channel, surePost *model.Post, translate bool, format string, timeRange subtitles.TimeRange) error {
+	if err := s.deletePartialSummaries(surePost, bot.GetMMBot().UserId); err != nil {
+		return err
+	}
+
+	transcriptionFileID, recordingFileID, err := transcriptFileIDs(transcriptionPost)
+	if err != nil {
+		return fmt.Errorf("unable to get transcription file id: %w", err)
+	}
+	transcriptionFileInfo, err := s.pluginAPI.File.GetInfo(transcriptionFileID)
+	if err != nil {
+		return fmt.Errorf("unable to get transcription file info: %w", err)
+	}
+	transcriptionFilePost, err := s.pluginAPI.Post.GetPost(transcriptionFileInfo.PostId)
+	if err != nil {
+		return fmt.Errorf("unable to get transcription file post: %w", err)
+	}
+	if transcriptionFilePost.ChannelId != channel.Id {
+		return errors.New("strange configuration of calls transcription file")
+	}
+	transcriptionFileReader, err := s.pluginAPI.File.Get(transcriptionFileID)
+	if err != nil {
+		return fmt.Errorf("unable to read calls file: %w", err)
+	}
+
+	text, err := subtitles.NewSubtitlesFromTranscript(transcriptionFileReader)
+	if err != nil {
+		return fmt.Errorf("unable to parse transcription file: %w", err)
+	}
+	if !timeRange.IsZero() {
+		timeRange = timeRange.Resolve(text.Duration())
+		text = text.Slice(timeRange)
+		if text.IsEmpty() {
+			return fmt.Errorf("nothing was said between %s", timeRange)
 		}
+	}
 
-		requestContext := s.contextBuilder.BuildLLMContextUserRequest(
-			bot,
-			requestingUser,
-			channel,
-			s.contextBuilder.WithLLMContextDefaultTools(bot, mmapi.IsDMWith(bot.GetMMBot().UserId, channel)),
-		)
-		summaryStream, err := s.SummarizeTranscription(bot, text, requestContext)
+	requestContext := s.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		requestingUser,
+		channel,
+		s.contextBuilder.WithLLMContextDefaultTools(bot, mmapi.IsDMWith(bot.GetMMBot().UserId, channel)),
+	)
+	if translate {
+		text, err = s.TranslateTranscription(bot, text, requesterLocale(requestingUser), requestContext)
 		if err != nil {
-			return fmt.Errorf("unable to summarize transcription: %w", err)
+			return fmt.Errorf("unable to translate transcription: %w", err)
 		}
+	}
 
-		summaryPost := &model.Post{
-			RootId:    surePost.Id,
-			ChannelId: surePost.ChannelId,
-			Message:   "",
-		}
-		summaryPost.AddProp(ReferencedTranscriptPostID, transcriptionPost.Id)
-		if err := s.streamingService.StreamToNewPost(context.Background(), bot.GetMMBot().UserId, requestingUser.Id, summaryStream, summaryPost, transcriptionPost.Id); err != nil {
-			return fmt.Errorf("unable to stream result to post: %w", err)
-		}
+	summaryPost := &model.Post{
+		RootId:    surePost.Id,
+		ChannelId: surePost.ChannelId,
+		Message:   "",
+	}
+	summaryPost.AddProp(ReferencedTranscriptPostID, transcriptionPost.Id)
+	summaryPost.AddProp(SummaryFormatProp, format)
+	if !timeRange.IsZero() {
+		summaryPost.AddProp(TranscriptRangeProp, timeRange.String())
+	}
+	if translate {
+		summaryPost.AddProp(TranslatedToProp, text.Language())
+	}
+	// Chapters are generated before the summary starts streaming, which would otherwise wait on them
+	s.addChapters(bot, text, requestContext, summaryPost)
 
-		return nil
-	}() //nolint:errcheck
+	summaryStream, err := s.SummarizeTranscription(bot, text, requestContext, format, recordingFileID)
+	if err != nil {
+		return fmt.Errorf("unable to summarize transcription: %w", err)
+	}
 
-	return surePost, nil
+	// Streamed here rather than with StreamToNewPost so the action items are extracted from the finished summary
+	streaming.ModifyPostForBot(bot.GetMMBot().UserId, requestingUser.Id, summaryPost, transcriptionPost.Id)
+	if err := s.pluginAPI.Post.CreatePost(summaryPost); err != nil {
+		return fmt.Errorf("unable to create summary post: %w", err)
+	}
+	streamCtx, err := s.streamingService.GetStreamingContext(ctx, summaryPost.Id)
+	if err != nil {
+		return fmt.Errorf("unable to get post streaming context: %w", err)
+	}
+	defer s.streamingService.FinishStreaming(summaryPost.Id)
+
+	s.streamingService.StreamToPost(streamCtx, summaryStream, summaryPost, requestingUser.Locale)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if streamCtx.Err() == nil {
+		s.postActionItems(bot, requestingUser, channel, transcriptionPost, summaryPost)
+		s.indexMeeting(channel, transcriptionPost, text, summaryPost.Message)
+	}
+
+	return nil
 }
 
-func (s *Service) summarizeCallRecording(bot *bots.Bot, rootID string, requestingUser *model.User, recordingFileID string, channel *model.Channel) error {
+// summarizeCallRecording transcribes the recordings in the language given, falling back to the language
+// configured for the channel and detecting it when neither is set. Recordings split into several files
+// are summarized together, in the order given. When translate is set the transcript is translated into
+// the requester's locale before it is summarized. The summary is written with the template of the format.
+// The work is queued so it survives restarts of the plugin.
+func (s *Service) summarizeCallRecording(bot *bots.Bot, rootID string, requestingUser *model.User, recordingPost *model.Post, recordingFileIDs []string, channel *model.Channel, language string, translate bool, format string) error {
 	T := i18n.LocalizerFunc(s.i18n, requestingUser.Locale)
+	if language == "" {
+		language = s.bots.TranscriptionLanguage(channel.Id)
+	}
 
 	transcriptPost := &model.Post{
 		RootId:  rootID,
 		Message: T("copilot.summarize_call_recording_processing", "Processing audio into transcription. This will take some time..."),
 	}
-	transcriptPost.AddProp(ReferencedRecordingFileID, recordingFileID)
+	transcriptPost.AddProp(ReferencedRecordingFileID, recordingFileIDs[0])
+	if len(recordingFileIDs) > 1 {
+		transcriptPost.AddProp(ReferencedRecordingFileIDs, recordingFileIDs)
+	}
+	transcriptPost.AddProp(SummaryFormatProp, format)
 	if err := s.botDMNonResponse(bot.GetMMBot().UserId, requestingUser.Id, transcriptPost); err != nil {
 		return err
 	}
 
-	go func() (reterr error) {
-		// Update to an error if we return one.
-		defer func() {
-			if reterr != nil {
-				transcriptPost.Message = T("copilot.summarize_call_recording_processing_error", "Sorry! Something went wrong. Check the server logs for details.")
-				if err := s.pluginAPI.Post.UpdatePost(transcriptPost); err != nil {
-					s.pluginAPI.Log.Error("Failed to update post in error handling handleCallRecordingPost", "error", err)
-				}
-				s.pluginAPI.Log.Error("Error in call recording post", "error", reterr)
-			}
-		}()
+	payload := summarizeRecordingJob{
+		BotID:            bot.GetMMBot().UserId,
+		RecordingPostID:  recordingPost.Id,
+		RecordingFileIDs: recordingFileIDs,
+		ChannelID:        channel.Id,
+		Language:         language,
+		Translate:        translate,
+		Format:           format,
+	}
+	if _, err := s.jobQueue.Enqueue(JobTypeSummarizeRecording, requestingUser.Id, transcriptPost.Id, payload); err != nil {
+		return fmt.Errorf("unable to queue recording summary: %w", err)
+	}
 
-		transcription, err := s.createTranscription(recordingFileID)
-		if err != nil {
+	return nil
+}
+
+// transcribeAndSummarizeRecording transcribes the recordings and streams the summary into transcriptPost,
+// which gets the transcripts attached. Everything is done again when an interrupted attempt is resumed.
+func (s *Service) transcribeAndSummarizeRecording(ctx context.Context, bot *bots.Bot, requestingUser *model.User, recordingPost *model.Post, recordingFileIDs []string, channel *model.Channel, transcriptPost *model.Post, language string, translate bool, format string) error {
+	// Whisper translates into English while transcribing, saving a pass over the transcript
+	locale := requesterLocale(requestingUser)
+	vocabulary := s.bots.TranscriptionVocabulary(channel.Id, channel.TeamId)
+	progress := s.newTranscriptionProgress(transcriptPost, requestingUser, len(recordingFileIDs))
+	transcription, err := s.createTranscriptions(ctx, recordingFileIDs, language, translate && sameLanguage(locale, "en"), vocabulary, progress)
+	transcriptPost.DelProp(TranscriptionProgressProp)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if err != nil {
+		// The captions and chat of the call still give an idea of what the meeting was about
+		fallback := s.fallbackTranscript(recordingPost)
+		if fallback.IsEmpty() {
 			return fmt.Errorf("failed to create transcription: %w", err)
 		}
-
-		transcriptFileInfo, err := s.pluginAPI.File.Upload(strings.NewReader(transcription.FormatVTT()), "transcript.txt", channel.Id)
-		if err != nil {
-			return fmt.Errorf("unable to upload transcript: %w", err)
+		s.pluginAPI.Log.Warn("Unable to transcribe the recording, summarizing the captions and chat of the call instead", "error", err)
+		if fallbackErr := s.summarizeFallback(ctx, bot, requestingUser, recordingPost, channel, transcriptPost, fallback, format); fallbackErr != nil {
+			return fmt.Errorf("failed to create transcription: %w, and to summarize the captions and chat: %w", err, fallbackErr)
 		}
+		return nil
+	}
+	if transcription.Language() == "" {
+		transcription.SetLanguage(language)
+	}
+	if transcription.Language() != "" {
+		transcriptPost.AddProp(TranscriptionLanguageProp, transcription.Language())
+	}
 
-		llmContext := s.contextBuilder.BuildLLMContextUserRequest(
-			bot,
-			requestingUser,
-			channel,
-			s.contextBuilder.WithLLMContextDefaultTools(bot, channel.Type == model.ChannelTypeDirect),
-		)
-		summaryStream, err := s.SummarizeTranscription(bot, transcription, llmContext)
-		if err != nil {
-			return fmt.Errorf("unable to summarize transcription: %w", err)
+	llmContext := s.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		requestingUser,
+		channel,
+		s.contextBuilder.WithLLMContextDefaultTools(bot, channel.Type == model.ChannelTypeDirect),
+	)
+
+	s.identifyCallSpeakers(bot, transcription, recordingPost, requestingUser, channel)
+	originalTranscript := transcription.FormatVTT()
+
+	// The transcript that is summarized comes first, the original is kept next to a translation
+	var transcriptFileInfos []*model.FileInfo
+	if translate {
+		translated, translateErr := s.TranslateTranscription(bot, transcription, locale, llmContext)
+		if translateErr != nil {
+			return fmt.Errorf("unable to translate transcription: %w", translateErr)
 		}
-
-		if err = s.updatePostWithFile(transcriptPost, transcriptFileInfo); err != nil {
-			return fmt.Errorf("unable to update transcript post: %w", err)
+		if translated != transcription {
+			translatedFileInfo, uploadErr := s.pluginAPI.File.Upload(strings.NewReader(translated.FormatVTT()), "transcript.txt", channel.Id)
+			if uploadErr != nil {
+				return fmt.Errorf("unable to upload translated transcript: %w", uploadErr)
+			}
+			transcriptFileInfos = append(transcriptFileInfos, translatedFileInfo)
 		}
+		transcriptPost.AddProp(TranslatedToProp, translated.Language())
+		transcription = translated
+	}
 
-		ctx, err := s.streamingService.GetStreamingContext(context.Background(), transcriptPost.Id)
-		if err != nil {
-			return fmt.Errorf("unable to get post streaming context: %w", err)
-		}
-		defer s.streamingService.FinishStreaming(transcriptPost.Id)
+	originalFileName := "transcript.txt"
+	if len(transcriptFileInfos) > 0 {
+		originalFileName = "transcript_original.txt"
+	}
+	originalFileInfo, err := s.pluginAPI.File.Upload(strings.NewReader(originalTranscript), originalFileName, channel.Id)
+	if err != nil {
+		return fmt.Errorf("unable to upload transcript: %w", err)
+	}
+	transcriptFileInfos = append(transcriptFileInfos, originalFileInfo)
 
-		s.streamingService.StreamToPost(ctx, summaryStream, transcriptPost, requestingUser.Locale)
+	// Key moments link into the recording, which is only possible when there is a single one
+	linkedRecordingFileID := ""
+	if len(recordingFileIDs) == 1 {
+		linkedRecordingFileID = recordingFileIDs[0]
+	}
+	// Chapters are generated before the summary starts streaming, which would otherwise wait on them
+	s.addChapters(bot, transcription, llmContext, transcriptPost)
 
-		return nil
-	}() //nolint:errcheck
+	summaryStream, err := s.SummarizeTranscription(bot, transcription, llmContext, format, linkedRecordingFileID)
+	if err != nil {
+		return fmt.Errorf("unable to summarize transcription: %w", err)
+	}
+
+	if err = s.updatePostWithFiles(transcriptPost, transcriptFileInfos...); err != nil {
+		return fmt.Errorf("unable to update transcript post: %w", err)
+	}
+
+	streamCtx, err := s.streamingService.GetStreamingContext(ctx, transcriptPost.Id)
+	if err != nil {
+		return fmt.Errorf("unable to get post streaming context: %w", err)
+	}
+	defer s.streamingService.FinishStreaming(transcriptPost.Id)
+
+	s.streamingService.StreamToPost(streamCtx, summaryStream, transcriptPost, requestingUser.Locale)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if streamCtx.Err() == nil {
+		s.postActionItems(bot, requestingUser, channel, recordingPost, transcriptPost)
+		s.indexMeeting(channel, recordingPost, transcription, transcriptPost.Message)
+	}
 
 	return nil
 }
 
-func (s *Service) SummarizeTranscription(bot *bots.Bot, transcription *subtitles.Subtitles, context *llm.Context) (*llm.TextStreamResult, error) {
+// SummarizeTranscription streams a summary of the transcription with the template of the format, see ParseSummaryFormat.
+// When the recording file is given, the summary lists key moments linking to the recording.
+func (s *Service) SummarizeTranscription(bot *bots.Bot, transcription *subtitles.Subtitles, context *llm.Context, format string, recordingFileID string) (*llm.TextStreamResult, error) {
 	llmFormattedTranscription := transcription.FormatForLLM()
 	tokens := bot.LLM().CountTokens(llmFormattedTranscription)
 	tokenLimitWithMargin := int(float64(bot.LLM().InputTokenLimit())*0.75) - ContextTokenMargin
 	if tokenLimitWithMargin < 0 {
 		tokenLimitWithMargin = ContextTokenMargin / 2
 	}
+	hasSpeakers := fmt.Sprintf("%t", len(transcription.Speakers()) > 0)
 	isChunked := false
 	if tokens > tokenLimitWithMargin {
 		s.pluginAPI.Log.Debug("Transcription too long, summarizing in chunks.", "tokens", tokens, "limit", tokenLimitWithMargin)
 		chunks := chunking.SplitPlaintextOnSentences(llmFormattedTranscription, tokenLimitWithMargin*4)
-		summarizedChunks := make([]string, 0, len(chunks))
 		s.pluginAPI.Log.Debug("Split into chunks", "chunks", len(chunks))
-		for _, chunk := range chunks {
-			systemPrompt, err := s.prompts.Format(prompts.PromptSummarizeChunkSystem, context)
-			if err != nil {
-				return nil, fmt.Errorf("unable to get summarize chunk prompt: %w", err)
-			}
-			request := llm.CompletionRequest{
-				Posts: []llm.Post{
-					{
-						Role:    llm.PostRoleSystem,
-						Message: systemPrompt,
-					},
-					{
-						Role:    llm.PostRoleUser,
-						Message: chunk,
-					},
-				},
-				Context: context,
-			}
-
-			summarizedChunk, err := bot.LLM().ChatCompletionNoStream(request)
-			if err != nil {
-				return nil, fmt.Errorf("unable to get summarized chunk: %w", err)
-			}
-
-			summarizedChunks = append(summarizedChunks, summarizedChunk)
+		context.Parameters = map[string]any{"IsChunked": "true", "HasSpeakers": hasSpeakers, "Language": transcription.Language()}
+		summarizedChunks, err := s.summarizeChunks(bot, prompts.PromptSummarizeChunkSystem, chunks, context)
+		if err != nil {
+			return nil, err
+		}
+		summarizedChunks, err = s.reduceChunkSummaries(bot, summarizedChunks, context, tokenLimitWithMargin)
+		if err != nil {
+			return nil, err
 		}
 
 		llmFormattedTranscription = strings.Join(summarizedChunks, "\n\n")
@@ -327,12 +517,25 @@ func (s *Service) SummarizeTranscription(bot *bots.Bot, transcription *subtitles
 		s.pluginAPI.Log.Debug("Completed chunk summarization", "chunks", len(summarizedChunks), "tokens", bot.LLM().CountTokens(llmFormattedTranscription))
 	}
 
-	context.Parameters = map[string]any{"IsChunked": fmt.Sprintf("%t", isChunked)}
-	systemPrompt, err := s.prompts.Format(prompts.PromptMeetingSummarySystem, context)
+	template := s.summaryTemplate(format)
+	context.Parameters = map[string]any{
+		"IsChunked":    fmt.Sprintf("%t", isChunked),
+		"HasSpeakers":  hasSpeakers,
+		"Language":     transcription.Language(),
+		"Instructions": template.instructions,
+	}
+	systemPrompt, err := s.prompts.Format(template.prompt, context)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get meeting summary prompt: %w", err)
 	}
 
+	locale := ""
+	if context.RequestingUser != nil {
+		locale = context.RequestingUser.Locale
+	}
+	T := i18n.LocalizerFunc(s.i18n, locale)
+	moments := s.keyMoments(bot, transcription, context, recordingFileID, T)
+
 	completionRequest := llm.CompletionRequest{
 		Posts: []llm.Post{
 			{
@@ -352,27 +555,158 @@ func (s *Service) SummarizeTranscription(bot *bots.Bot, transcription *subtitles
 		return nil, fmt.Errorf("unable to get meeting summary: %w", err)
 	}
 
-	return summaryStream, nil
+	participation := formatParticipation(transcription.SpeakerStats(), T)
+
+	return summaryStream.Append(moments + participation), nil
 }
 
-func (s *Service) updatePostWithFile(post *model.Post, fileinfo *model.FileInfo) error {
+// summarizeChunks summarizes the chunks of a long transcript concurrently with the prompt, a few at a time so
+// the LLM provider doesn't rate limit the requests. The summaries are returned in the order of the chunks.
+func (s *Service) summarizeChunks(bot *bots.Bot, promptName string, chunks []string, context *llm.Context) ([]string, error) {
+	systemPrompt, err := s.prompts.Format(promptName, context)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get summarize chunk prompt: %w", err)
+	}
+
+	summarizedChunks := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+	limit := make(chan struct{}, maxConcurrentChunkSummaries)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			request := llm.CompletionRequest{
+				Posts: []llm.Post{
+					{
+						Role:    llm.PostRoleSystem,
+						Message: systemPrompt,
+					},
+					{
+						Role:    llm.PostRoleUser,
+						Message: chunk,
+					},
+				},
+				Context: context,
+			}
+			summarizedChunks[i], errs[i] = bot.LLM().ChatCompletionNoStream(request, llm.WithFeature(llm.FeatureChunkSummaries))
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("unable to get summarized chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+	}
+	return summarizedChunks, nil
+}
+
+// reduceChunkSummaries summarizes groups of consecutive chunk summaries again until they fit the token limit
+// together, so transcripts of meetings lasting several hours can be summarized. Each level summarizes groups
+// that fit the limit, the summaries are returned as they are once they fit or after maxReduceLevels.
+func (s *Service) reduceChunkSummaries(bot *bots.Bot, summaries []string, context *llm.Context, tokenLimit int) ([]string, error) {
+	for level := 1; level <= maxReduceLevels; level++ {
+		if bot.LLM().CountTokens(strings.Join(summaries, "\n\n")) <= tokenLimit {
+			return summaries, nil
+		}
+
+		groups := groupChunkSummaries(summaries, tokenLimit, bot.LLM().CountTokens)
+		s.pluginAPI.Log.Debug("Chunk summaries too long, summarizing them again", "level", level, "summaries", len(summaries), "groups", len(groups))
+		reduced, err := s.summarizeChunks(bot, prompts.PromptSummarizeChunkSummariesSystem, groups, context)
+		if err != nil {
+			return nil, fmt.Errorf("unable to summarize chunk summaries: %w", err)
+		}
+		summaries = reduced
+	}
+
+	return summaries, nil
+}
+
+// groupChunkSummaries joins consecutive summaries into groups that each fit the token limit. A summary
+// larger than the limit on its own is a group of its own.
+func groupChunkSummaries(summaries []string, tokenLimit int, countTokens func(string) int) []string {
+	var groups []string
+	var group []string
+	for _, summary := range summaries {
+		if len(group) > 0 && countTokens(strings.Join(append(group, summary), "\n\n")) > tokenLimit {
+			groups = append(groups, strings.Join(group, "\n\n"))
+			group = nil
+		}
+		group = append(group, summary)
+	}
+	if len(group) > 0 {
+		groups = append(groups, strings.Join(group, "\n\n"))
+	}
+	return groups
+}
+
+// addChapters adds the chapters of the transcription to the post. Chapters are optional
+// so failures are logged rather than failing the summary.
+func (s *Service) addChapters(bot *bots.Bot, transcription *subtitles.Subtitles, context *llm.Context, post *model.Post) {
+	chapters, err := s.GenerateChapters(bot, transcription, context)
+	if err != nil {
+		s.pluginAPI.Log.Warn("Unable to generate chapters", "error", err)
+		return
+	}
+	if len(chapters) == 0 {
+		return
+	}
+	post.AddProp(ChaptersProp, chapters)
+}
+
+// identifyCallSpeakers attributes a diarized transcription to the participants of the call, logging any failure
+// since the summary is still useful with anonymous speakers.
+func (s *Service) identifyCallSpeakers(bot *bots.Bot, transcription *subtitles.Subtitles, recordingPost *model.Post, requestingUser *model.User, channel *model.Channel) {
+	if len(transcription.Speakers()) == 0 {
+		return
+	}
+
+	participants, err := s.callParticipants(recordingPost)
+	if err != nil {
+		s.pluginAPI.Log.Warn("Unable to get call participants", "error", err)
+		return
+	}
+
+	// A separate context so the parameters don't leak into the summary prompts
+	speakersContext := s.contextBuilder.BuildLLMContextUserRequest(bot, requestingUser, channel)
+	if err := s.IdentifySpeakers(bot, transcription, participants, speakersContext); err != nil {
+		s.pluginAPI.Log.Warn("Unable to identify speakers", "error", err)
+	}
+}
+
+func (s *Service) updatePostWithFiles(post *model.Post, fileinfos ...*model.FileInfo) error {
+	fileIDs := make([]string, 0, len(fileinfos))
+	for _, fileinfo := range fileinfos {
+		if err := s.claimFile(post, fileinfo.Id); err != nil {
+			return err
+		}
+		fileIDs = append(fileIDs, fileinfo.Id)
+	}
+
+	post.FileIds = fileIDs
+	post.Message = ""
+	if err := s.pluginAPI.Post.UpdatePost(post); err != nil {
+		return fmt.Errorf("unable to update post: %w", err)
+	}
+
+	return nil
+}
+
+// claimFile attaches an uploaded file to the post, files already attached to a post are left alone.
+func (s *Service) claimFile(post *model.Post, fileID string) error {
 	if _, err := s.db.ExecBuilder(s.db.Builder().
 		Update("FileInfo").
 		Set("PostId", post.Id).
 		Set("ChannelId", post.ChannelId).
 		Where(sq.And{
-			sq.Eq{"Id": fileinfo.Id},
+			sq.Eq{"Id": fileID},
 			sq.Eq{"PostId": ""},
 		})); err != nil {
 		return fmt.Errorf("unable to update file info: %w", err)
 	}
-
-	post.FileIds = []string{fileinfo.Id}
-	post.Message = ""
-	if err := s.pluginAPI.Post.UpdatePost(post); err != nil {
-		return fmt.Errorf("unable to update post: %w", err)
-	}
-
 	return nil
 }
 
@@ -0,0 +1,57 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupChunkSummaries(t *testing.T) {
+	// Four characters per token, each summary is ten tokens
+	countTokens := func(text string) int {
+		return len(text) / 4
+	}
+	summary := func(c string) string {
+		return strings.Repeat(c, 40)
+	}
+
+	tests := []struct {
+		name       string
+		summaries  []string
+		tokenLimit int
+		want       []string
+	}{
+		{
+			name:       "all fit in one group",
+			summaries:  []string{summary("a"), summary("b")},
+			tokenLimit: 100,
+			want:       []string{summary("a") + "\n\n" + summary("b")},
+		},
+		{
+			name:       "consecutive summaries grouped up to the limit",
+			summaries:  []string{summary("a"), summary("b"), summary("c"), summary("d"), summary("e")},
+			tokenLimit: 21,
+			want: []string{
+				summary("a") + "\n\n" + summary("b"),
+				summary("c") + "\n\n" + summary("d"),
+				summary("e"),
+			},
+		},
+		{
+			name:       "summaries larger than the limit are groups of their own",
+			summaries:  []string{summary("a"), summary("b")},
+			tokenLimit: 5,
+			want:       []string{summary("a"), summary("b")},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, groupChunkSummaries(tc.summaries, tc.tokenLimit, countTokens))
+		})
+	}
+}
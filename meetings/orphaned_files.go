@@ -0,0 +1,91 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// OrphanedFilesCleanupInterval is how often transcripts left unattached are looked for
+	OrphanedFilesCleanupInterval = time.Hour
+
+	// uploadCreatorID is the creator of the files uploaded through the plugin API
+	uploadCreatorID = "nouser"
+
+	// orphanedFilesBatchSize limits the files deleted by a single cleanup
+	orphanedFilesBatchSize = 1000
+)
+
+// uploadedTranscriptNames are the names of the transcripts the plugin uploads before attaching them to a post
+var uploadedTranscriptNames = []string{"transcript.txt", "transcript_original.txt", editedTranscriptFileName}
+
+type orphanedFile struct {
+	ID   string
+	Size int64
+}
+
+// DeleteOrphanedFiles deletes the transcripts uploaded to the DMs of the bots more than olderThan ago that
+// were never attached to a post, because summarizing failed after they were uploaded. Files are deleted
+// the way Mattermost deletes the files of deleted posts, the storage is reclaimed when Mattermost purges
+// deleted files. The number and total size of the deleted files are returned.
+func (s *Service) DeleteOrphanedFiles(olderThan time.Duration) (int, int64, error) {
+	allBots := s.bots.GetAllBots()
+	if len(allBots) == 0 {
+		return 0, 0, nil
+	}
+	botDMs := sq.Or{}
+	for _, bot := range allBots {
+		botDMs = append(botDMs, sq.Like{"c.Name": "%" + bot.GetMMBot().UserId + "%"})
+	}
+
+	files := []orphanedFile{}
+	if err := s.db.DoQuery(&files, s.db.Builder().
+		Select("f.Id", "f.Size").
+		From("FileInfo as f").
+		Join("Channels as c ON c.Id = f.ChannelId").
+		Where(sq.Eq{
+			"f.PostId":    "",
+			"f.DeleteAt":  0,
+			"f.CreatorId": uploadCreatorID,
+			"f.Name":      uploadedTranscriptNames,
+			"c.Type":      model.ChannelTypeDirect,
+		}).
+		Where(sq.Lt{"f.CreateAt": time.Now().Add(-olderThan).UnixMilli()}).
+		Where(botDMs).
+		Limit(orphanedFilesBatchSize),
+	); err != nil {
+		return 0, 0, fmt.Errorf("failed to find orphaned files: %w", err)
+	}
+	if len(files) == 0 {
+		return 0, 0, nil
+	}
+
+	fileIDs := make([]string, 0, len(files))
+	var size int64
+	for _, file := range files {
+		fileIDs = append(fileIDs, file.ID)
+		size += file.Size
+	}
+
+	// A file attached since it was found is left alone
+	now := model.GetMillis()
+	if _, err := s.db.ExecBuilder(s.db.Builder().
+		Update("FileInfo").
+		Set("DeleteAt", now).
+		Set("UpdateAt", now).
+		Where(sq.Eq{
+			"Id":     fileIDs,
+			"PostId": "",
+		})); err != nil {
+		return 0, 0, fmt.Errorf("failed to delete orphaned files: %w", err)
+	}
+
+	s.metricsService.ObserveOrphanedFilesDeleted(len(files), size)
+	return len(files), size, nil
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+)
+
+// formatParticipation formats the talk time and turns of each speaker as a markdown table appended to summaries.
+// It is empty unless at least two speakers are known, since there is no balance to show otherwise.
+func formatParticipation(stats []subtitles.SpeakerStats, T i18n.TranslationFunc) string {
+	if len(stats) < 2 {
+		return ""
+	}
+
+	var total time.Duration
+	for _, speaker := range stats {
+		total += speaker.TalkTime
+	}
+
+	var result strings.Builder
+	result.WriteString("\n\n#### " + T("copilot.participation", "Participation") + "\n\n")
+	fmt.Fprintf(&result, "| %s | %s | %s | %s |\n",
+		T("copilot.participation_speaker", "Speaker"),
+		T("copilot.participation_talk_time", "Talk time"),
+		T("copilot.participation_share", "Share"),
+		T("copilot.participation_turns", "Turns"),
+	)
+	result.WriteString("| --- | ---: | ---: | ---: |\n")
+	for _, speaker := range stats {
+		share := 0
+		if total > 0 {
+			share = int(speaker.TalkTime * 100 / total)
+		}
+		fmt.Fprintf(&result, "| %s | %s | %d%% | %d |\n",
+			strings.ReplaceAll(speaker.Speaker, "|", "\\|"),
+			speaker.TalkTime.Round(time.Second),
+			share,
+			speaker.Turns,
+		)
+	}
+	return result.String()
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatParticipation(t *testing.T) {
+	T := i18n.LocalizerFunc(i18n.Init(), "en")
+	const header = "\n\n#### Participation\n\n| Speaker | Talk time | Share | Turns |\n| --- | ---: | ---: | ---: |\n"
+
+	tests := []struct {
+		name  string
+		stats []subtitles.SpeakerStats
+		want  string
+	}{
+		{
+			name: "no speakers",
+			want: "",
+		},
+		{
+			name:  "single speaker",
+			stats: []subtitles.SpeakerStats{{Speaker: "alice", TalkTime: time.Minute, Turns: 3}},
+			want:  "",
+		},
+		{
+			name: "shares of the total talk time",
+			stats: []subtitles.SpeakerStats{
+				{Speaker: "alice", TalkTime: 6 * time.Second, Turns: 2},
+				{Speaker: "carol", TalkTime: 3 * time.Second, Turns: 1},
+				{Speaker: "bob", TalkTime: 1 * time.Second, Turns: 2},
+			},
+			want: header +
+				"| alice | 6s | 60% | 2 |\n" +
+				"| carol | 3s | 30% | 1 |\n" +
+				"| bob | 1s | 10% | 2 |\n",
+		},
+		{
+			name: "talk time rounded to seconds",
+			stats: []subtitles.SpeakerStats{
+				{Speaker: "alice", TalkTime: 90*time.Second + 600*time.Millisecond, Turns: 4},
+				{Speaker: "bob", TalkTime: 29*time.Second + 400*time.Millisecond, Turns: 3},
+			},
+			want: header +
+				"| alice | 1m31s | 75% | 4 |\n" +
+				"| bob | 29s | 24% | 3 |\n",
+		},
+		{
+			name: "pipes in names are escaped",
+			stats: []subtitles.SpeakerStats{
+				{Speaker: "alice | team lead", TalkTime: time.Second, Turns: 1},
+				{Speaker: "bob", TalkTime: time.Second, Turns: 1},
+			},
+			want: header +
+				"| alice \\| team lead | 1s | 50% | 1 |\n" +
+				"| bob | 1s | 50% | 1 |\n",
+		},
+		{
+			name: "no talk time",
+			stats: []subtitles.SpeakerStats{
+				{Speaker: "alice", Turns: 1},
+				{Speaker: "bob", Turns: 1},
+			},
+			want: header +
+				"| alice | 0s | 0% | 1 |\n" +
+				"| bob | 0s | 0% | 1 |\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, formatParticipation(tc.stats, T))
+		})
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// PlaybooksPluginID is the ID of the Playbooks plugin action items are turned into runs with
+	PlaybooksPluginID = "playbooks"
+
+	// PlaybookRunIDProp is the post prop holding the Playbooks run created from a checklist post
+	PlaybookRunIDProp = "playbook_run_id"
+)
+
+var (
+	// ErrPlaybooksUnavailable is returned when creating a run while the Playbooks plugin isn't running,
+	// or for a meeting that wasn't held in a team channel.
+	ErrPlaybooksUnavailable = errors.New("playbooks unavailable")
+
+	// ErrPlaybookRunExists is returned when creating a second run from the same action items.
+	ErrPlaybookRunExists = errors.New("a playbook run was already created from these action items")
+)
+
+// PlaybookRun is a Playbooks run created from the action items of a meeting.
+type PlaybookRun struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+type playbookRunRequest struct {
+	Name        string `json:"name"`
+	Summary     string `json:"summary"`
+	OwnerUserID string `json:"owner_user_id"`
+	TeamID      string `json:"team_id"`
+	ChannelID   string `json:"channel_id"`
+	PlaybookID  string `json:"playbook_id,omitempty"`
+}
+
+type playbookChecklistItem struct {
+	Title      string `json:"title"`
+	State      string `json:"state"`
+	AssigneeID string `json:"assignee_id,omitempty"`
+	DueDate    int64  `json:"due_date,omitempty"`
+}
+
+type playbookChecklist struct {
+	Title string                  `json:"title"`
+	Items []playbookChecklistItem `json:"items"`
+}
+
+// CreatePlaybookRun creates a Playbooks run in the channel of the meeting with the action items of a
+// checklist post as its checklist, assigning the items whose owner is a Mattermost user. The run is
+// created on behalf of the user, who owns it, so Playbooks applies their permissions. With a playbook
+// the run also gets the checklists of the playbook.
+func (s *Service) CreatePlaybookRun(post *model.Post, userID string, playbookID string) (*PlaybookRun, error) {
+	items, err := postActionItemsProp(post)
+	if err != nil {
+		return nil, err
+	}
+	if runID, ok := post.GetProp(PlaybookRunIDProp).(string); ok && runID != "" {
+		return nil, ErrPlaybookRunExists
+	}
+
+	status, err := s.pluginAPI.Plugin.GetPluginStatus(PlaybooksPluginID)
+	if err != nil || status.State != model.PluginStateRunning {
+		return nil, fmt.Errorf("%w: the Playbooks plugin isn't running", ErrPlaybooksUnavailable)
+	}
+
+	summaryPostID, _ := post.GetProp(SummaryPostIDProp).(string)
+	summaryPost, err := s.pluginAPI.Post.GetPost(summaryPostID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get summary post: %w", err)
+	}
+	meetingChannel, err := s.meetingChannel(summaryPost)
+	if err != nil {
+		return nil, err
+	}
+	if meetingChannel.TeamId == "" {
+		return nil, fmt.Errorf("%w: the meeting wasn't held in a team channel", ErrPlaybooksUnavailable)
+	}
+
+	user, err := s.pluginAPI.User.Get(userID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get user: %w", err)
+	}
+	T := i18n.LocalizerFunc(s.i18n, user.Locale)
+
+	var run struct {
+		ID string `json:"id"`
+	}
+	err = s.playbooksRequest(userID, http.MethodPost, "/runs", playbookRunRequest{
+		Name:        T("copilot.playbook_run_name", "Action items from %s", meetingChannel.DisplayName),
+		Summary:     summaryPost.Message,
+		OwnerUserID: userID,
+		TeamID:      meetingChannel.TeamId,
+		ChannelID:   meetingChannel.Id,
+		PlaybookID:  playbookID,
+	}, &run)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create playbook run: %w", err)
+	}
+
+	checklist := newPlaybookChecklist(T("copilot.playbook_checklist_title", "Meeting action items"), items)
+	if err = s.playbooksRequest(userID, http.MethodPost, "/runs/"+run.ID+"/checklists", checklist, nil); err != nil {
+		return nil, fmt.Errorf("unable to add action items to playbook run: %w", err)
+	}
+
+	post.AddProp(PlaybookRunIDProp, run.ID)
+	if err = s.pluginAPI.Post.UpdatePost(post); err != nil {
+		return nil, fmt.Errorf("unable to update action items: %w", err)
+	}
+
+	return &PlaybookRun{
+		ID:  run.ID,
+		URL: "/playbooks/runs/" + run.ID,
+	}, nil
+}
+
+// meetingChannel returns the channel a summarized meeting was held in.
+func (s *Service) meetingChannel(summaryPost *model.Post) (*model.Channel, error) {
+	var channelID string
+	if transcriptionPostID, ok := summaryPost.GetProp(ReferencedTranscriptPostID).(string); ok && transcriptionPostID != "" {
+		transcriptionPost, err := s.pluginAPI.Post.GetPost(transcriptionPostID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get transcription post: %w", err)
+		}
+		channelID = transcriptionPost.ChannelId
+	} else if recordingFileID, ok := summaryPost.GetProp(ReferencedRecordingFileID).(string); ok && recordingFileID != "" {
+		fileInfo, err := s.pluginAPI.File.GetInfo(recordingFileID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get recording file info: %w", err)
+		}
+		channelID = fileInfo.ChannelId
+	} else {
+		return nil, ErrNotMeetingSummary
+	}
+
+	channel, err := s.pluginAPI.Channel.Get(channelID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get meeting channel: %w", err)
+	}
+	return channel, nil
+}
+
+// newPlaybookChecklist converts action items into a Playbooks checklist. Items already done are closed.
+func newPlaybookChecklist(title string, items []ActionItem) playbookChecklist {
+	checklist := playbookChecklist{
+		Title: title,
+		Items: make([]playbookChecklistItem, 0, len(items)),
+	}
+	for _, item := range items {
+		checklistItem := playbookChecklistItem{
+			Title:      item.Task,
+			AssigneeID: item.OwnerUserID,
+		}
+		if item.Done {
+			checklistItem.State = "closed"
+		}
+		if dueDate, err := time.Parse(dueDateFormat, item.DueDate); err == nil {
+			checklistItem.DueDate = dueDate.UnixMilli()
+		}
+		checklist.Items = append(checklist.Items, checklistItem)
+	}
+	return checklist
+}
+
+// playbooksRequest calls the API of the Playbooks plugin as the user, decoding the response into result when it isn't nil.
+func (s *Service) playbooksRequest(userID string, method string, path string, body any, result any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("unable to marshal request: %w", err)
+	}
+	req, err := http.NewRequest(method, "/"+PlaybooksPluginID+"/api/v0"+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Mattermost-User-ID", userID)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := s.pluginAPI.Plugin.HTTP(req)
+	if resp == nil {
+		return errors.New("no response from the Playbooks plugin")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("playbooks request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("unable to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// TranscriptionProgressProp tracks how far the transcription of a recording is, as a map with the
+	// percentage done and the estimated seconds left
+	TranscriptionProgressProp = "transcription_progress"
+
+	// TranscriptionProgressEvent is the websocket event sent to the requester as a transcription progresses
+	TranscriptionProgressEvent = "transcription_progress"
+
+	// progressEventInterval and progressPostInterval limit how often the progress is sent to the webapp
+	// and written to the transcript post, which is edited for everyone in the channel
+	progressEventInterval = 2 * time.Second
+	progressPostInterval  = 15 * time.Second
+
+	// convertingShare is the share of the work on a recording spent converting it to audio, the rest
+	// is spent transcribing the audio
+	convertingShare = 0.2
+
+	// minProgressForETA is the progress below which the time left isn't estimated, since the first
+	// parts of the work tell little about the rest
+	minProgressForETA = 0.05
+)
+
+// transcriptionProgress follows the conversion and transcription of the recordings of a meeting.
+// Its methods can be called from the goroutines transcribing each recording.
+type transcriptionProgress struct {
+	mu           sync.Mutex
+	started      time.Time
+	converted    []float64
+	segmentsDone []int
+	segments     []int
+	lastEvent    time.Time
+	lastPost     time.Time
+
+	// publish is called with the progress when it changes, at most every progressEventInterval,
+	// updatePost is set at most every progressPostInterval
+	publish func(percent int, eta time.Duration, updatePost bool)
+}
+
+func newTranscriptionProgress(recordings int, started time.Time, publish func(percent int, eta time.Duration, updatePost bool)) *transcriptionProgress {
+	return &transcriptionProgress{
+		started:      started,
+		converted:    make([]float64, recordings),
+		segmentsDone: make([]int, recordings),
+		segments:     make([]int, recordings),
+		lastPost:     started,
+		publish:      publish,
+	}
+}
+
+// recording returns the progress of the recording at index.
+func (p *transcriptionProgress) recording(index int) recordingProgress {
+	return recordingProgress{progress: p, index: index}
+}
+
+// status returns the percentage done and the estimated time left, zero while too little is done to tell.
+// Each recording counts the same, converting taking convertingShare of it.
+func (p *transcriptionProgress) status(now time.Time) (int, time.Duration) {
+	if len(p.converted) == 0 {
+		return 0, 0
+	}
+
+	var done float64
+	for i, converted := range p.converted {
+		done += converted * convertingShare
+		if p.segments[i] > 0 {
+			done += float64(p.segmentsDone[i]) / float64(p.segments[i]) * (1 - convertingShare)
+		}
+	}
+	done /= float64(len(p.converted))
+
+	var eta time.Duration
+	if done >= minProgressForETA && done < 1 {
+		elapsed := now.Sub(p.started)
+		eta = time.Duration(float64(elapsed) * (1 - done) / done)
+	}
+
+	return int(done * 100), eta
+}
+
+// update publishes the progress unless it was published too recently.
+func (p *transcriptionProgress) update(now time.Time) {
+	if now.Sub(p.lastEvent) < progressEventInterval {
+		return
+	}
+	p.lastEvent = now
+
+	updatePost := now.Sub(p.lastPost) >= progressPostInterval
+	if updatePost {
+		p.lastPost = now
+	}
+
+	percent, eta := p.status(now)
+	p.publish(percent, eta, updatePost)
+}
+
+// finish publishes that the transcription is over, without updating the post which is replaced next.
+func (p *transcriptionProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.publish(100, 0, false)
+}
+
+// recordingProgress reports the progress of one of the recordings of a meeting.
+type recordingProgress struct {
+	progress *transcriptionProgress
+	index    int
+}
+
+// converting reports the share of the recording converted to audio so far.
+func (r recordingProgress) converting(converted float64) {
+	r.progress.mu.Lock()
+	defer r.progress.mu.Unlock()
+	r.progress.converted[r.index] = min(max(converted, 0), 1)
+	r.progress.update(time.Now())
+}
+
+// transcribed reports how many parts of the converted recording are transcribed.
+func (r recordingProgress) transcribed(done, total int) {
+	r.progress.mu.Lock()
+	defer r.progress.mu.Unlock()
+	r.progress.converted[r.index] = 1
+	r.progress.segmentsDone[r.index] = done
+	r.progress.segments[r.index] = total
+	r.progress.update(time.Now())
+}
+
+// newTranscriptionProgress reports the progress of the transcription to the requester with websocket events,
+// and on the transcript post. Failures to update the post are logged since the transcription goes on.
+func (s *Service) newTranscriptionProgress(transcriptPost *model.Post, requestingUser *model.User, recordings int) *transcriptionProgress {
+	T := i18n.LocalizerFunc(s.i18n, requestingUser.Locale)
+	return newTranscriptionProgress(recordings, time.Now(), func(percent int, eta time.Duration, updatePost bool) {
+		s.pluginAPI.Frontend.PublishWebSocketEvent(TranscriptionProgressEvent, map[string]any{
+			"post_id":     transcriptPost.Id,
+			"control":     TranscriptionProgressEvent,
+			"percent":     percent,
+			"eta_seconds": int(eta.Seconds()),
+		}, &model.WebsocketBroadcast{
+			UserId: requestingUser.Id,
+		})
+
+		if !updatePost {
+			return
+		}
+		if eta > 0 {
+			minutes := max(int(eta.Round(time.Minute).Minutes()), 1)
+			transcriptPost.Message = T("copilot.summarize_call_recording_progress_eta", "Processing audio into transcription: %d%% done, about %d min left...", percent, minutes)
+		} else {
+			transcriptPost.Message = T("copilot.summarize_call_recording_progress", "Processing audio into transcription: %d%% done...", percent)
+		}
+		transcriptPost.AddProp(TranscriptionProgressProp, map[string]any{
+			"percent":     percent,
+			"eta_seconds": int(eta.Seconds()),
+		})
+		if err := s.pluginAPI.Post.UpdatePost(transcriptPost); err != nil {
+			s.pluginAPI.Log.Warn("Failed to update transcription progress", "error", err)
+		}
+	})
+}
+
+// ffmpegProgressWriter reads the progress ffmpeg writes with -progress, calling onPosition with how far
+// into the input the conversion is.
+type ffmpegProgressWriter struct {
+	pending    []byte
+	onPosition func(position time.Duration)
+}
+
+func (w *ffmpegProgressWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		end := bytes.IndexByte(w.pending, '\n')
+		if end < 0 {
+			break
+		}
+		line := strings.TrimSpace(string(w.pending[:end]))
+		w.pending = w.pending[end+1:]
+
+		// The position is N/A until the first audio is written
+		if value, found := strings.CutPrefix(line, "out_time_us="); found {
+			if microseconds, err := strconv.ParseInt(value, 10, 64); err == nil && microseconds >= 0 {
+				w.onPosition(time.Duration(microseconds) * time.Microsecond)
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// ffmpegLog keeps what ffmpeg logs, and the duration of the input once ffmpeg has described it.
+type ffmpegLog struct {
+	mu       sync.Mutex
+	log      bytes.Buffer
+	duration time.Duration
+}
+
+func (l *ffmpegLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.log.Write(p)
+	if l.duration == 0 {
+		if duration, err := parseFFMPEGDuration(l.log.String()); err == nil {
+			l.duration = duration
+		}
+	}
+	return len(p), nil
+}
+
+// Duration returns the duration of the input, zero while unknown.
+func (l *ffmpegLog) Duration() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.duration
+}
+
+func (l *ffmpegLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.log.String()
+}
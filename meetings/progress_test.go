@@ -0,0 +1,136 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranscriptionProgressStatus(t *testing.T) {
+	started := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		converted    []float64
+		segmentsDone []int
+		segments     []int
+		elapsed      time.Duration
+		wantPercent  int
+		wantETA      time.Duration
+	}{
+		{
+			name:         "not started",
+			converted:    []float64{0},
+			segmentsDone: []int{0},
+			segments:     []int{0},
+			wantPercent:  0,
+		},
+		{
+			name:         "converting",
+			converted:    []float64{0.5},
+			segmentsDone: []int{0},
+			segments:     []int{0},
+			elapsed:      time.Minute,
+			wantPercent:  10,
+			wantETA:      9 * time.Minute,
+		},
+		{
+			name:         "too early to estimate",
+			converted:    []float64{0.2},
+			segmentsDone: []int{0},
+			segments:     []int{0},
+			elapsed:      time.Minute,
+			wantPercent:  4,
+		},
+		{
+			name:         "transcribing",
+			converted:    []float64{1},
+			segmentsDone: []int{1},
+			segments:     []int{2},
+			elapsed:      6 * time.Minute,
+			wantPercent:  60,
+			wantETA:      4 * time.Minute,
+		},
+		{
+			name:         "recordings count the same",
+			converted:    []float64{1, 0},
+			segmentsDone: []int{1, 0},
+			segments:     []int{1, 0},
+			elapsed:      5 * time.Minute,
+			wantPercent:  50,
+			wantETA:      5 * time.Minute,
+		},
+		{
+			name:         "done",
+			converted:    []float64{1},
+			segmentsDone: []int{3},
+			segments:     []int{3},
+			elapsed:      time.Minute,
+			wantPercent:  100,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			progress := newTranscriptionProgress(len(tc.converted), started, nil)
+			copy(progress.converted, tc.converted)
+			copy(progress.segmentsDone, tc.segmentsDone)
+			copy(progress.segments, tc.segments)
+
+			percent, eta := progress.status(started.Add(tc.elapsed))
+			assert.Equal(t, tc.wantPercent, percent)
+			assert.InDelta(t, tc.wantETA, eta, float64(time.Second))
+		})
+	}
+}
+
+func TestTranscriptionProgressThrottling(t *testing.T) {
+	started := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var published []bool
+	progress := newTranscriptionProgress(1, started, func(_ int, _ time.Duration, updatePost bool) {
+		published = append(published, updatePost)
+	})
+
+	progress.update(started.Add(time.Second))
+	progress.update(started.Add(2 * time.Second))
+	progress.update(started.Add(4 * time.Second))
+	progress.update(started.Add(16 * time.Second))
+	progress.update(started.Add(20 * time.Second))
+
+	assert.Equal(t, []bool{false, false, true, false}, published)
+}
+
+func TestFFmpegProgressWriter(t *testing.T) {
+	var positions []time.Duration
+	writer := &ffmpegProgressWriter{onPosition: func(position time.Duration) {
+		positions = append(positions, position)
+	}}
+
+	// Writes don't line up with the lines of the progress
+	for _, chunk := range []string{
+		"out_time_us=N/A\nout_time=N/A\nprogress=continue\n",
+		"total_size=1024\nout_time_us=1500",
+		"000\nprogress=continue\nout_time_us=3000000\n",
+		"progress=end\n",
+	} {
+		n, err := writer.Write([]byte(chunk))
+		assert.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+
+	assert.Equal(t, []time.Duration{1500 * time.Millisecond, 3 * time.Second}, positions)
+}
+
+func TestFFmpegLogDuration(t *testing.T) {
+	log := &ffmpegLog{}
+	_, _ = log.Write([]byte("Input #0, matroska,webm, from 'pipe:0':\n  Dura"))
+	assert.Zero(t, log.Duration())
+
+	_, _ = log.Write([]byte("tion: 00:01:30.50, start: 0.000000, bitrate: N/A\n"))
+	assert.Equal(t, 90*time.Second+500*time.Millisecond, log.Duration())
+	assert.Contains(t, log.String(), "Input #0")
+}
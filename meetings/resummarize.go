@@ -0,0 +1,104 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+var (
+	// ErrNotMeetingSummary is returned when summarizing again a post that isn't a meeting summary.
+	ErrNotMeetingSummary = errors.New("post is not a meeting summary")
+
+	// ErrResummarizeNotRequester is returned when someone else than the user who asked for a meeting
+	// summary summarizes it again.
+	ErrResummarizeNotRequester = errors.New("only the original requester can summarize again")
+)
+
+// HandleResummarize summarizes the transcript of a meeting summary again with another bot, in a new
+// conversation with that bot. The stored transcript is reused so the meeting isn't transcribed again,
+// and the summary keeps the template, time range and translation of the original one.
+func (s *Service) HandleResummarize(userID string, bot *bots.Bot, summaryPost *model.Post) (map[string]string, error) {
+	if summaryPost.GetProp(streaming.LLMRequesterUserID) != userID {
+		return nil, ErrResummarizeNotRequester
+	}
+
+	// Summaries of recordings hold the transcript they were written from, other summaries
+	// reference the post of the transcript
+	transcriptionPost := summaryPost
+	meetingChannelID := ""
+	translate := false
+	if transcriptionPostID, ok := summaryPost.GetProp(ReferencedTranscriptPostID).(string); ok && transcriptionPostID != "" {
+		post, err := s.pluginAPI.Post.GetPost(transcriptionPostID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get transcription post: %w", err)
+		}
+		transcriptionPost = post
+		meetingChannelID = post.ChannelId
+		translate = summaryPost.GetProp(TranslatedToProp) != nil
+	} else if recordingFileID, ok := summaryPost.GetProp(ReferencedRecordingFileID).(string); ok && recordingFileID != "" {
+		fileInfo, err := s.pluginAPI.File.GetInfo(recordingFileID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get recording file info: %w", err)
+		}
+		meetingChannelID = fileInfo.ChannelId
+	} else {
+		return nil, ErrNotMeetingSummary
+	}
+
+	// A corrected transcript is attached to the summary itself
+	if editedFileID, ok := summaryPost.GetProp(EditedTranscriptFileIDProp).(string); ok && editedFileID != "" {
+		transcriptionPost = summaryPost
+	}
+
+	if !s.pluginAPI.User.HasPermissionToChannel(userID, meetingChannelID, model.PermissionReadChannel) {
+		return nil, fmt.Errorf("user doesn't have permission to read the meeting channel: %w", bots.ErrUsageRestriction)
+	}
+	meetingChannel, err := s.pluginAPI.Channel.Get(meetingChannelID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get meeting channel: %w", err)
+	}
+	if err = s.bots.CheckUsageRestrictions(userID, bot, meetingChannel); err != nil {
+		return nil, err
+	}
+
+	transcriptionChannel, err := s.pluginAPI.Channel.Get(transcriptionPost.ChannelId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get transcription channel: %w", err)
+	}
+
+	user, err := s.pluginAPI.User.Get(userID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get user: %w", err)
+	}
+
+	format, _ := summaryPost.GetProp(SummaryFormatProp).(string)
+	var timeRange subtitles.TimeRange
+	if transcriptRange, ok := summaryPost.GetProp(TranscriptRangeProp).(string); ok && transcriptRange != "" {
+		timeRange, err = subtitles.ParseTimeRange(transcriptRange)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse transcript range: %w", err)
+		}
+	}
+
+	createdPost, err := s.newCallTranscriptionSummaryThread(bot, user, transcriptionPost, transcriptionChannel, translate, format, timeRange)
+	if err != nil {
+		return nil, fmt.Errorf("unable to summarize transcription: %w", err)
+	}
+
+	s.conversations.SaveTitleAsync(createdPost.Id, TitleMeetingSummary)
+	s.conversations.TagThreadAsync(createdPost.Id, conversations.CategoryMeeting)
+
+	return map[string]string{
+		"postid":    createdPost.Id,
+		"channelid": createdPost.ChannelId,
+	}, nil
+}
@@ -0,0 +1,21 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleResummarizeNotRequester(t *testing.T) {
+	summaryPost := &model.Post{Id: "summaryid"}
+	summaryPost.AddProp(streaming.LLMRequesterUserID, "requester")
+	summaryPost.AddProp(ReferencedTranscriptPostID, "transcriptid")
+
+	_, err := (&Service{}).HandleResummarize("other", nil, summaryPost)
+	assert.ErrorIs(t, err, ErrResummarizeNotRequester)
+}
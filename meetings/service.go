@@ -4,9 +4,15 @@
 package meetings
 
 import (
+	"sync"
+
 	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
 	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/ffmpeg"
 	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/llmcontext"
 	"github.com/mattermost/mattermost-plugin-ai/metrics"
@@ -19,8 +25,19 @@ const (
 	CallsRecordingPostType = "custom_calls_recording"
 	CallsBotUsername       = "calls"
 	ZoomBotUsername        = "zoom"
+
+	// PostbackPostType is the type of the meeting summaries posted back to the call channel
+	PostbackPostType = "custom_llm_postback"
 )
 
+// Config is the configuration the meetings service needs
+type Config interface {
+	GetFFmpegConfig() ffmpeg.Config
+	GetSummaryTemplates() []config.SummaryTemplate
+	GetAutoSummarizeBotName(channelID string) string
+	EmbeddingSearchConfig() embeddings.EmbeddingSearchConfig
+}
+
 // Service handles meeting summarization and transcription functionality
 type Service struct {
 	pluginAPI        *pluginapi.Client
@@ -32,8 +49,17 @@ type Service struct {
 	db               *mmapi.DBClient
 	contextBuilder   *llmcontext.Builder
 	conversations    *conversations.Conversations
+	config           Config
 
-	ffmpegPath string
+	// jobQueue runs the summaries, set by RegisterJobs
+	jobQueue *jobs.Queue
+
+	// embeddingProvider and embeddingSearch are optional, they are nil when search isn't configured.
+	// The provider follows configuration changes, embeddingMu guards it along with the embeddings made with it.
+	embeddingMu          sync.RWMutex
+	embeddingProvider    embeddings.EmbeddingProvider
+	transcriptEmbeddings *transcriptEmbeddingCache
+	embeddingSearch      embeddings.EmbeddingSearch
 }
 
 // NewService creates a new meetings service
@@ -47,6 +73,7 @@ func NewService(
 	db *mmapi.DBClient,
 	contextBuilder *llmcontext.Builder,
 	conversations *conversations.Conversations,
+	config Config,
 ) *Service {
 	service := &Service{
 		pluginAPI:        pluginAPI,
@@ -58,12 +85,12 @@ func NewService(
 		db:               db,
 		contextBuilder:   contextBuilder,
 		conversations:    conversations,
-	}
+		config:           config,
 
-	service.ffmpegPath = resolveFFMPEGPath()
-	if service.ffmpegPath == "" {
-		service.pluginAPI.Log.Error("ffmpeg not installed, transcriptions will be disabled.")
+		transcriptEmbeddings: newTranscriptEmbeddingCache(),
 	}
 
+	service.CheckFFmpeg()
+
 	return service
 }
@@ -0,0 +1,177 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// callPostIDProp references the call post from the posts the Calls plugin creates for its recordings and transcriptions
+	callPostIDProp = "call_post_id"
+
+	// callParticipantsProp lists the IDs of the users that joined the call
+	callParticipantsProp = "participants"
+)
+
+type speakersResponse struct {
+	Speakers []struct {
+		Label    string `json:"label"`
+		Username string `json:"username"`
+	} `json:"speakers"`
+}
+
+// callParticipants returns the users that joined the call a Calls recording or transcription post belongs to.
+func (s *Service) callParticipants(post *model.Post) ([]*model.User, error) {
+	participantIDs := stringsFromProp(post.GetProp(callParticipantsProp))
+	if len(participantIDs) == 0 {
+		callPostID, ok := post.GetProp(callPostIDProp).(string)
+		if !ok || callPostID == "" {
+			return nil, nil
+		}
+		callPost, err := s.pluginAPI.Post.GetPost(callPostID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get call post: %w", err)
+		}
+		participantIDs = stringsFromProp(callPost.GetProp(callParticipantsProp))
+	}
+	if len(participantIDs) == 0 {
+		return nil, nil
+	}
+
+	participants, err := s.pluginAPI.User.ListByUserIDs(participantIDs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get call participants: %w", err)
+	}
+	return participants, nil
+}
+
+func stringsFromProp(prop any) []string {
+	switch values := prop.(type) {
+	case []string:
+		return values
+	case []any:
+		result := make([]string, 0, len(values))
+		for _, value := range values {
+			if str, ok := value.(string); ok && str != "" {
+				result = append(result, str)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// IdentifySpeakers replaces the anonymous speaker labels of a diarized transcription with the
+// usernames of the call participants they belong to, as far as the transcription allows.
+func (s *Service) IdentifySpeakers(bot *bots.Bot, transcription *subtitles.Subtitles, participants []*model.User, context *llm.Context) error {
+	labels := transcription.Speakers()
+	if len(labels) == 0 || len(participants) == 0 {
+		return nil
+	}
+
+	// Nothing to work out when a single participant is the only speaker
+	if len(labels) == 1 && len(participants) == 1 {
+		transcription.RenameSpeakers(map[string]string{labels[0]: participants[0].Username})
+		return nil
+	}
+
+	var participantList strings.Builder
+	for _, participant := range participants {
+		fmt.Fprintf(&participantList, "- %s: %s\n", participant.Username, participant.GetFullName())
+	}
+	context.Parameters = map[string]any{
+		"Participants": participantList.String(),
+	}
+	systemPrompt, err := s.prompts.Format(prompts.PromptMeetingSpeakerIdentificationSystem, context)
+	if err != nil {
+		return fmt.Errorf("unable to get speaker identification prompt: %w", err)
+	}
+
+	// Speakers usually introduce themselves early, so the start of long meetings is enough
+	formattedTranscription := transcription.FormatForLLM()
+	maxChars := (int(float64(bot.LLM().InputTokenLimit())*0.5) - ContextTokenMargin) * 4
+	if maxChars > 0 && len(formattedTranscription) > maxChars {
+		// Cut on a rune boundary so multi-byte characters aren't split
+		for maxChars > 0 && !utf8.RuneStart(formattedTranscription[maxChars]) {
+			maxChars--
+		}
+		formattedTranscription = formattedTranscription[:maxChars]
+	}
+
+	request := llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: systemPrompt,
+			},
+			{
+				Role:    llm.PostRoleUser,
+				Message: formattedTranscription,
+			},
+		},
+		Context: context,
+	}
+	result, err := bot.LLM().ChatCompletionNoStream(request, llm.WithJSONOutput(&speakersResponse{}))
+	if err != nil {
+		return fmt.Errorf("unable to identify speakers: %w", err)
+	}
+
+	names, err := parseSpeakers(result, labels, participants)
+	if err != nil {
+		return err
+	}
+	transcription.RenameSpeakers(names)
+
+	return nil
+}
+
+// parseSpeakers converts the model response into a mapping of speaker labels to usernames,
+// skipping unknown labels and usernames and any participant identified more than once.
+func parseSpeakers(result string, labels []string, participants []*model.User) (map[string]string, error) {
+	var response speakersResponse
+	if err := llm.UnmarshalModelJSON(result, &response); err != nil {
+		return nil, fmt.Errorf("unable to parse speakers: %w", err)
+	}
+
+	knownLabels := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		knownLabels[label] = true
+	}
+	knownUsernames := make(map[string]bool, len(participants))
+	for _, participant := range participants {
+		knownUsernames[participant.Username] = true
+	}
+
+	names := map[string]string{}
+	usernameCount := map[string]int{}
+	for _, speaker := range response.Speakers {
+		username := strings.TrimPrefix(strings.TrimSpace(speaker.Username), "@")
+		if !knownLabels[speaker.Label] || !knownUsernames[username] {
+			continue
+		}
+		if _, ok := names[speaker.Label]; ok {
+			continue
+		}
+		names[speaker.Label] = username
+		usernameCount[username]++
+	}
+
+	// A participant matched to several speakers is ambiguous, keep those speakers anonymous
+	for label, username := range names {
+		if usernameCount[username] > 1 {
+			delete(names, label)
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSpeakers(t *testing.T) {
+	labels := []string{"Speaker 1", "Speaker 2", "Speaker 3"}
+	participants := []*model.User{
+		{Username: "alice"},
+		{Username: "bob"},
+	}
+
+	tests := []struct {
+		name    string
+		result  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "valid mapping",
+			result: `{"speakers": [{"label": "Speaker 1", "username": "alice"}, {"label": "Speaker 2", "username": "bob"}]}`,
+			want:   map[string]string{"Speaker 1": "alice", "Speaker 2": "bob"},
+		},
+		{
+			name:   "unknown label and username are skipped",
+			result: `{"speakers": [{"label": "Speaker 9", "username": "alice"}, {"label": "Speaker 2", "username": "carol"}, {"label": "Speaker 3", "username": "bob"}]}`,
+			want:   map[string]string{"Speaker 3": "bob"},
+		},
+		{
+			name:   "participant matched to several speakers is dropped",
+			result: `{"speakers": [{"label": "Speaker 1", "username": "alice"}, {"label": "Speaker 2", "username": "alice"}, {"label": "Speaker 3", "username": "bob"}]}`,
+			want:   map[string]string{"Speaker 3": "bob"},
+		},
+		{
+			name:   "mention prefix",
+			result: `{"speakers": [{"label": "Speaker 1", "username": "@alice"}]}`,
+			want:   map[string]string{"Speaker 1": "alice"},
+		},
+		{
+			name:   "code block",
+			result: "```json\n{\"speakers\": [{\"label\": \"Speaker 2\", \"username\": \"bob\"}]}\n```",
+			want:   map[string]string{"Speaker 2": "bob"},
+		},
+		{
+			name:    "invalid json",
+			result:  "Speaker 1 is alice",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSpeakers(tt.result, labels, participants)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
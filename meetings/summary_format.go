@@ -0,0 +1,130 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+)
+
+const (
+	// SummaryFormatStandard is the default summary with key discussion points and action items
+	SummaryFormatStandard = "standard"
+
+	// SummaryFormatStructured is a summary with separate decisions, open questions and next steps sections
+	SummaryFormatStructured = "structured"
+
+	// SummaryFormatStandup is a summary of a standup with what each person did, plans to do and is blocked on
+	SummaryFormatStandup = "standup"
+
+	// SummaryFormatRetro is a summary of a retrospective with what went well, what didn't and the improvements agreed on
+	SummaryFormatRetro = "retro"
+
+	// SummaryFormatIncidentReview is a summary of an incident review with the impact, timeline, causes and follow-ups
+	SummaryFormatIncidentReview = "incident_review"
+
+	// SummaryFormatCustomerCall is a summary of a call with a customer with their needs, feedback and commitments made
+	SummaryFormatCustomerCall = "customer_call"
+
+	// SummaryFormatProp is the post prop with the template a summary was written with, so it is kept when regenerating
+	SummaryFormatProp = "summary_format"
+)
+
+// ErrUnknownSummaryFormat is returned when a summary is requested in a format that doesn't exist.
+var ErrUnknownSummaryFormat = errors.New("unknown summary format")
+
+// SummaryTemplate is a format a meeting summary can be requested in.
+type SummaryTemplate struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	// Custom is set for the templates defined by admins
+	Custom bool `json:"custom"`
+
+	prompt       string
+	instructions string
+}
+
+var builtinSummaryTemplates = []SummaryTemplate{
+	{ID: SummaryFormatStandard, Name: "Standard", prompt: prompts.PromptMeetingSummarySystem},
+	{ID: SummaryFormatStructured, Name: "Structured", prompt: prompts.PromptMeetingSummaryStructuredSystem},
+	{ID: SummaryFormatStandup, Name: "Standup", prompt: prompts.PromptMeetingSummaryStandupSystem},
+	{ID: SummaryFormatRetro, Name: "Retrospective", prompt: prompts.PromptMeetingSummaryRetroSystem},
+	{ID: SummaryFormatIncidentReview, Name: "Incident review", prompt: prompts.PromptMeetingSummaryIncidentReviewSystem},
+	{ID: SummaryFormatCustomerCall, Name: "Customer call", prompt: prompts.PromptMeetingSummaryCustomerCallSystem},
+}
+
+// summaryTemplates lists the built-in templates followed by the ones defined by admins. A template defined
+// by admins with the ID of a built-in template replaces it, templates without instructions are ignored.
+func summaryTemplates(custom []config.SummaryTemplate) []SummaryTemplate {
+	templates := slices.Clone(builtinSummaryTemplates)
+	for _, c := range custom {
+		id := strings.TrimSpace(c.ID)
+		if id == "" || strings.TrimSpace(c.Instructions) == "" {
+			continue
+		}
+		name := strings.TrimSpace(c.Name)
+		if name == "" {
+			name = id
+		}
+
+		template := SummaryTemplate{
+			ID:           id,
+			Name:         name,
+			Custom:       true,
+			prompt:       prompts.PromptMeetingSummaryTemplateSystem,
+			instructions: c.Instructions,
+		}
+		if i := slices.IndexFunc(templates, func(t SummaryTemplate) bool { return t.ID == id }); i >= 0 {
+			templates[i] = template
+		} else {
+			templates = append(templates, template)
+		}
+	}
+	return templates
+}
+
+// findSummaryTemplate returns the template with the ID, an empty ID is the standard template.
+func findSummaryTemplate(templates []SummaryTemplate, id string) (SummaryTemplate, error) {
+	if id == "" {
+		id = SummaryFormatStandard
+	}
+	for _, template := range templates {
+		if template.ID == id {
+			return template, nil
+		}
+	}
+	return SummaryTemplate{}, fmt.Errorf("%w: %q", ErrUnknownSummaryFormat, id)
+}
+
+// SummaryTemplates returns the templates meeting summaries can be requested in.
+func (s *Service) SummaryTemplates() []SummaryTemplate {
+	return summaryTemplates(s.config.GetSummaryTemplates())
+}
+
+// ParseSummaryFormat validates a requested summary format, the ID of one of the summary templates.
+// An empty format is the standard one.
+func (s *Service) ParseSummaryFormat(format string) (string, error) {
+	template, err := findSummaryTemplate(s.SummaryTemplates(), format)
+	if err != nil {
+		return "", err
+	}
+	return template.ID, nil
+}
+
+// summaryTemplate returns the template of the format, falling back to the standard template when it
+// was removed since the summary was requested.
+func (s *Service) summaryTemplate(format string) SummaryTemplate {
+	templates := s.SummaryTemplates()
+	if template, err := findSummaryTemplate(templates, format); err == nil {
+		return template
+	}
+	template, _ := findSummaryTemplate(templates, SummaryFormatStandard)
+	return template
+}
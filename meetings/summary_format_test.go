@@ -0,0 +1,52 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindSummaryTemplate(t *testing.T) {
+	templates := summaryTemplates([]config.SummaryTemplate{
+		{ID: "board_meeting", Name: "Board meeting", Instructions: "List the resolutions passed."},
+		{ID: SummaryFormatRetro, Name: "Team retro", Instructions: "Use the start, stop, continue format."},
+		{ID: "empty", Name: "Without instructions"},
+	})
+
+	tests := []struct {
+		format       string
+		wantID       string
+		wantPrompt   string
+		instructions string
+	}{
+		{format: "", wantID: SummaryFormatStandard, wantPrompt: prompts.PromptMeetingSummarySystem},
+		{format: "standard", wantID: SummaryFormatStandard, wantPrompt: prompts.PromptMeetingSummarySystem},
+		{format: "structured", wantID: SummaryFormatStructured, wantPrompt: prompts.PromptMeetingSummaryStructuredSystem},
+		{format: "standup", wantID: SummaryFormatStandup, wantPrompt: prompts.PromptMeetingSummaryStandupSystem},
+		{format: "incident_review", wantID: SummaryFormatIncidentReview, wantPrompt: prompts.PromptMeetingSummaryIncidentReviewSystem},
+		{format: "customer_call", wantID: SummaryFormatCustomerCall, wantPrompt: prompts.PromptMeetingSummaryCustomerCallSystem},
+		{format: "board_meeting", wantID: "board_meeting", wantPrompt: prompts.PromptMeetingSummaryTemplateSystem, instructions: "List the resolutions passed."},
+		{format: "retro", wantID: SummaryFormatRetro, wantPrompt: prompts.PromptMeetingSummaryTemplateSystem, instructions: "Use the start, stop, continue format."},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			template, err := findSummaryTemplate(templates, tc.format)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantID, template.ID)
+			assert.Equal(t, tc.wantPrompt, template.prompt)
+			assert.Equal(t, tc.instructions, template.instructions)
+		})
+	}
+
+	for _, format := range []string{"bullet_points", "empty"} {
+		_, err := findSummaryTemplate(templates, format)
+		require.ErrorIs(t, err, ErrUnknownSummaryFormat)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+var (
+	// ErrNoTranscript is returned when a post doesn't reference a transcript.
+	ErrNoTranscript = errors.New("post has no transcript")
+
+	// ErrUnknownTranscriptFormat is returned when exporting a transcript in a format that isn't supported.
+	ErrUnknownTranscriptFormat = errors.New("unknown transcript format")
+)
+
+// Transcript export formats
+const (
+	TranscriptFormatSRT  = "srt"
+	TranscriptFormatVTT  = "vtt"
+	TranscriptFormatText = "txt"
+	TranscriptFormatJSON = "json"
+)
+
+// Transcript is the parsed transcript of a recording, ready to be rendered by a transcript viewer.
+type Transcript struct {
+	PostID          string              `json:"post_id"`
+	FileID          string              `json:"file_id"`
+	RecordingFileID string              `json:"recording_file_id,omitempty"`
+	Segments        []subtitles.Segment `json:"segments"`
+}
+
+// transcriptFileIDs finds the transcript file of a post and, when known, the recording it was created from.
+// Calls recording posts reference their captions in the post props, transcripts created by the
+// plugin have the transcript attached and reference the recording. Any other post can have an uploaded
+// transcript as its only attachment, its format is detected when it is read. Summaries whose
+// transcript was corrected use the corrected transcript, see EditTranscript.
+func transcriptFileIDs(post *model.Post) (transcriptFileID string, recordingFileID string, err error) {
+	if editedFileID, ok := post.GetProp(EditedTranscriptFileIDProp).(string); ok && editedFileID != "" {
+		_, recordingFileID, _ = originalTranscriptFileIDs(post)
+		return editedFileID, recordingFileID, nil
+	}
+	return originalTranscriptFileIDs(post)
+}
+
+func originalTranscriptFileIDs(post *model.Post) (transcriptFileID string, recordingFileID string, err error) {
+	if captionsFileID, captionsErr := GetCaptionsFileIDFromProps(post); captionsErr == nil {
+		if len(post.FileIds) == 1 {
+			recordingFileID = post.FileIds[0]
+		}
+		return captionsFileID, recordingFileID, nil
+	}
+
+	if referencedRecordingFileID, ok := post.GetProp(ReferencedRecordingFileID).(string); ok && referencedRecordingFileID != "" {
+		if len(post.FileIds) == 0 {
+			return "", "", ErrNoTranscript
+		}
+		// The timeline of meetings recorded in several files doesn't match any single recording
+		if post.GetProp(ReferencedRecordingFileIDs) != nil {
+			referencedRecordingFileID = ""
+		}
+		return post.FileIds[0], referencedRecordingFileID, nil
+	}
+
+	if post.Type == "custom_zoom_chat" && len(post.FileIds) > 0 {
+		return post.FileIds[0], "", nil
+	}
+
+	if len(post.FileIds) == 1 {
+		return post.FileIds[0], "", nil
+	}
+
+	return "", "", ErrNoTranscript
+}
+
+// TranscriptFileIDs is a wrapper method to make transcriptFileIDs available via the Service
+func (s *Service) TranscriptFileIDs(post *model.Post) (transcriptFileID string, recordingFileID string, err error) {
+	return transcriptFileIDs(post)
+}
+
+// TranscriptExport is a transcript formatted for download.
+type TranscriptExport struct {
+	Filename    string
+	ContentType string
+	Content     string
+}
+
+// readTranscript reads and parses the transcript file of the post.
+func (s *Service) readTranscript(post *model.Post, transcriptFileID string) (*subtitles.Subtitles, error) {
+	fileInfo, err := s.pluginAPI.File.GetInfo(transcriptFileID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get transcript file info: %w", err)
+	}
+
+	if fileInfo.ChannelId != post.ChannelId {
+		return nil, errors.New("transcript file is not in the post channel")
+	}
+
+	fileReader, err := s.pluginAPI.File.Get(transcriptFileID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read transcript file: %w", err)
+	}
+
+	transcript, err := subtitles.NewSubtitlesFromTranscript(fileReader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse transcript file: %w", err)
+	}
+
+	return transcript, nil
+}
+
+// GetTranscript returns the parsed transcript referenced by the post.
+func (s *Service) GetTranscript(post *model.Post) (*Transcript, error) {
+	transcriptFileID, recordingFileID, err := transcriptFileIDs(post)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript, err := s.readTranscript(post, transcriptFileID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transcript{
+		PostID:          post.Id,
+		FileID:          transcriptFileID,
+		RecordingFileID: recordingFileID,
+		Segments:        transcript.Segments(),
+	}, nil
+}
+
+// TranscriptForLLM returns the transcript referenced by the post formatted to be sent to a language model.
+func (s *Service) TranscriptForLLM(post *model.Post) (string, error) {
+	transcriptFileID, _, err := transcriptFileIDs(post)
+	if err != nil {
+		return "", err
+	}
+
+	transcript, err := s.readTranscript(post, transcriptFileID)
+	if err != nil {
+		return "", err
+	}
+
+	return transcript.FormatForLLM(), nil
+}
+
+// ExportTranscript formats the transcript referenced by the post for download in one of the transcript formats.
+func (s *Service) ExportTranscript(post *model.Post, format string) (*TranscriptExport, error) {
+	transcriptFileID, _, err := transcriptFileIDs(post)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript, err := s.readTranscript(post, transcriptFileID)
+	if err != nil {
+		return nil, err
+	}
+
+	return formatTranscriptExport(transcript, format)
+}
+
+// formatTranscriptExport formats the transcript in one of the transcript formats.
+func formatTranscriptExport(transcript *subtitles.Subtitles, format string) (*TranscriptExport, error) {
+	export := &TranscriptExport{
+		Filename: "transcript." + format,
+	}
+	switch format {
+	case TranscriptFormatSRT:
+		export.ContentType = "application/x-subrip"
+		export.Content = transcript.FormatSRT()
+	case TranscriptFormatVTT:
+		export.ContentType = "text/vtt"
+		export.Content = transcript.FormatVTT()
+	case TranscriptFormatText:
+		export.ContentType = "text/plain"
+		export.Content = transcript.FormatForLLM()
+	case TranscriptFormatJSON:
+		export.ContentType = "application/json"
+		export.Content = transcript.FormatJSON()
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTranscriptFormat, format)
+	}
+	return export, nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// EditedTranscriptFileIDProp is the corrected transcript of a summary, used in place of the original
+	// transcript when the summary is regenerated
+	EditedTranscriptFileIDProp = "edited_transcript_file_id"
+
+	// OriginalTranscriptFileIDProp links the corrected transcript of a summary to the transcript it corrects
+	OriginalTranscriptFileIDProp = "original_transcript_file_id"
+
+	// editedTranscriptFileName is the name of corrected transcripts attached to summaries
+	editedTranscriptFileName = "transcript_edited.vtt"
+)
+
+var (
+	// ErrInvalidTranscript is returned when a corrected transcript has no segments or segments with invalid times.
+	ErrInvalidTranscript = errors.New("invalid transcript")
+
+	// ErrTranscriptEditNotRequester is returned when someone else than the user who asked for a meeting
+	// summary edits its transcript.
+	ErrTranscriptEditNotRequester = errors.New("only the original requester can edit the transcript")
+)
+
+// EditTranscript stores a corrected version of the transcript of a meeting summary, such as one with names
+// fixed or noise removed, and attaches it to the summary. The original transcript is kept and linked from
+// the summary. Regenerating the summary afterwards uses the corrected transcript.
+func (s *Service) EditTranscript(userID string, summaryPost *model.Post, segments []subtitles.Segment) (*Transcript, error) {
+	if summaryPost.GetProp(streaming.LLMRequesterUserID) != userID {
+		return nil, ErrTranscriptEditNotRequester
+	}
+	if err := checkTranscriptSegments(segments); err != nil {
+		return nil, err
+	}
+
+	originalFileID, err := s.originalTranscriptFileID(summaryPost)
+	if err != nil {
+		return nil, err
+	}
+
+	edited := subtitles.NewSubtitlesFromSegments(segments)
+	fileInfo, err := s.pluginAPI.File.Upload(strings.NewReader(edited.FormatVTT()), editedTranscriptFileName, summaryPost.ChannelId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to upload edited transcript: %w", err)
+	}
+	if err = s.claimFile(summaryPost, fileInfo.Id); err != nil {
+		return nil, err
+	}
+
+	// Only the latest correction stays attached next to the original transcript
+	if previousFileID, ok := summaryPost.GetProp(EditedTranscriptFileIDProp).(string); ok && previousFileID != "" {
+		summaryPost.FileIds = slices.DeleteFunc(summaryPost.FileIds, func(fileID string) bool { return fileID == previousFileID })
+	}
+	summaryPost.FileIds = append(summaryPost.FileIds, fileInfo.Id)
+	summaryPost.AddProp(EditedTranscriptFileIDProp, fileInfo.Id)
+	summaryPost.AddProp(OriginalTranscriptFileIDProp, originalFileID)
+	if err = s.pluginAPI.Post.UpdatePost(summaryPost); err != nil {
+		return nil, fmt.Errorf("unable to update summary post: %w", err)
+	}
+
+	_, recordingFileID, _ := transcriptFileIDs(summaryPost)
+	return &Transcript{
+		PostID:          summaryPost.Id,
+		FileID:          fileInfo.Id,
+		RecordingFileID: recordingFileID,
+		Segments:        edited.Segments(),
+	}, nil
+}
+
+// originalTranscriptFileID returns the transcript a summary was written from before any correction.
+func (s *Service) originalTranscriptFileID(summaryPost *model.Post) (string, error) {
+	if originalFileID, ok := summaryPost.GetProp(OriginalTranscriptFileIDProp).(string); ok && originalFileID != "" {
+		return originalFileID, nil
+	}
+
+	// Summaries of recordings hold their transcript, other summaries reference the post of the transcript
+	if transcriptionPostID, ok := summaryPost.GetProp(ReferencedTranscriptPostID).(string); ok && transcriptionPostID != "" {
+		transcriptionPost, err := s.pluginAPI.Post.GetPost(transcriptionPostID)
+		if err != nil {
+			return "", fmt.Errorf("unable to get transcription post: %w", err)
+		}
+		transcriptFileID, _, err := originalTranscriptFileIDs(transcriptionPost)
+		return transcriptFileID, err
+	}
+	if summaryPost.GetProp(ReferencedRecordingFileID) != nil {
+		transcriptFileID, _, err := originalTranscriptFileIDs(summaryPost)
+		return transcriptFileID, err
+	}
+
+	return "", ErrNotMeetingSummary
+}
+
+// checkTranscriptSegments checks a corrected transcript has segments, each with text and an end after its start.
+func checkTranscriptSegments(segments []subtitles.Segment) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("%w: no segments", ErrInvalidTranscript)
+	}
+	for i, segment := range segments {
+		if strings.TrimSpace(segment.Text) == "" {
+			return fmt.Errorf("%w: segment %d has no text", ErrInvalidTranscript, i+1)
+		}
+		if segment.StartMS < 0 || segment.EndMS < segment.StartMS {
+			return fmt.Errorf("%w: segment %d ends before it starts", ErrInvalidTranscript, i+1)
+		}
+	}
+	return nil
+}
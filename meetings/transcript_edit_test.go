@@ -0,0 +1,63 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTranscriptSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []subtitles.Segment
+		wantErr  bool
+	}{
+		{name: "valid", segments: []subtitles.Segment{{StartMS: 0, EndMS: 1000, Speaker: "Jane Doe", Text: "Welcome"}}},
+		{name: "instant segment", segments: []subtitles.Segment{{StartMS: 1000, EndMS: 1000, Text: "Hi"}}},
+		{name: "no segments", segments: []subtitles.Segment{}, wantErr: true},
+		{name: "no text", segments: []subtitles.Segment{{StartMS: 0, EndMS: 1000, Text: "  "}}, wantErr: true},
+		{name: "ends before it starts", segments: []subtitles.Segment{{StartMS: 2000, EndMS: 1000, Text: "Hi"}}, wantErr: true},
+		{name: "negative start", segments: []subtitles.Segment{{StartMS: -1, EndMS: 1000, Text: "Hi"}}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkTranscriptSegments(tc.segments)
+			if tc.wantErr {
+				require.ErrorIs(t, err, ErrInvalidTranscript)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestEditTranscriptNotRequester(t *testing.T) {
+	summaryPost := &model.Post{Id: "summaryid"}
+	summaryPost.AddProp(streaming.LLMRequesterUserID, "requester")
+
+	_, err := (&Service{}).EditTranscript("other", summaryPost, []subtitles.Segment{{StartMS: 0, EndMS: 1000, Text: "Welcome"}})
+	assert.ErrorIs(t, err, ErrTranscriptEditNotRequester)
+}
+
+func TestTranscriptFileIDsEdited(t *testing.T) {
+	post := &model.Post{FileIds: []string{"transcript", "edited"}}
+	post.AddProp(ReferencedRecordingFileID, "recording")
+	post.AddProp(EditedTranscriptFileIDProp, "edited")
+
+	transcriptFileID, recordingFileID, err := transcriptFileIDs(post)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", transcriptFileID)
+	assert.Equal(t, "recording", recordingFileID)
+
+	originalFileID, _, err := originalTranscriptFileIDs(post)
+	require.NoError(t, err)
+	assert.Equal(t, "transcript", originalFileID)
+}
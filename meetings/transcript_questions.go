@@ -0,0 +1,349 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+const (
+	// transcriptChunkChars is the approximate size of the transcript excerpts used for retrieval
+	transcriptChunkChars = 2000
+
+	// maxTranscriptExcerpts is the number of excerpts given to the model when the transcript is too long
+	maxTranscriptExcerpts = 6
+
+	// maxCachedTranscriptEmbeddings is how many transcripts the excerpt embeddings are kept for
+	maxCachedTranscriptEmbeddings = 32
+
+	// transcriptEmbeddingTimeout bounds embedding the excerpts and the question before falling back to keywords
+	transcriptEmbeddingTimeout = 30 * time.Second
+)
+
+// ErrTranscriptNotFound is returned when the transcript a meeting summary thread was created from was deleted.
+var ErrTranscriptNotFound = errors.New("transcript not found")
+
+// transcriptChunk is a run of consecutive transcript segments.
+type transcriptChunk struct {
+	StartMS int64
+	Text    string
+}
+
+// transcriptEmbeddingCache keeps the embeddings of the excerpts of the latest transcripts asked about, so
+// follow-up questions only embed the question.
+type transcriptEmbeddingCache struct {
+	mu      sync.Mutex
+	order   []string
+	vectors map[string][][]float32
+}
+
+func newTranscriptEmbeddingCache() *transcriptEmbeddingCache {
+	return &transcriptEmbeddingCache{vectors: make(map[string][][]float32)}
+}
+
+func (c *transcriptEmbeddingCache) get(key string) ([][]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	vectors, ok := c.vectors[key]
+	return vectors, ok
+}
+
+// add keeps the embeddings of a transcript, forgetting the oldest transcript once full.
+func (c *transcriptEmbeddingCache) add(key string, vectors [][]float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.vectors[key]; !exists {
+		if len(c.order) >= maxCachedTranscriptEmbeddings {
+			delete(c.vectors, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.vectors[key] = vectors
+}
+
+// SetEmbeddingProvider sets the provider used to rank transcript excerpts by relevance, forgetting the
+// embeddings made with the previous one. Without one, excerpts are ranked by the words they share with
+// the question.
+func (s *Service) SetEmbeddingProvider(provider embeddings.EmbeddingProvider) {
+	s.embeddingMu.Lock()
+	defer s.embeddingMu.Unlock()
+	s.embeddingProvider = provider
+	s.transcriptEmbeddings = newTranscriptEmbeddingCache()
+}
+
+// transcriptEmbedder returns the embedding provider along with the embeddings made with it.
+func (s *Service) transcriptEmbedder() (embeddings.EmbeddingProvider, *transcriptEmbeddingCache) {
+	s.embeddingMu.RLock()
+	defer s.embeddingMu.RUnlock()
+	return s.embeddingProvider, s.transcriptEmbeddings
+}
+
+// TranscriptQuestionPrompt returns the system prompt for a follow-up question in a meeting summary thread,
+// including the parts of the transcript relevant to the question.
+// An empty prompt is returned when the thread isn't a meeting summary thread.
+func (s *Service) TranscriptQuestionPrompt(bot *bots.Bot, threadPosts []*model.Post, question string, llmContext *llm.Context) (string, error) {
+	transcriptPost, err := s.findThreadTranscriptPost(bot, threadPosts)
+	if err != nil {
+		return "", err
+	}
+	if transcriptPost == nil {
+		return "", nil
+	}
+
+	if !s.pluginAPI.User.HasPermissionToChannel(llmContext.RequestingUser.Id, transcriptPost.ChannelId, model.PermissionReadChannel) {
+		return "", errors.New("user doesn't have permission to read the transcript channel")
+	}
+
+	transcript, err := s.GetTranscript(transcriptPost)
+	if err != nil {
+		return "", fmt.Errorf("unable to get transcript: %w", err)
+	}
+
+	chunks := chunkTranscript(transcript.Segments, transcriptChunkChars)
+	fullTranscript := joinTranscriptChunks(chunks)
+
+	isPartial := false
+	tokenLimit := int(float64(bot.LLM().InputTokenLimit())*0.5) - ContextTokenMargin
+	if bot.LLM().CountTokens(fullTranscript) > tokenLimit {
+		chunks = s.relevantTranscriptChunks(chunks, transcriptEmbeddingKey(transcriptPost), question, maxTranscriptExcerpts)
+		fullTranscript = joinTranscriptChunks(chunks)
+		isPartial = true
+	}
+
+	// A separate context so the parameters don't leak into the prompts formatted later from it
+	questionContext := *llmContext
+	questionContext.Parameters = map[string]any{
+		"Transcript": fullTranscript,
+		"IsPartial":  fmt.Sprintf("%t", isPartial),
+	}
+	prompt, err := s.prompts.Format(prompts.PromptMeetingTranscriptQuestionSystem, &questionContext)
+	if err != nil {
+		return "", fmt.Errorf("unable to format transcript question prompt: %w", err)
+	}
+
+	return prompt, nil
+}
+
+// findThreadTranscriptPost returns the post holding the transcript a meeting summary thread was created from.
+func (s *Service) findThreadTranscriptPost(bot *bots.Bot, threadPosts []*model.Post) (*model.Post, error) {
+	for _, post := range threadPosts {
+		if post.UserId != bot.GetMMBot().UserId {
+			continue
+		}
+
+		if transcriptPostID, ok := post.GetProp(ReferencedTranscriptPostID).(string); ok && transcriptPostID != "" {
+			transcriptPost, err := s.pluginAPI.Post.GetPost(transcriptPostID)
+			if errors.Is(err, pluginapi.ErrNotFound) {
+				return nil, fmt.Errorf("%w: post %s", ErrTranscriptNotFound, transcriptPostID)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("unable to get transcription post: %w", err)
+			}
+			return transcriptPost, nil
+		}
+
+		if recordingFileID, ok := post.GetProp(ReferencedRecordingFileID).(string); ok && recordingFileID != "" && len(post.FileIds) > 0 {
+			return post, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// chunkTranscript groups consecutive segments into chunks of about maxChars characters.
+// Each line keeps its timestamp and speaker so the model can quote it accurately.
+func chunkTranscript(segments []subtitles.Segment, maxChars int) []transcriptChunk {
+	var chunks []transcriptChunk
+	var current strings.Builder
+	var currentStart int64
+	for _, segment := range segments {
+		line := formatTranscriptLine(segment)
+		if current.Len() > 0 && current.Len()+len(line) > maxChars {
+			chunks = append(chunks, transcriptChunk{StartMS: currentStart, Text: strings.TrimSpace(current.String())})
+			current.Reset()
+		}
+		if current.Len() == 0 {
+			currentStart = segment.StartMS
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, transcriptChunk{StartMS: currentStart, Text: strings.TrimSpace(current.String())})
+	}
+
+	return chunks
+}
+
+func formatTranscriptLine(segment subtitles.Segment) string {
+	timestamp := (time.Duration(segment.StartMS) * time.Millisecond).Round(time.Second)
+	hours := int(timestamp.Hours())
+	minutes := int(timestamp.Minutes()) % 60
+	seconds := int(timestamp.Seconds()) % 60
+
+	var line strings.Builder
+	if hours > 0 {
+		fmt.Fprintf(&line, "[%02d:%02d:%02d] ", hours, minutes, seconds)
+	} else {
+		fmt.Fprintf(&line, "[%02d:%02d] ", minutes, seconds)
+	}
+	if segment.Speaker != "" {
+		line.WriteString(segment.Speaker)
+		line.WriteString(": ")
+	}
+	line.WriteString(segment.Text)
+	line.WriteString("\n")
+
+	return line.String()
+}
+
+func joinTranscriptChunks(chunks []transcriptChunk) string {
+	texts := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		texts = append(texts, chunk.Text)
+	}
+	return strings.Join(texts, "\n...\n")
+}
+
+// transcriptEmbeddingKey identifies the version of the transcript of the post the excerpts were embedded from.
+func transcriptEmbeddingKey(transcriptPost *model.Post) string {
+	return transcriptPost.Id + ":" + strconv.FormatInt(transcriptPost.UpdateAt, 10)
+}
+
+// relevantTranscriptChunks returns the limit chunks most relevant to the question in transcript order.
+// Embeddings are used when available, falling back to keyword matching.
+func (s *Service) relevantTranscriptChunks(chunks []transcriptChunk, cacheKey, question string, limit int) []transcriptChunk {
+	if len(chunks) <= limit {
+		return chunks
+	}
+
+	var scores []float64
+	if provider, cache := s.transcriptEmbedder(); provider != nil {
+		var err error
+		scores, err = embeddingScores(provider, cache, cacheKey, chunks, question)
+		if err != nil {
+			s.pluginAPI.Log.Warn("Unable to rank transcript with embeddings, falling back to keywords", "error", err)
+		}
+	}
+	if scores == nil {
+		scores = keywordScores(chunks, question)
+	}
+
+	return topTranscriptChunks(chunks, scores, limit)
+}
+
+// embeddingScores scores chunks by the similarity of their embeddings to the question's. The embeddings
+// of the chunks are cached under the key, only the question is embedded for later questions.
+func embeddingScores(provider embeddings.EmbeddingProvider, cache *transcriptEmbeddingCache, cacheKey string, chunks []transcriptChunk, question string) ([]float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), transcriptEmbeddingTimeout)
+	defer cancel()
+
+	vectors, ok := cache.get(cacheKey)
+	if !ok || len(vectors) != len(chunks) {
+		texts := make([]string, 0, len(chunks))
+		for _, chunk := range chunks {
+			texts = append(texts, chunk.Text)
+		}
+		var err error
+		vectors, err = provider.BatchCreateEmbeddings(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(chunks) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(chunks), len(vectors))
+		}
+		cache.add(cacheKey, vectors)
+	}
+
+	questionVector, err := provider.CreateEmbedding(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, 0, len(vectors))
+	for _, vector := range vectors {
+		scores = append(scores, embeddings.CosineSimilarity(questionVector, vector))
+	}
+	return scores, nil
+}
+
+// keywordScores scores chunks by the question words they contain, weighting rarer words higher.
+func keywordScores(chunks []transcriptChunk, question string) []float64 {
+	chunkWords := make([]map[string]bool, len(chunks))
+	documentFrequency := map[string]int{}
+	for i, chunk := range chunks {
+		chunkWords[i] = map[string]bool{}
+		for _, word := range keywords(chunk.Text) {
+			if !chunkWords[i][word] {
+				chunkWords[i][word] = true
+				documentFrequency[word]++
+			}
+		}
+	}
+
+	scores := make([]float64, len(chunks))
+	for _, word := range keywords(question) {
+		if documentFrequency[word] == 0 {
+			continue
+		}
+		weight := math.Log(1 + float64(len(chunks))/float64(documentFrequency[word]))
+		for i := range chunks {
+			if chunkWords[i][word] {
+				scores[i] += weight
+			}
+		}
+	}
+	return scores
+}
+
+// keywords returns the lowercase words of text, skipping very short words.
+func keywords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+
+	result := make([]string, 0, len(words))
+	for _, word := range words {
+		if len([]rune(word)) > 2 {
+			result = append(result, word)
+		}
+	}
+	return result
+}
+
+func topTranscriptChunks(chunks []transcriptChunk, scores []float64, limit int) []transcriptChunk {
+	indexes := make([]int, len(chunks))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return scores[indexes[i]] > scores[indexes[j]]
+	})
+
+	indexes = indexes[:limit]
+	sort.Ints(indexes)
+
+	result := make([]transcriptChunk, 0, limit)
+	for _, index := range indexes {
+		result = append(result, chunks[index])
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkTranscript(t *testing.T) {
+	segments := []subtitles.Segment{
+		{StartMS: 0, Speaker: "Maria", Text: "Welcome everyone."},
+		{StartMS: 65000, Speaker: "Maria", Text: "Let's talk about the budget."},
+		{StartMS: 3725000, Text: "Thanks for joining."},
+	}
+
+	tests := []struct {
+		name     string
+		maxChars int
+		want     []transcriptChunk
+	}{
+		{
+			name:     "single chunk",
+			maxChars: 1000,
+			want: []transcriptChunk{
+				{StartMS: 0, Text: "[00:00] Maria: Welcome everyone.\n[01:05] Maria: Let's talk about the budget.\n[01:02:05] Thanks for joining."},
+			},
+		},
+		{
+			name:     "split between segments",
+			maxChars: 80,
+			want: []transcriptChunk{
+				{StartMS: 0, Text: "[00:00] Maria: Welcome everyone.\n[01:05] Maria: Let's talk about the budget."},
+				{StartMS: 3725000, Text: "[01:02:05] Thanks for joining."},
+			},
+		},
+		{
+			name:     "segments longer than the chunk size are kept whole",
+			maxChars: 10,
+			want: []transcriptChunk{
+				{StartMS: 0, Text: "[00:00] Maria: Welcome everyone."},
+				{StartMS: 65000, Text: "[01:05] Maria: Let's talk about the budget."},
+				{StartMS: 3725000, Text: "[01:02:05] Thanks for joining."},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, chunkTranscript(segments, tc.maxChars))
+		})
+	}
+}
+
+func TestTopTranscriptChunksByKeywords(t *testing.T) {
+	chunks := []transcriptChunk{
+		{StartMS: 0, Text: "Maria: the roadmap for next quarter"},
+		{StartMS: 1000, Text: "Maria: the budget needs another review"},
+		{StartMS: 2000, Text: "John: the hiring plan is on track"},
+		{StartMS: 3000, Text: "John: the budget was approved by finance"},
+	}
+
+	tests := []struct {
+		name     string
+		question string
+		limit    int
+		want     []int64
+	}{
+		{
+			name:     "matching chunks in transcript order",
+			question: "What did they say about the budget?",
+			limit:    2,
+			want:     []int64{1000, 3000},
+		},
+		{
+			name:     "rarer words rank higher",
+			question: "What did Maria say about the budget?",
+			limit:    1,
+			want:     []int64{1000},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := topTranscriptChunks(chunks, keywordScores(chunks, tc.question), tc.limit)
+			starts := make([]int64, 0, len(result))
+			for _, chunk := range result {
+				starts = append(starts, chunk.StartMS)
+			}
+			assert.Equal(t, tc.want, starts)
+		})
+	}
+}
+
+// keywordEmbeddingProvider embeds texts by whether they mention budgets, counting the texts it embeds.
+type keywordEmbeddingProvider struct {
+	embedded int
+}
+
+func (p *keywordEmbeddingProvider) CreateEmbedding(_ context.Context, text string) ([]float32, error) {
+	p.embedded++
+	if strings.Contains(text, "budget") {
+		return []float32{1, 0}, nil
+	}
+	return []float32{0, 1}, nil
+}
+
+func (p *keywordEmbeddingProvider) BatchCreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		vector, _ := p.CreateEmbedding(ctx, text)
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+func (p *keywordEmbeddingProvider) Dimensions() int {
+	return 2
+}
+
+func TestRelevantTranscriptChunks(t *testing.T) {
+	chunks := []transcriptChunk{
+		{StartMS: 0, Text: "welcome everyone"},
+		{StartMS: 1000, Text: "the budget is approved"},
+		{StartMS: 2000, Text: "see you next week"},
+	}
+
+	provider := &keywordEmbeddingProvider{}
+	service := &Service{}
+	service.SetEmbeddingProvider(provider)
+
+	relevant := service.relevantTranscriptChunks(chunks, "post:1", "what about the budget?", 1)
+	assert.Equal(t, []transcriptChunk{chunks[1]}, relevant)
+	assert.Equal(t, 4, provider.embedded)
+
+	t.Run("only embeds the question of later questions", func(t *testing.T) {
+		relevant := service.relevantTranscriptChunks(chunks, "post:1", "when do we meet again?", 1)
+		require.Len(t, relevant, 1)
+		assert.Equal(t, 5, provider.embedded)
+	})
+
+	t.Run("embeds the excerpts again with a new provider", func(t *testing.T) {
+		newProvider := &keywordEmbeddingProvider{}
+		service.SetEmbeddingProvider(newProvider)
+
+		service.relevantTranscriptChunks(chunks, "post:1", "what about the budget?", 1)
+		assert.Equal(t, 4, newProvider.embedded)
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscriptFileIDs(t *testing.T) {
+	tests := []struct {
+		name                string
+		post                func() *model.Post
+		wantTranscriptID    string
+		wantRecordingFileID string
+		wantErr             error
+	}{
+		{
+			name: "calls recording with captions",
+			post: func() *model.Post {
+				post := &model.Post{FileIds: []string{"recording"}}
+				post.AddProp("captions", []any{map[string]any{"file_id": "captions"}})
+				return post
+			},
+			wantTranscriptID:    "captions",
+			wantRecordingFileID: "recording",
+		},
+		{
+			name: "transcript of a recording",
+			post: func() *model.Post {
+				post := &model.Post{FileIds: []string{"transcript"}}
+				post.AddProp(ReferencedRecordingFileID, "recording")
+				return post
+			},
+			wantTranscriptID:    "transcript",
+			wantRecordingFileID: "recording",
+		},
+		{
+			name: "transcript of several recordings",
+			post: func() *model.Post {
+				post := &model.Post{FileIds: []string{"transcript"}}
+				post.AddProp(ReferencedRecordingFileID, "recording1")
+				post.AddProp(ReferencedRecordingFileIDs, []string{"recording1", "recording2"})
+				return post
+			},
+			wantTranscriptID: "transcript",
+		},
+		{
+			name: "zoom chat",
+			post: func() *model.Post {
+				return &model.Post{Type: "custom_zoom_chat", FileIds: []string{"chat"}}
+			},
+			wantTranscriptID: "chat",
+		},
+		{
+			name: "uploaded transcript",
+			post: func() *model.Post {
+				return &model.Post{FileIds: []string{"upload"}}
+			},
+			wantTranscriptID: "upload",
+		},
+		{
+			name: "several attachments",
+			post: func() *model.Post {
+				return &model.Post{FileIds: []string{"a", "b"}}
+			},
+			wantErr: ErrNoTranscript,
+		},
+		{
+			name: "no attachments",
+			post: func() *model.Post {
+				return &model.Post{}
+			},
+			wantErr: ErrNoTranscript,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			transcriptID, recordingFileID, err := transcriptFileIDs(tc.post())
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantTranscriptID, transcriptID)
+			assert.Equal(t, tc.wantRecordingFileID, recordingFileID)
+		})
+	}
+}
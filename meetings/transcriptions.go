@@ -9,7 +9,9 @@ import (
 	"slices"
 
 	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/conversations"
 	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
 	"github.com/mattermost/mattermost/server/public/model"
 )
 
@@ -17,26 +19,53 @@ const (
 	ReferencedRecordingFileID  = "referenced_recording_file_id"
 	ReferencedTranscriptPostID = "referenced_transcript_post_id"
 
+	// ReferencedRecordingFileIDs lists the recordings of meetings recorded in several files, in order
+	ReferencedRecordingFileIDs = "referenced_recording_file_ids"
+
+	// TranscriptionLanguageProp is the language a recording was transcribed in, as requested or detected
+	TranscriptionLanguageProp = "transcription_language"
+
+	// TranscriptRangeProp is the part of the transcript a summary was written from, when not the whole transcript
+	TranscriptRangeProp = "transcript_range"
+
 	TitleMeetingSummary = "Meeting Summary"
 )
 
-// HandleTranscribeFile handles file transcription requests
-func (s *Service) HandleTranscribeFile(userID string, bot *bots.Bot, post *model.Post, channel *model.Channel, fileID string) (map[string]string, error) {
-	user, err := s.pluginAPI.User.Get(userID)
+// ErrNoRecordings is returned when a transcription request doesn't name any recording.
+var ErrNoRecordings = errors.New("no recordings to transcribe")
+
+// HandleTranscribeFile handles file transcription requests. The recordings are transcribed in the
+// language given as an ISO-639-1 code, or in the channel's configured language when empty, and
+// summarized together as one meeting, in the order given.
+// When translate is set the transcript is translated into the user's locale before it is summarized.
+// The summary is written in the format given, see ParseSummaryFormat.
+func (s *Service) HandleTranscribeFile(userID string, bot *bots.Bot, post *model.Post, channel *model.Channel, fileIDs []string, language string, translate bool, format string) (map[string]string, error) {
+	if len(fileIDs) == 0 {
+		return nil, ErrNoRecordings
+	}
+
+	format, err := s.ParseSummaryFormat(format)
 	if err != nil {
 		return nil, err
 	}
 
-	recordingFileInfo, err := s.pluginAPI.File.GetInfo(fileID)
+	user, err := s.pluginAPI.User.Get(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	if recordingFileInfo.ChannelId != channel.Id || !slices.Contains(post.FileIds, fileID) {
-		return nil, errors.New("file not attached to specified post")
+	for _, fileID := range fileIDs {
+		recordingFileInfo, err := s.pluginAPI.File.GetInfo(fileID)
+		if err != nil {
+			return nil, err
+		}
+
+		if recordingFileInfo.ChannelId != channel.Id || !slices.Contains(post.FileIds, fileID) {
+			return nil, errors.New("file not attached to specified post")
+		}
 	}
 
-	createdPost, err := s.newCallRecordingThread(bot, user, post, channel, fileID)
+	createdPost, err := s.newCallRecordingThread(bot, user, post, channel, fileIDs, language, translate, format)
 	if err != nil {
 		return nil, err
 	}
@@ -44,6 +73,7 @@ func (s *Service) HandleTranscribeFile(userID string, bot *bots.Bot, post *model
 	if err := s.conversations.SaveTitle(createdPost.Id, TitleMeetingSummary); err != nil {
 		return nil, fmt.Errorf("failed to save title: %w", err)
 	}
+	s.conversations.TagThreadAsync(createdPost.Id, conversations.CategoryMeeting)
 
 	return map[string]string{
 		"postid":    createdPost.Id,
@@ -51,8 +81,16 @@ func (s *Service) HandleTranscribeFile(userID string, bot *bots.Bot, post *model
 	}, nil
 }
 
-// HandleSummarizeTranscription handles transcription summarization requests
-func (s *Service) HandleSummarizeTranscription(userID string, bot *bots.Bot, post *model.Post, channel *model.Channel) (map[string]string, error) {
+// HandleSummarizeTranscription handles transcription summarization requests.
+// When translate is set the transcript is translated into the user's locale before it is summarized.
+// The summary is written in the format given, see ParseSummaryFormat, from the part of the transcript
+// in the time range, the whole transcript when the range is zero.
+func (s *Service) HandleSummarizeTranscription(userID string, bot *bots.Bot, post *model.Post, channel *model.Channel, translate bool, format string, timeRange subtitles.TimeRange) (map[string]string, error) {
+	format, err := s.ParseSummaryFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
 	user, err := s.pluginAPI.User.Get(userID)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get user: %w", err)
@@ -63,16 +101,20 @@ func (s *Service) HandleSummarizeTranscription(userID string, bot *bots.Bot, pos
 		return nil, fmt.Errorf("unable to get calls user: %w", err)
 	}
 
-	if !targetPostUser.IsBot || (targetPostUser.Username != CallsBotUsername && targetPostUser.Username != ZoomBotUsername) {
-		return nil, errors.New("not a calls or zoom bot post")
+	// Posts from the Calls and Zoom bots reference their transcripts, any other post can be
+	// summarized when its only attachment is a transcript
+	isMeetingBotPost := targetPostUser.IsBot && (targetPostUser.Username == CallsBotUsername || targetPostUser.Username == ZoomBotUsername)
+	if !isMeetingBotPost && len(post.FileIds) != 1 {
+		return nil, ErrNoTranscript
 	}
 
-	createdPost, err := s.newCallTranscriptionSummaryThread(bot, user, post, channel)
+	createdPost, err := s.newCallTranscriptionSummaryThread(bot, user, post, channel, translate, format, timeRange)
 	if err != nil {
 		return nil, fmt.Errorf("unable to summarize transcription: %w", err)
 	}
 
 	s.conversations.SaveTitleAsync(createdPost.Id, TitleMeetingSummary)
+	s.conversations.TagThreadAsync(createdPost.Id, conversations.CategoryMeeting)
 
 	return map[string]string{
 		"postid":    createdPost.Id,
@@ -80,8 +122,30 @@ func (s *Service) HandleSummarizeTranscription(userID string, bot *bots.Bot, pos
 	}, nil
 }
 
-// HandlePostbackSummary handles posting back a summary to the original channel
-func (s *Service) HandlePostbackSummary(userID string, post *model.Post) (map[string]string, error) {
+// MaxPostbackChannels is the maximum number of channels a summary can be cross-posted to at once.
+const MaxPostbackChannels = 10
+
+// ErrPostbackNotPermitted is returned when the summary can't be posted to one of the requested channels.
+var ErrPostbackNotPermitted = errors.New("postback not permitted")
+
+// PostbackSummaryPost identifies a post created by posting back a summary.
+type PostbackSummaryPost struct {
+	PostID    string `json:"postid"`
+	RootID    string `json:"rootid"`
+	ChannelID string `json:"channelid"`
+}
+
+// PostbackSummaryResult describes where a summary was posted.
+// RootID and ChannelID refer to the first post so the client can navigate to it.
+type PostbackSummaryResult struct {
+	RootID    string                `json:"rootid"`
+	ChannelID string                `json:"channelid"`
+	Posts     []PostbackSummaryPost `json:"posts"`
+}
+
+// HandlePostbackSummary handles posting back a summary. Without channelIDs the summary is posted
+// to the original transcript thread, otherwise it is posted to each of the given channels.
+func (s *Service) HandlePostbackSummary(userID string, post *model.Post, channelIDs []string) (*PostbackSummaryResult, error) {
 	bot := s.bots.GetBotByID(post.UserId)
 	if bot == nil {
 		return nil, fmt.Errorf("unable to get bot")
@@ -106,24 +170,90 @@ func (s *Service) HandlePostbackSummary(userID string, post *model.Post) (map[st
 		return nil, fmt.Errorf("unable to get transcription post: %w", err)
 	}
 
-	if !s.pluginAPI.User.HasPermissionToChannel(userID, transcriptionPost.ChannelId, model.PermissionCreatePost) {
-		return nil, errors.New("user doesn't have permission to create a post in the transcript channel")
+	targets, err := s.postbackTargets(userID, bot, transcriptionPost, channelIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PostbackSummaryResult{
+		Posts: make([]PostbackSummaryPost, 0, len(targets)),
 	}
+	for _, target := range targets {
+		postedSummary := &model.Post{
+			UserId:    bot.GetMMBot().UserId,
+			ChannelId: target.ChannelId,
+			RootId:    target.RootId,
+			Message:   post.Message,
+			Type:      PostbackPostType,
+		}
+		postedSummary.AddProp("userid", userID)
+		streaming.MarkAIGenerated(bot.GetMMBot().UserId, postedSummary)
+		if err := s.pluginAPI.Post.CreatePost(postedSummary); err != nil {
+			return nil, fmt.Errorf("unable to post back summary: %w", err)
+		}
 
-	postedSummary := &model.Post{
-		UserId:    bot.GetMMBot().UserId,
-		ChannelId: transcriptionPost.ChannelId,
-		RootId:    transcriptionPost.RootId,
-		Message:   post.Message,
-		Type:      "custom_llm_postback",
+		result.Posts = append(result.Posts, PostbackSummaryPost{
+			PostID:    postedSummary.Id,
+			RootID:    postedSummary.RootId,
+			ChannelID: postedSummary.ChannelId,
+		})
 	}
-	postedSummary.AddProp("userid", userID)
-	if err := s.pluginAPI.Post.CreatePost(postedSummary); err != nil {
-		return nil, fmt.Errorf("unable to post back summary: %w", err)
+
+	result.RootID = result.Posts[0].RootID
+	result.ChannelID = result.Posts[0].ChannelID
+
+	return result, nil
+}
+
+// postbackTargets resolves and validates every channel the summary will be posted to.
+// All channels are checked before anything is posted so a bad channel doesn't leave a partial cross-post.
+func (s *Service) postbackTargets(userID string, bot *bots.Bot, transcriptionPost *model.Post, channelIDs []string) ([]*model.Post, error) {
+	if len(channelIDs) == 0 {
+		channelIDs = []string{transcriptionPost.ChannelId}
 	}
 
-	return map[string]string{
-		"rootid":    postedSummary.RootId,
-		"channelid": postedSummary.ChannelId,
-	}, nil
+	uniqueChannelIDs := make([]string, 0, len(channelIDs))
+	for _, channelID := range channelIDs {
+		if !slices.Contains(uniqueChannelIDs, channelID) {
+			uniqueChannelIDs = append(uniqueChannelIDs, channelID)
+		}
+	}
+	channelIDs = uniqueChannelIDs
+
+	if len(channelIDs) > MaxPostbackChannels {
+		return nil, fmt.Errorf("can't post to more than %d channels: %w", MaxPostbackChannels, ErrPostbackNotPermitted)
+	}
+
+	targets := make([]*model.Post, 0, len(channelIDs))
+	for _, channelID := range channelIDs {
+		if !model.IsValidId(channelID) {
+			return nil, fmt.Errorf("invalid channel ID %q: %w", channelID, ErrPostbackNotPermitted)
+		}
+
+		channel, err := s.pluginAPI.Channel.Get(channelID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get channel %s: %w", channelID, err)
+		}
+
+		if channel.DeleteAt != 0 {
+			return nil, fmt.Errorf("channel %s is archived: %w", channelID, ErrPostbackNotPermitted)
+		}
+
+		if !s.pluginAPI.User.HasPermissionToChannel(userID, channelID, model.PermissionCreatePost) {
+			return nil, fmt.Errorf("user doesn't have permission to create a post in channel %s: %w", channelID, ErrPostbackNotPermitted)
+		}
+
+		if err := s.bots.CheckUsageRestrictions(userID, bot, channel); err != nil {
+			return nil, fmt.Errorf("bot can't be used in channel %s: %w", channelID, ErrPostbackNotPermitted)
+		}
+
+		target := &model.Post{ChannelId: channelID}
+		// Keep posting into the call thread when the summary goes back to the transcript channel
+		if channelID == transcriptionPost.ChannelId {
+			target.RootId = transcriptionPost.RootId
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
 }
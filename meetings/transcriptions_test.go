@@ -0,0 +1,131 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlePostbackSummary(t *testing.T) {
+	callChannelID := model.NewId()
+	otherChannelID := model.NewId()
+	blockedChannelID := model.NewId()
+
+	summaryPost := &model.Post{Id: "summaryid", UserId: "botid", RootId: "summaryrootid", Message: "The summary"}
+	summaryPost.AddProp(streaming.LLMRequesterUserID, "requester")
+	summaryRoot := &model.Post{Id: "summaryrootid"}
+	summaryRoot.AddProp(ReferencedTranscriptPostID, "transcriptid")
+	transcriptionPost := &model.Post{Id: "transcriptid", ChannelId: callChannelID, RootId: "callthreadid"}
+
+	setup := func(t *testing.T) (*Service, *plugintest.API) {
+		mockAPI := &plugintest.API{}
+		t.Cleanup(func() { mockAPI.AssertExpectations(t) })
+
+		mmBots := &bots.MMBots{}
+		mmBots.SetBotsForTesting([]*bots.Bot{bots.NewBot(llm.BotConfig{
+			Name:               "ai",
+			ChannelAccessLevel: llm.ChannelAccessLevelBlock,
+			ChannelIDs:         []string{blockedChannelID},
+		}, &model.Bot{UserId: "botid", Username: "ai"})})
+
+		mockAPI.On("GetPost", "summaryrootid").Return(summaryRoot, nil)
+		mockAPI.On("GetPost", "transcriptid").Return(transcriptionPost, nil)
+		for _, channelID := range []string{callChannelID, otherChannelID, blockedChannelID} {
+			mockAPI.On("GetChannel", channelID).Return(&model.Channel{Id: channelID, Type: model.ChannelTypeOpen}, nil).Maybe()
+		}
+
+		return &Service{pluginAPI: pluginapi.NewClient(mockAPI, nil), bots: mmBots}, mockAPI
+	}
+
+	t.Run("posted to each channel the requester can post in", func(t *testing.T) {
+		s, mockAPI := setup(t)
+		mockAPI.On("HasPermissionToChannel", "requester", callChannelID, model.PermissionCreatePost).Return(true)
+		mockAPI.On("HasPermissionToChannel", "requester", otherChannelID, model.PermissionCreatePost).Return(true)
+		var posted []*model.Post
+		mockAPI.On("CreatePost", mock.Anything).Return(func(post *model.Post) (*model.Post, *model.AppError) {
+			assert.Equal(t, PostbackPostType, post.Type)
+			assert.Equal(t, "The summary", post.Message)
+			created := post.Clone()
+			created.Id = model.NewId()
+			posted = append(posted, created)
+			return created, nil
+		})
+
+		result, err := s.HandlePostbackSummary("requester", summaryPost, []string{callChannelID, otherChannelID, otherChannelID})
+		require.NoError(t, err)
+
+		require.Len(t, posted, 2, "channels requested twice are posted to once")
+		assert.Equal(t, "callthreadid", posted[0].RootId, "the summary goes back to the call thread")
+		assert.Empty(t, posted[1].RootId)
+		assert.Equal(t, callChannelID, result.ChannelID)
+		assert.Equal(t, "callthreadid", result.RootID)
+		require.Len(t, result.Posts, 2)
+		assert.Equal(t, posted[1].Id, result.Posts[1].PostID)
+	})
+
+	rejected := []struct {
+		name       string
+		channelIDs []string
+		setup      func(mockAPI *plugintest.API)
+	}{
+		{
+			name:       "channel the requester can't post in",
+			channelIDs: []string{callChannelID, otherChannelID},
+			setup: func(mockAPI *plugintest.API) {
+				mockAPI.On("HasPermissionToChannel", "requester", callChannelID, model.PermissionCreatePost).Return(true)
+				mockAPI.On("HasPermissionToChannel", "requester", otherChannelID, model.PermissionCreatePost).Return(false)
+			},
+		},
+		{
+			name:       "channel the bot is blocked in",
+			channelIDs: []string{blockedChannelID},
+			setup: func(mockAPI *plugintest.API) {
+				mockAPI.On("HasPermissionToChannel", "requester", blockedChannelID, model.PermissionCreatePost).Return(true)
+			},
+		},
+		{
+			name:       "bad channel ID",
+			channelIDs: []string{callChannelID, "../channelid"},
+			setup: func(mockAPI *plugintest.API) {
+				mockAPI.On("HasPermissionToChannel", "requester", callChannelID, model.PermissionCreatePost).Return(true)
+			},
+		},
+		{
+			name:       "too many channels",
+			channelIDs: []string{model.NewId(), model.NewId(), model.NewId(), model.NewId(), model.NewId(), model.NewId(), model.NewId(), model.NewId(), model.NewId(), model.NewId(), model.NewId()},
+			setup:      func(mockAPI *plugintest.API) {},
+		},
+	}
+
+	for _, tc := range rejected {
+		t.Run(tc.name, func(t *testing.T) {
+			s, mockAPI := setup(t)
+			tc.setup(mockAPI)
+
+			_, err := s.HandlePostbackSummary("requester", summaryPost, tc.channelIDs)
+			assert.ErrorIs(t, err, ErrPostbackNotPermitted)
+			mockAPI.AssertNotCalled(t, "CreatePost", mock.Anything)
+		})
+	}
+
+	t.Run("archived channel", func(t *testing.T) {
+		s, mockAPI := setup(t)
+		archivedChannelID := model.NewId()
+		mockAPI.On("GetChannel", archivedChannelID).Return(&model.Channel{Id: archivedChannelID, DeleteAt: 1}, nil)
+
+		_, err := s.HandlePostbackSummary("requester", summaryPost, []string{archivedChannelID})
+		assert.ErrorIs(t, err, ErrPostbackNotPermitted)
+		mockAPI.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+}
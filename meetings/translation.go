@@ -0,0 +1,125 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// TranslatedToProp is the locale a transcript was translated into before it was summarized
+	TranslatedToProp = "transcript_translated_to"
+
+	// translationBatchChars keeps each translation request well within the output limits of common models
+	translationBatchChars = 6000
+)
+
+// requesterLocale returns the locale transcripts are translated into for the user.
+func requesterLocale(user *model.User) string {
+	if user.Locale == "" {
+		return "en"
+	}
+	return user.Locale
+}
+
+// sameLanguage returns true if both languages have the same ISO-639-1 code, such as "de", "de-CH" and
+// "german".
+func sameLanguage(a, b string) bool {
+	code := subtitles.LanguageCode(a)
+	return code != "" && code == subtitles.LanguageCode(b)
+}
+
+// TranslateTranscription translates the text of a transcription into the language of the locale,
+// keeping its timing and speakers. Lines the model doesn't translate are kept in the original language.
+func (s *Service) TranslateTranscription(bot *bots.Bot, transcription *subtitles.Subtitles, locale string, context *llm.Context) (*subtitles.Subtitles, error) {
+	if sameLanguage(transcription.Language(), locale) {
+		return transcription, nil
+	}
+
+	// A separate context so the parameters don't leak into the summary prompts
+	translationContext := *context
+	translationContext.Parameters = map[string]any{
+		"Locale": locale,
+	}
+	systemPrompt, err := s.prompts.Format(prompts.PromptMeetingTranscriptTranslationSystem, &translationContext)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get transcript translation prompt: %w", err)
+	}
+
+	segments := transcription.Segments()
+	for start := 0; start < len(segments); {
+		end, batch := translationBatch(segments, start)
+
+		request := llm.CompletionRequest{
+			Posts: []llm.Post{
+				{
+					Role:    llm.PostRoleSystem,
+					Message: systemPrompt,
+				},
+				{
+					Role:    llm.PostRoleUser,
+					Message: batch,
+				},
+			},
+			Context: &translationContext,
+		}
+		result, err := bot.LLM().ChatCompletionNoStream(request)
+		if err != nil {
+			return nil, fmt.Errorf("unable to translate transcription: %w", err)
+		}
+		applyTranslatedLines(result, segments[start:end], start)
+
+		start = end
+	}
+
+	translated := subtitles.NewSubtitlesFromSegments(segments)
+	translated.SetLanguage(locale)
+	return translated, nil
+}
+
+// translationBatch formats the segments from start as numbered lines up to translationBatchChars
+// and returns the index after the last segment included.
+func translationBatch(segments []subtitles.Segment, start int) (int, string) {
+	var batch strings.Builder
+	end := start
+	for end < len(segments) {
+		line := fmt.Sprintf("%d: %s\n", end, strings.ReplaceAll(segments[end].Text, "\n", " "))
+		// Every batch has at least one line, however long it is
+		if end > start && batch.Len()+len(line) > translationBatchChars {
+			break
+		}
+		batch.WriteString(line)
+		end++
+	}
+	return end, batch.String()
+}
+
+// applyTranslatedLines replaces the text of the segments with the numbered lines of the result.
+// offset is the number of the first segment, lines with other numbers are ignored.
+func applyTranslatedLines(result string, segments []subtitles.Segment, offset int) {
+	for _, line := range strings.Split(result, "\n") {
+		number, text, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimSpace(number))
+		if err != nil {
+			continue
+		}
+		index -= offset
+		text = strings.TrimSpace(text)
+		if index < 0 || index >= len(segments) || text == "" {
+			continue
+		}
+		segments[index].Text = text
+	}
+}
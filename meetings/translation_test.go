@@ -0,0 +1,101 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameLanguage(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "de", b: "de", want: true},
+		{a: "de-DE", b: "de", want: true},
+		{a: "pt_BR", b: "pt-PT", want: true},
+		{a: "EN", b: "en", want: true},
+		{a: "en", b: "de", want: false},
+		{a: "", b: "", want: false},
+		{a: "english", b: "en", want: true},
+		{a: "de-CH", b: "German", want: true},
+		{a: "french", b: "en-US", want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.a+"/"+tc.b, func(t *testing.T) {
+			assert.Equal(t, tc.want, sameLanguage(tc.a, tc.b))
+		})
+	}
+}
+
+func TestTranslationBatch(t *testing.T) {
+	segments := []subtitles.Segment{
+		{Text: "Hallo zusammen."},
+		{Text: strings.Repeat("a", translationBatchChars)},
+		{Text: "Bis\nbald."},
+	}
+
+	end, batch := translationBatch(segments, 0)
+	assert.Equal(t, 1, end)
+	assert.Equal(t, "0: Hallo zusammen.\n", batch)
+
+	// A line longer than the batch is still sent on its own
+	end, batch = translationBatch(segments, 1)
+	assert.Equal(t, 2, end)
+	assert.True(t, strings.HasPrefix(batch, "1: aaa"))
+
+	end, batch = translationBatch(segments, 2)
+	assert.Equal(t, 3, end)
+	assert.Equal(t, "2: Bis bald.\n", batch)
+}
+
+func TestApplyTranslatedLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		want   []string
+	}{
+		{
+			name:   "all lines",
+			result: "10: Hello everyone.\n11: See you soon.",
+			want:   []string{"Hello everyone.", "See you soon."},
+		},
+		{
+			name:   "missing lines keep the original",
+			result: "11: See you soon.",
+			want:   []string{"Hallo zusammen.", "See you soon."},
+		},
+		{
+			name:   "ignores other lines and numbers",
+			result: "Here is the translation:\n9: Before\n10: Hello everyone.\n12: After\n11:",
+			want:   []string{"Hello everyone.", "Bis bald."},
+		},
+		{
+			name:   "keeps colons in the text",
+			result: "10: Agenda: budget\n11: See you soon.",
+			want:   []string{"Agenda: budget", "See you soon."},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			segments := []subtitles.Segment{
+				{Text: "Hallo zusammen."},
+				{Text: "Bis bald."},
+			}
+			applyTranslatedLines(tc.result, segments, 10)
+
+			texts := make([]string, 0, len(segments))
+			for _, segment := range segments {
+				texts = append(texts, segment.Text)
+			}
+			assert.Equal(t, tc.want, texts)
+		})
+	}
+}
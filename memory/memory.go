@@ -0,0 +1,74 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package memory keeps the facts bots remember about users across conversations, such as their role,
+// preferences and ongoing projects. Users can list and delete what is remembered about them.
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxMemoriesPerUser keeps the memories added to every request of a user short
+	MaxMemoriesPerUser = 50
+
+	maxContentLength = 500
+)
+
+var (
+	// ErrInvalidMemory is returned when remembering an empty fact or one that is too long.
+	ErrInvalidMemory = errors.New("invalid memory")
+
+	// ErrTooManyMemories is returned when remembering a fact about a user with MaxMemoriesPerUser memories.
+	ErrTooManyMemories = errors.New("too many memories")
+
+	// ErrMemoryNotFound is returned when deleting a memory that doesn't exist or is about another user.
+	ErrMemoryNotFound = errors.New("memory not found")
+)
+
+// Memory is a fact a bot remembers about a user.
+type Memory struct {
+	ID       string `json:"id"`
+	UserID   string `json:"userId"`
+	Content  string `json:"content"`
+	CreateAt int64  `json:"createAt"`
+}
+
+// normalizeContent collapses the whitespace of a fact, which is kept on a single line.
+func normalizeContent(content string) string {
+	return strings.Join(strings.Fields(content), " ")
+}
+
+func validateContent(content string) error {
+	if content == "" {
+		return fmt.Errorf("%w: the fact is empty", ErrInvalidMemory)
+	}
+	if utf8.RuneCountInString(content) > maxContentLength {
+		return fmt.Errorf("%w: the fact is longer than %d characters", ErrInvalidMemory, maxContentLength)
+	}
+	return nil
+}
+
+// find returns the memory with the content, ignoring case and whitespace.
+func find(memories []Memory, content string) (Memory, bool) {
+	content = normalizeContent(content)
+	for _, memory := range memories {
+		if strings.EqualFold(memory.Content, content) {
+			return memory, true
+		}
+	}
+	return Memory{}, false
+}
+
+// Contents returns the content of the memories, in the order they were remembered.
+func Contents(memories []Memory) []string {
+	contents := make([]string, 0, len(memories))
+	for _, memory := range memories {
+		contents = append(contents, memory.Content)
+	}
+	return contents
+}
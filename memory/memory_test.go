@@ -0,0 +1,94 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package memory
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "fact",
+			content: "Works on the mobile team.",
+		},
+		{
+			name:    "empty",
+			content: normalizeContent(" \n\t"),
+			wantErr: true,
+		},
+		{
+			name:    "longest fact",
+			content: strings.Repeat("é", maxContentLength),
+		},
+		{
+			name:    "too long",
+			content: strings.Repeat("a", maxContentLength+1),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateContent(tc.content)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidMemory)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	memories := []Memory{
+		{ID: "role", Content: "Is the release manager of the desktop app."},
+		{ID: "style", Content: "Prefers short answers."},
+	}
+
+	tests := []struct {
+		name       string
+		content    string
+		expectedID string
+	}{
+		{
+			name:       "exact",
+			content:    "Prefers short answers.",
+			expectedID: "style",
+		},
+		{
+			name:       "different case and spacing",
+			content:    "  is the release manager\nof the DESKTOP app. ",
+			expectedID: "role",
+		},
+		{
+			name:    "paraphrased",
+			content: "Likes short answers.",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			memory, found := find(memories, tc.content)
+			if tc.expectedID == "" {
+				assert.False(t, found)
+				return
+			}
+			assert.True(t, found)
+			assert.Equal(t, tc.expectedID, memory.ID)
+		})
+	}
+}
+
+func TestContents(t *testing.T) {
+	assert.Equal(t, []string{}, Contents(nil))
+	assert.Equal(t, []string{"a", "b"}, Contents([]Memory{{Content: "a"}, {Content: "b"}}))
+}
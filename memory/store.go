@@ -0,0 +1,90 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package memory
+
+import (
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Store keeps the memories in the LLM_UserMemory table.
+type Store struct {
+	db *mmapi.DBClient
+}
+
+// NewStore creates a memory store.
+func NewStore(db *mmapi.DBClient) *Store {
+	return &Store{db: db}
+}
+
+var memoryColumns = []string{"ID", "UserID", "Content", "CreateAt"}
+
+// List returns the memories of the user, in the order they were remembered.
+func (s *Store) List(userID string) ([]Memory, error) {
+	memories := []Memory{}
+	if err := s.db.DoQuery(&memories, s.db.Builder().
+		Select(memoryColumns...).
+		From("LLM_UserMemory").
+		Where(sq.Eq{"UserID": userID}).
+		OrderBy("CreateAt", "ID")); err != nil {
+		return nil, fmt.Errorf("failed to list memories: %w", err)
+	}
+	return memories, nil
+}
+
+// Add remembers a fact about the user. A fact already remembered is returned instead of being added again.
+func (s *Store) Add(userID, content string) (*Memory, error) {
+	content = normalizeContent(content)
+	if err := validateContent(content); err != nil {
+		return nil, err
+	}
+
+	memories, err := s.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing, found := find(memories, content); found {
+		return &existing, nil
+	}
+	if len(memories) >= MaxMemoriesPerUser {
+		return nil, ErrTooManyMemories
+	}
+
+	memory := Memory{
+		ID:       model.NewId(),
+		UserID:   userID,
+		Content:  content,
+		CreateAt: model.GetMillis(),
+	}
+	if _, err := s.db.ExecBuilder(s.db.Builder().Insert("LLM_UserMemory").
+		Columns(memoryColumns...).
+		Values(memory.ID, memory.UserID, memory.Content, memory.CreateAt)); err != nil {
+		return nil, fmt.Errorf("failed to add memory: %w", err)
+	}
+
+	return &memory, nil
+}
+
+// Delete forgets the memory with the ID, which must be about the user.
+func (s *Store) Delete(userID, id string) error {
+	result, err := s.db.ExecBuilder(s.db.Builder().Delete("LLM_UserMemory").Where(sq.Eq{"ID": id, "UserID": userID}))
+	if err != nil {
+		return fmt.Errorf("failed to delete memory: %w", err)
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return ErrMemoryNotFound
+	}
+	return nil
+}
+
+// DeleteAll forgets everything remembered about the user.
+func (s *Store) DeleteAll(userID string) error {
+	if _, err := s.db.ExecBuilder(s.db.Builder().Delete("LLM_UserMemory").Where(sq.Eq{"UserID": userID})); err != nil {
+		return fmt.Errorf("failed to delete memories: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+type RememberUserFactArgs struct {
+	Fact string `jsonschema_description:"The fact to remember about the user, written as a short standalone sentence in the third person. Example: 'Works on the mobile team and prefers answers with code samples.'"`
+}
+
+type ForgetUserFactArgs struct {
+	Fact string `jsonschema_description:"The fact to forget, exactly as it is listed in what you remember about the user."`
+}
+
+// Tools returns the tools bots use to remember durable facts about the requesting user across
+// conversations, and to forget them when the user asks.
+func Tools(store *Store) []llm.Tool {
+	return []llm.Tool{
+		{
+			Name:        "RememberUserFact",
+			Description: "Remember a durable fact about the user for future conversations, such as their role, preferences or ongoing projects. Only use it when the user asks you to remember something or shares a lasting preference, never for passing details or sensitive personal information.",
+			Schema:      llm.NewJSONSchemaFromStruct(RememberUserFactArgs{}),
+			Resolver: func(_ context.Context, llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+				var args RememberUserFactArgs
+				if err := argsGetter(&args); err != nil {
+					return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool RememberUserFact: %w", err)
+				}
+
+				_, err := store.Add(llmContext.RequestingUser.Id, args.Fact)
+				if errors.Is(err, ErrTooManyMemories) {
+					return fmt.Sprintf("Nothing was remembered, the user already has %d memories. They can delete some from the Memories tab of the AI panel.", MaxMemoriesPerUser), nil
+				}
+				if errors.Is(err, ErrInvalidMemory) {
+					return err.Error(), nil
+				}
+				if err != nil {
+					return "failed to remember the fact", err
+				}
+				return "The fact was remembered.", nil
+			},
+		},
+		{
+			Name:        "ForgetUserFact",
+			Description: "Forget a fact you remember about the user, when the user asks you to or it is no longer true.",
+			Schema:      llm.NewJSONSchemaFromStruct(ForgetUserFactArgs{}),
+			Resolver: func(_ context.Context, llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+				var args ForgetUserFactArgs
+				if err := argsGetter(&args); err != nil {
+					return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool ForgetUserFact: %w", err)
+				}
+
+				memories, err := store.List(llmContext.RequestingUser.Id)
+				if err != nil {
+					return "failed to forget the fact", err
+				}
+				memory, found := find(memories, args.Fact)
+				if !found {
+					return "No remembered fact matches, use the exact text of the fact.", nil
+				}
+				if err := store.Delete(llmContext.RequestingUser.Id, memory.ID); err != nil && !errors.Is(err, ErrMemoryNotFound) {
+					return "failed to forget the fact", err
+				}
+				return "The fact was forgotten.", nil
+			},
+		},
+	}
+}
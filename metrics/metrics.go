@@ -9,11 +9,12 @@ import (
 )
 
 const (
-	MetricsNamespace       = "copilot"
-	MetricsSubsystemSystem = "system"
-	MetricsSubsystemHTTP   = "http"
-	MetricsSubsystemAPI    = "api"
-	MetricsSubsystemLLM    = "llm"
+	MetricsNamespace           = "copilot"
+	MetricsSubsystemSystem     = "system"
+	MetricsSubsystemHTTP       = "http"
+	MetricsSubsystemAPI        = "api"
+	MetricsSubsystemLLM        = "llm"
+	MetricsSubsystemExperiment = "experiment"
 
 	MetricsCloudInstallationLabel = "installationId"
 	MetricsVersionLabel           = "version"
@@ -28,6 +29,14 @@ type Metrics interface {
 	IncrementHTTPErrors()
 
 	GetMetricsForAIService(llmName string) *llmMetrics
+
+	ObserveExperimentLatency(experiment, variant string, elapsed float64)
+	IncrementExperimentFeedback(experiment, variant string, positive bool)
+
+	ObserveModelLatency(model, baseModel string, elapsed float64)
+	IncrementModelFeedback(model, baseModel string, positive bool)
+
+	ObserveOrphanedFilesDeleted(files int, bytes int64)
 }
 
 type InstanceInfo struct {
@@ -49,6 +58,15 @@ type metrics struct {
 	httpErrorsTotal   prometheus.Counter
 
 	llmRequestsTotal *prometheus.CounterVec
+
+	experimentLatency       *prometheus.HistogramVec
+	experimentFeedbackTotal *prometheus.CounterVec
+
+	modelLatency       *prometheus.HistogramVec
+	modelFeedbackTotal *prometheus.CounterVec
+
+	orphanedFilesDeletedTotal      prometheus.Counter
+	orphanedFilesDeletedBytesTotal prometheus.Counter
 }
 
 // NewMetrics Factory method to create a new metrics collector.
@@ -129,6 +147,66 @@ func NewMetrics(info InstanceInfo) Metrics {
 	}, []string{"llm_name"})
 	m.registry.MustRegister(m.llmRequestsTotal)
 
+	m.experimentLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   MetricsNamespace,
+			Subsystem:   MetricsSubsystemExperiment,
+			Name:        "latency_seconds",
+			Help:        "Time to complete LLM requests per experiment variant.",
+			ConstLabels: additionalLabels,
+		},
+		[]string{"experiment", "variant"},
+	)
+	m.registry.MustRegister(m.experimentLatency)
+
+	m.experimentFeedbackTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   MetricsNamespace,
+		Subsystem:   MetricsSubsystemExperiment,
+		Name:        "feedback_total",
+		Help:        "The total number of user feedback reactions per experiment variant.",
+		ConstLabels: additionalLabels,
+	}, []string{"experiment", "variant", "feedback"})
+	m.registry.MustRegister(m.experimentFeedbackTotal)
+
+	m.modelLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   MetricsNamespace,
+			Subsystem:   MetricsSubsystemLLM,
+			Name:        "model_latency_seconds",
+			Help:        "Time to complete LLM requests per model.",
+			ConstLabels: additionalLabels,
+		},
+		[]string{"model", "base_model"},
+	)
+	m.registry.MustRegister(m.modelLatency)
+
+	m.modelFeedbackTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   MetricsNamespace,
+		Subsystem:   MetricsSubsystemLLM,
+		Name:        "model_feedback_total",
+		Help:        "The total number of user feedback reactions per model.",
+		ConstLabels: additionalLabels,
+	}, []string{"model", "base_model", "feedback"})
+	m.registry.MustRegister(m.modelFeedbackTotal)
+
+	m.orphanedFilesDeletedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   MetricsNamespace,
+		Subsystem:   MetricsSubsystemSystem,
+		Name:        "orphaned_files_deleted_total",
+		Help:        "The total number of uploaded transcripts deleted because they were never attached to a post.",
+		ConstLabels: additionalLabels,
+	})
+	m.registry.MustRegister(m.orphanedFilesDeletedTotal)
+
+	m.orphanedFilesDeletedBytesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   MetricsNamespace,
+		Subsystem:   MetricsSubsystemSystem,
+		Name:        "orphaned_files_deleted_bytes_total",
+		Help:        "The total size of the uploaded transcripts deleted because they were never attached to a post.",
+		ConstLabels: additionalLabels,
+	})
+	m.registry.MustRegister(m.orphanedFilesDeletedBytesTotal)
+
 	return m
 }
 
@@ -154,6 +232,45 @@ func (m *metrics) IncrementHTTPErrors() {
 	}
 }
 
+func (m *metrics) ObserveExperimentLatency(experiment, variant string, elapsed float64) {
+	if m != nil {
+		m.experimentLatency.With(prometheus.Labels{"experiment": experiment, "variant": variant}).Observe(elapsed)
+	}
+}
+
+func (m *metrics) IncrementExperimentFeedback(experiment, variant string, positive bool) {
+	if m != nil {
+		feedback := "negative"
+		if positive {
+			feedback = "positive"
+		}
+		m.experimentFeedbackTotal.With(prometheus.Labels{"experiment": experiment, "variant": variant, "feedback": feedback}).Inc()
+	}
+}
+
+func (m *metrics) ObserveModelLatency(model, baseModel string, elapsed float64) {
+	if m != nil {
+		m.modelLatency.With(prometheus.Labels{"model": model, "base_model": baseModel}).Observe(elapsed)
+	}
+}
+
+func (m *metrics) IncrementModelFeedback(model, baseModel string, positive bool) {
+	if m != nil {
+		feedback := "negative"
+		if positive {
+			feedback = "positive"
+		}
+		m.modelFeedbackTotal.With(prometheus.Labels{"model": model, "base_model": baseModel, "feedback": feedback}).Inc()
+	}
+}
+
+func (m *metrics) ObserveOrphanedFilesDeleted(files int, bytes int64) {
+	if m != nil {
+		m.orphanedFilesDeletedTotal.Add(float64(files))
+		m.orphanedFilesDeletedBytesTotal.Add(float64(bytes))
+	}
+}
+
 func (m *metrics) GetMetricsForAIService(llmName string) *llmMetrics {
 	if m == nil {
 		return nil
@@ -36,6 +36,31 @@ func (m *NoopMetrics) IncrementHTTPErrors() {
 	// No-op
 }
 
+// ObserveExperimentLatency is a no-op implementation.
+func (m *NoopMetrics) ObserveExperimentLatency(experiment, variant string, elapsed float64) {
+	// No-op
+}
+
+// IncrementExperimentFeedback is a no-op implementation.
+func (m *NoopMetrics) IncrementExperimentFeedback(experiment, variant string, positive bool) {
+	// No-op
+}
+
+// ObserveModelLatency is a no-op implementation.
+func (m *NoopMetrics) ObserveModelLatency(model, baseModel string, elapsed float64) {
+	// No-op
+}
+
+// IncrementModelFeedback is a no-op implementation.
+func (m *NoopMetrics) IncrementModelFeedback(model, baseModel string, positive bool) {
+	// No-op
+}
+
+// ObserveOrphanedFilesDeleted is a no-op implementation.
+func (m *NoopMetrics) ObserveOrphanedFilesDeleted(files int, bytes int64) {
+	// No-op
+}
+
 // GetMetricsForAIService returns a no-op implementation of LLMetrics.
 func (m *NoopMetrics) GetMetricsForAIService(llmName string) *llmMetrics { //nolint:revive
 	return &llmMetrics{}
@@ -0,0 +1,129 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+// TitleStore saves the titles of conversations.
+type TitleStore interface {
+	SaveTitle(threadID, title string) error
+}
+
+// Importer adds the bots and conversations of exports to the plugin.
+type Importer struct {
+	pluginAPI *pluginapi.Client
+	titles    TitleStore
+}
+
+// NewImporter creates an importer.
+func NewImporter(pluginAPI *pluginapi.Client, titles TitleStore) *Importer {
+	return &Importer{
+		pluginAPI: pluginAPI,
+		titles:    titles,
+	}
+}
+
+// Import adds the bots of the export to the configuration and saves the titles of its conversations.
+// Existing bots are never changed. On a dry run the result is computed without changing anything.
+func (i *Importer) Import(export *Export, dryRun bool) (*Result, error) {
+	existing, err := configuredBots(config.SavedConfig(&i.pluginAPI.Configuration))
+	if err != nil {
+		return nil, err
+	}
+
+	bots, botResults := PlanBots(existing, export)
+	result := &Result{
+		DryRun:        dryRun,
+		Bots:          botResults,
+		Conversations: i.checkConversations(export.Conversations),
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	if len(bots) > 0 {
+		if err := i.addBots(bots); err != nil {
+			return nil, err
+		}
+	}
+
+	for index, conversation := range export.Conversations {
+		if !result.Conversations[index].Imported {
+			continue
+		}
+		if err := i.titles.SaveTitle(conversation.RootPostID, strings.TrimSpace(conversation.Title)); err != nil {
+			result.Conversations[index].Imported = false
+			result.Conversations[index].Reason = "unable to save the title"
+			i.pluginAPI.Log.Warn("Unable to import conversation title", "error", err, "root_post_id", conversation.RootPostID)
+		}
+	}
+
+	return result, nil
+}
+
+// checkConversations returns which conversations can be imported, only the metadata of
+// threads that exist can be.
+func (i *Importer) checkConversations(conversations []ExportConversation) []ItemResult {
+	results := make([]ItemResult, 0, len(conversations))
+	for _, conversation := range conversations {
+		result := ItemResult{Name: conversation.RootPostID}
+		if strings.TrimSpace(conversation.Title) == "" {
+			result.Reason = "missing title"
+			results = append(results, result)
+			continue
+		}
+
+		post, err := i.pluginAPI.Post.GetPost(conversation.RootPostID)
+		switch {
+		case err != nil:
+			result.Reason = "post not found"
+		case post.RootId != "":
+			result.Reason = "post isn't the root of a thread"
+		default:
+			result.Imported = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// configuredBots returns the bots of the configuration as saved in the plugin settings.
+func configuredBots(saved map[string]any) ([]llm.BotConfig, error) {
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read plugin configuration: %w", err)
+	}
+	var cfg config.Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("unable to read plugin configuration: %w", err)
+	}
+	return cfg.Bots, nil
+}
+
+// addBots adds bots to the plugin settings. The rest of the settings are kept as they are,
+// including fields this version of the plugin doesn't know about.
+func (i *Importer) addBots(bots []llm.BotConfig) error {
+	data, err := json.Marshal(bots)
+	if err != nil {
+		return fmt.Errorf("unable to marshal bots: %w", err)
+	}
+	var botsValue []any
+	if err := json.Unmarshal(data, &botsValue); err != nil {
+		return fmt.Errorf("unable to marshal bots: %w", err)
+	}
+
+	return config.UpdateSavedConfig(&i.pluginAPI.Configuration, func(cfg map[string]any) error {
+		existing, _ := cfg["bots"].([]any)
+		cfg["bots"] = append(existing, botsValue...)
+		return nil
+	})
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package migration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// maxExportSize limits the size of an export read by ParseExport
+const maxExportSize = 10 * 1024 * 1024
+
+// ErrEmptyExport is returned when an export has nothing to import.
+var ErrEmptyExport = errors.New("export has no bots or conversations")
+
+// Export is the format bot configurations and conversations are imported from. Other AI integrations
+// are migrated by converting their configuration to this format.
+type Export struct {
+	// Source names the integration the export comes from, it is only informational
+	Source        string               `json:"source"`
+	Bots          []ExportBot          `json:"bots"`
+	Conversations []ExportConversation `json:"conversations"`
+
+	// Services are the bots of plugin versions from before bots were configured separately
+	Services []LegacyService `json:"services"`
+}
+
+// ExportBot is a bot of another integration.
+type ExportBot struct {
+	Name         string `json:"name"`
+	DisplayName  string `json:"displayName"`
+	Provider     string `json:"provider"`
+	Model        string `json:"model"`
+	APIKey       string `json:"apiKey"`
+	APIURL       string `json:"apiURL"`
+	OrgID        string `json:"orgId"`
+	SystemPrompt string `json:"systemPrompt"`
+
+	// Token limits, zero uses the provider defaults
+	InputTokenLimit  int `json:"inputTokenLimit"`
+	OutputTokenLimit int `json:"outputTokenLimit"`
+}
+
+// LegacyService is a bot as configured by the first versions of the plugin.
+type LegacyService struct {
+	Name         string `json:"name"`
+	ServiceName  string `json:"serviceName"`
+	DefaultModel string `json:"defaultModel"`
+	OrgID        string `json:"orgId"`
+	URL          string `json:"url"`
+	APIKey       string `json:"apiKey"`
+	TokenLimit   int    `json:"tokenLimit"`
+}
+
+// ExportConversation is the metadata of a conversation that already exists as a thread in Mattermost.
+type ExportConversation struct {
+	RootPostID string `json:"rootPostId"`
+	Title      string `json:"title"`
+}
+
+// ItemResult is the outcome of importing a bot or a conversation.
+type ItemResult struct {
+	Name     string `json:"name"`
+	Imported bool   `json:"imported"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Result is the outcome of an import. Nothing is changed on a dry run.
+type Result struct {
+	DryRun        bool         `json:"dryRun"`
+	Bots          []ItemResult `json:"bots"`
+	Conversations []ItemResult `json:"conversations"`
+}
+
+// providerTypes maps the provider names used by other integrations to service types
+var providerTypes = map[string]string{
+	"openai":            llm.ServiceTypeOpenAI,
+	"chatgpt":           llm.ServiceTypeOpenAI,
+	"azure":             llm.ServiceTypeAzure,
+	"azureopenai":       llm.ServiceTypeAzure,
+	"anthropic":         llm.ServiceTypeAnthropic,
+	"claude":            llm.ServiceTypeAnthropic,
+	"asage":             llm.ServiceTypeASage,
+	"asksage":           llm.ServiceTypeASage,
+	"openaicompatible":  llm.ServiceTypeOpenAICompatible,
+	"localai":           llm.ServiceTypeOpenAICompatible,
+	"ollama":            llm.ServiceTypeOpenAICompatible,
+	"vllm":              llm.ServiceTypeVLLM,
+	"lmstudio":          llm.ServiceTypeOpenAICompatible,
+	"openrouter":        llm.ServiceTypeOpenAICompatible,
+	"textgenerationui":  llm.ServiceTypeOpenAICompatible,
+	"textgenerationweb": llm.ServiceTypeOpenAICompatible,
+}
+
+// ParseExport reads an export.
+func ParseExport(r io.Reader) (*Export, error) {
+	var export Export
+	decoder := json.NewDecoder(io.LimitReader(r, maxExportSize))
+	if err := decoder.Decode(&export); err != nil {
+		return nil, fmt.Errorf("unable to parse export: %w", err)
+	}
+	if len(export.Bots) == 0 && len(export.Services) == 0 && len(export.Conversations) == 0 {
+		return nil, ErrEmptyExport
+	}
+	return &export, nil
+}
+
+// ServiceType returns the service type of a provider name, ignoring case, spaces, dashes and underscores.
+func ServiceType(provider string) (string, bool) {
+	normalized := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(provider))
+	serviceType, ok := providerTypes[normalized]
+	return serviceType, ok
+}
+
+// botUsername converts a name to a valid Mattermost username.
+func botUsername(name string) string {
+	username := strings.ToLower(strings.TrimSpace(name))
+	username = strings.ReplaceAll(username, " ", "-")
+	return username
+}
+
+// PlanBots converts the bots of an export to bot configurations. Bots that can't be converted
+// or that have the name of an existing bot are skipped, with the reason in their result.
+func PlanBots(existing []llm.BotConfig, export *Export) ([]llm.BotConfig, []ItemResult) {
+	exportBots := export.Bots
+	for _, service := range export.Services {
+		exportBots = append(exportBots, ExportBot{
+			Name:            service.Name,
+			DisplayName:     service.Name,
+			Provider:        service.ServiceName,
+			Model:           service.DefaultModel,
+			APIKey:          service.APIKey,
+			APIURL:          service.URL,
+			OrgID:           service.OrgID,
+			InputTokenLimit: service.TokenLimit,
+		})
+	}
+
+	taken := map[string]bool{}
+	for _, bot := range existing {
+		taken[bot.Name] = true
+	}
+
+	bots := make([]llm.BotConfig, 0, len(exportBots))
+	results := make([]ItemResult, 0, len(exportBots))
+	for _, exportBot := range exportBots {
+		bot, reason := convertBot(exportBot)
+		if reason == "" && taken[bot.Name] {
+			reason = "a bot with this name already exists"
+		}
+
+		result := ItemResult{
+			Name:     exportBot.Name,
+			Imported: reason == "",
+			Reason:   reason,
+		}
+		results = append(results, result)
+		if !result.Imported {
+			continue
+		}
+
+		taken[bot.Name] = true
+		bots = append(bots, bot)
+	}
+
+	return bots, results
+}
+
+// convertBot maps a bot of an export to a bot configuration, the reason is set when it can't be.
+func convertBot(exportBot ExportBot) (llm.BotConfig, string) {
+	username := botUsername(exportBot.Name)
+	if !model.IsValidUsername(username) {
+		return llm.BotConfig{}, fmt.Sprintf("%q isn't a valid username", exportBot.Name)
+	}
+
+	serviceType, ok := ServiceType(exportBot.Provider)
+	if !ok {
+		return llm.BotConfig{}, fmt.Sprintf("unknown provider %q", exportBot.Provider)
+	}
+
+	displayName := strings.TrimSpace(exportBot.DisplayName)
+	if displayName == "" {
+		displayName = exportBot.Name
+	}
+
+	bot := llm.BotConfig{
+		ID:                 model.NewId(),
+		Name:               username,
+		DisplayName:        displayName,
+		CustomInstructions: strings.TrimSpace(exportBot.SystemPrompt),
+		Service: llm.ServiceConfig{
+			Name:             displayName,
+			Type:             serviceType,
+			APIKey:           exportBot.APIKey,
+			APIURL:           exportBot.APIURL,
+			OrgID:            exportBot.OrgID,
+			DefaultModel:     exportBot.Model,
+			InputTokenLimit:  exportBot.InputTokenLimit,
+			OutputTokenLimit: exportBot.OutputTokenLimit,
+		},
+		// Exports don't say who may use their bots, so nobody can until an admin grants access
+		ChannelAccessLevel: llm.ChannelAccessLevelNone,
+		UserAccessLevel:    llm.UserAccessLevelNone,
+	}
+	if !bot.IsValid() {
+		return llm.BotConfig{}, fmt.Sprintf("missing settings for the %s provider", serviceType)
+	}
+
+	return bot, ""
+}
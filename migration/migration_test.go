@@ -0,0 +1,136 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package migration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExport(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name:  "bots and conversations",
+			input: `{"source": "chatgpt-bot", "bots": [{"name": "gpt", "provider": "openai"}], "conversations": [{"rootPostId": "abc", "title": "Pricing"}]}`,
+		},
+		{
+			name:  "legacy services",
+			input: `{"services": [{"name": "ai", "serviceName": "openai"}]}`,
+		},
+		{
+			name:    "nothing to import",
+			input:   `{"source": "chatgpt-bot"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid JSON",
+			input:   `{"bots": [`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			export, err := ParseExport(strings.NewReader(tc.input))
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, export)
+		})
+	}
+}
+
+func TestServiceType(t *testing.T) {
+	tests := []struct {
+		provider string
+		want     string
+		ok       bool
+	}{
+		{provider: "openai", want: llm.ServiceTypeOpenAI, ok: true},
+		{provider: "ChatGPT", want: llm.ServiceTypeOpenAI, ok: true},
+		{provider: "Azure OpenAI", want: llm.ServiceTypeAzure, ok: true},
+		{provider: "azure_openai", want: llm.ServiceTypeAzure, ok: true},
+		{provider: "claude", want: llm.ServiceTypeAnthropic, ok: true},
+		{provider: "Ask Sage", want: llm.ServiceTypeASage, ok: true},
+		{provider: "ollama", want: llm.ServiceTypeOpenAICompatible, ok: true},
+		{provider: "openai-compatible", want: llm.ServiceTypeOpenAICompatible, ok: true},
+		{provider: "unknown", ok: false},
+		{provider: "", ok: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.provider, func(t *testing.T) {
+			serviceType, ok := ServiceType(tc.provider)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.want, serviceType)
+		})
+	}
+}
+
+func TestPlanBots(t *testing.T) {
+	existing := []llm.BotConfig{{Name: "ai"}}
+	export := &Export{
+		Bots: []ExportBot{
+			{
+				Name:             "Support Bot",
+				Provider:         "chatgpt",
+				Model:            "gpt-4o",
+				APIKey:           "key",
+				SystemPrompt:     "  Answer support questions.  ",
+				OutputTokenLimit: 1000,
+			},
+			{Name: "ai", Provider: "openai", APIKey: "key"},
+			{Name: "support-bot", Provider: "anthropic", APIKey: "key"},
+			{Name: "local", Provider: "ollama"},
+			{Name: "mystery", Provider: "mystery-ai", APIKey: "key"},
+			{Name: "bad name!", Provider: "openai", APIKey: "key"},
+		},
+		Services: []LegacyService{
+			{Name: "legacy", ServiceName: "anthropic", DefaultModel: "claude-3", APIKey: "key", TokenLimit: 100000},
+		},
+	}
+
+	bots, results := PlanBots(existing, export)
+
+	assert.Equal(t, []ItemResult{
+		{Name: "Support Bot", Imported: true},
+		{Name: "ai", Reason: "a bot with this name already exists"},
+		{Name: "support-bot", Reason: "a bot with this name already exists"},
+		{Name: "local", Reason: "missing settings for the openaicompatible provider"},
+		{Name: "mystery", Reason: `unknown provider "mystery-ai"`},
+		{Name: "bad name!", Reason: `"bad name!" isn't a valid username`},
+		{Name: "legacy", Imported: true},
+	}, results)
+
+	require.Len(t, bots, 2)
+
+	assert.NotEmpty(t, bots[0].ID)
+	assert.Equal(t, "support-bot", bots[0].Name)
+	assert.Equal(t, "Support Bot", bots[0].DisplayName)
+	assert.Equal(t, "Answer support questions.", bots[0].CustomInstructions)
+	assert.Equal(t, llm.ServiceConfig{
+		Name:             "Support Bot",
+		Type:             llm.ServiceTypeOpenAI,
+		APIKey:           "key",
+		DefaultModel:     "gpt-4o",
+		OutputTokenLimit: 1000,
+	}, bots[0].Service)
+	assert.Equal(t, llm.ChannelAccessLevelNone, bots[0].ChannelAccessLevel, "imported bots can't be used until access is granted")
+	assert.Equal(t, llm.UserAccessLevelNone, bots[0].UserAccessLevel)
+
+	assert.Equal(t, "legacy", bots[1].Name)
+	assert.Equal(t, llm.ServiceTypeAnthropic, bots[1].Service.Type)
+	assert.Equal(t, "claude-3", bots[1].Service.DefaultModel)
+	assert.Equal(t, 100000, bots[1].Service.InputTokenLimit)
+}
@@ -42,6 +42,15 @@ func NewDBClient(pluginAPI *pluginapi.Client) *DBClient {
 	}
 }
 
+// NewDBClientFromDB creates the DB part of the client from a postgres connection opened outside of the
+// plugin API, such as in tests.
+func NewDBClientFromDB(db *sqlx.DB) *DBClient {
+	return &DBClient{
+		DB:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
 func (db *DBClient) DoQuery(dest any, b builder) error {
 	sqlString, args, err := b.ToSql()
 	if err != nil {
@@ -4,6 +4,7 @@
 package mmtools
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -34,7 +35,7 @@ func formatGithubIssue(issue *github.Issue) string {
 		issue.GetBody())
 }
 
-func (p *MMToolProvider) toolGetGithubIssue(context *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+func (p *MMToolProvider) toolGetGithubIssue(ctx context.Context, llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
 	var args GetGithubIssueArgs
 	err := argsGetter(&args)
 	if err != nil {
@@ -56,7 +57,7 @@ func (p *MMToolProvider) toolGetGithubIssue(context *llm.Context, argsGetter llm
 		return "invalid parameters to function", errors.New("invalid issue number")
 	}
 
-	req, err := http.NewRequest(http.MethodGet,
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
 		fmt.Sprintf("/github/api/v1/issue?owner=%s&repo=%s&number=%d",
 			url.QueryEscape(args.RepoOwner),
 			url.QueryEscape(args.RepoName),
@@ -67,7 +68,7 @@ func (p *MMToolProvider) toolGetGithubIssue(context *llm.Context, argsGetter llm
 	if err != nil {
 		return "internal failure", fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Mattermost-User-ID", context.RequestingUser.Id)
+	req.Header.Set("Mattermost-User-ID", llmContext.RequestingUser.Id)
 
 	resp := p.pluginAPI.PluginHTTP(req)
 	if resp == nil {
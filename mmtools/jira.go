@@ -4,6 +4,7 @@
 package mmtools
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -139,13 +140,13 @@ func formatJiraIssue(issue *jira.Issue) string {
 	return result.String()
 }
 
-func (p *MMToolProvider) getPublicJiraIssues(instanceURL string, issueKeys []string) ([]jira.Issue, error) {
+func (p *MMToolProvider) getPublicJiraIssues(ctx context.Context, instanceURL string, issueKeys []string) ([]jira.Issue, error) {
 	client, err := jira.NewClient(p.httpClient, instanceURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Jira client: %w", err)
 	}
 	jql := fmt.Sprintf("key in (%s)", strings.Join(issueKeys, ","))
-	issues, _, err := client.Issue.Search(jql, &jira.SearchOptions{Fields: fetchedFields})
+	issues, _, err := client.Issue.SearchWithContext(ctx, jql, &jira.SearchOptions{Fields: fetchedFields})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get issue: %w", err)
 	}
@@ -156,7 +157,7 @@ func (p *MMToolProvider) getPublicJiraIssues(instanceURL string, issueKeys []str
 	return issues, nil
 }
 
-func (p *MMToolProvider) toolGetJiraIssue(context *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+func (p *MMToolProvider) toolGetJiraIssue(ctx context.Context, _ *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
 	var args GetJiraIssueArgs
 	err := argsGetter(&args)
 	if err != nil {
@@ -170,7 +171,7 @@ func (p *MMToolProvider) toolGetJiraIssue(context *llm.Context, argsGetter llm.T
 		}
 	}
 
-	issues, err := p.getPublicJiraIssues(args.InstanceURL, args.IssueKeys)
+	issues, err := p.getPublicJiraIssues(ctx, args.InstanceURL, args.IssueKeys)
 	if err != nil {
 		return "internal failure", err
 	}
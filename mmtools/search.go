@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-ai/embeddings"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
@@ -23,7 +24,7 @@ type SearchServerArgs struct {
 	Term string `jsonschema_description:"The terms to search for in the server. Must be more than 3 and less than 300 characters."`
 }
 
-func (p *MMToolProvider) toolSearchServer(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+func (p *MMToolProvider) toolSearchServer(ctx context.Context, llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
 	var args SearchServerArgs
 	err := argsGetter(&args)
 	if err != nil {
@@ -44,7 +45,6 @@ func (p *MMToolProvider) toolSearchServer(llmContext *llm.Context, argsGetter ll
 	}
 
 	// Perform the search
-	ctx := context.Background()
 	searchResults, err := p.search.Search(ctx, args.Term, embeddings.SearchOptions{
 		Limit:  10,
 		UserID: llmContext.RequestingUser.Id,
@@ -94,8 +94,17 @@ func (p *MMToolProvider) formatSearchResults(results []embeddings.SearchResult,
 		}
 
 		// Format the result
-		builder.WriteString(fmt.Sprintf("%d. **%s** in ~%s (Score: %.2f)\n",
-			i+1, username, channelName, result.Score))
+		if result.Document.Corpus() == embeddings.CorpusMeetings {
+			source := "Meeting summary"
+			if result.Document.SourceType == embeddings.SourceTypeMeetingTranscript {
+				source = "Meeting transcript"
+			}
+			builder.WriteString(fmt.Sprintf("%d. **%s** from %s in ~%s (Score: %.2f)\n",
+				i+1, source, time.UnixMilli(result.Document.CreateAt).UTC().Format(time.DateOnly), channelName, result.Score))
+		} else {
+			builder.WriteString(fmt.Sprintf("%d. **%s** in ~%s (Score: %.2f)\n",
+				i+1, username, channelName, result.Score))
+		}
 
 		// Add message content (truncate if too long)
 		message := result.Document.Content
@@ -4,6 +4,7 @@
 package mmtools
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -15,7 +16,7 @@ type LookupMattermostUserArgs struct {
 	Username string `jsonschema_description:"The username of the user to lookup without a leading '@'. Example: 'firstname.lastname'"`
 }
 
-func (p *MMToolProvider) toolResolveLookupMattermostUser(context *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+func (p *MMToolProvider) toolResolveLookupMattermostUser(_ context.Context, llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
 	var args LookupMattermostUserArgs
 	err := argsGetter(&args)
 	if err != nil {
@@ -27,7 +28,7 @@ func (p *MMToolProvider) toolResolveLookupMattermostUser(context *llm.Context, a
 	}
 
 	// Check permissions
-	if !p.pluginAPI.HasPermissionTo(context.RequestingUser.Id, model.PermissionViewMembers) {
+	if !p.pluginAPI.HasPermissionTo(llmContext.RequestingUser.Id, model.PermissionViewMembers) {
 		return "user doesn't have permissions", errors.New("user doesn't have permission to lookup users")
 	}
 
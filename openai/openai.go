@@ -7,11 +7,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"image"
 	"image/png"
 	"io"
+	"math"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -38,13 +41,28 @@ type Config struct {
 type OpenAI struct {
 	client *openaiClient.Client
 	config Config
+
+	// vocabulary is the terms transcriptions are prompted with, see SetVocabulary
+	vocabulary []string
 }
 
 const (
 	MaxFunctionCalls   = 10
 	OpenAIMaxImageSize = 20 * 1024 * 1024 // 20 MB
+
+	// maxVocabularyPromptLength keeps the vocabulary within the 224 tokens of prompt Whisper reads
+	maxVocabularyPromptLength = 800
+
+	// DefaultEmbeddingModel is the embedding model used when none is configured
+	DefaultEmbeddingModel = string(openaiClient.LargeEmbedding3)
+
+	// maxStopSequences is the number of stop sequences the API accepts
+	maxStopSequences = 4
 )
 
+// reasoningModelPrefixes are the prefixes of the reasoning models, which reject stop sequences
+var reasoningModelPrefixes = []string{"o1", "o3", "o4", "gpt-5"}
+
 var ErrStreamingTimeout = errors.New("timeout streaming")
 
 func NewAzure(config Config, httpClient *http.Client) *OpenAI {
@@ -72,6 +90,10 @@ func New(config Config, httpClient *http.Client) *OpenAI {
 		func(apiKey string) openaiClient.ClientConfig {
 			clientConfig := openaiClient.DefaultConfig(apiKey)
 			clientConfig.OrgID = config.OrgID
+			// Regional endpoints, such as the one of the EU data residency region, replace the default one
+			if config.APIURL != "" {
+				clientConfig.BaseURL = strings.TrimSuffix(config.APIURL, "/")
+			}
 			return clientConfig
 		},
 	)
@@ -80,7 +102,7 @@ func New(config Config, httpClient *http.Client) *OpenAI {
 // NewEmbeddings creates a new OpenAI client configured only for embeddings functionality
 func NewEmbeddings(config Config, httpClient *http.Client) *OpenAI {
 	if config.EmbeddingModel == "" {
-		config.EmbeddingModel = string(openaiClient.LargeEmbedding3)
+		config.EmbeddingModel = DefaultEmbeddingModel
 		config.EmbeddingDimentions = 3072
 	}
 	return newOpenAI(config, httpClient,
@@ -94,7 +116,7 @@ func NewEmbeddings(config Config, httpClient *http.Client) *OpenAI {
 // NewCompatibleEmbeddings creates a new OpenAI client configured only for embeddings functionality
 func NewCompatibleEmbeddings(config Config, httpClient *http.Client) *OpenAI {
 	if config.EmbeddingModel == "" {
-		config.EmbeddingModel = string(openaiClient.LargeEmbedding3)
+		config.EmbeddingModel = DefaultEmbeddingModel
 		config.EmbeddingDimentions = 3072
 	}
 
@@ -123,12 +145,27 @@ func newOpenAI(
 
 func modifyCompletionRequestWithRequest(openAIRequest openaiClient.ChatCompletionRequest, interalRequest llm.CompletionRequest) openaiClient.ChatCompletionRequest {
 	openAIRequest.Messages = postsToChatCompletionMessages(interalRequest.Posts)
+	openAIRequest.Stop = nativeStopSequences(openAIRequest.Model, interalRequest.StopSequences)
 	if interalRequest.Context.Tools != nil {
 		openAIRequest.Tools = toolsToOpenAITools(interalRequest.Context.Tools.GetTools())
 	}
 	return openAIRequest
 }
 
+// nativeStopSequences returns the stop sequences the API accepts for the model. Reasoning models
+// reject them and the API takes at most maxStopSequences, the rest are applied to the stream instead.
+func nativeStopSequences(model string, stopSequences []string) []string {
+	if len(stopSequences) == 0 {
+		return nil
+	}
+	for _, prefix := range reasoningModelPrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return nil
+		}
+	}
+	return stopSequences[:min(len(stopSequences), maxStopSequences)]
+}
+
 func toolsToOpenAITools(tools []llm.Tool) []openaiClient.Tool {
 	result := make([]openaiClient.Tool, 0, len(tools))
 	for _, tool := range tools {
@@ -369,11 +406,13 @@ func (s *OpenAI) streamResultToChannels(request openaiClient.ChatCompletionReque
 			// Send tool calls event and end the stream
 			pendingToolCalls := make([]llm.ToolCall, 0, len(tools))
 			for _, tool := range tools {
+				providerRequest, _ := json.Marshal(tool)
 				pendingToolCalls = append(pendingToolCalls, llm.ToolCall{
-					ID:          tool.ID,
-					Name:        tool.Function.Name,
-					Description: "", // OpenAI doesn't provide description in the response
-					Arguments:   []byte(tool.Function.Arguments),
+					ID:              tool.ID,
+					Name:            tool.Function.Name,
+					Description:     "", // OpenAI doesn't provide description in the response
+					Arguments:       []byte(tool.Function.Arguments),
+					ProviderRequest: providerRequest,
 				})
 			}
 
@@ -448,6 +487,11 @@ func (s *OpenAI) completionRequestFromConfig(cfg llm.LanguageModelConfig) openai
 	}
 	request.MaxTokens = cfg.MaxGeneratedTokens
 
+	if cfg.Temperature != nil {
+		// The client omits a zero temperature, the smallest one it sends is as deterministic
+		request.Temperature = max(float32(*cfg.Temperature), math.SmallestNonzeroFloat32)
+	}
+
 	if cfg.JSONOutputFormat != nil {
 		request.ResponseFormat = &openaiClient.ChatCompletionResponseFormat{
 			Type: openaiClient.ChatCompletionResponseFormatTypeJSONSchema,
@@ -471,7 +515,14 @@ func (s *OpenAI) ChatCompletion(request llm.CompletionRequest, opts ...llm.Langu
 			openAIRequest.User = request.Context.RequestingUser.Id
 		}
 	}
-	return s.streamResult(openAIRequest, request.Context)
+	result, err := s.streamResult(openAIRequest, request.Context)
+	if err != nil {
+		return nil, err
+	}
+	if len(openAIRequest.Stop) < len(request.StopSequences) {
+		return result.StopAt(request.StopSequences), nil
+	}
+	return result, nil
 }
 
 func (s *OpenAI) ChatCompletionNoStream(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
@@ -483,25 +534,98 @@ func (s *OpenAI) ChatCompletionNoStream(request llm.CompletionRequest, opts ...l
 	return result.ReadAll()
 }
 
-func (s *OpenAI) Transcribe(file io.Reader) (*subtitles.Subtitles, error) {
+func (s *OpenAI) Transcribe(file io.Reader, language string) (*subtitles.Subtitles, error) {
+	// The verbose format includes the detected language along with the timed segments
 	resp, err := s.client.CreateTranscription(context.Background(), openaiClient.AudioRequest{
 		Model:    openaiClient.Whisper1,
 		Reader:   file,
-		FilePath: "input.mp3",
-		Format:   openaiClient.AudioResponseFormatVTT,
+		FilePath: audioFilePath(file),
+		Format:   openaiClient.AudioResponseFormatVerboseJSON,
+		Language: language,
+		Prompt:   vocabularyPrompt(s.vocabulary),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("unable to create whisper transcription: %w", err)
 	}
 
-	timedTranscript, err := subtitles.NewSubtitlesFromVTT(strings.NewReader(resp.Text))
+	// Whisper reports the detected language by its name, such as "english"
+	timedTranscript := audioResponseToSubtitles(resp)
+	timedTranscript.SetLanguage(subtitles.LanguageCode(resp.Language))
+	if timedTranscript.Language() == "" {
+		timedTranscript.SetLanguage(language)
+	}
+
+	return timedTranscript, nil
+}
+
+// TranscribeToEnglish transcribes the audio with Whisper's translate mode, which translates any spoken language into English.
+func (s *OpenAI) TranscribeToEnglish(file io.Reader) (*subtitles.Subtitles, error) {
+	resp, err := s.client.CreateTranslation(context.Background(), openaiClient.AudioRequest{
+		Model:    openaiClient.Whisper1,
+		Reader:   file,
+		FilePath: audioFilePath(file),
+		Format:   openaiClient.AudioResponseFormatVerboseJSON,
+		Prompt:   vocabularyPrompt(s.vocabulary),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse whisper transcription: %w", err)
+		return nil, fmt.Errorf("unable to create whisper translation: %w", err)
 	}
 
+	timedTranscript := audioResponseToSubtitles(resp)
+	timedTranscript.SetLanguage("en")
+
 	return timedTranscript, nil
 }
 
+// SetVocabulary sets the terms likely to be said, such as product names and acronyms, which Whisper
+// is prompted with so it spells them the same way.
+func (s *OpenAI) SetVocabulary(terms []string) {
+	s.vocabulary = terms
+}
+
+// vocabularyPrompt lists the terms in the prompt Whisper continues the transcript from. Whisper only
+// reads the end of long prompts, so terms past maxVocabularyPromptLength are left out.
+func vocabularyPrompt(terms []string) string {
+	if len(terms) == 0 {
+		return ""
+	}
+
+	prompt := "Glossary: " + terms[0]
+	for _, term := range terms[1:] {
+		if len(prompt)+len(", ")+len(term)+len(".") > maxVocabularyPromptLength {
+			break
+		}
+		prompt += ", " + term
+	}
+	return prompt + "."
+}
+
+// audioFilePath returns the file name Whisper detects the audio format from.
+// Files keep their own name, other readers are assumed to be mp3.
+func audioFilePath(file io.Reader) string {
+	if named, ok := file.(interface{ Name() string }); ok {
+		return filepath.Base(named.Name())
+	}
+	return "input.mp3"
+}
+
+func audioResponseToSubtitles(resp openaiClient.AudioResponse) *subtitles.Subtitles {
+	segments := make([]subtitles.Segment, 0, len(resp.Segments))
+	for _, segment := range resp.Segments {
+		text := strings.TrimSpace(segment.Text)
+		if text == "" {
+			continue
+		}
+		segments = append(segments, subtitles.Segment{
+			StartMS: int64(segment.Start * 1000),
+			EndMS:   int64(segment.End * 1000),
+			Text:    text,
+		})
+	}
+
+	return subtitles.NewSubtitlesFromSegments(segments)
+}
+
 func (s *OpenAI) GenerateImage(prompt string) (image.Image, error) {
 	req := openaiClient.ImageRequest{
 		Prompt:         prompt,
@@ -543,22 +667,8 @@ func (s *OpenAI) InputTokenLimit() int {
 		return s.config.InputTokenLimit
 	}
 
-	switch {
-	case strings.HasPrefix(s.config.DefaultModel, "gpt-4o"),
-		strings.HasPrefix(s.config.DefaultModel, "o1-preview"),
-		strings.HasPrefix(s.config.DefaultModel, "o1-mini"),
-		strings.HasPrefix(s.config.DefaultModel, "gpt-4-turbo"),
-		strings.HasPrefix(s.config.DefaultModel, "gpt-4-0125-preview"),
-		strings.HasPrefix(s.config.DefaultModel, "gpt-4-1106-preview"):
-		return 128000
-	case strings.HasPrefix(s.config.DefaultModel, "gpt-4"):
-		return 8192
-	case strings.HasPrefix(s.config.DefaultModel, "gpt-3.5-turbo"),
-		s.config.DefaultModel == "gpt-3.5-turbo-0125",
-		s.config.DefaultModel == "gpt-3.5-turbo-1106":
-		return 16385
-	case s.config.DefaultModel == "gpt-3.5-turbo-instruct":
-		return 4096
+	if limit := llm.LookupCapabilities(llm.ServiceTypeOpenAI, s.config.DefaultModel).MaxContextTokens; limit > 0 {
+		return limit
 	}
 
 	return 128000 // Default fallback
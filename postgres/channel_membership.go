@@ -0,0 +1,35 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ChannelMembership lists the channels of users from the Mattermost database, for vector stores that
+// can't join the embeddings with the channel members.
+type ChannelMembership struct {
+	db *sqlx.DB
+}
+
+func NewChannelMembership(db *sqlx.DB) *ChannelMembership {
+	return &ChannelMembership{db: db}
+}
+
+// ChannelIDsForUser returns the channels the user is a member of that aren't archived.
+func (m *ChannelMembership) ChannelIDsForUser(ctx context.Context, userID string) ([]string, error) {
+	channelIDs := []string{}
+	err := m.db.SelectContext(ctx, &channelIDs, `
+		SELECT cm.ChannelId
+		FROM ChannelMembers cm
+		JOIN Channels c ON c.Id = cm.ChannelId
+		WHERE cm.UserId = $1 AND c.DeleteAt = 0`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel memberships: %w", err)
+	}
+	return channelIDs, nil
+}
@@ -5,6 +5,7 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 
@@ -32,7 +33,7 @@ func NewPGVector(db *sqlx.DB, config PGVectorConfig) (*PGVector, error) {
 	// Create the llm_posts_embeddings table if it doesn't exist
 	createTableQuery := `
 		CREATE TABLE IF NOT EXISTS llm_posts_embeddings (
-			id TEXT PRIMARY KEY,             								-- Post ID or chunk ID (post_id_chunk_N)
+			id TEXT PRIMARY KEY,             								-- Post ID or chunk ID (post_id_chunk_N), prefixed by the source type for other documents
 			post_id TEXT NOT NULL REFERENCES Posts(Id) ON DELETE CASCADE,   -- Original post ID (same as id for non-chunks)
 			team_id TEXT NOT NULL,
 			channel_id TEXT NOT NULL,
@@ -48,6 +49,19 @@ func NewPGVector(db *sqlx.DB, config PGVectorConfig) (*PGVector, error) {
 		return nil, fmt.Errorf("failed to create llm_posts_embeddings table: %w", err)
 	}
 
+	// Columns added after the table was first released
+	alterTableQueries := []string{
+		// Empty for post messages, set for other documents such as meeting summaries
+		"ALTER TABLE llm_posts_embeddings ADD COLUMN IF NOT EXISTS source_type TEXT NOT NULL DEFAULT ''",
+		// JSON metadata of the source, for example the participants of a meeting
+		"ALTER TABLE llm_posts_embeddings ADD COLUMN IF NOT EXISTS metadata TEXT NOT NULL DEFAULT ''",
+	}
+	for _, query := range alterTableQueries {
+		if _, err := db.Exec(query); err != nil {
+			return nil, fmt.Errorf("failed to alter llm_posts_embeddings table: %w", err)
+		}
+	}
+
 	// Create indexes
 	queries := []string{
 		// Index for similarity search using HNSW
@@ -56,6 +70,8 @@ func NewPGVector(db *sqlx.DB, config PGVectorConfig) (*PGVector, error) {
 		"CREATE INDEX IF NOT EXISTS llm_posts_embeddings_post_id_idx ON llm_posts_embeddings(post_id)",
 		// Index on is_chunk to filter by chunks
 		"CREATE INDEX IF NOT EXISTS llm_posts_embeddings_is_chunk_idx ON llm_posts_embeddings(is_chunk)",
+		// Index on source_type to search a single corpus
+		"CREATE INDEX IF NOT EXISTS llm_posts_embeddings_source_type_idx ON llm_posts_embeddings(source_type)",
 	}
 
 	for _, query := range queries {
@@ -68,26 +84,54 @@ func NewPGVector(db *sqlx.DB, config PGVectorConfig) (*PGVector, error) {
 }
 
 func (pv *PGVector) Store(ctx context.Context, docs []embeddings.PostDocument, embeddings [][]float32) error {
+	if err := pv.deleteSources(ctx, docs); err != nil {
+		return err
+	}
+
 	for i, doc := range docs {
 		id := doc.PostID
+		if doc.SourceType != "" {
+			id = fmt.Sprintf("%s_%s", doc.PostID, doc.SourceType)
+		}
+		if doc.File != nil {
+			id = fmt.Sprintf("%s_%s", id, doc.File.ID)
+		}
 		if doc.IsChunk {
-			id = fmt.Sprintf("%s_chunk_%d", doc.PostID, doc.ChunkIndex)
+			id = fmt.Sprintf("%s_chunk_%d", id, doc.ChunkIndex)
 		}
+
+		var source any
+		switch {
+		case doc.Meeting != nil:
+			source = doc.Meeting
+		case doc.File != nil:
+			source = doc.File
+		}
+		metadata := ""
+		if source != nil {
+			metadataJSON, err := json.Marshal(source)
+			if err != nil {
+				return fmt.Errorf("failed to marshal metadata: %w", err)
+			}
+			metadata = string(metadataJSON)
+		}
+
 		_, err := pv.db.NamedExecContext(ctx, `
 			INSERT INTO llm_posts_embeddings (
 				id, post_id, team_id, channel_id, user_id, content, embedding, created_at,
-				is_chunk, chunk_index, total_chunks
+				is_chunk, chunk_index, total_chunks, source_type, metadata
 			)
 			VALUES (
 				:id, :post_id, :team_id, :channel_id, :user_id, :content, :embedding, :created_at,
-				:is_chunk, :chunk_index, :total_chunks
+				:is_chunk, :chunk_index, :total_chunks, :source_type, :metadata
 			)
 			ON CONFLICT (id) DO UPDATE SET
 				content = EXCLUDED.content,
 				embedding = EXCLUDED.embedding,
 				is_chunk = EXCLUDED.is_chunk,
 				chunk_index = EXCLUDED.chunk_index,
-				total_chunks = EXCLUDED.total_chunks`,
+				total_chunks = EXCLUDED.total_chunks,
+				metadata = EXCLUDED.metadata`,
 			map[string]interface{}{
 				"id":           id,
 				"post_id":      doc.PostID,
@@ -100,6 +144,8 @@ func (pv *PGVector) Store(ctx context.Context, docs []embeddings.PostDocument, e
 				"is_chunk":     doc.IsChunk,
 				"chunk_index":  sqlNullInt(doc.IsChunk, doc.ChunkIndex),
 				"total_chunks": sqlNullInt(doc.IsChunk, doc.TotalChunks),
+				"source_type":  doc.SourceType,
+				"metadata":     metadata,
 			},
 		)
 		if err != nil {
@@ -110,6 +156,27 @@ func (pv *PGVector) Store(ctx context.Context, docs []embeddings.PostDocument, e
 	return nil
 }
 
+// deleteSources removes the stored documents of the non-post sources being stored, so a shorter
+// summary or transcript doesn't leave chunks of the previous version behind
+func (pv *PGVector) deleteSources(ctx context.Context, docs []embeddings.PostDocument) error {
+	seen := map[string]bool{}
+	for _, doc := range docs {
+		key := doc.PostID + "/" + doc.SourceType
+		if doc.SourceType == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		_, err := pv.db.ExecContext(ctx,
+			"DELETE FROM llm_posts_embeddings WHERE post_id = $1 AND source_type = $2",
+			doc.PostID, doc.SourceType)
+		if err != nil {
+			return fmt.Errorf("failed to delete previous vectors: %w", err)
+		}
+	}
+	return nil
+}
+
 // sqlNullInt returns NULL if the condition is false, otherwise the value
 func sqlNullInt(condition bool, val int) interface{} {
 	if !condition {
@@ -133,21 +200,37 @@ func (pv *PGVector) Search(ctx context.Context, embedding []float32, opts embedd
 		"e.is_chunk",
 		"e.chunk_index",
 		"e.total_chunks",
+		"e.source_type",
+		"e.metadata",
 		"(e.embedding <-> ?) as similarity",
 	).
 		From("llm_posts_embeddings e").
 		Join("Channels c ON e.channel_id = c.Id").
 		Join("ChannelMembers cm ON e.channel_id = cm.ChannelId").
+		Join("Posts p ON e.post_id = p.Id").
 		Where("cm.UserId = ?", opts.UserID).
 		Where("c.DeleteAt = 0").
+		Where("p.DeleteAt = 0").
 		PlaceholderFormat(sq.Dollar)
 
 	if opts.TeamID != "" {
 		queryBuilder = queryBuilder.Where(sq.Eq{"e.team_id": opts.TeamID})
 	}
 
-	if opts.ChannelID != "" {
-		queryBuilder = queryBuilder.Where(sq.Eq{"e.channel_id": opts.ChannelID})
+	if channelIDs := opts.Channels(); channelIDs != nil {
+		queryBuilder = queryBuilder.Where(sq.Eq{"e.channel_id": channelIDs})
+	}
+
+	if len(opts.FromUserIDs) > 0 {
+		queryBuilder = queryBuilder.Where(sq.Eq{"e.user_id": opts.FromUserIDs})
+	}
+
+	if len(opts.ExcludedTeamIDs) > 0 {
+		queryBuilder = queryBuilder.Where(sq.NotEq{"e.team_id": opts.ExcludedTeamIDs})
+	}
+
+	if len(opts.ExcludedChannelIDs) > 0 {
+		queryBuilder = queryBuilder.Where(sq.NotEq{"e.channel_id": opts.ExcludedChannelIDs})
 	}
 
 	if opts.CreatedAfter != 0 {
@@ -158,6 +241,18 @@ func (pv *PGVector) Search(ctx context.Context, embedding []float32, opts embedd
 		queryBuilder = queryBuilder.Where(sq.Lt{"e.created_at": opts.CreatedBefore})
 	}
 
+	switch opts.Corpus {
+	case embeddings.CorpusPosts:
+		queryBuilder = queryBuilder.Where(sq.Eq{"e.source_type": ""})
+	case embeddings.CorpusMeetings:
+		queryBuilder = queryBuilder.Where(sq.Eq{"e.source_type": []string{
+			embeddings.SourceTypeMeetingSummary,
+			embeddings.SourceTypeMeetingTranscript,
+		}})
+	case embeddings.CorpusFiles:
+		queryBuilder = queryBuilder.Where(sq.Eq{"e.source_type": embeddings.SourceTypeFile})
+	}
+
 	queryBuilder = queryBuilder.OrderBy("similarity ASC")
 
 	if opts.Limit > 0 && opts.Limit < 100000 {
@@ -185,7 +280,7 @@ func (pv *PGVector) Search(ctx context.Context, embedding []float32, opts embedd
 func scanSearchResults(rows *sqlx.Rows, minScore float32) ([]embeddings.SearchResult, error) {
 	var results []embeddings.SearchResult
 	for rows.Next() {
-		var postID, teamID, channelID, userID, content string
+		var postID, teamID, channelID, userID, content, sourceType, metadata string
 		var isChunk bool
 		var chunkIndex, totalChunks *int
 		var similarity float32
@@ -201,6 +296,8 @@ func scanSearchResults(rows *sqlx.Rows, minScore float32) ([]embeddings.SearchRe
 			&isChunk,
 			&chunkIndex,
 			&totalChunks,
+			&sourceType,
+			&metadata,
 			&similarity,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
@@ -216,17 +313,33 @@ func scanSearchResults(rows *sqlx.Rows, minScore float32) ([]embeddings.SearchRe
 		}
 
 		doc := embeddings.PostDocument{
-			PostID:    postID,
-			CreateAt:  createAt,
-			TeamID:    teamID,
-			ChannelID: channelID,
-			UserID:    userID,
-			Content:   content,
+			PostID:     postID,
+			CreateAt:   createAt,
+			TeamID:     teamID,
+			ChannelID:  channelID,
+			UserID:     userID,
+			Content:    content,
+			SourceType: sourceType,
 			ChunkInfo: chunking.ChunkInfo{
 				IsChunk: isChunk,
 			},
 		}
 
+		if metadata != "" {
+			switch doc.Corpus() {
+			case embeddings.CorpusMeetings:
+				doc.Meeting = &embeddings.MeetingMetadata{}
+				if err := json.Unmarshal([]byte(metadata), doc.Meeting); err != nil {
+					return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+				}
+			case embeddings.CorpusFiles:
+				doc.File = &embeddings.FileMetadata{}
+				if err := json.Unmarshal([]byte(metadata), doc.File); err != nil {
+					return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+				}
+			}
+		}
+
 		if isChunk {
 			if chunkIndex != nil {
 				doc.ChunkIndex = *chunkIndex
@@ -246,9 +359,20 @@ func scanSearchResults(rows *sqlx.Rows, minScore float32) ([]embeddings.SearchRe
 }
 
 func (pv *PGVector) Delete(ctx context.Context, postIDs []string) error {
+	return pv.deleteWhere(ctx, sq.Eq{"post_id": postIDs})
+}
+
+func (pv *PGVector) DeleteRebuilt(ctx context.Context, postIDs []string) error {
+	return pv.deleteWhere(ctx, sq.And{
+		sq.Eq{"post_id": postIDs},
+		sq.Eq{"source_type": []string{"", embeddings.SourceTypeFile}},
+	})
+}
+
+func (pv *PGVector) deleteWhere(ctx context.Context, where sq.Sqlizer) error {
 	query, args, err := sq.
 		Delete("llm_posts_embeddings").
-		Where(sq.Eq{"post_id": postIDs}).
+		Where(where).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
@@ -262,7 +386,8 @@ func (pv *PGVector) Delete(ctx context.Context, postIDs []string) error {
 }
 
 func (pv *PGVector) Clear(ctx context.Context) error {
-	_, err := pv.db.ExecContext(ctx, "TRUNCATE TABLE llm_posts_embeddings")
+	// Documents from other sources can't be rebuilt by reindexing the posts, so they are kept
+	_, err := pv.db.ExecContext(ctx, "DELETE FROM llm_posts_embeddings WHERE source_type IN ('', $1)", embeddings.SourceTypeFile)
 	if err != nil {
 		return fmt.Errorf("failed to clear vectors: %w", err)
 	}
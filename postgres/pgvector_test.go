@@ -71,7 +71,8 @@ func testDB(t *testing.T) *sqlx.DB {
 	tables := []string{
 		`CREATE TABLE IF NOT EXISTS Posts (
 			Id TEXT PRIMARY KEY,
-			CreateAt BIGINT NOT NULL
+			CreateAt BIGINT NOT NULL,
+			DeleteAt BIGINT NOT NULL DEFAULT 0
 		)`,
 		`CREATE TABLE IF NOT EXISTS Channels (
 			Id TEXT PRIMARY KEY,
@@ -616,6 +617,41 @@ func TestSearch(t *testing.T) {
 		assert.Equal(t, "post3", results[0].Document.PostID)
 	})
 
+	t.Run("search with channels and author filters", func(t *testing.T) {
+		ctx, pgVector, db, _, searchVector := setupSearchTest(t)
+		defer cleanupDB(t, db)
+
+		opts := embeddings.SearchOptions{
+			ChannelIDs:  []string{"channel1", "channel3", "channel4"},
+			FromUserIDs: []string{"user2"},
+			UserID:      "system_user",
+		}
+
+		results, err := pgVector.Search(ctx, searchVector, opts)
+		require.NoError(t, err)
+		assert.Len(t, results, 2)
+		ids := []string{results[0].Document.PostID, results[1].Document.PostID}
+		assert.Contains(t, ids, "post3")
+		assert.Contains(t, ids, "post4")
+	})
+
+	t.Run("search leaving out excluded teams and channels", func(t *testing.T) {
+		ctx, pgVector, db, _, searchVector := setupSearchTest(t)
+		defer cleanupDB(t, db)
+
+		opts := embeddings.SearchOptions{
+			Limit:              1,
+			ExcludedTeamIDs:    []string{"team2"},
+			ExcludedChannelIDs: []string{"channel2"},
+			UserID:             "system_user",
+		}
+
+		results, err := pgVector.Search(ctx, searchVector, opts)
+		require.NoError(t, err)
+		require.Len(t, results, 1, "excluded documents don't take the place of others within the limit")
+		assert.Equal(t, "post1", results[0].Document.PostID)
+	})
+
 	t.Run("search with min score filter", func(t *testing.T) {
 		ctx, pgVector, db, _, searchVector := setupSearchTest(t)
 		defer cleanupDB(t, db)
@@ -1082,3 +1118,198 @@ func TestClear(t *testing.T) {
 		assert.Equal(t, 0, count)
 	})
 }
+
+func TestMeetingDocuments(t *testing.T) {
+	db := testDB(t)
+	defer cleanupDB(t, db)
+
+	pgVector, err := NewPGVector(db, PGVectorConfig{Dimensions: 3})
+	require.NoError(t, err)
+
+	now := model.GetMillis()
+	addTestPosts(t, db, []string{"post1", "call1"}, []int64{now, now})
+	addTestChannels(t, db, []string{"channel1"}, false)
+	addTestChannelMembers(t, db, "channel1", []string{"user1"})
+
+	meeting := &embeddings.MeetingMetadata{Participants: []string{"alice", "bob"}}
+	docs := []embeddings.PostDocument{
+		{
+			PostID:    "post1",
+			CreateAt:  now,
+			TeamID:    "team1",
+			ChannelID: "channel1",
+			UserID:    "user1",
+			Content:   "A post about pricing",
+		},
+		{
+			PostID:     "call1",
+			CreateAt:   now,
+			TeamID:     "team1",
+			ChannelID:  "channel1",
+			UserID:     "user1",
+			Content:    "We decided to raise the pricing",
+			SourceType: embeddings.SourceTypeMeetingSummary,
+			Meeting:    meeting,
+		},
+		{
+			PostID:     "call1",
+			CreateAt:   now,
+			TeamID:     "team1",
+			ChannelID:  "channel1",
+			UserID:     "user1",
+			Content:    "Transcript chunk 0",
+			SourceType: embeddings.SourceTypeMeetingTranscript,
+			Meeting:    meeting,
+			ChunkInfo:  chunking.ChunkInfo{IsChunk: true, ChunkIndex: 0, TotalChunks: 2},
+		},
+		{
+			PostID:     "call1",
+			CreateAt:   now,
+			TeamID:     "team1",
+			ChannelID:  "channel1",
+			UserID:     "user1",
+			Content:    "Transcript chunk 1",
+			SourceType: embeddings.SourceTypeMeetingTranscript,
+			Meeting:    meeting,
+			ChunkInfo:  chunking.ChunkInfo{IsChunk: true, ChunkIndex: 1, TotalChunks: 2},
+		},
+	}
+	embedVectors := [][]float32{
+		{0.1, 0.2, 0.3},
+		{0.1, 0.2, 0.3},
+		{0.4, 0.5, 0.6},
+		{0.7, 0.8, 0.9},
+	}
+
+	ctx := context.Background()
+	require.NoError(t, pgVector.Store(ctx, docs, embedVectors))
+
+	countRows := func() int {
+		var count int
+		require.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM llm_posts_embeddings"))
+		return count
+	}
+	require.Equal(t, 4, countRows())
+
+	t.Run("search filters by corpus", func(t *testing.T) {
+		tests := []struct {
+			corpus   string
+			expected []string
+		}{
+			{corpus: "", expected: []string{"", embeddings.SourceTypeMeetingSummary, embeddings.SourceTypeMeetingTranscript, embeddings.SourceTypeMeetingTranscript}},
+			{corpus: embeddings.CorpusPosts, expected: []string{""}},
+			{corpus: embeddings.CorpusMeetings, expected: []string{embeddings.SourceTypeMeetingSummary, embeddings.SourceTypeMeetingTranscript, embeddings.SourceTypeMeetingTranscript}},
+		}
+		for _, tc := range tests {
+			results, err := pgVector.Search(ctx, []float32{0.1, 0.2, 0.3}, embeddings.SearchOptions{
+				UserID: "user1",
+				Corpus: tc.corpus,
+			})
+			require.NoError(t, err)
+
+			var sourceTypes []string
+			for _, result := range results {
+				sourceTypes = append(sourceTypes, result.Document.SourceType)
+				if result.Document.SourceType != "" {
+					assert.Equal(t, meeting, result.Document.Meeting)
+				} else {
+					assert.Nil(t, result.Document.Meeting)
+				}
+			}
+			assert.ElementsMatch(t, tc.expected, sourceTypes, "corpus %q", tc.corpus)
+		}
+	})
+
+	t.Run("storing a source again replaces its chunks", func(t *testing.T) {
+		err := pgVector.Store(ctx, []embeddings.PostDocument{
+			{
+				PostID:     "call1",
+				CreateAt:   now,
+				TeamID:     "team1",
+				ChannelID:  "channel1",
+				UserID:     "user1",
+				Content:    "Shorter transcript",
+				SourceType: embeddings.SourceTypeMeetingTranscript,
+				Meeting:    meeting,
+			},
+		}, [][]float32{{0.4, 0.5, 0.6}})
+		require.NoError(t, err)
+
+		var ids []string
+		require.NoError(t, db.Select(&ids, "SELECT id FROM llm_posts_embeddings ORDER BY id"))
+		assert.Equal(t, []string{"call1_meeting_summary", "call1_meeting_transcript", "post1"}, ids)
+	})
+
+	t.Run("documents of deleted posts aren't found", func(t *testing.T) {
+		_, err := db.Exec("UPDATE Posts SET DeleteAt = $1 WHERE Id = $2", now, "call1")
+		require.NoError(t, err)
+		defer func() {
+			_, err := db.Exec("UPDATE Posts SET DeleteAt = 0 WHERE Id = $1", "call1")
+			require.NoError(t, err)
+		}()
+
+		results, err := pgVector.Search(ctx, []float32{0.1, 0.2, 0.3}, embeddings.SearchOptions{UserID: "user1"})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "post1", results[0].Document.PostID)
+	})
+
+	t.Run("reindexing and clearing keep meeting documents", func(t *testing.T) {
+		require.NoError(t, pgVector.DeleteRebuilt(ctx, []string{"call1"}))
+		assert.Equal(t, 3, countRows())
+
+		require.NoError(t, pgVector.Clear(ctx))
+
+		var ids []string
+		require.NoError(t, db.Select(&ids, "SELECT id FROM llm_posts_embeddings ORDER BY id"))
+		assert.Equal(t, []string{"call1_meeting_summary", "call1_meeting_transcript"}, ids)
+	})
+
+	t.Run("deleting the call post deletes its meeting documents", func(t *testing.T) {
+		require.NoError(t, pgVector.Delete(ctx, []string{"call1"}))
+		assert.Equal(t, 0, countRows())
+	})
+}
+
+func TestFileDocuments(t *testing.T) {
+	db := testDB(t)
+	defer cleanupDB(t, db)
+
+	pgVector, err := NewPGVector(db, PGVectorConfig{Dimensions: 3})
+	require.NoError(t, err)
+
+	now := model.GetMillis()
+	addTestPosts(t, db, []string{"post1"}, []int64{now})
+	addTestChannels(t, db, []string{"channel1"}, false)
+	addTestChannelMembers(t, db, "channel1", []string{"user1"})
+
+	spec := &embeddings.FileMetadata{ID: "file1", Name: "spec.pdf"}
+	notes := &embeddings.FileMetadata{ID: "file2", Name: "notes.txt"}
+	docs := []embeddings.PostDocument{
+		{PostID: "post1", CreateAt: now, TeamID: "team1", ChannelID: "channel1", UserID: "user1", Content: "See the attached files"},
+		{PostID: "post1", CreateAt: now, TeamID: "team1", ChannelID: "channel1", UserID: "user1", Content: "The API is versioned", SourceType: embeddings.SourceTypeFile, File: spec},
+		{PostID: "post1", CreateAt: now, TeamID: "team1", ChannelID: "channel1", UserID: "user1", Content: "Release on Friday", SourceType: embeddings.SourceTypeFile, File: notes},
+	}
+
+	ctx := context.Background()
+	require.NoError(t, pgVector.Store(ctx, docs, [][]float32{{0.1, 0.2, 0.3}, {0.4, 0.5, 0.6}, {0.7, 0.8, 0.9}}))
+
+	var ids []string
+	require.NoError(t, db.Select(&ids, "SELECT id FROM llm_posts_embeddings ORDER BY id"))
+	assert.Equal(t, []string{"post1", "post1_file_file1", "post1_file_file2"}, ids)
+
+	results, err := pgVector.Search(ctx, []float32{0.4, 0.5, 0.6}, embeddings.SearchOptions{
+		UserID: "user1",
+		Corpus: embeddings.CorpusFiles,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, spec, results[0].Document.File)
+	assert.Equal(t, notes, results[1].Document.File)
+
+	// Files are rebuilt from the posts, so they are deleted and cleared with them
+	require.NoError(t, pgVector.Delete(ctx, []string{"post1"}))
+	var count int
+	require.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM llm_posts_embeddings"))
+	assert.Equal(t, 0, count)
+}
@@ -5,24 +5,56 @@ package prompts
 
 // Automatically generated convenience vars for the filenames in prompts/
 const (
-	PromptDirectMessageQuestionSystem      = "direct_message_question_system"
-	PromptEmojiSelectSystem                = "emoji_select_system"
-	PromptFindActionItemsSystem            = "find_action_items_system"
-	PromptFindActionItemsUser              = "find_action_items_user"
-	PromptFindOpenQuestionsSystem          = "find_open_questions_system"
-	PromptFindOpenQuestionsUser            = "find_open_questions_user"
-	PromptLocale                           = "locale"
-	PromptMeetingSummaryGeneral            = "meeting_summary_general"
-	PromptMeetingSummarySystem             = "meeting_summary_system"
-	PromptMeetingSummaryUser               = "meeting_summary_user"
-	PromptSearchResults                    = "search_results"
-	PromptSearchSystem                     = "search_system"
-	PromptSearchUser                       = "search_user"
-	PromptStandardPersonality              = "standard_personality"
-	PromptStandardPersonalityWithoutLocale = "standard_personality_without_locale"
-	PromptSummarizeChannelRangeSystem      = "summarize_channel_range_system"
-	PromptSummarizeChannelSinceSystem      = "summarize_channel_since_system"
-	PromptSummarizeChunkSystem             = "summarize_chunk_system"
-	PromptSummarizeThreadSystem            = "summarize_thread_system"
-	PromptThreadUser                       = "thread_user"
+	PromptAskAboutPostSystem                 = "ask_about_post_system"
+	PromptComposeSuggestionsSystem           = "compose_suggestions_system"
+	PromptDirectMessageQuestionSystem        = "direct_message_question_system"
+	PromptEmojiSelectSystem                  = "emoji_select_system"
+	PromptEnsembleJudgeSystem                = "ensemble_judge_system"
+	PromptFindActionItemsSystem              = "find_action_items_system"
+	PromptFindActionItemsUser                = "find_action_items_user"
+	PromptFindOpenQuestionsSystem            = "find_open_questions_system"
+	PromptFindOpenQuestionsUser              = "find_open_questions_user"
+	PromptFollowUpSuggestionsSystem          = "follow_up_suggestions_system"
+	PromptFollowUpSuggestionsUser            = "follow_up_suggestions_user"
+	PromptGlossarySystem                     = "glossary_system"
+	PromptHandoffSystem                      = "handoff_system"
+	PromptLocale                             = "locale"
+	PromptLongContentChunkSystem             = "long_content_chunk_system"
+	PromptLongContentUser                    = "long_content_user"
+	PromptMeetingActionItemsSystem           = "meeting_action_items_system"
+	PromptMeetingChaptersSystem              = "meeting_chapters_system"
+	PromptMeetingKeyMomentsSystem            = "meeting_key_moments_system"
+	PromptMeetingSpeakerIdentificationSystem = "meeting_speaker_identification_system"
+	PromptMeetingSummaryCustomerCallSystem   = "meeting_summary_customer_call_system"
+	PromptMeetingSummaryGeneral              = "meeting_summary_general"
+	PromptMeetingSummaryIncidentReviewSystem = "meeting_summary_incident_review_system"
+	PromptMeetingSummaryRetroSystem          = "meeting_summary_retro_system"
+	PromptMeetingSummaryStandupSystem        = "meeting_summary_standup_system"
+	PromptMeetingSummaryStructuredSystem     = "meeting_summary_structured_system"
+	PromptMeetingSummarySystem               = "meeting_summary_system"
+	PromptMeetingSummaryTemplateSystem       = "meeting_summary_template_system"
+	PromptMeetingSummaryUser                 = "meeting_summary_user"
+	PromptMeetingTranscriptQuestionSystem    = "meeting_transcript_question_system"
+	PromptMeetingTranscriptTranslationSystem = "meeting_transcript_translation_system"
+	PromptRegenerationInstructionSystem      = "regeneration_instruction_system"
+	PromptRewriteGrammarSystem               = "rewrite_grammar_system"
+	PromptRewriteShortenSystem               = "rewrite_shorten_system"
+	PromptRewriteToneSystem                  = "rewrite_tone_system"
+	PromptRewriteTranslateSystem             = "rewrite_translate_system"
+	PromptSearchResults                      = "search_results"
+	PromptSearchSystem                       = "search_system"
+	PromptSearchUser                         = "search_user"
+	PromptStandardPersonality                = "standard_personality"
+	PromptStandardPersonalityWithoutLocale   = "standard_personality_without_locale"
+	PromptSummarizeChannelRangeSize          = "summarize_channel_range_size"
+	PromptSummarizeChannelRangeSystem        = "summarize_channel_range_system"
+	PromptSummarizeChannelSinceSystem        = "summarize_channel_since_system"
+	PromptSummarizeChunkSummariesSystem      = "summarize_chunk_summaries_system"
+	PromptSummarizeChunkSystem               = "summarize_chunk_system"
+	PromptSummarizeDroppedHistorySystem      = "summarize_dropped_history_system"
+	PromptSummarizeThreadSystem              = "summarize_thread_system"
+	PromptSummaryRefinementSystem            = "summary_refinement_system"
+	PromptThreadClassificationSystem         = "thread_classification_system"
+	PromptThreadUser                         = "thread_user"
+	PromptToolCallExplanationSystem          = "tool_call_explanation_system"
 )
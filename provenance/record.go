@@ -0,0 +1,147 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package provenance lists the posts generated by the agents with where they came from, in a stable
+// schema external compliance and DLP scanners can consume without knowing the props of the plugin.
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+)
+
+// SchemaVersion is the version of the records. Fields can be added within a version, it only changes
+// when fields are renamed, removed or change meaning.
+const SchemaVersion = 1
+
+// ErrInvalidCursor is returned when listing records from a cursor that wasn't returned by List.
+var ErrInvalidCursor = errors.New("invalid provenance cursor")
+
+// Record is the provenance of a post generated by an agent.
+type Record struct {
+	PostID    string `json:"postId"`
+	ChannelID string `json:"channelId"`
+	TeamID    string `json:"teamId"`
+	RootID    string `json:"rootId"`
+	PostType  string `json:"postType"`
+
+	// BotUserID is the agent that generated the post, which is also its author
+	BotUserID string `json:"botUserId"`
+
+	// RequesterUserID is the user the post was generated for, empty for posts no one asked for
+	RequesterUserID    string `json:"requesterUserId"`
+	RespondingToPostID string `json:"respondingToPostId"`
+
+	// Model is the model that generated the post as configured, BaseModel the model it is a fine-tune of.
+	// Both are empty for posts generated before models were recorded.
+	Model             string `json:"model"`
+	BaseModel         string `json:"baseModel"`
+	Experiment        string `json:"experiment"`
+	ExperimentVariant string `json:"experimentVariant"`
+
+	// MessageSHA256 is the hex SHA-256 of the message, to match the post against other exports without its content
+	MessageSHA256 string `json:"messageSha256"`
+
+	CreateAt int64 `json:"createAt"`
+	UpdateAt int64 `json:"updateAt"`
+	EditAt   int64 `json:"editAt"`
+	DeleteAt int64 `json:"deleteAt"`
+}
+
+// Page is a batch of records in the order posts were created.
+type Page struct {
+	SchemaVersion int      `json:"schemaVersion"`
+	Records       []Record `json:"records"`
+
+	// NextCursor continues the listing after the last record, it is empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// postRow is a post marked as AI generated, as read from the Posts table
+type postRow struct {
+	ID        string
+	ChannelID string
+	TeamID    string
+	RootID    string
+	UserID    string
+	Type      string
+	Message   string
+	Props     string
+	CreateAt  int64
+	UpdateAt  int64
+	EditAt    int64
+	DeleteAt  int64
+}
+
+// toRecord returns the provenance of the post. Posts marked by someone else than the bot named in
+// the mark aren't AI generated, since users can set props on their own posts.
+func (r postRow) toRecord() (Record, bool) {
+	props := map[string]any{}
+	if r.Props != "" {
+		if err := json.Unmarshal([]byte(r.Props), &props); err != nil {
+			return Record{}, false
+		}
+	}
+	prop := func(key string) string {
+		value, _ := props[key].(string)
+		return value
+	}
+
+	if prop(streaming.AIGeneratedProp) != "true" || r.UserID == "" || prop(streaming.AIGeneratedBotIDProp) != r.UserID {
+		return Record{}, false
+	}
+
+	hash := sha256.Sum256([]byte(r.Message))
+	return Record{
+		PostID:             r.ID,
+		ChannelID:          r.ChannelID,
+		TeamID:             r.TeamID,
+		RootID:             r.RootID,
+		PostType:           r.Type,
+		BotUserID:          r.UserID,
+		RequesterUserID:    prop(streaming.LLMRequesterUserID),
+		RespondingToPostID: prop(streaming.RespondingToProp),
+		Model:              prop(llm.ModelProp),
+		BaseModel:          prop(llm.BaseModelProp),
+		Experiment:         prop(llm.ExperimentProp),
+		ExperimentVariant:  prop(llm.ExperimentVariantProp),
+		MessageSHA256:      hex.EncodeToString(hash[:]),
+		CreateAt:           r.CreateAt,
+		UpdateAt:           r.UpdateAt,
+		EditAt:             r.EditAt,
+		DeleteAt:           r.DeleteAt,
+	}, true
+}
+
+// cursor identifies the last post of a page by its creation time and ID, which posts are ordered by
+type cursor struct {
+	CreateAt int64
+	PostID   string
+}
+
+func (c cursor) String() string {
+	return fmt.Sprintf("%d_%s", c.CreateAt, c.PostID)
+}
+
+func parseCursor(value string) (cursor, error) {
+	if value == "" {
+		return cursor{}, nil
+	}
+	createAt, postID, found := strings.Cut(value, "_")
+	if !found || postID == "" {
+		return cursor{}, ErrInvalidCursor
+	}
+	millis, err := strconv.ParseInt(createAt, 10, 64)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	return cursor{CreateAt: millis, PostID: postID}, nil
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package provenance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRowToRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		row      postRow
+		expected *Record
+	}{
+		{
+			name: "response",
+			row: postRow{
+				ID:        "post",
+				ChannelID: "channel",
+				TeamID:    "team",
+				UserID:    "bot",
+				Type:      "custom_llmbot",
+				Message:   "hello",
+				Props:     `{"ai_generated":"true","ai_generated_bot_id":"bot","llm_requester_user_id":"user","responding_to":"question","llm_model":"support","llm_base_model":"gpt-4o-mini","llm_experiment":"upgrade","llm_experiment_variant":"alternate"}`,
+				CreateAt:  10,
+				UpdateAt:  20,
+			},
+			expected: &Record{
+				PostID:             "post",
+				ChannelID:          "channel",
+				TeamID:             "team",
+				PostType:           "custom_llmbot",
+				BotUserID:          "bot",
+				RequesterUserID:    "user",
+				RespondingToPostID: "question",
+				Model:              "support",
+				BaseModel:          "gpt-4o-mini",
+				Experiment:         "upgrade",
+				ExperimentVariant:  "alternate",
+				MessageSHA256:      "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+				CreateAt:           10,
+				UpdateAt:           20,
+			},
+		},
+		{
+			name: "deleted post without model",
+			row: postRow{
+				ID:       "post",
+				UserID:   "bot",
+				Props:    `{"ai_generated":"true","ai_generated_bot_id":"bot"}`,
+				DeleteAt: 30,
+			},
+			expected: &Record{
+				PostID:        "post",
+				BotUserID:     "bot",
+				MessageSHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+				DeleteAt:      30,
+			},
+		},
+		{
+			name: "marked by a user",
+			row: postRow{
+				ID:     "post",
+				UserID: "user",
+				Props:  `{"ai_generated":"true","ai_generated_bot_id":"bot"}`,
+			},
+		},
+		{
+			name: "not marked",
+			row: postRow{
+				ID:     "post",
+				UserID: "bot",
+				Props:  `{"ai_generated_bot_id":"bot"}`,
+			},
+		},
+		{
+			name: "invalid props",
+			row: postRow{
+				ID:     "post",
+				UserID: "bot",
+				Props:  `{`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			record, ok := tc.row.toRecord()
+			if tc.expected == nil {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, *tc.expected, record)
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	parsed, err := parseCursor(cursor{CreateAt: 1700000000000, PostID: "abc"}.String())
+	require.NoError(t, err)
+	assert.Equal(t, cursor{CreateAt: 1700000000000, PostID: "abc"}, parsed)
+
+	parsed, err = parseCursor("")
+	require.NoError(t, err)
+	assert.Equal(t, cursor{}, parsed)
+
+	for _, invalid := range []string{"abc", "12_", "x_abc"} {
+		_, err = parseCursor(invalid)
+		assert.ErrorIs(t, err, ErrInvalidCursor, invalid)
+	}
+}
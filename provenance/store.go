@@ -0,0 +1,85 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package provenance
+
+import (
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+)
+
+// MaxPageSize limits the records listed at once
+const MaxPageSize = 1000
+
+// Store reads the provenance of AI generated posts from the Posts table.
+type Store struct {
+	db *mmapi.DBClient
+}
+
+// NewStore creates a provenance store.
+func NewStore(db *mmapi.DBClient) *Store {
+	return &Store{db: db}
+}
+
+// List returns the provenance of the posts generated between since and until, in milliseconds, in the order
+// they were created. Deleted posts are included with their deletion time. The listing continues after the
+// cursor of the previous page when one is given.
+func (s *Store) List(since, until int64, after string, limit int) (*Page, error) {
+	from, err := parseCursor(after)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	query := s.db.Builder().
+		Select(
+			"p.Id",
+			"p.ChannelId",
+			"COALESCE(c.TeamId, '') AS TeamId",
+			"p.RootId",
+			"p.UserId",
+			"p.Type",
+			"p.Message",
+			"p.Props",
+			"p.CreateAt",
+			"p.UpdateAt",
+			"p.EditAt",
+			"p.DeleteAt",
+		).
+		From("Posts AS p").
+		LeftJoin("Channels AS c ON c.Id = p.ChannelId").
+		Where(sq.Expr("p.Props->>? = 'true'", streaming.AIGeneratedProp)).
+		Where(sq.GtOrEq{"p.CreateAt": since}).
+		Where(sq.Lt{"p.CreateAt": until}).
+		OrderBy("p.CreateAt", "p.Id").
+		Limit(uint64(limit))
+	if from.PostID != "" {
+		query = query.Where(sq.Expr("(p.CreateAt, p.Id) > (?, ?)", from.CreateAt, from.PostID))
+	}
+
+	var rows []postRow
+	if err := s.db.DoQuery(&rows, query); err != nil {
+		return nil, fmt.Errorf("failed to list AI generated posts: %w", err)
+	}
+
+	page := &Page{
+		SchemaVersion: SchemaVersion,
+		Records:       make([]Record, 0, len(rows)),
+	}
+	for _, row := range rows {
+		if record, ok := row.toRecord(); ok {
+			page.Records = append(page.Records, record)
+		}
+	}
+	// Rows that aren't AI generated after all still move the cursor
+	if len(rows) == limit {
+		last := rows[len(rows)-1]
+		page.NextCursor = cursor{CreateAt: last.CreateAt, PostID: last.ID}.String()
+	}
+	return page, nil
+}
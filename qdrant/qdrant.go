@@ -0,0 +1,485 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package qdrant provides a vector store backed by an external Qdrant database, for deployments that
+// want to scale search beyond the Mattermost database. It talks to the REST API of Qdrant.
+package qdrant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mattermost/mattermost-plugin-ai/chunking"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+)
+
+const (
+	DefaultCollection = "mattermost_ai_posts"
+	DefaultBatchSize  = 100
+
+	// maxSearchLimit bounds the results of searches without a limit, Qdrant always needs one
+	maxSearchLimit = 1000
+)
+
+// rebuiltCorpora are the corpora of the documents rebuilt from the posts by reindexing
+var rebuiltCorpora = []string{embeddings.CorpusPosts, embeddings.CorpusFiles}
+
+// pointNamespace derives the UUIDs of the points from the IDs of the documents, Qdrant only accepts
+// UUIDs and integers as point IDs
+var pointNamespace = uuid.MustParse("5c4b6a3e-8f0d-4d8e-9a59-0f7f3c1d2b6e")
+
+type Config struct {
+	URL        string `json:"url"`
+	APIKey     string `json:"apiKey"`
+	Collection string `json:"collection"`
+	Dimensions int    `json:"dimensions"`
+
+	// BatchSize is the number of points upserted per request
+	BatchSize int `json:"batchSize"`
+}
+
+// ChannelMembership lists the channels a user can search, Qdrant can't check the memberships itself
+// since they are kept in the Mattermost database.
+type ChannelMembership interface {
+	ChannelIDsForUser(ctx context.Context, userID string) ([]string, error)
+}
+
+// Qdrant is a vector store keeping the documents as points of a Qdrant collection, with their
+// metadata in the payload of the points.
+type Qdrant struct {
+	config     Config
+	baseURL    string
+	httpClient *http.Client
+	channels   ChannelMembership
+}
+
+// payload is the metadata of a document stored with its point
+type payload struct {
+	PostID      string                      `json:"post_id"`
+	TeamID      string                      `json:"team_id"`
+	ChannelID   string                      `json:"channel_id"`
+	UserID      string                      `json:"user_id"`
+	Content     string                      `json:"content"`
+	CreatedAt   int64                       `json:"created_at"`
+	IsChunk     bool                        `json:"is_chunk"`
+	ChunkIndex  int                         `json:"chunk_index,omitempty"`
+	TotalChunks int                         `json:"total_chunks,omitempty"`
+	SourceType  string                      `json:"source_type"`
+	Corpus      string                      `json:"corpus"`
+	Meeting     *embeddings.MeetingMetadata `json:"meeting,omitempty"`
+	File        *embeddings.FileMetadata    `json:"file,omitempty"`
+}
+
+// indexedFields are the payload fields searches and deletions filter on, and their index type
+var indexedFields = map[string]string{
+	"post_id":     "keyword",
+	"team_id":     "keyword",
+	"channel_id":  "keyword",
+	"user_id":     "keyword",
+	"source_type": "keyword",
+	"corpus":      "keyword",
+	"created_at":  "integer",
+}
+
+type point struct {
+	ID      string    `json:"id"`
+	Vector  []float32 `json:"vector"`
+	Payload payload   `json:"payload"`
+}
+
+type scoredPoint struct {
+	ID      string  `json:"id"`
+	Score   float32 `json:"score"`
+	Payload payload `json:"payload"`
+}
+
+type condition struct {
+	Key   string `json:"key"`
+	Match any    `json:"match,omitempty"`
+	Range any    `json:"range,omitempty"`
+}
+
+type filter struct {
+	Must    []condition `json:"must"`
+	MustNot []condition `json:"must_not,omitempty"`
+}
+
+func matchValue(key string, value any) condition {
+	return condition{Key: key, Match: map[string]any{"value": value}}
+}
+
+func matchAny(key string, values []string) condition {
+	return condition{Key: key, Match: map[string]any{"any": values}}
+}
+
+// statusError is returned for requests Qdrant answers with an error status
+type statusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("qdrant returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// New creates a Qdrant vector store, creating the collection and the indexes of its payload if they
+// don't exist yet.
+func New(ctx context.Context, httpClient *http.Client, config Config, channels ChannelMembership) (*Qdrant, error) {
+	if config.URL == "" {
+		return nil, errors.New("qdrant URL is required")
+	}
+	if _, err := url.Parse(config.URL); err != nil {
+		return nil, fmt.Errorf("invalid qdrant URL: %w", err)
+	}
+	if config.Dimensions <= 0 {
+		return nil, errors.New("qdrant needs the dimensions of the embeddings")
+	}
+	if config.Collection == "" {
+		config.Collection = DefaultCollection
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+
+	q := &Qdrant{
+		config:     config,
+		baseURL:    strings.TrimSuffix(config.URL, "/"),
+		httpClient: httpClient,
+		channels:   channels,
+	}
+	if err := q.ensureCollection(ctx); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// ensureCollection creates the collection if needed, and checks an existing one has the dimensions
+// of the embeddings
+func (q *Qdrant) ensureCollection(ctx context.Context) error {
+	var info struct {
+		Config struct {
+			Params struct {
+				Vectors struct {
+					Size int `json:"size"`
+				} `json:"vectors"`
+			} `json:"params"`
+		} `json:"config"`
+	}
+	err := q.do(ctx, http.MethodGet, q.collectionPath(""), nil, &info)
+	var statusErr *statusError
+	switch {
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound:
+		err = q.do(ctx, http.MethodPut, q.collectionPath(""), map[string]any{
+			"vectors": map[string]any{
+				"size":     q.config.Dimensions,
+				"distance": "Cosine",
+			},
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create qdrant collection: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get qdrant collection: %w", err)
+	case info.Config.Params.Vectors.Size != q.config.Dimensions:
+		return fmt.Errorf("qdrant collection %s has %d dimensions instead of %d, reindex into a new collection", q.config.Collection, info.Config.Params.Vectors.Size, q.config.Dimensions)
+	}
+
+	// Creating an index that exists is a no-op
+	for field, schema := range indexedFields {
+		err := q.do(ctx, http.MethodPut, q.collectionPath("/index?wait=true"), map[string]any{
+			"field_name":   field,
+			"field_schema": schema,
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create qdrant index on %s: %w", field, err)
+		}
+	}
+	return nil
+}
+
+func (q *Qdrant) collectionPath(suffix string) string {
+	return "/collections/" + url.PathEscape(q.config.Collection) + suffix
+}
+
+// do sends a request to Qdrant and decodes the result of the response into result, when given.
+func (q *Qdrant) do(ctx context.Context, method, path string, body any, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal qdrant request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, q.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if q.config.APIKey != "" {
+		req.Header.Set("api-key", q.config.APIKey)
+	}
+
+	resp, err := q.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to reach qdrant: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Status json.RawMessage `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("unable to decode qdrant response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// Errors are reported as {"status": {"error": "..."}}
+		var status struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(response.Status, &status)
+		return &statusError{StatusCode: resp.StatusCode, Message: status.Error}
+	}
+
+	if result != nil && len(response.Result) > 0 {
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return fmt.Errorf("unable to decode qdrant result: %w", err)
+		}
+	}
+	return nil
+}
+
+// documentID identifies a document like the other vector stores do: by its post, its source type,
+// its file and its chunk
+func documentID(doc embeddings.PostDocument) string {
+	id := doc.PostID
+	if doc.SourceType != "" {
+		id = fmt.Sprintf("%s_%s", doc.PostID, doc.SourceType)
+	}
+	if doc.File != nil {
+		id = fmt.Sprintf("%s_%s", id, doc.File.ID)
+	}
+	if doc.IsChunk {
+		id = fmt.Sprintf("%s_chunk_%d", id, doc.ChunkIndex)
+	}
+	return id
+}
+
+func (q *Qdrant) Store(ctx context.Context, docs []embeddings.PostDocument, vectors [][]float32) error {
+	if len(docs) != len(vectors) {
+		return fmt.Errorf("got %d embeddings for %d documents", len(vectors), len(docs))
+	}
+	if err := q.deleteSources(ctx, docs); err != nil {
+		return err
+	}
+
+	points := make([]point, 0, len(docs))
+	for i, doc := range docs {
+		points = append(points, point{
+			ID:     uuid.NewSHA1(pointNamespace, []byte(documentID(doc))).String(),
+			Vector: vectors[i],
+			Payload: payload{
+				PostID:      doc.PostID,
+				TeamID:      doc.TeamID,
+				ChannelID:   doc.ChannelID,
+				UserID:      doc.UserID,
+				Content:     doc.Content,
+				CreatedAt:   doc.CreateAt,
+				IsChunk:     doc.IsChunk,
+				ChunkIndex:  doc.ChunkIndex,
+				TotalChunks: doc.TotalChunks,
+				SourceType:  doc.SourceType,
+				Corpus:      doc.Corpus(),
+				Meeting:     doc.Meeting,
+				File:        doc.File,
+			},
+		})
+	}
+
+	for start := 0; start < len(points); start += q.config.BatchSize {
+		batch := points[start:min(start+q.config.BatchSize, len(points))]
+		err := q.do(ctx, http.MethodPut, q.collectionPath("/points?wait=true"), map[string]any{"points": batch}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to upsert points: %w", err)
+		}
+	}
+	return nil
+}
+
+// deleteSources removes the stored documents of the non-post sources being stored, so a shorter
+// summary or transcript doesn't leave chunks of the previous version behind
+func (q *Qdrant) deleteSources(ctx context.Context, docs []embeddings.PostDocument) error {
+	seen := map[string]bool{}
+	for _, doc := range docs {
+		key := doc.PostID + "/" + doc.SourceType
+		if doc.SourceType == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		err := q.deletePoints(ctx, filter{Must: []condition{
+			matchValue("post_id", doc.PostID),
+			matchValue("source_type", doc.SourceType),
+		}})
+		if err != nil {
+			return fmt.Errorf("failed to delete previous points: %w", err)
+		}
+	}
+	return nil
+}
+
+func (q *Qdrant) deletePoints(ctx context.Context, pointsFilter filter) error {
+	return q.do(ctx, http.MethodPost, q.collectionPath("/points/delete?wait=true"), map[string]any{"filter": pointsFilter}, nil)
+}
+
+// Search returns the documents closest to the embedding in the channels the user is a member of.
+func (q *Qdrant) Search(ctx context.Context, embedding []float32, opts embeddings.SearchOptions) ([]embeddings.SearchResult, error) {
+	if opts.UserID == "" {
+		return nil, fmt.Errorf("user ID is required to validate permissions")
+	}
+
+	channelIDs, err := q.channels.ChannelIDsForUser(ctx, opts.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the channels of the user: %w", err)
+	}
+	if requested := opts.Channels(); requested != nil {
+		// Only the requested channels the user is a member of are searched
+		memberOf := channelIDs
+		channelIDs = nil
+		for _, channelID := range requested {
+			if slices.Contains(memberOf, channelID) {
+				channelIDs = append(channelIDs, channelID)
+			}
+		}
+	}
+	if len(opts.ExcludedChannelIDs) > 0 {
+		// The channels of the user may be cached, so they are left as they are
+		channelIDs = slices.DeleteFunc(slices.Clone(channelIDs), func(channelID string) bool {
+			return slices.Contains(opts.ExcludedChannelIDs, channelID)
+		})
+	}
+	if len(channelIDs) == 0 {
+		return nil, nil
+	}
+
+	searchFilter := filter{Must: []condition{matchAny("channel_id", channelIDs)}}
+	if len(opts.ExcludedTeamIDs) > 0 {
+		searchFilter.MustNot = append(searchFilter.MustNot, matchAny("team_id", opts.ExcludedTeamIDs))
+	}
+	if opts.TeamID != "" {
+		searchFilter.Must = append(searchFilter.Must, matchValue("team_id", opts.TeamID))
+	}
+	if len(opts.FromUserIDs) > 0 {
+		searchFilter.Must = append(searchFilter.Must, matchAny("user_id", opts.FromUserIDs))
+	}
+	if opts.CreatedAfter != 0 || opts.CreatedBefore != 0 {
+		createdRange := map[string]int64{}
+		if opts.CreatedAfter != 0 {
+			createdRange["gt"] = opts.CreatedAfter
+		}
+		if opts.CreatedBefore != 0 {
+			createdRange["lt"] = opts.CreatedBefore
+		}
+		searchFilter.Must = append(searchFilter.Must, condition{Key: "created_at", Range: createdRange})
+	}
+	if opts.Corpus != "" {
+		searchFilter.Must = append(searchFilter.Must, matchValue("corpus", opts.Corpus))
+	}
+
+	limit := maxSearchLimit
+	if opts.Limit > 0 && opts.Limit < maxSearchLimit {
+		limit = opts.Limit
+	}
+
+	request := map[string]any{
+		"vector":       embedding,
+		"filter":       searchFilter,
+		"limit":        limit,
+		"with_payload": true,
+	}
+	if opts.MinScore > 0 {
+		request["score_threshold"] = opts.MinScore
+	}
+
+	var points []scoredPoint
+	if err := q.do(ctx, http.MethodPost, q.collectionPath("/points/search"), request, &points); err != nil {
+		return nil, fmt.Errorf("failed to search points: %w", err)
+	}
+
+	results := make([]embeddings.SearchResult, 0, len(points))
+	for _, p := range points {
+		doc := embeddings.PostDocument{
+			PostID:     p.Payload.PostID,
+			CreateAt:   p.Payload.CreatedAt,
+			TeamID:     p.Payload.TeamID,
+			ChannelID:  p.Payload.ChannelID,
+			UserID:     p.Payload.UserID,
+			Content:    p.Payload.Content,
+			SourceType: p.Payload.SourceType,
+			Meeting:    p.Payload.Meeting,
+			File:       p.Payload.File,
+			ChunkInfo: chunking.ChunkInfo{
+				IsChunk: p.Payload.IsChunk,
+			},
+		}
+		if p.Payload.IsChunk {
+			doc.ChunkIndex = p.Payload.ChunkIndex
+			doc.TotalChunks = p.Payload.TotalChunks
+		}
+		results = append(results, embeddings.SearchResult{
+			Document: doc,
+			Score:    p.Score,
+		})
+	}
+	return results, nil
+}
+
+func (q *Qdrant) Delete(ctx context.Context, postIDs []string) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+	err := q.deletePoints(ctx, filter{Must: []condition{
+		matchAny("post_id", postIDs),
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to delete points: %w", err)
+	}
+	return nil
+}
+
+func (q *Qdrant) DeleteRebuilt(ctx context.Context, postIDs []string) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+	err := q.deletePoints(ctx, filter{Must: []condition{
+		matchAny("post_id", postIDs),
+		matchAny("corpus", rebuiltCorpora),
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to delete points: %w", err)
+	}
+	return nil
+}
+
+func (q *Qdrant) Clear(ctx context.Context) error {
+	// Documents from other sources can't be rebuilt by reindexing the posts, so they are kept
+	err := q.deletePoints(ctx, filter{Must: []condition{
+		matchAny("corpus", rebuiltCorpora),
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to clear points: %w", err)
+	}
+	return nil
+}
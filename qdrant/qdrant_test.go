@@ -0,0 +1,306 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-ai/chunking"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+)
+
+type recordedRequest struct {
+	Method string
+	Path   string
+	Body   map[string]any
+}
+
+// fakeQdrant records the requests it receives, and answers searches with the points it is given
+type fakeQdrant struct {
+	mu         sync.Mutex
+	requests   []recordedRequest
+	dimensions int // Dimensions of the existing collection, none exists when 0
+	points     string
+}
+
+func (f *fakeQdrant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("api-key") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"status":{"error":"missing api key"}}`))
+		return
+	}
+
+	request := recordedRequest{Method: r.Method, Path: r.URL.Path}
+	_ = json.NewDecoder(r.Body).Decode(&request.Body)
+	f.requests = append(f.requests, request)
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/collections/posts":
+		if f.dimensions == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":{"error":"Collection posts doesn't exist"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":{"config":{"params":{"vectors":{"size":` + strconv.Itoa(f.dimensions) + `,"distance":"Cosine"}}}},"status":"ok"}`))
+	case r.URL.Path == "/collections/posts/points/search":
+		_, _ = w.Write([]byte(`{"result":` + f.points + `,"status":"ok"}`))
+	default:
+		_, _ = w.Write([]byte(`{"result":true,"status":"ok"}`))
+	}
+}
+
+func (f *fakeQdrant) takeRequests() []recordedRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	requests := f.requests
+	f.requests = nil
+	return requests
+}
+
+type fakeMembership map[string][]string
+
+func (m fakeMembership) ChannelIDsForUser(_ context.Context, userID string) ([]string, error) {
+	return m[userID], nil
+}
+
+func newTestQdrant(t *testing.T, fake *fakeQdrant) *Qdrant {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := New(context.Background(), server.Client(), Config{
+		URL:        server.URL + "/",
+		APIKey:     "secret",
+		Collection: "posts",
+		Dimensions: 3,
+		BatchSize:  2,
+	}, fakeMembership{"user1": {"channel1", "channel2"}})
+	require.NoError(t, err)
+	fake.takeRequests()
+	return store
+}
+
+func TestNew(t *testing.T) {
+	t.Run("creates the collection and its indexes", func(t *testing.T) {
+		fake := &fakeQdrant{}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		_, err := New(context.Background(), server.Client(), Config{URL: server.URL, APIKey: "secret", Collection: "posts", Dimensions: 3}, fakeMembership{})
+		require.NoError(t, err)
+
+		requests := fake.takeRequests()
+		require.Len(t, requests, 2+len(indexedFields))
+		assert.Equal(t, http.MethodPut, requests[1].Method)
+		assert.Equal(t, map[string]any{"size": float64(3), "distance": "Cosine"}, requests[1].Body["vectors"])
+		for _, request := range requests[2:] {
+			assert.Equal(t, "/collections/posts/index", request.Path)
+			assert.Contains(t, indexedFields, request.Body["field_name"])
+		}
+	})
+
+	t.Run("refuses a collection with other dimensions", func(t *testing.T) {
+		fake := &fakeQdrant{dimensions: 1536}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		_, err := New(context.Background(), server.Client(), Config{URL: server.URL, APIKey: "secret", Collection: "posts", Dimensions: 3}, fakeMembership{})
+		assert.ErrorContains(t, err, "has 1536 dimensions instead of 3")
+	})
+
+	t.Run("reports errors of qdrant", func(t *testing.T) {
+		fake := &fakeQdrant{}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		_, err := New(context.Background(), server.Client(), Config{URL: server.URL, Collection: "posts", Dimensions: 3}, fakeMembership{})
+		assert.ErrorContains(t, err, "missing api key")
+	})
+}
+
+func TestStore(t *testing.T) {
+	fake := &fakeQdrant{dimensions: 3}
+	store := newTestQdrant(t, fake)
+
+	docs := []embeddings.PostDocument{
+		{PostID: "post1", TeamID: "team1", ChannelID: "channel1", UserID: "user1", Content: "first", CreateAt: 100},
+		{PostID: "post2", TeamID: "team1", ChannelID: "channel1", UserID: "user1", Content: "chunk", CreateAt: 200, ChunkInfo: chunking.ChunkInfo{IsChunk: true, ChunkIndex: 1, TotalChunks: 2}},
+		{PostID: "post3", TeamID: "team1", ChannelID: "channel2", UserID: "user2", Content: "summary", CreateAt: 300, SourceType: embeddings.SourceTypeMeetingSummary, Meeting: &embeddings.MeetingMetadata{Participants: []string{"alice"}}},
+	}
+	vectors := [][]float32{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	require.NoError(t, store.Store(context.Background(), docs, vectors))
+
+	requests := fake.takeRequests()
+	require.Len(t, requests, 3)
+
+	// The previous version of the meeting summary is replaced
+	assert.Equal(t, "/collections/posts/points/delete", requests[0].Path)
+	assert.Equal(t, map[string]any{"must": []any{
+		map[string]any{"key": "post_id", "match": map[string]any{"value": "post3"}},
+		map[string]any{"key": "source_type", "match": map[string]any{"value": embeddings.SourceTypeMeetingSummary}},
+	}}, requests[0].Body["filter"])
+
+	// The points are upserted in batches
+	assert.Len(t, requests[1].Body["points"], 2)
+	assert.Len(t, requests[2].Body["points"], 1)
+
+	summary := requests[2].Body["points"].([]any)[0].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"post_id":     "post3",
+		"team_id":     "team1",
+		"channel_id":  "channel2",
+		"user_id":     "user2",
+		"content":     "summary",
+		"created_at":  float64(300),
+		"is_chunk":    false,
+		"source_type": embeddings.SourceTypeMeetingSummary,
+		"corpus":      embeddings.CorpusMeetings,
+		"meeting":     map[string]any{"participants": []any{"alice"}},
+	}, summary["payload"])
+
+	// Storing the same documents again updates the same points
+	require.NoError(t, store.Store(context.Background(), docs[:1], vectors[:1]))
+	assert.Equal(t, requests[1].Body["points"].([]any)[0].(map[string]any)["id"], fake.takeRequests()[0].Body["points"].([]any)[0].(map[string]any)["id"])
+
+	assert.Error(t, store.Store(context.Background(), docs, vectors[:1]))
+}
+
+func TestSearch(t *testing.T) {
+	fake := &fakeQdrant{
+		dimensions: 3,
+		points:     `[{"id":"a","score":0.9,"payload":{"post_id":"post2","team_id":"team1","channel_id":"channel1","user_id":"user1","content":"chunk","created_at":200,"is_chunk":true,"chunk_index":1,"total_chunks":2,"source_type":"","corpus":"posts"}}]`,
+	}
+	store := newTestQdrant(t, fake)
+
+	results, err := store.Search(context.Background(), []float32{0, 1, 0}, embeddings.SearchOptions{
+		UserID:        "user1",
+		TeamID:        "team1",
+		Limit:         5,
+		MinScore:      0.5,
+		CreatedAfter:  100,
+		CreatedBefore: 300,
+		Corpus:        embeddings.CorpusPosts,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []embeddings.SearchResult{{
+		Document: embeddings.PostDocument{
+			PostID:    "post2",
+			TeamID:    "team1",
+			ChannelID: "channel1",
+			UserID:    "user1",
+			Content:   "chunk",
+			CreateAt:  200,
+			ChunkInfo: chunking.ChunkInfo{IsChunk: true, ChunkIndex: 1, TotalChunks: 2},
+		},
+		Score: 0.9,
+	}}, results)
+
+	requests := fake.takeRequests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, float64(5), requests[0].Body["limit"])
+	assert.Equal(t, 0.5, requests[0].Body["score_threshold"])
+	assert.Equal(t, map[string]any{"must": []any{
+		map[string]any{"key": "channel_id", "match": map[string]any{"any": []any{"channel1", "channel2"}}},
+		map[string]any{"key": "team_id", "match": map[string]any{"value": "team1"}},
+		map[string]any{"key": "created_at", "range": map[string]any{"gt": float64(100), "lt": float64(300)}},
+		map[string]any{"key": "corpus", "match": map[string]any{"value": "posts"}},
+	}}, requests[0].Body["filter"])
+
+	t.Run("filters on the requested channels and authors", func(t *testing.T) {
+		_, err := store.Search(context.Background(), []float32{0, 1, 0}, embeddings.SearchOptions{
+			UserID:      "user1",
+			ChannelID:   "channel2",
+			ChannelIDs:  []string{"channel2", "channel3"},
+			FromUserIDs: []string{"user1", "user2"},
+		})
+		require.NoError(t, err)
+
+		requests := fake.takeRequests()
+		require.Len(t, requests, 1)
+		assert.Equal(t, map[string]any{"must": []any{
+			map[string]any{"key": "channel_id", "match": map[string]any{"any": []any{"channel2"}}},
+			map[string]any{"key": "user_id", "match": map[string]any{"any": []any{"user1", "user2"}}},
+		}}, requests[0].Body["filter"])
+	})
+
+	t.Run("leaves out the excluded teams and channels", func(t *testing.T) {
+		_, err := store.Search(context.Background(), []float32{0, 1, 0}, embeddings.SearchOptions{
+			UserID:             "user1",
+			ExcludedTeamIDs:    []string{"team2"},
+			ExcludedChannelIDs: []string{"channel2"},
+		})
+		require.NoError(t, err)
+
+		requests := fake.takeRequests()
+		require.Len(t, requests, 1)
+		assert.Equal(t, map[string]any{
+			"must": []any{
+				map[string]any{"key": "channel_id", "match": map[string]any{"any": []any{"channel1"}}},
+			},
+			"must_not": []any{
+				map[string]any{"key": "team_id", "match": map[string]any{"any": []any{"team2"}}},
+			},
+		}, requests[0].Body["filter"])
+	})
+
+	t.Run("searches nothing when the channel isn't among the requested channels", func(t *testing.T) {
+		results, err := store.Search(context.Background(), []float32{0, 1, 0}, embeddings.SearchOptions{
+			UserID:     "user1",
+			ChannelID:  "channel1",
+			ChannelIDs: []string{"channel2"},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		assert.Empty(t, fake.takeRequests())
+	})
+
+	t.Run("only searches the channels of the user", func(t *testing.T) {
+		results, err := store.Search(context.Background(), []float32{0, 1, 0}, embeddings.SearchOptions{UserID: "user1", ChannelID: "channel3"})
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		results, err = store.Search(context.Background(), []float32{0, 1, 0}, embeddings.SearchOptions{UserID: "user2"})
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		assert.Empty(t, fake.takeRequests())
+
+		_, err = store.Search(context.Background(), []float32{0, 1, 0}, embeddings.SearchOptions{})
+		assert.Error(t, err)
+	})
+}
+
+func TestDeleteAndClear(t *testing.T) {
+	fake := &fakeQdrant{dimensions: 3}
+	store := newTestQdrant(t, fake)
+
+	require.NoError(t, store.Delete(context.Background(), []string{"post1", "post2"}))
+	require.NoError(t, store.Delete(context.Background(), nil))
+	require.NoError(t, store.DeleteRebuilt(context.Background(), []string{"post1"}))
+	require.NoError(t, store.DeleteRebuilt(context.Background(), nil))
+	require.NoError(t, store.Clear(context.Background()))
+
+	requests := fake.takeRequests()
+	require.Len(t, requests, 3)
+	assert.Equal(t, map[string]any{"must": []any{
+		map[string]any{"key": "post_id", "match": map[string]any{"any": []any{"post1", "post2"}}},
+	}}, requests[0].Body["filter"], "deleted posts lose the documents of every corpus")
+	assert.Equal(t, map[string]any{"must": []any{
+		map[string]any{"key": "post_id", "match": map[string]any{"any": []any{"post1"}}},
+		map[string]any{"key": "corpus", "match": map[string]any{"any": []any{"posts", "files"}}},
+	}}, requests[1].Body["filter"])
+	assert.Equal(t, map[string]any{"must": []any{
+		map[string]any{"key": "corpus", "match": map[string]any{"any": []any{"posts", "files"}}},
+	}}, requests[2].Body["filter"])
+}
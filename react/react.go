@@ -51,10 +51,13 @@ func (r *React) Resolve(message string, context *llm.Context) (string, error) {
 			},
 		},
 		Context: context,
+		// Only the emoji name is needed, stop before any explanation on the next lines. A space
+		// isn't a stop sequence since models often start their answer with one.
+		StopSequences: []string{"\n"},
 	}
 
 	// Get emoji from LLM
-	emojiName, err := r.llm.ChatCompletionNoStream(completionRequest, llm.WithMaxGeneratedTokens(25))
+	emojiName, err := r.llm.ChatCompletionNoStream(completionRequest, llm.WithMaxGeneratedTokens(25), llm.WithFeature(llm.FeatureReactions))
 	if err != nil {
 		return "", fmt.Errorf("failed to get emoji from LLM: %w", err)
 	}
@@ -0,0 +1,226 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package regions provides a language model routing requests between the regional endpoints of a
+// service, failing over to another region when one can't serve a request.
+package regions
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	anthropicSDK "github.com/anthropics/anthropic-sdk-go"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	openaiClient "github.com/sashabaranov/go-openai"
+)
+
+const (
+	// failedRegionCooldown is how long a region that failed is only tried after the others
+	failedRegionCooldown = time.Minute
+
+	// latencyWeight is the weight of the latest request in the average latency of a region
+	latencyWeight = 0.2
+)
+
+// ErrNoRegion is returned when no region is configured.
+var ErrNoRegion = errors.New("no region is configured")
+
+// Region is a regional endpoint of a service.
+type Region struct {
+	Name  string
+	Model llm.LanguageModel
+}
+
+type region struct {
+	Region
+
+	mu       sync.Mutex
+	failedAt time.Time
+
+	// latency is the average time to the first event of a response, zero until measured
+	latency time.Duration
+}
+
+// Router is a language model that sends each request to the first of its regions the routing picks,
+// and to the next ones when it fails before responding.
+type Router struct {
+	routing string
+	regions []*region
+}
+
+// New creates a router between the regions with the routing, llm.RegionRoutingPriority when empty.
+// The first region is the service's own endpoint.
+func New(routing string, regions []Region) *Router {
+	router := &Router{
+		routing: routing,
+	}
+	for _, r := range regions {
+		router.regions = append(router.regions, &region{Region: r})
+	}
+	return router
+}
+
+func (r *region) hasFailed(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return now.Sub(r.failedAt) < failedRegionCooldown
+}
+
+func (r *region) markFailed(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failedAt = now
+}
+
+func (r *region) averageLatency() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.latency
+}
+
+func (r *region) recordLatency(latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.latency == 0 {
+		r.latency = latency
+		return
+	}
+	r.latency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(r.latency))
+}
+
+// candidates returns the regions in the order a request should try them: the regions that didn't fail
+// recently, in the configured order or fastest first, then the ones that did in case they recovered.
+func (rt *Router) candidates(now time.Time) []*region {
+	var available, failed []*region
+	for _, r := range rt.regions {
+		if r.hasFailed(now) {
+			failed = append(failed, r)
+		} else {
+			available = append(available, r)
+		}
+	}
+
+	// Regions without requests yet come first so their latency gets measured
+	if rt.routing == llm.RegionRoutingLatency {
+		sort.SliceStable(available, func(i, j int) bool {
+			return available[i].averageLatency() < available[j].averageLatency()
+		})
+	}
+
+	return append(available, failed...)
+}
+
+// isRegionFailure returns true if an error means the region couldn't serve the request, because it is
+// down, overloaded or out of quota, rather than the request being rejected, so another region may serve it.
+func isRegionFailure(err error) bool {
+	status := 0
+	var openaiAPIErr *openaiClient.APIError
+	var openaiRequestErr *openaiClient.RequestError
+	var anthropicErr *anthropicSDK.Error
+	switch {
+	case errors.As(err, &openaiAPIErr):
+		status = openaiAPIErr.HTTPStatusCode
+	case errors.As(err, &openaiRequestErr):
+		status = openaiRequestErr.HTTPStatusCode
+	case errors.As(err, &anthropicErr):
+		status = anthropicErr.StatusCode
+	default:
+		return true
+	}
+	return status == 0 || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// ChatCompletion streams the completion from the first region the routing picks. When a region fails
+// before streaming anything the request is sent to the next one and the region is tried last for
+// failedRegionCooldown. Requests the region rejected aren't sent to the others, which would reject them too.
+func (rt *Router) ChatCompletion(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+	if len(rt.regions) == 0 {
+		return nil, ErrNoRegion
+	}
+	candidates := rt.candidates(time.Now())
+
+	output := make(chan llm.TextStreamEvent)
+	go func() {
+		defer close(output)
+
+		var errs []error
+		for _, r := range candidates {
+			failed, err := streamFromRegion(r, request, opts, output)
+			if !failed {
+				return
+			}
+			errs = append(errs, fmt.Errorf("region %s: %w", r.Name, err))
+			if !isRegionFailure(err) {
+				break
+			}
+			r.markFailed(time.Now())
+		}
+
+		output <- llm.TextStreamEvent{
+			Type:  llm.EventTypeError,
+			Value: fmt.Errorf("no region served the request: %w", errors.Join(errs...)),
+		}
+	}()
+
+	return &llm.TextStreamResult{Stream: output}, nil
+}
+
+// streamFromRegion forwards the completion of a region to the output. It returns true with the error
+// if the region failed before anything was forwarded, so the request can be sent to another region.
+func streamFromRegion(r *region, request llm.CompletionRequest, opts []llm.LanguageModelOption, output chan<- llm.TextStreamEvent) (bool, error) {
+	started := time.Now()
+	result, err := r.Model.ChatCompletion(request, opts...)
+	if err != nil {
+		return true, err
+	}
+
+	forwarded := false
+	for event := range result.Stream {
+		if !forwarded {
+			if event.Type == llm.EventTypeError {
+				// Drain the rest of the stream so the region's goroutine can finish
+				go func() {
+					for range result.Stream {
+					}
+				}()
+				err, _ := event.Value.(error)
+				if err == nil {
+					err = fmt.Errorf("%v", event.Value)
+				}
+				return true, err
+			}
+			r.recordLatency(time.Since(started))
+		}
+		forwarded = true
+		output <- event
+	}
+
+	return false, nil
+}
+
+func (rt *Router) ChatCompletionNoStream(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
+	result, err := rt.ChatCompletion(request, opts...)
+	if err != nil {
+		return "", err
+	}
+	return result.ReadAll()
+}
+
+// CountTokens and InputTokenLimit are the same in every region, which serve the same models.
+func (rt *Router) CountTokens(text string) int {
+	if len(rt.regions) == 0 {
+		return 0
+	}
+	return rt.regions[0].Model.CountTokens(text)
+}
+
+func (rt *Router) InputTokenLimit() int {
+	if len(rt.regions) == 0 {
+		return 0
+	}
+	return rt.regions[0].Model.InputTokenLimit()
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package regions
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	openaiClient "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// regionModel answers with text, or fails with err before streaming anything.
+type regionModel struct {
+	text  string
+	err   error
+	calls int
+}
+
+func (m *regionModel) ChatCompletion(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+	m.calls++
+	output := make(chan llm.TextStreamEvent)
+	go func() {
+		defer close(output)
+		if m.err != nil {
+			output <- llm.TextStreamEvent{Type: llm.EventTypeError, Value: m.err}
+			return
+		}
+		output <- llm.TextStreamEvent{Type: llm.EventTypeText, Value: m.text}
+		output <- llm.TextStreamEvent{Type: llm.EventTypeEnd}
+	}()
+	return &llm.TextStreamResult{Stream: output}, nil
+}
+
+func (m *regionModel) ChatCompletionNoStream(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
+	result, err := m.ChatCompletion(request, opts...)
+	if err != nil {
+		return "", err
+	}
+	return result.ReadAll()
+}
+
+func (m *regionModel) CountTokens(text string) int {
+	return len(text)
+}
+
+func (m *regionModel) InputTokenLimit() int {
+	return 100
+}
+
+func TestCandidates(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		routing  string
+		latency  []time.Duration
+		failedAt []time.Time
+		expected []string
+	}{
+		{
+			name:     "priority keeps the configured order",
+			routing:  llm.RegionRoutingPriority,
+			latency:  []time.Duration{3 * time.Second, time.Second, 2 * time.Second},
+			failedAt: []time.Time{{}, {}, {}},
+			expected: []string{"eu", "us", "asia"},
+		},
+		{
+			name:     "latency puts the fastest first",
+			routing:  llm.RegionRoutingLatency,
+			latency:  []time.Duration{3 * time.Second, time.Second, 2 * time.Second},
+			failedAt: []time.Time{{}, {}, {}},
+			expected: []string{"us", "asia", "eu"},
+		},
+		{
+			name:     "latency tries unmeasured regions first",
+			routing:  llm.RegionRoutingLatency,
+			latency:  []time.Duration{time.Second, 0, 2 * time.Second},
+			failedAt: []time.Time{{}, {}, {}},
+			expected: []string{"us", "eu", "asia"},
+		},
+		{
+			name:     "failed regions last",
+			routing:  llm.RegionRoutingPriority,
+			latency:  []time.Duration{0, 0, 0},
+			failedAt: []time.Time{now.Add(-time.Second), {}, {}},
+			expected: []string{"us", "asia", "eu"},
+		},
+		{
+			name:     "failed regions tried again after the cooldown",
+			routing:  llm.RegionRoutingPriority,
+			latency:  []time.Duration{0, 0, 0},
+			failedAt: []time.Time{now.Add(-2 * failedRegionCooldown), {}, {}},
+			expected: []string{"eu", "us", "asia"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := New(tc.routing, []Region{
+				{Name: "eu", Model: &regionModel{}},
+				{Name: "us", Model: &regionModel{}},
+				{Name: "asia", Model: &regionModel{}},
+			})
+			for i, r := range router.regions {
+				r.latency = tc.latency[i]
+				r.failedAt = tc.failedAt[i]
+			}
+
+			var names []string
+			for _, r := range router.candidates(now) {
+				names = append(names, r.Name)
+			}
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestChatCompletionFailover(t *testing.T) {
+	unavailable := &openaiClient.APIError{HTTPStatusCode: http.StatusServiceUnavailable, Message: "unavailable"}
+	rejected := &openaiClient.APIError{HTTPStatusCode: http.StatusBadRequest, Message: "invalid request"}
+
+	tests := []struct {
+		name          string
+		models        []*regionModel
+		expected      string
+		wantErr       bool
+		expectedCalls []int
+		failed        []bool
+	}{
+		{
+			name:          "first region serves",
+			models:        []*regionModel{{text: "eu"}, {text: "us"}},
+			expected:      "eu",
+			expectedCalls: []int{1, 0},
+			failed:        []bool{false, false},
+		},
+		{
+			name:          "fails over to the next region",
+			models:        []*regionModel{{err: unavailable}, {text: "us"}},
+			expected:      "us",
+			expectedCalls: []int{1, 1},
+			failed:        []bool{true, false},
+		},
+		{
+			name:          "rejected requests aren't sent to other regions",
+			models:        []*regionModel{{err: rejected}, {text: "us"}},
+			wantErr:       true,
+			expectedCalls: []int{1, 0},
+			failed:        []bool{false, false},
+		},
+		{
+			name:          "all regions fail",
+			models:        []*regionModel{{err: unavailable}, {err: errors.New("connection refused")}},
+			wantErr:       true,
+			expectedCalls: []int{1, 1},
+			failed:        []bool{true, true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := New(llm.RegionRoutingPriority, []Region{
+				{Name: "eu", Model: tc.models[0]},
+				{Name: "us", Model: tc.models[1]},
+			})
+
+			text, err := router.ChatCompletionNoStream(llm.CompletionRequest{})
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, text)
+			}
+
+			now := time.Now()
+			for i, model := range tc.models {
+				assert.Equal(t, tc.expectedCalls[i], model.calls)
+				assert.Equal(t, tc.failed[i], router.regions[i].hasFailed(now))
+			}
+		})
+	}
+}
+
+func TestNoRegion(t *testing.T) {
+	_, err := New("", nil).ChatCompletion(llm.CompletionRequest{})
+	assert.ErrorIs(t, err, ErrNoRegion)
+}
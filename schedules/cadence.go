@@ -0,0 +1,183 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package schedules
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCadence is returned when parsing a cadence that isn't a valid cron expression.
+var ErrInvalidCadence = errors.New("invalid cadence")
+
+// maxSearch bounds the search for the next run, far enough for cadences such as February 29th
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// cadenceMacros are the shorthands accepted in place of the five fields
+var cadenceMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Cadence is when a prompt runs, a cron expression of five fields: minute, hour, day of the month, month
+// and day of the week. Prompts run at most once an hour, so the minute is a single number.
+type Cadence struct {
+	minute     int
+	hours      []bool
+	daysOfMon  []bool
+	months     []bool
+	daysOfWeek []bool
+
+	// As in cron, when both days are restricted a day matching either runs the prompt
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+type fieldRange struct {
+	name     string
+	min, max int
+}
+
+var (
+	hourRange      = fieldRange{name: "hour", min: 0, max: 23}
+	dayOfMonRange  = fieldRange{name: "day of the month", min: 1, max: 31}
+	monthRange     = fieldRange{name: "month", min: 1, max: 12}
+	dayOfWeekRange = fieldRange{name: "day of the week", min: 0, max: 7}
+)
+
+// ParseCadence parses a cron expression such as "0 9 * * 1" for every Monday at 9:00.
+func ParseCadence(spec string) (*Cadence, error) {
+	spec = strings.TrimSpace(spec)
+	if macro, ok := cadenceMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, minute hour day-of-month month day-of-week", ErrInvalidCadence)
+	}
+
+	minute, err := strconv.Atoi(fields[0])
+	if err != nil || minute < 0 || minute > 59 {
+		return nil, fmt.Errorf("%w: the minute must be a single number from 0 to 59", ErrInvalidCadence)
+	}
+
+	cadence := &Cadence{
+		minute:        minute,
+		anyDayOfMonth: fields[2] == "*",
+		anyDayOfWeek:  fields[4] == "*",
+	}
+	if cadence.hours, err = parseField(fields[1], hourRange); err != nil {
+		return nil, err
+	}
+	if cadence.daysOfMon, err = parseField(fields[2], dayOfMonRange); err != nil {
+		return nil, err
+	}
+	if cadence.months, err = parseField(fields[3], monthRange); err != nil {
+		return nil, err
+	}
+	if cadence.daysOfWeek, err = parseField(fields[4], dayOfWeekRange); err != nil {
+		return nil, err
+	}
+	// Sunday is both 0 and 7
+	if cadence.daysOfWeek[7] {
+		cadence.daysOfWeek[0] = true
+	}
+
+	return cadence, nil
+}
+
+// parseField parses a comma separated list of values, ranges like 1-5 and steps like */2 or 8-18/2.
+func parseField(field string, r fieldRange) ([]bool, error) {
+	values := make([]bool, r.max+1)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if before, after, found := strings.Cut(part, "/"); found {
+			var err error
+			step, err = strconv.Atoi(after)
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("%w: invalid step %q in the %s", ErrInvalidCadence, part, r.name)
+			}
+			rangePart = before
+		}
+
+		start, end := r.min, r.max
+		if rangePart != "*" {
+			before, after, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(before); err != nil {
+				return nil, fmt.Errorf("%w: invalid %s %q", ErrInvalidCadence, r.name, part)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(after); err != nil {
+					return nil, fmt.Errorf("%w: invalid %s %q", ErrInvalidCadence, r.name, part)
+				}
+			} else if step > 1 {
+				// A start with a step runs until the end of the range, as in 5/15
+				end = r.max
+			}
+		}
+		if start < r.min || end > r.max || start > end {
+			return nil, fmt.Errorf("%w: the %s must be from %d to %d", ErrInvalidCadence, r.name, r.min, r.max)
+		}
+
+		for value := start; value <= end; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
+
+// matchesDay returns true if the prompt runs on the day of t.
+func (c *Cadence) matchesDay(t time.Time) bool {
+	if !c.months[int(t.Month())] {
+		return false
+	}
+	dayOfMonth := c.daysOfMon[t.Day()]
+	dayOfWeek := c.daysOfWeek[int(t.Weekday())]
+	switch {
+	case c.anyDayOfMonth && c.anyDayOfWeek:
+		return true
+	case c.anyDayOfMonth:
+		return dayOfWeek
+	case c.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
+
+// Next returns the first time after the time given the prompt runs, in the location of the time given.
+// It returns the zero time when the cadence never runs, such as on February 30th.
+func (c *Cadence) Next(after time.Time) time.Time {
+	loc := after.Location()
+	limit := after.Add(maxSearch)
+
+	day := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, loc)
+	for !day.After(limit) {
+		if c.matchesDay(day) {
+			for hour := 0; hour < 24; hour++ {
+				if !c.hours[hour] {
+					continue
+				}
+				run := time.Date(day.Year(), day.Month(), day.Day(), hour, c.minute, 0, 0, loc)
+				// Times skipped by daylight saving changes are moved by time.Date, keep only the real ones
+				if run.Hour() != hour {
+					continue
+				}
+				if run.After(after) {
+					return run
+				}
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package schedules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCadence(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{name: "every Monday at 9", spec: "0 9 * * 1"},
+		{name: "weekdays every two hours", spec: "30 8-18/2 * * 1-5"},
+		{name: "lists", spec: "15 9,17 1,15 * *"},
+		{name: "Sunday as 7", spec: "0 9 * * 7"},
+		{name: "macro", spec: "@weekly"},
+		{name: "too few fields", spec: "0 9 * *", wantErr: true},
+		{name: "every minute", spec: "* * * * *", wantErr: true},
+		{name: "minute list", spec: "0,30 * * * *", wantErr: true},
+		{name: "hour out of range", spec: "0 24 * * *", wantErr: true},
+		{name: "month out of range", spec: "0 9 * 13 *", wantErr: true},
+		{name: "reversed range", spec: "0 9 * * 5-1", wantErr: true},
+		{name: "zero step", spec: "0 */0 * * *", wantErr: true},
+		{name: "not a number", spec: "0 9 * * mon", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseCadence(tc.spec)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidCadence)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCadenceNext(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	// Wednesday
+	wednesday := time.Date(2026, time.October, 14, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		spec     string
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "next Monday",
+			spec:     "0 9 * * 1",
+			after:    wednesday,
+			expected: time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "later the same day",
+			spec:     "30 8-18/2 * * *",
+			after:    wednesday,
+			expected: time.Date(2026, time.October, 14, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "not at the time given",
+			spec:     "0 10 * * *",
+			after:    wednesday,
+			expected: time.Date(2026, time.October, 15, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day of the month or of the week",
+			spec:     "0 9 1 * 5",
+			after:    wednesday,
+			expected: time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "next month",
+			spec:     "@monthly",
+			after:    wednesday,
+			expected: time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "leap day",
+			spec:     "0 9 29 2 *",
+			after:    wednesday,
+			expected: time.Date(2028, time.February, 29, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "in the location given",
+			spec:     "0 9 * * 1",
+			after:    wednesday.In(paris),
+			expected: time.Date(2026, time.October, 19, 9, 0, 0, 0, paris),
+		},
+		{
+			name:     "skipped by daylight saving time",
+			spec:     "30 2 * * *",
+			after:    time.Date(2027, time.March, 28, 0, 0, 0, 0, paris),
+			expected: time.Date(2027, time.March, 29, 2, 30, 0, 0, paris),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cadence, err := ParseCadence(tc.spec)
+			require.NoError(t, err)
+			assert.True(t, tc.expected.Equal(cadence.Next(tc.after)), "expected %s, got %s", tc.expected, cadence.Next(tc.after))
+		})
+	}
+
+	never, err := ParseCadence("0 9 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.Next(wednesday).IsZero())
+}
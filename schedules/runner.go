@@ -0,0 +1,76 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package schedules
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+const (
+	// RunInterval is how often due schedules are looked for, prompts run within a minute of their time
+	RunInterval = time.Minute
+
+	// maxRunsPerInterval spreads the prompts due at the same time, such as Monday 9:00, over a few minutes
+	maxRunsPerInterval = 50
+)
+
+// PromptRunner sends the response of a bot to a prompt to the user in a direct message. The response is
+// streamed, so it returns once the request is started.
+type PromptRunner interface {
+	RunScheduledPrompt(userID, botUsername, prompt, title string) error
+}
+
+// Runner runs the due schedules, on one server of the cluster at a time.
+type Runner struct {
+	store  *Store
+	prompt PromptRunner
+	log    pluginapi.LogService
+}
+
+// NewRunner creates a runner of the schedules of the store.
+func NewRunner(store *Store, prompt PromptRunner, log pluginapi.LogService) *Runner {
+	return &Runner{
+		store:  store,
+		prompt: prompt,
+		log:    log,
+	}
+}
+
+// RunDue runs the schedules due at the time given. Schedules missed while the plugin was stopped run once,
+// not once for each time they were missed.
+func (r *Runner) RunDue(now time.Time) {
+	due, err := r.store.due(now, maxRunsPerInterval)
+	if err != nil {
+		r.log.Error("Failed to get due scheduled prompts", "error", err)
+		return
+	}
+
+	for _, schedule := range due {
+		next, err := schedule.next(now)
+		if err != nil {
+			// The time zone database of the server changed, keep trying daily rather than every minute
+			r.log.Warn("Failed to get the next run of a scheduled prompt", "schedule_id", schedule.ID, "error", err)
+			next = now.Add(24 * time.Hour)
+		}
+
+		claimed, err := r.store.claim(schedule, now, next.UnixMilli())
+		if err != nil {
+			r.log.Error("Failed to claim scheduled prompt", "schedule_id", schedule.ID, "error", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		runErr := r.prompt.RunScheduledPrompt(schedule.UserID, schedule.BotUsername, schedule.Prompt, schedule.Title())
+		if runErr != nil {
+			r.log.Warn("Failed to run scheduled prompt", "schedule_id", schedule.ID, "user_id", schedule.UserID, "error", runErr)
+		}
+		if err := r.store.saveResult(schedule.ID, runErr); err != nil {
+			r.log.Error("Failed to save scheduled prompt result", "schedule_id", schedule.ID, "error", err)
+		}
+	}
+}
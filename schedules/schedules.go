@@ -0,0 +1,121 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package schedules runs prompts users scheduled on a recurring cadence, such as summarizing a channel
+// every Monday morning, and sends the responses to them in a direct message from the bot they picked.
+package schedules
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxSchedulesPerUser keeps the requests made on behalf of a user in check
+	MaxSchedulesPerUser = 20
+
+	maxNameLength   = 64
+	maxPromptLength = 4000
+)
+
+var (
+	// ErrInvalidSchedule is returned when saving a schedule without a prompt, bot or valid cadence.
+	ErrInvalidSchedule = errors.New("invalid schedule")
+
+	// ErrTooManySchedules is returned when creating a schedule for a user with MaxSchedulesPerUser schedules.
+	ErrTooManySchedules = errors.New("too many schedules")
+
+	// ErrScheduleNotFound is returned when getting, updating or deleting a schedule that doesn't exist or
+	// belongs to another user.
+	ErrScheduleNotFound = errors.New("schedule not found")
+)
+
+// Schedule is a prompt run on a recurring cadence for a user, answered by a bot in a direct message.
+type Schedule struct {
+	ID          string `json:"id"`
+	UserID      string `json:"userId"`
+	BotUsername string `json:"botUsername"`
+	Name        string `json:"name"`
+	Prompt      string `json:"prompt"`
+
+	// Cadence is a cron expression evaluated in the time zone, an IANA name such as Europe/Paris
+	Cadence  string `json:"cadence"`
+	TimeZone string `json:"timeZone"`
+	Enabled  bool   `json:"enabled"`
+
+	CreateAt  int64 `json:"createAt"`
+	UpdateAt  int64 `json:"updateAt"`
+	NextRunAt int64 `json:"nextRunAt"`
+	LastRunAt int64 `json:"lastRunAt"`
+
+	// LastError is why the last run failed, empty when it succeeded
+	LastError string `json:"lastError"`
+}
+
+// normalize trims the fields, schedules without a time zone run in UTC.
+func (s Schedule) normalize() Schedule {
+	s.BotUsername = strings.TrimSpace(s.BotUsername)
+	s.Name = strings.TrimSpace(s.Name)
+	s.Prompt = strings.TrimSpace(s.Prompt)
+	s.Cadence = strings.TrimSpace(s.Cadence)
+	s.TimeZone = strings.TrimSpace(s.TimeZone)
+	if s.TimeZone == "" {
+		s.TimeZone = "UTC"
+	}
+	return s
+}
+
+// IsValid checks the schedule has a prompt within the length limits, a bot, and a cadence in a known time zone.
+func (s Schedule) IsValid() error {
+	if s.UserID == "" {
+		return fmt.Errorf("%w: user is required", ErrInvalidSchedule)
+	}
+	if s.BotUsername == "" {
+		return fmt.Errorf("%w: bot is required", ErrInvalidSchedule)
+	}
+	if utf8.RuneCountInString(s.Name) > maxNameLength {
+		return fmt.Errorf("%w: name is too long", ErrInvalidSchedule)
+	}
+	if s.Prompt == "" {
+		return fmt.Errorf("%w: prompt is required", ErrInvalidSchedule)
+	}
+	if utf8.RuneCountInString(s.Prompt) > maxPromptLength {
+		return fmt.Errorf("%w: prompt is too long", ErrInvalidSchedule)
+	}
+	if _, err := s.next(time.Now()); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+	}
+	return nil
+}
+
+// next returns when the schedule runs next after the time given.
+func (s Schedule) next(after time.Time) (time.Time, error) {
+	cadence, err := ParseCadence(s.Cadence)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown time zone %q", s.TimeZone)
+	}
+	next := cadence.Next(after.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: the cadence never runs", ErrInvalidCadence)
+	}
+	return next, nil
+}
+
+// Title is how the conversation with the responses of the schedule is named.
+func (s Schedule) Title() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	title := strings.Join(strings.Fields(s.Prompt), " ")
+	if utf8.RuneCountInString(title) > maxNameLength {
+		title = string([]rune(title)[:maxNameLength-1]) + "…"
+	}
+	return title
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package schedules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValid(t *testing.T) {
+	valid := Schedule{
+		UserID:      "user",
+		BotUsername: "ai",
+		Prompt:      "Summarize ~releases from last week",
+		Cadence:     "0 9 * * 1",
+		TimeZone:    "America/New_York",
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(s *Schedule)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(*Schedule) {}},
+		{name: "no bot", modify: func(s *Schedule) { s.BotUsername = "" }, wantErr: true},
+		{name: "no prompt", modify: func(s *Schedule) { s.Prompt = "" }, wantErr: true},
+		{name: "prompt too long", modify: func(s *Schedule) { s.Prompt = strings.Repeat("a", maxPromptLength+1) }, wantErr: true},
+		{name: "name too long", modify: func(s *Schedule) { s.Name = strings.Repeat("a", maxNameLength+1) }, wantErr: true},
+		{name: "invalid cadence", modify: func(s *Schedule) { s.Cadence = "every monday" }, wantErr: true},
+		{name: "cadence that never runs", modify: func(s *Schedule) { s.Cadence = "0 9 31 4 *" }, wantErr: true},
+		{name: "unknown time zone", modify: func(s *Schedule) { s.TimeZone = "Mars/Olympus_Mons" }, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule := valid
+			tc.modify(&schedule)
+			err := schedule.IsValid()
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSchedule)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	schedule := Schedule{Prompt: " Summarize \n", TimeZone: " "}.normalize()
+	assert.Equal(t, "Summarize", schedule.Prompt)
+	assert.Equal(t, "UTC", schedule.TimeZone)
+}
+
+func TestTitle(t *testing.T) {
+	assert.Equal(t, "Weekly releases", Schedule{Name: "Weekly releases", Prompt: "Summarize"}.Title())
+	assert.Equal(t, "Summarize ~releases", Schedule{Prompt: "Summarize\n~releases"}.Title())
+
+	long := Schedule{Prompt: strings.Repeat("a", 100)}.Title()
+	assert.Equal(t, maxNameLength, len([]rune(long)))
+	assert.True(t, strings.HasSuffix(long, "…"))
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package schedules
+
+import (
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Store keeps the schedules in the LLM_Schedules table.
+type Store struct {
+	db *mmapi.DBClient
+}
+
+// NewStore creates a schedule store.
+func NewStore(db *mmapi.DBClient) *Store {
+	return &Store{db: db}
+}
+
+var scheduleColumns = []string{
+	"ID", "UserID", "BotUsername", "Name", "Prompt", "Cadence", "TimeZone", "Enabled",
+	"CreateAt", "UpdateAt", "NextRunAt", "LastRunAt", "LastError",
+}
+
+// List returns the schedules of the user, in the order they were created.
+func (s *Store) List(userID string) ([]Schedule, error) {
+	schedules := []Schedule{}
+	if err := s.db.DoQuery(&schedules, s.db.Builder().
+		Select(scheduleColumns...).
+		From("LLM_Schedules").
+		Where(sq.Eq{"UserID": userID}).
+		OrderBy("CreateAt", "ID")); err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// Get returns the schedule of the user with the ID.
+func (s *Store) Get(userID, id string) (*Schedule, error) {
+	var schedules []Schedule
+	if err := s.db.DoQuery(&schedules, s.db.Builder().
+		Select(scheduleColumns...).
+		From("LLM_Schedules").
+		Where(sq.Eq{"ID": id, "UserID": userID})); err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if len(schedules) == 0 {
+		return nil, ErrScheduleNotFound
+	}
+	return &schedules[0], nil
+}
+
+// Create adds a schedule for its user, first running at the next time of its cadence.
+func (s *Store) Create(schedule Schedule) (*Schedule, error) {
+	schedule = schedule.normalize()
+	if err := schedule.IsValid(); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.List(schedule.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= MaxSchedulesPerUser {
+		return nil, ErrTooManySchedules
+	}
+
+	now := time.Now()
+	next, err := schedule.next(now)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+	}
+	schedule.ID = model.NewId()
+	schedule.CreateAt = now.UnixMilli()
+	schedule.UpdateAt = schedule.CreateAt
+	schedule.NextRunAt = next.UnixMilli()
+	schedule.LastRunAt = 0
+	schedule.LastError = ""
+	if _, err := s.db.ExecBuilder(s.db.Builder().Insert("LLM_Schedules").
+		Columns(scheduleColumns...).
+		Values(
+			schedule.ID, schedule.UserID, schedule.BotUsername, schedule.Name, schedule.Prompt, schedule.Cadence,
+			schedule.TimeZone, schedule.Enabled, schedule.CreateAt, schedule.UpdateAt, schedule.NextRunAt,
+			schedule.LastRunAt, schedule.LastError,
+		)); err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	return &schedule, nil
+}
+
+// Update replaces the bot, name, prompt, cadence and time zone of the schedule with the same ID and user,
+// and enables or disables it. The next run is computed again from the new cadence.
+func (s *Store) Update(schedule Schedule) (*Schedule, error) {
+	schedule = schedule.normalize()
+	if err := schedule.IsValid(); err != nil {
+		return nil, err
+	}
+	existing, err := s.Get(schedule.UserID, schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	next, err := schedule.next(now)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+	}
+	schedule.CreateAt = existing.CreateAt
+	schedule.UpdateAt = now.UnixMilli()
+	schedule.NextRunAt = next.UnixMilli()
+	schedule.LastRunAt = existing.LastRunAt
+	schedule.LastError = existing.LastError
+	result, err := s.db.ExecBuilder(s.db.Builder().Update("LLM_Schedules").
+		Set("BotUsername", schedule.BotUsername).
+		Set("Name", schedule.Name).
+		Set("Prompt", schedule.Prompt).
+		Set("Cadence", schedule.Cadence).
+		Set("TimeZone", schedule.TimeZone).
+		Set("Enabled", schedule.Enabled).
+		Set("UpdateAt", schedule.UpdateAt).
+		Set("NextRunAt", schedule.NextRunAt).
+		Where(sq.Eq{"ID": schedule.ID, "UserID": schedule.UserID}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update schedule: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return nil, ErrScheduleNotFound
+	}
+
+	return &schedule, nil
+}
+
+// Delete removes the schedule of the user with the ID.
+func (s *Store) Delete(userID, id string) error {
+	result, err := s.db.ExecBuilder(s.db.Builder().Delete("LLM_Schedules").Where(sq.Eq{"ID": id, "UserID": userID}))
+	if err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return ErrScheduleNotFound
+	}
+	return nil
+}
+
+// due returns the enabled schedules whose next run is at or before the time given, the most overdue first.
+func (s *Store) due(now time.Time, limit uint64) ([]Schedule, error) {
+	schedules := []Schedule{}
+	if err := s.db.DoQuery(&schedules, s.db.Builder().
+		Select(scheduleColumns...).
+		From("LLM_Schedules").
+		Where(sq.Eq{"Enabled": true}).
+		Where(sq.LtOrEq{"NextRunAt": now.UnixMilli()}).
+		OrderBy("NextRunAt").
+		Limit(limit)); err != nil {
+		return nil, fmt.Errorf("failed to get due schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// claim moves the next run of a due schedule to the time given, and returns false if it was already claimed.
+func (s *Store) claim(schedule Schedule, now time.Time, next int64) (bool, error) {
+	result, err := s.db.ExecBuilder(s.db.Builder().Update("LLM_Schedules").
+		Set("NextRunAt", next).
+		Set("LastRunAt", now.UnixMilli()).
+		Where(sq.Eq{"ID": schedule.ID, "NextRunAt": schedule.NextRunAt}))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim schedule: %w", err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim schedule: %w", err)
+	}
+	return claimed > 0, nil
+}
+
+// saveResult records why the last run of the schedule failed, or clears it when it succeeded.
+func (s *Store) saveResult(scheduleID string, runErr error) error {
+	lastError := ""
+	if runErr != nil {
+		lastError = runErr.Error()
+	}
+	if _, err := s.db.ExecBuilder(s.db.Builder().Update("LLM_Schedules").
+		Set("LastError", lastError).
+		Where(sq.Eq{"ID": scheduleID})); err != nil {
+		return fmt.Errorf("failed to save schedule result: %w", err)
+	}
+	return nil
+}
@@ -4,21 +4,31 @@
 package search
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/mattermost/mattermost-plugin-ai/chunking"
+	"github.com/mattermost/mattermost-plugin-ai/cohere"
 	"github.com/mattermost/mattermost-plugin-ai/embeddings"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/openai"
 	"github.com/mattermost/mattermost-plugin-ai/postgres"
+	"github.com/mattermost/mattermost-plugin-ai/qdrant"
+	"github.com/mattermost/mattermost-plugin-ai/sentencetransformers"
 )
 
+// vectorStoreSetupTimeout bounds the time external vector stores take to set up their collections
+const vectorStoreSetupTimeout = 30 * time.Second
+
 // newVectorStore creates a new vector store based on the provided configuration
-func newVectorStore(db *sqlx.DB, config embeddings.UpstreamConfig, dimensions int) (embeddings.VectorStore, error) {
-	switch config.Type { //nolint:gocritic
+func newVectorStore(db *sqlx.DB, httpClient *http.Client, config embeddings.UpstreamConfig, dimensions int) (embeddings.VectorStore, error) {
+	switch config.Type {
 	case embeddings.VectorStoreTypePGVector:
 		pgVectorConfig := postgres.PGVectorConfig{
 			Dimensions: dimensions,
@@ -27,13 +37,25 @@ func newVectorStore(db *sqlx.DB, config embeddings.UpstreamConfig, dimensions in
 			return nil, fmt.Errorf("failed to unmarshal pgvector config: %w", err)
 		}
 		return postgres.NewPGVector(db, pgVectorConfig)
+	case embeddings.VectorStoreTypeQdrant:
+		qdrantConfig := qdrant.Config{
+			Dimensions: dimensions,
+		}
+		if err := json.Unmarshal(config.Parameters, &qdrantConfig); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal qdrant config: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), vectorStoreSetupTimeout)
+		defer cancel()
+		return qdrant.New(ctx, httpClient, qdrantConfig, postgres.NewChannelMembership(db))
 	}
 
 	return nil, fmt.Errorf("unsupported vector store type: %s", config.Type)
 }
 
-// newEmbeddingProvider creates a new embedding provider based on the provided configuration
-func newEmbeddingProvider(config embeddings.UpstreamConfig, httpClient *http.Client) (embeddings.EmbeddingProvider, error) {
+// newEmbeddingProvider creates a new embedding provider based on the provided configuration, creating
+// embeddings of the given dimensions
+func newEmbeddingProvider(config embeddings.UpstreamConfig, dimensions int, httpClient *http.Client) (embeddings.EmbeddingProvider, error) {
 	switch config.Type {
 	case embeddings.ProviderTypeOpenAICompatible:
 		compatibleConfig := openai.Config{}
@@ -46,12 +68,71 @@ func newEmbeddingProvider(config embeddings.UpstreamConfig, httpClient *http.Cli
 		if err := json.Unmarshal(config.Parameters, &openaiConfig); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal OpenAI config: %w", err)
 		}
+		if openaiConfig.EmbeddingModel == "" {
+			openaiConfig.EmbeddingModel = openai.DefaultEmbeddingModel
+		}
+		// The text-embedding-3 models shorten their embeddings to the dimensions they are asked for
+		if openaiConfig.EmbeddingDimentions == 0 && strings.HasPrefix(openaiConfig.EmbeddingModel, "text-embedding-3") {
+			openaiConfig.EmbeddingDimentions = dimensions
+		}
 		return openai.NewCompatibleEmbeddings(openaiConfig, httpClient), nil
+	case embeddings.ProviderTypeCohere:
+		cohereConfig := cohere.Config{
+			Dimensions: dimensions,
+		}
+		if err := json.Unmarshal(config.Parameters, &cohereConfig); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal Cohere config: %w", err)
+		}
+		return cohere.NewEmbeddings(cohereConfig, httpClient)
+	case embeddings.ProviderTypeSentenceTransformers:
+		sentenceTransformersConfig := sentencetransformers.Config{
+			Dimensions: dimensions,
+		}
+		if err := json.Unmarshal(config.Parameters, &sentenceTransformersConfig); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal sentence-transformers config: %w", err)
+		}
+		return sentencetransformers.NewEmbeddings(sentenceTransformersConfig, httpClient)
 	}
 
 	return nil, fmt.Errorf("unsupported embedding provider type: %s", config.Type)
 }
 
+// EmbeddingModel returns the model the configuration creates embeddings with, the default model of
+// the provider when none is configured.
+func EmbeddingModel(cfg embeddings.EmbeddingSearchConfig) embeddings.EmbeddingModel {
+	var parameters struct {
+		EmbeddingModel string `json:"embeddingModel"`
+	}
+	if len(cfg.EmbeddingProvider.Parameters) > 0 {
+		// Invalid parameters are reported when creating the provider
+		_ = json.Unmarshal(cfg.EmbeddingProvider.Parameters, &parameters)
+	}
+
+	model := parameters.EmbeddingModel
+	if model == "" {
+		switch cfg.EmbeddingProvider.Type {
+		case embeddings.ProviderTypeOpenAI, embeddings.ProviderTypeOpenAICompatible:
+			model = openai.DefaultEmbeddingModel
+		case embeddings.ProviderTypeCohere:
+			model = cohere.DefaultEmbeddingModel
+		}
+	}
+
+	return embeddings.EmbeddingModel{
+		Provider:   cfg.EmbeddingProvider.Type,
+		Model:      model,
+		Dimensions: cfg.Dimensions,
+	}
+}
+
+// ChunkingOptions returns the configured chunking options, or the defaults when none are configured
+func ChunkingOptions(cfg embeddings.EmbeddingSearchConfig) chunking.Options {
+	if cfg.ChunkingOptions.ChunkSize == 0 {
+		return chunking.DefaultOptions()
+	}
+	return cfg.ChunkingOptions
+}
+
 // InitEmbeddingsSearch creates and initializes the embedding search system
 func InitEmbeddingsSearch(db *sqlx.DB, httpClient *http.Client, cfg embeddings.EmbeddingSearchConfig, licenseChecker *enterprise.LicenseChecker) (embeddings.EmbeddingSearch, error) {
 	if cfg.Type == "" {
@@ -64,23 +145,43 @@ func InitEmbeddingsSearch(db *sqlx.DB, httpClient *http.Client, cfg embeddings.E
 
 	switch cfg.Type { //nolint:gocritic
 	case embeddings.SearchTypeComposite:
-		vector, err := newVectorStore(db, cfg.VectorStore, cfg.Dimensions)
+		vector, err := newVectorStore(db, httpClient, cfg.VectorStore, cfg.Dimensions)
 		if err != nil {
 			return nil, err
 		}
-		embeddor, err := newEmbeddingProvider(cfg.EmbeddingProvider, httpClient)
+		embeddor, err := newEmbeddingProvider(cfg.EmbeddingProvider, cfg.Dimensions, httpClient)
 		if err != nil {
 			return nil, err
 		}
 
-		// Check if we have specific chunking options configured
-		chunkingOpts := cfg.ChunkingOptions
-		if chunkingOpts.ChunkSize == 0 {
-			chunkingOpts = chunking.DefaultOptions()
-		}
-
-		return embeddings.NewCompositeSearch(vector, embeddor, chunkingOpts), nil
+		return embeddings.NewCompositeSearch(vector, embeddor, ChunkingOptions(cfg)), nil
 	}
 
 	return nil, fmt.Errorf("unsupported search type: %s", cfg.Type)
 }
+
+// InitEmbeddingProvider creates the configured embedding provider so other features can embed
+// text without going through the vector store.
+func InitEmbeddingProvider(httpClient *http.Client, cfg embeddings.EmbeddingSearchConfig, licenseChecker *enterprise.LicenseChecker) (embeddings.EmbeddingProvider, error) {
+	if cfg.Type == "" {
+		return nil, fmt.Errorf("search is disabled")
+	}
+
+	if !licenseChecker.IsBasicsLicensed() {
+		return nil, fmt.Errorf("search is unavailable without a valid license")
+	}
+
+	return newEmbeddingProvider(cfg.EmbeddingProvider, cfg.Dimensions, httpClient)
+}
+
+// relevanceScoringTimeout bounds the time spent ranking history before a request, after which the oldest history is dropped
+const relevanceScoringTimeout = 30 * time.Second
+
+// NewRelevanceScorer ranks conversation history by the similarity of its embeddings to the latest question.
+func NewRelevanceScorer(provider embeddings.EmbeddingProvider) llm.RelevanceScorer {
+	return func(query string, messages []string) ([]float64, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), relevanceScoringTimeout)
+		defer cancel()
+		return embeddings.RelevanceScores(ctx, provider, query, messages)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package search
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+)
+
+func TestEmbeddingModel(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   string
+		parameters string
+		expected   string
+	}{
+		{name: "configured model", provider: embeddings.ProviderTypeOpenAI, parameters: `{"embeddingModel":"text-embedding-3-small"}`, expected: "text-embedding-3-small"},
+		{name: "default OpenAI model", provider: embeddings.ProviderTypeOpenAI, parameters: `{"embeddingModel":""}`, expected: "text-embedding-3-large"},
+		{name: "default Cohere model", provider: embeddings.ProviderTypeCohere, expected: "embed-english-v3.0"},
+		{name: "sentence-transformers model", provider: embeddings.ProviderTypeSentenceTransformers, parameters: `{"embeddingModel":"all-MiniLM-L6-v2"}`, expected: "all-MiniLM-L6-v2"},
+		{name: "unnamed sentence-transformers model", provider: embeddings.ProviderTypeSentenceTransformers},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			model := EmbeddingModel(embeddings.EmbeddingSearchConfig{
+				EmbeddingProvider: embeddings.UpstreamConfig{Type: tc.provider, Parameters: json.RawMessage(tc.parameters)},
+				Dimensions:        768,
+			})
+			assert.Equal(t, embeddings.EmbeddingModel{Provider: tc.provider, Model: tc.expected, Dimensions: 768}, model)
+		})
+	}
+}
+
+func TestNewEmbeddingProvider(t *testing.T) {
+	t.Run("providers create embeddings of the configured dimensions", func(t *testing.T) {
+		for _, config := range []embeddings.UpstreamConfig{
+			{Type: embeddings.ProviderTypeOpenAI, Parameters: json.RawMessage(`{"apiKey":"secret"}`)},
+			{Type: embeddings.ProviderTypeCohere, Parameters: json.RawMessage(`{"apiKey":"secret"}`)},
+			{Type: embeddings.ProviderTypeSentenceTransformers, Parameters: json.RawMessage(`{"apiURL":"http://localhost:8080"}`)},
+		} {
+			provider, err := newEmbeddingProvider(config, 256, nil)
+			assert.NoError(t, err, config.Type)
+			assert.Equal(t, 256, provider.Dimensions(), config.Type)
+		}
+	})
+
+	t.Run("models that can't shorten embeddings aren't asked for dimensions", func(t *testing.T) {
+		provider, err := newEmbeddingProvider(embeddings.UpstreamConfig{
+			Type:       embeddings.ProviderTypeOpenAI,
+			Parameters: json.RawMessage(`{"apiKey":"secret","embeddingModel":"text-embedding-ada-002"}`),
+		}, 1536, nil)
+		assert.NoError(t, err)
+		assert.Zero(t, provider.Dimensions())
+	})
+
+	t.Run("unknown providers", func(t *testing.T) {
+		_, err := newEmbeddingProvider(embeddings.UpstreamConfig{Type: "unknown"}, 256, nil)
+		assert.EqualError(t, err, "unsupported embedding provider type: unknown")
+	})
+}
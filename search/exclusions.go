@@ -0,0 +1,73 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package search
+
+import (
+	"context"
+	"slices"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+)
+
+// SetExclusions sets the teams and channels whose posts aren't searched
+func (s *Search) SetExclusions(exclusions embeddings.Exclusions) {
+	s.exclusions.Store(&exclusions)
+}
+
+// Search searches the index, leaving out the results from excluded teams and channels. They may
+// still be in the index when they were excluded after it was built. Teams and channels excluded by ID
+// are left out by the index itself, so they don't take the place of other results within the limit,
+// while channels excluded by name are left out of the results found.
+func (s *Search) Search(ctx context.Context, query string, opts embeddings.SearchOptions) ([]embeddings.SearchResult, error) {
+	exclusions := s.exclusions.Load()
+	if exclusions == nil || exclusions.IsEmpty() {
+		return s.EmbeddingSearch.Search(ctx, query, opts)
+	}
+	if exclusions.ExcludesTeam(opts.TeamID) {
+		return nil, nil
+	}
+
+	opts.ExcludedTeamIDs = append(slices.Clip(opts.ExcludedTeamIDs), exclusions.TeamIDs...)
+	opts.ExcludedChannelIDs = append(slices.Clip(opts.ExcludedChannelIDs), exclusions.ChannelIDs...)
+	results, err := s.EmbeddingSearch.Search(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(exclusions.ChannelNamePatterns) == 0 {
+		return results, nil
+	}
+
+	excludedChannels := map[string]bool{}
+	var filtered []embeddings.SearchResult
+	for _, result := range results {
+		channelID := result.Document.ChannelID
+		excluded, checked := excludedChannels[channelID]
+		if !checked {
+			excluded = s.isExcludedChannel(*exclusions, result.Document)
+			excludedChannels[channelID] = excluded
+		}
+		if !excluded {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered, nil
+}
+
+// isExcludedChannel returns whether the channel of a document is excluded. Channels that can't be
+// looked up are excluded when their name may be.
+func (s *Search) isExcludedChannel(exclusions embeddings.Exclusions, doc embeddings.PostDocument) bool {
+	if exclusions.Excludes(doc.TeamID, doc.ChannelID, "") {
+		return true
+	}
+	if len(exclusions.ChannelNamePatterns) == 0 {
+		return false
+	}
+
+	channel, err := s.mmclient.GetChannel(doc.ChannelID)
+	if err != nil {
+		s.mmclient.LogWarn("Failed to get channel to check search exclusions", "error", err, "channelID", doc.ChannelID)
+		return true
+	}
+	return exclusions.Excludes(channel.TeamId, channel.Id, channel.Name)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	mmapimocks "github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+)
+
+// staticSearch finds the same documents whatever the query, keeping the options of the last search
+type staticSearch struct {
+	embeddings.EmbeddingSearch
+	results []embeddings.SearchResult
+	opts    *embeddings.SearchOptions
+}
+
+func (s staticSearch) Search(_ context.Context, _ string, opts embeddings.SearchOptions) ([]embeddings.SearchResult, error) {
+	*s.opts = opts
+	return s.results, nil
+}
+
+func TestSearchExclusions(t *testing.T) {
+	result := func(postID, teamID, channelID string) embeddings.SearchResult {
+		return embeddings.SearchResult{Document: embeddings.PostDocument{PostID: postID, TeamID: teamID, ChannelID: channelID}}
+	}
+	postIDs := func(results []embeddings.SearchResult) []string {
+		var ids []string
+		for _, result := range results {
+			ids = append(ids, result.Document.PostID)
+		}
+		return ids
+	}
+
+	index := staticSearch{results: []embeddings.SearchResult{
+		result("post1", "team1", "general"),
+		result("post2", "team1", "hr"),
+		result("post3", "team2", "sales"),
+		result("post4", "team1", "hr"),
+		result("post5", "team1", "private"),
+	}, opts: &embeddings.SearchOptions{}}
+
+	t.Run("finds everything without exclusions", func(t *testing.T) {
+		search := New(index, nil, nil, nil, nil)
+		results, err := search.Search(context.Background(), "query", embeddings.SearchOptions{})
+		require.NoError(t, err)
+		assert.Len(t, results, 5)
+	})
+
+	t.Run("excluded teams and channels are left out by the index", func(t *testing.T) {
+		search := New(index, nil, nil, nil, nil)
+		search.SetExclusions(embeddings.Exclusions{
+			TeamIDs:    []string{"team2"},
+			ChannelIDs: []string{"hr"},
+		})
+
+		results, err := search.Search(context.Background(), "query", embeddings.SearchOptions{Limit: 5})
+		require.NoError(t, err)
+		assert.Len(t, results, 5, "results aren't filtered again")
+		assert.Equal(t, embeddings.SearchOptions{
+			Limit:              5,
+			ExcludedTeamIDs:    []string{"team2"},
+			ExcludedChannelIDs: []string{"hr"},
+		}, *index.opts)
+	})
+
+	t.Run("leaves out excluded teams and channels", func(t *testing.T) {
+		client := mmapimocks.NewMockClient(t)
+		client.EXPECT().GetChannel("general").Return(&model.Channel{Id: "general", TeamId: "team1", Name: "town-square"}, nil).Once()
+		client.EXPECT().GetChannel("hr").Return(&model.Channel{Id: "hr", TeamId: "team1", Name: "hr-reviews"}, nil).Once()
+		client.EXPECT().GetChannel("private").Return(nil, model.NewAppError("GetChannel", "not_found", nil, "", 404))
+		client.EXPECT().LogWarn(mock.Anything, mock.Anything).Once()
+
+		search := New(index, client, nil, nil, nil)
+		search.SetExclusions(embeddings.Exclusions{
+			TeamIDs:             []string{"team2"},
+			ChannelNamePatterns: []string{"hr-*"},
+		})
+
+		results, err := search.Search(context.Background(), "query", embeddings.SearchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"post1"}, postIDs(results))
+
+		results, err = search.Search(context.Background(), "query", embeddings.SearchOptions{TeamID: "team2"})
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
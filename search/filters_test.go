@@ -0,0 +1,35 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFiltersValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters Filters
+		wantErr bool
+	}{
+		{name: "no filters", filters: Filters{}},
+		{name: "date range", filters: Filters{CreatedAfter: 100, CreatedBefore: 200}},
+		{name: "open date range", filters: Filters{CreatedAfter: 100}},
+		{name: "empty date range", filters: Filters{CreatedAfter: 200, CreatedBefore: 200}, wantErr: true},
+		{name: "reversed date range", filters: Filters{CreatedAfter: 300, CreatedBefore: 200}, wantErr: true},
+		{name: "negative date", filters: Filters{CreatedBefore: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filters.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
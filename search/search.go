@@ -7,6 +7,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/embeddings"
@@ -28,6 +30,46 @@ type Request struct {
 	TeamID     string `json:"teamId"`
 	ChannelID  string `json:"channelId"`
 	MaxResults int    `json:"maxResults"`
+	Corpus     string `json:"corpus"`
+	Filters
+}
+
+// Filters narrow a search down to the documents matching all of them. They are applied by the
+// vector store, so the results are the closest documents among the matching ones rather than the
+// matching ones among the closest documents.
+type Filters struct {
+	ChannelIDs  []string `json:"channelIds"`  // Documents in any of the channels
+	FromUserIDs []string `json:"fromUserIds"` // Documents posted by any of the users
+
+	// Documents created after or before the timestamps, in milliseconds
+	CreatedAfter  int64 `json:"createdAfter"`
+	CreatedBefore int64 `json:"createdBefore"`
+}
+
+// Validate checks the filters can match documents
+func (f Filters) Validate() error {
+	if f.CreatedAfter < 0 || f.CreatedBefore < 0 {
+		return fmt.Errorf("search dates can't be negative")
+	}
+	if f.CreatedAfter != 0 && f.CreatedBefore != 0 && f.CreatedAfter >= f.CreatedBefore {
+		return fmt.Errorf("createdAfter must be before createdBefore")
+	}
+	return nil
+}
+
+// options returns the search options of a search of the user with the filters
+func (f Filters) options(userID, teamID, channelID, corpus string, maxResults int) embeddings.SearchOptions {
+	return embeddings.SearchOptions{
+		Limit:         maxResults,
+		TeamID:        teamID,
+		ChannelID:     channelID,
+		ChannelIDs:    f.ChannelIDs,
+		UserID:        userID,
+		FromUserIDs:   f.FromUserIDs,
+		CreatedAfter:  f.CreatedAfter,
+		CreatedBefore: f.CreatedBefore,
+		Corpus:        corpus,
+	}
 }
 
 // Response represents a response to a search query
@@ -47,6 +89,15 @@ type RAGResult struct {
 	Username    string  `json:"username"`
 	Content     string  `json:"content"`
 	Score       float32 `json:"score"`
+
+	// Set for meeting summaries, transcripts and attached files
+	SourceType   string   `json:"sourceType,omitempty"`
+	MeetingDate  string   `json:"meetingDate,omitempty"`
+	Participants []string `json:"participants,omitempty"`
+
+	// Set for attached files, the post is the one the file is attached to
+	FileID   string `json:"fileId,omitempty"`
+	FileName string `json:"fileName,omitempty"`
 }
 
 type Search struct {
@@ -55,6 +106,9 @@ type Search struct {
 	prompts          *llm.Prompts
 	streamingService streaming.Service
 	licenseChecker   *enterprise.LicenseChecker
+
+	// Teams and channels whose posts aren't searched
+	exclusions atomic.Pointer[embeddings.Exclusions]
 }
 
 func New(
@@ -115,7 +169,7 @@ func (s *Search) convertToRAGResults(searchResults []embeddings.SearchResult) []
 				result.Document.TotalChunks)
 		}
 
-		ragResults = append(ragResults, RAGResult{
+		ragResult := RAGResult{
 			PostID:      result.Document.PostID,
 			ChannelID:   result.Document.ChannelID,
 			ChannelName: channelName + chunkInfo,
@@ -123,14 +177,40 @@ func (s *Search) convertToRAGResults(searchResults []embeddings.SearchResult) []
 			Username:    username,
 			Content:     content,
 			Score:       result.Score,
-		})
+		}
+		switch result.Document.Corpus() {
+		case embeddings.CorpusMeetings:
+			ragResult.SourceType = result.Document.SourceType
+			ragResult.MeetingDate = time.UnixMilli(result.Document.CreateAt).UTC().Format(time.DateOnly)
+			if result.Document.Meeting != nil {
+				ragResult.Participants = result.Document.Meeting.Participants
+			}
+		case embeddings.CorpusFiles:
+			ragResult.SourceType = result.Document.SourceType
+			if result.Document.File != nil {
+				ragResult.FileID = result.Document.File.ID
+				ragResult.FileName = result.Document.File.Name
+			}
+		}
+		ragResults = append(ragResults, ragResult)
 	}
 
 	return ragResults
 }
 
-// RunSearch initiates a search and sends results to a DM
-func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, query, teamID, channelID string, maxResults int) (map[string]string, error) {
+// noResultsMessage is the answer when nothing relevant was found in the corpus
+func noResultsMessage(corpus string) string {
+	switch corpus {
+	case embeddings.CorpusMeetings:
+		return "I couldn't find any relevant meetings for your query. Please try a different search term."
+	case embeddings.CorpusFiles:
+		return "I couldn't find any relevant files for your query. Please try a different search term."
+	}
+	return "I couldn't find any relevant messages for your query. Please try a different search term."
+}
+
+// RunSearch initiates a search and sends results to a DM. The filters are validated by the caller.
+func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, query, teamID, channelID, corpus string, maxResults int, filters Filters) (map[string]string, error) {
 	if s.EmbeddingSearch == nil {
 		return nil, fmt.Errorf("search functionality is not configured")
 	}
@@ -139,6 +219,10 @@ func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, qu
 		return nil, fmt.Errorf("query cannot be empty")
 	}
 
+	if !embeddings.IsValidCorpus(corpus) {
+		return nil, fmt.Errorf("unknown search corpus: %s", corpus)
+	}
+
 	// Create the initial question post
 	questionPost := &model.Post{
 		UserId:  userID,
@@ -150,7 +234,7 @@ func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, qu
 	}
 
 	// Start processing the search asynchronously
-	go func(query, teamID, channelID string, maxResults int) {
+	go func(query, teamID, channelID, corpus string, maxResults int, filters Filters) {
 		// Create response post as a reply
 		responsePost := &model.Post{
 			RootId: questionPost.Id,
@@ -179,12 +263,7 @@ func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, qu
 			maxResults = 5
 		}
 
-		searchResults, err := s.Search(context.Background(), query, embeddings.SearchOptions{
-			Limit:     maxResults,
-			TeamID:    teamID,
-			ChannelID: channelID,
-			UserID:    userID,
-		})
+		searchResults, err := s.Search(context.Background(), query, filters.options(userID, teamID, channelID, corpus, maxResults))
 		if err != nil {
 			s.mmclient.LogError("Error performing search", "error", err)
 			processingError = err
@@ -193,7 +272,7 @@ func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, qu
 
 		ragResults := s.convertToRAGResults(searchResults)
 		if len(ragResults) == 0 {
-			responsePost.Message = "I couldn't find any relevant messages for your query. Please try a different search term."
+			responsePost.Message = noResultsMessage(corpus)
 			if updateErr := s.mmclient.UpdatePost(responsePost); updateErr != nil {
 				s.mmclient.LogError("Error updating post on error", "error", updateErr)
 			}
@@ -258,7 +337,7 @@ func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, qu
 		}
 		defer s.streamingService.FinishStreaming(responsePost.Id)
 		s.streamingService.StreamToPost(streamContext, resultStream, responsePost, "")
-	}(query, teamID, channelID, maxResults)
+	}(query, teamID, channelID, corpus, maxResults, filters)
 
 	return map[string]string{
 		"PostID":    questionPost.Id,
@@ -266,23 +345,22 @@ func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, qu
 	}, nil
 }
 
-// SearchQuery performs a search and returns results immediately
-func (s *Search) SearchQuery(ctx context.Context, userID string, bot *bots.Bot, query, teamID, channelID string, maxResults int) (Response, error) {
+// SearchQuery performs a search and returns results immediately. The filters are validated by the caller.
+func (s *Search) SearchQuery(ctx context.Context, userID string, bot *bots.Bot, query, teamID, channelID, corpus string, maxResults int, filters Filters) (Response, error) {
 	if s.EmbeddingSearch == nil {
 		return Response{}, fmt.Errorf("search functionality is not configured")
 	}
 
+	if !embeddings.IsValidCorpus(corpus) {
+		return Response{}, fmt.Errorf("unknown search corpus: %s", corpus)
+	}
+
 	if maxResults == 0 {
 		maxResults = 5
 	}
 
 	// Search for relevant posts using embeddings
-	searchResults, err := s.Search(ctx, query, embeddings.SearchOptions{
-		Limit:     maxResults,
-		TeamID:    teamID,
-		ChannelID: channelID,
-		UserID:    userID,
-	})
+	searchResults, err := s.Search(ctx, query, filters.options(userID, teamID, channelID, corpus, maxResults))
 	if err != nil {
 		return Response{}, fmt.Errorf("search failed: %w", err)
 	}
@@ -290,7 +368,7 @@ func (s *Search) SearchQuery(ctx context.Context, userID string, bot *bots.Bot,
 	ragResults := s.convertToRAGResults(searchResults)
 	if len(ragResults) == 0 {
 		return Response{
-			Answer:  "I couldn't find any relevant messages for your query. Please try a different search term.",
+			Answer:  noResultsMessage(corpus),
 			Results: []RAGResult{},
 		}, nil
 	}
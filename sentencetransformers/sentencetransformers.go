@@ -0,0 +1,131 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package sentencetransformers provides an embedding provider backed by a self-hosted server running
+// a sentence-transformers model, such as Hugging Face Text Embeddings Inference. Posts are embedded
+// without leaving the network.
+package sentencetransformers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultBatchSize is the largest batch Text Embeddings Inference accepts by default
+const DefaultBatchSize = 32
+
+type Config struct {
+	APIURL string `json:"apiURL"`
+	APIKey string `json:"apiKey"` // Only sent when the server requires one
+
+	// EmbeddingModel is the model the server runs. It isn't sent since the server runs a single
+	// model, but it is recorded with the index so changing it flags the index as stale.
+	EmbeddingModel string `json:"embeddingModel"`
+	Dimensions     int    `json:"dimensions"`
+
+	// BatchSize is the number of texts embedded per request
+	BatchSize int `json:"batchSize"`
+}
+
+// Embeddings creates embeddings with a sentence-transformers server
+type Embeddings struct {
+	config     Config
+	httpClient *http.Client
+}
+
+type embedRequest struct {
+	Inputs   []string `json:"inputs"`
+	Truncate bool     `json:"truncate"`
+}
+
+// NewEmbeddings creates a sentence-transformers embedding provider
+func NewEmbeddings(config Config, httpClient *http.Client) (*Embeddings, error) {
+	if config.APIURL == "" {
+		return nil, errors.New("sentence-transformers server URL is required")
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	config.APIURL = strings.TrimSuffix(config.APIURL, "/")
+
+	return &Embeddings{
+		config:     config,
+		httpClient: httpClient,
+	}, nil
+}
+
+func (e *Embeddings) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// BatchCreateEmbeddings embeds the texts in batches of the configured size
+func (e *Embeddings) BatchCreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += e.config.BatchSize {
+		batch, err := e.embed(ctx, texts[start:min(start+e.config.BatchSize, len(texts))])
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
+func (e *Embeddings) Dimensions() int {
+	return e.config.Dimensions
+}
+
+// embed embeds texts with the /embed endpoint. Texts longer than the model accepts are truncated
+// rather than refused, chunking keeps most of them short enough.
+func (e *Embeddings) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(embedRequest{Inputs: texts, Truncate: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.APIURL+"/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.config.APIKey)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach the sentence-transformers server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Errors are reported as {"error": "...", "error_type": "..."}
+		var errorResponse struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(data, &errorResponse) != nil || errorResponse.Error == "" {
+			errorResponse.Error = strings.TrimSpace(string(data))
+		}
+		return nil, fmt.Errorf("sentence-transformers server returned status %d: %s", resp.StatusCode, errorResponse.Error)
+	}
+
+	var embeddings [][]float32
+	if err := json.NewDecoder(resp.Body).Decode(&embeddings); err != nil {
+		return nil, fmt.Errorf("unable to decode embeddings: %w", err)
+	}
+	if len(embeddings) != len(texts) {
+		return nil, fmt.Errorf("sentence-transformers server returned %d embeddings for %d texts", len(embeddings), len(texts))
+	}
+	return embeddings, nil
+}
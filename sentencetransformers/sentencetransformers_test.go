@@ -0,0 +1,65 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package sentencetransformers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddings(t *testing.T) {
+	var requests []embedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embed" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var request embedRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request)
+		if len(request.Inputs) == 0 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"error":"Input validation error: inputs cannot be empty","error_type":"Validation"}`))
+			return
+		}
+
+		// Embeds each text as its length
+		embeddings := make([][]float32, 0, len(request.Inputs))
+		for _, input := range request.Inputs {
+			embeddings = append(embeddings, []float32{float32(len(input))})
+		}
+		_ = json.NewEncoder(w).Encode(embeddings)
+	}))
+	defer server.Close()
+
+	provider, err := NewEmbeddings(Config{APIURL: server.URL, Dimensions: 384, BatchSize: 2}, server.Client())
+	require.NoError(t, err)
+	assert.Equal(t, 384, provider.Dimensions())
+
+	embedding, err := provider.CreateEmbedding(context.Background(), "query")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{5}, embedding)
+
+	requests = nil
+	embeddings, err := provider.BatchCreateEmbeddings(context.Background(), []string{"a", "bb", "ccc"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {2}, {3}}, embeddings)
+	assert.Equal(t, []embedRequest{
+		{Inputs: []string{"a", "bb"}, Truncate: true},
+		{Inputs: []string{"ccc"}, Truncate: true},
+	}, requests)
+
+	_, err = provider.embed(context.Background(), nil)
+	assert.EqualError(t, err, "sentence-transformers server returned status 422: Input validation error: inputs cannot be empty")
+
+	_, err = NewEmbeddings(Config{}, server.Client())
+	assert.Error(t, err)
+}
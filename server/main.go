@@ -4,7 +4,6 @@
 package main
 
 import (
-	"context"
 	"net/http"
 	"os"
 	"time"
@@ -15,21 +14,30 @@ import (
 	"github.com/mattermost/mattermost-plugin-ai/conversations"
 	"github.com/mattermost/mattermost-plugin-ai/database"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/glossary"
 	"github.com/mattermost/mattermost-plugin-ai/i18n"
 	"github.com/mattermost/mattermost-plugin-ai/indexer"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
+	"github.com/mattermost/mattermost-plugin-ai/linking"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/llmcontext"
 	"github.com/mattermost/mattermost-plugin-ai/mcp"
 	"github.com/mattermost/mattermost-plugin-ai/meetings"
+	"github.com/mattermost/mattermost-plugin-ai/memory"
 	"github.com/mattermost/mattermost-plugin-ai/metrics"
+	"github.com/mattermost/mattermost-plugin-ai/migration"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost-plugin-ai/mmtools"
 	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/provenance"
+	"github.com/mattermost/mattermost-plugin-ai/schedules"
 	"github.com/mattermost/mattermost-plugin-ai/search"
+	"github.com/mattermost/mattermost-plugin-ai/snippets"
 	"github.com/mattermost/mattermost-plugin-ai/streaming"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
 	"github.com/mattermost/mattermost/server/public/shared/httpservice"
 )
 
@@ -46,7 +54,13 @@ type Plugin struct {
 	apiService           *api.API
 	indexerService       *indexer.Indexer
 	conversationsService *conversations.Conversations
+	meetingsService      *meetings.Service
 	mcpClientManager     *mcp.ClientManager
+	metricsService       metrics.Metrics
+	orphanedFilesJob     *cluster.Job
+	scheduledPromptsJob  *cluster.Job
+	mcpAuditCleanupJob   *cluster.Job
+	jobQueue             *jobs.Queue
 }
 
 func (p *Plugin) OnActivate() error {
@@ -76,7 +90,102 @@ func (p *Plugin) OnActivate() error {
 		p.configuration.Update(&newCfg)
 	}
 
+	llmPrompts, promptManagerErr := llm.NewPrompts(prompts.PromptsFolder)
+	if promptManagerErr != nil {
+		pluginAPI.Log.Error("failed to initialize prompts", "error", promptManagerErr)
+		return promptManagerErr
+	}
+
 	bots := bots.New(p.API, pluginAPI, licenseChecker, &p.configuration, llmUpstreamHTTPClient)
+
+	embeddingProvider, err := search.InitEmbeddingProvider(
+		llmUpstreamHTTPClient,
+		p.configuration.EmbeddingSearchConfig(),
+		licenseChecker,
+	)
+	if err != nil {
+		embeddingProvider = nil
+	}
+
+	// Summarize the history that doesn't fit in the context window instead of silently dropping it,
+	// dropping the least relevant history first for bots that opted in
+	historySummaries := llm.NewHistorySummaryCache(
+		llm.NewPromptHistorySummarizer(llmPrompts, prompts.PromptSummarizeDroppedHistorySystem),
+		llm.HistorySummaryCacheSize,
+	)
+	var relevanceScorer llm.RelevanceScorer
+	if embeddingProvider != nil {
+		relevanceScorer = search.NewRelevanceScorer(embeddingProvider)
+	}
+	bots.Middlewares().Register("truncation", llm.MiddlewarePriorityTruncation, func(bot llm.BotConfig) llm.Middleware {
+		if !bot.HistoryRelevanceFiltering {
+			return llm.TruncationMiddleware(historySummaries.Summarizer(bot), nil)
+		}
+		return llm.TruncationMiddleware(historySummaries.Summarizer(bot), relevanceScorer)
+	})
+
+	// Definitions of the organization's terms that appear in each conversation
+	glossaryStore := glossary.NewStore(dbClient)
+	bots.Middlewares().Register("glossary", glossary.MiddlewarePriority, func(_ llm.BotConfig) llm.Middleware {
+		return glossary.Middleware(glossaryStore, llmPrompts, prompts.PromptGlossarySystem, pluginAPI.Log)
+	})
+
+	// Instructions users regenerate responses with, such as "shorter"
+	bots.Middlewares().Register("regeneration", llm.MiddlewarePriorityRegeneration, func(_ llm.BotConfig) llm.Middleware {
+		return llm.RegenerationMiddleware(llmPrompts, prompts.PromptRegenerationInstructionSystem)
+	})
+
+	// Model experiments configured per bot
+	bots.Middlewares().Register("experiment", llm.MiddlewarePriorityExperiment, func(bot llm.BotConfig) llm.Middleware {
+		if !bot.Experiment.IsActive() {
+			return nil
+		}
+		experiment := bot.Experiment
+		if experiment.Name == "" {
+			experiment.Name = bot.Name
+		}
+		return llm.ExperimentMiddleware(experiment, metricsService)
+	})
+
+	// Cheaper models for the lightweight features of bots
+	bots.Middlewares().Register("feature_models", llm.MiddlewarePriorityFeatureModels, func(bot llm.BotConfig) llm.Middleware {
+		if !bot.FeatureModels.Enabled {
+			return nil
+		}
+		return llm.FeatureModelsMiddleware(bot.Service.Type, bot.FeatureModels)
+	})
+
+	// Aliases of fine-tuned models, with usage tagged by model for comparisons with the base model
+	bots.Middlewares().Register("model_aliases", llm.MiddlewarePriorityModelAliases, func(bot llm.BotConfig) llm.Middleware {
+		return llm.ModelAliasMiddleware(bot.Service, metricsService)
+	})
+
+	// Tokens used by each answer, recorded against its thread for cost accounting
+	bots.Middlewares().Register("usage", llm.MiddlewarePriorityUsage, func(bot llm.BotConfig) llm.Middleware {
+		return llm.UsageMiddleware(bot)
+	})
+
+	// Ensemble answers for high-stakes channels
+	ensembleJudge := llm.NewPromptEnsembleJudge(llmPrompts, prompts.PromptEnsembleJudgeSystem)
+	bots.Middlewares().Register("ensemble", llm.MiddlewarePriorityEnsemble, func(bot llm.BotConfig) llm.Middleware {
+		if !bot.Ensemble.Enabled {
+			return nil
+		}
+		secondaryBotName := bot.Ensemble.SecondaryBot
+		secondary := func() llm.LanguageModel {
+			if secondaryBotName == "" || secondaryBotName == bot.Name {
+				return nil
+			}
+			secondaryBot := bots.GetBotByUsername(secondaryBotName)
+			// A secondary bot with its own ensemble would answer in a loop
+			if secondaryBot == nil || secondaryBot.GetConfig().Ensemble.Enabled {
+				pluginAPI.Log.Warn("Ensemble secondary bot unavailable, answering twice with the same bot", "bot_name", bot.Name, "secondary_bot_name", secondaryBotName)
+				return nil
+			}
+			return secondaryBot.LLM()
+		}
+		return llm.EnsembleMiddleware(bot.Ensemble, secondary, ensembleJudge)
+	})
 	p.configuration.RegisterUpdateListener(func() {
 		if ensureErr := bots.EnsureBots(p.configuration.GetBots()); ensureErr != nil {
 			pluginAPI.Log.Error("failed to ensure bots on configuration update", "error", ensureErr)
@@ -95,13 +204,19 @@ func (p *Plugin) OnActivate() error {
 		return setupTablesErr
 	}
 
-	prompts, promptManagerErr := llm.NewPrompts(prompts.PromptsFolder)
-	if promptManagerErr != nil {
-		pluginAPI.Log.Error("failed to initialize prompts", "error", promptManagerErr)
-		return promptManagerErr
-	}
-
 	streamingService := streaming.NewMMPostStreamService(mmClient, i18nBundle)
+	streamingService.SetKeepAliveInterval(p.configuration.GetStreamingKeepAliveInterval())
+	p.configuration.RegisterUpdateListener(func() {
+		streamingService.SetKeepAliveInterval(p.configuration.GetStreamingKeepAliveInterval())
+	})
+
+	// Link the users, channels and tickets mentioned in responses
+	entityLinker := linking.NewProcessor(pluginAPI)
+	entityLinker.Configure(p.configuration.GetEntityLinkingConfig())
+	p.configuration.RegisterUpdateListener(func() {
+		entityLinker.Configure(p.configuration.GetEntityLinkingConfig())
+	})
+	streamingService.RegisterMessageProcessor(entityLinker.Process)
 
 	embeddingsSearch, err := search.InitEmbeddingsSearch(
 		dbClient.DB,
@@ -114,38 +229,73 @@ func (p *Plugin) OnActivate() error {
 		// Continue without search functionality
 	}
 
-	indexerService := indexer.New(embeddingsSearch, mmClient, bots, dbClient.DB)
+	indexerService := indexer.New(embeddingsSearch, mmClient, bots, dbClient.DB, &pluginAPI.File, search.EmbeddingModel(p.configuration.EmbeddingSearchConfig()))
+	if recordErr := indexerService.RecordIndexedModel(); recordErr != nil {
+		pluginAPI.Log.Warn("failed to record the embedding model of the search index", "error", recordErr)
+	}
+	// A job left running by a server that restarted or stopped can be resumed
+	if recoverErr := indexerService.RecoverInterruptedJob(); recoverErr != nil {
+		pluginAPI.Log.Warn("failed to recover the interrupted reindex job", "error", recoverErr)
+	}
+	indexerService.SetChunkingOptions(search.ChunkingOptions(p.configuration.EmbeddingSearchConfig()))
+	indexerService.StartQueue()
 
 	searchService := search.New(
 		embeddingsSearch,
 		mmClient,
-		prompts,
+		llmPrompts,
 		streamingService,
 		licenseChecker,
 	)
 
+	// Exclude teams and channels from search both when indexing and when searching
+	setSearchExclusions := func() {
+		exclusions := p.configuration.EmbeddingSearchConfig().Exclusions
+		indexerService.SetExclusions(exclusions)
+		searchService.SetExclusions(exclusions)
+	}
+	setSearchExclusions()
+	p.configuration.RegisterUpdateListener(setSearchExclusions)
+
 	toolProvider := mmtools.NewMMToolProvider(
 		mmClient,
 		searchService,
 		untrustedHTTPClient,
 	)
 
-	mcpClientManager := mcp.NewClientManager(p.configuration.MCP(), pluginAPI.Log)
+	mcpAuditStore := mcp.NewAuditStore(dbClient)
+	// MCP secrets are encrypted with keys kept outside of the KV store, derived from the at-rest key of the
+	// server unless configured in its environment
+	var atRestEncryptKey string
+	if serverConfig := pluginAPI.Configuration.GetUnsanitizedConfig(); serverConfig != nil && serverConfig.SqlSettings.AtRestEncryptKey != nil {
+		atRestEncryptKey = *serverConfig.SqlSettings.AtRestEncryptKey
+	}
+	mcpSecretsKeyring, err := mcp.NewSecretKeyring(atRestEncryptKey)
+	if err != nil {
+		pluginAPI.Log.Error("failed to load the encryption keys of MCP secrets", "error", err)
+		// Don't fail, servers referencing secrets fail to connect until the keys are configured
+	}
+	mcpSecretStore := mcp.NewSecretStore(&pluginAPI.KV, mcpSecretsKeyring)
+	mcpClientManager := mcp.NewClientManager(p.configuration.MCP(), pluginAPI.Log, mcpAuditStore, mcpSecretStore)
 	p.configuration.RegisterUpdateListener(func() {
 		mcpClientManager.ReInit(p.configuration.MCP())
 	})
 
+	memoryStore := memory.NewStore(dbClient)
+
 	contextBuilder := llmcontext.NewLLMContextBuilder(
 		pluginAPI,
 		toolProvider,
 		mcpClientManager,
 		&p.configuration,
+		memoryStore,
 	)
 
 	conversationsService := conversations.New(
-		prompts,
+		llmPrompts,
 		mmClient,
 		pluginAPI,
+		p.API,
 		streamingService,
 		contextBuilder,
 		bots,
@@ -153,19 +303,89 @@ func (p *Plugin) OnActivate() error {
 		licenseChecker,
 		i18nBundle,
 		nil, // meetingsService will be set after it's created
+		&p.configuration,
 	)
+	mcpClientManager.SetSampler(conversationsService)
+	streamingService.RegisterToolCallPreparer(conversationsService.AssignToolCallApprovers)
+	streamingService.RegisterToolCallListener(conversationsService.HandleToolCallsPosted)
+	streamingService.RegisterUsageListener(conversationsService.RecordUsage)
+	streamingService.RegisterCompletionListener(conversationsService.SuggestFollowUps)
 
 	meetingsService := meetings.NewService(
 		pluginAPI,
 		streamingService,
-		prompts,
+		llmPrompts,
 		bots,
 		i18nBundle,
 		metricsService,
 		dbClient,
 		contextBuilder,
 		conversationsService,
+		&p.configuration,
 	)
+	p.configuration.RegisterUpdateListener(meetingsService.CheckFFmpeg)
+
+	// Transcriptions and summaries run from a queue in the database so they survive restarts
+	jobQueue := jobs.NewQueue(dbClient, pluginAPI.Log)
+	meetingsService.RegisterJobs(jobQueue)
+
+	// Transcripts uploaded by summaries that failed before attaching them, run on one server of the cluster
+	orphanedFilesJob, err := cluster.Schedule(p.API, "ai_orphaned_files_cleanup", cluster.MakeWaitForRoundedInterval(meetings.OrphanedFilesCleanupInterval), func() {
+		files, size, cleanupErr := meetingsService.DeleteOrphanedFiles(p.configuration.GetOrphanedFileRetention())
+		if cleanupErr != nil {
+			pluginAPI.Log.Error("Failed to delete orphaned transcript files", "error", cleanupErr)
+			return
+		}
+		if files > 0 {
+			pluginAPI.Log.Info("Deleted orphaned transcript files", "files", files, "bytes", size)
+		}
+	})
+	if err != nil {
+		pluginAPI.Log.Error("failed to schedule orphaned files cleanup", "error", err)
+		// Don't fail, the files are only cleaned up later
+	}
+
+	// Prompts users scheduled, run on one server of the cluster
+	scheduleStore := schedules.NewStore(dbClient)
+	scheduleRunner := schedules.NewRunner(scheduleStore, conversationsService, pluginAPI.Log)
+	scheduledPromptsJob, err := cluster.Schedule(p.API, "ai_scheduled_prompts", cluster.MakeWaitForRoundedInterval(schedules.RunInterval), func() {
+		scheduleRunner.RunDue(time.Now())
+	})
+	if err != nil {
+		pluginAPI.Log.Error("failed to start scheduled prompts", "error", err)
+		// Don't fail, the prompts run once the plugin is restarted
+	}
+
+	// Records of MCP tool calls older than the retention, run on one server of the cluster
+	mcpAuditCleanupJob, err := cluster.Schedule(p.API, "ai_mcp_tool_calls_cleanup", cluster.MakeWaitForRoundedInterval(mcp.AuditCleanupInterval), func() {
+		deleted, cleanupErr := mcpAuditStore.DeleteToolCallsBefore(time.Now().Add(-p.configuration.MCP().AuditRetention()))
+		if cleanupErr != nil {
+			pluginAPI.Log.Error("Failed to delete expired MCP tool calls", "error", cleanupErr)
+			return
+		}
+		if deleted > 0 {
+			pluginAPI.Log.Info("Deleted expired MCP tool calls", "count", deleted)
+		}
+	})
+	if err != nil {
+		pluginAPI.Log.Error("failed to schedule MCP tool calls cleanup", "error", err)
+		// Don't fail, the records are only deleted later
+	}
+
+	if embeddingProvider != nil {
+		meetingsService.SetEmbeddingProvider(embeddingProvider)
+	}
+	// Transcripts are ranked with the provider of the current search configuration
+	p.configuration.RegisterUpdateListener(func() {
+		provider, providerErr := search.InitEmbeddingProvider(llmUpstreamHTTPClient, p.configuration.EmbeddingSearchConfig(), licenseChecker)
+		if providerErr != nil {
+			provider = nil
+		}
+		meetingsService.SetEmbeddingProvider(provider)
+	})
+	if embeddingsSearch != nil {
+		meetingsService.SetEmbeddingSearch(embeddingsSearch)
+	}
 
 	// Set the meetings service on conversations to break circular dependency
 	// TODO: Refactor to avoid circular dependency
@@ -177,11 +397,21 @@ func (p *Plugin) OnActivate() error {
 		meetingsService,
 		indexerService,
 		searchService,
+		glossaryStore,
+		snippets.NewStore(dbClient),
+		migration.NewImporter(pluginAPI, conversationsService),
+		jobQueue,
+		provenance.NewStore(dbClient),
+		memoryStore,
+		scheduleStore,
+		mcpClientManager,
+		mcpAuditStore,
+		mcpSecretStore,
 		pluginAPI,
 		metricsService,
 		contextBuilder,
 		&p.configuration,
-		prompts,
+		llmPrompts,
 		mmClient,
 		licenseChecker,
 		streamingService,
@@ -193,7 +423,15 @@ func (p *Plugin) OnActivate() error {
 	p.apiService = apiService
 	p.indexerService = indexerService
 	p.conversationsService = conversationsService
+	p.meetingsService = meetingsService
 	p.mcpClientManager = mcpClientManager
+	p.metricsService = metricsService
+	p.orphanedFilesJob = orphanedFilesJob
+	p.scheduledPromptsJob = scheduledPromptsJob
+	p.mcpAuditCleanupJob = mcpAuditCleanupJob
+	p.jobQueue = jobQueue
+
+	jobQueue.Start()
 
 	return nil
 }
@@ -201,45 +439,122 @@ func (p *Plugin) OnActivate() error {
 func (p *Plugin) OnDeactivate() error {
 	// Clean up MCP client manager if it exists
 	p.mcpClientManager.Close()
+
+	// Posts waiting in the indexing queue are indexed before stopping
+	if p.indexerService != nil {
+		p.indexerService.Close()
+	}
+
+	// Running jobs are put back in the queue to be resumed on the next start
+	if p.jobQueue != nil {
+		p.jobQueue.Close()
+	}
+
+	if p.orphanedFilesJob != nil {
+		if err := p.orphanedFilesJob.Close(); err != nil {
+			p.pluginAPI.Log.Error("Failed to stop orphaned files cleanup", "error", err)
+		}
+	}
+
+	if p.scheduledPromptsJob != nil {
+		if err := p.scheduledPromptsJob.Close(); err != nil {
+			p.pluginAPI.Log.Error("Failed to stop scheduled prompts", "error", err)
+		}
+	}
+	if p.mcpAuditCleanupJob != nil {
+		if err := p.mcpAuditCleanupJob.Close(); err != nil {
+			p.pluginAPI.Log.Error("Failed to stop MCP tool calls cleanup", "error", err)
+		}
+	}
 	return nil
 }
 
 func (p *Plugin) MessageHasBeenPosted(c *plugin.Context, post *model.Post) {
-	// Index the new message in the vector database
+	// Queue the new message to be indexed in the vector database
 	if p.indexerService != nil {
 		// Get channel to retrieve team ID
 		channel, err := p.API.GetChannel(post.ChannelId)
 		if err != nil {
 			p.pluginAPI.Log.Error("Failed to get channel for post indexing", "error", err)
 		} else {
-			if err := p.indexerService.IndexPost(context.Background(), post, channel); err != nil {
-				p.pluginAPI.Log.Error("Failed to index post in vector database", "error", err)
-			}
+			p.indexerService.QueuePost(post, channel)
 		}
 	}
 
 	p.conversationsService.MessageHasBeenPosted(c, post)
+	p.meetingsService.MessageHasBeenPosted(c, post)
 }
 
 func (p *Plugin) MessageHasBeenUpdated(c *plugin.Context, newPost, oldPost *model.Post) {
-	// Handle indexing of updated posts
+	// Queue the updated post to replace its previous version in the vector database
 	if p.indexerService != nil {
-		// Delete the old post from index
-		if err := p.indexerService.DeletePost(context.Background(), oldPost.Id); err != nil {
-			p.pluginAPI.Log.Error("Failed to delete post from vector database", "error", err)
-		}
-
 		// Get channel to retrieve team ID
 		channel, err := p.API.GetChannel(newPost.ChannelId)
 		if err != nil {
 			p.pluginAPI.Log.Error("Failed to get channel for post indexing", "error", err)
 		} else {
-			// Index the updated post
-			if err := p.indexerService.IndexPost(context.Background(), newPost, channel); err != nil {
-				p.pluginAPI.Log.Error("Failed to index updated post in vector database", "error", err)
-			}
+			p.indexerService.QueueUpdate(newPost, channel)
+		}
+	}
+}
+
+func (p *Plugin) MessageHasBeenDeleted(c *plugin.Context, post *model.Post) {
+	// Queue the deleted post to be removed from the vector database
+	if p.indexerService != nil {
+		p.indexerService.QueueDelete(post)
+	}
+}
+
+func (p *Plugin) ReactionHasBeenAdded(c *plugin.Context, reaction *model.Reaction) {
+	// Only a few reactions are handled, the post isn't fetched for the others
+	refinement, isRefinement := conversations.RefinementForReaction(reaction.EmojiName)
+	var positive bool
+	switch reaction.EmojiName {
+	case "+1", "thumbsup":
+		positive = true
+	case "-1", "thumbsdown":
+		positive = false
+	default:
+		if !isRefinement {
+			return
 		}
 	}
+
+	post, err := p.pluginAPI.Post.GetPost(reaction.PostId)
+	if err != nil {
+		p.pluginAPI.Log.Error("Failed to get post for reaction", "error", err)
+		return
+	}
+
+	// The requester of a summary can refine it by reacting to it
+	if isRefinement {
+		if !conversations.IsSummaryPost(post) || post.GetProp(streaming.LLMRequesterUserID) != reaction.UserId {
+			return
+		}
+		channel, err := p.pluginAPI.Channel.Get(post.ChannelId)
+		if err != nil {
+			p.pluginAPI.Log.Error("Failed to get channel to refine summary", "error", err)
+			return
+		}
+		go func() {
+			if err := p.conversationsService.HandleRefineSummary(reaction.UserId, post, channel, refinement); err != nil {
+				p.pluginAPI.Log.Error("Failed to refine summary", "error", err)
+			}
+		}()
+		return
+	}
+
+	// Reactions on responses are recorded as feedback for the model and the experiment variant
+	if err := llm.RecordFeedback(&p.pluginAPI.KV, p.metricsService, post, reaction.UserId, positive); err != nil {
+		p.pluginAPI.Log.Error("Failed to record feedback", "error", err)
+	}
+}
+
+func (p *Plugin) OnPluginClusterEvent(c *plugin.Context, ev model.PluginClusterEvent) {
+	// Sampling requests of MCP servers wait for their decision on the server the tool runs on
+	if ev.Id == conversations.SamplingDecisionEvent && p.conversationsService != nil {
+		p.conversationsService.NotifySamplingDecision(string(ev.Data))
+	}
 }
 
 func (p *Plugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Request) {
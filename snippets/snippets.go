@@ -0,0 +1,166 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package snippets keeps the prompt snippets shared by the members of a team, published to the whole
+// organization by admins, or kept by users for themselves. A snippet is a prompt with {{variables}}
+// that are filled in when the snippet is used in a direct message with a bot.
+package snippets
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxNameLength        = 64
+	maxDescriptionLength = 256
+	maxTemplateLength    = 4000
+
+	// maxVariables keeps the dialog filling in the variables short enough to use
+	maxVariables = 10
+)
+
+// Scopes of a snippet, who it is available to
+const (
+	// ScopeTeam snippets are available to the members of their team
+	ScopeTeam = "team"
+
+	// ScopePersonal snippets are only available to their creator, in their team
+	ScopePersonal = "personal"
+
+	// ScopeOrganization snippets are published by system admins to every team
+	ScopeOrganization = "organization"
+)
+
+var (
+	// ErrInvalidSnippet is returned when saving a snippet without a name or prompt or with fields that are too long.
+	ErrInvalidSnippet = errors.New("invalid snippet")
+
+	// ErrMissingValues is returned when using a snippet without a value for each of its variables.
+	ErrMissingValues = errors.New("missing snippet values")
+)
+
+// variablePattern matches the variables of a template, such as {{incident_id}}
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_]*)\s*\}\}`)
+
+// namePattern restricts names to what can be typed after the /snippet command
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// Snippet is a reusable prompt shared by the members of a team, the organization, or kept by its creator.
+// Organization snippets have no team.
+type Snippet struct {
+	ID          string `json:"id"`
+	TeamID      string `json:"teamId"`
+	Scope       string `json:"scope"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Template    string `json:"template"`
+	CreatorID   string `json:"creatorId"`
+	UpdateAt    int64  `json:"updateAt"`
+}
+
+// Variables returns the names of the variables of the template, in the order they first appear.
+func (s Snippet) Variables() []string {
+	variables := []string{}
+	for _, match := range variablePattern.FindAllStringSubmatch(s.Template, -1) {
+		if !slices.Contains(variables, match[1]) {
+			variables = append(variables, match[1])
+		}
+	}
+	return variables
+}
+
+// Missing returns the variables of the template that have no value.
+func (s Snippet) Missing(values map[string]string) []string {
+	var missing []string
+	for _, variable := range s.Variables() {
+		if strings.TrimSpace(values[variable]) == "" {
+			missing = append(missing, variable)
+		}
+	}
+	return missing
+}
+
+// Render fills in the variables of the template with the values given.
+func (s Snippet) Render(values map[string]string) (string, error) {
+	if missing := s.Missing(values); len(missing) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrMissingValues, strings.Join(missing, ", "))
+	}
+
+	return variablePattern.ReplaceAllStringFunc(s.Template, func(match string) string {
+		variable := variablePattern.FindStringSubmatch(match)[1]
+		return strings.TrimSpace(values[variable])
+	}), nil
+}
+
+// IsValid checks the snippet has a name and a prompt within the length limits.
+func (s Snippet) IsValid() error {
+	switch s.Scope {
+	case ScopeTeam, ScopePersonal:
+		if s.TeamID == "" {
+			return fmt.Errorf("%w: team is required", ErrInvalidSnippet)
+		}
+	case ScopeOrganization:
+		if s.TeamID != "" {
+			return fmt.Errorf("%w: organization snippets have no team", ErrInvalidSnippet)
+		}
+	default:
+		return fmt.Errorf("%w: unknown scope %q", ErrInvalidSnippet, s.Scope)
+	}
+	if !namePattern.MatchString(s.Name) {
+		return fmt.Errorf("%w: name must be letters, numbers, dashes and underscores", ErrInvalidSnippet)
+	}
+	if utf8.RuneCountInString(s.Name) > maxNameLength {
+		return fmt.Errorf("%w: name is too long", ErrInvalidSnippet)
+	}
+	if utf8.RuneCountInString(s.Description) > maxDescriptionLength {
+		return fmt.Errorf("%w: description is too long", ErrInvalidSnippet)
+	}
+	if strings.TrimSpace(s.Template) == "" {
+		return fmt.Errorf("%w: template is required", ErrInvalidSnippet)
+	}
+	if utf8.RuneCountInString(s.Template) > maxTemplateLength {
+		return fmt.Errorf("%w: template is too long", ErrInvalidSnippet)
+	}
+	if len(s.Variables()) > maxVariables {
+		return fmt.Errorf("%w: templates can have at most %d variables", ErrInvalidSnippet, maxVariables)
+	}
+	return nil
+}
+
+// normalize trims the fields, snippets without a scope are shared with their team.
+func (s Snippet) normalize() Snippet {
+	if s.Scope == "" {
+		s.Scope = ScopeTeam
+	}
+	if s.Scope == ScopeOrganization {
+		s.TeamID = ""
+	}
+	s.Name = strings.TrimSpace(s.Name)
+	s.Description = strings.TrimSpace(s.Description)
+	s.Template = strings.TrimSpace(s.Template)
+	return s
+}
+
+// scopePrecedence orders the scopes from the most to the least specific.
+var scopePrecedence = []string{ScopePersonal, ScopeTeam, ScopeOrganization}
+
+// Visible returns the snippets a user sees, without those hidden by a more specific snippet of the same
+// name: personal snippets come before the team's, which come before the organization's.
+func Visible(snippets []Snippet) []Snippet {
+	visible := make([]Snippet, 0, len(snippets))
+	for _, snippet := range snippets {
+		hidden := slices.ContainsFunc(snippets, func(other Snippet) bool {
+			return strings.EqualFold(other.Name, snippet.Name) &&
+				slices.Index(scopePrecedence, other.Scope) < slices.Index(scopePrecedence, snippet.Scope)
+		})
+		if !hidden {
+			visible = append(visible, snippet)
+		}
+	}
+	return visible
+}
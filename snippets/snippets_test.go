@@ -0,0 +1,108 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package snippets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariables(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     []string
+	}{
+		{name: "no variables", template: "Summarize the incident", want: []string{}},
+		{name: "in order of appearance", template: "Incident {{incident_id}} is {{ status }}", want: []string{"incident_id", "status"}},
+		{name: "repeated variables", template: "{{a}} then {{b}} then {{a}}", want: []string{"a", "b"}},
+		{name: "not a variable", template: "{{1st}} and {{ }} and {single}", want: []string{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Snippet{Template: tc.template}.Variables())
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	snippet := Snippet{Template: "Write an update for incident {{incident_id}}. Status: {{ status }}. Again {{incident_id}}."}
+
+	rendered, err := snippet.Render(map[string]string{"incident_id": " INC-42 ", "status": "mitigated"})
+	require.NoError(t, err)
+	assert.Equal(t, "Write an update for incident INC-42. Status: mitigated. Again INC-42.", rendered)
+
+	_, err = snippet.Render(map[string]string{"incident_id": "INC-42", "status": "  "})
+	require.ErrorIs(t, err, ErrMissingValues)
+	assert.Contains(t, err.Error(), "status")
+
+	assert.Equal(t, []string{"incident_id", "status"}, snippet.Missing(nil))
+}
+
+func TestIsValid(t *testing.T) {
+	valid := Snippet{TeamID: "team", Scope: ScopeTeam, Name: "incident-update", Template: "Update for {{incident_id}}"}
+
+	tests := []struct {
+		name    string
+		modify  func(s *Snippet)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(*Snippet) {}},
+		{name: "no team", modify: func(s *Snippet) { s.TeamID = "" }, wantErr: true},
+		{name: "personal", modify: func(s *Snippet) { s.Scope = ScopePersonal }},
+		{name: "personal without team", modify: func(s *Snippet) { s.Scope = ScopePersonal; s.TeamID = "" }, wantErr: true},
+		{name: "organization", modify: func(s *Snippet) { s.Scope = ScopeOrganization; s.TeamID = "" }},
+		{name: "organization with a team", modify: func(s *Snippet) { s.Scope = ScopeOrganization }, wantErr: true},
+		{name: "unknown scope", modify: func(s *Snippet) { s.Scope = "channel" }, wantErr: true},
+		{name: "no name", modify: func(s *Snippet) { s.Name = "" }, wantErr: true},
+		{name: "name with spaces", modify: func(s *Snippet) { s.Name = "incident update" }, wantErr: true},
+		{name: "name too long", modify: func(s *Snippet) { s.Name = strings.Repeat("a", maxNameLength+1) }, wantErr: true},
+		{name: "description too long", modify: func(s *Snippet) { s.Description = strings.Repeat("a", maxDescriptionLength+1) }, wantErr: true},
+		{name: "no template", modify: func(s *Snippet) { s.Template = " " }, wantErr: true},
+		{name: "template too long", modify: func(s *Snippet) { s.Template = strings.Repeat("a", maxTemplateLength+1) }, wantErr: true},
+		{name: "too many variables", modify: func(s *Snippet) { s.Template = "{{a}}{{b}}{{c}}{{d}}{{e}}{{f}}{{g}}{{h}}{{i}}{{j}}{{k}}" }, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			snippet := valid
+			tc.modify(&snippet)
+			err := snippet.IsValid()
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSnippet)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, ScopeTeam, Snippet{TeamID: "team"}.normalize().Scope)
+
+	organization := Snippet{TeamID: "team", Scope: ScopeOrganization}.normalize()
+	assert.Equal(t, ScopeOrganization, organization.Scope)
+	assert.Empty(t, organization.TeamID)
+}
+
+func TestVisible(t *testing.T) {
+	snippets := []Snippet{
+		{ID: "org-standup", Name: "standup", Scope: ScopeOrganization},
+		{ID: "team-standup", Name: "Standup", Scope: ScopeTeam},
+		{ID: "org-retro", Name: "retro", Scope: ScopeOrganization},
+		{ID: "team-incident", Name: "incident", Scope: ScopeTeam},
+		{ID: "personal-incident", Name: "incident", Scope: ScopePersonal},
+		{ID: "personal-notes", Name: "notes", Scope: ScopePersonal},
+	}
+
+	var ids []string
+	for _, snippet := range Visible(snippets) {
+		ids = append(ids, snippet.ID)
+	}
+	assert.Equal(t, []string{"team-standup", "org-retro", "personal-incident", "personal-notes"}, ids)
+}
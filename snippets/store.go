@@ -0,0 +1,151 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package snippets
+
+import (
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+var (
+	// ErrSnippetNotFound is returned when getting, updating or deleting a snippet that doesn't exist.
+	ErrSnippetNotFound = errors.New("snippet not found")
+
+	// ErrDuplicateSnippet is returned when a snippet with the same name already exists in the same scope.
+	ErrDuplicateSnippet = errors.New("snippet already exists")
+)
+
+// Store keeps the snippets in the LLM_Snippets table.
+type Store struct {
+	db *mmapi.DBClient
+}
+
+// NewStore creates a snippet store.
+func NewStore(db *mmapi.DBClient) *Store {
+	return &Store{db: db}
+}
+
+var snippetColumns = []string{"ID", "TeamID", "Scope", "Name", "Description", "Template", "CreatorID", "UpdateAt"}
+
+// List returns the snippets the user sees in the team in alphabetical order: the team's, the user's own and
+// the organization's.
+func (s *Store) List(teamID, userID string) ([]Snippet, error) {
+	snippets := []Snippet{}
+	if err := s.db.DoQuery(&snippets, s.db.Builder().
+		Select(snippetColumns...).
+		From("LLM_Snippets").
+		Where(sq.Or{
+			sq.Eq{"TeamID": teamID, "Scope": ScopeTeam},
+			sq.Eq{"TeamID": teamID, "Scope": ScopePersonal, "CreatorID": userID},
+			sq.Eq{"Scope": ScopeOrganization},
+		}).
+		OrderBy("LOWER(Name)")); err != nil {
+		return nil, fmt.Errorf("failed to list snippets: %w", err)
+	}
+	return Visible(snippets), nil
+}
+
+// Get returns the snippet with the ID.
+func (s *Store) Get(id string) (*Snippet, error) {
+	var snippets []Snippet
+	if err := s.db.DoQuery(&snippets, s.db.Builder().
+		Select(snippetColumns...).
+		From("LLM_Snippets").
+		Where(sq.Eq{"ID": id})); err != nil {
+		return nil, fmt.Errorf("failed to get snippet: %w", err)
+	}
+	if len(snippets) == 0 {
+		return nil, ErrSnippetNotFound
+	}
+	return &snippets[0], nil
+}
+
+// Create adds a snippet to its scope.
+func (s *Store) Create(snippet Snippet) (*Snippet, error) {
+	snippet = snippet.normalize()
+	if err := snippet.IsValid(); err != nil {
+		return nil, err
+	}
+	if err := s.checkDuplicate(snippet); err != nil {
+		return nil, err
+	}
+
+	snippet.ID = model.NewId()
+	snippet.UpdateAt = model.GetMillis()
+	if _, err := s.db.ExecBuilder(s.db.Builder().Insert("LLM_Snippets").
+		Columns(snippetColumns...).
+		Values(snippet.ID, snippet.TeamID, snippet.Scope, snippet.Name, snippet.Description, snippet.Template, snippet.CreatorID, snippet.UpdateAt)); err != nil {
+		return nil, fmt.Errorf("failed to create snippet: %w", err)
+	}
+
+	return &snippet, nil
+}
+
+// Update replaces the name, description and template of the snippet with the same ID.
+// The team, scope and creator of a snippet don't change.
+func (s *Store) Update(snippet Snippet) (*Snippet, error) {
+	snippet = snippet.normalize()
+	if err := snippet.IsValid(); err != nil {
+		return nil, err
+	}
+	if err := s.checkDuplicate(snippet); err != nil {
+		return nil, err
+	}
+
+	snippet.UpdateAt = model.GetMillis()
+	result, err := s.db.ExecBuilder(s.db.Builder().Update("LLM_Snippets").
+		Set("Name", snippet.Name).
+		Set("Description", snippet.Description).
+		Set("Template", snippet.Template).
+		Set("UpdateAt", snippet.UpdateAt).
+		Where(sq.Eq{"ID": snippet.ID, "TeamID": snippet.TeamID, "Scope": snippet.Scope}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update snippet: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return nil, ErrSnippetNotFound
+	}
+
+	return &snippet, nil
+}
+
+// Delete removes the snippet with the ID.
+func (s *Store) Delete(id string) error {
+	result, err := s.db.ExecBuilder(s.db.Builder().Delete("LLM_Snippets").Where(sq.Eq{"ID": id}))
+	if err != nil {
+		return fmt.Errorf("failed to delete snippet: %w", err)
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return ErrSnippetNotFound
+	}
+
+	return nil
+}
+
+// checkDuplicate returns ErrDuplicateSnippet if another snippet of the same scope has the same name, ignoring
+// case. Snippets of different scopes can share a name, the most specific one is used.
+func (s *Store) checkDuplicate(snippet Snippet) error {
+	sameScope := sq.Eq{"TeamID": snippet.TeamID, "Scope": snippet.Scope}
+	if snippet.Scope == ScopePersonal {
+		sameScope["CreatorID"] = snippet.CreatorID
+	}
+
+	var ids []string
+	if err := s.db.DoQuery(&ids, s.db.Builder().
+		Select("ID").
+		From("LLM_Snippets").
+		Where(sameScope).
+		Where("LOWER(Name) = LOWER(?)", snippet.Name).
+		Where(sq.NotEq{"ID": snippet.ID})); err != nil {
+		return fmt.Errorf("failed to check for duplicate snippets: %w", err)
+	}
+	if len(ids) > 0 {
+		return ErrDuplicateSnippet
+	}
+	return nil
+}
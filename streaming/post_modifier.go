@@ -12,10 +12,24 @@ const RespondingToProp = "responding_to"
 const NoRegen = "no_regen"
 const UnsafeLinksPostProp = "unsafe_links"
 
+// AIGeneratedProp marks posts with content generated by a model, whatever their type, so retention rules,
+// exports and other plugins can identify them. AIGeneratedBotIDProp is the bot that generated the content.
+const AIGeneratedProp = "ai_generated"
+const AIGeneratedBotIDProp = "ai_generated_bot_id"
+
+// MarkAIGenerated adds the props identifying a post as generated by the bot. Posts of other types than
+// the one set by ModifyPostForBot must be marked when they are created, and given a type starting with
+// custom_llm.
+func MarkAIGenerated(botid string, post *model.Post) {
+	post.AddProp(AIGeneratedProp, "true")
+	post.AddProp(AIGeneratedBotIDProp, botid)
+}
+
 // ModifyPostForBot modifies a post to add bot-specific properties
 func ModifyPostForBot(botid string, requesterUserID string, post *model.Post, respondingToPostID string) {
 	post.UserId = botid
 	post.Type = "custom_llmbot" // This must be the only place we add this type for security.
+	MarkAIGenerated(botid, post)
 	post.AddProp(LLMRequesterUserID, requesterUserID)
 	// This tags that the post has unsafe links since they could have been generated by a prompt injection.
 	// This will prevent the server from making OpenGraph requests and markdown images being rendered.
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-ai/i18n"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
@@ -20,6 +22,13 @@ const PostStreamingControlCancel = "cancel"
 const PostStreamingControlEnd = "end"
 const PostStreamingControlStart = "start"
 
+// PostStreamingControlHeartbeat is sent while waiting for the LLM, so proxies don't close idle
+// connections and clients can tell a slow generation from a lost connection
+const PostStreamingControlHeartbeat = "heartbeat"
+
+// DefaultKeepAliveInterval is how long a stream can be idle before a heartbeat is sent
+const DefaultKeepAliveInterval = 15 * time.Second
+
 const ToolCallProp = "pending_tool_call"
 
 type Service interface {
@@ -27,14 +36,37 @@ type Service interface {
 	StreamToNewDM(ctx context.Context, botID string, stream *llm.TextStreamResult, userID string, post *model.Post, respondingToPostID string) error
 	StreamToPost(ctx context.Context, stream *llm.TextStreamResult, post *model.Post, userLocale string)
 	StopStreaming(postID string)
+	GetStreamState(postID string) (StreamState, bool)
 	GetStreamingContext(inCtx context.Context, postID string) (context.Context, error)
 	FinishStreaming(postID string)
 }
 
+// ToolCallListener is notified once tool calls have been added to a post.
+type ToolCallListener func(post *model.Post, toolCalls []llm.ToolCall)
+
+// ToolCallPreparer changes the tool calls of a post before they are saved, such as to assign their approvers.
+type ToolCallPreparer func(post *model.Post, toolCalls []llm.ToolCall)
+
+// MessageProcessor rewrites the message of a completed response before the post is saved.
+type MessageProcessor func(post *model.Post, message string) string
+
+// UsageListener is notified of the tokens used by a response once it has been streamed to a post.
+type UsageListener func(post *model.Post, usage *llm.TokenUsage)
+
+// CompletionListener is notified once a response has been streamed to a post in full and saved.
+type CompletionListener func(post *model.Post)
+
 type postStreamContext struct {
 	cancel context.CancelFunc
 }
 
+// StreamState is the progress of a post being streamed. Seq is the sequence number of the last event
+// sent for the post, clients that missed events resume from the message instead of waiting for more.
+type StreamState struct {
+	Message string `json:"message"`
+	Seq     int64  `json:"seq"`
+}
+
 var ErrAlreadyStreamingToPost = fmt.Errorf("already streaming to post")
 
 type MMPostStreamService struct {
@@ -42,14 +74,74 @@ type MMPostStreamService struct {
 	contextsMutex sync.Mutex
 	mmClient      mmapi.Client
 	i18n          *i18n.Bundle
+
+	// streams are the posts being streamed, guarded by streamsMutex
+	streams      map[string]*StreamState
+	streamsMutex sync.Mutex
+
+	keepAliveInterval atomic.Int64
+
+	toolCallPreparers []ToolCallPreparer
+	toolCallListeners []ToolCallListener
+	messageProcessors []MessageProcessor
+	usageListeners    []UsageListener
+
+	completionListeners []CompletionListener
 }
 
 func NewMMPostStreamService(mmClient mmapi.Client, i18n *i18n.Bundle) *MMPostStreamService {
-	return &MMPostStreamService{
+	service := &MMPostStreamService{
 		contexts: make(map[string]postStreamContext),
+		streams:  make(map[string]*StreamState),
 		mmClient: mmClient,
 		i18n:     i18n,
 	}
+	service.keepAliveInterval.Store(int64(DefaultKeepAliveInterval))
+	return service
+}
+
+// SetKeepAliveInterval sets how long streams can be idle before a heartbeat is sent.
+func (p *MMPostStreamService) SetKeepAliveInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultKeepAliveInterval
+	}
+	p.keepAliveInterval.Store(int64(interval))
+}
+
+// GetStreamState returns the progress of the post when it is being streamed.
+func (p *MMPostStreamService) GetStreamState(postID string) (StreamState, bool) {
+	p.streamsMutex.Lock()
+	defer p.streamsMutex.Unlock()
+	state, ok := p.streams[postID]
+	if !ok {
+		return StreamState{}, false
+	}
+	return *state, true
+}
+
+// RegisterToolCallPreparer adds a preparer for tool calls made by streamed responses.
+func (p *MMPostStreamService) RegisterToolCallPreparer(preparer ToolCallPreparer) {
+	p.toolCallPreparers = append(p.toolCallPreparers, preparer)
+}
+
+// RegisterToolCallListener adds a listener for tool calls made by streamed responses.
+func (p *MMPostStreamService) RegisterToolCallListener(listener ToolCallListener) {
+	p.toolCallListeners = append(p.toolCallListeners, listener)
+}
+
+// RegisterMessageProcessor adds a processor run on the message of each completed response.
+func (p *MMPostStreamService) RegisterMessageProcessor(processor MessageProcessor) {
+	p.messageProcessors = append(p.messageProcessors, processor)
+}
+
+// RegisterUsageListener adds a listener for the tokens used by streamed responses.
+func (p *MMPostStreamService) RegisterUsageListener(listener UsageListener) {
+	p.usageListeners = append(p.usageListeners, listener)
+}
+
+// RegisterCompletionListener adds a listener for the responses streamed in full.
+func (p *MMPostStreamService) RegisterCompletionListener(listener CompletionListener) {
+	p.completionListeners = append(p.completionListeners, listener)
 }
 
 func (p *MMPostStreamService) StreamToNewPost(ctx context.Context, botID string, requesterUserID string, stream *llm.TextStreamResult, post *model.Post, respondingToPostID string) error {
@@ -137,19 +229,39 @@ func (p *MMPostStreamService) StreamToNewDM(ctx context.Context, botID string, s
 }
 
 func (p *MMPostStreamService) sendPostStreamingUpdateEvent(post *model.Post, message string) {
-	p.mmClient.PublishWebSocketEvent("postupdate", map[string]interface{}{
+	p.publishPostUpdate(post, map[string]interface{}{
 		"post_id": post.Id,
 		"next":    message,
-	}, &model.WebsocketBroadcast{
-		ChannelId: post.ChannelId,
 	})
 }
 
 func (p *MMPostStreamService) sendPostStreamingControlEvent(post *model.Post, control string) {
-	p.mmClient.PublishWebSocketEvent("postupdate", map[string]interface{}{
+	p.publishPostUpdate(post, map[string]interface{}{
 		"post_id": post.Id,
 		"control": control,
-	}, &model.WebsocketBroadcast{
+	})
+}
+
+// sendPostStreamingHeartbeatEvent tells clients the post is still being generated and when to expect the next heartbeat.
+func (p *MMPostStreamService) sendPostStreamingHeartbeatEvent(post *model.Post, interval time.Duration) {
+	p.publishPostUpdate(post, map[string]interface{}{
+		"post_id":      post.Id,
+		"control":      PostStreamingControlHeartbeat,
+		"keepalive_ms": interval.Milliseconds(),
+	})
+}
+
+// publishPostUpdate numbers the events of posts being streamed and records their message, see GetStreamState.
+func (p *MMPostStreamService) publishPostUpdate(post *model.Post, data map[string]interface{}) {
+	p.streamsMutex.Lock()
+	if state, ok := p.streams[post.Id]; ok {
+		state.Seq++
+		state.Message = post.Message
+		data["seq"] = state.Seq
+	}
+	p.streamsMutex.Unlock()
+
+	p.mmClient.PublishWebSocketEvent("postupdate", data, &model.WebsocketBroadcast{
 		ChannelId: post.ChannelId,
 	})
 }
@@ -194,14 +306,46 @@ func (p *MMPostStreamService) FinishStreaming(postID string) {
 // it will internally handle logging needs and updating the post.
 func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.TextStreamResult, post *model.Post, userLocale string) {
 	T := i18n.LocalizerFunc(p.i18n, userLocale)
+	for key, value := range stream.Props {
+		post.AddProp(key, value)
+	}
+
+	p.streamsMutex.Lock()
+	p.streams[post.Id] = &StreamState{Message: post.Message}
+	p.streamsMutex.Unlock()
+	defer func() {
+		p.streamsMutex.Lock()
+		delete(p.streams, post.Id)
+		p.streamsMutex.Unlock()
+	}()
+
 	p.sendPostStreamingControlEvent(post, PostStreamingControlStart)
 	defer func() {
 		p.sendPostStreamingControlEvent(post, PostStreamingControlEnd)
 	}()
 
+	// Responses that were stopped or failed partway still used tokens
+	if stream.Usage != nil {
+		defer func() {
+			for _, listener := range p.usageListeners {
+				listener(post, stream.Usage)
+			}
+		}()
+	}
+
+	// Heartbeats are sent whenever the LLM has been silent for the keep-alive interval,
+	// such as while it is reasoning or waiting on a tool
+	keepAliveInterval := time.Duration(p.keepAliveInterval.Load())
+	keepAlive := time.NewTimer(keepAliveInterval)
+	defer keepAlive.Stop()
+
 	for {
 		select {
+		case <-keepAlive.C:
+			p.sendPostStreamingHeartbeatEvent(post, keepAliveInterval)
+			keepAlive.Reset(keepAliveInterval)
 		case event := <-stream.Stream:
+			keepAlive.Reset(keepAliveInterval)
 			switch event.Type {
 			case llm.EventTypeText:
 				// Handle text event
@@ -211,15 +355,26 @@ func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.Text
 				}
 			case llm.EventTypeEnd:
 				// Stream has closed cleanly
-				if strings.TrimSpace(post.Message) == "" {
+				completed := strings.TrimSpace(post.Message) != ""
+				if !completed {
 					p.mmClient.LogError("LLM closed stream with no result")
 					post.Message = T("copilot.stream_to_post_llm_not_return", "Sorry! The LLM did not return a result.")
 					p.sendPostStreamingUpdateEvent(post, post.Message)
+				} else if len(p.messageProcessors) > 0 {
+					for _, processor := range p.messageProcessors {
+						post.Message = processor(post, post.Message)
+					}
+					p.sendPostStreamingUpdateEvent(post, post.Message)
 				}
 				if err := p.mmClient.UpdatePost(post); err != nil {
 					p.mmClient.LogError("Streaming failed to update post", "error", err)
 					return
 				}
+				if completed {
+					for _, listener := range p.completionListeners {
+						listener(post)
+					}
+				}
 				return
 			case llm.EventTypeError:
 				// Handle error event
@@ -252,6 +407,9 @@ func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.Text
 					for i := range toolCalls {
 						toolCalls[i].Status = llm.ToolCallStatusPending
 					}
+					for _, preparer := range p.toolCallPreparers {
+						preparer(post, toolCalls)
+					}
 
 					// Add the tool call as a prop to the post
 					toolCallJSON, err := json.Marshal(toolCalls)
@@ -274,6 +432,10 @@ func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.Text
 					}, &model.WebsocketBroadcast{
 						ChannelId: post.ChannelId,
 					})
+
+					for _, listener := range p.toolCallListeners {
+						listener(post, toolCalls)
+					}
 				}
 				return
 			}
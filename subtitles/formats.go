@@ -0,0 +1,232 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package subtitles
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/asticode/go-astisub"
+)
+
+// Transcript formats recognized by DetectFormat
+const (
+	FormatWebVTT     = "webvtt"
+	FormatWebexVTT   = "webex_vtt"
+	FormatZoomChat   = "zoom_chat"
+	FormatGoogleMeet = "google_meet"
+)
+
+// maxTranscriptSize limits the size of transcripts read by NewSubtitlesFromTranscript
+const maxTranscriptSize = 50 * 1024 * 1024
+
+// googleMeetItemDuration is how long each line of a Google Meet transcript is assumed to last
+// when the end of its section isn't known
+const googleMeetItemDuration = 5 * time.Second
+
+// ErrUnknownFormat is returned when a transcript isn't in any of the recognized formats.
+var ErrUnknownFormat = errors.New("unknown transcript format")
+
+var (
+	// webexCueIdentifier matches the cue identifiers of Webex, which name the speaker: 1 "Jane Doe" (123456789)
+	webexCueIdentifier = regexp.MustCompile(`^(\d+)\s+"([^"]*)"(\s*\(\d+\))?$`)
+
+	// zoomChatLine matches the lines of Zoom chat exports, which start with the time of day
+	zoomChatLine = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}[\t ]`)
+
+	// googleMeetTimestamp matches the timestamps on their own line that start sections of Google Meet transcripts
+	googleMeetTimestamp = regexp.MustCompile(`^\d{1,2}:\d{2}(:\d{2})?$`)
+
+	// googleMeetSpeakerLine matches the lines of Google Meet transcripts, which start with the speaker name
+	googleMeetSpeakerLine = regexp.MustCompile(`^([^:]{1,100}): (.*)$`)
+
+	// googleMeetEnd matches the line after the last section of Google Meet transcripts
+	googleMeetEnd = regexp.MustCompile(`^Transcription ended after (\d{1,2}:\d{2}(:\d{2})?)`)
+)
+
+// DetectFormat returns the format of a transcript from its content, or empty when it isn't recognized.
+func DetectFormat(content []byte) string {
+	lines := nonEmptyLines(content)
+	if len(lines) == 0 {
+		return ""
+	}
+
+	if fields := strings.Fields(lines[0]); len(fields) > 0 && fields[0] == "WEBVTT" {
+		for _, line := range lines {
+			if webexCueIdentifier.MatchString(line) {
+				return FormatWebexVTT
+			}
+		}
+		return FormatWebVTT
+	}
+
+	isZoomChat := true
+	for _, line := range lines {
+		if !zoomChatLine.MatchString(line) {
+			isZoomChat = false
+			break
+		}
+	}
+	if isZoomChat {
+		return FormatZoomChat
+	}
+
+	for i, line := range lines[:len(lines)-1] {
+		if googleMeetTimestamp.MatchString(line) && googleMeetSpeakerLine.MatchString(lines[i+1]) {
+			return FormatGoogleMeet
+		}
+	}
+
+	return ""
+}
+
+// NewSubtitlesFromTranscript parses a transcript in any of the recognized formats, see DetectFormat.
+func NewSubtitlesFromTranscript(transcript io.Reader) (*Subtitles, error) {
+	content, err := io.ReadAll(io.LimitReader(transcript, maxTranscriptSize))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read transcript: %w", err)
+	}
+
+	switch DetectFormat(content) {
+	case FormatWebVTT:
+		return NewSubtitlesFromVTT(bytes.NewReader(content))
+	case FormatWebexVTT:
+		return NewSubtitlesFromWebexVTT(bytes.NewReader(content))
+	case FormatZoomChat:
+		return NewSubtitlesFromZoomChat(bytes.NewReader(content))
+	case FormatGoogleMeet:
+		return NewSubtitlesFromGoogleMeet(bytes.NewReader(content))
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// NewSubtitlesFromWebexVTT parses a Webex transcript. Webex names the speaker in the cue identifier
+// and repeats the name at the start of the text, the speaker is kept as a voice tag instead.
+func NewSubtitlesFromWebexVTT(webvtt io.Reader) (*Subtitles, error) {
+	var normalized strings.Builder
+	speaker := ""
+	scanner := bufio.NewScanner(webvtt)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case webexCueIdentifier.MatchString(line):
+			match := webexCueIdentifier.FindStringSubmatch(line)
+			line = match[1]
+			speaker = strings.NewReplacer("<", "", ">", "").Replace(strings.TrimSpace(match[2]))
+		case line == "" || strings.Contains(line, "-->"):
+		case speaker != "":
+			text := strings.TrimPrefix(line, speaker+": ")
+			line = "<v " + speaker + ">" + text
+			speaker = ""
+		}
+		normalized.WriteString(line)
+		normalized.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read Webex transcript: %w", err)
+	}
+
+	return NewSubtitlesFromVTT(strings.NewReader(normalized.String()))
+}
+
+// NewSubtitlesFromGoogleMeet parses a Google Meet transcript exported from Google Docs. The transcript
+// is split into sections starting with a timestamp, the lines of each section are spread over it.
+func NewSubtitlesFromGoogleMeet(transcript io.Reader) (*Subtitles, error) {
+	type section struct {
+		start time.Duration
+		items []*astisub.Item
+	}
+
+	var sections []*section
+	var end time.Duration
+	scanner := bufio.NewScanner(transcript)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if match := googleMeetEnd.FindStringSubmatch(line); match != nil && len(sections) > 0 {
+			end, _ = ParseLLMTimestamp(match[1])
+			break
+		}
+
+		if googleMeetTimestamp.MatchString(line) {
+			start, err := ParseLLMTimestamp(line)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse Google Meet timestamp: %w", err)
+			}
+			sections = append(sections, &section{start: start})
+			continue
+		}
+
+		// Lines before the first timestamp are the title and attendees
+		if len(sections) == 0 {
+			continue
+		}
+		current := sections[len(sections)-1]
+
+		if match := googleMeetSpeakerLine.FindStringSubmatch(line); match != nil {
+			current.items = append(current.items, &astisub.Item{
+				Lines: []astisub.Line{{
+					VoiceName: strings.TrimSpace(match[1]),
+					Items:     []astisub.LineItem{{Text: strings.TrimSpace(match[2])}},
+				}},
+			})
+			continue
+		}
+
+		// A paragraph without a speaker continues the previous line
+		if len(current.items) > 0 {
+			lineItems := current.items[len(current.items)-1].Lines[0].Items
+			lineItems[0].Text += " " + line
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read Google Meet transcript: %w", err)
+	}
+
+	storage := astisub.NewSubtitles()
+	for i, section := range sections {
+		if len(section.items) == 0 {
+			continue
+		}
+
+		sectionEnd := section.start + time.Duration(len(section.items))*googleMeetItemDuration
+		if i+1 < len(sections) {
+			sectionEnd = sections[i+1].start
+		} else if end > section.start {
+			sectionEnd = end
+		}
+
+		itemDuration := (sectionEnd - section.start) / time.Duration(len(section.items))
+		for j, item := range section.items {
+			item.StartAt = section.start + time.Duration(j)*itemDuration
+			item.EndAt = item.StartAt + itemDuration
+			storage.Items = append(storage.Items, item)
+		}
+	}
+
+	return &Subtitles{storage: storage}, nil
+}
+
+func nonEmptyLines(content []byte) []string {
+	content = bytes.TrimPrefix(content, astisub.BytesBOM)
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
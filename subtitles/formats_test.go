@@ -0,0 +1,95 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package subtitles
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebexVTT = `WEBVTT
+
+1 "Jane Doe" (1234567890)
+00:00:01.000 --> 00:00:04.000
+Jane Doe: Let's get started with the release plan.
+
+2 "John Smith" (987654321)
+00:00:04.500 --> 00:00:07.000
+John Smith: The branch is cut already.
+`
+
+const testGoogleMeet = `Weekly sync - Transcript
+Attendees
+Jane Doe, John Smith
+Transcript
+00:00:00
+Jane Doe: Let's get started with the release plan.
+John Smith: The branch is cut already.
+00:00:30
+Jane Doe: Great.
+We can ship on Friday then.
+00:01:00
+John Smith: Sounds good.
+Transcription ended after 00:01:10
+`
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "WebVTT", content: testSubtitles, expected: FormatWebVTT},
+		{name: "WebVTT with byte order mark", content: "\ufeff" + testSubtitles, expected: FormatWebVTT},
+		{name: "Webex", content: testWebexVTT, expected: FormatWebexVTT},
+		{name: "Zoom chat", content: "00:00:05\tJane Doe: hello\n00:00:10\tJohn Smith: hi\n", expected: FormatZoomChat},
+		{name: "Google Meet", content: testGoogleMeet, expected: FormatGoogleMeet},
+		{name: "plain text", content: "Meeting notes\nJane Doe: hello", expected: ""},
+		{name: "empty", content: "", expected: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, DetectFormat([]byte(tc.content)))
+		})
+	}
+}
+
+func TestNewSubtitlesFromTranscript(t *testing.T) {
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := NewSubtitlesFromTranscript(strings.NewReader("Meeting notes"))
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	})
+
+	t.Run("WebVTT", func(t *testing.T) {
+		transcript, err := NewSubtitlesFromTranscript(strings.NewReader(testSubtitles))
+		require.NoError(t, err)
+		assert.Equal(t, expectedFormatForLLM, transcript.FormatForLLM())
+	})
+}
+
+func TestNewSubtitlesFromWebexVTT(t *testing.T) {
+	transcript, err := NewSubtitlesFromTranscript(strings.NewReader(testWebexVTT))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Segment{
+		{StartMS: 1000, EndMS: 4000, Speaker: "Jane Doe", Text: "Let's get started with the release plan."},
+		{StartMS: 4500, EndMS: 7000, Speaker: "John Smith", Text: "The branch is cut already."},
+	}, transcript.Segments())
+}
+
+func TestNewSubtitlesFromGoogleMeet(t *testing.T) {
+	transcript, err := NewSubtitlesFromTranscript(strings.NewReader(testGoogleMeet))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Segment{
+		{StartMS: 0, EndMS: 15000, Speaker: "Jane Doe", Text: "Let's get started with the release plan."},
+		{StartMS: 15000, EndMS: 30000, Speaker: "John Smith", Text: "The branch is cut already."},
+		{StartMS: 30000, EndMS: 60000, Speaker: "Jane Doe", Text: "Great. We can ship on Friday then."},
+		{StartMS: 60000, EndMS: 70000, Speaker: "John Smith", Text: "Sounds good."},
+	}, transcript.Segments())
+}
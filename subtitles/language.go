@@ -0,0 +1,120 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package subtitles
+
+import "strings"
+
+// languageNames maps the English names of the languages Whisper reports to their ISO-639-1 codes.
+// Languages without an ISO-639-1 code, such as Cantonese and Hawaiian, aren't mapped.
+var languageNames = map[string]string{
+	"afrikaans":      "af",
+	"albanian":       "sq",
+	"amharic":        "am",
+	"arabic":         "ar",
+	"armenian":       "hy",
+	"assamese":       "as",
+	"azerbaijani":    "az",
+	"bashkir":        "ba",
+	"basque":         "eu",
+	"belarusian":     "be",
+	"bengali":        "bn",
+	"bosnian":        "bs",
+	"breton":         "br",
+	"bulgarian":      "bg",
+	"catalan":        "ca",
+	"chinese":        "zh",
+	"croatian":       "hr",
+	"czech":          "cs",
+	"danish":         "da",
+	"dutch":          "nl",
+	"english":        "en",
+	"estonian":       "et",
+	"faroese":        "fo",
+	"finnish":        "fi",
+	"french":         "fr",
+	"galician":       "gl",
+	"georgian":       "ka",
+	"german":         "de",
+	"greek":          "el",
+	"gujarati":       "gu",
+	"haitian creole": "ht",
+	"hausa":          "ha",
+	"hebrew":         "he",
+	"hindi":          "hi",
+	"hungarian":      "hu",
+	"icelandic":      "is",
+	"indonesian":     "id",
+	"italian":        "it",
+	"japanese":       "ja",
+	"javanese":       "jv",
+	"kannada":        "kn",
+	"kazakh":         "kk",
+	"khmer":          "km",
+	"korean":         "ko",
+	"lao":            "lo",
+	"latin":          "la",
+	"latvian":        "lv",
+	"lingala":        "ln",
+	"lithuanian":     "lt",
+	"luxembourgish":  "lb",
+	"macedonian":     "mk",
+	"malagasy":       "mg",
+	"malay":          "ms",
+	"malayalam":      "ml",
+	"maltese":        "mt",
+	"maori":          "mi",
+	"marathi":        "mr",
+	"mongolian":      "mn",
+	"myanmar":        "my",
+	"nepali":         "ne",
+	"norwegian":      "no",
+	"nynorsk":        "nn",
+	"occitan":        "oc",
+	"pashto":         "ps",
+	"persian":        "fa",
+	"polish":         "pl",
+	"portuguese":     "pt",
+	"punjabi":        "pa",
+	"romanian":       "ro",
+	"russian":        "ru",
+	"sanskrit":       "sa",
+	"serbian":        "sr",
+	"shona":          "sn",
+	"sindhi":         "sd",
+	"sinhala":        "si",
+	"slovak":         "sk",
+	"slovenian":      "sl",
+	"somali":         "so",
+	"spanish":        "es",
+	"sundanese":      "su",
+	"swahili":        "sw",
+	"swedish":        "sv",
+	"tagalog":        "tl",
+	"tajik":          "tg",
+	"tamil":          "ta",
+	"tatar":          "tt",
+	"telugu":         "te",
+	"thai":           "th",
+	"tibetan":        "bo",
+	"turkish":        "tr",
+	"turkmen":        "tk",
+	"ukrainian":      "uk",
+	"urdu":           "ur",
+	"uzbek":          "uz",
+	"vietnamese":     "vi",
+	"welsh":          "cy",
+	"yiddish":        "yi",
+	"yoruba":         "yo",
+}
+
+// LanguageCode returns the ISO-639-1 code of a language given by its English name, as Whisper reports
+// it, or by a code or locale such as "de" or "de-CH". Names it doesn't know are returned in lower case.
+func LanguageCode(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if code, ok := languageNames[language]; ok {
+		return code
+	}
+	code, _, _ := strings.Cut(strings.ReplaceAll(language, "_", "-"), "-")
+	return code
+}
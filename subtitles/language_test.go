@@ -0,0 +1,33 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package subtitles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanguageCode(t *testing.T) {
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{language: "english", want: "en"},
+		{language: "German", want: "de"},
+		{language: "haitian creole", want: "ht"},
+		{language: "de", want: "de"},
+		{language: "de-CH", want: "de"},
+		{language: "pt_BR", want: "pt"},
+		{language: " EN ", want: "en"},
+		{language: "cantonese", want: "cantonese"},
+		{language: "", want: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.language, func(t *testing.T) {
+			assert.Equal(t, tc.want, LanguageCode(tc.language))
+		})
+	}
+}
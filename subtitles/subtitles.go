@@ -5,8 +5,12 @@ package subtitles
 
 import (
 	"bufio"
+	"cmp"
+	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +19,9 @@ import (
 
 type Subtitles struct {
 	storage *astisub.Subtitles
+
+	// language spoken, as reported by the transcription backend
+	language string
 }
 
 func readZoomChat(chat io.Reader) (*astisub.Subtitles, error) {
@@ -57,6 +64,22 @@ func NewSubtitlesFromVTT(webvtt io.Reader) (*Subtitles, error) {
 	return &Subtitles{storage: storage}, nil
 }
 
+// NewSubtitlesFromSegments creates subtitles from timed segments, keeping speakers as voice tags.
+func NewSubtitlesFromSegments(segments []Segment) *Subtitles {
+	storage := astisub.NewSubtitles()
+	for _, segment := range segments {
+		storage.Items = append(storage.Items, &astisub.Item{
+			StartAt: time.Duration(segment.StartMS) * time.Millisecond,
+			EndAt:   time.Duration(segment.EndMS) * time.Millisecond,
+			Lines: []astisub.Line{{
+				VoiceName: segment.Speaker,
+				Items:     []astisub.LineItem{{Text: segment.Text}},
+			}},
+		})
+	}
+	return &Subtitles{storage: storage}
+}
+
 func (s *Subtitles) WebVTT() io.Reader {
 	reader, writer := io.Pipe()
 	go func() {
@@ -73,11 +96,17 @@ func (s *Subtitles) FormatForLLM() string {
 	var result strings.Builder
 	for _, item := range s.storage.Items {
 		// Timestamps
-		result.WriteString(formatDurationForLLM(item.StartAt))
+		result.WriteString(FormatLLMTimestamp(item.StartAt))
 		result.WriteString(" to ")
-		result.WriteString(formatDurationForLLM(item.EndAt))
+		result.WriteString(FormatLLMTimestamp(item.EndAt))
 		result.WriteString(" - ")
 
+		// Speaker, when known
+		if speaker := itemSpeaker(item); speaker != "" {
+			result.WriteString(speaker)
+			result.WriteString(": ")
+		}
+
 		// Words
 		result.WriteString(item.String())
 		result.WriteString("\n")
@@ -104,11 +133,274 @@ func (s *Subtitles) FormatVTT() string {
 	return result.String()
 }
 
+// FormatSRT formats the subtitles as SubRip. Speakers are kept by prefixing their lines since SubRip has no voice tags.
+func (s *Subtitles) FormatSRT() string {
+	var result strings.Builder
+	for i, item := range s.storage.Items {
+		fmt.Fprintf(&result, "%d\n%s --> %s\n", i+1, formatDurationClock(item.StartAt, ","), formatDurationClock(item.EndAt, ","))
+		if speaker := itemSpeaker(item); speaker != "" {
+			result.WriteString(speaker)
+			result.WriteString(": ")
+		}
+		result.WriteString(item.String())
+		result.WriteString("\n\n")
+	}
+
+	return result.String()
+}
+
+// timestampedSegment is a segment with readable timestamps for the JSON format.
+type timestampedSegment struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Segment
+}
+
+// FormatJSON formats the subtitles as JSON with the language, when known, and the timestamped segments.
+func (s *Subtitles) FormatJSON() string {
+	segments := s.Segments()
+	timestamped := make([]timestampedSegment, 0, len(segments))
+	for _, segment := range segments {
+		timestamped = append(timestamped, timestampedSegment{
+			Start:   formatDurationClock(time.Duration(segment.StartMS)*time.Millisecond, "."),
+			End:     formatDurationClock(time.Duration(segment.EndMS)*time.Millisecond, "."),
+			Segment: segment,
+		})
+	}
+
+	result, err := json.MarshalIndent(struct {
+		Language string               `json:"language,omitempty"`
+		Segments []timestampedSegment `json:"segments"`
+	}{
+		Language: s.language,
+		Segments: timestamped,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error formatting JSON: %v", err)
+	}
+	return string(result)
+}
+
+// Segment is a single timed piece of a transcript.
+type Segment struct {
+	StartMS int64  `json:"start_ms"`
+	EndMS   int64  `json:"end_ms"`
+	Speaker string `json:"speaker,omitempty"`
+	Text    string `json:"text"`
+}
+
+// Segments returns the subtitles as timed segments attributed to the speaker when the source has voice tags.
+func (s *Subtitles) Segments() []Segment {
+	segments := make([]Segment, 0, len(s.storage.Items))
+	for _, item := range s.storage.Items {
+		segments = append(segments, Segment{
+			StartMS: item.StartAt.Milliseconds(),
+			EndMS:   item.EndAt.Milliseconds(),
+			Speaker: itemSpeaker(item),
+			Text:    item.String(),
+		})
+	}
+
+	return segments
+}
+
+// Speakers returns the distinct speakers of the subtitles in the order they first speak.
+func (s *Subtitles) Speakers() []string {
+	var speakers []string
+	seen := map[string]bool{}
+	for _, item := range s.storage.Items {
+		speaker := itemSpeaker(item)
+		if speaker == "" || seen[speaker] {
+			continue
+		}
+		seen[speaker] = true
+		speakers = append(speakers, speaker)
+	}
+	return speakers
+}
+
+// SpeakerStats is how much a speaker talked in the subtitles.
+type SpeakerStats struct {
+	Speaker  string
+	TalkTime time.Duration
+	Turns    int
+}
+
+// SpeakerStats returns the talk time and number of turns of each speaker, the speaker who talked the longest first.
+// Consecutive items of the same speaker are one turn, items without a speaker are skipped.
+func (s *Subtitles) SpeakerStats() []SpeakerStats {
+	var stats []SpeakerStats
+	index := map[string]int{}
+	previous := ""
+	for _, item := range s.storage.Items {
+		speaker := itemSpeaker(item)
+		if speaker == "" {
+			continue
+		}
+
+		i, ok := index[speaker]
+		if !ok {
+			i = len(stats)
+			index[speaker] = i
+			stats = append(stats, SpeakerStats{Speaker: speaker})
+		}
+		if item.EndAt > item.StartAt {
+			stats[i].TalkTime += item.EndAt - item.StartAt
+		}
+		if speaker != previous {
+			stats[i].Turns++
+		}
+		previous = speaker
+	}
+
+	// Stable so speakers who talked as long keep the order they first spoke in
+	slices.SortStableFunc(stats, func(a, b SpeakerStats) int {
+		return cmp.Compare(b.TalkTime, a.TalkTime)
+	})
+	return stats
+}
+
+// RenameSpeakers replaces speaker names, speakers not in names are kept as is.
+func (s *Subtitles) RenameSpeakers(names map[string]string) {
+	for _, item := range s.storage.Items {
+		for i := range item.Lines {
+			if name, ok := names[item.Lines[i].VoiceName]; ok && name != "" {
+				item.Lines[i].VoiceName = name
+			}
+		}
+	}
+}
+
+// Append adds the items of other to the end of the subtitles, shifted by offset.
+// It is used to join transcripts of consecutive parts of a recording.
+func (s *Subtitles) Append(other *Subtitles, offset time.Duration) {
+	if s.language == "" {
+		s.language = other.language
+	}
+	for _, item := range other.storage.Items {
+		shifted := *item
+		shifted.StartAt += offset
+		shifted.EndAt += offset
+		shifted.Lines = slices.Clone(item.Lines)
+		s.storage.Items = append(s.storage.Items, &shifted)
+	}
+}
+
+// TimeRange is a part of a transcript, such as the last 20 minutes of a meeting. Negative offsets count
+// back from the end of the transcript and an End of zero is the end of the transcript.
+type TimeRange struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// IsZero reports whether the range is the whole transcript.
+func (r TimeRange) IsZero() bool {
+	return r.Start == 0 && r.End == 0
+}
+
+// Resolve returns the range as offsets from the start of a transcript of the given duration.
+func (r TimeRange) Resolve(duration time.Duration) TimeRange {
+	resolve := func(offset time.Duration) time.Duration {
+		if offset < 0 {
+			offset += duration
+		}
+		return min(max(offset, 0), duration)
+	}
+
+	resolved := TimeRange{Start: resolve(r.Start), End: duration}
+	if r.End != 0 {
+		resolved.End = resolve(r.End)
+	}
+	return resolved
+}
+
+// String formats a resolved range with the timestamps of FormatForLLM, see ParseTimeRange.
+func (r TimeRange) String() string {
+	return FormatLLMTimestamp(r.Start) + "-" + FormatLLMTimestamp(r.End)
+}
+
+// ParseTimeRange parses a range formatted by TimeRange.String.
+func ParseTimeRange(value string) (TimeRange, error) {
+	start, end, ok := strings.Cut(value, "-")
+	if !ok {
+		return TimeRange{}, fmt.Errorf("invalid time range %q", value)
+	}
+	startOffset, err := ParseLLMTimestamp(start)
+	if err != nil {
+		return TimeRange{}, err
+	}
+	endOffset, err := ParseLLMTimestamp(end)
+	if err != nil {
+		return TimeRange{}, err
+	}
+	return TimeRange{Start: startOffset, End: endOffset}, nil
+}
+
+// Slice returns the items of the subtitles said during the range, including items overlapping its bounds.
+// Items keep their timestamps so they still match the recording.
+func (s *Subtitles) Slice(r TimeRange) *Subtitles {
+	r = r.Resolve(s.Duration())
+	storage := astisub.NewSubtitles()
+	for _, item := range s.storage.Items {
+		if item.EndAt > r.Start && item.StartAt < r.End {
+			storage.Items = append(storage.Items, item)
+		}
+	}
+	return &Subtitles{storage: storage, language: s.language}
+}
+
+func itemSpeaker(item *astisub.Item) string {
+	for _, line := range item.Lines {
+		if line.VoiceName != "" {
+			return line.VoiceName
+		}
+	}
+	return ""
+}
+
+// Language returns the language spoken as reported by the transcription backend, empty when unknown.
+func (s *Subtitles) Language() string {
+	return s.language
+}
+
+// SetLanguage sets the language spoken.
+func (s *Subtitles) SetLanguage(language string) {
+	s.language = language
+}
+
 func (s *Subtitles) IsEmpty() bool {
 	return s.storage.IsEmpty()
 }
 
-func formatDurationForLLM(dur time.Duration) string {
+// Duration returns the end time of the last subtitle.
+func (s *Subtitles) Duration() time.Duration {
+	if len(s.storage.Items) == 0 {
+		return 0
+	}
+	return s.storage.Items[len(s.storage.Items)-1].EndAt
+}
+
+// ParseLLMTimestamp parses a timestamp in the MM:SS or HH:MM:SS format used by FormatForLLM.
+func ParseLLMTimestamp(timestamp string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(timestamp), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+
+	var dur time.Duration
+	for _, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid timestamp %q", timestamp)
+		}
+		dur = dur*60 + time.Duration(value)
+	}
+
+	return dur * time.Second, nil
+}
+
+// FormatLLMTimestamp formats a duration in the MM:SS or HH:MM:SS format used by FormatForLLM.
+func FormatLLMTimestamp(dur time.Duration) string {
 	dur = dur.Round(time.Second)
 	hours := dur / time.Hour
 	minutes := (dur - hours*time.Hour) / time.Minute
@@ -120,3 +412,13 @@ func formatDurationForLLM(dur time.Duration) string {
 
 	return fmt.Sprintf("%02d:%02d:%02d", int(hours), int(minutes), int(seconds))
 }
+
+// formatDurationClock formats the duration as HH:MM:SS followed by the milliseconds after the separator.
+func formatDurationClock(dur time.Duration, millisecondsSeparator string) string {
+	hours := dur / time.Hour
+	minutes := (dur - hours*time.Hour) / time.Minute
+	seconds := (dur - hours*time.Hour - minutes*time.Minute) / time.Second
+	milliseconds := (dur - hours*time.Hour - minutes*time.Minute - seconds*time.Second) / time.Millisecond
+
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", int(hours), int(minutes), int(seconds), millisecondsSeparator, int(milliseconds))
+}
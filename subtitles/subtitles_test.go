@@ -6,6 +6,7 @@ package subtitles
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -53,3 +54,219 @@ func TestFormatTextOnly(t *testing.T) {
 
 	require.Equal(t, expectedFormatTextOnly, subtitles.FormatTextOnly())
 }
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		vtt      string
+		expected []Segment
+	}{
+		{
+			name: "without speakers",
+			vtt:  testSubtitles,
+			expected: []Segment{
+				{StartMS: 0, EndMS: 5600, Text: "But just with a variety of reasons, what I have is a pull request. And so I'd like to"},
+				{StartMS: 6320, EndMS: 9840, Text: "simultaneously go back and just, you know, solicit that feedback in case there's some"},
+				{StartMS: 9840, EndMS: 14560, Text: "blind spots here. Obviously, if there are, we need to fix them. That's great. But also to,"},
+				{StartMS: 15600, EndMS: 20480, Text: "if there isn't, but also to communicate some of the changes happening around prepackaged plugins."},
+			},
+		},
+		{
+			name: "with speakers",
+			vtt: `WEBVTT
+
+1
+00:00:01.000 --> 00:00:02.500
+<v Alice>Hello everyone
+
+2
+00:00:03.000 --> 00:00:04.000
+<v Bob>Hi Alice
+`,
+			expected: []Segment{
+				{StartMS: 1000, EndMS: 2500, Speaker: "Alice", Text: "Hello everyone"},
+				{StartMS: 3000, EndMS: 4000, Speaker: "Bob", Text: "Hi Alice"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			subtitles, err := NewSubtitlesFromVTT(strings.NewReader(tc.vtt))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, subtitles.Segments())
+		})
+	}
+}
+
+func TestNewSubtitlesFromSegments(t *testing.T) {
+	segments := []Segment{
+		{StartMS: 1000, EndMS: 2500, Speaker: "Speaker 1", Text: "Hello everyone"},
+		{StartMS: 3000, EndMS: 4000, Text: "Hi"},
+	}
+
+	subtitles := NewSubtitlesFromSegments(segments)
+	require.Equal(t, segments, subtitles.Segments())
+
+	// Speakers survive a round trip through WebVTT
+	fromVTT, err := NewSubtitlesFromVTT(strings.NewReader(subtitles.FormatVTT()))
+	require.NoError(t, err)
+	require.Equal(t, segments, fromVTT.Segments())
+}
+
+func TestFormatSRT(t *testing.T) {
+	subtitles := NewSubtitlesFromSegments([]Segment{
+		{StartMS: 1000, EndMS: 2500, Speaker: "Alice", Text: "Hello everyone"},
+		{StartMS: 3723004, EndMS: 3725000, Text: "Hi"},
+	})
+
+	require.Equal(t, "1\n00:00:01,000 --> 00:00:02,500\nAlice: Hello everyone\n\n2\n01:02:03,004 --> 01:02:05,000\nHi\n\n", subtitles.FormatSRT())
+}
+
+func TestFormatJSON(t *testing.T) {
+	subtitles := NewSubtitlesFromSegments([]Segment{
+		{StartMS: 1000, EndMS: 2500, Speaker: "Alice", Text: "Hello everyone"},
+		{StartMS: 3000, EndMS: 4000, Text: "Hi"},
+	})
+	subtitles.SetLanguage("en")
+
+	require.JSONEq(t, `{
+		"language": "en",
+		"segments": [
+			{"start": "00:00:01.000", "end": "00:00:02.500", "start_ms": 1000, "end_ms": 2500, "speaker": "Alice", "text": "Hello everyone"},
+			{"start": "00:00:03.000", "end": "00:00:04.000", "start_ms": 3000, "end_ms": 4000, "text": "Hi"}
+		]
+	}`, subtitles.FormatJSON())
+}
+
+func TestRenameSpeakers(t *testing.T) {
+	subtitles := NewSubtitlesFromSegments([]Segment{
+		{StartMS: 1000, EndMS: 2000, Speaker: "Speaker 2", Text: "Hello everyone"},
+		{StartMS: 3000, EndMS: 4000, Speaker: "Speaker 1", Text: "Hi"},
+		{StartMS: 5000, EndMS: 6000, Speaker: "Speaker 2", Text: "Let's start"},
+		{StartMS: 7000, EndMS: 8000, Text: "Unattributed"},
+	})
+	require.Equal(t, []string{"Speaker 2", "Speaker 1"}, subtitles.Speakers())
+
+	subtitles.RenameSpeakers(map[string]string{"Speaker 2": "alice", "Speaker 3": "bob"})
+	require.Equal(t, []string{"alice", "Speaker 1"}, subtitles.Speakers())
+	require.Equal(t, "00:01 to 00:02 - alice: Hello everyone\n00:03 to 00:04 - Speaker 1: Hi\n00:05 to 00:06 - alice: Let's start\n00:07 to 00:08 - Unattributed", subtitles.FormatForLLM())
+}
+
+func TestSpeakerStats(t *testing.T) {
+	subtitles := NewSubtitlesFromSegments([]Segment{
+		{StartMS: 0, EndMS: 2000, Speaker: "alice", Text: "Hello everyone"},
+		{StartMS: 2000, EndMS: 5000, Speaker: "alice", Text: "Let's start with the roadmap"},
+		{StartMS: 5000, EndMS: 6000, Speaker: "bob", Text: "Sounds good"},
+		{StartMS: 6000, EndMS: 7000, Text: "Unattributed"},
+		{StartMS: 7000, EndMS: 12000, Speaker: "carol", Text: "I have an update on pricing"},
+		{StartMS: 12000, EndMS: 13000, Speaker: "bob", Text: "Thanks"},
+		{StartMS: 13000, EndMS: 14000, Speaker: "alice", Text: "Next topic"},
+	})
+
+	require.Equal(t, []SpeakerStats{
+		{Speaker: "alice", TalkTime: 6 * time.Second, Turns: 2},
+		{Speaker: "carol", TalkTime: 5 * time.Second, Turns: 1},
+		{Speaker: "bob", TalkTime: 2 * time.Second, Turns: 2},
+	}, subtitles.SpeakerStats())
+
+	require.Empty(t, NewSubtitlesFromSegments([]Segment{{StartMS: 0, EndMS: 1000, Text: "No speakers"}}).SpeakerStats())
+}
+
+func TestAppend(t *testing.T) {
+	first := NewSubtitlesFromSegments([]Segment{
+		{StartMS: 0, EndMS: 2000, Text: "Welcome"},
+	})
+	second := NewSubtitlesFromSegments([]Segment{
+		{StartMS: 0, EndMS: 1500, Text: "Next topic"},
+		{StartMS: 1500, EndMS: 3000, Text: "Thanks"},
+	})
+
+	first.Append(second, 10*time.Minute)
+
+	require.Equal(t, []Segment{
+		{StartMS: 0, EndMS: 2000, Text: "Welcome"},
+		{StartMS: 600000, EndMS: 601500, Text: "Next topic"},
+		{StartMS: 601500, EndMS: 603000, Text: "Thanks"},
+	}, first.Segments())
+	require.Equal(t, []Segment{
+		{StartMS: 0, EndMS: 1500, Text: "Next topic"},
+		{StartMS: 1500, EndMS: 3000, Text: "Thanks"},
+	}, second.Segments())
+	require.Equal(t, 603*time.Second, first.Duration())
+}
+
+func TestSlice(t *testing.T) {
+	subtitles := NewSubtitlesFromSegments([]Segment{
+		{StartMS: 0, EndMS: 60000, Text: "Introductions"},
+		{StartMS: 60000, EndMS: 600000, Text: "Roadmap"},
+		{StartMS: 600000, EndMS: 1200000, Text: "Hiring"},
+		{StartMS: 1200000, EndMS: 1500000, Text: "Questions"},
+	})
+
+	tests := []struct {
+		name      string
+		timeRange TimeRange
+		want      []string
+	}{
+		{name: "whole transcript", timeRange: TimeRange{}, want: []string{"Introductions", "Roadmap", "Hiring", "Questions"}},
+		{name: "from an offset to the end", timeRange: TimeRange{Start: 20 * time.Minute}, want: []string{"Questions"}},
+		{name: "between offsets", timeRange: TimeRange{Start: 5 * time.Minute, End: 10 * time.Minute}, want: []string{"Roadmap"}},
+		{name: "overlapping items", timeRange: TimeRange{Start: 9 * time.Minute, End: 11 * time.Minute}, want: []string{"Roadmap", "Hiring"}},
+		{name: "last minutes", timeRange: TimeRange{Start: -15 * time.Minute}, want: []string{"Hiring", "Questions"}},
+		{name: "end counted back from the end", timeRange: TimeRange{End: -15 * time.Minute}, want: []string{"Introductions", "Roadmap"}},
+		{name: "past the end", timeRange: TimeRange{Start: time.Hour}, want: []string{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			texts := []string{}
+			for _, segment := range subtitles.Slice(tc.timeRange).Segments() {
+				texts = append(texts, segment.Text)
+			}
+			require.Equal(t, tc.want, texts)
+		})
+	}
+}
+
+func TestTimeRange(t *testing.T) {
+	resolved := TimeRange{Start: -20 * time.Minute}.Resolve(time.Hour)
+	require.Equal(t, TimeRange{Start: 40 * time.Minute, End: time.Hour}, resolved)
+	require.Equal(t, "40:00-01:00:00", resolved.String())
+
+	parsed, err := ParseTimeRange(resolved.String())
+	require.NoError(t, err)
+	require.Equal(t, resolved, parsed)
+
+	_, err = ParseTimeRange("40:00")
+	require.Error(t, err)
+	require.True(t, TimeRange{}.IsZero())
+}
+
+func TestParseLLMTimestamp(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp string
+		want      time.Duration
+		wantErr   bool
+	}{
+		{name: "minutes and seconds", timestamp: "05:30", want: 5*time.Minute + 30*time.Second},
+		{name: "hours", timestamp: "01:02:03", want: time.Hour + 2*time.Minute + 3*time.Second},
+		{name: "surrounding whitespace", timestamp: " 00:10 ", want: 10 * time.Second},
+		{name: "seconds only", timestamp: "10", wantErr: true},
+		{name: "not a number", timestamp: "aa:10", wantErr: true},
+		{name: "negative", timestamp: "-1:10", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseLLMTimestamp(tc.timestamp)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
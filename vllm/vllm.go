@@ -0,0 +1,307 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package vllm provides a language model backed by a pool of self-hosted vLLM servers.
+// Each replica is a vLLM OpenAI-compatible server, usually serving a model split over several GPUs
+// with tensor parallelism, and requests are balanced between the healthy replicas serving the model.
+package vllm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/openai"
+	openaiClient "github.com/sashabaranov/go-openai"
+)
+
+const (
+	DefaultHealthCheckInterval = 30 * time.Second
+	healthCheckTimeout         = 5 * time.Second
+)
+
+// ErrNoReplica is returned when no healthy replica of the pool serves the requested model.
+var ErrNoReplica = errors.New("no healthy vLLM replica serves the model")
+
+type Config struct {
+	// OpenAI is the configuration shared by all replicas, its API URL is ignored
+	OpenAI openai.Config
+
+	// ReplicaURLs are the base URLs of the OpenAI-compatible API of each replica, such as http://gpu-1:8000/v1
+	ReplicaURLs []string
+
+	HealthCheckInterval time.Duration
+}
+
+type replica struct {
+	url   string
+	model *openai.OpenAI
+
+	// inFlight counts the requests being served, new requests go to the least busy replica
+	inFlight atomic.Int64
+
+	mu        sync.Mutex
+	healthy   bool
+	models    map[string]bool
+	checkedAt time.Time
+	checking  bool
+}
+
+// Pool is a language model that balances requests between vLLM replicas.
+type Pool struct {
+	config     Config
+	replicas   []*replica
+	httpClient *http.Client
+
+	// next rotates the order replicas are considered in, so equally busy replicas share the load
+	next atomic.Uint64
+}
+
+func New(config Config, httpClient *http.Client) *Pool {
+	if config.HealthCheckInterval <= 0 {
+		config.HealthCheckInterval = DefaultHealthCheckInterval
+	}
+
+	pool := &Pool{
+		config:     config,
+		httpClient: httpClient,
+	}
+	for _, url := range config.ReplicaURLs {
+		replicaConfig := config.OpenAI
+		replicaConfig.APIURL = url
+		pool.replicas = append(pool.replicas, &replica{
+			url:   strings.TrimSuffix(url, "/"),
+			model: openai.NewCompatible(replicaConfig, httpClient),
+			// Replicas are assumed healthy until checked, so the first requests aren't refused
+			healthy: true,
+		})
+	}
+
+	return pool
+}
+
+// serves returns true if the replica can be sent requests for the model. Models are pinned to the
+// replicas that list them, replicas that haven't been checked yet are assumed to serve any model.
+func (r *replica) serves(model string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.models == nil || r.models[model]
+}
+
+func (r *replica) isHealthy() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.healthy
+}
+
+func (r *replica) markUnhealthy() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.healthy = false
+}
+
+// checkHealthIfStale starts a health check of the replica in the background when the last one is
+// older than the interval. Checks are done on demand so the pool doesn't need to be stopped.
+func (p *Pool) checkHealthIfStale(r *replica) {
+	r.mu.Lock()
+	if r.checking || time.Since(r.checkedAt) < p.config.HealthCheckInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.checking = true
+	r.mu.Unlock()
+
+	go func() {
+		models, err := p.listModels(r.url)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.checking = false
+		r.checkedAt = time.Now()
+		r.healthy = err == nil
+		if err == nil {
+			r.models = models
+		}
+	}()
+}
+
+// listModels returns the models a replica serves, which also tells whether it is up.
+func (p *Pool) listModels(url string) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.config.OpenAI.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.OpenAI.APIKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach replica %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("replica %s returned status %d", url, resp.StatusCode)
+	}
+
+	var list openaiClient.ModelsList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("unable to decode models of replica %s: %w", url, err)
+	}
+
+	models := make(map[string]bool, len(list.Models))
+	for _, model := range list.Models {
+		models[model.ID] = true
+	}
+	return models, nil
+}
+
+// candidates returns the replicas a request for the model can be sent to, in the order they should
+// be tried: the least busy healthy replicas first, then the unhealthy ones in case they recovered.
+func (p *Pool) candidates(model string) ([]*replica, error) {
+	if len(p.replicas) == 0 {
+		return nil, fmt.Errorf("%w: no replicas are configured", ErrNoReplica)
+	}
+
+	start := int(p.next.Add(1))
+	var healthy, unhealthy []*replica
+	for i := range p.replicas {
+		r := p.replicas[(start+i)%len(p.replicas)]
+		p.checkHealthIfStale(r)
+		if !r.serves(model) {
+			continue
+		}
+		if r.isHealthy() {
+			healthy = append(healthy, r)
+		} else {
+			unhealthy = append(unhealthy, r)
+		}
+	}
+
+	if len(healthy) == 0 && len(unhealthy) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNoReplica, model)
+	}
+
+	sort.SliceStable(healthy, func(i, j int) bool {
+		return healthy[i].inFlight.Load() < healthy[j].inFlight.Load()
+	})
+
+	return append(healthy, unhealthy...), nil
+}
+
+// isReplicaFailure returns true if an error means the replica couldn't serve the request, rather
+// than the request being rejected, so it is worth retrying on another replica.
+func isReplicaFailure(err error) bool {
+	var apiErr *openaiClient.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode == 0 || apiErr.HTTPStatusCode >= http.StatusInternalServerError
+	}
+	var requestErr *openaiClient.RequestError
+	if errors.As(err, &requestErr) {
+		return requestErr.HTTPStatusCode == 0 || requestErr.HTTPStatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
+
+func (p *Pool) requestedModel(opts []llm.LanguageModelOption) string {
+	cfg := llm.LanguageModelConfig{Model: p.config.OpenAI.DefaultModel}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg.Model
+}
+
+// ChatCompletion streams the completion from the least busy replica serving the model. When a replica
+// fails before streaming anything the request is retried on the next one and the replica is skipped
+// until its next successful health check.
+func (p *Pool) ChatCompletion(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+	replicas, err := p.candidates(p.requestedModel(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	output := make(chan llm.TextStreamEvent)
+	go func() {
+		defer close(output)
+
+		var lastErr error
+		for _, r := range replicas {
+			failed, err := streamFromReplica(r, request, opts, output)
+			if !failed {
+				return
+			}
+			r.markUnhealthy()
+			lastErr = err
+		}
+
+		output <- llm.TextStreamEvent{
+			Type:  llm.EventTypeError,
+			Value: fmt.Errorf("all vLLM replicas failed: %w", lastErr),
+		}
+	}()
+
+	return &llm.TextStreamResult{Stream: output}, nil
+}
+
+// streamFromReplica forwards the completion of a replica to the output. It returns true with the error
+// if the replica failed before anything was forwarded, so the request can be retried elsewhere.
+func streamFromReplica(r *replica, request llm.CompletionRequest, opts []llm.LanguageModelOption, output chan<- llm.TextStreamEvent) (bool, error) {
+	r.inFlight.Add(1)
+	defer r.inFlight.Add(-1)
+
+	result, err := r.model.ChatCompletion(request, opts...)
+	if err != nil {
+		return true, err
+	}
+
+	forwarded := false
+	for event := range result.Stream {
+		if !forwarded && event.Type == llm.EventTypeError {
+			if err, ok := event.Value.(error); ok && isReplicaFailure(err) {
+				// Drain the rest of the stream so the replica's goroutine can finish
+				go func() {
+					for range result.Stream {
+					}
+				}()
+				return true, err
+			}
+		}
+		forwarded = true
+		output <- event
+	}
+
+	return false, nil
+}
+
+func (p *Pool) ChatCompletionNoStream(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
+	result, err := p.ChatCompletion(request, opts...)
+	if err != nil {
+		return "", err
+	}
+	return result.ReadAll()
+}
+
+func (p *Pool) CountTokens(text string) int {
+	if len(p.replicas) == 0 {
+		return 0
+	}
+	return p.replicas[0].model.CountTokens(text)
+}
+
+func (p *Pool) InputTokenLimit() int {
+	if len(p.replicas) == 0 {
+		return 0
+	}
+	return p.replicas[0].model.InputTokenLimit()
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package vllm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    string
+		models   []map[string]bool
+		healthy  []bool
+		inFlight []int64
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "least busy first",
+			model:    "llama",
+			models:   []map[string]bool{nil, nil, nil},
+			healthy:  []bool{true, true, true},
+			inFlight: []int64{3, 1, 2},
+			expected: []string{"http://b", "http://c", "http://a"},
+		},
+		{
+			name:     "pinned to the replicas serving the model",
+			model:    "llama",
+			models:   []map[string]bool{{"llama": true}, {"mistral": true}, {"llama": true}},
+			healthy:  []bool{true, true, true},
+			inFlight: []int64{1, 0, 0},
+			expected: []string{"http://c", "http://a"},
+		},
+		{
+			name:     "unhealthy replicas last",
+			model:    "llama",
+			models:   []map[string]bool{nil, nil, nil},
+			healthy:  []bool{false, true, true},
+			inFlight: []int64{0, 2, 1},
+			expected: []string{"http://c", "http://b", "http://a"},
+		},
+		{
+			name:     "model not served",
+			model:    "qwen",
+			models:   []map[string]bool{{"llama": true}, {"llama": true}, {"mistral": true}},
+			healthy:  []bool{true, true, true},
+			inFlight: []int64{0, 0, 0},
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := New(Config{ReplicaURLs: []string{"http://a", "http://b", "http://c"}}, http.DefaultClient)
+			for i, r := range pool.replicas {
+				r.models = tc.models[i]
+				r.healthy = tc.healthy[i]
+				r.inFlight.Store(tc.inFlight[i])
+				// Skip the health checks so the state set by the test is kept
+				r.checkedAt = time.Now()
+			}
+
+			replicas, err := pool.candidates(tc.model)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrNoReplica)
+				return
+			}
+			require.NoError(t, err)
+
+			urls := make([]string, 0, len(replicas))
+			for _, r := range replicas {
+				urls = append(urls, r.url)
+			}
+			assert.Equal(t, tc.expected, urls)
+		})
+	}
+}
+
+// newReplicaServer starts a fake vLLM server answering completions with the status given,
+// streaming the text when the status is OK.
+func newReplicaServer(t *testing.T, status int, text string, completions *atomic.Int64) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"object":"list","data":[{"id":"llama","object":"model"}]}`)
+		case "/v1/chat/completions":
+			completions.Add(1)
+			if status != http.StatusOK {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				fmt.Fprint(w, `{"error":{"message":"replica error","type":"error"}}`)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", text)
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChatCompletionFailover(t *testing.T) {
+	tests := []struct {
+		name                string
+		firstStatus         int
+		expected            string
+		wantErr             bool
+		expectedSecondCalls int64
+	}{
+		{
+			name:                "replica failure is retried on the next replica",
+			firstStatus:         http.StatusServiceUnavailable,
+			expected:            "from second",
+			expectedSecondCalls: 1,
+		},
+		{
+			name:                "rejected request is not retried",
+			firstStatus:         http.StatusBadRequest,
+			wantErr:             true,
+			expectedSecondCalls: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var firstCalls, secondCalls atomic.Int64
+			first := newReplicaServer(t, tc.firstStatus, "from first", &firstCalls)
+			second := newReplicaServer(t, http.StatusOK, "from second", &secondCalls)
+
+			pool := New(Config{
+				OpenAI:      openai.Config{DefaultModel: "llama", StreamingTimeout: 10 * time.Second},
+				ReplicaURLs: []string{first.URL + "/v1", second.URL + "/v1"},
+			}, http.DefaultClient)
+			// Send the request to the first replica first
+			pool.replicas[1].inFlight.Store(1)
+			for _, r := range pool.replicas {
+				r.checkedAt = time.Now()
+			}
+
+			result, err := pool.ChatCompletionNoStream(llm.CompletionRequest{
+				Posts:   []llm.Post{{Role: llm.PostRoleUser, Message: "hello"}},
+				Context: llm.NewContext(),
+			})
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, result)
+				assert.False(t, pool.replicas[0].isHealthy())
+			}
+			assert.Equal(t, int64(1), firstCalls.Load())
+			assert.Equal(t, tc.expectedSecondCalls, secondCalls.Load())
+		})
+	}
+}
@@ -24,6 +24,7 @@ export interface LLMBot {
     userAccessLevel: UserAccessLevel;
     userIDs: string[];
     teamIDs: string[];
+    handoffEnabled?: boolean;
 }
 
 const defaultBotLocalStorageKey = 'defaultBot';
@@ -51,6 +52,11 @@ export const useBotlist = () => {
                 type: 'SET_SEARCH_ENABLED',
                 searchEnabled: response.searchEnabled,
             });
+
+            dispatch({
+                type: 'SET_MEMORY_ENABLED',
+                memoryEnabled: response.memoryEnabled,
+            });
         };
         if (!bots) {
             fetchBots();
@@ -7,9 +7,32 @@ import {ChannelWithTeamData} from '@mattermost/types/channels';
 import {NotPagedTeamSearchOpts, Team} from '@mattermost/types/teams';
 
 import manifest from './manifest';
+import {ToolCall} from './components/llmbot_post';
 
 const Client4 = new Client4Class();
 
+// Expected usage and cost of an operation, the cost is in US dollars and only set when price_known is true
+export type CostEstimate = {
+    input_tokens: number;
+    output_tokens: number;
+    audio_minutes?: number;
+    cost: number;
+    price_known: boolean;
+};
+
+export type EmbeddingModel = {
+    provider: string;
+    model: string;
+    dimensions: number;
+};
+
+// Whether the search index was built with the configured embedding model, indexed is null when unknown
+export type IndexedModelStatus = {
+    indexed: EmbeddingModel | null;
+    configured: EmbeddingModel;
+    stale: boolean;
+};
+
 function baseRoute(): string {
     return `/plugins/${manifest.id}`;
 }
@@ -59,8 +82,45 @@ export async function doThreadAnalysis(postid: string, analysisType: string, bot
     });
 }
 
-export async function doTranscribe(postid: string, fileID: string) {
-    const url = `${postRoute(postid)}/transcribe/file/${fileID}`;
+export async function doAskAboutPost(postid: string, question: string, botUsername: string): Promise<{postid: string, channelid: string}> {
+    return doJSONRequest(`${postRoute(postid)}/ask?botUsername=${botUsername}`, 'POST', {question});
+}
+
+export async function doPrivateAsk(channelID: string, question: string, botUsername: string, rootID = ''): Promise<{postid: string, channelid: string}> {
+    return doJSONRequest(`${channelRoute(channelID)}/ask?botUsername=${botUsername}`, 'POST', {question, root_id: rootID});
+}
+
+export async function getComposeSuggestions(channelID: string, draft: string, botUsername: string, rootID = ''): Promise<{suggestions: string[]}> {
+    return doJSONRequest(`${channelRoute(channelID)}/compose?botUsername=${botUsername}`, 'POST', {draft, root_id: rootID});
+}
+
+export type RewriteOptions = {
+    action: 'grammar' | 'tone' | 'shorten' | 'translate'
+    tone?: 'formal' | 'friendly' | 'confident' | 'empathetic'
+    language?: string
+}
+
+export async function doRewriteDraft(channelID: string, message: string, options: RewriteOptions, botUsername: string): Promise<{message: string}> {
+    return doJSONRequest(`${channelRoute(channelID)}/rewrite?botUsername=${botUsername}`, 'POST', {...options, message});
+}
+
+export async function doRewritePost(postid: string, options: RewriteOptions, botUsername: string): Promise<{message: string}> {
+    return doJSONRequest(`${postRoute(postid)}/rewrite?botUsername=${botUsername}`, 'POST', options);
+}
+
+export async function doTranscribe(postid: string, fileID: string, language?: string, translate?: boolean, format?: string) {
+    const params = new URLSearchParams();
+    if (language) {
+        params.set('language', language);
+    }
+    if (translate) {
+        params.set('translate', 'true');
+    }
+    if (format) {
+        params.set('format', format);
+    }
+    const query = params.toString();
+    const url = `${postRoute(postid)}/transcribe/file/${fileID}${query ? `?${query}` : ''}`;
     const response = await fetch(url, Client4.getOptions({
         method: 'POST',
     }));
@@ -76,10 +136,23 @@ export async function doTranscribe(postid: string, fileID: string) {
     });
 }
 
-export async function doSummarizeTranscription(postid: string) {
-    const url = `${postRoute(postid)}/summarize_transcription`;
+// doTranscribeFiles summarizes a meeting recorded in several files of the post as one meeting, in the order given
+export async function doTranscribeFiles(postid: string, fileIDs: string[], language?: string, translate?: boolean, format?: string) {
+    const params = new URLSearchParams();
+    if (language) {
+        params.set('language', language);
+    }
+    if (translate) {
+        params.set('translate', 'true');
+    }
+    if (format) {
+        params.set('format', format);
+    }
+    const query = params.toString();
+    const url = `${postRoute(postid)}/transcribe/files${query ? `?${query}` : ''}`;
     const response = await fetch(url, Client4.getOptions({
         method: 'POST',
+        body: JSON.stringify({file_ids: fileIDs}),
     }));
 
     if (response.ok) {
@@ -93,14 +166,14 @@ export async function doSummarizeTranscription(postid: string) {
     });
 }
 
-export async function doStopGenerating(postid: string) {
-    const url = `${postRoute(postid)}/stop`;
+export async function getTranscribeEstimate(postid: string, fileID: string): Promise<CostEstimate> {
+    const url = `${postRoute(postid)}/transcribe/file/${fileID}/estimate`;
     const response = await fetch(url, Client4.getOptions({
-        method: 'POST',
+        method: 'GET',
     }));
 
     if (response.ok) {
-        return;
+        return response.json();
     }
 
     throw new ClientError(Client4.url, {
@@ -110,14 +183,58 @@ export async function doStopGenerating(postid: string) {
     });
 }
 
-export async function doRegenerate(postid: string) {
-    const url = `${postRoute(postid)}/regenerate`;
+// SummaryTemplate is a format meeting summaries can be requested in, its ID is passed as the summary format
+export type SummaryTemplate = {
+    id: string;
+    name: string;
+    custom: boolean;
+};
+
+export async function getSummaryTemplates(): Promise<SummaryTemplate[]> {
+    const url = `${baseRoute()}/summary_templates`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'GET',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+// TranscriptRange is the part of a transcript to summarize, in seconds. Negative offsets count back from the end.
+export type TranscriptRange = {
+    start?: number;
+    end?: number;
+};
+
+export async function doSummarizeTranscription(postid: string, translate?: boolean, format?: string, range?: TranscriptRange) {
+    const params = new URLSearchParams();
+    if (translate) {
+        params.set('translate', 'true');
+    }
+    if (format) {
+        params.set('format', format);
+    }
+    if (range?.start) {
+        params.set('start', String(range.start));
+    }
+    if (range?.end) {
+        params.set('end', String(range.end));
+    }
+    const query = params.toString();
+    const url = `${postRoute(postid)}/summarize_transcription${query ? `?${query}` : ''}`;
     const response = await fetch(url, Client4.getOptions({
         method: 'POST',
     }));
 
     if (response.ok) {
-        return;
+        return response.json();
     }
 
     throw new ClientError(Client4.url, {
@@ -127,13 +244,10 @@ export async function doRegenerate(postid: string) {
     });
 }
 
-export async function doToolCall(postid: string, toolIDs: string[]) {
-    const url = `${postRoute(postid)}/tool_call`;
+export async function doStopGenerating(postid: string) {
+    const url = `${postRoute(postid)}/stop`;
     const response = await fetch(url, Client4.getOptions({
         method: 'POST',
-        body: JSON.stringify({
-            accepted_tool_ids: toolIDs,
-        }),
     }));
 
     if (response.ok) {
@@ -147,10 +261,17 @@ export async function doToolCall(postid: string, toolIDs: string[]) {
     });
 }
 
-export async function doPostbackSummary(postid: string) {
-    const url = `${postRoute(postid)}/postback_summary`;
+export type StreamState = {
+    generating: boolean;
+    message: string;
+    seq: number;
+};
+
+// getStreamState returns the message of a post as streamed so far, to resume after missing stream events
+export async function getStreamState(postid: string): Promise<StreamState> {
+    const url = `${postRoute(postid)}/stream`;
     const response = await fetch(url, Client4.getOptions({
-        method: 'POST',
+        method: 'GET',
     }));
 
     if (response.ok) {
@@ -164,25 +285,34 @@ export async function doPostbackSummary(postid: string) {
     });
 }
 
-export async function viewMyChannel(channelID: string) {
-    return Client4.viewMyChannel(channelID);
+// Sends a follow-up question suggested after a bot response as the user's next message in the thread
+export async function doSendFollowUp(channelID: string, rootID: string, message: string) {
+    return Client4.createPost({channel_id: channelID, root_id: rootID, message} as any);
 }
 
-export async function getAIDirectChannel(currentUserId: string) {
-    const botUser = await Client4.getUserByUsername('ai');
-    const dm = await Client4.createDirectChannel([currentUserId, botUser.id]);
-    return dm.id;
-}
+export async function doRegenerate(postid: string, instruction?: string) {
+    const url = `${postRoute(postid)}/regenerate`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: instruction ? JSON.stringify({instruction}) : undefined,
+    }));
 
-export async function getBotDirectChannel(currentUserId: string, botUserID: string) {
-    const dm = await Client4.createDirectChannel([currentUserId, botUserID]);
-    return dm.id;
+    if (response.ok) {
+        return;
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
 }
 
-export async function getAIThreads() {
-    const url = `${baseRoute()}/ai_threads`;
+// doResummarize summarizes the transcript of a meeting summary again with another bot, in a new conversation with it
+export async function doResummarize(postid: string, botUsername: string) {
+    const url = `${postRoute(postid)}/resummarize?botUsername=${encodeURIComponent(botUsername)}`;
     const response = await fetch(url, Client4.getOptions({
-        method: 'GET',
+        method: 'POST',
     }));
 
     if (response.ok) {
@@ -196,14 +326,17 @@ export async function getAIThreads() {
     });
 }
 
-export async function getAIBots() {
-    const url = `${baseRoute()}/ai_bots`;
+export async function doRefineSummary(postid: string, refinement: string) {
+    const url = `${postRoute(postid)}/refine`;
     const response = await fetch(url, Client4.getOptions({
-        method: 'GET',
+        method: 'POST',
+        body: JSON.stringify({
+            refinement,
+        }),
     }));
 
     if (response.ok) {
-        return response.json();
+        return;
     }
 
     throw new ClientError(Client4.url, {
@@ -213,40 +346,61 @@ export async function getAIBots() {
     });
 }
 
-export async function createPost(post: any) {
-    const created = await Client4.createPost(post);
-    return created;
-}
+export async function doToolCall(postid: string, toolIDs: string[]) {
+    const url = `${postRoute(postid)}/tool_call`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: JSON.stringify({
+            accepted_tool_ids: toolIDs,
+        }),
+    }));
 
-export async function updateRead(userId: string, teamId: string, selectedPostId: string, timestamp: number) {
-    Client4.updateThreadReadForUser(userId, teamId, selectedPostId, timestamp);
-}
+    if (response.ok) {
+        return;
+    }
 
-export function getProfilePictureUrl(userId: string, lastIconUpdate: number) {
-    return Client4.getProfilePictureUrl(userId, lastIconUpdate);
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
 }
 
-export async function getBotProfilePictureUrl(username: string) {
-    const user = await Client4.getUserByUsername(username);
-    if (!user || user.id === '') {
-        return '';
+export type ToolApprovalRequest = {
+    post_id: string;
+    channel_id: string;
+    requester_user_id: string;
+    tool_calls: ToolCall[];
+};
+
+export async function getToolApproval(postid: string): Promise<ToolApprovalRequest> {
+    const url = `${baseRoute()}/tool_approval/${postid}`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'GET',
+    }));
+
+    if (response.ok) {
+        return response.json();
     }
-    return getProfilePictureUrl(user.id, user.last_picture_update);
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
 }
 
-export async function doRunSearch(query: string, teamId: string, channelId: string, botUsername?: string) {
-    const url = `${baseRoute()}/search/run${botUsername ? `?botUsername=${botUsername}` : ''}`;
+export async function doToolApproval(postid: string, toolIDs: string[]) {
+    const url = `${baseRoute()}/tool_approval/${postid}`;
     const response = await fetch(url, Client4.getOptions({
         method: 'POST',
         body: JSON.stringify({
-            query,
-            teamId,
-            channelId,
+            accepted_tool_ids: toolIDs,
         }),
     }));
 
     if (response.ok) {
-        return response.json();
+        return;
     }
 
     throw new ClientError(Client4.url, {
@@ -256,74 +410,82 @@ export async function doRunSearch(query: string, teamId: string, channelId: stri
     });
 }
 
-export async function setUserProfilePictureByUsername(username: string, file: File) {
-    const user = await Client4.getUserByUsername(username);
-    if (!user || user.id === '') {
-        return;
+export type ToolCallExplanation = {
+    tool_call: ToolCall;
+    explanation: string;
+};
+
+export async function explainToolCall(postid: string, toolCallID: string): Promise<ToolCallExplanation> {
+    const url = `${postRoute(postid)}/tool_call/${encodeURIComponent(toolCallID)}/explain`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+    }));
+
+    if (response.ok) {
+        return response.json();
     }
-    await setUserProfilePicture(user.id, file);
-}
 
-export async function setUserProfilePicture(userId: string, file: File) {
-    await Client4.uploadProfileImage(userId, file);
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
 }
 
-export async function getAutocompleteAllUsers(name: string) {
-    return Client4.autocompleteUsers(name, '', '');
-}
+export async function doPostbackSummary(postid: string, channelIDs: string[] = []) {
+    const url = `${postRoute(postid)}/postback_summary`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: channelIDs.length > 0 ? JSON.stringify({channel_ids: channelIDs}) : undefined,
+    }));
 
-export async function getProfilesByIds(userIds: string[]) {
-    if (userIds.length === 0) {
-        return [];
+    if (response.ok) {
+        return response.json();
     }
-    return Client4.getProfilesByIds(userIds);
-}
 
-export async function searchAllChannels(term: string): Promise<ChannelWithTeamData[]> {
-    return Client4.searchAllChannels(term, {
-        nonAdminSearch: false,
-        public: true,
-        private: true,
-        include_deleted: false,
-        deleted: false,
-    }) as Promise<ChannelWithTeamData[]>; // With these paremeters we should always get ChannelWithTeamData[]
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
 }
 
-export async function getChannelById(channelId: string): Promise<ChannelWithTeamData> {
-    const channel = await Client4.getChannel(channelId);
-    const team = await Client4.getTeam(channel.team_id);
-    return {
-        ...channel,
-        team_name: team.display_name,
-        team_display_name: team.display_name,
-        team_update_at: team.update_at,
-    };
-}
+export async function doHandoff(postid: string) {
+    const url = `${postRoute(postid)}/handoff`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+    }));
 
-export async function getTeamsByIds(teamIds: string[]) {
-    if (teamIds.length === 0) {
-        return [];
+    if (response.ok) {
+        return response.json();
     }
-    return Promise.all(teamIds.map((id) => Client4.getTeam(id)));
-}
 
-export async function searchTeams(term: string): Promise<Team[]> {
-    const opts: NotPagedTeamSearchOpts = {};
-
-    // Types are messed up
-    return Client4.searchTeams(term, opts) as unknown as Promise<Team[]>;
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
 }
 
-export function getTeamIconUrl(teamId: string, lastTeamIconUpdate: number) {
-    return Client4.getTeamIconUrl(teamId, lastTeamIconUpdate);
-}
+export async function doResend(postid: string) {
+    const url = `${postRoute(postid)}/resend`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+    }));
 
-export function getPost(postId: string) {
-    return Client4.getPost(postId);
+    if (response.ok) {
+        return;
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
 }
 
-export async function doReindexPosts() {
-    const url = `${baseRoute()}/admin/reindex`;
+export async function doFork(postid: string) {
+    const url = `${postRoute(postid)}/fork`;
     const response = await fetch(url, Client4.getOptions({
         method: 'POST',
     }));
@@ -339,14 +501,25 @@ export async function doReindexPosts() {
     });
 }
 
-export async function getReindexStatus() {
-    const url = `${baseRoute()}/admin/reindex/status`;
+export type ActionItem = {
+    id: string;
+    task: string;
+    owner: string;
+    owner_user_id: string;
+    due_date: string;
+    done: boolean;
+    sent_at: number;
+};
+
+export async function setActionItemDone(postid: string, itemID: string, done: boolean) {
+    const url = `${postRoute(postid)}/action_items/${itemID}/done`;
     const response = await fetch(url, Client4.getOptions({
-        method: 'GET',
+        method: 'POST',
+        body: JSON.stringify({done}),
     }));
 
     if (response.ok) {
-        return response.json();
+        return;
     }
 
     throw new ClientError(Client4.url, {
@@ -356,14 +529,14 @@ export async function getReindexStatus() {
     });
 }
 
-export async function cancelReindex() {
-    const url = `${baseRoute()}/admin/reindex/cancel`;
+export async function sendActionItem(postid: string, itemID: string) {
+    const url = `${postRoute(postid)}/action_items/${itemID}/send`;
     const response = await fetch(url, Client4.getOptions({
         method: 'POST',
     }));
 
     if (response.ok) {
-        return response.json();
+        return;
     }
 
     throw new ClientError(Client4.url, {
@@ -372,15 +545,831 @@ export async function cancelReindex() {
         url,
     });
 }
-export async function getChannelInterval(
-    channelID: string,
-    startTime: number,
-    endTime: number,
-    presetPrompt: string,
-    prompt?: string,
-    botUsername?: string,
-) {
-    const url = `${channelRoute(channelID)}/interval${botUsername ? `?botUsername=${botUsername}` : ''}`;
+
+export type PlaybookRun = {
+    id: string;
+    url: string;
+};
+
+// createPlaybookRun creates a Playbooks run in the meeting channel with the action items as its checklist
+export async function createPlaybookRun(postid: string, playbookID = ''): Promise<PlaybookRun> {
+    const url = `${postRoute(postid)}/action_items/playbook_run`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: JSON.stringify({playbook_id: playbookID}),
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export async function getTranscript(postid: string) {
+    const url = `${postRoute(postid)}/transcript`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'GET',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export type TranscriptSegment = {
+    start_ms: number;
+    end_ms: number;
+    speaker?: string;
+    text: string;
+};
+
+// editTranscript stores a corrected transcript for a meeting summary, regenerating the summary from it when asked
+export async function editTranscript(postid: string, segments: TranscriptSegment[], regenerate = false) {
+    const url = `${postRoute(postid)}/transcript${regenerate ? '?regenerate=true' : ''}`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'PUT',
+        body: JSON.stringify({segments}),
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export type TranscriptExportFormat = 'srt' | 'vtt' | 'txt' | 'json';
+
+export function getTranscriptExportURL(postid: string, format: TranscriptExportFormat) {
+    return `${postRoute(postid)}/transcript/export?format=${format}`;
+}
+
+export type ConversationExportFormat = 'md' | 'json';
+
+export function getConversationExportURL(postid: string, format: ConversationExportFormat) {
+    return `${postRoute(postid)}/export?format=${format}`;
+}
+
+export async function viewMyChannel(channelID: string) {
+    return Client4.viewMyChannel(channelID);
+}
+
+export async function getAIDirectChannel(currentUserId: string) {
+    const botUser = await Client4.getUserByUsername('ai');
+    const dm = await Client4.createDirectChannel([currentUserId, botUser.id]);
+    return dm.id;
+}
+
+export async function getBotDirectChannel(currentUserId: string, botUserID: string) {
+    const dm = await Client4.createDirectChannel([currentUserId, botUserID]);
+    return dm.id;
+}
+
+export async function getAIThreads(category = '') {
+    const url = `${baseRoute()}/ai_threads${category ? `?category=${encodeURIComponent(category)}` : ''}`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'GET',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export async function getAIBots() {
+    const url = `${baseRoute()}/ai_bots`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'GET',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export async function createPost(post: any) {
+    const created = await Client4.createPost(post);
+    return created;
+}
+
+export async function updateRead(userId: string, teamId: string, selectedPostId: string, timestamp: number) {
+    Client4.updateThreadReadForUser(userId, teamId, selectedPostId, timestamp);
+}
+
+export function getProfilePictureUrl(userId: string, lastIconUpdate: number) {
+    return Client4.getProfilePictureUrl(userId, lastIconUpdate);
+}
+
+export async function getBotProfilePictureUrl(username: string) {
+    const user = await Client4.getUserByUsername(username);
+    if (!user || user.id === '') {
+        return '';
+    }
+    return getProfilePictureUrl(user.id, user.last_picture_update);
+}
+
+// Narrows a search down to the posts in any of the channels, by any of the users, and created between
+// the timestamps in milliseconds. Unset filters match everything.
+export type SearchFilters = {
+    channelIds?: string[];
+    fromUserIds?: string[];
+    createdAfter?: number;
+    createdBefore?: number;
+};
+
+// corpus is 'posts', 'meetings' or 'files' to search only one of them, everything is searched when it's empty
+export async function doRunSearch(query: string, teamId: string, channelId: string, botUsername?: string, corpus?: string, filters?: SearchFilters) {
+    const url = `${baseRoute()}/search/run${botUsername ? `?botUsername=${botUsername}` : ''}`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: JSON.stringify({
+            query,
+            teamId,
+            channelId,
+            corpus,
+            ...filters,
+        }),
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export async function setUserProfilePictureByUsername(username: string, file: File) {
+    const user = await Client4.getUserByUsername(username);
+    if (!user || user.id === '') {
+        return;
+    }
+    await setUserProfilePicture(user.id, file);
+}
+
+export async function setUserProfilePicture(userId: string, file: File) {
+    await Client4.uploadProfileImage(userId, file);
+}
+
+export async function getAutocompleteAllUsers(name: string) {
+    return Client4.autocompleteUsers(name, '', '');
+}
+
+export async function getProfilesByIds(userIds: string[]) {
+    if (userIds.length === 0) {
+        return [];
+    }
+    return Client4.getProfilesByIds(userIds);
+}
+
+export async function searchAllChannels(term: string, nonAdminSearch = false): Promise<ChannelWithTeamData[]> {
+    return Client4.searchAllChannels(term, {
+        nonAdminSearch,
+        public: true,
+        private: true,
+        include_deleted: false,
+        deleted: false,
+    }) as Promise<ChannelWithTeamData[]>; // With these paremeters we should always get ChannelWithTeamData[]
+}
+
+export async function getChannelById(channelId: string): Promise<ChannelWithTeamData> {
+    const channel = await Client4.getChannel(channelId);
+    const team = await Client4.getTeam(channel.team_id);
+    return {
+        ...channel,
+        team_name: team.display_name,
+        team_display_name: team.display_name,
+        team_update_at: team.update_at,
+    };
+}
+
+export async function getTeamsByIds(teamIds: string[]) {
+    if (teamIds.length === 0) {
+        return [];
+    }
+    return Promise.all(teamIds.map((id) => Client4.getTeam(id)));
+}
+
+export async function searchTeams(term: string): Promise<Team[]> {
+    const opts: NotPagedTeamSearchOpts = {};
+
+    // Types are messed up
+    return Client4.searchTeams(term, opts) as unknown as Promise<Team[]>;
+}
+
+export function getTeamIconUrl(teamId: string, lastTeamIconUpdate: number) {
+    return Client4.getTeamIconUrl(teamId, lastTeamIconUpdate);
+}
+
+export function getPost(postId: string) {
+    return Client4.getPost(postId);
+}
+
+export async function doReindexPosts() {
+    const url = `${baseRoute()}/admin/reindex`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export async function getReindexEstimate(): Promise<CostEstimate> {
+    const url = `${baseRoute()}/admin/reindex/estimate`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'GET',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export async function getReindexStatus() {
+    const url = `${baseRoute()}/admin/reindex/status`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'GET',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export async function getIndexedModel(): Promise<IndexedModelStatus> {
+    const url = `${baseRoute()}/admin/reindex/model`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'GET',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export async function resumeReindex() {
+    const url = `${baseRoute()}/admin/reindex/resume`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export async function cancelReindex() {
+    const url = `${baseRoute()}/admin/reindex/cancel`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export type FFmpegDiagnostics = {
+    path: string;
+    version: string;
+    encoder: string;
+    hwaccel?: string;
+    errors: string[];
+};
+
+export async function getFFmpegDiagnostics(ffmpegConfig?: object): Promise<FFmpegDiagnostics> {
+    const url = `${baseRoute()}/admin/ffmpeg/diagnostics`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: ffmpegConfig ? JSON.stringify(ffmpegConfig) : undefined,
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+// MCPServerHealth is the result of the last connection to an MCP server
+export type MCPServerHealth = {
+    server_id: string;
+    transport: string;
+    status: 'unknown' | 'connected' | 'error' | 'disabled';
+    last_error?: string;
+    last_error_at?: number;
+    tool_count: number;
+    latency_ms: number;
+    checked_at?: number;
+    active_connections: number;
+};
+
+export async function getMCPServerHealth(): Promise<MCPServerHealth[]> {
+    return doJSONRequest(`${baseRoute()}/admin/mcp/status`, 'GET');
+}
+
+// testMCPServer connects to the server with the settings given, which don't need to be saved
+export async function testMCPServer(serverID: string, serverConfig: object): Promise<MCPServerHealth> {
+    return doJSONRequest(`${baseRoute()}/admin/mcp/servers/${encodeURIComponent(serverID)}/test`, 'POST', serverConfig);
+}
+
+// MCPSecret is a credential MCP server configs reference by name, listed without its value
+export type MCPSecret = {
+    name: string;
+    key_id: string;
+    update_at: number;
+};
+
+export async function getMCPSecrets(): Promise<MCPSecret[]> {
+    return doJSONRequest(`${baseRoute()}/admin/mcp/secrets`, 'GET');
+}
+
+export async function setMCPSecret(name: string, value: string) {
+    await doRequestWithoutResponse(`${baseRoute()}/admin/mcp/secrets/${encodeURIComponent(name)}`, 'PUT', {value});
+}
+
+export async function deleteMCPSecret(name: string) {
+    await doJSONRequest(`${baseRoute()}/admin/mcp/secrets/${encodeURIComponent(name)}`, 'DELETE');
+}
+
+// rotateMCPSecretsKey encrypts the stored secrets with the first of the configured keys
+export async function rotateMCPSecretsKey() {
+    await doRequestWithoutResponse(`${baseRoute()}/admin/mcp/secrets/rotate`, 'POST');
+}
+
+async function doRequestWithoutResponse(url: string, method: string, body?: object) {
+    const response = await fetch(url, Client4.getOptions({
+        method,
+        body: body ? JSON.stringify(body) : undefined,
+    }));
+
+    if (response.ok) {
+        return;
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export type GlossaryTerm = {
+    id: string;
+    term: string;
+    aliases: string[];
+    definition: string;
+    updateAt: number;
+};
+
+async function doJSONRequest(url: string, method: string, body?: object) {
+    const response = await fetch(url, Client4.getOptions({
+        method,
+        body: body ? JSON.stringify(body) : undefined,
+    }));
+
+    if (response.ok) {
+        return method === 'DELETE' ? null : response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export async function getGlossary(): Promise<GlossaryTerm[]> {
+    return doJSONRequest(`${baseRoute()}/admin/glossary`, 'GET');
+}
+
+export async function createGlossaryTerm(term: GlossaryTerm): Promise<GlossaryTerm> {
+    return doJSONRequest(`${baseRoute()}/admin/glossary`, 'POST', term);
+}
+
+export async function updateGlossaryTerm(term: GlossaryTerm): Promise<GlossaryTerm> {
+    return doJSONRequest(`${baseRoute()}/admin/glossary/${term.id}`, 'PUT', term);
+}
+
+export async function deleteGlossaryTerm(termID: string) {
+    return doJSONRequest(`${baseRoute()}/admin/glossary/${termID}`, 'DELETE');
+}
+
+export type SnippetScope = 'team' | 'personal' | 'organization';
+
+// Snippet is a reusable prompt, organization snippets have no team
+export type Snippet = {
+    id: string;
+    teamId: string;
+    scope: SnippetScope;
+    name: string;
+    description: string;
+    template: string;
+    creatorId: string;
+    updateAt: number;
+};
+
+function snippetsRoute(teamID: string): string {
+    return `${baseRoute()}/team/${teamID}/snippets`;
+}
+
+export async function getSnippets(teamID: string): Promise<Snippet[]> {
+    return doJSONRequest(snippetsRoute(teamID), 'GET');
+}
+
+export async function createSnippet(teamID: string, snippet: Snippet): Promise<Snippet> {
+    return doJSONRequest(snippetsRoute(teamID), 'POST', snippet);
+}
+
+export async function updateSnippet(teamID: string, snippet: Snippet): Promise<Snippet> {
+    return doJSONRequest(`${snippetsRoute(teamID)}/${snippet.id}`, 'PUT', snippet);
+}
+
+export async function deleteSnippet(teamID: string, snippetID: string) {
+    return doJSONRequest(`${snippetsRoute(teamID)}/${snippetID}`, 'DELETE');
+}
+
+// snippetDialogURL receives the dialog filling in the variables of a snippet
+export function snippetDialogURL(): string {
+    return `${baseRoute()}/snippets/dialog`;
+}
+
+export type MCPPromptArgument = {
+    name: string;
+    description: string;
+    required: boolean;
+};
+
+// MCPPrompt is a prompt template provided by an MCP server
+export type MCPPrompt = {
+    server_id: string;
+    name: string;
+    description: string;
+    arguments: MCPPromptArgument[];
+};
+
+export async function getMCPPrompts(): Promise<MCPPrompt[]> {
+    return doJSONRequest(`${baseRoute()}/mcp/prompts`, 'GET');
+}
+
+// mcpPromptDialogURL receives the dialog filling in the arguments of an MCP prompt
+export function mcpPromptDialogURL(): string {
+    return `${baseRoute()}/mcp/prompts/dialog`;
+}
+
+export type Memory = {
+    id: string;
+    userId: string;
+    content: string;
+    createAt: number;
+};
+
+export async function getMemories(): Promise<Memory[]> {
+    return doJSONRequest(`${baseRoute()}/memories`, 'GET');
+}
+
+export async function deleteMemory(memoryID: string) {
+    return doJSONRequest(`${baseRoute()}/memories/${memoryID}`, 'DELETE');
+}
+
+export async function deleteAllMemories() {
+    return doJSONRequest(`${baseRoute()}/memories`, 'DELETE');
+}
+
+// Schedule is a prompt run on a recurring cron cadence, answered by a bot in a direct message
+export type Schedule = {
+    id: string;
+    userId: string;
+    botUsername: string;
+    name: string;
+    prompt: string;
+    cadence: string;
+    timeZone: string;
+    enabled: boolean;
+    createAt: number;
+    updateAt: number;
+    nextRunAt: number;
+    lastRunAt: number;
+    lastError: string;
+};
+
+export type ScheduleRequest = {
+    botUsername: string;
+    name?: string;
+    prompt: string;
+    cadence: string;
+    timeZone?: string;
+    enabled?: boolean;
+};
+
+export async function getSchedules(): Promise<Schedule[]> {
+    return doJSONRequest(`${baseRoute()}/schedules`, 'GET');
+}
+
+export async function createSchedule(schedule: ScheduleRequest): Promise<Schedule> {
+    return doJSONRequest(`${baseRoute()}/schedules`, 'POST', schedule);
+}
+
+export async function updateSchedule(scheduleID: string, schedule: ScheduleRequest): Promise<Schedule> {
+    return doJSONRequest(`${baseRoute()}/schedules/${scheduleID}`, 'PUT', schedule);
+}
+
+export async function deleteSchedule(scheduleID: string) {
+    return doJSONRequest(`${baseRoute()}/schedules/${scheduleID}`, 'DELETE');
+}
+
+// Persona is a way for bots to behave, defined by admins, that users pick for a conversation
+export type Persona = {
+    id: string;
+    name: string;
+};
+
+export async function getPersonas(): Promise<Persona[]> {
+    return doJSONRequest(`${baseRoute()}/personas`, 'GET');
+}
+
+export async function getThreadPersona(postID: string): Promise<{personaID: string}> {
+    return doJSONRequest(`${postRoute(postID)}/persona`, 'GET');
+}
+
+export async function setThreadPersona(postID: string, personaID: string): Promise<{personaID: string}> {
+    return doJSONRequest(`${postRoute(postID)}/persona`, 'PUT', {personaID});
+}
+
+export async function getThreadBot(postID: string): Promise<{botUsername: string}> {
+    return doJSONRequest(`${postRoute(postID)}/bot`, 'GET');
+}
+
+export async function setThreadBot(postID: string, botUsername: string): Promise<{botUsername: string}> {
+    return doJSONRequest(`${postRoute(postID)}/bot`, 'PUT', {botUsername});
+}
+
+export async function getPinnedContext(postID: string): Promise<{postIds: string[]}> {
+    return doJSONRequest(`${postRoute(postID)}/pinned_context`, 'GET');
+}
+
+export async function pinContextPost(postID: string): Promise<{postIds: string[]}> {
+    return doJSONRequest(`${postRoute(postID)}/pin_context`, 'POST');
+}
+
+export async function unpinContextPost(postID: string) {
+    return doJSONRequest(`${postRoute(postID)}/pin_context`, 'DELETE');
+}
+
+export type ModelUsage = CostEstimate & {
+    bot_username: string;
+    model: string;
+    responses: number;
+};
+
+export type ThreadUsage = {
+    thread_id: string;
+    models: ModelUsage[];
+    total: CostEstimate;
+};
+
+export async function getThreadUsage(postID: string): Promise<ThreadUsage> {
+    return doJSONRequest(`${postRoute(postID)}/usage`, 'GET');
+}
+
+export type ThreadCategoryUsage = {
+    category: string;
+    threads: number;
+    replies: number;
+};
+
+export async function getThreadCategoryUsage(days: number): Promise<ThreadCategoryUsage[]> {
+    return doJSONRequest(`${baseRoute()}/admin/thread_categories?days=${days}`, 'GET');
+}
+
+export type BackgroundJob = {
+    id: string;
+    type: string;
+    state: 'pending' | 'running' | 'succeeded' | 'failed' | 'cancelled';
+    userId: string;
+    postId: string;
+    attempts: number;
+    maxAttempts: number;
+    error: string;
+    createAt: number;
+    updateAt: number;
+    nextAttemptAt: number;
+};
+
+export async function getBackgroundJobs(state?: string): Promise<BackgroundJob[]> {
+    return doJSONRequest(`${baseRoute()}/admin/jobs${state ? `?state=${state}` : ''}`, 'GET');
+}
+
+export async function cancelBackgroundJob(jobID: string) {
+    const url = `${baseRoute()}/admin/jobs/${jobID}/cancel`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+    }));
+
+    if (response.ok) {
+        return;
+    }
+
+    throw new ClientError(Client4.url, {
+        message: await response.text(),
+        status_code: response.status,
+        url,
+    });
+}
+
+export type ImportItemResult = {
+    name: string;
+    imported: boolean;
+    reason?: string;
+};
+
+export type ImportResult = {
+    dryRun: boolean;
+    bots: ImportItemResult[];
+    conversations: ImportItemResult[];
+};
+
+// importExport migrates the bots and conversation titles of an export from another AI integration.
+// On a dry run it only reports what would be imported.
+export async function importExport(exportJSON: string, dryRun: boolean): Promise<ImportResult> {
+    const url = `${baseRoute()}/admin/import${dryRun ? '?dry_run=true' : ''}`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: exportJSON,
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+export type ConfigImportResult = {
+    dryRun: boolean;
+    changed: string[];
+    unresolved: string[];
+};
+
+// exportConfigURL downloads the configuration as a YAML bundle without its secrets
+export function exportConfigURL(): string {
+    return `${baseRoute()}/admin/config/export`;
+}
+
+// importConfig replaces the configuration with a YAML bundle. On a dry run it only reports the settings that would change.
+export async function importConfig(bundle: string, dryRun: boolean): Promise<ConfigImportResult> {
+    const url = `${baseRoute()}/admin/config/import${dryRun ? '?dry_run=true' : ''}`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: bundle,
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: await response.text(),
+        status_code: response.status,
+        url,
+    });
+}
+
+export type PlaygroundResponse = {
+    response: string;
+    error?: string;
+};
+
+export type PlaygroundResult = {
+    input: string;
+    draft: PlaygroundResponse;
+    published: PlaygroundResponse;
+};
+
+// runPlayground answers sample inputs with a draft of the bot's custom instructions and with the published ones
+export async function runPlayground(botName: string, customInstructions: string, inputs: string[]): Promise<PlaygroundResult[]> {
+    const url = `${baseRoute()}/admin/playground/run`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: JSON.stringify({botName, customInstructions, inputs}),
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: await response.text(),
+        status_code: response.status,
+        url,
+    });
+}
+
+// publishPlaygroundInstructions saves a draft as the bot's custom instructions. It fails with a conflict when
+// the published instructions are no longer publishedInstructions.
+export async function publishPlaygroundInstructions(botName: string, customInstructions: string, publishedInstructions: string) {
+    const url = `${baseRoute()}/admin/playground/publish`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: JSON.stringify({botName, customInstructions, publishedInstructions}),
+    }));
+
+    if (response.ok) {
+        return;
+    }
+
+    throw new ClientError(Client4.url, {
+        message: await response.text(),
+        status_code: response.status,
+        url,
+    });
+}
+
+export async function getChannelInterval(
+    channelID: string,
+    startTime: number,
+    endTime: number,
+    presetPrompt: string,
+    prompt?: string,
+    botUsername?: string,
+) {
+    const url = `${channelRoute(channelID)}/interval${botUsername ? `?botUsername=${botUsername}` : ''}`;
     const response = await fetch(url, Client4.getOptions({
         method: 'POST',
         body: JSON.stringify({
@@ -401,3 +1390,60 @@ export async function getChannelInterval(
         url,
     });
 }
+
+export async function getChannelIntervalEstimate(
+    channelID: string,
+    startTime: number,
+    endTime: number,
+    presetPrompt: string,
+    botUsername?: string,
+): Promise<CostEstimate> {
+    const url = `${channelRoute(channelID)}/interval/estimate${botUsername ? `?botUsername=${botUsername}` : ''}`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: JSON.stringify({
+            start_time: startTime,
+            end_time: endTime,
+            preset_prompt: presetPrompt,
+        }),
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
+
+// Summarizes a named range of the channel such as 'today', 'this_week', 'since_last_visit' or 'since_post'
+export async function getChannelIntervalRange(
+    channelID: string,
+    range: string,
+    presetPrompt: string,
+    botUsername?: string,
+    postID?: string,
+) {
+    const url = `${channelRoute(channelID)}/interval${botUsername ? `?botUsername=${botUsername}` : ''}`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: JSON.stringify({
+            range,
+            post_id: postID || '',
+            preset_prompt: presetPrompt,
+        }),
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw new ClientError(Client4.url, {
+        message: '',
+        status_code: response.status,
+        url,
+    });
+}
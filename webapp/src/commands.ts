@@ -1,7 +1,8 @@
 // Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
 // See LICENSE.txt for license information.
 
-import {doRunSearch, getChannelInterval} from './client';
+import manifest from './manifest';
+import {MCPPrompt, doRunSearch, getChannelInterval, getChannelIntervalRange, getMCPPrompts, getSnippets, mcpPromptDialogURL, snippetDialogURL} from './client';
 import {doSelectPost} from './hooks';
 
 export async function handleAskChannelCommand(
@@ -61,6 +62,9 @@ export async function handleSummarizeChannelCommand(
     // Default to summarize 24 hours (in milliseconds)
     const defaultTimePeriod = 24 * 60 * 60 * 1000;
 
+    // Named ranges are resolved by the server using the user's timezone and channel membership
+    const namedRange = options.period ? namedRanges[options.period] : undefined;
+
     // Calculate time since based on period option
     let timeSince: number;
     if (options.period) {
@@ -70,7 +74,12 @@ export async function handleSummarizeChannelCommand(
     }
 
     try {
-        const result = await getChannelInterval(
+        const result = namedRange ? await getChannelIntervalRange(
+            args.channel_id,
+            namedRange,
+            'summarize_range',
+            botUsername,
+        ) : await getChannelInterval(
             args.channel_id,
             timeSince,
             0,
@@ -94,6 +103,158 @@ export async function handleSummarizeChannelCommand(
     }
 }
 
+// Variables of a snippet template, in the order they first appear, matching the server
+function snippetVariables(template: string): string[] {
+    const variables: string[] = [];
+    for (const match of template.matchAll(/\{\{\s*([A-Za-z][A-Za-z0-9_]*)\s*\}\}/g)) {
+        if (!variables.includes(match[1])) {
+            variables.push(match[1]);
+        }
+    }
+    return variables;
+}
+
+// Opens a dialog to fill in the variables of a snippet, the submitted prompt is posted to the bot
+export async function handleSnippetCommand(
+    message: string,
+    args: {
+        channel_id: string;
+        team_id: string;
+        root_id: string;
+    },
+    store: any,
+) {
+    const bots = store.getState()['plugins-' + manifest.id]?.bots || [];
+    if (!bots.some((bot: any) => bot.dmChannelID === args.channel_id)) {
+        return {
+            error: {
+                message: 'Snippets can only be used in a direct message with a bot',
+            },
+        };
+    }
+
+    const name = message.trim();
+    let teamSnippets;
+    try {
+        teamSnippets = await getSnippets(args.team_id);
+    } catch (error) {
+        return {
+            error: {
+                message: 'Failed to get snippets ' + error,
+            },
+        };
+    }
+
+    const snippet = teamSnippets.find((s) => s.name.toLowerCase() === name.toLowerCase());
+    if (!snippet) {
+        const available = teamSnippets.map((s) => s.name).join(', ') || 'none';
+        return {
+            error: {
+                message: `Usage: /snippet <name>. Snippets available in this team: ${available}`,
+            },
+        };
+    }
+
+    store.dispatch({
+        type: 'RECEIVED_DIALOG',
+        data: {
+            url: snippetDialogURL(),
+            dialog: {
+                callback_id: 'snippet',
+                title: snippet.name,
+                introduction_text: snippet.description,
+                elements: snippetVariables(snippet.template).map((variable) => ({
+                    display_name: variable.replace(/_/g, ' '),
+                    name: variable,
+                    type: 'textarea',
+                    optional: false,
+                    max_length: 3000,
+                })),
+                submit_label: 'Send',
+                notify_on_cancel: false,
+                state: snippet.id,
+            },
+        },
+    });
+
+    // Return empty object to prevent default error message
+    return {};
+}
+
+// Opens a dialog to fill in the arguments of an MCP prompt, the prompt returned by the server is posted
+// to the bot in the direct message channel
+export function openMCPPromptDialog(prompt: MCPPrompt, channelID: string, dispatch: any) {
+    dispatch({
+        type: 'RECEIVED_DIALOG',
+        data: {
+            url: mcpPromptDialogURL(),
+            dialog: {
+                callback_id: 'mcp_prompt',
+                title: prompt.name,
+                introduction_text: prompt.description,
+                elements: prompt.arguments.map((argument) => ({
+                    display_name: argument.name.replace(/_/g, ' '),
+                    name: argument.name,
+                    type: 'textarea',
+                    help_text: argument.description,
+                    optional: !argument.required,
+                    max_length: 3000,
+                })),
+                submit_label: 'Send',
+                notify_on_cancel: false,
+                state: JSON.stringify({server_id: prompt.server_id, name: prompt.name, channel_id: channelID}),
+            },
+        },
+    });
+}
+
+// Runs a prompt of the MCP servers, named by its name or by its server and name as server/name
+export async function handleMCPPromptCommand(
+    message: string,
+    args: {
+        channel_id: string;
+        team_id: string;
+        root_id: string;
+    },
+    store: any,
+) {
+    const bots = store.getState()['plugins-' + manifest.id]?.bots || [];
+    if (!bots.some((bot: any) => bot.dmChannelID === args.channel_id)) {
+        return {
+            error: {
+                message: 'MCP prompts can only be used in a direct message with a bot',
+            },
+        };
+    }
+
+    let prompts: MCPPrompt[];
+    try {
+        prompts = await getMCPPrompts();
+    } catch (error) {
+        return {
+            error: {
+                message: 'Failed to get MCP prompts ' + error,
+            },
+        };
+    }
+
+    const name = message.trim().toLowerCase();
+    const matching = prompts.filter((p) => p.name.toLowerCase() === name || `${p.server_id}/${p.name}`.toLowerCase() === name);
+    if (matching.length !== 1) {
+        const available = prompts.map((p) => `${p.server_id}/${p.name}`).join(', ') || 'none';
+        return {
+            error: {
+                message: `Usage: /mcp-prompt <name>. Prompts available: ${available}`,
+            },
+        };
+    }
+
+    openMCPPromptDialog(matching[0], args.channel_id, store.dispatch);
+
+    // Return empty object to prevent default error message
+    return {};
+}
+
 // Parses options from the command message
 function parseOptionsFromMessage(message: string): { bot?: string; period?: string } {
     const options: { bot?: string; period?: string } = {};
@@ -113,6 +274,13 @@ function parseOptionsFromMessage(message: string): { bot?: string; period?: stri
     return options;
 }
 
+// Period options that map to server side named ranges
+const namedRanges: {[period: string]: string} = {
+    today: 'today',
+    week: 'this_week',
+    unread: 'since_last_visit',
+};
+
 // Calculates timestamp based on period string
 function calculateTimeSince(periodStr: string): number {
     const now = Date.now();
@@ -0,0 +1,185 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useState} from 'react';
+import {useSelector} from 'react-redux';
+import {FormattedMessage} from 'react-intl';
+import styled from 'styled-components';
+import {SendIcon} from '@mattermost/compass-icons/components';
+
+import {GlobalState} from '@mattermost/types/store';
+
+import {ActionItem, createPlaybookRun, sendActionItem, setActionItemDone} from '@/client';
+
+import Checkbox from './checkbox';
+
+interface Props {
+    post: any;
+}
+
+// playbookRunPending marks the Playbooks run as pending, action items are marked by their ID
+const playbookRunPending = 'playbook_run';
+
+// ActionItemsPost shows the action items of a meeting as a checklist. The user who asked for the summary
+// can check items off, send each one to its owner by DM and, when Playbooks is installed, turn them into a run.
+export const ActionItemsPost = (props: Props) => {
+    const currentUserId = useSelector<GlobalState, string>((state) => state.entities.users.currentUserId);
+    const siteURL = useSelector<GlobalState, string | undefined>((state) => state.entities.general.config.SiteURL);
+    const playbooksActive = useSelector<GlobalState, boolean>((state: any) => Boolean(state.plugins?.plugins?.playbooks));
+    const [pending, setPending] = useState('');
+    const [error, setError] = useState(false);
+
+    const items: ActionItem[] = props.post.props?.action_items || [];
+    const canEdit = props.post.props?.llm_requester_user_id === currentUserId;
+    const playbookRunID: string | undefined = props.post.props?.playbook_run_id;
+
+    // The post is updated over the websocket once the change is saved
+    const run = async (itemID: string, action: () => Promise<void>) => {
+        setPending(itemID);
+        setError(false);
+        try {
+            await action();
+        } catch (err) {
+            setError(true);
+        }
+        setPending('');
+    };
+
+    return (
+        <>
+            <FormattedMessage defaultMessage='Action items from the meeting:'/>
+            <Items>
+                {items.map((item) => (
+                    <Item key={item.id}>
+                        <Checkbox
+                            testId={`action-item-${item.id}`}
+                            text={item.task}
+                            checked={item.done}
+                            disabled={!canEdit || pending === item.id}
+                            onChange={(done) => run(item.id, () => setActionItemDone(props.post.id, item.id, done))}
+                        />
+                        {(item.owner || item.due_date) && (
+                            <Details>
+                                {item.owner && (item.owner_user_id ? '@' + item.owner : item.owner)}
+                                {item.owner && item.due_date && ' · '}
+                                {item.due_date && (
+                                    <FormattedMessage
+                                        defaultMessage='due {date}'
+                                        values={{date: item.due_date}}
+                                    />
+                                )}
+                            </Details>
+                        )}
+                        {canEdit && item.owner_user_id && (
+                            <SendButton
+                                disabled={pending === item.id}
+                                onClick={() => run(item.id, () => sendActionItem(props.post.id, item.id))}
+                            >
+                                <SendIcon size={14}/>
+                                {item.sent_at ? (
+                                    <FormattedMessage
+                                        defaultMessage='Sent to @{owner}'
+                                        values={{owner: item.owner}}
+                                    />
+                                ) : (
+                                    <FormattedMessage
+                                        defaultMessage='Send to @{owner}'
+                                        values={{owner: item.owner}}
+                                    />
+                                )}
+                            </SendButton>
+                        )}
+                    </Item>
+                ))}
+            </Items>
+            {playbookRunID && (
+                <PlaybookLink href={`${siteURL ?? ''}/playbooks/runs/${playbookRunID}`}>
+                    <FormattedMessage defaultMessage='Open the Playbooks run'/>
+                </PlaybookLink>
+            )}
+            {canEdit && playbooksActive && !playbookRunID && (
+                <PlaybookButton
+                    disabled={pending === playbookRunPending}
+                    onClick={() => run(playbookRunPending, async () => {
+                        await createPlaybookRun(props.post.id);
+                    })}
+                >
+                    <FormattedMessage defaultMessage='Create a Playbooks run'/>
+                </PlaybookButton>
+            )}
+            {error && (
+                <ErrorText>
+                    <FormattedMessage defaultMessage='Unable to update the action item.'/>
+                </ErrorText>
+            )}
+        </>
+    );
+};
+
+const Items = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 4px;
+    margin-top: 8px;
+`;
+
+const Item = styled.div`
+    display: flex;
+    flex-wrap: wrap;
+    align-items: center;
+    gap: 8px;
+
+    label {
+        white-space: normal;
+    }
+`;
+
+const Details = styled.span`
+    font-size: 12px;
+    color: rgba(var(--center-channel-color-rgb), 0.64);
+`;
+
+const SendButton = styled.button`
+    display: flex;
+    align-items: center;
+    gap: 4px;
+    padding: 2px 8px;
+    background: none;
+    border: none;
+    border-radius: 4px;
+    color: var(--button-bg);
+    font-size: 12px;
+    font-weight: 600;
+
+    &:hover {
+        background: rgba(var(--button-bg-rgb), 0.08);
+    }
+`;
+
+const PlaybookButton = styled.button`
+    margin-top: 8px;
+    padding: 4px 12px;
+    background: rgba(var(--button-bg-rgb), 0.08);
+    border: none;
+    border-radius: 4px;
+    color: var(--button-bg);
+    font-size: 12px;
+    font-weight: 600;
+
+    &:hover {
+        background: rgba(var(--button-bg-rgb), 0.12);
+    }
+`;
+
+const PlaybookLink = styled.a`
+    display: inline-block;
+    margin-top: 8px;
+    font-size: 12px;
+    font-weight: 600;
+`;
+
+const ErrorText = styled.div`
+    margin-top: 8px;
+    font-size: 12px;
+    color: var(--error-text);
+`;
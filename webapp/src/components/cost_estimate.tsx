@@ -0,0 +1,48 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import {FormattedMessage, FormattedNumber} from 'react-intl';
+
+import {CostEstimate as CostEstimateData} from '@/client';
+
+// Operations estimated to use more input tokens than this are confirmed before they run
+export const LargeOperationTokens = 50000;
+
+interface Props {
+    estimate: CostEstimateData;
+}
+
+const CostEstimate = ({estimate}: Props) => {
+    const tokens = (
+        <FormattedNumber value={estimate.input_tokens + estimate.output_tokens}/>
+    );
+
+    if (!estimate.price_known) {
+        return (
+            <FormattedMessage
+                defaultMessage='This will use about {tokens} tokens. The price of the model is unknown.'
+                values={{tokens}}
+            />
+        );
+    }
+
+    return (
+        <FormattedMessage
+            defaultMessage='This will use about {tokens} tokens at an estimated cost of {cost}.'
+            values={{
+                tokens,
+                cost: (
+                    <FormattedNumber
+                        value={estimate.cost}
+                        style='currency'
+                        currency='USD'
+                        maximumFractionDigits={4}
+                    />
+                ),
+            }}
+        />
+    );
+};
+
+export default CostEstimate;
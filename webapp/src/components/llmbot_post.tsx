@@ -2,7 +2,7 @@
 // See LICENSE.txt for license information.
 
 import React, {useEffect, useRef, useState} from 'react';
-import {FormattedMessage} from 'react-intl';
+import {FormattedMessage, useIntl} from 'react-intl';
 import {useSelector} from 'react-redux';
 import styled from 'styled-components';
 
@@ -11,12 +11,16 @@ import {GlobalState} from '@mattermost/types/store';
 
 import {SendIcon} from '@mattermost/compass-icons/components';
 
-import {doPostbackSummary, doRegenerate, doStopGenerating} from '@/client';
+import {doFork, doHandoff, doPostbackSummary, doRefineSummary, doRegenerate, doResummarize, doSendFollowUp, doStopGenerating, getConversationExportURL, getStreamState} from '@/client';
+import {LLMBot} from '@/bots';
+import manifest from '@/manifest';
 
-import {useSelectNotAIPost} from '@/hooks';
+import {useSelectNotAIPost, useSelectPost} from '@/hooks';
 
 import {PostMessagePreview} from '@/mm_webapp';
 
+import {SelectChannel} from './select';
+
 import {SearchSources} from './search_sources';
 
 import PostText from './post_text';
@@ -25,6 +29,28 @@ import IconCancel from './assets/icon_cancel';
 import ToolApprovalSet from './tool_approval_set';
 
 const SearchResultsPropKey = 'search_results';
+const LongContentProgressPropKey = 'long_content_progress';
+const TranscriptionProgressPropKey = 'transcription_progress';
+const SummaryVersionsPropKey = 'summary_versions';
+const IngestedFilesPropKey = 'ingested_files';
+const FollowUpSuggestionsPropKey = 'follow_up_suggestions';
+
+type IngestedFile = {
+    id: string;
+    name: string;
+    truncated: boolean;
+};
+
+// Streams send a heartbeat when idle, a stream is resumed when a few heartbeats in a row are missed
+const defaultKeepAliveMS = 15000;
+const missedHeartbeatsBeforeResume = 3;
+
+const summaryRefinements = [
+    {refinement: 'shorter', message: <FormattedMessage defaultMessage='Shorter'/>},
+    {refinement: 'more_detail', message: <FormattedMessage defaultMessage='More detail'/>},
+    {refinement: 'action_items', message: <FormattedMessage defaultMessage='Action items only'/>},
+    {refinement: 'translate', message: <FormattedMessage defaultMessage='Translate'/>},
+];
 
 const PostBody = styled.div`
 `;
@@ -38,6 +64,12 @@ const ControlsBar = styled.div`
 	gap: 4px;
 `;
 
+// Follow-up questions are longer than the other buttons and wrap onto several lines
+const FollowUpsBar = styled(ControlsBar)`
+	flex-wrap: wrap;
+	height: auto;
+`;
+
 const GenerationButton = styled.button`
 	display: flex;
 	border: none;
@@ -81,6 +113,43 @@ const PostSummaryButton = styled(GenerationButton)`
 const StopGeneratingButton = styled(GenerationButton)`
 `;
 
+const ProgressTrack = styled.div`
+	height: 4px;
+	margin-top: 8px;
+	border-radius: 2px;
+	background: rgba(var(--center-channel-color-rgb), 0.08);
+	overflow: hidden;
+`;
+
+const ProgressFill = styled.div<{percent: number}>`
+	height: 100%;
+	width: ${(props) => props.percent}%;
+	background: var(--button-bg);
+	transition: width 0.3s ease;
+`;
+
+const ProgressDetails = styled.div`
+	margin-top: 4px;
+	font-size: 12px;
+	color: rgba(var(--center-channel-color-rgb), 0.64);
+`;
+
+const IngestedFiles = styled.div`
+	margin-top: 4px;
+	font-size: 12px;
+	color: rgba(var(--center-channel-color-rgb), 0.64);
+`;
+
+const OriginalSummary = styled.div`
+	margin-top: 8px;
+	padding-left: 12px;
+	border-left: 3px solid rgba(var(--center-channel-color-rgb), 0.16);
+`;
+
+const PostbackChannelPicker = styled.div`
+	margin-top: 8px;
+`;
+
 const PostSummaryHelpMessage = styled.div`
 	font-size: 14px;
 	font-style: italic;
@@ -98,6 +167,15 @@ export interface PostUpdateWebsocketMessage {
     next?: string
     control?: string
     tool_call?: string
+    seq?: number
+    keepalive_ms?: number
+    percent?: number
+    eta_seconds?: number
+}
+
+interface TranscriptionProgress {
+    percent: number
+    eta_seconds: number
 }
 
 export enum ToolCallStatus {
@@ -105,7 +183,8 @@ export enum ToolCallStatus {
     Accepted = 1,
     Rejected = 2,
     Error = 3,
-    Success = 4
+    Success = 4,
+    TimedOut = 5
 }
 
 export interface ToolCall {
@@ -115,6 +194,18 @@ export interface ToolCall {
     arguments: any;
     result?: string;
     status: ToolCallStatus;
+    provider_request?: any;
+    error?: string;
+    approver_ids?: string[];
+    decided_by?: string;
+    argument_summary?: ToolArgument[];
+    argument_errors?: string[];
+}
+
+// An argument of a tool call with its value formatted for the user approving the call
+export interface ToolArgument {
+    name: string;
+    value: string;
 }
 
 interface Props {
@@ -124,7 +215,9 @@ interface Props {
 }
 
 export const LLMBotPost = (props: Props) => {
+    const intl = useIntl();
     const selectPost = useSelectNotAIPost();
+    const selectAIPost = useSelectPost();
     const [message, setMessage] = useState(props.post.message);
 
     // Generating is true while we are reciving new content from the websocket
@@ -136,11 +229,34 @@ export const LLMBotPost = (props: Props) => {
     const stoppedRef = useRef(stopped);
     stoppedRef.current = stopped;
 
+    // Last stream event received, to notice events lost to a dropped connection
+    const lastSeqRef = useRef(0);
+    const lastEventAtRef = useRef(0);
+    const keepAliveMSRef = useRef(defaultKeepAliveMS);
+
     // State for tool calls
     const [toolCalls, setToolCalls] = useState<ToolCall[]>([]);
     const [error, setError] = useState('');
 
+    // Channels to post the summary to. When empty the summary goes back to the original call thread.
+    const [showChannelPicker, setShowChannelPicker] = useState(false);
+    const [postbackChannelIDs, setPostbackChannelIDs] = useState<string[]>([]);
+
+    // Handoff to people on the support team, once requested the conversation stays handed off
+    const [handedOff, setHandedOff] = useState(false);
+
+    // A fork is being created from the post
+    const [forking, setForking] = useState(false);
+
+    // Refined summaries can show the original summary next to the current version
+    const [showOriginal, setShowOriginal] = useState(false);
+
+    // Recordings report their transcription more often to the requester than the post is updated
+    const [liveTranscriptionProgress, setLiveTranscriptionProgress] = useState<TranscriptionProgress | null>(null);
+
     const currentUserId = useSelector<GlobalState, string>((state) => state.entities.users.currentUserId);
+    const bots = useSelector<GlobalState, LLMBot[] | undefined>((state: any) => state['plugins-' + manifest.id].bots);
+    const bot = bots?.find((b: LLMBot) => b.id === props.post.user_id);
     const rootPost = useSelector<GlobalState, any>((state) => state.entities.posts.posts[props.post.root_id]);
 
     // Get tool calls from post props
@@ -165,6 +281,36 @@ export const LLMBotPost = (props: Props) => {
         }
     }, [props.post.message]);
 
+    // Catches up with a stream after missing its events, such as when a proxy dropped the connection
+    const resumeStream = async () => {
+        lastEventAtRef.current = Date.now();
+        try {
+            const state = await getStreamState(props.post.id);
+            if (stoppedRef.current) {
+                return;
+            }
+            lastSeqRef.current = state.seq;
+            setMessage(state.message);
+            setGenerating(state.generating);
+        } catch (err) {
+            // Tried again after the next missed heartbeats
+        }
+    };
+
+    useEffect(() => {
+        if (!generating) {
+            return () => {/* no cleanup */};
+        }
+
+        lastEventAtRef.current = Date.now();
+        const interval = setInterval(() => {
+            if (Date.now() - lastEventAtRef.current > keepAliveMSRef.current * missedHeartbeatsBeforeResume) {
+                resumeStream();
+            }
+        }, keepAliveMSRef.current);
+        return () => clearInterval(interval);
+    }, [generating]);
+
     useEffect(() => {
         if (props.websocketRegister && props.websocketUnregister) {
             const listenerID = Math.random().toString(36).substring(7);
@@ -177,6 +323,29 @@ export const LLMBotPost = (props: Props) => {
                     return;
                 }
 
+                // Events are numbered from the start of each stream, a gap means some were lost
+                lastEventAtRef.current = Date.now();
+                if (data.seq) {
+                    const missedEvents = data.seq > lastSeqRef.current + 1 && lastSeqRef.current > 0;
+                    lastSeqRef.current = data.seq;
+                    if (missedEvents) {
+                        resumeStream();
+                    }
+                }
+
+                if (data.control === 'heartbeat') {
+                    keepAliveMSRef.current = data.keepalive_ms || defaultKeepAliveMS;
+                    if (!stoppedRef.current) {
+                        setGenerating(true);
+                    }
+                    return;
+                }
+
+                if (data.control === 'transcription_progress') {
+                    setLiveTranscriptionProgress({percent: data.percent || 0, eta_seconds: data.eta_seconds || 0});
+                    return;
+                }
+
                 // Handle tool call events from the websocket event
                 if (data.control === 'tool_call' && data.tool_call) {
                     try {
@@ -223,6 +392,27 @@ export const LLMBotPost = (props: Props) => {
         doRegenerate(props.post.id);
     };
 
+    const refineSummary = async (refinement: string) => {
+        setGenerating(true);
+        setStopped(false);
+        try {
+            await doRefineSummary(props.post.id, refinement);
+            setMessage('');
+        } catch (err) {
+            setGenerating(false);
+            setError('Unable to refine the summary');
+        }
+    };
+
+    const resummarize = async (otherBot: LLMBot) => {
+        try {
+            const result = await doResummarize(props.post.id, otherBot.username);
+            selectAIPost(result.postid, result.channelid);
+        } catch (err) {
+            setError('Unable to summarize the meeting again');
+        }
+    };
+
     const stopGenerating = () => {
         setStopped(true);
         setGenerating(false);
@@ -230,13 +420,51 @@ export const LLMBotPost = (props: Props) => {
     };
 
     const postSummary = async () => {
-        const result = await doPostbackSummary(props.post.id);
+        const result = await doPostbackSummary(props.post.id, postbackChannelIDs);
+        setShowChannelPicker(false);
+        setPostbackChannelIDs([]);
         selectPost(result.rootid, result.channelid);
     };
 
+    const handoff = async () => {
+        setHandedOff(true);
+        try {
+            await doHandoff(props.post.id);
+        } catch (err) {
+            setHandedOff(false);
+            setError('Unable to hand off the conversation');
+        }
+    };
+
+    const fork = async () => {
+        setForking(true);
+        try {
+            const result = await doFork(props.post.id);
+            selectAIPost(result.postid, result.channelid);
+        } catch (err) {
+            setError('Unable to fork the conversation');
+        }
+        setForking(false);
+    };
+
+    const sendFollowUp = async (question: string) => {
+        try {
+            await doSendFollowUp(props.post.channel_id, props.post.root_id || props.post.id, question);
+        } catch (err) {
+            setError('Unable to send the question');
+        }
+    };
+
     const requesterIsCurrentUser = (props.post.props?.llm_requester_user_id === currentUserId);
     const isThreadSummaryPost = (props.post.props?.referenced_thread && props.post.props?.referenced_thread !== '');
     const isNoShowRegen = (props.post.props?.no_regen && props.post.props?.no_regen !== '');
+    const isSummaryPost = !isNoShowRegen && Boolean(
+        (isThreadSummaryPost && props.post.props?.prompt_type === 'summarize_thread') ||
+        props.post.props?.referenced_recording_file_id ||
+        props.post.props?.referenced_transcript_post_id,
+    );
+    const isMeetingSummaryPost = isSummaryPost && !isThreadSummaryPost;
+    const summaryVersions = props.post.props?.[SummaryVersionsPropKey] || [];
     const isTranscriptionResult = rootPost?.props?.referenced_transcript_post_id && rootPost?.props?.referenced_transcript_post_id !== '';
 
     let permalinkView = null;
@@ -252,10 +480,31 @@ export const LLMBotPost = (props: Props) => {
         }
     }
 
-    const showRegenerate = !generating && requesterIsCurrentUser && !isNoShowRegen;
+    // Long messages are read in parts before the response is generated
+    const longContentProgress = props.post.props?.[LongContentProgressPropKey];
+    const showLongContentProgress = !generating && longContentProgress?.total > 0;
+
+    // Recordings are converted and transcribed before the summary is written
+    const transcriptionProgress: TranscriptionProgress | undefined = liveTranscriptionProgress ?? props.post.props?.[TranscriptionProgressPropKey];
+    const showTranscriptionProgress = !generating && transcriptionProgress !== undefined && transcriptionProgress.percent < 100;
+
+    // Documents attached to the request whose text the bot read
+    const ingestedFiles: IngestedFile[] = props.post.props?.[IngestedFilesPropKey] || [];
+    const ingestedFileNames = ingestedFiles.map((f) => (f.truncated ? intl.formatMessage({defaultMessage: '{name} (beginning only)'}, {name: f.name}) : f.name));
+
+    const showRegenerate = !generating && !showLongContentProgress && requesterIsCurrentUser && !isNoShowRegen;
     const showPostbackButton = !generating && requesterIsCurrentUser && isTranscriptionResult;
     const showStopGeneratingButton = generating && requesterIsCurrentUser;
-    const showControlsBar = (showRegenerate || showPostbackButton || showStopGeneratingButton) && message !== '';
+    const showHandoffButton = !generating && requesterIsCurrentUser && Boolean(bot?.handoffEnabled) && bot?.dmChannelID === props.post.channel_id;
+    const showForkButton = !generating && requesterIsCurrentUser && bot?.dmChannelID === props.post.channel_id;
+    const showExportButton = !generating && requesterIsCurrentUser;
+    const showRefineButtons = !generating && requesterIsCurrentUser && isSummaryPost;
+    const followUpSuggestions: string[] = props.post.props?.[FollowUpSuggestionsPropKey] || [];
+    const showFollowUps = !generating && requesterIsCurrentUser && followUpSuggestions.length > 0;
+
+    // Meeting summaries can be written again by another bot from the same transcript
+    const otherBots = isMeetingSummaryPost ? (bots ?? []).filter((b) => b.id !== props.post.user_id) : [];
+    const showControlsBar = (showRegenerate || showPostbackButton || showStopGeneratingButton || showHandoffButton || showForkButton || showExportButton) && message !== '';
 
     return (
         <PostBody
@@ -273,6 +522,49 @@ export const LLMBotPost = (props: Props) => {
                 postID={props.post.id}
                 showCursor={generating}
             />
+            {showLongContentProgress && (
+                <ProgressTrack data-testid='llm-bot-post-long-content-progress'>
+                    <ProgressFill percent={Math.round((longContentProgress.done / longContentProgress.total) * 100)}/>
+                </ProgressTrack>
+            )}
+            {showTranscriptionProgress && (
+                <>
+                    <ProgressTrack data-testid='llm-bot-post-transcription-progress'>
+                        <ProgressFill percent={transcriptionProgress.percent}/>
+                    </ProgressTrack>
+                    <ProgressDetails>
+                        {transcriptionProgress.eta_seconds > 0 ? (
+                            <FormattedMessage
+                                defaultMessage='{percent}% transcribed, about {minutes, plural, one {# minute} other {# minutes}} left'
+                                values={{percent: transcriptionProgress.percent, minutes: Math.max(Math.round(transcriptionProgress.eta_seconds / 60), 1)}}
+                            />
+                        ) : (
+                            <FormattedMessage
+                                defaultMessage='{percent}% transcribed'
+                                values={{percent: transcriptionProgress.percent}}
+                            />
+                        )}
+                    </ProgressDetails>
+                </>
+            )}
+            {!generating && ingestedFileNames.length > 0 && (
+                <IngestedFiles data-testid='llm-bot-post-ingested-files'>
+                    <FormattedMessage
+                        defaultMessage='Read from attachments: {files}'
+                        values={{files: ingestedFileNames.join(', ')}}
+                    />
+                </IngestedFiles>
+            )}
+            {showOriginal && summaryVersions.length > 0 && (
+                <OriginalSummary data-testid='llm-bot-post-original-summary'>
+                    <PostText
+                        message={summaryVersions[0].message}
+                        channelID={props.post.channel_id}
+                        postID={props.post.id}
+                        showCursor={false}
+                    />
+                </OriginalSummary>
+            )}
             {props.post.props?.[SearchResultsPropKey] && (
                 <SearchSources
                     sources={JSON.parse(props.post.props[SearchResultsPropKey])}
@@ -281,6 +573,7 @@ export const LLMBotPost = (props: Props) => {
             {toolCalls && toolCalls.length > 0 && (
                 <ToolApprovalSet
                     postID={props.post.id}
+                    requesterID={props.post.props?.llm_requester_user_id}
                     toolCalls={toolCalls}
                 />
             )}
@@ -289,6 +582,15 @@ export const LLMBotPost = (props: Props) => {
                 <FormattedMessage defaultMessage='Would you like to post this summary to the original call thread? You can also ask Copilot to make changes.'/>
             </PostSummaryHelpMessage>
             }
+            { showPostbackButton && showChannelPicker &&
+            <PostbackChannelPicker>
+                <SelectChannel
+                    channelIDs={postbackChannelIDs}
+                    onChangeChannelIDs={setPostbackChannelIDs}
+                    nonAdminSearch={true}
+                />
+            </PostbackChannelPicker>
+            }
             { showControlsBar &&
             <ControlsBar>
                 { showStopGeneratingButton &&
@@ -306,9 +608,24 @@ export const LLMBotPost = (props: Props) => {
                     onClick={postSummary}
                 >
                     <SendIcon/>
-                    <FormattedMessage defaultMessage='Post summary'/>
+                    {postbackChannelIDs.length > 0 ? (
+                        <FormattedMessage
+                            defaultMessage='Post summary to {count, plural, one {# channel} other {# channels}}'
+                            values={{count: postbackChannelIDs.length}}
+                        />
+                    ) : (
+                        <FormattedMessage defaultMessage='Post summary'/>
+                    )}
                 </PostSummaryButton>
                 }
+                {showPostbackButton && !showChannelPicker &&
+                <GenerationButton
+                    data-testid='llm-bot-post-summary-choose-channels'
+                    onClick={() => setShowChannelPicker(true)}
+                >
+                    <FormattedMessage defaultMessage='Choose channels'/>
+                </GenerationButton>
+                }
                 { showRegenerate &&
                 <GenerationButton
                     data-testid='regenerate-button'
@@ -318,8 +635,83 @@ export const LLMBotPost = (props: Props) => {
                     <FormattedMessage defaultMessage='Regenerate'/>
                 </GenerationButton>
                 }
+                { showForkButton &&
+                <GenerationButton
+                    data-testid='fork-button'
+                    onClick={fork}
+                    disabled={forking}
+                >
+                    <FormattedMessage defaultMessage='Fork conversation'/>
+                </GenerationButton>
+                }
+                { showExportButton &&
+                <GenerationButton
+                    data-testid='export-button'
+                    onClick={() => window.open(getConversationExportURL(props.post.id, 'md'), '_blank')}
+                >
+                    <FormattedMessage defaultMessage='Export'/>
+                </GenerationButton>
+                }
+                { showHandoffButton &&
+                <GenerationButton
+                    data-testid='handoff-button'
+                    onClick={handoff}
+                    disabled={handedOff}
+                >
+                    {handedOff ? (
+                        <FormattedMessage defaultMessage='Handed off'/>
+                    ) : (
+                        <FormattedMessage defaultMessage='Talk to a person'/>
+                    )}
+                </GenerationButton>
+                }
+            </ControlsBar>
+            }
+            { showRefineButtons && message !== '' &&
+            <ControlsBar data-testid='llm-bot-post-refine-summary'>
+                {summaryRefinements.map(({refinement, message: label}) => (
+                    <GenerationButton
+                        key={refinement}
+                        onClick={() => refineSummary(refinement)}
+                    >
+                        {label}
+                    </GenerationButton>
+                ))}
+                {otherBots.map((otherBot) => (
+                    <GenerationButton
+                        key={otherBot.id}
+                        data-testid='llm-bot-post-resummarize'
+                        onClick={() => resummarize(otherBot)}
+                    >
+                        <FormattedMessage
+                            defaultMessage='Summarize with {botName}'
+                            values={{botName: otherBot.displayName}}
+                        />
+                    </GenerationButton>
+                ))}
+                {summaryVersions.length > 0 &&
+                <GenerationButton onClick={() => setShowOriginal(!showOriginal)}>
+                    {showOriginal ? (
+                        <FormattedMessage defaultMessage='Hide original'/>
+                    ) : (
+                        <FormattedMessage defaultMessage='Show original'/>
+                    )}
+                </GenerationButton>
+                }
             </ControlsBar>
             }
+            { showFollowUps && message !== '' &&
+            <FollowUpsBar data-testid='llm-bot-post-follow-ups'>
+                {followUpSuggestions.map((question) => (
+                    <GenerationButton
+                        key={question}
+                        onClick={() => sendFollowUp(question)}
+                    >
+                        {question}
+                    </GenerationButton>
+                ))}
+            </FollowUpsBar>
+            }
         </PostBody>
     );
 };
@@ -1,14 +1,14 @@
 // Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
 // See LICENSE.txt for license information.
 
-import React from 'react';
+import React, {useState} from 'react';
 import {FormattedMessage, useIntl} from 'react-intl';
 
 import {Post} from '@mattermost/types/posts';
 
 import styled from 'styled-components';
 
-import {doReaction, doThreadAnalysis} from '../client';
+import {doReaction, doSummarizeTranscription, doThreadAnalysis, TranscriptRange} from '../client';
 
 import {useSelectPost} from '@/hooks';
 
@@ -24,6 +24,7 @@ import DotMenu, {DropdownMenu, DropdownMenuItem} from './dot_menu';
 import IconThreadSummarization from './assets/icon_thread_summarization';
 import {Divider, DropdownChannelBlocked, DropdownInfoOnlyVisibleToYou} from './dropdown_info';
 import {DropdownBotSelector} from './bot_selector';
+import {DropdownSummaryTemplateSelector, defaultSummaryTemplateID, useSummaryTemplates} from './summary_template_selector';
 
 type Props = {
     post: Post,
@@ -41,6 +42,17 @@ const PostMenu = (props: Props) => {
         selectPost(result.postid, result.channelid);
     };
 
+    // Uploaded transcripts are summarized like meeting transcripts, the server detects their format
+    const files = post.metadata?.files ?? [];
+    const isTranscriptUpload = files.length === 1 && transcriptExtensions.includes(files[0].extension.toLowerCase());
+    const summaryTemplates = useSummaryTemplates(isTranscriptUpload);
+    const [summaryTemplateID, setSummaryTemplateID] = useState(defaultSummaryTemplateID);
+
+    const summarizeTranscript = async (range?: TranscriptRange) => {
+        const result = await doSummarizeTranscription(post.id, false, summaryTemplateID, range);
+        selectPost(result.postid, result.channelid);
+    };
+
     if (!isBasicsLicensed) {
         return null;
     }
@@ -87,6 +99,28 @@ const PostMenu = (props: Props) => {
                 <span className='icon'><IconSparkleQuestionStyled/></span>
                 <FormattedMessage defaultMessage='Find open questions'/>
             </DropdownMenuItem>
+            {isTranscriptUpload && summaryTemplates.length > 1 && (
+                <DropdownSummaryTemplateSelector
+                    templates={summaryTemplates}
+                    templateID={summaryTemplateID}
+                    setTemplateID={setSummaryTemplateID}
+                />
+            )}
+            {isTranscriptUpload && (
+                <DropdownMenuItem onClick={() => summarizeTranscript()}>
+                    <span className='icon'><IconThreadSummarization/></span>
+                    <FormattedMessage defaultMessage='Summarize transcript'/>
+                </DropdownMenuItem>
+            )}
+            {isTranscriptUpload && (
+                <DropdownMenuItem onClick={() => summarizeTranscript({start: -recentTranscriptMinutes * 60})}>
+                    <span className='icon'><IconThreadSummarization/></span>
+                    <FormattedMessage
+                        defaultMessage='Summarize last {minutes} minutes'
+                        values={{minutes: recentTranscriptMinutes}}
+                    />
+                </DropdownMenuItem>
+            )}
             <DropdownMenuItem onClick={() => doReaction(post.id)}>
                 <span className='icon'><IconReactForMe/></span>
                 <FormattedMessage defaultMessage='React for me'/>
@@ -97,6 +131,11 @@ const PostMenu = (props: Props) => {
     );
 };
 
+const transcriptExtensions = ['vtt', 'txt'];
+
+// recentTranscriptMinutes is how much of the end of a transcript is summarized to catch up on a meeting
+const recentTranscriptMinutes = 20;
+
 const IconSparkleCheckmarkStyled = styled(IconSparkleCheckmark)`
 	color: rgba(var(--center-channel-color-rgb), 0.56);
 `;
@@ -0,0 +1,144 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useEffect, useState} from 'react';
+import {FormattedMessage, useIntl} from 'react-intl';
+import styled from 'styled-components';
+
+import {CheckIcon, ChevronDownIcon} from '@mattermost/compass-icons/components';
+
+import {Persona, getPersonas, getThreadPersona, setThreadPersona} from '@/client';
+
+import DotMenu, {DropdownMenu, DropdownMenuItem} from '../dot_menu';
+import {GrayPill} from '../pill';
+
+// usePersonas loads the personas conversations can be conducted in
+export const usePersonas = () => {
+    const [personas, setPersonas] = useState<Persona[]>([]);
+
+    useEffect(() => {
+        getPersonas().then(setPersonas).catch(() => setPersonas([]));
+    }, []);
+
+    return personas;
+};
+
+type Props = {
+    personas: Persona[]
+    personaID: string
+    setPersonaID: (id: string) => void
+}
+
+// PersonaSelector picks the persona of a conversation, the bot's default behavior when the ID is empty
+export const PersonaSelector = (props: Props) => {
+    const intl = useIntl();
+    const defaultName = intl.formatMessage({defaultMessage: 'Default'});
+    const activeName = props.personas.find((p) => p.id === props.personaID)?.name ?? defaultName;
+    const options = [{id: '', name: defaultName}, ...props.personas];
+
+    return (
+        <DotMenu
+            icon={(
+                <>
+                    <SelectMessage>
+                        <FormattedMessage defaultMessage='Persona:'/>
+                    </SelectMessage>
+                    <PersonaPill>
+                        {activeName}
+                        <ChevronDownIcon/>
+                    </PersonaPill>
+                </>
+            )}
+            title={activeName}
+            dotMenuButton={SelectorContainer}
+            dropdownMenu={StyledDropdownMenu}
+            portal={false}
+            testId='persona-selector'
+        >
+            {options.map((persona) => (
+                <StyledDropdownMenuItem
+                    key={persona.id}
+                    onClick={() => props.setPersonaID(persona.id)}
+                >
+                    {persona.name}
+                    {persona.id === props.personaID && (
+                        <StyledCheckIcon/>
+                    )}
+                </StyledDropdownMenuItem>
+            ))}
+        </DotMenu>
+    );
+};
+
+type ThreadProps = {
+    personas: Persona[]
+    rootPostID: string
+}
+
+// ThreadPersonaSelector switches the persona the following responses of a thread are written in
+export const ThreadPersonaSelector = (props: ThreadProps) => {
+    const [personaID, setPersonaID] = useState<string | null>(null);
+
+    useEffect(() => {
+        setPersonaID(null);
+        getThreadPersona(props.rootPostID).then((r) => setPersonaID(r.personaID)).catch(() => setPersonaID(null));
+    }, [props.rootPostID]);
+
+    if (personaID === null || props.personas.length === 0) {
+        return null;
+    }
+
+    const switchPersona = async (id: string) => {
+        try {
+            const result = await setThreadPersona(props.rootPostID, id);
+            setPersonaID(result.personaID);
+        } catch {
+            // The thread keeps its persona
+        }
+    };
+
+    return (
+        <PersonaSelector
+            personas={props.personas}
+            personaID={personaID}
+            setPersonaID={switchPersona}
+        />
+    );
+};
+
+const SelectorContainer = styled.div`
+	display: flex;
+	flex-direction: row;
+	align-items: center;
+	gap: 8px;
+
+	margin: 8px 16px;
+	color: rgba(var(--center-channel-color-rgb), 0.56);
+`;
+
+const PersonaPill = styled(GrayPill)`
+	font-size: 12px;
+	padding: 2px 6px;
+	gap: 0;
+`;
+
+const SelectMessage = styled.div`
+	font-size: 12px;
+	font-weight: 600;
+	line-height: 16px;
+	letter-spacing: 0.24px;
+	text-transform: uppercase;
+`;
+
+const StyledDropdownMenu = styled(DropdownMenu)`
+	min-width: 220px;
+`;
+
+const StyledDropdownMenuItem = styled(DropdownMenuItem)`
+	padding: 8px 16px;
+`;
+
+const StyledCheckIcon = styled(CheckIcon)`
+	margin-left: auto;
+	color: var(--button-bg);
+`;
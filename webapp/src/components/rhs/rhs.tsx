@@ -19,8 +19,11 @@ import RHSImage from '../assets/rhs_image';
 import {ThreadViewer as UnstyledThreadViewer} from '@/mm_webapp';
 
 import ThreadItem from './thread_item';
+import ThreadCategoryFilter from './thread_category_filter';
 import RHSHeader from './rhs_header';
 import RHSNewTab from './rhs_new_tab';
+import RHSMemories from './rhs_memories';
+import {ThreadPersonaSelector, usePersonas} from './persona_selector';
 import {RHSPaddingContainer, RHSText, RHSTitle} from './common';
 
 const ThreadViewer = UnstyledThreadViewer && styled(UnstyledThreadViewer)`
@@ -69,12 +72,14 @@ export default function RHS() {
     const selectedPostId = useSelector((state: any) => state['plugins-' + manifest.id].selectedPostId);
     const currentUserId = useSelector<GlobalState, string>((state) => state.entities.users.currentUserId);
     const currentTeamId = useSelector<GlobalState, string>((state) => state.entities.teams.currentTeamId);
+    const memoryEnabled = useSelector<GlobalState, boolean>((state: any) => state['plugins-' + manifest.id].memoryEnabled);
 
     const [threads, setThreads] = useState<AIThread[] | null>(null);
+    const [category, setCategory] = useState('');
 
     useEffect(() => {
         const fetchThreads = async () => {
-            setThreads(await getAIThreads());
+            setThreads(await getAIThreads(category));
         };
         if (currentTab === 'threads') {
             fetchThreads();
@@ -88,13 +93,14 @@ export default function RHS() {
                 updateRead(currentUserId, currentTeamId, selectedPostId, Date.now() + twentyFourHoursInMS);
             }
         };
-    }, [currentTab, selectedPostId]);
+    }, [currentTab, selectedPostId, category]);
 
     const selectPost = useCallback((postId: string) => {
         dispatch({type: 'SELECT_AI_POST', postId});
     }, [dispatch]);
 
     const {bots, activeBot, setActiveBot} = useBotlist();
+    const personas = usePersonas();
 
     // Unconfigured state
     if (bots && bots.length === 0) {
@@ -126,38 +132,52 @@ export default function RHS() {
             setCurrentTab('thread');
         }
         content = (
-            <ThreadViewer
-                data-testid='rhs-thread-viewer'
-                inputPlaceholder={intl.formatMessage({defaultMessage: 'Reply...'})}
-                rootPostId={selectedPostId}
-                useRelativeTimestamp={false}
-                isThreadView={false}
-            />
+            <>
+                <ThreadPersonaSelector
+                    personas={personas}
+                    rootPostID={selectedPostId}
+                />
+                <ThreadViewer
+                    data-testid='rhs-thread-viewer'
+                    inputPlaceholder={intl.formatMessage({defaultMessage: 'Reply...'})}
+                    rootPostId={selectedPostId}
+                    useRelativeTimestamp={false}
+                    isThreadView={false}
+                />
+            </>
         );
     } else if (currentTab === 'threads') {
         if (threads && bots) {
             content = (
-                <ThreadsList
-                    data-testid='rhs-threads-list'
-                >
-                    {threads.map((p) => (
-                        <ThreadItem
-                            key={p.ID}
-                            postTitle={p.Title}
-                            postMessage={p.Message}
-                            repliesCount={p.ReplyCount}
-                            lastActivityDate={p.UpdateAt}
-                            label={bots.find((bot) => bot.dmChannelID === p.ChannelID)?.displayName ?? ''}
-                            onClick={() => {
-                                setCurrentTab('thread');
-                                selectPost(p.ID);
-                            }}
-                        />))}
-                </ThreadsList>
+                <>
+                    <ThreadCategoryFilter
+                        category={category}
+                        setCategory={setCategory}
+                    />
+                    <ThreadsList
+                        data-testid='rhs-threads-list'
+                    >
+                        {threads.map((p) => (
+                            <ThreadItem
+                                key={p.ID}
+                                postTitle={p.Title}
+                                postMessage={p.Message}
+                                repliesCount={p.ReplyCount}
+                                lastActivityDate={p.UpdateAt}
+                                label={bots.find((bot) => bot.dmChannelID === p.ChannelID)?.displayName ?? ''}
+                                onClick={() => {
+                                    setCurrentTab('thread');
+                                    selectPost(p.ID);
+                                }}
+                            />))}
+                    </ThreadsList>
+                </>
             );
         } else {
             content = null;
         }
+    } else if (currentTab === 'memories') {
+        content = <RHSMemories/>;
     } else if (currentTab === 'new') {
         content = (
             <RHSNewTab
@@ -176,6 +196,7 @@ export default function RHS() {
                 currentTab={currentTab}
                 setCurrentTab={setCurrentTab}
                 selectPost={selectPost}
+                memoryEnabled={memoryEnabled}
                 bots={bots}
                 activeBot={activeBot}
                 setActiveBot={setActiveBot}
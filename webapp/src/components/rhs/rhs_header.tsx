@@ -16,6 +16,7 @@ import {Button} from './common';
 
 type Props = {
     currentTab: string
+    memoryEnabled: boolean
     bots: LLMBot[] | null
     activeBot: LLMBot | null
     setCurrentTab: (tab: string) => void
@@ -50,6 +51,18 @@ const RHSHeader = (props: Props) => {
     return (
         <Header>
             {historyButton}
+            {props.memoryEnabled && props.currentTab !== 'memories' && (
+                <HistoryButton
+                    data-testid='memories'
+                    onClick={() => {
+                        props.setCurrentTab('memories');
+                        props.selectPost('');
+                    }}
+                >
+                    <i className='icon-lightbulb-outline'/>
+                    <FormattedMessage defaultMessage='Memories'/>
+                </HistoryButton>
+            )}
             {props.currentTab !== 'new' && (
                 <NewChatButton
                     data-testid='new-chat'
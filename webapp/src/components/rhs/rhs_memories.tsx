@@ -0,0 +1,122 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useEffect, useState} from 'react';
+import {FormattedMessage, useIntl} from 'react-intl';
+import styled from 'styled-components';
+import {TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
+
+import {Memory, deleteAllMemories, deleteMemory, getMemories} from '@/client';
+
+import {ButtonIcon} from '../assets/buttons';
+
+import {Button, RHSPaddingContainer, RHSText} from './common';
+
+// RHSMemories lists what the bots remember about the user, who can delete any of it.
+const RHSMemories = () => {
+    const intl = useIntl();
+    const [memories, setMemories] = useState<Memory[] | null>(null);
+    const [error, setError] = useState(false);
+
+    useEffect(() => {
+        getMemories().then(setMemories).catch(() => setError(true));
+    }, []);
+
+    const forget = async (memoryID: string) => {
+        try {
+            await deleteMemory(memoryID);
+            setMemories((current) => current?.filter((m) => m.id !== memoryID) ?? null);
+        } catch {
+            setError(true);
+        }
+    };
+
+    const forgetAll = async () => {
+        try {
+            await deleteAllMemories();
+            setMemories([]);
+        } catch {
+            setError(true);
+        }
+    };
+
+    if (error) {
+        return (
+            <RHSPaddingContainer>
+                <RHSText>
+                    <FormattedMessage defaultMessage='Unable to update your memories. Please try again later.'/>
+                </RHSText>
+            </RHSPaddingContainer>
+        );
+    }
+
+    if (memories === null) {
+        return null;
+    }
+
+    return (
+        <RHSPaddingContainer data-testid='rhs-memories'>
+            <HelpText>
+                <FormattedMessage defaultMessage='Things you asked the bots to remember about you, such as your role or preferences, are used in your future conversations. Ask a bot to forget something, or delete it here.'/>
+            </HelpText>
+            {memories.length === 0 ? (
+                <RHSText>
+                    <FormattedMessage defaultMessage='The bots don’t remember anything about you yet.'/>
+                </RHSText>
+            ) : (
+                <>
+                    <MemoryList>
+                        {memories.map((memory) => (
+                            <MemoryItem key={memory.id}>
+                                <MemoryContent>{memory.content}</MemoryContent>
+                                <ButtonIcon
+                                    aria-label={intl.formatMessage({defaultMessage: 'Forget'})}
+                                    onClick={() => forget(memory.id)}
+                                >
+                                    <TrashCanOutlineIcon size={16}/>
+                                </ButtonIcon>
+                            </MemoryItem>
+                        ))}
+                    </MemoryList>
+                    <ForgetAllButton onClick={forgetAll}>
+                        <FormattedMessage defaultMessage='Forget everything'/>
+                    </ForgetAllButton>
+                </>
+            )}
+        </RHSPaddingContainer>
+    );
+};
+
+const HelpText = styled(RHSText)`
+    color: rgba(var(--center-channel-color-rgb), 0.64);
+`;
+
+const MemoryList = styled.ul`
+    padding: 0;
+    margin: 0;
+    list-style: none;
+`;
+
+const MemoryItem = styled.li`
+    display: flex;
+    align-items: center;
+    gap: 8px;
+    padding: 8px 0;
+    border-bottom: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+`;
+
+const MemoryContent = styled(RHSText)`
+    flex-grow: 1;
+`;
+
+const ForgetAllButton = styled(Button)`
+    align-self: flex-start;
+    color: var(--error-text);
+
+    &:hover {
+        background-color: rgba(var(--error-text-color-rgb), 0.08);
+        color: var(--error-text);
+    }
+`;
+
+export default RHSMemories;
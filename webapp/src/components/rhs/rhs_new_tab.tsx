@@ -15,7 +15,8 @@ import {useDispatch, useSelector} from 'react-redux';
 
 import RHSImage from '../assets/rhs_image';
 
-import {createPost, getBotDirectChannel} from '@/client';
+import {MCPPrompt, createPost, getBotDirectChannel, getMCPPrompts} from '@/client';
+import {openMCPPromptDialog} from '@/commands';
 
 import {AdvancedTextEditor, CreatePost} from '@/mm_webapp';
 
@@ -24,6 +25,7 @@ import {BotsHandler} from '@/redux';
 import manifest from '@/manifest';
 
 import {Button, RHSPaddingContainer, RHSText, RHSTitle} from './common';
+import {PersonaSelector, usePersonas} from './persona_selector';
 
 const CreatePostContainer = styled.div`
 	.custom-textarea {
@@ -98,10 +100,18 @@ const RHSNewTab = ({selectPost, setCurrentTab, activeBot}: Props) => {
     const currentBots = useSelector((state: any) =>
         state[`plugins-${manifest.id}`]?.bots || [],
     );
+    const personas = usePersonas();
+    const selectedPersonaId = useSelector((state: any) => state[`plugins-${manifest.id}`]?.selectedPersonaId || '');
 
     // State for error handling
     const [channelError, setChannelError] = useState(false);
 
+    // Prompts provided by the MCP servers, offered next to the built-in ones
+    const [mcpPrompts, setMCPPrompts] = useState<MCPPrompt[]>([]);
+    useEffect(() => {
+        getMCPPrompts().then(setMCPPrompts).catch(() => setMCPPrompts([]));
+    }, []);
+
     // If botChannelId is empty, we need to create a direct channel
     useEffect(() => {
         const createDirectChannel = async () => {
@@ -192,7 +202,7 @@ const RHSNewTab = ({selectPost, setCurrentTab, activeBot}: Props) => {
                 onSubmit={async (p: any) => {
                     const post = {...p};
                     post.channel_id = botChannelId || '';
-                    post.props = {};
+                    post.props = selectedPersonaId ? {ai_persona: selectedPersonaId} : {};
                     post.uploadsInProgress = [];
                     post.file_ids = p.fileInfos.map((f: any) => f.id);
                     const created = await createPost(post);
@@ -247,7 +257,23 @@ const RHSNewTab = ({selectPost, setCurrentTab, activeBot}: Props) => {
                         <PlaylistCheckIcon/>
                         <FormattedMessage defaultMessage='To-do list'/>
                     </OptionButton>
+                    {botChannelId && mcpPrompts.map((prompt) => (
+                        <OptionButton
+                            key={`${prompt.server_id}/${prompt.name}`}
+                            title={prompt.description}
+                            onClick={() => openMCPPromptDialog(prompt, botChannelId, dispatch)}
+                        >
+                            {prompt.name}
+                        </OptionButton>
+                    ))}
                 </QuestionOptions>
+                {personas.length > 0 && (
+                    <PersonaSelector
+                        personas={personas}
+                        personaID={selectedPersonaId}
+                        setPersonaID={(personaId: string) => dispatch({type: 'SELECT_AI_PERSONA', personaId})}
+                    />
+                )}
                 <CreatePostContainer
                     data-testid='rhs-new-tab-create-post'
                 >
@@ -0,0 +1,65 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import {useIntl} from 'react-intl';
+import styled from 'styled-components';
+
+// useThreadCategoryNames returns the translated names of the categories AI threads are classified into
+export const useThreadCategoryNames = (): Record<string, string> => {
+    const intl = useIntl();
+    return {
+        coding: intl.formatMessage({defaultMessage: 'Coding'}),
+        hr: intl.formatMessage({defaultMessage: 'HR'}),
+        support: intl.formatMessage({defaultMessage: 'Support'}),
+        meeting: intl.formatMessage({defaultMessage: 'Meeting'}),
+        other: intl.formatMessage({defaultMessage: 'Other'}),
+    };
+};
+
+type Props = {
+    category: string
+    setCategory: (category: string) => void
+}
+
+// ThreadCategoryFilter shows only the chat history of a category, all of it when the category is empty
+const ThreadCategoryFilter = (props: Props) => {
+    const intl = useIntl();
+    const categoryNames = useThreadCategoryNames();
+    const options = [['', intl.formatMessage({defaultMessage: 'All'})], ...Object.entries(categoryNames)];
+
+    return (
+        <FilterContainer data-testid='thread-category-filter'>
+            {options.map(([category, name]) => (
+                <CategoryButton
+                    key={category}
+                    isActive={category === props.category}
+                    onClick={() => props.setCategory(category)}
+                >
+                    {name}
+                </CategoryButton>
+            ))}
+        </FilterContainer>
+    );
+};
+
+const FilterContainer = styled.div`
+    display: flex;
+    flex-wrap: wrap;
+    gap: 6px;
+    padding: 8px 12px;
+`;
+
+const CategoryButton = styled.button<{isActive: boolean}>`
+    border: none;
+    border-radius: 12px;
+    padding: 2px 10px;
+    font-size: 12px;
+    font-weight: 600;
+    line-height: 20px;
+    cursor: pointer;
+    color: ${(props) => (props.isActive ? 'var(--button-color)' : 'rgba(var(--center-channel-color-rgb), 0.72)')};
+    background: ${(props) => (props.isActive ? 'var(--button-bg)' : 'rgba(var(--center-channel-color-rgb), 0.08)')};
+`;
+
+export default ThreadCategoryFilter;
@@ -96,12 +96,23 @@ const SourceItem = styled.div`
     }
 `;
 
+const MeetingLabel = styled.span`
+    color: rgba(var(--center-channel-color-rgb), 0.65);
+    font-size: 12px;
+    margin-left: 8px;
+`;
+
 interface Source {
     postId: string;
     channelId: string;
     userId: string;
     content: string;
     score: number;
+
+    // Set for meeting summaries, transcripts and attached files
+    sourceType?: string;
+    meetingDate?: string;
+    fileName?: string;
 }
 
 interface SourceItemProps {
@@ -117,6 +128,30 @@ const SearchSource = ({source, index}: SourceItemProps & {index: number}) => {
                     <ScoreIcon className='icon icon-check-circle'/>
                     {formatScore(source.score)}
                 </RelevanceScore>
+                {source.sourceType === 'meeting_summary' && (
+                    <MeetingLabel>
+                        <FormattedMessage
+                            defaultMessage='Meeting summary · {date}'
+                            values={{date: source.meetingDate}}
+                        />
+                    </MeetingLabel>
+                )}
+                {source.sourceType === 'meeting_transcript' && (
+                    <MeetingLabel>
+                        <FormattedMessage
+                            defaultMessage='Meeting transcript · {date}'
+                            values={{date: source.meetingDate}}
+                        />
+                    </MeetingLabel>
+                )}
+                {source.sourceType === 'file' && (
+                    <MeetingLabel>
+                        <FormattedMessage
+                            defaultMessage='File · {name}'
+                            values={{name: source.fileName}}
+                        />
+                    </MeetingLabel>
+                )}
             </SourceHeader>
             <PostPreview
                 postId={source.postId}
@@ -154,7 +189,7 @@ export const SearchSources = ({sources}: Props) => {
             <SourcesList isOpen={isOpen}>
                 {sources.map((source, index) => (
                     <SearchSource
-                        key={source.postId}
+                        key={`${source.postId}_${index}`}
                         index={index}
                         source={source}
                     />
@@ -240,6 +240,9 @@ export const SelectUser = (props: SelectUserProps) => {
 type SelectChannelProps = {
     channelIDs: string[];
     onChangeChannelIDs: (channelIds: string[]) => void;
+
+    // Search only the channels the current user can access instead of all channels
+    nonAdminSearch?: boolean;
 };
 
 export const SelectChannel = (props: SelectChannelProps) => {
@@ -264,7 +267,7 @@ export const SelectChannel = (props: SelectChannelProps) => {
     }, [props.channelIDs]);
 
     const loadOptions = async (inputValue: string) => {
-        const channels = await searchAllChannels(inputValue);
+        const channels = await searchAllChannels(inputValue, props.nonAdminSearch);
         return channels.map((channel: ChannelWithTeamData) => ({
             value: channel.id,
             label: channel.display_name,
@@ -0,0 +1,126 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useEffect, useState} from 'react';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import styled from 'styled-components';
+
+import {CheckIcon, ChevronDownIcon} from '@mattermost/compass-icons/components';
+
+import {SummaryTemplate, getSummaryTemplates} from '@/client';
+
+import DotMenu, {DropdownMenu, DropdownMenuItem} from './dot_menu';
+import {GrayPill} from './pill';
+
+export const defaultSummaryTemplateID = 'standard';
+
+// useSummaryTemplates loads the templates meeting summaries can be requested in, only once enabled
+export const useSummaryTemplates = (enabled: boolean) => {
+    const [templates, setTemplates] = useState<SummaryTemplate[]>([]);
+
+    useEffect(() => {
+        if (!enabled) {
+            return;
+        }
+        getSummaryTemplates().then(setTemplates).catch(() => setTemplates([]));
+    }, [enabled]);
+
+    return templates;
+};
+
+// useSummaryTemplateName returns the name of a template, built-in templates are translated
+const useSummaryTemplateName = () => {
+    const intl = useIntl();
+    const builtinNames: Record<string, string> = {
+        standard: intl.formatMessage({defaultMessage: 'Standard'}),
+        structured: intl.formatMessage({defaultMessage: 'Structured'}),
+        standup: intl.formatMessage({defaultMessage: 'Standup'}),
+        retro: intl.formatMessage({defaultMessage: 'Retrospective'}),
+        incident_review: intl.formatMessage({defaultMessage: 'Incident review'}),
+        customer_call: intl.formatMessage({defaultMessage: 'Customer call'}),
+    };
+
+    return (template: SummaryTemplate) => (template.custom ? template.name : builtinNames[template.id] ?? template.name);
+};
+
+type Props = {
+    templates: SummaryTemplate[]
+    templateID: string
+    setTemplateID: (id: string) => void
+}
+
+export const DropdownSummaryTemplateSelector = (props: Props) => {
+    const templateName = useSummaryTemplateName();
+    const active = props.templates.find((t) => t.id === props.templateID);
+
+    return (
+        <DotMenu
+            icon={(
+                <>
+                    <SelectMessage>
+                        <FormattedMessage defaultMessage='Summary template:'/>
+                    </SelectMessage>
+                    <TemplatePill>
+                        {active ? templateName(active) : props.templateID}
+                        <ChevronDownIcon/>
+                    </TemplatePill>
+                </>
+            )}
+            title={active ? templateName(active) : props.templateID}
+            dotMenuButton={SelectorContainer}
+            dropdownMenu={StyledDropdownMenu}
+            portal={false}
+            testId='summary-template-selector'
+        >
+            {props.templates.map((template) => (
+                <StyledDropdownMenuItem
+                    key={template.id}
+                    onClick={() => props.setTemplateID(template.id)}
+                >
+                    {templateName(template)}
+                    {template.id === props.templateID && (
+                        <StyledCheckIcon/>
+                    )}
+                </StyledDropdownMenuItem>
+            ))}
+        </DotMenu>
+    );
+};
+
+const SelectorContainer = styled.div`
+	display: flex;
+	flex-direction: row;
+	align-items: center;
+	gap: 8px;
+
+	margin: 8px 16px;
+	color: rgba(var(--center-channel-color-rgb), 0.56);
+`;
+
+const TemplatePill = styled(GrayPill)`
+	font-size: 12px;
+	padding: 2px 6px;
+	gap: 0;
+`;
+
+const SelectMessage = styled.div`
+	font-size: 12px;
+	font-weight: 600;
+	line-height: 16px;
+	letter-spacing: 0.24px;
+	text-transform: uppercase;
+`;
+
+const StyledDropdownMenu = styled(DropdownMenu)`
+	min-width: 220px;
+`;
+
+const StyledDropdownMenuItem = styled(DropdownMenuItem)`
+	padding: 8px 16px;
+`;
+
+const StyledCheckIcon = styled(CheckIcon)`
+	margin-left: auto;
+	color: var(--button-bg);
+`;
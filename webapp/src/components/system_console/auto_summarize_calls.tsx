@@ -0,0 +1,61 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {SelectChannel} from '../select';
+
+import {LLMBotConfig} from './bot';
+import {HelpText, ItemLabel, ItemList, SelectionItem, SelectionItemOption} from './item';
+
+export type AutoSummarizeCallsConfig = {
+    botName: string;
+    channelIDs: string[];
+};
+
+type Props = {
+    value: AutoSummarizeCallsConfig;
+    bots: LLMBotConfig[];
+    onChange: (value: AutoSummarizeCallsConfig) => void;
+};
+
+const AutoSummarizeCalls = (props: Props) => {
+    const intl = useIntl();
+
+    return (
+        <ItemList>
+            <ItemLabel>
+                <FormattedMessage defaultMessage='Channels'/>
+            </ItemLabel>
+            <div>
+                <SelectChannel
+                    channelIDs={props.value.channelIDs || []}
+                    onChangeChannelIDs={(channelIDs: string[]) => props.onChange({...props.value, channelIDs})}
+                />
+                <HelpText>
+                    <FormattedMessage defaultMessage='Recordings the Calls bot posts in these channels are transcribed and summarized without anyone asking. The summary is sent to the host of the call in a direct message.'/>
+                </HelpText>
+            </div>
+            <SelectionItem
+                label={intl.formatMessage({defaultMessage: 'Summarizing bot'})}
+                value={props.value.botName}
+                onChange={(e) => props.onChange({...props.value, botName: e.target.value})}
+            >
+                <SelectionItemOption value=''>
+                    {intl.formatMessage({defaultMessage: 'Default bot'})}
+                </SelectionItemOption>
+                {props.bots.map((bot) => (
+                    <SelectionItemOption
+                        key={bot.name}
+                        value={bot.name}
+                    >
+                        {bot.displayName}
+                    </SelectionItemOption>
+                ))}
+            </SelectionItem>
+        </ItemList>
+    );
+};
+
+export default AutoSummarizeCalls;
@@ -0,0 +1,112 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import {useIntl} from 'react-intl';
+
+import {BooleanItem, ItemList, TextItem} from './item';
+
+export type AzureSpeechConfig = {
+    enabled: boolean;
+    region: string;
+    apiKey: string;
+    endpoint: string;
+    locale: string;
+    conversationTranscription: boolean;
+    maxSpeakers: number;
+    pricePerMinute: number;
+};
+
+export const defaultAzureSpeechConfig: AzureSpeechConfig = {
+    enabled: false,
+    region: '',
+    apiKey: '',
+    endpoint: '',
+    locale: '',
+    conversationTranscription: false,
+    maxSpeakers: 0,
+    pricePerMinute: 0,
+};
+
+type Props = {
+    value: AzureSpeechConfig;
+    onChange: (config: AzureSpeechConfig) => void;
+};
+
+const AzureSpeech = (props: Props) => {
+    const intl = useIntl();
+    const config = {...defaultAzureSpeechConfig, ...props.value};
+
+    return (
+        <ItemList>
+            <BooleanItem
+                label={intl.formatMessage({defaultMessage: 'Use Azure Speech for transcription'})}
+                value={config.enabled}
+                onChange={(to) => props.onChange({...config, enabled: to})}
+                helpText={intl.formatMessage({defaultMessage: 'Transcribe recordings with Azure AI Speech instead of the transcript generator bot.'})}
+            />
+            {config.enabled && (
+                <>
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Region'})}
+                        value={config.region}
+                        placeholder='eastus'
+                        onChange={(e) => props.onChange({...config, region: e.target.value})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'API Key'})}
+                        type='password'
+                        value={config.apiKey}
+                        onChange={(e) => props.onChange({...config, apiKey: e.target.value})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Custom endpoint'})}
+                        value={config.endpoint}
+                        placeholder='https://eastus.api.cognitive.microsoft.com'
+                        helptext={intl.formatMessage({defaultMessage: 'Optional. Overrides the regional endpoint, for example for sovereign clouds or private endpoints.'})}
+                        onChange={(e) => props.onChange({...config, endpoint: e.target.value})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Locale'})}
+                        value={config.locale}
+                        placeholder='en-US'
+                        helptext={intl.formatMessage({defaultMessage: 'Language of the recordings. Defaults to en-US.'})}
+                        onChange={(e) => props.onChange({...config, locale: e.target.value})}
+                    />
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Conversation transcription'})}
+                        value={config.conversationTranscription}
+                        onChange={(to) => props.onChange({...config, conversationTranscription: to})}
+                        helpText={intl.formatMessage({defaultMessage: 'Tell speakers apart and attribute the transcript to each of them.'})}
+                    />
+                    {config.conversationTranscription && (
+                        <TextItem
+                            label={intl.formatMessage({defaultMessage: 'Maximum speakers'})}
+                            type='number'
+                            value={config.maxSpeakers.toString()}
+                            helptext={intl.formatMessage({defaultMessage: 'Maximum number of speakers to tell apart. Leave at 0 for the default of 10.'})}
+                            onChange={(e) => {
+                                const value = parseInt(e.target.value, 10);
+                                props.onChange({...config, maxSpeakers: isNaN(value) ? 0 : value});
+                            }}
+                        />
+                    )}
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Price per minute'})}
+                        type='number'
+                        step='0.0001'
+                        min='0'
+                        value={config.pricePerMinute.toString()}
+                        helptext={intl.formatMessage({defaultMessage: 'Price in US dollars per minute of transcribed audio used for cost estimates. Leave at 0 to use the list price.'})}
+                        onChange={(e) => {
+                            const value = parseFloat(e.target.value);
+                            props.onChange({...config, pricePerMinute: isNaN(value) ? 0 : value});
+                        }}
+                    />
+                </>
+            )}
+        </ItemList>
+    );
+};
+
+export default AzureSpeech;
@@ -0,0 +1,129 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useEffect, useState} from 'react';
+import styled from 'styled-components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {BackgroundJob, cancelBackgroundJob, getBackgroundJobs} from '@/client';
+
+import {TertiaryButton} from '../assets/buttons';
+
+// BackgroundJobs lists the latest transcriptions and summaries of the job queue so admins can see
+// what is stuck or failing, and cancel pending or running jobs.
+const BackgroundJobs = () => {
+    const intl = useIntl();
+    const [jobs, setJobs] = useState<BackgroundJob[] | null>(null);
+    const [error, setError] = useState('');
+
+    const jobTypes: Record<string, string> = {
+        meetings_summarize_recording: intl.formatMessage({defaultMessage: 'Recording summary'}),
+        meetings_summarize_transcription: intl.formatMessage({defaultMessage: 'Transcript summary'}),
+    };
+    const jobStates: Record<BackgroundJob['state'], string> = {
+        pending: intl.formatMessage({defaultMessage: 'Pending'}),
+        running: intl.formatMessage({defaultMessage: 'Running'}),
+        succeeded: intl.formatMessage({defaultMessage: 'Succeeded'}),
+        failed: intl.formatMessage({defaultMessage: 'Failed'}),
+        cancelled: intl.formatMessage({defaultMessage: 'Cancelled'}),
+    };
+
+    const load = async () => {
+        setError('');
+        try {
+            setJobs(await getBackgroundJobs());
+        } catch {
+            setError(intl.formatMessage({defaultMessage: 'Unable to load the background jobs.'}));
+        }
+    };
+
+    useEffect(() => {
+        load();
+    }, []);
+
+    const cancel = async (jobID: string) => {
+        setError('');
+        try {
+            await cancelBackgroundJob(jobID);
+        } catch (err: any) {
+            if (err?.status_code !== 409) {
+                setError(intl.formatMessage({defaultMessage: 'Unable to cancel the job.'}));
+            }
+        }
+        load();
+    };
+
+    return (
+        <>
+            <TertiaryButton onClick={load}>
+                <FormattedMessage defaultMessage='Refresh'/>
+            </TertiaryButton>
+            {error && <ErrorText>{error}</ErrorText>}
+            {jobs && jobs.length === 0 && (
+                <Empty>
+                    <FormattedMessage defaultMessage='No background jobs in the last 30 days.'/>
+                </Empty>
+            )}
+            {jobs && jobs.length > 0 && (
+                <JobsTable>
+                    <thead>
+                        <tr>
+                            <th><FormattedMessage defaultMessage='Job'/></th>
+                            <th><FormattedMessage defaultMessage='Created'/></th>
+                            <th><FormattedMessage defaultMessage='State'/></th>
+                            <th><FormattedMessage defaultMessage='Attempts'/></th>
+                            <th><FormattedMessage defaultMessage='Last error'/></th>
+                            <th/>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {jobs.map((job) => (
+                            <tr key={job.id}>
+                                <td>{jobTypes[job.type] ?? job.type}</td>
+                                <td>{new Date(job.createAt).toLocaleString()}</td>
+                                <td>{jobStates[job.state] ?? job.state}</td>
+                                <td>{`${job.attempts}/${job.maxAttempts}`}</td>
+                                <td>{job.error}</td>
+                                <td>
+                                    {(job.state === 'pending' || job.state === 'running') && (
+                                        <TertiaryButton onClick={() => cancel(job.id)}>
+                                            <FormattedMessage defaultMessage='Cancel'/>
+                                        </TertiaryButton>
+                                    )}
+                                </td>
+                            </tr>
+                        ))}
+                    </tbody>
+                </JobsTable>
+            )}
+        </>
+    );
+};
+
+const JobsTable = styled.table`
+    margin-top: 16px;
+    width: 100%;
+
+    th, td {
+        padding: 6px 12px;
+        text-align: left;
+        vertical-align: middle;
+        border-bottom: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    }
+
+    th {
+        font-weight: 600;
+    }
+`;
+
+const Empty = styled.div`
+    margin-top: 16px;
+`;
+
+const ErrorText = styled.div`
+    color: var(--error-text);
+    font-size: 12px;
+    margin-top: 16px;
+`;
+
+export default BackgroundJobs;
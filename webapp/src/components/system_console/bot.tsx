@@ -12,9 +12,14 @@ import {DangerPill, Pill} from '../pill';
 
 import {ButtonIcon} from '../assets/buttons';
 
-import {BooleanItem, ItemList, SelectionItem, SelectionItemOption, TextItem} from './item';
+import {SelectChannel, SelectUser} from '../select';
+
+import {BooleanItem, HelpText, ItemLabel, ItemList, SelectionItem, SelectionItemOption, TextItem} from './item';
 import AvatarItem from './avatar';
 import {ChannelAccessLevelItem, UserAccessLevelItem} from './llm_access';
+import ModelAliases, {ModelAlias, invalidModelAliases} from './model_aliases';
+import ModelPrices, {ModelPrice} from './model_prices';
+import ServiceRegions, {ServiceRegion, invalidServiceRegions, serviceTypeSupportsRegions} from './service_regions';
 
 export type LLMService = {
     type: string
@@ -26,6 +31,15 @@ export type LLMService = {
     streamingTimeoutSeconds: number
     sendUserId: boolean
     outputTokenLimit: number
+    inputTokenPrice?: number
+    outputTokenPrice?: number
+    transcriptionPricePerMinute?: number
+    modelPrices?: ModelPrice[]
+    modelAliases?: ModelAlias[]
+    replicaURLs?: string[]
+    healthCheckIntervalSeconds?: number
+    regions?: ServiceRegion[]
+    regionRouting?: string
 }
 
 export enum ChannelAccessLevel {
@@ -55,10 +69,66 @@ export type LLMBotConfig = {
     userAccessLevel: UserAccessLevel
     userIDs: string[]
     teamIDs: string[]
+    experiment?: ExperimentConfig
+    ensemble?: EnsembleConfig
+    handoff?: HandoffConfig
+    historyRelevanceFiltering?: boolean
+    featureModels?: FeatureModelsConfig
+}
+
+export type FeatureModelsConfig = {
+    enabled: boolean
+    smallModel: string
+    models: Record<string, string>
+}
+
+export type ExperimentConfig = {
+    enabled: boolean
+    name: string
+    alternateModel: string
+    percentage: number
+}
+
+export type EnsembleConfig = {
+    enabled: boolean
+    secondaryBot: string
+    channelIDs: string[]
 }
 
+export type HandoffConfig = {
+    enabled: boolean
+    channelID: string
+    onCallUserIDs: string[]
+}
+
+const defaultHandoff: HandoffConfig = {
+    enabled: false,
+    channelID: '',
+    onCallUserIDs: [],
+};
+
+const defaultEnsemble: EnsembleConfig = {
+    enabled: false,
+    secondaryBot: '',
+    channelIDs: [],
+};
+
+const defaultFeatureModels: FeatureModelsConfig = {
+    enabled: false,
+    smallModel: '',
+    models: {},
+};
+
+const defaultExperiment: ExperimentConfig = {
+    enabled: false,
+    name: '',
+    alternateModel: '',
+    percentage: 10,
+};
+
 type Props = {
     bot: LLMBotConfig
+    otherBots: LLMBotConfig[]
     onChange: (bot: LLMBotConfig) => void
     onDelete: () => void
     changedAvatar: (image: File) => void
@@ -69,6 +139,7 @@ const mapServiceTypeToDisplayName = new Map<string, string>([
     ['openaicompatible', 'OpenAI Compatible'],
     ['azure', 'Azure'],
     ['anthropic', 'Anthropic'],
+    ['vllm', 'vLLM'],
 ]);
 
 function serviceTypeToDisplayName(serviceType: string): string {
@@ -81,11 +152,13 @@ const Bot = (props: Props) => {
     const missingInfo = props.bot.name === '' ||
 		props.bot.displayName === '' ||
 		props.bot.service.type === '' ||
-		(props.bot.service.type !== 'openaicompatible' && props.bot.service.type !== 'azure' && props.bot.service.apiKey === '') ||
-		((props.bot.service.type === 'openaicompatible' || props.bot.service.type === 'azure') && props.bot.service.apiURL === '');
+		(props.bot.service.type !== 'openaicompatible' && props.bot.service.type !== 'azure' && props.bot.service.type !== 'vllm' && props.bot.service.apiKey === '') ||
+		((props.bot.service.type === 'openaicompatible' || props.bot.service.type === 'azure' || props.bot.service.type === 'vllm') && props.bot.service.apiURL === '');
 
     const invalidUsername = props.bot.name !== '' && (!(/^[a-z0-9.\-_]+$/).test(props.bot.name) || !(/[a-z]/).test(props.bot.name.charAt(0)));
     const invalidMaxTokens = props.bot.service.type === 'anthropic' && props.bot.service?.outputTokenLimit === 0;
+    const invalidAliases = invalidModelAliases(props.bot.service.type, props.bot.service.modelAliases);
+    const invalidRegions = invalidServiceRegions(props.bot.service.type, props.bot.service.regions);
     return (
         <BotContainer>
             <HeaderContainer onClick={() => setOpen((o) => !o)}>
@@ -118,6 +191,18 @@ const Bot = (props: Props) => {
                         <FormattedMessage defaultMessage='Output token limit must be greater than 0'/>
                     </DangerPill>
                 )}
+                {invalidAliases && (
+                    <DangerPill>
+                        <AlertOutlineIcon/>
+                        <FormattedMessage defaultMessage='Invalid model aliases'/>
+                    </DangerPill>
+                )}
+                {invalidRegions && (
+                    <DangerPill>
+                        <AlertOutlineIcon/>
+                        <FormattedMessage defaultMessage='Invalid regions'/>
+                    </DangerPill>
+                )}
 
                 <ButtonIcon
                     onClick={props.onDelete}
@@ -154,6 +239,7 @@ const Bot = (props: Props) => {
                             <SelectionItemOption value='openaicompatible'>{'OpenAI Compatible'}</SelectionItemOption>
                             <SelectionItemOption value='azure'>{'Azure'}</SelectionItemOption>
                             <SelectionItemOption value='anthropic'>{'Anthropic'}</SelectionItemOption>
+                            <SelectionItemOption value='vllm'>{'vLLM'}</SelectionItemOption>
                         </SelectionItem>
                         <ServiceItem
                             service={props.bot.service}
@@ -166,7 +252,7 @@ const Bot = (props: Props) => {
                             value={props.bot.customInstructions}
                             onChange={(e) => props.onChange({...props.bot, customInstructions: e.target.value})}
                         />
-                        {(props.bot.service.type === 'openai' || props.bot.service.type === 'openaicompatible' || props.bot.service.type === 'azure' || props.bot.service.type === 'anthropic') && (
+                        {(props.bot.service.type === 'openai' || props.bot.service.type === 'openaicompatible' || props.bot.service.type === 'azure' || props.bot.service.type === 'anthropic' || props.bot.service.type === 'vllm') && (
                             <>
                                 <BooleanItem
                                     label={
@@ -189,6 +275,12 @@ const Bot = (props: Props) => {
                                 />
                             </>
                         )}
+                        <BooleanItem
+                            label={intl.formatMessage({defaultMessage: 'Keep the most relevant history'})}
+                            value={props.bot.historyRelevanceFiltering ?? false}
+                            onChange={(to: boolean) => props.onChange({...props.bot, historyRelevanceFiltering: to})}
+                            helpText={intl.formatMessage({defaultMessage: 'When a conversation is too long for the model, drop the messages least related to the latest question first instead of the oldest ones. Requires embedding search to be configured.'})}
+                        />
                         <ChannelAccessLevelItem
                             label={intl.formatMessage({defaultMessage: 'Channel access'})}
                             level={props.bot.channelAccessLevel ?? ChannelAccessLevel.All}
@@ -204,6 +296,24 @@ const Bot = (props: Props) => {
                             teamIDs={props.bot.teamIDs ?? []}
                             onChangeIDs={(userIds: string[], teamIds: string[]) => props.onChange({...props.bot, userIDs: userIds, teamIDs: teamIds})}
                         />
+                        <FeatureModelsItem
+                            featureModels={props.bot.featureModels ?? defaultFeatureModels}
+                            serviceType={props.bot.service.type}
+                            onChange={(featureModels) => props.onChange({...props.bot, featureModels})}
+                        />
+                        <ExperimentItem
+                            experiment={props.bot.experiment ?? defaultExperiment}
+                            onChange={(experiment) => props.onChange({...props.bot, experiment})}
+                        />
+                        <EnsembleItem
+                            ensemble={props.bot.ensemble ?? defaultEnsemble}
+                            otherBots={props.otherBots}
+                            onChange={(ensemble) => props.onChange({...props.bot, ensemble})}
+                        />
+                        <HandoffItem
+                            handoff={props.bot.handoff ?? defaultHandoff}
+                            onChange={(handoff) => props.onChange({...props.bot, handoff})}
+                        />
 
                     </ItemList>
                 </ItemListContainer>
@@ -219,6 +329,234 @@ const Horizontal = styled.div`
 	gap: 8px;
 `;
 
+const defaultSmallModels: Record<string, string> = {
+    openai: 'gpt-4o-mini',
+    anthropic: 'claude-3-5-haiku-latest',
+};
+
+type FeatureModelsItemProps = {
+    featureModels: FeatureModelsConfig
+    serviceType: string
+    onChange: (featureModels: FeatureModelsConfig) => void
+}
+
+const FeatureModelsItem = (props: FeatureModelsItemProps) => {
+    const intl = useIntl();
+
+    const setModel = (feature: string, model: string) => {
+        props.onChange({...props.featureModels, models: {...props.featureModels.models, [feature]: model.trim()}});
+    };
+
+    return (
+        <>
+            <BooleanItem
+                label={intl.formatMessage({defaultMessage: 'Use a smaller model for light tasks'})}
+                value={props.featureModels.enabled}
+                onChange={(to: boolean) => props.onChange({...props.featureModels, enabled: to})}
+                helpText={intl.formatMessage({defaultMessage: 'Generate conversation titles, emoji reactions, compose suggestions, follow-up questions and summaries of parts of long transcripts and documents with a small, cheaper model. Answers keep using the default model.'})}
+            />
+            {props.featureModels.enabled && (
+                <>
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Small model'})}
+                        placeholder={defaultSmallModels[props.serviceType] ?? intl.formatMessage({defaultMessage: 'Defaults to the default model'})}
+                        value={props.featureModels.smallModel}
+                        onChange={(e) => props.onChange({...props.featureModels, smallModel: e.target.value.trim()})}
+                        helptext={intl.formatMessage({defaultMessage: 'A model of the same service, or one of its model aliases.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Conversation titles model'})}
+                        placeholder={intl.formatMessage({defaultMessage: 'Defaults to the small model'})}
+                        value={props.featureModels.models?.titles ?? ''}
+                        onChange={(e) => setModel('titles', e.target.value)}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Emoji reactions model'})}
+                        placeholder={intl.formatMessage({defaultMessage: 'Defaults to the small model'})}
+                        value={props.featureModels.models?.reactions ?? ''}
+                        onChange={(e) => setModel('reactions', e.target.value)}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Part summaries model'})}
+                        placeholder={intl.formatMessage({defaultMessage: 'Defaults to the small model'})}
+                        value={props.featureModels.models?.chunkSummaries ?? ''}
+                        onChange={(e) => setModel('chunkSummaries', e.target.value)}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Compose suggestions model'})}
+                        placeholder={intl.formatMessage({defaultMessage: 'Defaults to the small model'})}
+                        value={props.featureModels.models?.compose ?? ''}
+                        onChange={(e) => setModel('compose', e.target.value)}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Follow-up questions model'})}
+                        placeholder={intl.formatMessage({defaultMessage: 'Defaults to the small model'})}
+                        value={props.featureModels.models?.followUps ?? ''}
+                        onChange={(e) => setModel('followUps', e.target.value)}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Answers model'})}
+                        placeholder={intl.formatMessage({defaultMessage: 'Defaults to the default model'})}
+                        value={props.featureModels.models?.chat ?? ''}
+                        onChange={(e) => setModel('chat', e.target.value)}
+                    />
+                </>
+            )}
+        </>
+    );
+};
+
+type ExperimentItemProps = {
+    experiment: ExperimentConfig
+    onChange: (experiment: ExperimentConfig) => void
+}
+
+const ExperimentItem = (props: ExperimentItemProps) => {
+    const intl = useIntl();
+
+    return (
+        <>
+            <BooleanItem
+                label={intl.formatMessage({defaultMessage: 'Enable model experiment'})}
+                value={props.experiment.enabled}
+                onChange={(to: boolean) => props.onChange({...props.experiment, enabled: to})}
+                helpText={intl.formatMessage({defaultMessage: 'Send a percentage of requests to an alternate model. Responses are tagged with the model variant and latency and reaction feedback are recorded per variant.'})}
+            />
+            {props.experiment.enabled && (
+                <>
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Experiment name'})}
+                        placeholder={intl.formatMessage({defaultMessage: 'Defaults to the bot name'})}
+                        value={props.experiment.name}
+                        onChange={(e) => props.onChange({...props.experiment, name: e.target.value})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Alternate model'})}
+                        value={props.experiment.alternateModel}
+                        onChange={(e) => props.onChange({...props.experiment, alternateModel: e.target.value})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Alternate model traffic (%)'})}
+                        type='number'
+                        min='0'
+                        max='100'
+                        value={props.experiment.percentage.toString()}
+                        onChange={(e) => {
+                            const value = parseInt(e.target.value, 10);
+                            const percentage = isNaN(value) ? 0 : Math.min(Math.max(value, 0), 100);
+                            props.onChange({...props.experiment, percentage});
+                        }}
+                    />
+                </>
+            )}
+        </>
+    );
+};
+
+type EnsembleItemProps = {
+    ensemble: EnsembleConfig
+    otherBots: LLMBotConfig[]
+    onChange: (ensemble: EnsembleConfig) => void
+}
+
+const EnsembleItem = (props: EnsembleItemProps) => {
+    const intl = useIntl();
+
+    return (
+        <>
+            <BooleanItem
+                label={intl.formatMessage({defaultMessage: 'Enable ensemble answers'})}
+                value={props.ensemble.enabled}
+                onChange={(to: boolean) => props.onChange({...props.ensemble, enabled: to})}
+                helpText={intl.formatMessage({defaultMessage: 'Answer every question twice and have the bot reconcile the answers, flagging any disagreement. Doubles the cost and slows down responses, tools are not available.'})}
+            />
+            {props.ensemble.enabled && (
+                <>
+                    <SelectionItem
+                        label={intl.formatMessage({defaultMessage: 'Second answer from'})}
+                        value={props.ensemble.secondaryBot}
+                        onChange={(e) => props.onChange({...props.ensemble, secondaryBot: e.target.value})}
+                        helptext={intl.formatMessage({defaultMessage: 'Another bot whose model gives the second answer. When answered by the same bot, the answers are checked for consistency.'})}
+                    >
+                        <SelectionItemOption value=''>
+                            {intl.formatMessage({defaultMessage: 'This bot'})}
+                        </SelectionItemOption>
+                        {props.otherBots.map((bot) => (
+                            <SelectionItemOption
+                                key={bot.id}
+                                value={bot.name}
+                            >
+                                {bot.displayName}
+                            </SelectionItemOption>
+                        ))}
+                    </SelectionItem>
+                    <ItemLabel>
+                        <FormattedMessage defaultMessage='Ensemble channels'/>
+                    </ItemLabel>
+                    <div>
+                        <SelectChannel
+                            channelIDs={props.ensemble.channelIDs ?? []}
+                            onChangeChannelIDs={(channelIDs: string[]) => props.onChange({...props.ensemble, channelIDs})}
+                        />
+                        <HelpText>
+                            <FormattedMessage defaultMessage='Channels to use ensemble answers in, such as legal or security channels. Leave empty to use them everywhere.'/>
+                        </HelpText>
+                    </div>
+                </>
+            )}
+        </>
+    );
+};
+
+type HandoffItemProps = {
+    handoff: HandoffConfig
+    onChange: (handoff: HandoffConfig) => void
+}
+
+const HandoffItem = (props: HandoffItemProps) => {
+    const intl = useIntl();
+
+    return (
+        <>
+            <BooleanItem
+                label={intl.formatMessage({defaultMessage: 'Enable handoff to people'})}
+                value={props.handoff.enabled}
+                onChange={(to: boolean) => props.onChange({...props.handoff, enabled: to})}
+                helpText={intl.formatMessage({defaultMessage: 'Let users hand a direct message conversation over to a support channel, with a summary, the links shared and the open questions.'})}
+            />
+            {props.handoff.enabled && (
+                <>
+                    <ItemLabel>
+                        <FormattedMessage defaultMessage='Handoff channel'/>
+                    </ItemLabel>
+                    <div>
+                        <SelectChannel
+                            channelIDs={props.handoff.channelID ? [props.handoff.channelID] : []}
+                            onChangeChannelIDs={(channelIDs: string[]) => props.onChange({...props.handoff, channelID: channelIDs[channelIDs.length - 1] ?? ''})}
+                        />
+                        <HelpText>
+                            <FormattedMessage defaultMessage='The support or escalation channel conversations are handed off to.'/>
+                        </HelpText>
+                    </div>
+                    <ItemLabel>
+                        <FormattedMessage defaultMessage='On-call people'/>
+                    </ItemLabel>
+                    <div>
+                        <SelectUser
+                            userIDs={props.handoff.onCallUserIDs ?? []}
+                            teamIDs={[]}
+                            onChangeIDs={(userIDs: string[]) => props.onChange({...props.handoff, onCallUserIDs: userIDs})}
+                        />
+                        <HelpText>
+                            <FormattedMessage defaultMessage='People mentioned when a conversation is handed off.'/>
+                        </HelpText>
+                    </div>
+                </>
+            )}
+        </>
+    );
+};
+
 type ServiceItemProps = {
     service: LLMService
     onChange: (service: LLMService) => void
@@ -228,6 +566,7 @@ const ServiceItem = (props: ServiceItemProps) => {
     const type = props.service.type;
     const intl = useIntl();
     const isOpenAIType = type === 'openai' || type === 'openaicompatible' || type === 'azure';
+    const isVLLM = type === 'vllm';
 
     const getDefaultOutputTokenLimit = () => {
         switch (type) {
@@ -240,13 +579,37 @@ const ServiceItem = (props: ServiceItemProps) => {
 
     return (
         <>
-            {(type === 'openaicompatible' || type === 'azure') && (
+            {(type === 'openaicompatible' || type === 'azure' || isVLLM) && (
                 <TextItem
                     label={intl.formatMessage({defaultMessage: 'API URL'})}
                     value={props.service.apiURL}
+                    placeholder={isVLLM ? 'http://vllm-1:8000/v1' : undefined}
                     onChange={(e) => props.onChange({...props.service, apiURL: e.target.value})}
                 />
             )}
+            {isVLLM && (
+                <>
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Additional replica URLs'})}
+                        multiline={true}
+                        value={(props.service.replicaURLs ?? []).join('\n')}
+                        placeholder={'http://vllm-2:8000/v1\nhttp://vllm-3:8000/v1'}
+                        helptext={intl.formatMessage({defaultMessage: 'One URL per line. Requests are balanced between the API URL and these replicas, sending each request to the least busy healthy replica that serves the model.'})}
+                        onChange={(e) => props.onChange({...props.service, replicaURLs: e.target.value.split('\n')})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Health check interval seconds'})}
+                        type='number'
+                        value={props.service.healthCheckIntervalSeconds?.toString() || '0'}
+                        helptext={intl.formatMessage({defaultMessage: 'How often each replica is asked which models it serves. Leave at 0 to check every 30 seconds.'})}
+                        onChange={(e) => {
+                            const value = parseInt(e.target.value, 10);
+                            const healthCheckIntervalSeconds = isNaN(value) ? 0 : value;
+                            props.onChange({...props.service, healthCheckIntervalSeconds});
+                        }}
+                    />
+                </>
+            )}
             <TextItem
                 label={intl.formatMessage({defaultMessage: 'API Key'})}
                 type='password'
@@ -268,11 +631,24 @@ const ServiceItem = (props: ServiceItemProps) => {
                     />
                 </>
             )}
+            {serviceTypeSupportsRegions(type) && (
+                <ServiceRegions
+                    serviceType={type}
+                    regions={props.service.regions ?? []}
+                    routing={props.service.regionRouting ?? ''}
+                    onChange={(regions, regionRouting) => props.onChange({...props.service, regions, regionRouting})}
+                />
+            )}
             <TextItem
                 label={intl.formatMessage({defaultMessage: 'Default model'})}
                 value={props.service.defaultModel}
                 onChange={(e) => props.onChange({...props.service, defaultModel: e.target.value})}
             />
+            <ModelAliases
+                serviceType={type}
+                aliases={props.service.modelAliases ?? []}
+                onChange={(modelAliases) => props.onChange({...props.service, modelAliases})}
+            />
             <TextItem
                 label={intl.formatMessage({defaultMessage: 'Input token limit'})}
                 type='number'
@@ -293,7 +669,46 @@ const ServiceItem = (props: ServiceItemProps) => {
                     props.onChange({...props.service, outputTokenLimit});
                 }}
             />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Input token price'})}
+                type='number'
+                value={props.service.inputTokenPrice?.toString() || '0'}
+                helptext={intl.formatMessage({defaultMessage: 'Price in US dollars per million input tokens, used to estimate the cost of large jobs. Leave at 0 to use the built in price of the default model.'})}
+                onChange={(e) => {
+                    const value = parseFloat(e.target.value);
+                    const inputTokenPrice = isNaN(value) ? 0 : value;
+                    props.onChange({...props.service, inputTokenPrice});
+                }}
+            />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Output token price'})}
+                type='number'
+                value={props.service.outputTokenPrice?.toString() || '0'}
+                helptext={intl.formatMessage({defaultMessage: 'Price in US dollars per million output tokens.'})}
+                onChange={(e) => {
+                    const value = parseFloat(e.target.value);
+                    const outputTokenPrice = isNaN(value) ? 0 : value;
+                    props.onChange({...props.service, outputTokenPrice});
+                }}
+            />
+            <ModelPrices
+                prices={props.service.modelPrices ?? []}
+                onChange={(modelPrices) => props.onChange({...props.service, modelPrices})}
+            />
             {isOpenAIType && (
+                <TextItem
+                    label={intl.formatMessage({defaultMessage: 'Transcription price per minute'})}
+                    type='number'
+                    value={props.service.transcriptionPricePerMinute?.toString() || '0'}
+                    helptext={intl.formatMessage({defaultMessage: 'Price in US dollars per minute of transcribed audio. Leave at 0 to use the Whisper price.'})}
+                    onChange={(e) => {
+                        const value = parseFloat(e.target.value);
+                        const transcriptionPricePerMinute = isNaN(value) ? 0 : value;
+                        props.onChange({...props.service, transcriptionPricePerMinute});
+                    }}
+                />
+            )}
+            {(isOpenAIType || isVLLM) && (
                 <TextItem
                     label={intl.formatMessage({defaultMessage: 'Streaming Timeout Seconds'})}
                     type='number'
@@ -87,6 +87,7 @@ const Bots = (props: Props) => {
                     <Bot
                         key={bot.id}
                         bot={bot}
+                        otherBots={props.bots.filter((b) => b.id !== bot.id)}
                         onChange={onChange}
                         onDelete={() => onDelete(bot.id)}
                         changedAvatar={(image: File) => props.botChangedAvatar(bot, image)}
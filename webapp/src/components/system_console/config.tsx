@@ -13,21 +13,52 @@ import Panel, {PanelFooterText} from './panel';
 import Bots, {firstNewBot} from './bots';
 import {LLMBotConfig} from './bot';
 import {BooleanItem, ItemList, SelectionItem, SelectionItemOption, TextItem} from './item';
+import {IntItem} from './number_items';
 import NoBotsPage from './no_bots_page';
 import EmbeddingSearchPanel from './embedding_search/embedding_search_panel';
 import {EmbeddingSearchConfig} from './embedding_search/types';
 import MCPServers, {MCPConfig} from './mcp_servers';
+import AzureSpeech, {AzureSpeechConfig, defaultAzureSpeechConfig} from './azure_speech';
+import ToolApprovals, {ToolApprovalPolicy} from './tool_approvals';
+import TranscriptionLanguages, {ChannelTranscriptionLanguage} from './transcription_languages';
+import TranscriptionVocabularies, {TranscriptionVocabulary} from './transcription_vocabularies';
+import SummaryTemplates, {SummaryTemplateConfig} from './summary_templates';
+import Personas, {PersonaConfig} from './personas';
+import AutoSummarizeCalls, {AutoSummarizeCallsConfig} from './auto_summarize_calls';
+import ThreadTagging, {ThreadTaggingConfig} from './thread_tagging';
+import FFmpegSettings, {FFmpegConfig, defaultFFmpegConfig} from './ffmpeg_settings';
+import Glossary from './glossary';
+import ImportMigration from './import_migration';
+import ConfigBundle from './config_bundle';
+import PromptPlayground from './prompt_playground';
+import BackgroundJobs from './background_jobs';
+import EntityLinking, {EntityLinkingConfig, defaultEntityLinkingConfig} from './entity_linking';
 
 type Config = {
     services: ServiceData[],
     bots: LLMBotConfig[],
     defaultBotName: string,
     transcriptBackend: string,
+    azureSpeech: AzureSpeechConfig,
+    transcriptionLanguage: string,
+    channelTranscriptionLanguages: ChannelTranscriptionLanguage[],
+    transcriptionVocabularies: TranscriptionVocabulary[],
+    ffmpeg: FFmpegConfig,
+    entityLinking: EntityLinkingConfig,
     enableLLMTrace: boolean,
+    enableUserMemory: boolean,
+    enableFollowUpSuggestions: boolean,
     enableCallSummary: boolean,
     allowedUpstreamHostnames: string,
     embeddingSearchConfig: EmbeddingSearchConfig,
     mcp: MCPConfig
+    toolApprovals: ToolApprovalPolicy[]
+    summaryTemplates: SummaryTemplateConfig[]
+    personas: PersonaConfig[]
+    autoSummarizeCalls: AutoSummarizeCallsConfig
+    threadTagging: ThreadTaggingConfig
+    streamingKeepAliveSeconds: number
+    orphanedFileRetentionHours: number
 }
 
 type Props = {
@@ -74,7 +105,12 @@ const defaultConfig = {
     services: [],
     llmBackend: '',
     transcriptBackend: '',
+    azureSpeech: defaultAzureSpeechConfig,
+    ffmpeg: defaultFFmpegConfig,
+    entityLinking: defaultEntityLinkingConfig,
     enableLLMTrace: false,
+    enableUserMemory: false,
+    enableFollowUpSuggestions: false,
     embeddingSearchConfig: {
         type: 'disabled',
         vectorStore: {
@@ -212,8 +248,154 @@ const Config = (props: Props) => {
                         onChange={(e) => props.onChange(props.id, {...value, allowedUpstreamHostnames: e.target.value})}
                         helptext={intl.formatMessage({defaultMessage: 'Comma separated list of hostnames that LLMs are allowed to contact when using tools. Supports wildcards like *.mydomain.com. For instance to allow JIRA tool use to the Mattermost JIRA instance use mattermost.atlassian.net'})}
                     />
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable User Memory'})}
+                        value={value.enableUserMemory}
+                        onChange={(to) => {
+                            props.onChange(props.id, {...value, enableUserMemory: to});
+                            props.setSaveNeeded();
+                        }}
+                        helpText={intl.formatMessage({defaultMessage: 'Let bots with tools remember facts users share about themselves, such as their role, preferences and ongoing projects, in direct messages. Remembered facts are given to the bots in every conversation of the user. Users can see and delete their memories from the AI panel.'})}
+                    />
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Suggest Follow-up Questions'})}
+                        value={value.enableFollowUpSuggestions}
+                        onChange={(to) => {
+                            props.onChange(props.id, {...value, enableFollowUpSuggestions: to});
+                            props.setSaveNeeded();
+                        }}
+                        helpText={intl.formatMessage({defaultMessage: 'After a bot answers, suggest a few questions the user could ask next. Users send one by clicking it. The questions are generated with the small model of the bot when it uses a smaller model for light tasks.'})}
+                    />
                 </ItemList>
             </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Prompt Playground'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Try changes to the instructions of a bot on sample messages and compare them with the published instructions before publishing them.'})}
+            >
+                <PromptPlayground
+                    bots={props.value.bots ?? []}
+                    onPublished={(bots) => props.onChange(props.id, {...value, bots})}
+                />
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Transcription'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Configure how meeting recordings are transcribed.'})}
+            >
+                <AzureSpeech
+                    value={value.azureSpeech || defaultConfig.azureSpeech}
+                    onChange={(azureSpeech) => {
+                        props.onChange(props.id, {...value, azureSpeech});
+                        props.setSaveNeeded();
+                    }}
+                />
+                <TranscriptionLanguages
+                    language={value.transcriptionLanguage || ''}
+                    channelLanguages={value.channelTranscriptionLanguages || []}
+                    onChange={(transcriptionLanguage, channelTranscriptionLanguages) => {
+                        props.onChange(props.id, {...value, transcriptionLanguage, channelTranscriptionLanguages});
+                        props.setSaveNeeded();
+                    }}
+                />
+                <TranscriptionVocabularies
+                    vocabularies={value.transcriptionVocabularies || []}
+                    onChange={(transcriptionVocabularies) => {
+                        props.onChange(props.id, {...value, transcriptionVocabularies});
+                        props.setSaveNeeded();
+                    }}
+                />
+                <FFmpegSettings
+                    value={value.ffmpeg || defaultConfig.ffmpeg}
+                    onChange={(ffmpeg) => {
+                        props.onChange(props.id, {...value, ffmpeg});
+                        props.setSaveNeeded();
+                    }}
+                />
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Background Jobs'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Transcriptions and summaries of meetings run in the background. They are resumed when the plugin restarts and failed attempts are retried.'})}
+            >
+                <BackgroundJobs/>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Automatic Call Summaries'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Summarize call recordings as soon as they are posted in the channels that opt in.'})}
+            >
+                <AutoSummarizeCalls
+                    value={value.autoSummarizeCalls || {botName: '', channelIDs: []}}
+                    bots={value.bots || []}
+                    onChange={(autoSummarizeCalls) => {
+                        props.onChange(props.id, {...value, autoSummarizeCalls});
+                        props.setSaveNeeded();
+                    }}
+                />
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Meeting Summary Templates'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Add templates people can pick when requesting a meeting summary, next to the built-in standard, structured, standup, retrospective, incident review and customer call templates.'})}
+            >
+                <SummaryTemplates
+                    value={value.summaryTemplates || []}
+                    onChange={(summaryTemplates) => {
+                        props.onChange(props.id, {...value, summaryTemplates});
+                        props.setSaveNeeded();
+                    }}
+                />
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Personas'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Add personas people can pick when starting a conversation with a bot, or switch to within a conversation, to change how the bot answers.'})}
+            >
+                <Personas
+                    value={value.personas || []}
+                    onChange={(personas) => {
+                        props.onChange(props.id, {...value, personas});
+                        props.setSaveNeeded();
+                    }}
+                />
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Thread Tagging'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Tag conversations with bots by category to filter chat history and see what the agents are used for.'})}
+            >
+                <ThreadTagging
+                    value={value.threadTagging || {enabled: false, model: ''}}
+                    onChange={(threadTagging) => {
+                        props.onChange(props.id, {...value, threadTagging});
+                        props.setSaveNeeded();
+                    }}
+                />
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Glossary'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Define terms, acronyms and product names used at your organization. Definitions are given to the agents when a term appears in the conversation. Terms are saved immediately.'})}
+            >
+                <Glossary/>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Import from Other AI Integrations'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Migrate bots and conversation titles from an export of another AI integration. Existing bots are never changed.'})}
+            >
+                <ImportMigration/>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Configuration as Code'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Export the configuration as a YAML file to keep it in version control or promote it from a staging server to production. Secrets are replaced with references to environment variables.'})}
+            >
+                <ConfigBundle/>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Entity Linking'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Link the users, channels and tickets mentioned in responses.'})}
+            >
+                <EntityLinking
+                    value={value.entityLinking || defaultConfig.entityLinking}
+                    onChange={(entityLinking) => {
+                        props.onChange(props.id, {...value, entityLinking});
+                        props.setSaveNeeded();
+                    }}
+                />
+            </Panel>
             <Panel
                 title={intl.formatMessage({defaultMessage: 'Debug'})}
                 subtitle=''
@@ -225,6 +407,30 @@ const Config = (props: Props) => {
                         onChange={(to) => props.onChange(props.id, {...value, enableLLMTrace: to})}
                         helpText={intl.formatMessage({defaultMessage: 'Enable tracing of LLM requests. Outputs full conversation data to the logs.'})}
                     />
+                    <IntItem
+                        label={intl.formatMessage({defaultMessage: 'Streaming keep-alive interval (seconds)'})}
+                        value={value.streamingKeepAliveSeconds || undefined}
+                        min={1}
+                        allowEmpty={true}
+                        placeholder='15'
+                        helptext={intl.formatMessage({defaultMessage: 'How long a response can go without new text before a heartbeat is sent to keep the connection open. Lower it when proxies close idle connections during long generations. Leave empty for 15 seconds.'})}
+                        onChange={(streamingKeepAliveSeconds) => {
+                            props.onChange(props.id, {...value, streamingKeepAliveSeconds});
+                            props.setSaveNeeded();
+                        }}
+                    />
+                    <IntItem
+                        label={intl.formatMessage({defaultMessage: 'Unattached transcript retention (hours)'})}
+                        value={value.orphanedFileRetentionHours || undefined}
+                        min={1}
+                        allowEmpty={true}
+                        placeholder='24'
+                        helptext={intl.formatMessage({defaultMessage: 'Transcripts uploaded for summaries that failed before attaching them to a post are deleted after this long. Leave empty for 24 hours.'})}
+                        onChange={(orphanedFileRetentionHours) => {
+                            props.onChange(props.id, {...value, orphanedFileRetentionHours});
+                            props.setSaveNeeded();
+                        }}
+                    />
                 </ItemList>
             </Panel>
             <EmbeddingSearchPanel
@@ -256,6 +462,18 @@ const Config = (props: Props) => {
                     }}
                 />
             </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Tool Approvals'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Have calls to certain tools approved by channel admins or selected users instead of the user who asked.'})}
+            >
+                <ToolApprovals
+                    value={value.toolApprovals || []}
+                    onChange={(toolApprovals) => {
+                        props.onChange(props.id, {...value, toolApprovals});
+                        props.setSaveNeeded();
+                    }}
+                />
+            </Panel>
         </ConfigContainer>
     );
 };
@@ -0,0 +1,143 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useRef, useState} from 'react';
+import styled from 'styled-components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {ConfigImportResult, exportConfigURL, importConfig} from '@/client';
+
+import {PrimaryButton, TertiaryButton} from '../assets/buttons';
+
+// ConfigBundle exports the configuration as a YAML bundle and imports bundles exported on another server.
+// Bundles are checked with a dry run first so the admin can review the settings that will change.
+// The import is saved to the configuration on the server, so the page has to be reloaded before
+// saving other settings or they would overwrite it.
+const ConfigBundle = () => {
+    const intl = useIntl();
+    const fileInput = useRef<HTMLInputElement>(null);
+    const [bundle, setBundle] = useState('');
+    const [result, setResult] = useState<ConfigImportResult | null>(null);
+    const [error, setError] = useState('');
+    const [pending, setPending] = useState(false);
+
+    const run = async (yaml: string, dryRun: boolean) => {
+        setPending(true);
+        setError('');
+        try {
+            setResult(await importConfig(yaml, dryRun));
+        } catch (err: any) {
+            setResult(null);
+            if (err?.status_code === 400) {
+                setError(intl.formatMessage({defaultMessage: 'The file isn\'t a valid configuration bundle.'}));
+            } else {
+                setError(intl.formatMessage({defaultMessage: 'Unable to import the configuration. Check the server logs for details.'}));
+            }
+        }
+        setPending(false);
+    };
+
+    const chooseFile = async (e: React.ChangeEvent<HTMLInputElement>) => {
+        const file = e.target.files?.[0];
+        e.target.value = '';
+        if (!file) {
+            return;
+        }
+        const yaml = await file.text();
+        setBundle(yaml);
+        run(yaml, true);
+    };
+
+    const unresolved = result?.unresolved ?? [];
+    const importable = result?.dryRun && result.changed.length > 0 && unresolved.length === 0;
+
+    return (
+        <>
+            <Buttons>
+                <TertiaryButton
+                    as='a'
+                    href={exportConfigURL()}
+                    download={true}
+                >
+                    <FormattedMessage defaultMessage='Export Configuration'/>
+                </TertiaryButton>
+                <HiddenInput
+                    ref={fileInput}
+                    type='file'
+                    accept='.yaml,.yml'
+                    onChange={chooseFile}
+                />
+                <TertiaryButton
+                    disabled={pending}
+                    onClick={() => fileInput.current?.click()}
+                >
+                    <FormattedMessage defaultMessage='Import Configuration'/>
+                </TertiaryButton>
+            </Buttons>
+            {error && <ErrorText>{error}</ErrorText>}
+            {result && (
+                <Results>
+                    {!result.dryRun && (
+                        <FormattedMessage defaultMessage='Import complete. Reload the page to see the imported configuration before changing other settings.'/>
+                    )}
+                    {result.dryRun && result.changed.length === 0 && (
+                        <FormattedMessage defaultMessage='The bundle is the same as the current configuration.'/>
+                    )}
+                    {result.dryRun && result.changed.length > 0 && (
+                        <div>
+                            <FormattedMessage defaultMessage='These settings will change:'/>
+                            <ul>
+                                {result.changed.map((setting) => <li key={setting}><code>{setting}</code></li>)}
+                            </ul>
+                        </div>
+                    )}
+                    {unresolved.length > 0 && (
+                        <UnresolvedSecrets>
+                            <FormattedMessage defaultMessage='The bundle references secrets missing on this server. Set these environment variables on the server before importing:'/>
+                            <ul>
+                                {unresolved.map((name) => <li key={name}><code>{name}</code></li>)}
+                            </ul>
+                        </UnresolvedSecrets>
+                    )}
+                    {importable && (
+                        <PrimaryButton
+                            disabled={pending}
+                            onClick={() => run(bundle, false)}
+                        >
+                            <FormattedMessage defaultMessage='Import'/>
+                        </PrimaryButton>
+                    )}
+                </Results>
+            )}
+        </>
+    );
+};
+
+const Buttons = styled.div`
+    display: flex;
+    gap: 8px;
+`;
+
+const HiddenInput = styled.input`
+    display: none;
+`;
+
+const Results = styled.div`
+    display: flex;
+    flex-direction: column;
+    align-items: flex-start;
+    gap: 12px;
+    margin-top: 16px;
+`;
+
+const UnresolvedSecrets = styled.div`
+    color: var(--error-text);
+`;
+
+const ErrorText = styled.div`
+    color: var(--error-text);
+    font-size: 12px;
+    margin-top: 16px;
+`;
+
+export default ConfigBundle;
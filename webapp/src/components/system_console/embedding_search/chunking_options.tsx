@@ -37,11 +37,12 @@ export const ChunkingOptionsConfig = ({value, onChange}: ChunkingOptionsProps) =
                         chunkingStrategy: e.target.value,
                     } as ChunkingOptions,
                 })}
-                helptext={intl.formatMessage({defaultMessage: 'The strategy to use for splitting text into chunks.'})}
+                helptext={intl.formatMessage({defaultMessage: 'The strategy to use for splitting text into chunks. Threads indexes the posts of a thread together, keeping consecutive posts in the same chunk. Restart the plugin and reindex after changing it.'})}
             >
                 <SelectionItemOption value='sentences'>{'Sentences'}</SelectionItemOption>
                 <SelectionItemOption value='paragraphs'>{'Paragraphs'}</SelectionItemOption>
                 <SelectionItemOption value='fixed'>{'Fixed Size'}</SelectionItemOption>
+                <SelectionItemOption value='threads'>{'Threads'}</SelectionItemOption>
             </SelectionItem>
 
             <IntItem
@@ -93,7 +94,7 @@ export const ChunkingOptionsConfig = ({value, onChange}: ChunkingOptionsProps) =
                 }}
                 min={0}
                 max={1}
-                helptext={intl.formatMessage({defaultMessage: 'Minimum chunk size as a fraction of the maximum size (0.0-1.0). Used for sentence and paragraph chunking.'})}
+                helptext={intl.formatMessage({defaultMessage: 'Minimum chunk size as a fraction of the maximum size (0.0-1.0). Used for sentence, paragraph and thread chunking.'})}
             />
         </>
     );
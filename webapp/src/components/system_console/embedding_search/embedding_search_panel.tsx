@@ -14,8 +14,10 @@ import {ItemList, SelectionItem, SelectionItemOption} from '../item';
 import {IntItem} from '../number_items';
 
 import {EmbeddingSearchConfig} from './types';
-import {OpenAIProviderConfig, OpenAICompatibleProviderConfig} from './provider_configs';
+import {OpenAIProviderConfig, OpenAICompatibleProviderConfig, CohereProviderConfig, SentenceTransformersProviderConfig} from './provider_configs';
+import {QdrantVectorStoreConfig} from './vector_store_configs';
 import {ChunkingOptionsConfig} from './chunking_options';
+import {ExclusionsConfig} from './exclusions';
 import {ReindexSection} from './reindex_section';
 import {ReindexConfirmation} from './reindex_confirmation';
 import {useJobStatus} from './use_job_status';
@@ -38,12 +40,14 @@ const EmbeddingSearchPanel = ({value, onChange}: Props) => {
 
     const {
         jobStatus,
+        indexedModel,
         statusMessage,
         showReindexConfirmation,
         handleReindexClick,
         handleConfirmReindex,
         handleCancelReindex,
         handleCancelJob,
+        handleResumeJob,
     } = useJobStatus();
 
     if (!isBasicsLicensed) {
@@ -125,13 +129,21 @@ const EmbeddingSearchPanel = ({value, onChange}: Props) => {
                     value={value.vectorStore.type}
                     onChange={(e) => onChange({
                         ...value,
-                        vectorStore: {...value.vectorStore, type: e.target.value},
+                        vectorStore: {type: e.target.value, parameters: {}},
                     })}
                 >
                     <SelectionItemOption value='pgvector'>{'PostgreSQL pgvector'}</SelectionItemOption>
+                    <SelectionItemOption value='qdrant'>{'Qdrant'}</SelectionItemOption>
                 </SelectionItem>
                 }
 
+                {value.type && value.type !== '' && value.vectorStore.type === 'qdrant' && (
+                    <QdrantVectorStoreConfig
+                        value={value.vectorStore}
+                        onChange={(config) => onChange({...value, vectorStore: config})}
+                    />
+                )}
+
                 {value.type && value.type !== '' &&
                 <SelectionItem
                     label={intl.formatMessage({defaultMessage: 'Embedding Provider Type'})}
@@ -143,6 +155,10 @@ const EmbeddingSearchPanel = ({value, onChange}: Props) => {
                             newParameters = {embeddingModel: '', apiKey: '', apiURL: ''};
                         } else if (newType === 'openai') {
                             newParameters = {embeddingModel: '', apiKey: ''};
+                        } else if (newType === 'cohere') {
+                            newParameters = {embeddingModel: '', apiKey: '', apiURL: ''};
+                        } else if (newType === 'sentence-transformers') {
+                            newParameters = {embeddingModel: '', apiURL: '', apiKey: ''};
                         }
                         onChange({
                             ...value,
@@ -155,6 +171,8 @@ const EmbeddingSearchPanel = ({value, onChange}: Props) => {
                 >
                     <SelectionItemOption value='openai'>{'OpenAI'}</SelectionItemOption>
                     <SelectionItemOption value='openai-compatible'>{'OpenAI-compatible API'}</SelectionItemOption>
+                    <SelectionItemOption value='cohere'>{'Cohere'}</SelectionItemOption>
+                    <SelectionItemOption value='sentence-transformers'>{'Sentence Transformers (self-hosted)'}</SelectionItemOption>
                 </SelectionItem>
                 }
 
@@ -172,6 +190,20 @@ const EmbeddingSearchPanel = ({value, onChange}: Props) => {
                     />
                 )}
 
+                {value.type && value.type !== '' && value.embeddingProvider.type === 'cohere' && (
+                    <CohereProviderConfig
+                        value={value.embeddingProvider}
+                        onChange={(config) => onChange({...value, embeddingProvider: config})}
+                    />
+                )}
+
+                {value.type && value.type !== '' && value.embeddingProvider.type === 'sentence-transformers' && (
+                    <SentenceTransformersProviderConfig
+                        value={value.embeddingProvider}
+                        onChange={(config) => onChange({...value, embeddingProvider: config})}
+                    />
+                )}
+
                 {value.type === 'composite' && (
                     <>
                         <IntItem
@@ -185,22 +217,29 @@ const EmbeddingSearchPanel = ({value, onChange}: Props) => {
                                 });
                             }}
                             min={0}
-                            helptext={intl.formatMessage({defaultMessage: 'The number of dimensions for the vector embeddings. Common values are 768, 1024, or 1536 depending on the model.'})}
+                            helptext={intl.formatMessage({defaultMessage: 'The number of dimensions for the vector embeddings. Common values are 768, 1024, or 1536 depending on the model. OpenAI text-embedding-3 models are shortened to this size. Changing the model or the dimensions requires reindexing.'})}
                         />
 
                         <ChunkingOptionsConfig
                             value={value}
                             onChange={onChange}
                         />
+
+                        <ExclusionsConfig
+                            value={value}
+                            onChange={onChange}
+                        />
                     </>
                 )}
 
                 {value.type && value.type !== '' && (
                     <ReindexSection
                         jobStatus={jobStatus}
+                        indexedModel={indexedModel}
                         statusMessage={statusMessage}
                         onReindexClick={handleReindexClick}
                         onCancelJob={handleCancelJob}
+                        onResumeJob={handleResumeJob}
                     />
                 )}
             </ItemList>
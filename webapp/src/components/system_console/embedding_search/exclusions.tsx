@@ -0,0 +1,57 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import {useIntl} from 'react-intl';
+
+import {TextItem} from '../item';
+
+import {EmbeddingSearchConfig, IndexingExclusions} from './types';
+
+interface ExclusionsConfigProps {
+    value: EmbeddingSearchConfig;
+    onChange: (config: EmbeddingSearchConfig) => void;
+}
+
+const splitList = (list: string) => list.split(',').map((item) => item.trim()).filter(Boolean);
+
+export const ExclusionsConfig = ({value, onChange}: ExclusionsConfigProps) => {
+    const intl = useIntl();
+    const exclusions = value.exclusions || {};
+
+    const updateExclusions = (update: IndexingExclusions) => {
+        onChange({
+            ...value,
+            exclusions: {
+                ...exclusions,
+                ...update,
+            },
+        });
+    };
+
+    return (
+        <>
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Excluded Teams'})}
+                value={(exclusions.teamIds || []).join(', ')}
+                placeholder='e.g. 4xp9fdt77pncbef59f4k1qe83o'
+                onChange={(e) => updateExclusions({teamIds: splitList(e.target.value)})}
+                helptext={intl.formatMessage({defaultMessage: 'Comma separated IDs of the teams whose posts are neither indexed nor searched.'})}
+            />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Excluded Channels'})}
+                value={(exclusions.channelIds || []).join(', ')}
+                placeholder='e.g. 8ffxgo5hmjgbtbjzfqa8cuoaxc'
+                onChange={(e) => updateExclusions({channelIds: splitList(e.target.value)})}
+                helptext={intl.formatMessage({defaultMessage: 'Comma separated IDs of the channels whose posts are neither indexed nor searched.'})}
+            />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Excluded Channel Names'})}
+                value={(exclusions.channelNamePatterns || []).join(', ')}
+                placeholder='hr-*, *-private'
+                onChange={(e) => updateExclusions({channelNamePatterns: splitList(e.target.value)})}
+                helptext={intl.formatMessage({defaultMessage: 'Comma separated patterns matched against channel names, where * matches any characters. Reindex to remove posts that were indexed before their channel was excluded, until then they are left out of search results.'})}
+            />
+        </>
+    );
+};
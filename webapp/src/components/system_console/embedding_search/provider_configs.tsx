@@ -8,12 +8,12 @@ import {TextItem} from '../item';
 
 import {UpstreamConfig} from './types';
 
-interface OpenAIConfigProps {
+interface ProviderConfigProps {
     value: UpstreamConfig;
     onChange: (config: UpstreamConfig) => void;
 }
 
-export const OpenAIProviderConfig = ({value, onChange}: OpenAIConfigProps) => {
+export const OpenAIProviderConfig = ({value, onChange}: ProviderConfigProps) => {
     const intl = useIntl();
 
     return (
@@ -46,7 +46,7 @@ export const OpenAIProviderConfig = ({value, onChange}: OpenAIConfigProps) => {
     );
 };
 
-export const OpenAICompatibleProviderConfig = ({value, onChange}: OpenAIConfigProps) => {
+export const OpenAICompatibleProviderConfig = ({value, onChange}: ProviderConfigProps) => {
     const intl = useIntl();
 
     return (
@@ -88,4 +88,97 @@ export const OpenAICompatibleProviderConfig = ({value, onChange}: OpenAIConfigPr
             />
         </>
     );
-};
\ No newline at end of file
+};
+export const CohereProviderConfig = ({value, onChange}: ProviderConfigProps) => {
+    const intl = useIntl();
+
+    return (
+        <>
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'API Key'})}
+                type='password'
+                value={(value.parameters?.apiKey as string) || ''}
+                onChange={(e) => onChange({
+                    ...value,
+                    parameters: {
+                        ...value.parameters,
+                        apiKey: e.target.value,
+                    },
+                })}
+            />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Model'})}
+                placeholder='embed-english-v3.0'
+                value={(value.parameters?.embeddingModel as string) || ''}
+                onChange={(e) => onChange({
+                    ...value,
+                    parameters: {
+                        ...value.parameters,
+                        embeddingModel: e.target.value,
+                    },
+                })}
+                helptext={intl.formatMessage({defaultMessage: 'The Cohere embedding model, such as embed-english-v3.0 (1024 dimensions) or embed-multilingual-v3.0 (1024 dimensions).'})}
+            />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'API URL'})}
+                placeholder='https://api.cohere.com'
+                value={(value.parameters?.apiURL as string) || ''}
+                onChange={(e) => onChange({
+                    ...value,
+                    parameters: {
+                        ...value.parameters,
+                        apiURL: e.target.value,
+                    },
+                })}
+            />
+        </>
+    );
+};
+
+export const SentenceTransformersProviderConfig = ({value, onChange}: ProviderConfigProps) => {
+    const intl = useIntl();
+
+    return (
+        <>
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Server URL'})}
+                placeholder='http://localhost:8080'
+                value={(value.parameters?.apiURL as string) || ''}
+                onChange={(e) => onChange({
+                    ...value,
+                    parameters: {
+                        ...value.parameters,
+                        apiURL: e.target.value,
+                    },
+                })}
+                helptext={intl.formatMessage({defaultMessage: 'A server exposing the /embed endpoint of Hugging Face Text Embeddings Inference for a sentence-transformers model.'})}
+            />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Model'})}
+                placeholder='sentence-transformers/all-MiniLM-L6-v2'
+                value={(value.parameters?.embeddingModel as string) || ''}
+                onChange={(e) => onChange({
+                    ...value,
+                    parameters: {
+                        ...value.parameters,
+                        embeddingModel: e.target.value,
+                    },
+                })}
+                helptext={intl.formatMessage({defaultMessage: 'The model the server runs. It is only used to tell when the index has to be rebuilt for another model.'})}
+            />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'API Key'})}
+                type='password'
+                value={(value.parameters?.apiKey as string) || ''}
+                onChange={(e) => onChange({
+                    ...value,
+                    parameters: {
+                        ...value.parameters,
+                        apiKey: e.target.value,
+                    },
+                })}
+                helptext={intl.formatMessage({defaultMessage: 'Only needed when the server requires one.'})}
+            />
+        </>
+    );
+};
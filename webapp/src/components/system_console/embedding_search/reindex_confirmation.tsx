@@ -1,10 +1,13 @@
 // Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
 // See LICENSE.txt for license information.
 
-import React from 'react';
+import React, {useEffect, useState} from 'react';
 import {FormattedMessage} from 'react-intl';
 
+import {CostEstimate as CostEstimateData, getReindexEstimate} from '@/client';
+
 import ConfirmationDialog from '../../confirmation_dialog';
+import CostEstimate from '../../cost_estimate';
 
 interface ReindexConfirmationProps {
     show: boolean;
@@ -13,6 +16,19 @@ interface ReindexConfirmationProps {
 }
 
 export const ReindexConfirmation = ({show, onConfirm, onCancel}: ReindexConfirmationProps) => {
+    const [estimate, setEstimate] = useState<CostEstimateData | null>(null);
+
+    useEffect(() => {
+        if (!show) {
+            return;
+        }
+
+        setEstimate(null);
+        getReindexEstimate().then(setEstimate).catch(() => {
+            // The estimate is informational, the dialog works without it
+        });
+    }, [show]);
+
     if (!show) {
         return null;
     }
@@ -33,6 +49,11 @@ export const ReindexConfirmation = ({show, onConfirm, onCancel}: ReindexConfirma
                         <li><FormattedMessage defaultMessage='Take a significant amount of time for large installations'/></li>
                         <li><FormattedMessage defaultMessage='Increase database load during the reindexing process'/></li>
                     </ul>
+                    {estimate && (
+                        <p>
+                            <CostEstimate estimate={estimate}/>
+                        </p>
+                    )}
                 </>
             }
             confirmButtonText={<FormattedMessage defaultMessage='Reindex'/>}
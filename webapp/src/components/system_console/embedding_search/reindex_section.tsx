@@ -6,6 +6,7 @@ import {FormattedMessage} from 'react-intl';
 import styled from 'styled-components';
 
 import {PrimaryButton, SecondaryButton} from '../../assets/buttons';
+import {EmbeddingModel, IndexedModelStatus} from '../../../client';
 
 import {HelpText, ItemLabel} from '../item';
 
@@ -56,6 +57,13 @@ const ProgressText = styled(HelpText)`
     font-size: 12px;
 `;
 
+const StaleHelpText = styled(HelpText)`
+    margin-bottom: 12px;
+    color: var(--error-text);
+`;
+
+const describeModel = (model: EmbeddingModel) => `${model.model || model.provider} (${model.dimensions})`;
+
 const ButtonGroup = styled.div`
     display: flex;
     gap: 8px;
@@ -63,20 +71,27 @@ const ButtonGroup = styled.div`
 
 interface ReindexSectionProps {
     jobStatus: JobStatusType | null;
+    indexedModel: IndexedModelStatus | null;
     statusMessage: StatusMessageType;
     onReindexClick: () => void;
     onCancelJob: () => void;
+    onResumeJob: () => void;
 }
 
 export const ReindexSection = ({
     jobStatus,
+    indexedModel,
     statusMessage,
     onReindexClick,
     onCancelJob,
+    onResumeJob,
 }: ReindexSectionProps) => {
     // Check if job is running
     const isReindexing = jobStatus?.status === 'running';
 
+    // Failed and canceled jobs resume after the last post they indexed
+    const isResumable = (jobStatus?.status === 'failed' || jobStatus?.status === 'canceled') && Boolean(jobStatus?.last_post_id);
+
     return (
         <ButtonContainer>
             <ActionContainer>
@@ -84,6 +99,18 @@ export const ReindexSection = ({
                     <FormattedMessage defaultMessage='Reindex All Posts'/>
                 </ItemLabel>
                 <div>
+                    {indexedModel?.stale && indexedModel.indexed && !isReindexing && (
+                        <StaleHelpText>
+                            <FormattedMessage
+                                defaultMessage='The index was built with {indexed}, but {configured} is configured. Search results are unreliable until posts are reindexed with the new model.'
+                                values={{
+                                    indexed: describeModel(indexedModel.indexed),
+                                    configured: describeModel(indexedModel.configured),
+                                }}
+                            />
+                        </StaleHelpText>
+                    )}
+
                     {/* Show different UI based on job status */}
                     {isReindexing ? (
                         <>
@@ -104,6 +131,18 @@ export const ReindexSection = ({
                                                 percent: jobStatus.total_rows ? Math.floor((jobStatus.processed_rows / jobStatus.total_rows) * 100) : 0,
                                             }}
                                         />
+                                        {Boolean(jobStatus.eta_seconds) && (
+                                            <>
+                                                {' '}
+                                                <FormattedMessage
+                                                    defaultMessage='About {minutes, plural, one {# minute} other {# minutes}} left at {rate} posts per second.'
+                                                    values={{
+                                                        minutes: Math.max(Math.round((jobStatus.eta_seconds || 0) / 60), 1),
+                                                        rate: Math.round(jobStatus.rows_per_second || 0).toLocaleString(),
+                                                    }}
+                                                />
+                                            </>
+                                        )}
                                     </ProgressText>
                                     <ProgressContainer>
                                         <ProgressBar
@@ -114,9 +153,22 @@ export const ReindexSection = ({
                             )}
                         </>
                     ) : (
-                        <PrimaryButton onClick={onReindexClick}>
-                            <FormattedMessage defaultMessage='Reindex Posts'/>
-                        </PrimaryButton>
+                        <ButtonGroup>
+                            <PrimaryButton onClick={onReindexClick}>
+                                <FormattedMessage defaultMessage='Reindex Posts'/>
+                            </PrimaryButton>
+                            {isResumable && jobStatus && (
+                                <SecondaryButton onClick={onResumeJob}>
+                                    <FormattedMessage
+                                        defaultMessage='Resume Reindexing ({processed} of {total} posts done)'
+                                        values={{
+                                            processed: jobStatus.processed_rows.toLocaleString(),
+                                            total: jobStatus.total_rows.toLocaleString(),
+                                        }}
+                                    />
+                                </SecondaryButton>
+                            )}
+                        </ButtonGroup>
                     )}
 
                     {statusMessage.message && (
@@ -132,7 +184,7 @@ export const ReindexSection = ({
                     )}
 
                     <HelpText>
-                        <FormattedMessage defaultMessage='Reindex all posts to update the embedding search database. This process will clear the current index and rebuild it from scratch. It may take a significant amount of time for large installations.'/>
+                        <FormattedMessage defaultMessage='Reindex all posts to update the embedding search database. This process will clear the current index and rebuild it from scratch. It may take a significant amount of time for large installations. A failed or canceled reindex can be resumed where it stopped.'/>
                     </HelpText>
                 </div>
             </ActionContainer>
@@ -13,6 +13,12 @@ export interface ChunkingOptions {
     chunkingStrategy: string;
 }
 
+export interface IndexingExclusions {
+    teamIds?: string[];
+    channelIds?: string[];
+    channelNamePatterns?: string[];
+}
+
 export interface EmbeddingSearchConfig {
     type: string;
     vectorStore: UpstreamConfig;
@@ -20,6 +26,7 @@ export interface EmbeddingSearchConfig {
     parameters: Record<string, unknown>;
     dimensions: number;
     chunkingOptions?: ChunkingOptions;
+    exclusions?: IndexingExclusions;
 }
 
 // Match the server's JobStatus struct field names
@@ -30,6 +37,10 @@ export interface JobStatusType {
     completed_at?: string;
     processed_rows: number;
     total_rows: number;
+    remaining_rows?: number;
+    rows_per_second?: number;
+    eta_seconds?: number; // 0 until the pace of the job is known
+    last_post_id?: string; // Set once posts are indexed, failed and canceled jobs resume after it
 }
 
 export interface StatusMessageType {
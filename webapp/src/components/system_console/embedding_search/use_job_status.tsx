@@ -4,7 +4,8 @@
 import {useState, useEffect, useCallback} from 'react';
 import {useIntl} from 'react-intl';
 
-import {doReindexPosts, getReindexStatus, cancelReindex} from '../../../client';
+import {doReindexPosts, getReindexStatus, cancelReindex, resumeReindex, getIndexedModel, IndexedModelStatus} from '../../../client';
+import {listenToReindexProgress} from '../../../websocket';
 
 import {JobStatusType, StatusMessageType} from './types';
 
@@ -14,36 +15,53 @@ export const useJobStatus = () => {
     const [statusMessage, setStatusMessage] = useState<StatusMessageType>({});
     const [polling, setPolling] = useState(false);
     const [showReindexConfirmation, setShowReindexConfirmation] = useState(false);
+    const [indexedModel, setIndexedModel] = useState<IndexedModelStatus | null>(null);
+
+    // The index is stale once the configured embedding model changes, until it's rebuilt
+    const fetchIndexedModel = useCallback(async () => {
+        try {
+            setIndexedModel(await getIndexedModel());
+        } catch (error) {
+            setIndexedModel(null);
+        }
+    }, []);
+
+    // Shows the status of the job, from the status endpoint or from the websocket events of its progress
+    const applyJobStatus = useCallback((status: JobStatusType) => {
+        setJobStatus(status);
+
+        // Handle different status conditions
+        if (status.status === 'running') {
+            setPolling(true);
+        } else if (status.status === 'completed') {
+            setStatusMessage({
+                success: true,
+                message: intl.formatMessage({defaultMessage: 'Posts reindexing completed successfully.'}),
+            });
+            setPolling(false);
+            fetchIndexedModel();
+        } else if (status.status === 'failed') {
+            setStatusMessage({
+                success: false,
+                message: intl.formatMessage(
+                    {defaultMessage: 'Failed to reindex posts: {error}'},
+                    {error: status.error || intl.formatMessage({defaultMessage: 'Unknown error'})},
+                ),
+            });
+            setPolling(false);
+        } else if (status.status === 'canceled') {
+            setStatusMessage({
+                success: false,
+                message: intl.formatMessage({defaultMessage: 'Reindexing was canceled.'}),
+            });
+            setPolling(false);
+        }
+    }, [intl, fetchIndexedModel]);
 
     // Function to fetch job status
     const fetchJobStatus = useCallback(async () => {
         try {
-            const status = await getReindexStatus();
-            setJobStatus(status);
-
-            // Handle different status conditions
-            if (status.status === 'completed') {
-                setStatusMessage({
-                    success: true,
-                    message: intl.formatMessage({defaultMessage: 'Posts reindexing completed successfully.'}),
-                });
-                setPolling(false);
-            } else if (status.status === 'failed') {
-                setStatusMessage({
-                    success: false,
-                    message: intl.formatMessage(
-                        {defaultMessage: 'Failed to reindex posts: {error}'},
-                        {error: status.error || intl.formatMessage({defaultMessage: 'Unknown error'})},
-                    ),
-                });
-                setPolling(false);
-            } else if (status.status === 'canceled') {
-                setStatusMessage({
-                    success: false,
-                    message: intl.formatMessage({defaultMessage: 'Reindexing was canceled.'}),
-                });
-                setPolling(false);
-            }
+            applyJobStatus(await getReindexStatus());
         } catch (error) {
             // 404 is expected when no job has run yet, don't show an error
             if (error && typeof error === 'object' && 'status_code' in error && error.status_code !== 404) {
@@ -54,14 +72,17 @@ export const useJobStatus = () => {
             }
             setPolling(false);
         }
-    }, [intl]);
+    }, [intl, applyJobStatus]);
 
-    // Polling effect for job status
+    // Progress is sent with websocket events as the job runs
+    useEffect(() => listenToReindexProgress(applyJobStatus), [applyJobStatus]);
+
+    // Polling effect for job status, in case websocket events are missed while reconnecting
     useEffect(() => {
         if (polling) {
             const interval = setInterval(() => {
                 fetchJobStatus();
-            }, 2000); // Poll every 2 seconds
+            }, 10000); // Poll every 10 seconds
 
             return () => clearInterval(interval);
         }
@@ -73,7 +94,8 @@ export const useJobStatus = () => {
     // Check status on component mount
     useEffect(() => {
         fetchJobStatus();
-    }, [fetchJobStatus]);
+        fetchIndexedModel();
+    }, [fetchJobStatus, fetchIndexedModel]);
 
     const handleReindexClick = () => {
         setShowReindexConfirmation(true);
@@ -88,6 +110,14 @@ export const useJobStatus = () => {
             setJobStatus(response);
             setPolling(true);
         } catch (error) {
+            // The search keeps the embedding model it was set up with until the plugin restarts
+            if (error && typeof error === 'object' && 'status_code' in error && error.status_code === 412) {
+                setStatusMessage({
+                    success: false,
+                    message: intl.formatMessage({defaultMessage: 'Restart the plugin to apply the new embedding settings, then reindex.'}),
+                });
+                return;
+            }
             setStatusMessage({
                 success: false,
                 message: intl.formatMessage({defaultMessage: 'Failed to start reindexing. Please try again.'}),
@@ -99,6 +129,29 @@ export const useJobStatus = () => {
         setShowReindexConfirmation(false);
     };
 
+    const handleResumeJob = async () => {
+        setStatusMessage({});
+
+        try {
+            const response = await resumeReindex();
+            setJobStatus(response);
+            setPolling(true);
+        } catch (error) {
+            // Posts can't be added to an index built with another embedding model
+            if (error && typeof error === 'object' && 'status_code' in error && error.status_code === 412) {
+                setStatusMessage({
+                    success: false,
+                    message: intl.formatMessage({defaultMessage: 'The embedding settings changed since reindexing started. Restart the plugin if needed, then reindex all posts.'}),
+                });
+                return;
+            }
+            setStatusMessage({
+                success: false,
+                message: intl.formatMessage({defaultMessage: 'Failed to resume reindexing. Please try again.'}),
+            });
+        }
+    };
+
     const handleCancelJob = async () => {
         try {
             const response = await cancelReindex();
@@ -118,6 +171,7 @@ export const useJobStatus = () => {
 
     return {
         jobStatus,
+        indexedModel,
         statusMessage,
         polling,
         showReindexConfirmation,
@@ -125,5 +179,6 @@ export const useJobStatus = () => {
         handleConfirmReindex,
         handleCancelReindex,
         handleCancelJob,
+        handleResumeJob,
     };
 };
\ No newline at end of file
@@ -0,0 +1,59 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import {useIntl} from 'react-intl';
+
+import {TextItem} from '../item';
+import {IntItem} from '../number_items';
+
+import {UpstreamConfig} from './types';
+
+interface VectorStoreConfigProps {
+    value: UpstreamConfig;
+    onChange: (config: UpstreamConfig) => void;
+}
+
+export const QdrantVectorStoreConfig = ({value, onChange}: VectorStoreConfigProps) => {
+    const intl = useIntl();
+
+    const setParameter = (name: string, parameter: string | number) => onChange({
+        ...value,
+        parameters: {
+            ...value.parameters,
+            [name]: parameter,
+        },
+    });
+
+    return (
+        <>
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Qdrant URL'})}
+                placeholder='http://localhost:6333'
+                value={(value.parameters?.url as string) || ''}
+                onChange={(e) => setParameter('url', e.target.value)}
+            />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Qdrant API Key'})}
+                type='password'
+                value={(value.parameters?.apiKey as string) || ''}
+                onChange={(e) => setParameter('apiKey', e.target.value)}
+            />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Collection'})}
+                placeholder='mattermost_ai_posts'
+                value={(value.parameters?.collection as string) || ''}
+                onChange={(e) => setParameter('collection', e.target.value)}
+                helptext={intl.formatMessage({defaultMessage: 'The collection is created if it doesn\'t exist. Use a new collection when changing the dimensions of the embeddings.'})}
+            />
+            <IntItem
+                label={intl.formatMessage({defaultMessage: 'Batch Size'})}
+                placeholder='100'
+                value={(value.parameters?.batchSize as number) || 0}
+                onChange={(batchSize) => setParameter('batchSize', batchSize)}
+                min={0}
+                helptext={intl.formatMessage({defaultMessage: 'The number of embeddings sent to Qdrant per request when indexing. Defaults to 100.'})}
+            />
+        </>
+    );
+};
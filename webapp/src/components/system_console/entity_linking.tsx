@@ -0,0 +1,132 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import styled from 'styled-components';
+import {PlusIcon, TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {TertiaryButton} from '../assets/buttons';
+
+import {BooleanItem, ItemList, TextItem} from './item';
+
+export type TicketPattern = {
+    pattern: string;
+    url: string;
+};
+
+export type EntityLinkingConfig = {
+    enabled: boolean;
+    ticketPatterns: TicketPattern[];
+};
+
+export const defaultEntityLinkingConfig: EntityLinkingConfig = {
+    enabled: false,
+    ticketPatterns: [],
+};
+
+type Props = {
+    value: EntityLinkingConfig;
+    onChange: (config: EntityLinkingConfig) => void;
+};
+
+const EntityLinking = (props: Props) => {
+    const intl = useIntl();
+    const config = {...defaultEntityLinkingConfig, ...props.value};
+    const patterns = config.ticketPatterns || [];
+
+    const updatePattern = (index: number, pattern: TicketPattern) => {
+        props.onChange({...config, ticketPatterns: patterns.map((p, i) => (i === index ? pattern : p))});
+    };
+
+    return (
+        <>
+            <ItemList>
+                <BooleanItem
+                    label={intl.formatMessage({defaultMessage: 'Link Entities in Responses'})}
+                    value={config.enabled}
+                    onChange={(enabled) => props.onChange({...config, enabled})}
+                    helpText={intl.formatMessage({defaultMessage: 'Turn usernames into @mentions and public channel names into channel links once a response is complete. Mentions added this way don\'t send notifications.'})}
+                />
+            </ItemList>
+            {config.enabled && (
+                <>
+                    <PatternsList>
+                        {patterns.map((pattern, index) => (
+                            <PatternContainer key={index}>
+                                <ItemList>
+                                    <TextItem
+                                        label={intl.formatMessage({defaultMessage: 'Ticket ID Pattern'})}
+                                        value={pattern.pattern}
+                                        placeholder='\bMM-\d+\b'
+                                        helptext={intl.formatMessage({defaultMessage: 'Regular expression matching the ticket IDs of a tracker.'})}
+                                        onChange={(e) => updatePattern(index, {...pattern, pattern: e.target.value})}
+                                    />
+                                    <TextItem
+                                        label={intl.formatMessage({defaultMessage: 'Ticket URL'})}
+                                        value={pattern.url}
+                                        placeholder='https://tracker.example.com/browse/{id}'
+                                        helptext={intl.formatMessage({defaultMessage: 'Link for the ticket, where {id} is replaced by the ticket ID.'}, {id: '{id}'})}
+                                        onChange={(e) => updatePattern(index, {...pattern, url: e.target.value.trim()})}
+                                    />
+                                </ItemList>
+                                <DeleteButton onClick={() => props.onChange({...config, ticketPatterns: patterns.filter((_, i) => i !== index)})}>
+                                    <TrashCanOutlineIcon size={16}/>
+                                    <FormattedMessage defaultMessage='Delete Pattern'/>
+                                </DeleteButton>
+                            </PatternContainer>
+                        ))}
+                    </PatternsList>
+                    <TertiaryButton onClick={() => props.onChange({...config, ticketPatterns: [...patterns, {pattern: '', url: ''}]})}>
+                        <PlusPatternIcon/>
+                        <FormattedMessage defaultMessage='Add Ticket Pattern'/>
+                    </TertiaryButton>
+                </>
+            )}
+        </>
+    );
+};
+
+const PatternsList = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    margin: 16px 0;
+`;
+
+const PatternContainer = styled.div`
+    display: flex;
+    flex-direction: column;
+    align-items: flex-start;
+    gap: 16px;
+    border: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    border-radius: 4px;
+    padding: 16px;
+    background-color: var(--center-channel-bg);
+`;
+
+const DeleteButton = styled.button`
+    display: flex;
+    align-items: center;
+    gap: 6px;
+    padding: 8px 12px;
+    background: none;
+    border: none;
+    border-radius: 4px;
+    color: var(--error-text);
+    cursor: pointer;
+    font-size: 12px;
+    font-weight: 600;
+
+    &:hover {
+        background: rgba(var(--error-text-color-rgb), 0.08);
+    }
+`;
+
+const PlusPatternIcon = styled(PlusIcon)`
+    width: 18px;
+    height: 18px;
+    margin-right: 8px;
+`;
+
+export default EntityLinking;
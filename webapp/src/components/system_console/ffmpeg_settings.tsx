@@ -0,0 +1,142 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useState} from 'react';
+import styled from 'styled-components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {getFFmpegDiagnostics, FFmpegDiagnostics} from '@/client';
+
+import {TertiaryButton} from '../assets/buttons';
+
+import {ItemList, SelectionItem, SelectionItemOption, TextItem} from './item';
+
+export type FFmpegConfig = {
+    path: string;
+    audioBitrate: string;
+    sampleRate: number;
+    audioCodec: string;
+    hwaccel: string;
+};
+
+export const defaultFFmpegConfig: FFmpegConfig = {
+    path: '',
+    audioBitrate: '',
+    sampleRate: 0,
+    audioCodec: '',
+    hwaccel: '',
+};
+
+type Props = {
+    value: FFmpegConfig;
+    onChange: (config: FFmpegConfig) => void;
+};
+
+const FFmpegSettings = (props: Props) => {
+    const intl = useIntl();
+    const config = {...defaultFFmpegConfig, ...props.value};
+    const [checking, setChecking] = useState(false);
+    const [diagnostics, setDiagnostics] = useState<FFmpegDiagnostics | null>(null);
+    const [checkError, setCheckError] = useState('');
+
+    const check = async () => {
+        setChecking(true);
+        setCheckError('');
+        try {
+            setDiagnostics(await getFFmpegDiagnostics(config));
+        } catch (err) {
+            setDiagnostics(null);
+            setCheckError(intl.formatMessage({defaultMessage: 'Unable to check ffmpeg.'}));
+        }
+        setChecking(false);
+    };
+
+    return (
+        <>
+            <ItemList>
+                <TextItem
+                    label={intl.formatMessage({defaultMessage: 'ffmpeg Path'})}
+                    value={config.path}
+                    placeholder='/usr/bin/ffmpeg'
+                    helptext={intl.formatMessage({defaultMessage: 'Path to the ffmpeg binary used to convert recordings. Leave empty to use ffmpeg from the PATH or the one bundled with the plugin.'})}
+                    onChange={(e) => props.onChange({...config, path: e.target.value.trim()})}
+                />
+                <SelectionItem
+                    label={intl.formatMessage({defaultMessage: 'Audio Codec'})}
+                    value={config.audioCodec}
+                    onChange={(e) => props.onChange({...config, audioCodec: e.target.value})}
+                    helptext={intl.formatMessage({defaultMessage: 'Codec recordings are converted to before they are transcribed.'})}
+                >
+                    <SelectionItemOption value=''>{intl.formatMessage({defaultMessage: 'MP3 (default)'})}</SelectionItemOption>
+                    <SelectionItemOption value='opus'>{'Opus'}</SelectionItemOption>
+                    <SelectionItemOption value='flac'>{'FLAC'}</SelectionItemOption>
+                </SelectionItem>
+                <TextItem
+                    label={intl.formatMessage({defaultMessage: 'Audio Bitrate'})}
+                    value={config.audioBitrate}
+                    placeholder='64k'
+                    helptext={intl.formatMessage({defaultMessage: 'Bitrate of the converted audio, such as "32k". Higher bitrates make larger parts to transcribe. Not used for FLAC.'})}
+                    onChange={(e) => props.onChange({...config, audioBitrate: e.target.value.trim()})}
+                />
+                <TextItem
+                    label={intl.formatMessage({defaultMessage: 'Sample Rate'})}
+                    type='number'
+                    min='0'
+                    value={config.sampleRate.toString()}
+                    helptext={intl.formatMessage({defaultMessage: 'Sample rate in Hz of the converted audio, such as 16000. Leave at 0 to keep the sample rate of the recording.'})}
+                    onChange={(e) => {
+                        const value = parseInt(e.target.value, 10);
+                        props.onChange({...config, sampleRate: isNaN(value) ? 0 : value});
+                    }}
+                />
+                <TextItem
+                    label={intl.formatMessage({defaultMessage: 'Hardware Acceleration'})}
+                    value={config.hwaccel}
+                    placeholder='auto'
+                    helptext={intl.formatMessage({defaultMessage: 'Hardware acceleration method used to decode recordings, such as "cuda", "vaapi" or "auto". Leave empty to decode in software.'})}
+                    onChange={(e) => props.onChange({...config, hwaccel: e.target.value.trim()})}
+                />
+            </ItemList>
+            <CheckContainer>
+                <TertiaryButton
+                    onClick={check}
+                    disabled={checking}
+                >
+                    <FormattedMessage defaultMessage='Check ffmpeg'/>
+                </TertiaryButton>
+                {checkError && <CheckError>{checkError}</CheckError>}
+                {diagnostics && diagnostics.errors.length === 0 && (
+                    <CheckSuccess>
+                        <FormattedMessage
+                            defaultMessage='ffmpeg {version} at {path} can convert recordings with these settings.'
+                            values={{version: diagnostics.version, path: diagnostics.path}}
+                        />
+                    </CheckSuccess>
+                )}
+                {diagnostics && diagnostics.errors.map((error) => (
+                    <CheckError key={error}>{error}</CheckError>
+                ))}
+            </CheckContainer>
+        </>
+    );
+};
+
+const CheckContainer = styled.div`
+    display: flex;
+    flex-direction: column;
+    align-items: flex-start;
+    gap: 8px;
+    margin-top: 16px;
+`;
+
+const CheckSuccess = styled.div`
+    color: var(--online-indicator);
+    font-size: 12px;
+`;
+
+const CheckError = styled.div`
+    color: var(--error-text);
+    font-size: 12px;
+`;
+
+export default FFmpegSettings;
@@ -0,0 +1,184 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useEffect, useState} from 'react';
+import styled from 'styled-components';
+import {PlusIcon, TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {createGlossaryTerm, deleteGlossaryTerm, getGlossary, GlossaryTerm, updateGlossaryTerm} from '@/client';
+
+import {TertiaryButton} from '../assets/buttons';
+
+import {ItemList, TextItem} from './item';
+
+type EditableTerm = GlossaryTerm & {
+    aliasesText: string;
+    saved: boolean;
+};
+
+const toEditable = (term: GlossaryTerm): EditableTerm => ({
+    ...term,
+    aliases: term.aliases || [],
+    aliasesText: (term.aliases || []).join(', '),
+    saved: true,
+});
+
+const fromEditable = (term: EditableTerm): GlossaryTerm => ({
+    id: term.id,
+    term: term.term,
+    aliases: term.aliasesText.split(',').map((alias) => alias.trim()).filter((alias) => alias !== ''),
+    definition: term.definition,
+    updateAt: term.updateAt,
+});
+
+// The glossary is stored in the database rather than the plugin configuration,
+// so terms are saved one at a time instead of with the rest of the settings.
+const Glossary = () => {
+    const intl = useIntl();
+    const [terms, setTerms] = useState<EditableTerm[]>([]);
+    const [error, setError] = useState('');
+
+    useEffect(() => {
+        getGlossary().
+            then((loaded) => setTerms(loaded.map(toEditable))).
+            catch(() => setError(intl.formatMessage({defaultMessage: 'Unable to load the glossary.'})));
+    }, []);
+
+    const updateTerm = (index: number, term: EditableTerm) => {
+        setTerms(terms.map((t, i) => (i === index ? {...term, saved: false} : t)));
+    };
+
+    const saveTerm = async (index: number) => {
+        setError('');
+        try {
+            const term = fromEditable(terms[index]);
+            const saved = term.id ? await updateGlossaryTerm(term) : await createGlossaryTerm(term);
+            setTerms((current) => current.map((t, i) => (i === index ? toEditable(saved) : t)));
+        } catch (err: any) {
+            if (err?.status_code === 409) {
+                setError(intl.formatMessage({defaultMessage: 'The glossary already has the term "{term}".'}, {term: terms[index].term}));
+            } else {
+                setError(intl.formatMessage({defaultMessage: 'Unable to save the term. Terms need a name and a definition.'}));
+            }
+        }
+    };
+
+    const removeTerm = async (index: number) => {
+        setError('');
+        const term = terms[index];
+        try {
+            if (term.id) {
+                await deleteGlossaryTerm(term.id);
+            }
+            setTerms((current) => current.filter((_, i) => i !== index));
+        } catch (err) {
+            setError(intl.formatMessage({defaultMessage: 'Unable to delete the term.'}));
+        }
+    };
+
+    return (
+        <>
+            <TermsList>
+                {terms.map((term, index) => (
+                    <TermContainer key={term.id || `new-${index}`}>
+                        <ItemList>
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'Term'})}
+                                value={term.term}
+                                placeholder='SRE'
+                                onChange={(e) => updateTerm(index, {...term, term: e.target.value})}
+                            />
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'Aliases'})}
+                                value={term.aliasesText}
+                                placeholder={intl.formatMessage({defaultMessage: 'site reliability, reliability team'})}
+                                helptext={intl.formatMessage({defaultMessage: 'Optional. Other names for the term, separated by commas.'})}
+                                onChange={(e) => updateTerm(index, {...term, aliasesText: e.target.value})}
+                            />
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'Definition'})}
+                                value={term.definition}
+                                multiline={true}
+                                maxLength={2000}
+                                onChange={(e) => updateTerm(index, {...term, definition: e.target.value})}
+                            />
+                        </ItemList>
+                        <Actions>
+                            <TertiaryButton
+                                onClick={() => saveTerm(index)}
+                                disabled={term.saved}
+                            >
+                                <FormattedMessage defaultMessage='Save Term'/>
+                            </TertiaryButton>
+                            <DeleteButton onClick={() => removeTerm(index)}>
+                                <TrashCanOutlineIcon size={16}/>
+                                <FormattedMessage defaultMessage='Delete Term'/>
+                            </DeleteButton>
+                        </Actions>
+                    </TermContainer>
+                ))}
+            </TermsList>
+            {error && <ErrorText>{error}</ErrorText>}
+            <TertiaryButton onClick={() => setTerms([...terms, {id: '', term: '', aliases: [], aliasesText: '', definition: '', updateAt: 0, saved: false}])}>
+                <PlusTermIcon/>
+                <FormattedMessage defaultMessage='Add Term'/>
+            </TertiaryButton>
+        </>
+    );
+};
+
+const TermsList = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    margin-bottom: 16px;
+`;
+
+const TermContainer = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    border: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    border-radius: 4px;
+    padding: 16px;
+    background-color: var(--center-channel-bg);
+`;
+
+const Actions = styled.div`
+    display: flex;
+    align-items: center;
+    gap: 8px;
+`;
+
+const DeleteButton = styled.button`
+    display: flex;
+    align-items: center;
+    gap: 6px;
+    padding: 8px 12px;
+    background: none;
+    border: none;
+    border-radius: 4px;
+    color: var(--error-text);
+    cursor: pointer;
+    font-size: 12px;
+    font-weight: 600;
+
+    &:hover {
+        background: rgba(var(--error-text-color-rgb), 0.08);
+    }
+`;
+
+const ErrorText = styled.div`
+    color: var(--error-text);
+    font-size: 12px;
+    margin-bottom: 16px;
+`;
+
+const PlusTermIcon = styled(PlusIcon)`
+    width: 18px;
+    height: 18px;
+    margin-right: 8px;
+`;
+
+export default Glossary;
@@ -0,0 +1,171 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useRef, useState} from 'react';
+import styled from 'styled-components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {importExport, ImportItemResult, ImportResult} from '@/client';
+
+import {PrimaryButton, TertiaryButton} from '../assets/buttons';
+
+// ImportMigration migrates bots and conversation titles from an export of another AI integration.
+// The export is checked with a dry run first so the admin can review what will be imported.
+// Imported bots are saved to the configuration on the server, so the page has to be reloaded
+// before saving other settings or they would be overwritten.
+const ImportMigration = () => {
+    const intl = useIntl();
+    const fileInput = useRef<HTMLInputElement>(null);
+    const [exportJSON, setExportJSON] = useState('');
+    const [result, setResult] = useState<ImportResult | null>(null);
+    const [error, setError] = useState('');
+    const [pending, setPending] = useState(false);
+
+    const run = async (json: string, dryRun: boolean) => {
+        setPending(true);
+        setError('');
+        try {
+            setResult(await importExport(json, dryRun));
+        } catch (err: any) {
+            setResult(null);
+            if (err?.status_code === 400) {
+                setError(intl.formatMessage({defaultMessage: 'The file isn\'t a valid export or has nothing to import.'}));
+            } else {
+                setError(intl.formatMessage({defaultMessage: 'Unable to import the export. Check the server logs for details.'}));
+            }
+        }
+        setPending(false);
+    };
+
+    const chooseFile = async (e: React.ChangeEvent<HTMLInputElement>) => {
+        const file = e.target.files?.[0];
+        e.target.value = '';
+        if (!file) {
+            return;
+        }
+        const json = await file.text();
+        setExportJSON(json);
+        run(json, true);
+    };
+
+    const importable = result?.dryRun && [...result.bots, ...result.conversations].some((item) => item.imported);
+
+    return (
+        <>
+            <HiddenInput
+                ref={fileInput}
+                type='file'
+                accept='application/json,.json'
+                onChange={chooseFile}
+            />
+            <TertiaryButton
+                disabled={pending}
+                onClick={() => fileInput.current?.click()}
+            >
+                <FormattedMessage defaultMessage='Choose Export File'/>
+            </TertiaryButton>
+            {error && <ErrorText>{error}</ErrorText>}
+            {result && (
+                <Results>
+                    {result.dryRun ? (
+                        <FormattedMessage defaultMessage='The export contains:'/>
+                    ) : (
+                        <FormattedMessage defaultMessage='Import complete. Reload the page to see the imported bots before changing other settings.'/>
+                    )}
+                    <ResultList
+                        title={intl.formatMessage({defaultMessage: 'Bots'})}
+                        items={result.bots}
+                        dryRun={result.dryRun}
+                    />
+                    <ResultList
+                        title={intl.formatMessage({defaultMessage: 'Conversations'})}
+                        items={result.conversations}
+                        dryRun={result.dryRun}
+                    />
+                    {importable && (
+                        <PrimaryButton
+                            disabled={pending}
+                            onClick={() => run(exportJSON, false)}
+                        >
+                            <FormattedMessage defaultMessage='Import'/>
+                        </PrimaryButton>
+                    )}
+                </Results>
+            )}
+        </>
+    );
+};
+
+type ResultListProps = {
+    title: string;
+    items: ImportItemResult[];
+    dryRun: boolean;
+};
+
+const ResultList = (props: ResultListProps) => {
+    if (!props.items || props.items.length === 0) {
+        return null;
+    }
+
+    return (
+        <div>
+            <ListTitle>{props.title}</ListTitle>
+            <ul>
+                {props.items.map((item, index) => (
+                    <li key={index}>
+                        {item.name}
+                        {item.imported && (
+                            <Status>
+                                {props.dryRun ? (
+                                    <FormattedMessage defaultMessage=' · will be imported'/>
+                                ) : (
+                                    <FormattedMessage defaultMessage=' · imported'/>
+                                )}
+                            </Status>
+                        )}
+                        {!item.imported && (
+                            <SkippedStatus>
+                                <FormattedMessage
+                                    defaultMessage=' · skipped: {reason}'
+                                    values={{reason: item.reason}}
+                                />
+                            </SkippedStatus>
+                        )}
+                    </li>
+                ))}
+            </ul>
+        </div>
+    );
+};
+
+const HiddenInput = styled.input`
+    display: none;
+`;
+
+const Results = styled.div`
+    display: flex;
+    flex-direction: column;
+    align-items: flex-start;
+    gap: 12px;
+    margin-top: 16px;
+`;
+
+const ListTitle = styled.div`
+    font-weight: 600;
+`;
+
+const Status = styled.span`
+    color: rgba(var(--center-channel-color-rgb), 0.64);
+`;
+
+const SkippedStatus = styled.span`
+    color: var(--error-text);
+`;
+
+const ErrorText = styled.div`
+    color: var(--error-text);
+    font-size: 12px;
+    margin-top: 16px;
+`;
+
+export default ImportMigration;
@@ -0,0 +1,206 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useEffect, useState} from 'react';
+import styled from 'styled-components';
+import {TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {MCPSecret, deleteMCPSecret, getMCPSecrets, rotateMCPSecretsKey, setMCPSecret} from '@/client';
+
+import {TertiaryButton} from '../assets/buttons';
+
+// Credentials of the MCP servers, stored encrypted and referenced from the server settings as ${secret:name}.
+// Values are write only, they are never sent back to the browser.
+const MCPSecrets = () => {
+    const intl = useIntl();
+    const [secrets, setSecrets] = useState<MCPSecret[]>([]);
+    const [name, setName] = useState('');
+    const [value, setValue] = useState('');
+    const [error, setError] = useState('');
+    const [saving, setSaving] = useState(false);
+
+    const load = async () => {
+        try {
+            setSecrets(await getMCPSecrets());
+        } catch (err) {
+            setError(intl.formatMessage({defaultMessage: 'Unable to load the secrets.'}));
+        }
+    };
+
+    useEffect(() => {
+        load();
+    }, []);
+
+    // Runs a change to the secrets, and lists them again
+    const update = async (change: () => Promise<void>, failure: string) => {
+        setSaving(true);
+        setError('');
+        try {
+            await change();
+            await load();
+        } catch (err) {
+            setError(failure);
+        }
+        setSaving(false);
+    };
+
+    const save = () => update(async () => {
+        await setMCPSecret(name.trim(), value);
+        setName('');
+        setValue('');
+    }, intl.formatMessage({defaultMessage: 'Unable to save the secret. Names can only contain letters, numbers, "-", "_" and ".".'}));
+
+    const remove = (secretName: string) => update(
+        () => deleteMCPSecret(secretName),
+        intl.formatMessage({defaultMessage: 'Unable to delete the secret.'}),
+    );
+
+    const rotate = () => update(
+        rotateMCPSecretsKey,
+        intl.formatMessage({defaultMessage: 'Unable to rotate the encryption key.'}),
+    );
+
+    return (
+        <SecretsContainer>
+            <SecretsTitle>
+                <FormattedMessage defaultMessage='Secrets'/>
+            </SecretsTitle>
+            <SecretsHelp>
+                <FormattedMessage
+                    defaultMessage='Credentials stored encrypted instead of in the plugin configuration. Reference them in the headers, arguments and environment variables of servers as {reference}. Saving or deleting a secret reconnects the servers.'
+                    values={{reference: <code>{'${secret:name}'}</code>}}
+                />
+            </SecretsHelp>
+
+            {secrets.map((secret) => (
+                <SecretRow key={secret.name}>
+                    <SecretName>{secret.name}</SecretName>
+                    <SecretUpdated>
+                        {intl.formatMessage(
+                            {defaultMessage: 'Updated {time}'},
+                            {time: new Date(secret.update_at).toLocaleString()},
+                        )}
+                    </SecretUpdated>
+                    <RemoveSecretButton
+                        onClick={() => remove(secret.name)}
+                        disabled={saving}
+                        aria-label={intl.formatMessage({defaultMessage: 'Delete secret'})}
+                    >
+                        <TrashCanOutlineIcon size={14}/>
+                    </RemoveSecretButton>
+                </SecretRow>
+            ))}
+
+            <SecretRow>
+                <SecretInput
+                    placeholder={intl.formatMessage({defaultMessage: 'Name'})}
+                    value={name}
+                    onChange={(e) => setName(e.target.value)}
+                />
+                <SecretInput
+                    type='password'
+                    autoComplete='new-password'
+                    placeholder={intl.formatMessage({defaultMessage: 'Value'})}
+                    value={value}
+                    onChange={(e) => setValue(e.target.value)}
+                />
+                <TertiaryButton
+                    onClick={save}
+                    disabled={saving || name.trim() === '' || value === ''}
+                >
+                    <FormattedMessage defaultMessage='Save Secret'/>
+                </TertiaryButton>
+            </SecretRow>
+
+            {secrets.length > 0 && (
+                <TertiaryButton
+                    onClick={rotate}
+                    disabled={saving}
+                >
+                    <FormattedMessage defaultMessage='Rotate Encryption Key'/>
+                </TertiaryButton>
+            )}
+            {error && <SecretsError>{error}</SecretsError>}
+        </SecretsContainer>
+    );
+};
+
+const SecretsContainer = styled.div`
+    display: flex;
+    flex-direction: column;
+    align-items: flex-start;
+    gap: 12px;
+    border: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    border-radius: 4px;
+    padding: 16px;
+    margin-bottom: 16px;
+    background-color: var(--center-channel-bg);
+`;
+
+const SecretsTitle = styled.div`
+    font-weight: 600;
+    font-size: 16px;
+    color: var(--center-channel-color);
+`;
+
+const SecretsHelp = styled.div`
+    font-size: 12px;
+    color: rgba(var(--center-channel-color-rgb), 0.72);
+`;
+
+const SecretRow = styled.div`
+    display: flex;
+    gap: 8px;
+    align-items: center;
+    align-self: stretch;
+`;
+
+const SecretName = styled.span`
+    flex: 1;
+    font-family: monospace;
+    font-size: 14px;
+`;
+
+const SecretUpdated = styled.span`
+    font-size: 12px;
+    color: rgba(var(--center-channel-color-rgb), 0.64);
+`;
+
+const SecretInput = styled.input`
+    flex: 1;
+    padding: 8px 12px;
+    border-radius: 4px;
+    border: 1px solid rgba(var(--center-channel-color-rgb), 0.16);
+    background: var(--center-channel-bg);
+    font-size: 14px;
+
+    &:focus {
+        border-color: var(--button-bg);
+        outline: none;
+    }
+`;
+
+const RemoveSecretButton = styled.button`
+    display: flex;
+    align-items: center;
+    justify-content: center;
+    width: 28px;
+    height: 28px;
+    background: none;
+    border: none;
+    border-radius: 4px;
+    color: var(--error-text);
+    cursor: pointer;
+
+    &:hover {
+        background: rgba(var(--error-text-color-rgb), 0.08);
+    }
+`;
+
+const SecretsError = styled.div`
+    color: var(--error-text);
+    font-size: 12px;
+`;
+
+export default MCPSecrets;
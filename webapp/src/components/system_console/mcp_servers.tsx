@@ -1,18 +1,29 @@
 // Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
 // See LICENSE.txt for license information.
 
-import React, {useState} from 'react';
+import React, {useEffect, useState} from 'react';
 import styled from 'styled-components';
 import {PlusIcon, TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
 import {FormattedMessage, useIntl} from 'react-intl';
 
+import {MCPServerHealth, getMCPServerHealth, testMCPServer} from '@/client';
+
 import {TertiaryButton} from '../assets/buttons';
 
-import {BooleanItem, ItemList, TextItem} from './item';
+import {BooleanItem, ItemList, SelectionItem, SelectionItemOption, TextItem} from './item';
+import MCPSecrets from './mcp_secrets';
 
 export type MCPServerConfig = {
     baseURL: string;
     headers: {[key: string]: string};
+    transport?: string;
+    command?: string;
+    args?: string[];
+    env?: {[key: string]: string};
+    allowSampling?: boolean;
+    shared?: boolean;
+    roots?: string[];
+    toolTimeoutSeconds?: number;
 };
 
 export type MCPConfig = {
@@ -32,16 +43,106 @@ const defaultServerConfig: MCPServerConfig = {
     headers: {},
 };
 
+// Editable list of the headers or environment variables of a server
+const KeyValueSection = (props: {
+    title: string;
+    keyPlaceholder: string;
+    addLabel: string;
+    entries: {[key: string]: string};
+    onAdd: () => void;
+    onUpdate: (oldKey: string, newKey: string, value: string) => void;
+    onRemove: (key: string) => void;
+}) => {
+    const intl = useIntl();
+
+    return (
+        <HeadersSection>
+            <HeadersSectionTitle>
+                {props.title}
+            </HeadersSectionTitle>
+
+            <HeadersList>
+                {Object.entries(props.entries).map(([key, value]) => (
+                    <HeaderRow key={key}>
+                        <HeaderInput
+                            placeholder={props.keyPlaceholder}
+                            value={key}
+                            onChange={(e) => props.onUpdate(key, e.target.value, value)}
+                        />
+                        <HeaderInput
+                            placeholder={intl.formatMessage({defaultMessage: 'Value'})}
+                            value={value}
+                            onChange={(e) => props.onUpdate(key, key, e.target.value)}
+                        />
+                        <RemoveHeaderButton
+                            onClick={() => props.onRemove(key)}
+                        >
+                            <TrashCanOutlineIcon size={14}/>
+                        </RemoveHeaderButton>
+                    </HeaderRow>
+                ))}
+            </HeadersList>
+
+            <AddHeaderButton
+                onClick={props.onAdd}
+            >
+                <PlusIcon size={14}/>
+                {props.addLabel}
+            </AddHeaderButton>
+        </HeadersSection>
+    );
+};
+
+// Status of the last connection to a server, from any user or the last connection test
+const ServerStatus = ({health}: {health?: MCPServerHealth}) => {
+    const intl = useIntl();
+
+    if (!health || health.status === 'unknown') {
+        return (
+            <StatusText>
+                {intl.formatMessage({defaultMessage: 'Not connected yet. Test the connection to check the server.'})}
+            </StatusText>
+        );
+    }
+
+    return (
+        <StatusText>
+            {health.status === 'connected' && (
+                <StatusConnected>
+                    {intl.formatMessage(
+                        {defaultMessage: 'Connected: {toolCount} tools, {latency} ms round trip, {connections} active connections'},
+                        {toolCount: health.tool_count, latency: health.latency_ms, connections: health.active_connections},
+                    )}
+                </StatusConnected>
+            )}
+            {health.status === 'error' && (
+                <StatusError>{intl.formatMessage({defaultMessage: 'Connection failed'})}</StatusError>
+            )}
+            {health.status === 'disabled' && intl.formatMessage({defaultMessage: 'MCP is disabled'})}
+            {health.last_error && (
+                <div>
+                    {intl.formatMessage(
+                        {defaultMessage: 'Last error ({time}): {error}'},
+                        {time: new Date(health.last_error_at || 0).toLocaleString(), error: health.last_error},
+                    )}
+                </div>
+            )}
+        </StatusText>
+    );
+};
+
 // Component for a single MCP server configuration
 const MCPServer = ({
     serverID,
     serverConfig,
+    health,
     onChange,
     onDelete,
     onRename,
 }: {
     serverID: string;
     serverConfig: MCPServerConfig;
+    health?: MCPServerHealth;
     onChange: (serverID: string, config: MCPServerConfig) => void;
     onDelete: () => void;
     onRename: (oldID: string, newID: string, config: MCPServerConfig) => void;
@@ -49,6 +150,8 @@ const MCPServer = ({
     const intl = useIntl();
     const [isEditingName, setIsEditingName] = useState(false);
     const [serverName, setServerName] = useState(serverID);
+    const [testing, setTesting] = useState(false);
+    const [testResult, setTestResult] = useState<MCPServerHealth | undefined>();
 
     // Ensure server config has all required properties
     const config = {
@@ -64,47 +167,124 @@ const MCPServer = ({
         });
     };
 
-    // Add a new header
-    const addHeader = () => {
-        const headers = config.headers || {};
+    // Update the transport used to connect to the server
+    const updateTransport = (transport: string) => {
+        onChange(serverID, {
+            ...config,
+            transport,
+        });
+    };
+
+    // Update the command launching a stdio server
+    const updateCommand = (command: string) => {
+        onChange(serverID, {
+            ...config,
+            command,
+        });
+    };
+
+    // Update the arguments of the command, one per line
+    const updateArgs = (args: string) => {
+        onChange(serverID, {
+            ...config,
+            args: args === '' ? [] : args.split('\n'),
+        });
+    };
+
+    // Update the roots the server is scoped to, one per line
+    const updateRoots = (roots: string) => {
+        onChange(serverID, {
+            ...config,
+            roots: roots === '' ? [] : roots.split('\n'),
+        });
+    };
+
+    // Update how long the tools of the server can run, the default when empty
+    const updateToolTimeout = (value: string) => {
+        const toolTimeoutSeconds = parseInt(value, 10);
+        onChange(serverID, {
+            ...config,
+            toolTimeoutSeconds: isNaN(toolTimeoutSeconds) ? 0 : Math.max(0, toolTimeoutSeconds),
+        });
+    };
+
+    // Allow the server to ask the bot for completions
+    const updateAllowSampling = (allowSampling: boolean) => {
+        onChange(serverID, {
+            ...config,
+            allowSampling,
+        });
+    };
+
+    const updateShared = (shared: boolean) => {
+        onChange(serverID, {
+            ...config,
+            shared,
+        });
+    };
+
+    // Add a new header or environment variable
+    const addEntry = (field: 'headers' | 'env') => {
+        const entries = config[field] || {};
         onChange(serverID, {
             ...config,
-            headers: {
-                ...headers,
+            [field]: {
+                ...entries,
                 '': '',
             },
         });
     };
 
-    // Update a header's key or value
-    const updateHeader = (oldKey: string, newKey: string, value: string) => {
-        const headers = {...(config.headers || {})};
+    // Update a header's or environment variable's key or value
+    const updateEntry = (field: 'headers' | 'env', oldKey: string, newKey: string, value: string) => {
+        const entries = {...(config[field] || {})};
 
         // If the key has changed, remove the old one
         if (oldKey !== newKey && oldKey !== '') {
-            delete headers[oldKey];
+            delete entries[oldKey];
         }
 
         // Set the new key-value pair
-        headers[newKey] = value;
+        entries[newKey] = value;
 
         onChange(serverID, {
             ...config,
-            headers,
+            [field]: entries,
         });
     };
 
-    // Remove a header
-    const removeHeader = (key: string) => {
-        const headers = {...(config.headers || {})};
-        delete headers[key];
+    // Remove a header or environment variable
+    const removeEntry = (field: 'headers' | 'env', key: string) => {
+        const entries = {...(config[field] || {})};
+        delete entries[key];
 
         onChange(serverID, {
             ...config,
-            headers,
+            [field]: entries,
         });
     };
 
+    // Test the connection with the settings being edited
+    const testConnection = async () => {
+        setTesting(true);
+        try {
+            setTestResult(await testMCPServer(serverID, config));
+        } catch (error) {
+            setTestResult({
+                server_id: serverID,
+                transport: config.transport || '',
+                status: 'error',
+                last_error: String(error),
+                last_error_at: Date.now(),
+                tool_count: 0,
+                latency_ms: 0,
+                active_connections: 0,
+            });
+        } finally {
+            setTesting(false);
+        }
+    };
+
     // Handle renaming the server
     const handleRename = () => {
         const newName = serverName.trim();
@@ -151,48 +331,104 @@ const MCPServer = ({
                 </DeleteButton>
             </ServerHeader>
 
+            <SelectionItem
+                label={intl.formatMessage({defaultMessage: 'Transport'})}
+                value={config.transport || ''}
+                onChange={(e) => updateTransport(e.target.value)}
+                helptext={intl.formatMessage({defaultMessage: 'How to connect to the MCP server. Automatic uses streamable HTTP, and falls back to SSE for servers that only support it. Stdio launches the server on the Mattermost server.'})}
+            >
+                <SelectionItemOption value=''>{intl.formatMessage({defaultMessage: 'Automatic'})}</SelectionItemOption>
+                <SelectionItemOption value='streamable_http'>{intl.formatMessage({defaultMessage: 'Streamable HTTP'})}</SelectionItemOption>
+                <SelectionItemOption value='sse'>{intl.formatMessage({defaultMessage: 'SSE'})}</SelectionItemOption>
+                <SelectionItemOption value='stdio'>{intl.formatMessage({defaultMessage: 'Stdio'})}</SelectionItemOption>
+            </SelectionItem>
+
+            {config.transport === 'stdio' ? (
+                <>
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Command'})}
+                        placeholder='/opt/mcp/bin/files-server'
+                        value={config.command || ''}
+                        onChange={(e) => updateCommand(e.target.value)}
+                        helptext={intl.formatMessage({defaultMessage: 'The command launching the MCP server. It is launched for each user, and restarted if it exits.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Arguments'})}
+                        placeholder={intl.formatMessage({defaultMessage: 'One argument per line'})}
+                        multiline={true}
+                        value={(config.args || []).join('\n')}
+                        onChange={(e) => updateArgs(e.target.value)}
+                        helptext={intl.formatMessage({defaultMessage: 'The arguments of the command, one per line.'})}
+                    />
+                    <KeyValueSection
+                        title={intl.formatMessage({defaultMessage: 'Environment Variables'})}
+                        keyPlaceholder={intl.formatMessage({defaultMessage: 'Variable name'})}
+                        addLabel={intl.formatMessage({defaultMessage: 'Add Variable'})}
+                        entries={config.env || {}}
+                        onAdd={() => addEntry('env')}
+                        onUpdate={(oldKey, newKey, value) => updateEntry('env', oldKey, newKey, value)}
+                        onRemove={(key) => removeEntry('env', key)}
+                    />
+                </>
+            ) : (
+                <>
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Server URL'})}
+                        placeholder='https://mcp.example.com'
+                        value={config.baseURL}
+                        onChange={(e) => updateServerURL(e.target.value)}
+                        helptext={intl.formatMessage({defaultMessage: 'The base URL of the MCP server.'})}
+                    />
+                    <KeyValueSection
+                        title={intl.formatMessage({defaultMessage: 'Headers'})}
+                        keyPlaceholder={intl.formatMessage({defaultMessage: 'Header name'})}
+                        addLabel={intl.formatMessage({defaultMessage: 'Add Header'})}
+                        entries={config.headers || {}}
+                        onAdd={() => addEntry('headers')}
+                        onUpdate={(oldKey, newKey, value) => updateEntry('headers', oldKey, newKey, value)}
+                        onRemove={(key) => removeEntry('headers', key)}
+                    />
+                </>
+            )}
+
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Roots'})}
+                placeholder='file:///srv/projects/{channel_id}'
+                multiline={true}
+                value={(config.roots || []).join('\n')}
+                onChange={(e) => updateRoots(e.target.value)}
+                helptext={intl.formatMessage({defaultMessage: 'The URIs of the directories or repositories the server is scoped to, one per line. {user_id} and {channel_id} are replaced with the user and the channel of the conversation. Not supported with the SSE transport.'}, {user_id: '{user_id}', channel_id: '{channel_id}'})}
+            />
             <TextItem
-                label={intl.formatMessage({defaultMessage: 'Server URL'})}
-                placeholder='https://mcp.example.com'
-                value={config.baseURL}
-                onChange={(e) => updateServerURL(e.target.value)}
-                helptext={intl.formatMessage({defaultMessage: 'The base URL of the MCP server.'})}
+                label={intl.formatMessage({defaultMessage: 'Tool Timeout (seconds)'})}
+                placeholder='300'
+                type='number'
+                value={config.toolTimeoutSeconds ? config.toolTimeoutSeconds.toString() : ''}
+                onChange={(e) => updateToolTimeout(e.target.value)}
+                helptext={intl.formatMessage({defaultMessage: 'How long a tool of the server can run before it is cancelled. Leave empty for the default of 5 minutes.'})}
+            />
+            <BooleanItem
+                label={intl.formatMessage({defaultMessage: 'Allow Sampling'})}
+                value={Boolean(config.allowSampling)}
+                onChange={updateAllowSampling}
+                helpText={intl.formatMessage({defaultMessage: 'Let the server ask the bot running one of its tools to generate a response. Users approve each request like a tool call. Not supported with the SSE transport or shared connections.'})}
+            />
+            <BooleanItem
+                label={intl.formatMessage({defaultMessage: 'Shared Connection'})}
+                value={Boolean(config.shared)}
+                onChange={updateShared}
+                helpText={intl.formatMessage({defaultMessage: 'Use a single connection to the server for all users, for servers that don\'t need to know who the user is. The user ID header or environment variable is not sent.'})}
             />
 
-            <HeadersSection>
-                <HeadersSectionTitle>
-                    {intl.formatMessage({defaultMessage: 'Headers'})}
-                </HeadersSectionTitle>
-
-                <HeadersList>
-                    {Object.entries(config.headers || {}).map(([key, value]) => (
-                        <HeaderRow key={key}>
-                            <HeaderInput
-                                placeholder={intl.formatMessage({defaultMessage: 'Header name'})}
-                                value={key}
-                                onChange={(e) => updateHeader(key, e.target.value, value)}
-                            />
-                            <HeaderInput
-                                placeholder={intl.formatMessage({defaultMessage: 'Value'})}
-                                value={value}
-                                onChange={(e) => updateHeader(key, key, e.target.value)}
-                            />
-                            <RemoveHeaderButton
-                                onClick={() => removeHeader(key)}
-                            >
-                                <TrashCanOutlineIcon size={14}/>
-                            </RemoveHeaderButton>
-                        </HeaderRow>
-                    ))}
-                </HeadersList>
-
-                <AddHeaderButton
-                    onClick={addHeader}
+            <StatusSection>
+                <ServerStatus health={testResult || health}/>
+                <TertiaryButton
+                    onClick={testConnection}
+                    disabled={testing}
                 >
-                    <PlusIcon size={14}/>
-                    <FormattedMessage defaultMessage='Add Header'/>
-                </AddHeaderButton>
-            </HeadersSection>
+                    {testing ? intl.formatMessage({defaultMessage: 'Testing...'}) : intl.formatMessage({defaultMessage: 'Test Connection'})}
+                </TertiaryButton>
+            </StatusSection>
         </ServerContainer>
     );
 };
@@ -209,6 +445,14 @@ const MCPServers = ({value, onChange}: Props) => {
         mcpConfig.servers = {};
     }
 
+    // Health of the saved servers, from the connections made to them
+    const [health, setHealth] = useState<{[serverID: string]: MCPServerHealth}>({});
+    useEffect(() => {
+        getMCPServerHealth().then((servers) => {
+            setHealth(Object.fromEntries(servers.map((server) => [server.server_id, server])));
+        }).catch(() => setHealth({}));
+    }, []);
+
     // Ensure idleTimeout has a value
     if (typeof mcpConfig.idleTimeout !== 'number') {
         mcpConfig.idleTimeout = 30; // Default to 30 minutes
@@ -337,6 +581,7 @@ const MCPServers = ({value, onChange}: Props) => {
                                     key={serverID}
                                     serverID={serverID}
                                     serverConfig={serverConfig}
+                                    health={health[serverID]}
                                     onChange={updateServer}
                                     onDelete={() => deleteServer(serverID)}
                                     onRename={renameServer}
@@ -353,6 +598,8 @@ const MCPServers = ({value, onChange}: Props) => {
                             <FormattedMessage defaultMessage='Add MCP Server'/>
                         </TertiaryButton>
                     </AddServerContainer>
+
+                    <MCPSecrets/>
                 </>
             )}
         </div>
@@ -427,6 +674,29 @@ const DeleteButton = styled.button`
     }
 `;
 
+const StatusSection = styled.div`
+    display: flex;
+    justify-content: space-between;
+    align-items: center;
+    gap: 16px;
+`;
+
+const StatusText = styled.div`
+    font-size: 12px;
+    color: rgba(var(--center-channel-color-rgb), 0.72);
+    overflow-wrap: anywhere;
+`;
+
+const StatusConnected = styled.span`
+    color: var(--online-indicator);
+    font-weight: 600;
+`;
+
+const StatusError = styled.div`
+    color: var(--error-text);
+    font-weight: 600;
+`;
+
 const HeadersSection = styled.div`
     display: flex;
     flex-direction: column;
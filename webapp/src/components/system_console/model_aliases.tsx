@@ -0,0 +1,161 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import styled from 'styled-components';
+import {PlusIcon, TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {TertiaryButton} from '../assets/buttons';
+
+import {HelpText, ItemLabel, ItemList, TextItem} from './item';
+
+export type ModelAlias = {
+    alias: string;
+    model: string;
+    baseModel: string;
+};
+
+// invalidModelAliases mirrors the server validation closely enough to flag mistakes before saving.
+// Aliases that fail the server validation disable the bot.
+export const invalidModelAliases = (serviceType: string, aliases: ModelAlias[] | undefined) => {
+    const seen = new Set<string>();
+    for (const alias of aliases ?? []) {
+        if (alias.alias === '' || (/\s/).test(alias.alias) || seen.has(alias.alias)) {
+            return true;
+        }
+        seen.add(alias.alias);
+
+        if (alias.model === '' || (/\s/).test(alias.model)) {
+            return true;
+        }
+        if (serviceType === 'openai' && alias.model.startsWith('ft:') && !(/^ft:[^:]+:[^:]*:([^:]*:)?[^:]+$/).test(alias.model)) {
+            return true;
+        }
+        if (serviceType === 'azure' && !(/^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$/).test(alias.model)) {
+            return true;
+        }
+    }
+    return false;
+};
+
+type Props = {
+    serviceType: string;
+    aliases: ModelAlias[];
+    onChange: (aliases: ModelAlias[]) => void;
+};
+
+const ModelAliases = (props: Props) => {
+    const intl = useIntl();
+
+    const updateAlias = (index: number, alias: ModelAlias) => {
+        props.onChange(props.aliases.map((a, i) => (i === index ? alias : a)));
+    };
+
+    const modelPlaceholder = () => {
+        switch (props.serviceType) {
+        case 'openai':
+            return 'ft:gpt-4o-mini-2024-07-18:my-org:support:9ABel2dg';
+        case 'azure':
+            return intl.formatMessage({defaultMessage: 'Deployment name'});
+        default:
+            return intl.formatMessage({defaultMessage: 'Model or LoRA adapter name'});
+        }
+    };
+
+    return (
+        <>
+            <ItemLabel>
+                <FormattedMessage defaultMessage='Model aliases'/>
+            </ItemLabel>
+            <div>
+                <AliasesList>
+                    {props.aliases.map((alias, index) => (
+                        <AliasContainer key={index}>
+                            <ItemList>
+                                <TextItem
+                                    label={intl.formatMessage({defaultMessage: 'Alias'})}
+                                    value={alias.alias}
+                                    placeholder='support-tuned'
+                                    onChange={(e) => updateAlias(index, {...alias, alias: e.target.value.trim()})}
+                                />
+                                <TextItem
+                                    label={intl.formatMessage({defaultMessage: 'Model ID'})}
+                                    value={alias.model}
+                                    placeholder={modelPlaceholder()}
+                                    onChange={(e) => updateAlias(index, {...alias, model: e.target.value.trim()})}
+                                />
+                                <TextItem
+                                    label={intl.formatMessage({defaultMessage: 'Base model'})}
+                                    value={alias.baseModel}
+                                    placeholder={intl.formatMessage({defaultMessage: 'Detected from OpenAI fine-tune IDs'})}
+                                    helptext={intl.formatMessage({defaultMessage: 'The model that was fine-tuned. Usage is tagged with it so the fine-tune can be compared with the base model.'})}
+                                    onChange={(e) => updateAlias(index, {...alias, baseModel: e.target.value.trim()})}
+                                />
+                            </ItemList>
+                            <DeleteButton onClick={() => props.onChange(props.aliases.filter((_, i) => i !== index))}>
+                                <TrashCanOutlineIcon size={16}/>
+                                <FormattedMessage defaultMessage='Delete Alias'/>
+                            </DeleteButton>
+                        </AliasContainer>
+                    ))}
+                </AliasesList>
+                <TertiaryButton onClick={() => props.onChange([...props.aliases, {alias: '', model: '', baseModel: ''}])}>
+                    <PlusAliasIcon/>
+                    <FormattedMessage defaultMessage='Add Model Alias'/>
+                </TertiaryButton>
+                <HelpText>
+                    <FormattedMessage defaultMessage='Short names for fine-tuned models, such as OpenAI fine-tunes, Azure deployments or LoRA adapters. Use an alias as the default model or the alternate model of an experiment.'/>
+                </HelpText>
+            </div>
+        </>
+    );
+};
+
+const AliasesList = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    margin-bottom: 16px;
+
+    &:empty {
+        display: none;
+    }
+`;
+
+const AliasContainer = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    border: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    border-radius: 4px;
+    padding: 16px;
+    background-color: var(--center-channel-bg);
+`;
+
+const DeleteButton = styled.button`
+    display: flex;
+    align-self: flex-start;
+    align-items: center;
+    gap: 6px;
+    padding: 8px 12px;
+    background: none;
+    border: none;
+    border-radius: 4px;
+    color: var(--error-text);
+    cursor: pointer;
+    font-size: 12px;
+    font-weight: 600;
+
+    &:hover {
+        background: rgba(var(--error-text-color-rgb), 0.08);
+    }
+`;
+
+const PlusAliasIcon = styled(PlusIcon)`
+    width: 18px;
+    height: 18px;
+    margin-right: 8px;
+`;
+
+export default ModelAliases;
@@ -0,0 +1,131 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import styled from 'styled-components';
+import {PlusIcon, TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {TertiaryButton} from '../assets/buttons';
+
+import {HelpText, ItemLabel, ItemList, TextItem} from './item';
+
+export type ModelPrice = {
+    model: string;
+    inputTokenPrice: number;
+    outputTokenPrice: number;
+};
+
+type Props = {
+    prices: ModelPrice[];
+    onChange: (prices: ModelPrice[]) => void;
+};
+
+const parsePrice = (value: string) => {
+    const price = parseFloat(value);
+    return isNaN(price) ? 0 : price;
+};
+
+const ModelPrices = (props: Props) => {
+    const intl = useIntl();
+
+    const updatePrice = (index: number, price: ModelPrice) => {
+        props.onChange(props.prices.map((p, i) => (i === index ? price : p)));
+    };
+
+    return (
+        <>
+            <ItemLabel>
+                <FormattedMessage defaultMessage='Model prices'/>
+            </ItemLabel>
+            <div>
+                <PricesList>
+                    {props.prices.map((price, index) => (
+                        <PriceContainer key={index}>
+                            <ItemList>
+                                <TextItem
+                                    label={intl.formatMessage({defaultMessage: 'Model'})}
+                                    value={price.model}
+                                    placeholder='gpt-4o-mini'
+                                    helptext={intl.formatMessage({defaultMessage: 'A model ID or one of the model aliases.'})}
+                                    onChange={(e) => updatePrice(index, {...price, model: e.target.value.trim()})}
+                                />
+                                <TextItem
+                                    label={intl.formatMessage({defaultMessage: 'Input token price'})}
+                                    type='number'
+                                    value={price.inputTokenPrice.toString()}
+                                    onChange={(e) => updatePrice(index, {...price, inputTokenPrice: parsePrice(e.target.value)})}
+                                />
+                                <TextItem
+                                    label={intl.formatMessage({defaultMessage: 'Output token price'})}
+                                    type='number'
+                                    value={price.outputTokenPrice.toString()}
+                                    onChange={(e) => updatePrice(index, {...price, outputTokenPrice: parsePrice(e.target.value)})}
+                                />
+                            </ItemList>
+                            <DeleteButton onClick={() => props.onChange(props.prices.filter((_, i) => i !== index))}>
+                                <TrashCanOutlineIcon size={16}/>
+                                <FormattedMessage defaultMessage='Delete Price'/>
+                            </DeleteButton>
+                        </PriceContainer>
+                    ))}
+                </PricesList>
+                <TertiaryButton onClick={() => props.onChange([...props.prices, {model: '', inputTokenPrice: 0, outputTokenPrice: 0}])}>
+                    <PlusPriceIcon/>
+                    <FormattedMessage defaultMessage='Add Model Price'/>
+                </TertiaryButton>
+                <HelpText>
+                    <FormattedMessage defaultMessage='Prices in US dollars per million tokens of the models used by the bot, such as the models of experiments and light tasks. They are used to estimate the cost of each thread, and override the built in price list.'/>
+                </HelpText>
+            </div>
+        </>
+    );
+};
+
+const PricesList = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    margin-bottom: 16px;
+
+    &:empty {
+        display: none;
+    }
+`;
+
+const PriceContainer = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    border: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    border-radius: 4px;
+    padding: 16px;
+    background-color: var(--center-channel-bg);
+`;
+
+const DeleteButton = styled.button`
+    display: flex;
+    align-self: flex-start;
+    align-items: center;
+    gap: 6px;
+    padding: 8px 12px;
+    background: none;
+    border: none;
+    border-radius: 4px;
+    color: var(--error-text);
+    cursor: pointer;
+    font-size: 12px;
+    font-weight: 600;
+
+    &:hover {
+        background: rgba(var(--error-text-color-rgb), 0.08);
+    }
+`;
+
+const PlusPriceIcon = styled(PlusIcon)`
+    width: 18px;
+    height: 18px;
+    margin-right: 8px;
+`;
+
+export default ModelPrices;
@@ -0,0 +1,137 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import styled from 'styled-components';
+import {PlusIcon, TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {TertiaryButton} from '../assets/buttons';
+
+import {ItemList, TextItem} from './item';
+
+export type PersonaConfig = {
+    id: string;
+    name: string;
+    systemPrompt: string;
+    temperature: number | null;
+    allowedTools: string[];
+};
+
+type Props = {
+    value: PersonaConfig[];
+    onChange: (value: PersonaConfig[]) => void;
+};
+
+const Personas = (props: Props) => {
+    const intl = useIntl();
+    const personas = props.value || [];
+
+    const updatePersona = (index: number, persona: PersonaConfig) => {
+        props.onChange(personas.map((p, i) => (i === index ? persona : p)));
+    };
+
+    return (
+        <>
+            <PersonasList>
+                {personas.map((persona, index) => (
+                    <PersonaContainer key={index}>
+                        <ItemList>
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'Name'})}
+                                value={persona.name}
+                                placeholder={intl.formatMessage({defaultMessage: 'Code reviewer'})}
+                                onChange={(e) => updatePersona(index, {...persona, name: e.target.value})}
+                            />
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'ID'})}
+                                value={persona.id}
+                                placeholder='code_reviewer'
+                                helptext={intl.formatMessage({defaultMessage: 'Identifies the persona of conversations. Conversations whose persona is removed go back to the bot\'s default behavior.'})}
+                                onChange={(e) => updatePersona(index, {...persona, id: e.target.value.trim()})}
+                            />
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'System Prompt'})}
+                                value={persona.systemPrompt}
+                                multiline={true}
+                                placeholder={intl.formatMessage({defaultMessage: 'Review the code you are given critically, pointing out bugs before style issues.'})}
+                                helptext={intl.formatMessage({defaultMessage: 'Added to the bot\'s instructions in the conversations in this persona. Personas without a system prompt are not offered.'})}
+                                onChange={(e) => updatePersona(index, {...persona, systemPrompt: e.target.value})}
+                            />
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'Temperature'})}
+                                type='number'
+                                step='0.1'
+                                min='0'
+                                max='2'
+                                value={persona.temperature === null || persona.temperature === undefined ? '' : persona.temperature.toString()}
+                                placeholder={intl.formatMessage({defaultMessage: 'Model default'})}
+                                helptext={intl.formatMessage({defaultMessage: 'Lower temperatures give more focused and consistent answers, higher ones more varied answers. Leave empty for the model\'s default.'})}
+                                onChange={(e) => updatePersona(index, {...persona, temperature: e.target.value === '' ? null : parseFloat(e.target.value)})}
+                            />
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'Allowed Tools'})}
+                                value={(persona.allowedTools || []).join(', ')}
+                                placeholder='SearchServer, LookupMattermostUser'
+                                helptext={intl.formatMessage({defaultMessage: 'Comma separated names of the only tools the bot can call in this persona. Leave empty to allow all of the bot\'s tools.'})}
+                                onChange={(e) => updatePersona(index, {...persona, allowedTools: e.target.value.trim() === '' ? [] : e.target.value.split(',').map((t) => t.trim())})}
+                            />
+                        </ItemList>
+                        <DeleteButton onClick={() => props.onChange(personas.filter((_, i) => i !== index))}>
+                            <TrashCanOutlineIcon size={16}/>
+                            <FormattedMessage defaultMessage='Delete Persona'/>
+                        </DeleteButton>
+                    </PersonaContainer>
+                ))}
+            </PersonasList>
+            <TertiaryButton onClick={() => props.onChange([...personas, {id: '', name: '', systemPrompt: '', temperature: null, allowedTools: []}])}>
+                <PlusPersonaIcon/>
+                <FormattedMessage defaultMessage='Add Persona'/>
+            </TertiaryButton>
+        </>
+    );
+};
+
+const PersonasList = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    margin-bottom: 16px;
+`;
+
+const PersonaContainer = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    border: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    border-radius: 4px;
+    padding: 16px;
+    background-color: var(--center-channel-bg);
+`;
+
+const DeleteButton = styled.button`
+    display: flex;
+    align-self: flex-start;
+    align-items: center;
+    gap: 6px;
+    padding: 8px 12px;
+    background: none;
+    border: none;
+    border-radius: 4px;
+    color: var(--error-text);
+    cursor: pointer;
+    font-size: 12px;
+    font-weight: 600;
+
+    &:hover {
+        background: rgba(var(--error-text-color-rgb), 0.08);
+    }
+`;
+
+const PlusPersonaIcon = styled(PlusIcon)`
+    width: 18px;
+    height: 18px;
+    margin-right: 8px;
+`;
+
+export default Personas;
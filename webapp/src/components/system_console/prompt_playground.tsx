@@ -0,0 +1,206 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useState} from 'react';
+import styled from 'styled-components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {PlaygroundResponse, PlaygroundResult, publishPlaygroundInstructions, runPlayground} from '@/client';
+
+import {PrimaryButton, TertiaryButton} from '../assets/buttons';
+
+import {LLMBotConfig} from './bot';
+import {ItemList, SelectionItem, SelectionItemOption, TextItem} from './item';
+
+type Props = {
+    bots: LLMBotConfig[];
+
+    // onPublished updates the bots of the page once the instructions are saved on the server, so saving
+    // the page afterwards doesn't bring back the previous ones
+    onPublished: (bots: LLMBotConfig[]) => void;
+};
+
+const maxInputs = 5;
+
+// PromptPlayground runs sample inputs with a draft of a bot's custom instructions and shows the responses
+// next to those of the published instructions. Publishing saves the draft on the server right away.
+const PromptPlayground = (props: Props) => {
+    const intl = useIntl();
+    const [botName, setBotName] = useState(props.bots[0]?.name ?? '');
+    const bot = props.bots.find((b) => b.name === botName);
+    const [draft, setDraft] = useState(bot?.customInstructions ?? '');
+    const [inputs, setInputs] = useState('');
+    const [results, setResults] = useState<PlaygroundResult[]>([]);
+    const [error, setError] = useState('');
+    const [published, setPublished] = useState(false);
+    const [pending, setPending] = useState(false);
+
+    const sampleInputs = inputs.split('\n').map((input) => input.trim()).filter((input) => input !== '');
+
+    const selectBot = (name: string) => {
+        setBotName(name);
+        setDraft(props.bots.find((b) => b.name === name)?.customInstructions ?? '');
+        setResults([]);
+        setError('');
+        setPublished(false);
+    };
+
+    const run = async () => {
+        setPending(true);
+        setError('');
+        setPublished(false);
+        try {
+            setResults(await runPlayground(botName, draft, sampleInputs));
+        } catch {
+            setResults([]);
+            setError(intl.formatMessage({defaultMessage: 'Unable to run the sample inputs. Save the bot before trying it in the playground.'}));
+        }
+        setPending(false);
+    };
+
+    const publish = async () => {
+        if (!bot) {
+            return;
+        }
+        setPending(true);
+        setError('');
+        try {
+            await publishPlaygroundInstructions(botName, draft, bot.customInstructions);
+            props.onPublished(props.bots.map((b) => (b.name === botName ? {...b, customInstructions: draft.trim()} : b)));
+            setPublished(true);
+        } catch (err: any) {
+            if (err?.status_code === 409) {
+                setError(intl.formatMessage({defaultMessage: 'The instructions of this bot were changed since the page was loaded. Reload the page before publishing.'}));
+            } else {
+                setError(intl.formatMessage({defaultMessage: 'Unable to publish the instructions. Check the server logs for details.'}));
+            }
+        }
+        setPending(false);
+    };
+
+    if (props.bots.length === 0) {
+        return <FormattedMessage defaultMessage='Add a bot to try its instructions.'/>;
+    }
+
+    return (
+        <>
+            <ItemList>
+                <SelectionItem
+                    label={intl.formatMessage({defaultMessage: 'Bot'})}
+                    value={botName}
+                    onChange={(e) => selectBot(e.target.value)}
+                >
+                    {props.bots.map((b) => (
+                        <SelectionItemOption
+                            key={b.name}
+                            value={b.name}
+                        >
+                            {b.displayName || b.name}
+                        </SelectionItemOption>
+                    ))}
+                </SelectionItem>
+                <TextItem
+                    label={intl.formatMessage({defaultMessage: 'Draft instructions'})}
+                    value={draft}
+                    multiline={true}
+                    helptext={intl.formatMessage({defaultMessage: 'A working copy of the custom instructions of the bot. People keep getting the published instructions until the draft is published.'})}
+                    onChange={(e) => setDraft(e.target.value)}
+                />
+                <TextItem
+                    label={intl.formatMessage({defaultMessage: 'Sample inputs'})}
+                    value={inputs}
+                    multiline={true}
+                    placeholder={intl.formatMessage({defaultMessage: 'One message per line'})}
+                    helptext={intl.formatMessage({defaultMessage: 'Up to {max} messages, one per line. Each is answered with the draft and with the published instructions, without tools.'}, {max: maxInputs})}
+                    onChange={(e) => setInputs(e.target.value)}
+                />
+            </ItemList>
+            <Buttons>
+                <TertiaryButton
+                    disabled={pending || sampleInputs.length === 0 || sampleInputs.length > maxInputs}
+                    onClick={run}
+                >
+                    <FormattedMessage defaultMessage='Run'/>
+                </TertiaryButton>
+                <PrimaryButton
+                    disabled={pending || !bot || draft.trim() === bot.customInstructions}
+                    onClick={publish}
+                >
+                    <FormattedMessage defaultMessage='Publish'/>
+                </PrimaryButton>
+            </Buttons>
+            {error && <ErrorText>{error}</ErrorText>}
+            {published && (
+                <Notice>
+                    <FormattedMessage defaultMessage='The draft is now the published instructions of the bot.'/>
+                </Notice>
+            )}
+            {results.length > 0 && (
+                <ResultsTable>
+                    <thead>
+                        <tr>
+                            <th><FormattedMessage defaultMessage='Input'/></th>
+                            <th><FormattedMessage defaultMessage='Draft'/></th>
+                            <th><FormattedMessage defaultMessage='Published'/></th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {results.map((result, i) => (
+                            <tr key={i}>
+                                <td>{result.input}</td>
+                                <td><Response response={result.draft}/></td>
+                                <td><Response response={result.published}/></td>
+                            </tr>
+                        ))}
+                    </tbody>
+                </ResultsTable>
+            )}
+        </>
+    );
+};
+
+const Response = (props: {response: PlaygroundResponse}) => {
+    if (props.response.error) {
+        return <ErrorText>{props.response.error}</ErrorText>;
+    }
+    return <ResponseText>{props.response.response}</ResponseText>;
+};
+
+const Buttons = styled.div`
+    display: flex;
+    gap: 8px;
+    margin-top: 24px;
+`;
+
+const ResultsTable = styled.table`
+    margin-top: 24px;
+    width: 100%;
+    table-layout: fixed;
+
+    th, td {
+        padding: 6px 12px;
+        text-align: left;
+        vertical-align: top;
+        border-bottom: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    }
+
+    th {
+        font-weight: 600;
+    }
+`;
+
+const ResponseText = styled.div`
+    white-space: pre-wrap;
+`;
+
+const Notice = styled.div`
+    margin-top: 16px;
+`;
+
+const ErrorText = styled.div`
+    color: var(--error-text);
+    font-size: 12px;
+    margin-top: 16px;
+`;
+
+export default PromptPlayground;
@@ -0,0 +1,178 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import styled from 'styled-components';
+import {PlusIcon, TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {TertiaryButton} from '../assets/buttons';
+
+import {HelpText, ItemLabel, ItemList, SelectionItem, SelectionItemOption, TextItem} from './item';
+
+export type ServiceRegion = {
+    name: string;
+    apiURL: string;
+    apiKey: string;
+};
+
+export const serviceTypeSupportsRegions = (serviceType: string) => {
+    return serviceType === 'openai' || serviceType === 'openaicompatible' || serviceType === 'azure' || serviceType === 'anthropic';
+};
+
+// invalidServiceRegions mirrors the server validation closely enough to flag mistakes before saving.
+// Regions that fail the server validation disable the bot.
+export const invalidServiceRegions = (serviceType: string, regions: ServiceRegion[] | undefined) => {
+    if (!regions || regions.length === 0) {
+        return false;
+    }
+    if (!serviceTypeSupportsRegions(serviceType)) {
+        return true;
+    }
+
+    const seen = new Set<string>(['default']);
+    for (const region of regions) {
+        if (region.name.trim() === '' || seen.has(region.name)) {
+            return true;
+        }
+        seen.add(region.name);
+
+        if (!(/^https?:\/\/[^/\s]+/).test(region.apiURL.trim())) {
+            return true;
+        }
+    }
+    return false;
+};
+
+type Props = {
+    serviceType: string;
+    regions: ServiceRegion[];
+    routing: string;
+    onChange: (regions: ServiceRegion[], routing: string) => void;
+};
+
+const ServiceRegions = (props: Props) => {
+    const intl = useIntl();
+
+    const updateRegion = (index: number, region: ServiceRegion) => {
+        props.onChange(props.regions.map((r, i) => (i === index ? region : r)), props.routing);
+    };
+
+    const urlPlaceholder = () => {
+        switch (props.serviceType) {
+        case 'openai':
+            return 'https://eu.api.openai.com/v1';
+        case 'azure':
+            return 'https://my-resource-swedencentral.openai.azure.com';
+        default:
+            return 'https://';
+        }
+    };
+
+    return (
+        <>
+            <ItemLabel>
+                <FormattedMessage defaultMessage='Regions'/>
+            </ItemLabel>
+            <div>
+                <RegionsList>
+                    {props.regions.map((region, index) => (
+                        <RegionContainer key={index}>
+                            <ItemList>
+                                <TextItem
+                                    label={intl.formatMessage({defaultMessage: 'Name'})}
+                                    value={region.name}
+                                    placeholder='eu-west'
+                                    onChange={(e) => updateRegion(index, {...region, name: e.target.value.trim()})}
+                                />
+                                <TextItem
+                                    label={intl.formatMessage({defaultMessage: 'API URL'})}
+                                    value={region.apiURL}
+                                    placeholder={urlPlaceholder()}
+                                    onChange={(e) => updateRegion(index, {...region, apiURL: e.target.value.trim()})}
+                                />
+                                <TextItem
+                                    label={intl.formatMessage({defaultMessage: 'API Key'})}
+                                    type='password'
+                                    value={region.apiKey}
+                                    helptext={intl.formatMessage({defaultMessage: 'Leave empty to use the API key of the service.'})}
+                                    onChange={(e) => updateRegion(index, {...region, apiKey: e.target.value})}
+                                />
+                            </ItemList>
+                            <DeleteButton onClick={() => props.onChange(props.regions.filter((_, i) => i !== index), props.routing)}>
+                                <TrashCanOutlineIcon size={16}/>
+                                <FormattedMessage defaultMessage='Delete Region'/>
+                            </DeleteButton>
+                        </RegionContainer>
+                    ))}
+                </RegionsList>
+                <TertiaryButton onClick={() => props.onChange([...props.regions, {name: '', apiURL: '', apiKey: ''}], props.routing)}>
+                    <PlusRegionIcon/>
+                    <FormattedMessage defaultMessage='Add Region'/>
+                </TertiaryButton>
+                <HelpText>
+                    <FormattedMessage defaultMessage='Other endpoints serving the same models, such as Azure deployments in other regions. When an endpoint is down, overloaded or out of quota, requests fail over to the next one. Only list regions your data may be sent to.'/>
+                </HelpText>
+            </div>
+            {props.regions.length > 0 && (
+                <SelectionItem
+                    label={intl.formatMessage({defaultMessage: 'Region routing'})}
+                    value={props.routing || 'priority'}
+                    onChange={(e) => props.onChange(props.regions, e.target.value)}
+                    helptext={intl.formatMessage({defaultMessage: 'Priority sends requests to the API URL of the service and then to the regions in order. Latency sends requests to the region answering fastest.'})}
+                >
+                    <SelectionItemOption value='priority'>{intl.formatMessage({defaultMessage: 'Priority'})}</SelectionItemOption>
+                    <SelectionItemOption value='latency'>{intl.formatMessage({defaultMessage: 'Latency'})}</SelectionItemOption>
+                </SelectionItem>
+            )}
+        </>
+    );
+};
+
+const RegionsList = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    margin-bottom: 16px;
+
+    &:empty {
+        display: none;
+    }
+`;
+
+const RegionContainer = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    border: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    border-radius: 4px;
+    padding: 16px;
+    background-color: var(--center-channel-bg);
+`;
+
+const DeleteButton = styled.button`
+    display: flex;
+    align-self: flex-start;
+    align-items: center;
+    gap: 6px;
+    padding: 8px 12px;
+    background: none;
+    border: none;
+    border-radius: 4px;
+    color: var(--error-text);
+    cursor: pointer;
+    font-size: 12px;
+    font-weight: 600;
+
+    &:hover {
+        background: rgba(var(--error-text-color-rgb), 0.08);
+    }
+`;
+
+const PlusRegionIcon = styled(PlusIcon)`
+    width: 18px;
+    height: 18px;
+    margin-right: 8px;
+`;
+
+export default ServiceRegions;
@@ -0,0 +1,117 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import styled from 'styled-components';
+import {PlusIcon, TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {TertiaryButton} from '../assets/buttons';
+
+import {ItemList, TextItem} from './item';
+
+export type SummaryTemplateConfig = {
+    id: string;
+    name: string;
+    instructions: string;
+};
+
+type Props = {
+    value: SummaryTemplateConfig[];
+    onChange: (value: SummaryTemplateConfig[]) => void;
+};
+
+const SummaryTemplates = (props: Props) => {
+    const intl = useIntl();
+    const templates = props.value || [];
+
+    const updateTemplate = (index: number, template: SummaryTemplateConfig) => {
+        props.onChange(templates.map((t, i) => (i === index ? template : t)));
+    };
+
+    return (
+        <>
+            <TemplatesList>
+                {templates.map((template, index) => (
+                    <TemplateContainer key={index}>
+                        <ItemList>
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'Name'})}
+                                value={template.name}
+                                placeholder={intl.formatMessage({defaultMessage: 'Sprint planning'})}
+                                onChange={(e) => updateTemplate(index, {...template, name: e.target.value})}
+                            />
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'ID'})}
+                                value={template.id}
+                                placeholder='sprint_planning'
+                                helptext={intl.formatMessage({defaultMessage: 'Identifies the template in summaries and API requests. Use the ID of a built-in template, such as "standup", "retro", "incident_review" or "customer_call", to replace it.'})}
+                                onChange={(e) => updateTemplate(index, {...template, id: e.target.value.trim()})}
+                            />
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'Instructions'})}
+                                value={template.instructions}
+                                multiline={true}
+                                placeholder={intl.formatMessage({defaultMessage: 'List the stories committed to, their estimates and the sprint goal.'})}
+                                helptext={intl.formatMessage({defaultMessage: 'How the summary should be written, such as the sections it should have. Templates without instructions are not offered.'})}
+                                onChange={(e) => updateTemplate(index, {...template, instructions: e.target.value})}
+                            />
+                        </ItemList>
+                        <DeleteButton onClick={() => props.onChange(templates.filter((_, i) => i !== index))}>
+                            <TrashCanOutlineIcon size={16}/>
+                            <FormattedMessage defaultMessage='Delete Template'/>
+                        </DeleteButton>
+                    </TemplateContainer>
+                ))}
+            </TemplatesList>
+            <TertiaryButton onClick={() => props.onChange([...templates, {id: '', name: '', instructions: ''}])}>
+                <PlusTemplateIcon/>
+                <FormattedMessage defaultMessage='Add Summary Template'/>
+            </TertiaryButton>
+        </>
+    );
+};
+
+const TemplatesList = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    margin-bottom: 16px;
+`;
+
+const TemplateContainer = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    border: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    border-radius: 4px;
+    padding: 16px;
+    background-color: var(--center-channel-bg);
+`;
+
+const DeleteButton = styled.button`
+    display: flex;
+    align-self: flex-start;
+    align-items: center;
+    gap: 6px;
+    padding: 8px 12px;
+    background: none;
+    border: none;
+    border-radius: 4px;
+    color: var(--error-text);
+    cursor: pointer;
+    font-size: 12px;
+    font-weight: 600;
+
+    &:hover {
+        background: rgba(var(--error-text-color-rgb), 0.08);
+    }
+`;
+
+const PlusTemplateIcon = styled(PlusIcon)`
+    width: 18px;
+    height: 18px;
+    margin-right: 8px;
+`;
+
+export default SummaryTemplates;
@@ -0,0 +1,100 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useEffect, useState} from 'react';
+import styled from 'styled-components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {getThreadCategoryUsage, ThreadCategoryUsage} from '@/client';
+
+import {useThreadCategoryNames} from '../rhs/thread_category_filter';
+
+import {BooleanItem, ItemList, TextItem} from './item';
+
+export type ThreadTaggingConfig = {
+    enabled: boolean;
+    model: string;
+};
+
+type Props = {
+    value: ThreadTaggingConfig;
+    onChange: (value: ThreadTaggingConfig) => void;
+};
+
+const usageDays = 30;
+
+const ThreadTagging = (props: Props) => {
+    const intl = useIntl();
+    const categoryNames = useThreadCategoryNames();
+    const [usage, setUsage] = useState<ThreadCategoryUsage[] | null>(null);
+
+    useEffect(() => {
+        if (!props.value.enabled) {
+            return;
+        }
+        getThreadCategoryUsage(usageDays).then(setUsage).catch(() => setUsage(null));
+    }, [props.value.enabled]);
+
+    return (
+        <>
+            <ItemList>
+                <BooleanItem
+                    label={intl.formatMessage({defaultMessage: 'Enable thread tagging'})}
+                    value={props.value.enabled}
+                    onChange={(enabled) => props.onChange({...props.value, enabled})}
+                    helpText={intl.formatMessage({defaultMessage: 'Classify each new conversation with a bot as coding, HR, support, meeting or other. People can filter their chat history by category.'})}
+                />
+                <TextItem
+                    label={intl.formatMessage({defaultMessage: 'Classification model'})}
+                    value={props.value.model}
+                    placeholder='gpt-4o-mini'
+                    helptext={intl.formatMessage({defaultMessage: 'A cheaper model of the same service as the bot, or one of its model aliases. Leave empty to use the default model of the bot.'})}
+                    onChange={(e) => props.onChange({...props.value, model: e.target.value.trim()})}
+                />
+            </ItemList>
+            {props.value.enabled && usage && (
+                <UsageTable>
+                    <thead>
+                        <tr>
+                            <th><FormattedMessage defaultMessage='Category'/></th>
+                            <th>
+                                <FormattedMessage
+                                    defaultMessage='Threads in the last {days} days'
+                                    values={{days: usageDays}}
+                                />
+                            </th>
+                            <th><FormattedMessage defaultMessage='Replies'/></th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {usage.map((row) => (
+                            <tr key={row.category}>
+                                <td>{categoryNames[row.category] ?? row.category}</td>
+                                <td>{row.threads}</td>
+                                <td>{row.replies}</td>
+                            </tr>
+                        ))}
+                    </tbody>
+                </UsageTable>
+            )}
+        </>
+    );
+};
+
+const UsageTable = styled.table`
+    margin-top: 24px;
+    width: 100%;
+    max-width: 600px;
+
+    th, td {
+        padding: 6px 12px;
+        text-align: left;
+        border-bottom: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    }
+
+    th {
+        font-weight: 600;
+    }
+`;
+
+export default ThreadTagging;
@@ -0,0 +1,142 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import styled from 'styled-components';
+import {PlusIcon, TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {TertiaryButton} from '../assets/buttons';
+import {SelectUser} from '../select';
+
+import {HelpText, ItemLabel, ItemList, SelectionItem, SelectionItemOption, TextItem} from './item';
+
+export type ToolApprovalPolicy = {
+    tools: string[];
+    approvers: 'channel_admins' | 'users';
+    approverUserIDs: string[];
+};
+
+const newPolicy: ToolApprovalPolicy = {
+    tools: [],
+    approvers: 'channel_admins',
+    approverUserIDs: [],
+};
+
+type Props = {
+    value: ToolApprovalPolicy[];
+    onChange: (policies: ToolApprovalPolicy[]) => void;
+};
+
+const ToolApprovals = (props: Props) => {
+    const intl = useIntl();
+    const policies = props.value || [];
+
+    const updatePolicy = (index: number, policy: ToolApprovalPolicy) => {
+        props.onChange(policies.map((p, i) => (i === index ? policy : p)));
+    };
+
+    return (
+        <>
+            <PoliciesList>
+                {policies.map((policy, index) => (
+                    <PolicyContainer key={index}>
+                        <ItemList>
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'Tools'})}
+                                value={policy.tools.join(', ')}
+                                placeholder='create_jira_issue, send_email'
+                                helptext={intl.formatMessage({defaultMessage: 'Comma separated names of the tools that need approval.'})}
+                                onChange={(e) => updatePolicy(index, {
+                                    ...policy,
+                                    tools: e.target.value.split(',').map((tool) => tool.trim()).filter(Boolean),
+                                })}
+                            />
+                            <SelectionItem
+                                label={intl.formatMessage({defaultMessage: 'Approved by'})}
+                                value={policy.approvers}
+                                onChange={(e) => updatePolicy(index, {...policy, approvers: e.target.value as ToolApprovalPolicy['approvers']})}
+                            >
+                                <SelectionItemOption value='channel_admins'>{intl.formatMessage({defaultMessage: 'Channel admins'})}</SelectionItemOption>
+                                <SelectionItemOption value='users'>{intl.formatMessage({defaultMessage: 'Selected users'})}</SelectionItemOption>
+                            </SelectionItem>
+                            <ItemLabel>
+                                {policy.approvers === 'users' ? (
+                                    <FormattedMessage defaultMessage='Approvers'/>
+                                ) : (
+                                    <FormattedMessage defaultMessage='Fallback approvers'/>
+                                )}
+                            </ItemLabel>
+                            <div>
+                                <SelectUser
+                                    userIDs={policy.approverUserIDs}
+                                    teamIDs={[]}
+                                    onChangeIDs={(userIDs) => updatePolicy(index, {...policy, approverUserIDs: userIDs})}
+                                />
+                                <HelpText>
+                                    {policy.approvers === 'users' ? (
+                                        <FormattedMessage defaultMessage='Users who approve calls to these tools. Each approver is asked by direct message.'/>
+                                    ) : (
+                                        <FormattedMessage defaultMessage='Users who approve calls made in channels without admins, such as direct messages. Calls nobody can approve are rejected.'/>
+                                    )}
+                                </HelpText>
+                            </div>
+                        </ItemList>
+                        <DeleteButton onClick={() => props.onChange(policies.filter((_, i) => i !== index))}>
+                            <TrashCanOutlineIcon size={16}/>
+                            <FormattedMessage defaultMessage='Delete Policy'/>
+                        </DeleteButton>
+                    </PolicyContainer>
+                ))}
+            </PoliciesList>
+            <TertiaryButton onClick={() => props.onChange([...policies, {...newPolicy}])}>
+                <PlusPolicyIcon/>
+                <FormattedMessage defaultMessage='Add Approval Policy'/>
+            </TertiaryButton>
+        </>
+    );
+};
+
+const PoliciesList = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    margin-bottom: 16px;
+`;
+
+const PolicyContainer = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    border: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    border-radius: 4px;
+    padding: 16px;
+    background-color: var(--center-channel-bg);
+`;
+
+const DeleteButton = styled.button`
+    display: flex;
+    align-self: flex-start;
+    align-items: center;
+    gap: 6px;
+    padding: 8px 12px;
+    background: none;
+    border: none;
+    border-radius: 4px;
+    color: var(--error-text);
+    cursor: pointer;
+    font-size: 12px;
+    font-weight: 600;
+
+    &:hover {
+        background: rgba(var(--error-text-color-rgb), 0.08);
+    }
+`;
+
+const PlusPolicyIcon = styled(PlusIcon)`
+    width: 18px;
+    height: 18px;
+    margin-right: 8px;
+`;
+
+export default ToolApprovals;
@@ -0,0 +1,124 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import styled from 'styled-components';
+import {PlusIcon, TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {TertiaryButton} from '../assets/buttons';
+import {SelectChannel} from '../select';
+
+import {HelpText, ItemLabel, ItemList, TextItem} from './item';
+
+export type ChannelTranscriptionLanguage = {
+    language: string;
+    channelIDs: string[];
+};
+
+type Props = {
+    language: string;
+    channelLanguages: ChannelTranscriptionLanguage[];
+    onChange: (language: string, channelLanguages: ChannelTranscriptionLanguage[]) => void;
+};
+
+const TranscriptionLanguages = (props: Props) => {
+    const intl = useIntl();
+    const channelLanguages = props.channelLanguages || [];
+
+    const updateChannelLanguage = (index: number, channelLanguage: ChannelTranscriptionLanguage) => {
+        props.onChange(props.language, channelLanguages.map((l, i) => (i === index ? channelLanguage : l)));
+    };
+
+    return (
+        <>
+            <ItemList>
+                <TextItem
+                    label={intl.formatMessage({defaultMessage: 'Default Language'})}
+                    value={props.language}
+                    placeholder='en'
+                    helptext={intl.formatMessage({defaultMessage: 'ISO-639-1 code of the language recordings are transcribed in, such as "en" or "de". Leave empty to detect the language of each recording.'})}
+                    onChange={(e) => props.onChange(e.target.value.trim(), channelLanguages)}
+                />
+            </ItemList>
+            <LanguagesList>
+                {channelLanguages.map((channelLanguage, index) => (
+                    <LanguageContainer key={index}>
+                        <ItemList>
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'Language'})}
+                                value={channelLanguage.language}
+                                placeholder='de'
+                                onChange={(e) => updateChannelLanguage(index, {...channelLanguage, language: e.target.value.trim()})}
+                            />
+                            <ItemLabel>
+                                <FormattedMessage defaultMessage='Channels'/>
+                            </ItemLabel>
+                            <div>
+                                <SelectChannel
+                                    channelIDs={channelLanguage.channelIDs}
+                                    onChangeChannelIDs={(channelIDs: string[]) => updateChannelLanguage(index, {...channelLanguage, channelIDs})}
+                                />
+                                <HelpText>
+                                    <FormattedMessage defaultMessage='Recordings in these channels are transcribed in this language instead of the default.'/>
+                                </HelpText>
+                            </div>
+                        </ItemList>
+                        <DeleteButton onClick={() => props.onChange(props.language, channelLanguages.filter((_, i) => i !== index))}>
+                            <TrashCanOutlineIcon size={16}/>
+                            <FormattedMessage defaultMessage='Delete Channel Language'/>
+                        </DeleteButton>
+                    </LanguageContainer>
+                ))}
+            </LanguagesList>
+            <TertiaryButton onClick={() => props.onChange(props.language, [...channelLanguages, {language: '', channelIDs: []}])}>
+                <PlusLanguageIcon/>
+                <FormattedMessage defaultMessage='Add Channel Language'/>
+            </TertiaryButton>
+        </>
+    );
+};
+
+const LanguagesList = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    margin: 16px 0;
+`;
+
+const LanguageContainer = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    border: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    border-radius: 4px;
+    padding: 16px;
+    background-color: var(--center-channel-bg);
+`;
+
+const DeleteButton = styled.button`
+    display: flex;
+    align-self: flex-start;
+    align-items: center;
+    gap: 6px;
+    padding: 8px 12px;
+    background: none;
+    border: none;
+    border-radius: 4px;
+    color: var(--error-text);
+    cursor: pointer;
+    font-size: 12px;
+    font-weight: 600;
+
+    &:hover {
+        background: rgba(var(--error-text-color-rgb), 0.08);
+    }
+`;
+
+const PlusLanguageIcon = styled(PlusIcon)`
+    width: 18px;
+    height: 18px;
+    margin-right: 8px;
+`;
+
+export default TranscriptionLanguages;
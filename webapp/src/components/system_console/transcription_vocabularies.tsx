@@ -0,0 +1,129 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React from 'react';
+import styled from 'styled-components';
+import {PlusIcon, TrashCanOutlineIcon} from '@mattermost/compass-icons/components';
+import {FormattedMessage, useIntl} from 'react-intl';
+
+import {TertiaryButton} from '../assets/buttons';
+import {SelectChannel, SelectUser} from '../select';
+
+import {HelpText, ItemLabel, ItemList, TextItem} from './item';
+
+export type TranscriptionVocabulary = {
+    terms: string[];
+    channelIDs: string[];
+    teamIDs: string[];
+};
+
+type Props = {
+    vocabularies: TranscriptionVocabulary[];
+    onChange: (vocabularies: TranscriptionVocabulary[]) => void;
+};
+
+// TranscriptionVocabularies edits the terms recordings are transcribed with, one per line, for every
+// recording or for the recordings of some channels and teams.
+const TranscriptionVocabularies = (props: Props) => {
+    const intl = useIntl();
+    const vocabularies = props.vocabularies || [];
+
+    const updateVocabulary = (index: number, vocabulary: TranscriptionVocabulary) => {
+        props.onChange(vocabularies.map((v, i) => (i === index ? vocabulary : v)));
+    };
+
+    return (
+        <>
+            <VocabulariesList>
+                {vocabularies.map((vocabulary, index) => (
+                    <VocabularyContainer key={index}>
+                        <ItemList>
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'Terms'})}
+                                value={vocabulary.terms.join('\n')}
+                                multiline={true}
+                                placeholder={'Mattermost\nMMCTL\nPlaybooks'}
+                                helptext={intl.formatMessage({defaultMessage: 'Product names, acronyms and other terms said in meetings, one per line, so transcripts spell them right. Transcription backends may only use the first few dozen terms.'})}
+                                onChange={(e) => updateVocabulary(index, {...vocabulary, terms: e.target.value.split('\n')})}
+                            />
+                            <ItemLabel>
+                                <FormattedMessage defaultMessage='Channels'/>
+                            </ItemLabel>
+                            <div>
+                                <SelectChannel
+                                    channelIDs={vocabulary.channelIDs}
+                                    onChangeChannelIDs={(channelIDs: string[]) => updateVocabulary(index, {...vocabulary, channelIDs})}
+                                />
+                            </div>
+                            <ItemLabel>
+                                <FormattedMessage defaultMessage='Teams'/>
+                            </ItemLabel>
+                            <div>
+                                <SelectUser
+                                    userIDs={[]}
+                                    teamIDs={vocabulary.teamIDs}
+                                    onChangeIDs={(_, teamIDs: string[]) => updateVocabulary(index, {...vocabulary, teamIDs})}
+                                />
+                                <HelpText>
+                                    <FormattedMessage defaultMessage='Recordings in these channels and teams are transcribed with these terms. Leave both empty to use the terms for every recording.'/>
+                                </HelpText>
+                            </div>
+                        </ItemList>
+                        <DeleteButton onClick={() => props.onChange(vocabularies.filter((_, i) => i !== index))}>
+                            <TrashCanOutlineIcon size={16}/>
+                            <FormattedMessage defaultMessage='Delete Vocabulary'/>
+                        </DeleteButton>
+                    </VocabularyContainer>
+                ))}
+            </VocabulariesList>
+            <TertiaryButton onClick={() => props.onChange([...vocabularies, {terms: [], channelIDs: [], teamIDs: []}])}>
+                <PlusVocabularyIcon/>
+                <FormattedMessage defaultMessage='Add Vocabulary'/>
+            </TertiaryButton>
+        </>
+    );
+};
+
+const VocabulariesList = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    margin: 16px 0;
+`;
+
+const VocabularyContainer = styled.div`
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+    border: 1px solid rgba(var(--center-channel-color-rgb), 0.08);
+    border-radius: 4px;
+    padding: 16px;
+    background-color: var(--center-channel-bg);
+`;
+
+const DeleteButton = styled.button`
+    display: flex;
+    align-self: flex-start;
+    align-items: center;
+    gap: 6px;
+    padding: 8px 12px;
+    background: none;
+    border: none;
+    border-radius: 4px;
+    color: var(--error-text);
+    cursor: pointer;
+    font-size: 12px;
+    font-weight: 600;
+
+    &:hover {
+        background: rgba(var(--error-text-color-rgb), 0.08);
+    }
+`;
+
+const PlusVocabularyIcon = styled(PlusIcon)`
+    width: 18px;
+    height: 18px;
+    margin-right: 8px;
+`;
+
+export default TranscriptionVocabularies;
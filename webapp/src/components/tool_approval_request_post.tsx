@@ -0,0 +1,73 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useCallback, useEffect, useState} from 'react';
+import {FormattedMessage} from 'react-intl';
+import styled from 'styled-components';
+
+import {doToolApproval, getToolApproval, ToolApprovalRequest} from '@/client';
+
+import PostText from './post_text';
+import ToolApprovalSet from './tool_approval_set';
+
+const Unavailable = styled.div`
+    margin-top: 8px;
+    font-size: 12px;
+    color: rgba(var(--center-channel-color-rgb), 0.64);
+`;
+
+interface Props {
+    post: any;
+}
+
+// ToolApprovalRequestPost lets a delegated approver decide on tool calls made in another conversation
+export const ToolApprovalRequestPost = (props: Props) => {
+    const toolCallPostID = props.post.props?.tool_call_post_id;
+    const [request, setRequest] = useState<ToolApprovalRequest | null>(null);
+    const [unavailable, setUnavailable] = useState(false);
+
+    const load = useCallback(async () => {
+        try {
+            setRequest(await getToolApproval(toolCallPostID));
+            setUnavailable(false);
+        } catch (err) {
+            setUnavailable(true);
+        }
+    }, [toolCallPostID]);
+
+    useEffect(() => {
+        if (toolCallPostID) {
+            load();
+        }
+    }, [toolCallPostID, load]);
+
+    const submit = async (approvedToolIDs: string[]) => {
+        await doToolApproval(toolCallPostID, approvedToolIDs);
+        await load();
+    };
+
+    return (
+        <>
+            <PostText
+                message={props.post.message}
+                channelID={props.post.channel_id}
+                postID={props.post.id}
+            />
+            {/* Keyed on the statuses so decided tool calls reset the submitting state */}
+            {request && (
+                <ToolApprovalSet
+                    key={JSON.stringify(request.tool_calls.map((tool) => tool.status))}
+                    postID={request.post_id}
+                    requesterID={request.requester_user_id}
+                    toolCalls={request.tool_calls}
+                    onSubmit={submit}
+                />
+            )}
+            {unavailable && (
+                <Unavailable>
+                    <FormattedMessage defaultMessage='These tool calls are no longer available.'/>
+                </Unavailable>
+            )}
+        </>
+    );
+};
@@ -2,10 +2,13 @@
 // See LICENSE.txt for license information.
 
 import React, {useState} from 'react';
+import {useSelector} from 'react-redux';
 import styled from 'styled-components';
 import {FormattedMessage} from 'react-intl';
 
-import {doToolCall} from '@/client';
+import {GlobalState} from '@mattermost/types/store';
+
+import {doStopGenerating, doToolCall} from '@/client';
 
 import {ToolCall, ToolCallStatus} from './llmbot_post';
 import ToolCard from './tool_card';
@@ -29,24 +32,52 @@ const StatusBar = styled.div`
     font-size: 12px;
 `;
 
+const StopButton = styled.button`
+    background: none;
+    border: none;
+    padding: 0;
+    font-size: 12px;
+    font-weight: 600;
+    color: var(--button-bg);
+    cursor: pointer;
+`;
+
 // Tool call interfaces
 interface ToolApprovalSetProps {
     postID: string;
+    requesterID: string;
     toolCalls: ToolCall[];
+
+    // Submits the decisions, defaults to deciding from the conversation
+    onSubmit?: (approvedToolIDs: string[]) => Promise<void>;
 }
 
+// Tool calls with delegated approvers are decided by them, the others by the requester
+export const canDecideToolCall = (tool: ToolCall, userID: string, requesterID: string) => {
+    if (tool.approver_ids && tool.approver_ids.length > 0) {
+        return tool.approver_ids.includes(userID);
+    }
+    return userID === requesterID;
+};
+
 // Define a type for tool decisions
 type ToolDecision = {
     [toolId: string]: boolean | null; // true = approved, false = rejected, null = undecided
 };
 
 const ToolApprovalSet: React.FC<ToolApprovalSetProps> = (props) => {
+    const currentUserId = useSelector<GlobalState, string>((state) => state.entities.users.currentUserId);
+
     // Track which tools are currently being processed
     const [isSubmitting, setIsSubmitting] = useState(false);
     const [error, setError] = useState('');
     const [collapsedTools, setCollapsedTools] = useState<string[]>([]);
     const [toolDecisions, setToolDecisions] = useState<ToolDecision>({});
 
+    const decidableToolCalls = props.toolCalls.filter((call) => {
+        return call.status === ToolCallStatus.Pending && canDecideToolCall(call, currentUserId, props.requesterID);
+    });
+
     const handleToolDecision = async (toolID: string, approved: boolean) => {
         if (isSubmitting) {
             return;
@@ -58,7 +89,7 @@ const ToolApprovalSet: React.FC<ToolApprovalSetProps> = (props) => {
         };
         setToolDecisions(updatedDecisions);
 
-        const hasUndecided = props.toolCalls.some((tool) => {
+        const hasUndecided = decidableToolCalls.some((tool) => {
             return !Object.hasOwn(updatedDecisions, tool.id) || updatedDecisions[tool.id] === null;
         });
 
@@ -77,13 +108,22 @@ const ToolApprovalSet: React.FC<ToolApprovalSetProps> = (props) => {
 
         setIsSubmitting(true);
         try {
-            await doToolCall(props.postID, approvedToolIDs);
+            if (props.onSubmit) {
+                await props.onSubmit(approvedToolIDs);
+            } else {
+                await doToolCall(props.postID, approvedToolIDs);
+            }
         } catch (err) {
             setError('Failed to submit tool decisions');
             setIsSubmitting(false);
         }
     };
 
+    // Cancels the tools still running, only the requester can stop them
+    const stopTools = () => {
+        doStopGenerating(props.postID);
+    };
+
     const toggleCollapse = (toolID: string) => {
         setCollapsedTools((prev) =>
             (prev.includes(toolID) ? prev.filter((id) => id !== toolID) : [...prev, toolID]),
@@ -112,9 +152,11 @@ const ToolApprovalSet: React.FC<ToolApprovalSetProps> = (props) => {
             {pendingToolCalls.map((tool) => (
                 <ToolCard
                     key={tool.id}
+                    postID={props.postID}
                     tool={tool}
                     isCollapsed={collapsedTools.includes(tool.id)}
                     isProcessing={isSubmitting}
+                    canDecide={decidableToolCalls.includes(tool)}
                     onToggleCollapse={() => toggleCollapse(tool.id)}
                     onApprove={() => handleToolDecision(tool.id, true)}
                     onReject={() => handleToolDecision(tool.id, false)}
@@ -125,6 +167,7 @@ const ToolApprovalSet: React.FC<ToolApprovalSetProps> = (props) => {
             {processedToolCalls.map((tool) => (
                 <ToolCard
                     key={tool.id}
+                    postID={props.postID}
                     tool={tool}
                     isCollapsed={collapsedTools.includes(tool.id)}
                     isProcessing={false}
@@ -132,8 +175,8 @@ const ToolApprovalSet: React.FC<ToolApprovalSetProps> = (props) => {
                 />
             ))}
 
-            {/* Only show status bar for multiple pending tools */}
-            {pendingToolCalls.length > 1 && isSubmitting && (
+            {/* Only show status bar for multiple pending tools, the requester can stop them instead */}
+            {decidableToolCalls.length > 1 && isSubmitting && currentUserId !== props.requesterID && (
                 <StatusBar>
                     <div>
                         <FormattedMessage
@@ -144,8 +187,23 @@ const ToolApprovalSet: React.FC<ToolApprovalSetProps> = (props) => {
                 </StatusBar>
             )}
 
+            {isSubmitting && currentUserId === props.requesterID && (
+                <StatusBar>
+                    <FormattedMessage
+                        id='ai.tool_call.running'
+                        defaultMessage='Running tools...'
+                    />
+                    <StopButton onClick={stopTools}>
+                        <FormattedMessage
+                            id='ai.tool_call.stop'
+                            defaultMessage='Stop'
+                        />
+                    </StopButton>
+                </StatusBar>
+            )}
+
             {/* Only show status counter for multiple pending tools that haven't been submitted yet */}
-            {pendingToolCalls.length > 1 && undecidedCount > 0 && !isSubmitting && (
+            {decidableToolCalls.length > 1 && undecidedCount > 0 && !isSubmitting && (
                 <StatusBar>
                     <div>
                         <FormattedMessage
@@ -1,11 +1,13 @@
 // Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
 // See LICENSE.txt for license information.
 
-import React from 'react';
+import React, {useState} from 'react';
 import styled from 'styled-components';
 import {FormattedMessage} from 'react-intl';
 import {ChevronDownIcon, ChevronRightIcon} from '@mattermost/compass-icons/components';
 
+import {explainToolCall} from '@/client';
+
 import {ToolCall, ToolCallStatus} from './llmbot_post';
 
 import LoadingSpinner from './assets/loading_spinner';
@@ -58,6 +60,25 @@ const ToolName = styled.span`
     flex-grow: 1;
 `;
 
+const ToolServer = styled.span`
+    margin-left: 4px;
+    color: rgba(var(--center-channel-color-rgb), 0.56);
+`;
+
+// MCP tool names are prefixed with the key of the server providing the tool
+const MCP_TOOL_NAME_SEPARATOR = '__';
+
+const splitToolName = (name: string): {server?: string, toolName: string} => {
+    const index = name.indexOf(MCP_TOOL_NAME_SEPARATOR);
+    if (index <= 0 || index + MCP_TOOL_NAME_SEPARATOR.length >= name.length) {
+        return {toolName: name};
+    }
+    return {
+        server: name.slice(0, index),
+        toolName: name.slice(index + MCP_TOOL_NAME_SEPARATOR.length),
+    };
+};
+
 const ToolCallDescription = styled.div`
     margin: 4px 0;
     font-size: 14px;
@@ -74,6 +95,44 @@ const ToolCallArguments = styled.pre`
     line-height: 1.4;
 `;
 
+const ArgumentSummary = styled.dl`
+    display: grid;
+    grid-template-columns: max-content 1fr;
+    gap: 4px 12px;
+    margin: 8px 0 12px;
+    padding: 12px;
+    border-radius: 4px;
+    background: rgba(var(--center-channel-color-rgb), 0.04);
+    font-size: 12px;
+    line-height: 1.4;
+`;
+
+const ArgumentName = styled.dt`
+    font-weight: 600;
+    color: rgba(var(--center-channel-color-rgb), 0.72);
+`;
+
+const ArgumentValue = styled.dd`
+    margin: 0;
+    white-space: pre-wrap;
+    word-break: break-word;
+`;
+
+const ArgumentErrors = styled.div`
+    margin: 0 0 12px;
+    padding: 8px 12px;
+    border-left: 3px solid var(--error-text);
+    background: rgba(var(--error-text-color-rgb), 0.08);
+    font-size: 12px;
+    line-height: 16px;
+    color: var(--error-text);
+
+    ul {
+        margin: 4px 0 0;
+        padding-left: 16px;
+    }
+`;
+
 const StatusContainer = styled.div`
     display: flex;
     align-items: center;
@@ -210,10 +269,47 @@ const ResultContainer = styled.pre`
     line-height: 1.4;
 `;
 
+const ExplainButton = styled.button`
+    align-self: flex-start;
+    background: none;
+    border: none;
+    padding: 0;
+    margin-top: 12px;
+    font-size: 12px;
+    font-weight: 600;
+    line-height: 16px;
+    color: var(--button-bg);
+    cursor: pointer;
+
+    &:disabled {
+        color: rgba(var(--center-channel-color-rgb), 0.56);
+        cursor: default;
+    }
+`;
+
+const Explanation = styled.div`
+    margin-top: 8px;
+    padding: 12px;
+    border-left: 3px solid rgba(var(--button-bg-rgb), 0.48);
+    background: rgba(var(--button-bg-rgb), 0.04);
+    font-size: 14px;
+    line-height: 20px;
+    white-space: pre-wrap;
+    color: rgba(var(--center-channel-color-rgb), 0.88);
+`;
+
+const ExplanationError = styled.div`
+    margin-top: 8px;
+    font-size: 12px;
+    color: var(--error-text);
+`;
+
 interface ToolCardProps {
+    postID: string;
     tool: ToolCall;
     isCollapsed: boolean;
     isProcessing: boolean;
+    canDecide?: boolean;
     onToggleCollapse: () => void;
     onApprove?: () => void;
     onReject?: () => void;
@@ -221,9 +317,11 @@ interface ToolCardProps {
 }
 
 const ToolCard: React.FC<ToolCardProps> = ({
+    postID,
     tool,
     isCollapsed,
     isProcessing,
+    canDecide = true,
     onToggleCollapse,
     onApprove,
     onReject,
@@ -234,6 +332,25 @@ const ToolCard: React.FC<ToolCardProps> = ({
     const isSuccess = tool.status === ToolCallStatus.Success;
     const isError = tool.status === ToolCallStatus.Error;
     const isRejected = tool.status === ToolCallStatus.Rejected;
+    const isTimedOut = tool.status === ToolCallStatus.TimedOut;
+
+    const [explanation, setExplanation] = useState('');
+    const [isExplaining, setIsExplaining] = useState(false);
+    const [explanationFailed, setExplanationFailed] = useState(false);
+
+    const {server, toolName} = splitToolName(tool.name);
+
+    const explain = async () => {
+        setIsExplaining(true);
+        setExplanationFailed(false);
+        try {
+            const result = await explainToolCall(postID, tool.id);
+            setExplanation(result.explanation);
+        } catch (err) {
+            setExplanationFailed(true);
+        }
+        setIsExplaining(false);
+    };
 
     return (
         <ToolCallCard>
@@ -242,9 +359,20 @@ const ToolCard: React.FC<ToolCardProps> = ({
                     {isCollapsed ? <ChevronRightIcon size={16}/> : <ChevronDownIcon size={16}/>}
                 </StyledChevronIcon>
                 <ToolIcon/>
-                <ToolName>{tool.name}</ToolName>
+                <ToolName>
+                    {toolName}
+                    {server && (
+                        <ToolServer>
+                            <FormattedMessage
+                                id='ai.tool_call.server'
+                                defaultMessage='from {server}'
+                                values={{server}}
+                            />
+                        </ToolServer>
+                    )}
+                </ToolName>
 
-                {isPending && decision !== null && !isProcessing && (
+                {isPending && canDecide && decision !== null && !isProcessing && (
                     <DecisionTag approved={decision}>
                         {decision ? (
                             <FormattedMessage
@@ -264,9 +392,43 @@ const ToolCard: React.FC<ToolCardProps> = ({
             {!isCollapsed && (
                 <>
                     <ToolCallDescription>{tool.description}</ToolCallDescription>
-                    <ToolCallArguments>{JSON.stringify(tool.arguments, null, 2)}</ToolCallArguments>
+                    {tool.argument_summary && tool.argument_summary.length > 0 ? (
+                        <ArgumentSummary>
+                            {tool.argument_summary.map((argument) => (
+                                <React.Fragment key={argument.name}>
+                                    <ArgumentName>{argument.name}</ArgumentName>
+                                    <ArgumentValue>{argument.value}</ArgumentValue>
+                                </React.Fragment>
+                            ))}
+                        </ArgumentSummary>
+                    ) : (
+                        <ToolCallArguments>{JSON.stringify(tool.arguments, null, 2)}</ToolCallArguments>
+                    )}
+                    {isPending && tool.argument_errors && tool.argument_errors.length > 0 && (
+                        <ArgumentErrors>
+                            <FormattedMessage
+                                id='ai.tool_call.argument_errors'
+                                defaultMessage='The arguments don’t match what the tool expects:'
+                            />
+                            <ul>
+                                {tool.argument_errors.map((problem) => (
+                                    <li key={problem}>{problem}</li>
+                                ))}
+                            </ul>
+                        </ArgumentErrors>
+                    )}
 
-                    {isPending && (
+                    {isPending && !canDecide && (
+                        <StatusContainer>
+                            <FormattedMessage
+                                id='ai.tool_call.status.awaiting_approval'
+                                defaultMessage='Waiting for approval from {count, plural, one {# approver} other {# approvers}}'
+                                values={{count: tool.approver_ids?.length ?? 0}}
+                            />
+                        </StatusContainer>
+                    )}
+
+                    {isPending && canDecide && (
                         isProcessing ? (
                             <StatusContainer>
                                 <ProcessingSpinnerContainer>
@@ -339,7 +501,20 @@ const ToolCard: React.FC<ToolCardProps> = ({
                                     defaultMessage='Error'
                                 />
                             </StatusContainer>
-                            {tool.result && <ResultContainer>{tool.result}</ResultContainer>}
+                            {tool.result && <ResultContainer>{tool.error || tool.result}</ResultContainer>}
+                        </>
+                    )}
+
+                    {isTimedOut && (
+                        <>
+                            <StatusContainer>
+                                <span>{'⏱️'}</span>
+                                <FormattedMessage
+                                    id='ai.tool_call.status.timed_out'
+                                    defaultMessage='Timed out'
+                                />
+                            </StatusContainer>
+                            {tool.error && <ResultContainer>{tool.error}</ResultContainer>}
                         </>
                     )}
 
@@ -352,6 +527,34 @@ const ToolCard: React.FC<ToolCardProps> = ({
                             />
                         </StatusContainer>
                     )}
+
+                    {!explanation && (
+                        <ExplainButton
+                            onClick={explain}
+                            disabled={isExplaining}
+                        >
+                            {isExplaining ? (
+                                <FormattedMessage
+                                    id='ai.tool_call.explaining'
+                                    defaultMessage='Explaining...'
+                                />
+                            ) : (
+                                <FormattedMessage
+                                    id='ai.tool_call.explain'
+                                    defaultMessage='Explain this tool call'
+                                />
+                            )}
+                        </ExplainButton>
+                    )}
+                    {explanation && <Explanation>{explanation}</Explanation>}
+                    {explanationFailed && (
+                        <ExplanationError>
+                            <FormattedMessage
+                                id='ai.tool_call.explain_failed'
+                                defaultMessage='Unable to explain this tool call.'
+                            />
+                        </ExplanationError>
+                    )}
                 </>
             )}
         </ToolCallCard>
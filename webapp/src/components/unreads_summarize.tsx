@@ -1,13 +1,13 @@
 // Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
 // See LICENSE.txt for license information.
 
-import React from 'react';
+import React, {useState} from 'react';
 import styled from 'styled-components';
 import {FormattedMessage} from 'react-intl';
 
 import {useSelectPost} from '@/hooks';
 
-import {getChannelInterval} from '@/client';
+import {CostEstimate as CostEstimateData, getChannelInterval, getChannelIntervalEstimate} from '@/client';
 import {useIsBasicsLicensed} from '@/license';
 
 import {useBotlistForChannel} from '@/bots';
@@ -20,6 +20,8 @@ import IconThreadSummarization from './assets/icon_thread_summarization';
 import DotMenu, {DropdownMenu, DropdownMenuItem} from './dot_menu';
 import {Divider, DropdownChannelBlocked, DropdownInfoOnlyVisibleToYou} from './dropdown_info';
 import {DropdownBotSelector} from './bot_selector';
+import ConfirmationDialog from './confirmation_dialog';
+import CostEstimate, {LargeOperationTokens} from './cost_estimate';
 
 const AskAIButton = styled(DotMenu)`
 	display: flex;
@@ -86,21 +88,48 @@ const UnreadsSumarize = (props: Props) => {
     const isBasicsLicensed = useIsBasicsLicensed();
     const {bots, activeBot, setActiveBot, wasFiltered} = useBotlistForChannel(props.channelId);
 
-    const summarizeNew = async () => {
-        const result = await getChannelInterval(props.channelId, props.lastViewedAt, 0, 'summarize_unreads', '', activeBot?.username || '');
-        selectPost(result.postid, result.channelid);
-    };
+    const [pending, setPending] = useState<{promptName: string, estimate: CostEstimateData} | null>(null);
 
-    const actionItems = async () => {
-        const result = await getChannelInterval(props.channelId, props.lastViewedAt, 0, 'action_items', '', activeBot?.username || '');
+    const runInterval = async (promptName: string) => {
+        const result = await getChannelInterval(props.channelId, props.lastViewedAt, 0, promptName, '', activeBot?.username || '');
         selectPost(result.postid, result.channelid);
     };
 
-    const openQuestions = async () => {
-        const result = await getChannelInterval(props.channelId, props.lastViewedAt, 0, 'open_questions', '', activeBot?.username || '');
-        selectPost(result.postid, result.channelid);
+    // Large summaries are confirmed with an estimate of their cost first
+    const runIntervalWithEstimate = async (promptName: string) => {
+        let estimate: CostEstimateData | null = null;
+        try {
+            estimate = await getChannelIntervalEstimate(props.channelId, props.lastViewedAt, 0, promptName, activeBot?.username || '');
+        } catch (e) {
+            // The estimate is informational, run without it
+        }
+
+        if (estimate && estimate.input_tokens >= LargeOperationTokens) {
+            setPending({promptName, estimate});
+            return;
+        }
+
+        await runInterval(promptName);
     };
 
+    const summarizeNew = () => runIntervalWithEstimate('summarize_unreads');
+    const actionItems = () => runIntervalWithEstimate('action_items');
+    const openQuestions = () => runIntervalWithEstimate('open_questions');
+
+    const confirmation = pending && (
+        <ConfirmationDialog
+            title={<FormattedMessage defaultMessage='Summarize a large number of messages?'/>}
+            message={<CostEstimate estimate={pending.estimate}/>}
+            confirmButtonText={<FormattedMessage defaultMessage='Continue'/>}
+            onConfirm={() => {
+                const promptName = pending.promptName;
+                setPending(null);
+                runInterval(promptName);
+            }}
+            onCancel={() => setPending(null)}
+        />
+    );
+
     if (!isBasicsLicensed) {
         return null;
     }
@@ -125,39 +154,42 @@ const UnreadsSumarize = (props: Props) => {
     }
 
     return (
-        <AskAIButton
-            icon={<><SmallerIconAI/>
-                <FormattedMessage defaultMessage=' Ask AI'/>
-            </>}
-            dropdownMenu={StyledDropdownMenu}
-        >
-            <DropdownBotSelector
-                bots={bots ?? []}
-                activeBot={activeBot}
-                setActiveBot={setActiveBot}
-            />
-            <Divider/>
-            <DropdownMenuItemStyled
-                onClick={summarizeNew}
-            >
-                <IconThreadSummarization/>
-                <FormattedMessage defaultMessage='Summarize new messages'/>
-            </DropdownMenuItemStyled>
-            <DropdownMenuItemStyled
-                onClick={actionItems}
-            >
-                <IconSparkleCheckmarkStyled/>
-                <FormattedMessage defaultMessage='Find action items'/>
-            </DropdownMenuItemStyled>
-            <DropdownMenuItemStyled
-                onClick={openQuestions}
+        <>
+            {confirmation}
+            <AskAIButton
+                icon={<><SmallerIconAI/>
+                    <FormattedMessage defaultMessage=' Ask AI'/>
+                </>}
+                dropdownMenu={StyledDropdownMenu}
             >
-                <IconSparkleQuestionStyled/>
-                <FormattedMessage defaultMessage='Find open questions'/>
-            </DropdownMenuItemStyled>
-            <Divider/>
-            <DropdownInfoOnlyVisibleToYou/>
-        </AskAIButton>
+                <DropdownBotSelector
+                    bots={bots ?? []}
+                    activeBot={activeBot}
+                    setActiveBot={setActiveBot}
+                />
+                <Divider/>
+                <DropdownMenuItemStyled
+                    onClick={summarizeNew}
+                >
+                    <IconThreadSummarization/>
+                    <FormattedMessage defaultMessage='Summarize new messages'/>
+                </DropdownMenuItemStyled>
+                <DropdownMenuItemStyled
+                    onClick={actionItems}
+                >
+                    <IconSparkleCheckmarkStyled/>
+                    <FormattedMessage defaultMessage='Find action items'/>
+                </DropdownMenuItemStyled>
+                <DropdownMenuItemStyled
+                    onClick={openQuestions}
+                >
+                    <IconSparkleQuestionStyled/>
+                    <FormattedMessage defaultMessage='Find open questions'/>
+                </DropdownMenuItemStyled>
+                <Divider/>
+                <DropdownInfoOnlyVisibleToYou/>
+            </AskAIButton>
+        </>
     );
 };
 
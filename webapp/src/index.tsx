@@ -21,14 +21,16 @@ import RHS from './components/rhs/rhs';
 import Config from './components/system_console/config';
 import {doReaction, doRunSearch, doThreadAnalysis, getAIDirectChannel} from './client';
 import {setOpenRHSAction} from './redux_actions';
-import PostEventListener from './websocket';
+import PostEventListener, {handleReindexProgressWebsockets} from './websocket';
 import {BotsHandler, setupRedux} from './redux';
 import UnreadsSummarize from './components/unreads_summarize';
 import {PostbackPost} from './components/postback_post';
+import {ToolApprovalRequestPost} from './components/tool_approval_request_post';
+import {ActionItemsPost} from './components/action_items_post';
 import {isRHSCompatable} from './mm_webapp';
 import SearchButton from './components/search_button';
 import {doSelectPost} from './hooks';
-import {handleAskChannelCommand, handleSummarizeChannelCommand} from './commands';
+import {handleAskChannelCommand, handleMCPPromptCommand, handleSnippetCommand, handleSummarizeChannelCommand} from './commands';
 import SearchHints from './components/search_hints';
 
 type WebappStore = Store<GlobalState, Action<Record<string, unknown>>>
@@ -101,6 +103,8 @@ export default class Plugin {
         // Handle all post-related websocket events with one handler
         registry.registerWebSocketEventHandler('custom_mattermost-ai_postupdate', this.postEventListener.handlePostUpdateWebsockets);
         registry.registerWebSocketEventHandler('custom_mattermost-ai_tool_call_status_updated', this.postEventListener.handlePostUpdateWebsockets);
+        registry.registerWebSocketEventHandler('custom_mattermost-ai_transcription_progress', this.postEventListener.handlePostUpdateWebsockets);
+        registry.registerWebSocketEventHandler('custom_mattermost-ai_reindex_progress', handleReindexProgressWebsockets);
 
         const LLMBotPostWithWebsockets = (props: any) => {
             return (
@@ -121,6 +125,8 @@ export default class Plugin {
 
         registry.registerPostTypeComponent('custom_llmbot', LLMBotPostWithWebsockets);
         registry.registerPostTypeComponent('custom_llm_postback', PostbackPost);
+        registry.registerPostTypeComponent('custom_llm_tool_approval', ToolApprovalRequestPost);
+        registry.registerPostTypeComponent('custom_llm_action_items', ActionItemsPost);
         if (registry.registerPostActionComponent) {
             registry.registerPostActionComponent(PostMenu);
         } else {
@@ -159,11 +165,30 @@ export default class Plugin {
                 } else if (message.startsWith('/summarize-channel')) {
                     const commandParams = message.replace('/summarize-channel', '').trim();
                     return handleSummarizeChannelCommand(commandParams, args, store, rhs);
+                } else if (message === '/snippet' || message.startsWith('/snippet ')) {
+                    const name = message.replace('/snippet', '').trim();
+                    return handleSnippetCommand(name, args, store);
+                } else if (message === '/mcp-prompt' || message.startsWith('/mcp-prompt ')) {
+                    const name = message.replace('/mcp-prompt', '').trim();
+                    return handleMCPPromptCommand(name, args, store);
                 }
                 return {message, args};
             });
         }
 
+        // New conversations with the bots are started in the persona picked in the RHS
+        if (registry.registerMessageWillBePostedHook) {
+            registry.registerMessageWillBePostedHook((post: any) => {
+                const pluginState = (store.getState() as any)['plugins-' + manifest.id];
+                const personaId = pluginState?.selectedPersonaId;
+                const isBotDM = (pluginState?.bots || []).some((bot: any) => bot.dmChannelID === post.channel_id);
+                if (!personaId || post.root_id || !isBotDM) {
+                    return {post};
+                }
+                return {post: {...post, props: {...post.props, ai_persona: personaId}}};
+            });
+        }
+
         if (registry.registerSearchComponents) {
             // The SearchButton and SearchHints components will check if search is enabled
             registry.registerSearchComponents({
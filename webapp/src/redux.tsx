@@ -19,6 +19,8 @@ export async function setupRedux(registry: any, store: WebappStore) {
         botChannelId,
         selectedPostId,
         searchEnabled,
+        memoryEnabled,
+        selectedPersonaId,
     });
     registry.registerReducer(reducer);
 
@@ -74,6 +76,25 @@ function searchEnabled(state = false, action: any) {
     }
 }
 
+function memoryEnabled(state = false, action: any) {
+    switch (action.type) {
+    case 'SET_MEMORY_ENABLED':
+        return action.memoryEnabled;
+    default:
+        return state;
+    }
+}
+
+// selectedPersonaId is the persona new conversations with the bots are started in
+function selectedPersonaId(state = '', action: any) {
+    switch (action.type) {
+    case 'SELECT_AI_PERSONA':
+        return action.personaId;
+    default:
+        return state;
+    }
+}
+
 function botChannelId(state = '', action: any) {
     switch (action.type) {
     case 'SET_AI_BOT_CHANNEL':
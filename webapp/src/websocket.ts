@@ -4,6 +4,7 @@
 import {WebSocketMessage} from '@mattermost/client';
 
 import {PostUpdateWebsocketMessage} from './components/llmbot_post';
+import {JobStatusType} from './components/system_console/embedding_search/types';
 
 type WebsocketListener = (msg: WebSocketMessage<PostUpdateWebsocketMessage>) => void
 type WebsocketListenerObject = {
@@ -37,3 +38,19 @@ export default class PostEventListener {
         });
     };
 }
+
+type ReindexProgressListener = (status: JobStatusType) => void;
+
+const reindexProgressListeners = new Set<ReindexProgressListener>();
+
+// Listens to the progress of the reindex job sent to system admins, returns a function to stop listening
+export const listenToReindexProgress = (listener: ReindexProgressListener) => {
+    reindexProgressListeners.add(listener);
+    return () => {
+        reindexProgressListeners.delete(listener);
+    };
+};
+
+export const handleReindexProgressWebsockets = (msg: WebSocketMessage<JobStatusType>) => {
+    reindexProgressListeners.forEach((listener) => listener(msg.data));
+};